@@ -0,0 +1,133 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+
+package kubewatchv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion7
+
+// EventServiceClient is the client API for EventService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type EventServiceClient interface {
+	// Publish streams the events, in the order they are handled. The server
+	// acknowledges each event once it is processed, with a PublishResponse.
+	Publish(ctx context.Context, opts ...grpc.CallOption) (EventService_PublishClient, error)
+}
+
+type eventServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewEventServiceClient(cc grpc.ClientConnInterface) EventServiceClient {
+	return &eventServiceClient{cc}
+}
+
+func (c *eventServiceClient) Publish(ctx context.Context, opts ...grpc.CallOption) (EventService_PublishClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_EventService_serviceDesc.Streams[0], "/kubewatch.v1.EventService/Publish", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &eventServicePublishClient{stream}
+	return x, nil
+}
+
+type EventService_PublishClient interface {
+	Send(*Event) error
+	Recv() (*PublishResponse, error)
+	grpc.ClientStream
+}
+
+type eventServicePublishClient struct {
+	grpc.ClientStream
+}
+
+func (x *eventServicePublishClient) Send(m *Event) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *eventServicePublishClient) Recv() (*PublishResponse, error) {
+	m := new(PublishResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// EventServiceServer is the server API for EventService service.
+// All implementations must embed UnimplementedEventServiceServer
+// for forward compatibility
+type EventServiceServer interface {
+	// Publish streams the events, in the order they are handled. The server
+	// acknowledges each event once it is processed, with a PublishResponse.
+	Publish(EventService_PublishServer) error
+	mustEmbedUnimplementedEventServiceServer()
+}
+
+// UnimplementedEventServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedEventServiceServer struct {
+}
+
+func (UnimplementedEventServiceServer) Publish(EventService_PublishServer) error {
+	return status.Errorf(codes.Unimplemented, "method Publish not implemented")
+}
+func (UnimplementedEventServiceServer) mustEmbedUnimplementedEventServiceServer() {}
+
+// UnsafeEventServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to EventServiceServer will
+// result in compilation errors.
+type UnsafeEventServiceServer interface {
+	mustEmbedUnimplementedEventServiceServer()
+}
+
+func RegisterEventServiceServer(s *grpc.Server, srv EventServiceServer) {
+	s.RegisterService(&_EventService_serviceDesc, srv)
+}
+
+func _EventService_Publish_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(EventServiceServer).Publish(&eventServicePublishServer{stream})
+}
+
+type EventService_PublishServer interface {
+	Send(*PublishResponse) error
+	Recv() (*Event, error)
+	grpc.ServerStream
+}
+
+type eventServicePublishServer struct {
+	grpc.ServerStream
+}
+
+func (x *eventServicePublishServer) Send(m *PublishResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *eventServicePublishServer) Recv() (*Event, error) {
+	m := new(Event)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+var _EventService_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "kubewatch.v1.EventService",
+	HandlerType: (*EventServiceServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Publish",
+			Handler:       _EventService_Publish_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "kubewatch/v1/event.proto",
+}
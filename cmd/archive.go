@@ -0,0 +1,73 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// archiveConfigCmd represents the archive subcommand
+var archiveConfigCmd = &cobra.Command{
+	Use:   "archive",
+	Short: "specific archive configuration",
+	Long:  `specific archive configuration`,
+	Run: func(cmd *cobra.Command, args []string) {
+		conf, err := config.New()
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		provider, err := cmd.Flags().GetString("provider")
+		if err == nil {
+			if len(provider) > 0 {
+				conf.Handler.Archive.Provider = provider
+			}
+		} else {
+			logrus.Fatal(err)
+		}
+
+		bucket, err := cmd.Flags().GetString("bucket")
+		if err == nil {
+			if len(bucket) > 0 {
+				conf.Handler.Archive.Bucket = bucket
+			}
+		} else {
+			logrus.Fatal(err)
+		}
+
+		prefix, err := cmd.Flags().GetString("prefix")
+		if err == nil {
+			if len(prefix) > 0 {
+				conf.Handler.Archive.Prefix = prefix
+			}
+		} else {
+			logrus.Fatal(err)
+		}
+
+		if err = conf.Write(); err != nil {
+			logrus.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	archiveConfigCmd.Flags().StringP("provider", "p", "", "Specify archive storage provider, s3, gcs or azure")
+	archiveConfigCmd.Flags().StringP("bucket", "b", "", "Specify archive bucket, or Azure container")
+	archiveConfigCmd.Flags().StringP("prefix", "x", "", "Specify archive file prefix template, kubewatch/{year}/{month}/{day}/ by default")
+}
@@ -0,0 +1,60 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// awsConfigCmd represents the aws subcommand
+var awsConfigCmd = &cobra.Command{
+	Use:   "aws",
+	Short: "specific aws sns/sqs configuration",
+	Long:  `specific aws sns/sqs configuration`,
+	Run: func(cmd *cobra.Command, args []string) {
+		conf, err := config.New()
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		arn, err := cmd.Flags().GetString("arn")
+		if err == nil {
+			if len(arn) > 0 {
+				conf.Handler.AWS.ARN = arn
+			}
+		} else {
+			logrus.Fatal(err)
+		}
+		region, err := cmd.Flags().GetString("region")
+		if err == nil {
+			if len(region) > 0 {
+				conf.Handler.AWS.Region = region
+			}
+		}
+
+		if err = conf.Write(); err != nil {
+			logrus.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	awsConfigCmd.Flags().StringP("arn", "a", "", "Specify SNS topic or SQS queue ARN")
+	awsConfigCmd.Flags().StringP("region", "r", "", "Specify AWS region")
+}
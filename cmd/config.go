@@ -24,9 +24,8 @@ import (
 
 	"github.com/bitnami-labs/kubewatch/config"
 	"github.com/bitnami-labs/kubewatch/pkg/client"
-	"github.com/bitnami-labs/kubewatch/pkg/event"
-	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
 )
 
 const kubewatchConfigFile = ".kubewatch.yaml"
@@ -53,27 +52,14 @@ Adds webhook config to ~/.kubewatch.yaml`,
 }
 
 var configTestCmd = &cobra.Command{
-	Use:   "test",
+	Use:   "test [handler...]",
 	Short: "test handler config present in ~/.kubewatch.yaml",
 	Long: `
-Tests handler configs present in ~/.kubewatch.yaml by sending test messages`,
+Tests handler configs present in ~/.kubewatch.yaml by sending test messages,
+same as kubewatch test`,
 	Run: func(cmd *cobra.Command, args []string) {
 		fmt.Println("Testing Handler configs from .kubewatch.yaml")
-		conf, err := config.New()
-		if err != nil {
-			logrus.Fatal(err)
-		}
-		eventHandler := client.ParseEventHandler(conf)
-		e := event.Event{
-			Namespace: "testNamespace",
-			Name:      "testResource",
-			Kind:      "testKind",
-			Component: "testComponent",
-			Host:      "testHost",
-			Reason:    "Tested",
-			Status:    "Normal",
-		}
-		eventHandler.Handle(e)
+		runTest(args)
 	},
 }
 
@@ -87,6 +73,74 @@ Print a sample config file which can be put in ~/.kubewatch.yaml`,
 	},
 }
 
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "validate ~/.kubewatch.yaml",
+	Long: `
+Checks ~/.kubewatch.yaml for unknown keys, missing handler settings and
+invalid filters, schedules and templates, printing the problems found`,
+	Run: func(cmd *cobra.Command, args []string) {
+		var problems []string
+		conf := &config.Config{}
+		if err := conf.LoadStrict(); err != nil {
+			typeErr, ok := err.(*yaml.TypeError)
+			if !ok {
+				fmt.Fprintf(os.Stderr, "%v\n", err)
+				os.Exit(1)
+			}
+			problems = append(problems, typeErr.Errors...)
+		}
+		if conf.APIVersion == "" {
+			fmt.Fprintln(os.Stderr, "No apiVersion, the legacy schema is migrated when loaded; run kubewatch config migrate to set it")
+		}
+		conf.CheckMissingResourceEnvvars()
+		for _, err := range client.Validate(conf) {
+			problems = append(problems, err.Error())
+		}
+
+		if len(problems) == 0 {
+			fmt.Println("Config is valid")
+			return
+		}
+		for _, p := range problems {
+			fmt.Fprintln(os.Stderr, p)
+		}
+		os.Exit(1)
+	},
+}
+
+var configMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "migrate ~/.kubewatch.yaml to the current schema",
+	Long: `
+Rewrites ~/.kubewatch.yaml without apiVersion in the current schema, renaming
+the legacy keys and setting the apiVersion, printing the changes made`,
+	Run: func(cmd *cobra.Command, args []string) {
+		path := config.FilePath()
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+		migrated, changes, err := config.Migrate(b)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+		if len(changes) == 0 {
+			fmt.Println("Config already uses apiVersion " + config.APIVersion)
+			return
+		}
+		if err := ioutil.WriteFile(path, migrated, 0644); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+		for _, change := range changes {
+			fmt.Println(change)
+		}
+	},
+}
+
 var configViewCmd = &cobra.Command{
 	Use:   "view",
 	Short: "view ~/.kubewatch.yaml",
@@ -109,6 +163,8 @@ func init() {
 		configAddCmd,
 		configTestCmd,
 		configSampleCmd,
+		configValidateCmd,
+		configMigrateCmd,
 		configViewCmd,
 	)
 
@@ -120,5 +176,31 @@ func init() {
 		webhookConfigCmd,
 		msteamsConfigCmd,
 		smtpConfigCmd,
+		opsgenieConfigCmd,
+		kafkaConfigCmd,
+		natsConfigCmd,
+		awsConfigCmd,
+		pubsubConfigCmd,
+		rocketchatConfigCmd,
+		telegramConfigCmd,
+		googlechatConfigCmd,
+		grpcConfigCmd,
+		syslogConfigCmd,
+		elasticsearchConfigCmd,
+		lokiConfigCmd,
+		execConfigCmd,
+		jiraConfigCmd,
+		servicenowConfigCmd,
+		githubConfigCmd,
+		alertmanagerConfigCmd,
+		datadogConfigCmd,
+		webexConfigCmd,
+		zulipConfigCmd,
+		ntfyConfigCmd,
+		mqttConfigCmd,
+		databaseConfigCmd,
+		archiveConfigCmd,
+		larkConfigCmd,
+		dingtalkConfigCmd,
 	)
 }
@@ -0,0 +1,63 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// datadogConfigCmd represents the datadog subcommand
+var datadogConfigCmd = &cobra.Command{
+	Use:   "datadog",
+	Short: "specific datadog configuration",
+	Long:  `specific datadog configuration`,
+	Run: func(cmd *cobra.Command, args []string) {
+		conf, err := config.New()
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		apiKey, err := cmd.Flags().GetString("api-key")
+		if err == nil {
+			if len(apiKey) > 0 {
+				conf.Handler.Datadog.APIKey = apiKey
+			}
+		} else {
+			logrus.Fatal(err)
+		}
+
+		statsdAddress, err := cmd.Flags().GetString("statsd-address")
+		if err == nil {
+			if len(statsdAddress) > 0 {
+				conf.Handler.Datadog.StatsdAddress = statsdAddress
+			}
+		} else {
+			logrus.Fatal(err)
+		}
+
+		if err = conf.Write(); err != nil {
+			logrus.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	datadogConfigCmd.Flags().StringP("api-key", "k", "", "Specify Datadog API key")
+	datadogConfigCmd.Flags().String("statsd-address", "", "Specify DogStatsD address, as host:port, sending the events through the agent")
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// dingtalkConfigCmd represents the dingtalk subcommand
+var dingtalkConfigCmd = &cobra.Command{
+	Use:   "dingtalk",
+	Short: "specific dingtalk configuration",
+	Long:  `specific dingtalk configuration`,
+	Run: func(cmd *cobra.Command, args []string) {
+		conf, err := config.New()
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		url, err := cmd.Flags().GetString("url")
+		if err == nil {
+			if len(url) > 0 {
+				conf.Handler.DingTalk.WebhookURL = url
+			}
+		} else {
+			logrus.Fatal(err)
+		}
+
+		secret, err := cmd.Flags().GetString("secret")
+		if err == nil {
+			if len(secret) > 0 {
+				conf.Handler.DingTalk.Secret = secret
+			}
+		} else {
+			logrus.Fatal(err)
+		}
+
+		if err = conf.Write(); err != nil {
+			logrus.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	dingtalkConfigCmd.Flags().StringP("url", "u", "", "Specify DingTalk robot webhook url, with its access token")
+	dingtalkConfigCmd.Flags().StringP("secret", "s", "", "Specify DingTalk robot signing secret")
+}
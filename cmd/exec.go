@@ -0,0 +1,63 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// execConfigCmd represents the exec subcommand
+var execConfigCmd = &cobra.Command{
+	Use:   "exec",
+	Short: "specific exec configuration",
+	Long:  `specific exec configuration`,
+	Run: func(cmd *cobra.Command, args []string) {
+		conf, err := config.New()
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		command, err := cmd.Flags().GetString("command")
+		if err == nil {
+			if len(command) > 0 {
+				conf.Handler.Exec.Command = command
+			}
+		} else {
+			logrus.Fatal(err)
+		}
+
+		commandArgs, err := cmd.Flags().GetStringSlice("args")
+		if err == nil {
+			if len(commandArgs) > 0 {
+				conf.Handler.Exec.Args = commandArgs
+			}
+		} else {
+			logrus.Fatal(err)
+		}
+
+		if err = conf.Write(); err != nil {
+			logrus.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	execConfigCmd.Flags().StringP("command", "c", "", "Specify path of the command handling the events")
+	execConfigCmd.Flags().StringSlice("args", nil, "Specify arguments of the command, comma separated")
+}
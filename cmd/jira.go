@@ -0,0 +1,83 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// jiraConfigCmd represents the jira subcommand
+var jiraConfigCmd = &cobra.Command{
+	Use:   "jira",
+	Short: "specific jira configuration",
+	Long:  `specific jira configuration`,
+	Run: func(cmd *cobra.Command, args []string) {
+		conf, err := config.New()
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		url, err := cmd.Flags().GetString("url")
+		if err == nil {
+			if len(url) > 0 {
+				conf.Handler.Jira.Url = url
+			}
+		} else {
+			logrus.Fatal(err)
+		}
+
+		project, err := cmd.Flags().GetString("project")
+		if err == nil {
+			if len(project) > 0 {
+				conf.Handler.Jira.Project = project
+			}
+		} else {
+			logrus.Fatal(err)
+		}
+
+		token, err := cmd.Flags().GetString("token")
+		if err == nil {
+			if len(token) > 0 {
+				conf.Handler.Jira.Token = token
+			}
+		} else {
+			logrus.Fatal(err)
+		}
+
+		username, err := cmd.Flags().GetString("username")
+		if err == nil {
+			if len(username) > 0 {
+				conf.Handler.Jira.Username = username
+			}
+		} else {
+			logrus.Fatal(err)
+		}
+
+		if err = conf.Write(); err != nil {
+			logrus.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	jiraConfigCmd.Flags().StringP("url", "u", "", "Specify Jira url")
+	jiraConfigCmd.Flags().StringP("project", "p", "", "Specify Jira project key")
+	jiraConfigCmd.Flags().StringP("token", "t", "", "Specify Jira API token")
+	jiraConfigCmd.Flags().String("username", "", "Specify user of the Jira API token")
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// kafkaConfigCmd represents the kafka subcommand
+var kafkaConfigCmd = &cobra.Command{
+	Use:   "kafka",
+	Short: "specific kafka configuration",
+	Long:  `specific kafka configuration`,
+	Run: func(cmd *cobra.Command, args []string) {
+		conf, err := config.New()
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		brokers, err := cmd.Flags().GetStringSlice("brokers")
+		if err == nil {
+			if len(brokers) > 0 {
+				conf.Handler.Kafka.Brokers = brokers
+			}
+		} else {
+			logrus.Fatal(err)
+		}
+		topic, err := cmd.Flags().GetString("topic")
+		if err == nil {
+			if len(topic) > 0 {
+				conf.Handler.Kafka.Topic = topic
+			}
+		} else {
+			logrus.Fatal(err)
+		}
+		key, err := cmd.Flags().GetString("key")
+		if err == nil {
+			if len(key) > 0 {
+				conf.Handler.Kafka.Key = key
+			}
+		}
+
+		if err = conf.Write(); err != nil {
+			logrus.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	kafkaConfigCmd.Flags().StringSliceP("brokers", "b", nil, "Specify kafka brokers (host:port)")
+	kafkaConfigCmd.Flags().StringP("topic", "t", "", "Specify kafka topic")
+	kafkaConfigCmd.Flags().StringP("key", "k", "", "Specify kafka message key: namespace, kind or name")
+}
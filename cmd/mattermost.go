@@ -53,13 +53,22 @@ var mattermostConfigCmd = &cobra.Command{
 
 		username, err := cmd.Flags().GetString("username")
 		if err == nil {
-			if len(url) > 0 {
+			if len(username) > 0 {
 				conf.Handler.Mattermost.Username = username
 			}
 		} else {
 			logrus.Fatal(err)
 		}
 
+		icon, err := cmd.Flags().GetString("icon")
+		if err == nil {
+			if len(icon) > 0 {
+				conf.Handler.Mattermost.IconUrl = icon
+			}
+		} else {
+			logrus.Fatal(err)
+		}
+
 		if err = conf.Write(); err != nil {
 			logrus.Fatal(err)
 		}
@@ -70,4 +79,5 @@ func init() {
 	mattermostConfigCmd.Flags().StringP("channel", "c", "", "Specify Mattermost channel")
 	mattermostConfigCmd.Flags().StringP("url", "u", "", "Specify Mattermost url")
 	mattermostConfigCmd.Flags().StringP("username", "n", "", "Specify Mattermost username")
+	mattermostConfigCmd.Flags().StringP("icon", "i", "", "Specify Mattermost profile picture url")
 }
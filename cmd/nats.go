@@ -0,0 +1,69 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// natsConfigCmd represents the nats subcommand
+var natsConfigCmd = &cobra.Command{
+	Use:   "nats",
+	Short: "specific nats configuration",
+	Long:  `specific nats configuration`,
+	Run: func(cmd *cobra.Command, args []string) {
+		conf, err := config.New()
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		url, err := cmd.Flags().GetString("url")
+		if err == nil {
+			if len(url) > 0 {
+				conf.Handler.NATS.Url = url
+			}
+		} else {
+			logrus.Fatal(err)
+		}
+		subject, err := cmd.Flags().GetString("subject")
+		if err == nil {
+			if len(subject) > 0 {
+				conf.Handler.NATS.Subject = subject
+			}
+		} else {
+			logrus.Fatal(err)
+		}
+		jetstream, err := cmd.Flags().GetBool("jetstream")
+		if err == nil {
+			if jetstream {
+				conf.Handler.NATS.JetStream = true
+			}
+		}
+
+		if err = conf.Write(); err != nil {
+			logrus.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	natsConfigCmd.Flags().StringP("url", "u", "", "Specify NATS server url")
+	natsConfigCmd.Flags().StringP("subject", "s", "", "Specify NATS subject template")
+	natsConfigCmd.Flags().Bool("jetstream", false, "Publish through JetStream")
+}
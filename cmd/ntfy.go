@@ -0,0 +1,73 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// ntfyConfigCmd represents the ntfy subcommand
+var ntfyConfigCmd = &cobra.Command{
+	Use:   "ntfy",
+	Short: "specific ntfy configuration",
+	Long:  `specific ntfy configuration`,
+	Run: func(cmd *cobra.Command, args []string) {
+		conf, err := config.New()
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		url, err := cmd.Flags().GetString("url")
+		if err == nil {
+			if len(url) > 0 {
+				conf.Handler.Ntfy.Url = url
+			}
+		} else {
+			logrus.Fatal(err)
+		}
+
+		topic, err := cmd.Flags().GetString("topic")
+		if err == nil {
+			if len(topic) > 0 {
+				conf.Handler.Ntfy.Topic = topic
+			}
+		} else {
+			logrus.Fatal(err)
+		}
+
+		token, err := cmd.Flags().GetString("token")
+		if err == nil {
+			if len(token) > 0 {
+				conf.Handler.Ntfy.Token = token
+			}
+		} else {
+			logrus.Fatal(err)
+		}
+
+		if err = conf.Write(); err != nil {
+			logrus.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	ntfyConfigCmd.Flags().StringP("url", "u", "", "Specify ntfy server url, https://ntfy.sh by default")
+	ntfyConfigCmd.Flags().StringP("topic", "t", "", "Specify ntfy topic")
+	ntfyConfigCmd.Flags().StringP("token", "k", "", "Specify ntfy access token")
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// opsgenieConfigCmd represents the opsgenie subcommand
+var opsgenieConfigCmd = &cobra.Command{
+	Use:   "opsgenie",
+	Short: "specific opsgenie configuration",
+	Long:  `specific opsgenie configuration`,
+	Run: func(cmd *cobra.Command, args []string) {
+		conf, err := config.New()
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		apiKey, err := cmd.Flags().GetString("apikey")
+		if err == nil {
+			if len(apiKey) > 0 {
+				conf.Handler.OpsGenie.APIKey = apiKey
+			}
+		} else {
+			logrus.Fatal(err)
+		}
+		teams, err := cmd.Flags().GetStringSlice("teams")
+		if err == nil {
+			if len(teams) > 0 {
+				conf.Handler.OpsGenie.Teams = teams
+			}
+		} else {
+			logrus.Fatal(err)
+		}
+		priority, err := cmd.Flags().GetString("priority")
+		if err == nil {
+			if len(priority) > 0 {
+				conf.Handler.OpsGenie.Priority = priority
+			}
+		}
+
+		if err = conf.Write(); err != nil {
+			logrus.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	opsgenieConfigCmd.Flags().StringP("apikey", "k", "", "Specify OpsGenie API key")
+	opsgenieConfigCmd.Flags().StringSliceP("teams", "t", nil, "Specify OpsGenie teams to route alerts to")
+	opsgenieConfigCmd.Flags().StringP("priority", "p", "", "Specify default OpsGenie alert priority (P1-P5)")
+}
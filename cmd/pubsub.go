@@ -0,0 +1,62 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// pubsubConfigCmd represents the pubsub subcommand
+var pubsubConfigCmd = &cobra.Command{
+	Use:   "pubsub",
+	Short: "specific google cloud pub/sub configuration",
+	Long:  `specific google cloud pub/sub configuration`,
+	Run: func(cmd *cobra.Command, args []string) {
+		conf, err := config.New()
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		project, err := cmd.Flags().GetString("project")
+		if err == nil {
+			if len(project) > 0 {
+				conf.Handler.PubSub.Project = project
+			}
+		} else {
+			logrus.Fatal(err)
+		}
+		topic, err := cmd.Flags().GetString("topic")
+		if err == nil {
+			if len(topic) > 0 {
+				conf.Handler.PubSub.Topic = topic
+			}
+		} else {
+			logrus.Fatal(err)
+		}
+
+		if err = conf.Write(); err != nil {
+			logrus.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	pubsubConfigCmd.Flags().StringP("project", "p", "", "Specify Google Cloud project")
+	pubsubConfigCmd.Flags().StringP("topic", "t", "", "Specify Pub/Sub topic")
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/client"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var renderCmd = &cobra.Command{
+	Use:   "render handler [event.json]",
+	Short: "print the requests of a handler for an event without sending them",
+	Long: `
+Renders a sample event with a handler instance configured in ~/.kubewatch.yaml,
+"default" being the handler of the handler section, printing the HTTP
+requests it would send and the instances the event is routed to, without
+sending anything. The event is read as JSON from the file, or from the
+standard input with "-", the event of the test command being rendered when
+none is given:
+
+  kubewatch render slack event.json
+  echo '{"namespace":"shop","kind":"pod","name":"web","reason":"deleted"}' | kubewatch render default -`,
+	Args: cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		runRender(args)
+	},
+}
+
+// runRender prints the rendering of the event of the arguments.
+func runRender(args []string) {
+	conf, err := config.New()
+	if err != nil {
+		logrus.Fatal(err)
+	}
+
+	e := client.TestEvent(conf)
+	if len(args) == 2 {
+		var data []byte
+		if args[1] == "-" {
+			data, err = ioutil.ReadAll(os.Stdin)
+		} else {
+			data, err = ioutil.ReadFile(args[1])
+		}
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		e = event.Event{}
+		if err := json.Unmarshal(data, &e); err != nil {
+			logrus.Fatalf("Invalid event: %v", err)
+		}
+	}
+
+	// the handlers log their deliveries as if they were sent
+	logrus.SetLevel(logrus.WarnLevel)
+	r, err := client.Render(conf, args[0], e)
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	if !printRendering(os.Stdout, r) {
+		os.Exit(1)
+	}
+}
+
+// printRendering prints the routing and the requests of the rendering,
+// reporting whether the handler succeeded.
+func printRendering(w io.Writer, r *client.Rendering) bool {
+	fmt.Fprintf(w, "Severity: %s\n", r.Event.Severity)
+	routed := false
+	for _, name := range r.Routed {
+		routed = routed || name == r.Name
+	}
+	if routed {
+		fmt.Fprintf(w, "Routed to: %s\n", strings.Join(r.Routed, ", "))
+	} else {
+		fmt.Fprintf(w, "Not routed to %s by the routes and filters, rendered anyway\n", r.Name)
+	}
+	if len(r.Requests) == 0 {
+		fmt.Fprintf(w, "\nNo request sent by the %s handler\n", r.Type)
+	}
+	for _, req := range r.Requests {
+		fmt.Fprintf(w, "\n%s %s\n", req.Method, req.URL)
+		var keys []string
+		for k := range req.Header {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			value := strings.Join(req.Header[k], ", ")
+			if k == "Authorization" {
+				value = "<redacted>"
+			}
+			fmt.Fprintf(w, "%s: %s\n", k, value)
+		}
+		body := req.Body
+		var indented bytes.Buffer
+		if json.Indent(&indented, body, "", "  ") == nil {
+			body = indented.Bytes()
+		}
+		fmt.Fprintf(w, "\n%s\n", body)
+	}
+	if r.Err != nil {
+		fmt.Fprintf(w, "\nThe %s handler failed: %v\n", r.Type, r.Err)
+		return false
+	}
+	return true
+}
+
+func init() {
+	RootCmd.AddCommand(renderCmd)
+}
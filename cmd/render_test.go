@@ -0,0 +1,117 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"regexp"
+	"testing"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/client"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+)
+
+var renderedWebhook = `Severity: critical
+Routed to: default
+
+POST https://hooks.example.com/kubewatch
+Authorization: <redacted>
+Content-Type: application/json
+X-Env: prod
+X-Kubewatch-Event-Id: 42
+
+{
+  "eventmeta": {
+    "cluster": "prod",
+    "kind": "pod",
+    "name": "web",
+    "namespace": "shop",
+    "reason": "deleted",
+    "severity": "critical",
+    "id": "42"
+  },
+  "text": "[prod] A ` + "`pod`" + ` in namespace ` + "`shop`" + ` has been ` + "`deleted`" + `:\n` + "`web`" + `",
+  "time": "<time>"
+}
+`
+
+var renderedMSTeams = `Severity: critical
+Not routed to teams by the routes and filters, rendered anyway
+
+POST https://outlook.example.com/webhook/1
+Content-Type: application/json
+
+{
+  "@type": "MessageCard",
+  "@context": "http://schema.org/extensions",
+  "themeColor": "8C1A1A",
+  "summary": "kubewatch notification received",
+  "title": "kubewatch",
+  "sections": [
+    {
+      "activityTitle": "[prod] A ` + "`pod`" + ` in namespace ` + "`shop`" + ` has been ` + "`deleted`" + `:\n` + "`web`" + `",
+      "facts": null,
+      "markdown": true
+    }
+  ]
+}
+
+`
+
+// renderedTime matches the time of the notifications, changing with each
+// rendering.
+var renderedTime = regexp.MustCompile(`"time": "[^"]*"`)
+
+func TestPrintRendering(t *testing.T) {
+	conf := &config.Config{
+		ClusterName: "prod",
+		Handler: config.Handler{Webhook: config.Webhook{
+			Url:         "https://hooks.example.com/kubewatch",
+			Headers:     map[string]string{"X-Env": "prod"},
+			BearerToken: "secret",
+		}},
+		Handlers: []config.HandlerInstance{{
+			Name:    "teams",
+			Handler: config.Handler{MSTeams: config.MSTeams{WebhookURL: "https://outlook.example.com/webhook/1"}},
+			Filter:  config.Filter{Namespaces: []string{"kube-system"}},
+		}},
+	}
+	e := event.Event{ID: "42", Namespace: "shop", Kind: "pod", Name: "web", Reason: "deleted", Status: "Danger"}
+
+	var Tests = []struct {
+		name string
+		want string
+	}{
+		{"default", renderedWebhook},
+		{"teams", renderedMSTeams},
+	}
+
+	for _, tt := range Tests {
+		r, err := client.Render(conf, tt.name, e)
+		if err != nil {
+			t.Fatalf("Render(%s): %v", tt.name, err)
+		}
+		var out bytes.Buffer
+		if !printRendering(&out, r) {
+			t.Fatalf("printRendering(%s): the handler failed", tt.name)
+		}
+		if got := renderedTime.ReplaceAllString(out.String(), `"time": "<time>"`); got != tt.want {
+			t.Fatalf("printRendering(%s): got\n%s\nwant\n%s", tt.name, got, tt.want)
+		}
+	}
+}
@@ -113,6 +113,10 @@ func configureResource(operation string, cmd *cobra.Command, conf *config.Config
 			"pv",
 			&conf.Resource.PersistentVolume,
 		},
+		{
+			"pvc",
+			&conf.Resource.PersistentVolumeClaim,
+		},
 		{
 			"ds",
 			&conf.Resource.DaemonSet,
@@ -141,6 +145,26 @@ func configureResource(operation string, cmd *cobra.Command, conf *config.Config
 			"sa",
 			&conf.Resource.ServiceAccount,
 		},
+		{
+			"event",
+			&conf.Resource.Event,
+		},
+		{
+			"sts",
+			&conf.Resource.StatefulSet,
+		},
+		{
+			"cronjob",
+			&conf.Resource.CronJob,
+		},
+		{
+			"hpa",
+			&conf.Resource.HorizontalPodAutoscaler,
+		},
+		{
+			"netpol",
+			&conf.Resource.NetworkPolicy,
+		},
 	}
 
 	for _, flag := range flags {
@@ -180,6 +204,7 @@ func init() {
 	resourceConfigCmd.PersistentFlags().Bool("rs", false, "watch for replicasets")
 	resourceConfigCmd.PersistentFlags().Bool("ns", false, "watch for namespaces")
 	resourceConfigCmd.PersistentFlags().Bool("pv", false, "watch for persistent volumes")
+	resourceConfigCmd.PersistentFlags().Bool("pvc", false, "watch for persistent volume claims")
 	resourceConfigCmd.PersistentFlags().Bool("job", false, "watch for jobs")
 	resourceConfigCmd.PersistentFlags().Bool("ds", false, "watch for daemonsets")
 	resourceConfigCmd.PersistentFlags().Bool("secret", false, "watch for plain secrets")
@@ -188,4 +213,9 @@ func init() {
 	resourceConfigCmd.PersistentFlags().Bool("node", false, "watch for Nodes")
 	resourceConfigCmd.PersistentFlags().Bool("clusterrole", false, "watch for cluster roles")
 	resourceConfigCmd.PersistentFlags().Bool("sa", false, "watch for service accounts")
+	resourceConfigCmd.PersistentFlags().Bool("event", false, "watch for Kubernetes events")
+	resourceConfigCmd.PersistentFlags().Bool("sts", false, "watch for statefulsets")
+	resourceConfigCmd.PersistentFlags().Bool("cronjob", false, "watch for cronjobs")
+	resourceConfigCmd.PersistentFlags().Bool("hpa", false, "watch for horizontal pod autoscalers")
+	resourceConfigCmd.PersistentFlags().Bool("netpol", false, "watch for network policies")
 }
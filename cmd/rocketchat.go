@@ -0,0 +1,73 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// rocketchatConfigCmd represents the rocketchat subcommand
+var rocketchatConfigCmd = &cobra.Command{
+	Use:   "rocketchat",
+	Short: "specific rocket.chat configuration",
+	Long:  `specific rocket.chat configuration`,
+	Run: func(cmd *cobra.Command, args []string) {
+		conf, err := config.New()
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		url, err := cmd.Flags().GetString("url")
+		if err == nil {
+			if len(url) > 0 {
+				conf.Handler.RocketChat.Url = url
+			}
+		} else {
+			logrus.Fatal(err)
+		}
+
+		channel, err := cmd.Flags().GetString("channel")
+		if err == nil {
+			if len(channel) > 0 {
+				conf.Handler.RocketChat.Channel = channel
+			}
+		} else {
+			logrus.Fatal(err)
+		}
+
+		username, err := cmd.Flags().GetString("username")
+		if err == nil {
+			if len(username) > 0 {
+				conf.Handler.RocketChat.Username = username
+			}
+		} else {
+			logrus.Fatal(err)
+		}
+
+		if err = conf.Write(); err != nil {
+			logrus.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	rocketchatConfigCmd.Flags().StringP("url", "u", "", "Specify Rocket.Chat incoming webhook url")
+	rocketchatConfigCmd.Flags().StringP("channel", "c", "", "Specify Rocket.Chat channel")
+	rocketchatConfigCmd.Flags().StringP("username", "n", "", "Specify Rocket.Chat username")
+}
@@ -28,6 +28,8 @@ import (
 )
 
 var cfgFile string
+var enableLeaderElection, dryRun, enablePprof bool
+var logLevel, logFormat string
 
 // RootCmd represents the base command when called without any subcommands
 var RootCmd = &cobra.Command{
@@ -46,14 +48,57 @@ supported webhooks:
  - mattermost
  - flock
  - webhook
+ - msteams
+ - smtp
+ - opsgenie
+ - kafka
+ - nats
+ - aws
+ - pubsub
+ - rocketchat
+ - telegram
+ - googlechat
+ - grpc
+ - syslog
+ - elasticsearch
+ - loki
+ - exec
+ - jira
+ - servicenow
+ - github
+ - alertmanager
+ - datadog
+ - webex
+ - zulip
+ - ntfy
+ - mqtt
+ - database
+ - archive
+ - lark
+ - dingtalk
 `,
 
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		if err := setupLogging(logLevel, logFormat); err != nil {
+			logrus.Fatal(err)
+		}
+	},
+
 	Run: func(cmd *cobra.Command, args []string) {
 		config := &config.Config{}
 		if err := config.Load(); err != nil {
 			logrus.Fatal(err)
 		}
 		config.CheckMissingResourceEnvvars()
+		if enableLeaderElection {
+			config.LeaderElection.Enabled = true
+		}
+		if dryRun {
+			config.DryRun = true
+		}
+		if enablePprof {
+			config.Server.Pprof = true
+		}
 		c.Run(config)
 	},
 }
@@ -69,15 +114,40 @@ func Execute() {
 
 func init() {
 	cobra.OnInitialize(initConfig)
+	config.SetSecretResolver(c.ResolveSecret)
 
 	// Disable Help subcommand
 	RootCmd.SetHelpCommand(&cobra.Command{
 		Use:    "no-help",
 		Hidden: true,
 	})
+	RootCmd.PersistentFlags().StringVar(&logLevel, "log-level", "info", "Log level: debug, info, warn or error")
+	RootCmd.PersistentFlags().StringVar(&logFormat, "log-format", "text", "Log format: text or json")
+	RootCmd.Flags().BoolVar(&enableLeaderElection, "enable-leader-election", false, "Only dispatch events while holding the kubewatch lease, for running several replicas")
+	RootCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Log the notifications instead of sending them")
+	RootCmd.Flags().BoolVar(&enablePprof, "enable-pprof", false, "Serve the Go profiles on /debug/pprof/ of the HTTP server")
 	//RootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.kubewatch.yaml)")
 }
 
+// setupLogging configures the level and format of the logs.
+func setupLogging(level, format string) error {
+	l, err := logrus.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	logrus.SetLevel(l)
+
+	switch format {
+	case "text":
+		logrus.SetFormatter(&logrus.TextFormatter{FullTimestamp: true})
+	case "json":
+		logrus.SetFormatter(&logrus.JSONFormatter{})
+	default:
+		return fmt.Errorf("unknown log format %q, must be text or json", format)
+	}
+	return nil
+}
+
 // initConfig reads in config file and ENV variables if set.
 func initConfig() {
 	if cfgFile != "" { // enable ability to specify config file via flag
@@ -0,0 +1,83 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// servicenowConfigCmd represents the servicenow subcommand
+var servicenowConfigCmd = &cobra.Command{
+	Use:   "servicenow",
+	Short: "specific servicenow configuration",
+	Long:  `specific servicenow configuration`,
+	Run: func(cmd *cobra.Command, args []string) {
+		conf, err := config.New()
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		url, err := cmd.Flags().GetString("url")
+		if err == nil {
+			if len(url) > 0 {
+				conf.Handler.ServiceNow.Url = url
+			}
+		} else {
+			logrus.Fatal(err)
+		}
+
+		username, err := cmd.Flags().GetString("username")
+		if err == nil {
+			if len(username) > 0 {
+				conf.Handler.ServiceNow.BasicAuth.Username = username
+			}
+		} else {
+			logrus.Fatal(err)
+		}
+
+		password, err := cmd.Flags().GetString("password")
+		if err == nil {
+			if len(password) > 0 {
+				conf.Handler.ServiceNow.BasicAuth.Password = password
+			}
+		} else {
+			logrus.Fatal(err)
+		}
+
+		mode, err := cmd.Flags().GetString("mode")
+		if err == nil {
+			if len(mode) > 0 {
+				conf.Handler.ServiceNow.Mode = mode
+			}
+		} else {
+			logrus.Fatal(err)
+		}
+
+		if err = conf.Write(); err != nil {
+			logrus.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	servicenowConfigCmd.Flags().StringP("url", "u", "", "Specify ServiceNow instance url")
+	servicenowConfigCmd.Flags().String("username", "", "Specify ServiceNow username")
+	servicenowConfigCmd.Flags().String("password", "", "Specify ServiceNow password")
+	servicenowConfigCmd.Flags().StringP("mode", "m", "", "Specify the records created: incident or event")
+}
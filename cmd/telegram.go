@@ -0,0 +1,63 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// telegramConfigCmd represents the telegram subcommand
+var telegramConfigCmd = &cobra.Command{
+	Use:   "telegram",
+	Short: "specific telegram configuration",
+	Long:  `specific telegram configuration`,
+	Run: func(cmd *cobra.Command, args []string) {
+		conf, err := config.New()
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		token, err := cmd.Flags().GetString("token")
+		if err == nil {
+			if len(token) > 0 {
+				conf.Handler.Telegram.Token = token
+			}
+		} else {
+			logrus.Fatal(err)
+		}
+
+		chats, err := cmd.Flags().GetStringSlice("chat")
+		if err == nil {
+			if len(chats) > 0 {
+				conf.Handler.Telegram.ChatIDs = chats
+			}
+		} else {
+			logrus.Fatal(err)
+		}
+
+		if err = conf.Write(); err != nil {
+			logrus.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	telegramConfigCmd.Flags().StringP("token", "t", "", "Specify Telegram bot token")
+	telegramConfigCmd.Flags().StringSliceP("chat", "c", nil, "Specify Telegram chat ids, comma separated")
+}
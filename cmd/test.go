@@ -0,0 +1,74 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/client"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var testCmd = &cobra.Command{
+	Use:   "test [handler...]",
+	Short: "send a test notification with the configured handlers",
+	Long: `
+Sends a synthetic event with the handlers configured in ~/.kubewatch.yaml and
+reports whether each one delivered it. The handler instances are given by
+name, "default" being the handler of the handler section; all of them are
+tested when none is given.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		runTest(args)
+	},
+}
+
+// runTest sends the test event to the named handler instances, exiting
+// with an error status when one of them fails.
+func runTest(names []string) {
+	conf, err := config.New()
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	conf.CheckMissingResourceEnvvars()
+
+	results, err := client.SendTest(conf, names)
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	failed := false
+	for _, r := range results {
+		switch {
+		case r.Err != nil:
+			failed = true
+			fmt.Printf("%s: FAILED: %v\n", r.Name, r.Err)
+		case r.Confirmed:
+			fmt.Printf("%s: OK\n", r.Name)
+		default:
+			fmt.Printf("%s: SENT (delivery errors are only logged)\n", r.Name)
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func init() {
+	RootCmd.AddCommand(testCmd)
+}
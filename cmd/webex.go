@@ -0,0 +1,63 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// webexConfigCmd represents the webex subcommand
+var webexConfigCmd = &cobra.Command{
+	Use:   "webex",
+	Short: "specific webex configuration",
+	Long:  `specific webex configuration`,
+	Run: func(cmd *cobra.Command, args []string) {
+		conf, err := config.New()
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		token, err := cmd.Flags().GetString("token")
+		if err == nil {
+			if len(token) > 0 {
+				conf.Handler.Webex.Token = token
+			}
+		} else {
+			logrus.Fatal(err)
+		}
+
+		room, err := cmd.Flags().GetString("room")
+		if err == nil {
+			if len(room) > 0 {
+				conf.Handler.Webex.RoomID = room
+			}
+		} else {
+			logrus.Fatal(err)
+		}
+
+		if err = conf.Write(); err != nil {
+			logrus.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	webexConfigCmd.Flags().StringP("token", "t", "", "Specify Webex bot token")
+	webexConfigCmd.Flags().StringP("room", "r", "", "Specify Webex room id")
+}
@@ -41,6 +41,12 @@ var webhookConfigCmd = &cobra.Command{
 		} else {
 			logrus.Fatal(err)
 		}
+		format, err := cmd.Flags().GetString("format")
+		if err == nil {
+			if len(format) > 0 {
+				conf.Handler.Webhook.Format = format
+			}
+		}
 
 		if err = conf.Write(); err != nil {
 			logrus.Fatal(err)
@@ -50,4 +56,5 @@ var webhookConfigCmd = &cobra.Command{
 
 func init() {
 	webhookConfigCmd.Flags().StringP("url", "u", "", "Specify Webhook url")
+	webhookConfigCmd.Flags().StringP("format", "f", "", "Specify Webhook payload format: kubewatch or cloudevents")
 }
@@ -0,0 +1,93 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// zulipConfigCmd represents the zulip subcommand
+var zulipConfigCmd = &cobra.Command{
+	Use:   "zulip",
+	Short: "specific zulip configuration",
+	Long:  `specific zulip configuration`,
+	Run: func(cmd *cobra.Command, args []string) {
+		conf, err := config.New()
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		url, err := cmd.Flags().GetString("url")
+		if err == nil {
+			if len(url) > 0 {
+				conf.Handler.Zulip.Url = url
+			}
+		} else {
+			logrus.Fatal(err)
+		}
+
+		email, err := cmd.Flags().GetString("email")
+		if err == nil {
+			if len(email) > 0 {
+				conf.Handler.Zulip.Email = email
+			}
+		} else {
+			logrus.Fatal(err)
+		}
+
+		apiKey, err := cmd.Flags().GetString("api-key")
+		if err == nil {
+			if len(apiKey) > 0 {
+				conf.Handler.Zulip.APIKey = apiKey
+			}
+		} else {
+			logrus.Fatal(err)
+		}
+
+		stream, err := cmd.Flags().GetString("stream")
+		if err == nil {
+			if len(stream) > 0 {
+				conf.Handler.Zulip.Stream = stream
+			}
+		} else {
+			logrus.Fatal(err)
+		}
+
+		topic, err := cmd.Flags().GetString("topic")
+		if err == nil {
+			if len(topic) > 0 {
+				conf.Handler.Zulip.Topic = topic
+			}
+		} else {
+			logrus.Fatal(err)
+		}
+
+		if err = conf.Write(); err != nil {
+			logrus.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	zulipConfigCmd.Flags().StringP("url", "u", "", "Specify Zulip server url")
+	zulipConfigCmd.Flags().StringP("email", "e", "", "Specify Zulip bot email")
+	zulipConfigCmd.Flags().StringP("api-key", "k", "", "Specify Zulip bot API key")
+	zulipConfigCmd.Flags().StringP("stream", "s", "", "Specify Zulip stream")
+	zulipConfigCmd.Flags().String("topic", "", "Specify Zulip topic, the namespace of the objects by default")
+}
@@ -0,0 +1,222 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v3"
+)
+
+// APIVersion is the version of the config schema, set by the apiVersion key.
+// The config files without it use the legacy schema, migrated when loaded.
+const APIVersion = "kubewatch.io/v1"
+
+// legacyKeys maps the keys of the legacy schema to the current ones, by
+// section. The handler names of the CLI, e.g. ms-teams, and the short names of
+// the resources, e.g. po, used to be silently ignored.
+var legacyKeys = map[string]map[string]string{
+	"handler": {
+		"ms-teams": "msteams",
+		"msteam":   "msteams",
+	},
+	"resource": {
+		"rc":                     "replicationcontroller",
+		"rs":                     "replicaset",
+		"ds":                     "daemonset",
+		"svc":                    "services",
+		"service":                "services",
+		"po":                     "pod",
+		"sa":                     "serviceaccount",
+		"pv":                     "persistentvolume",
+		"pvc":                    "persistentvolumeclaim",
+		"ns":                     "namespace",
+		"ing":                    "ingress",
+		"sts":                    "statefulset",
+		"hpa":                    "horizontalpodautoscaler",
+		"netpol":                 "networkpolicy",
+		"replication-controller": "replicationcontroller",
+	},
+}
+
+// Migrate converts a config file of the legacy schema to the current one,
+// renaming the legacy keys and setting the apiVersion. It returns the
+// changes made, none for a file of the current schema.
+func Migrate(b []byte) ([]byte, []string, error) {
+	doc, version, err := parseDocument(b)
+	if err != nil || doc == nil {
+		return b, nil, err
+	}
+	if version != "" {
+		if version != APIVersion {
+			return nil, nil, fmt.Errorf("unsupported apiVersion %q, must be %s", version, APIVersion)
+		}
+		return b, nil, nil
+	}
+
+	changes := migrate(doc.Content[0])
+	doc.Content[0].Content = append([]*yaml.Node{
+		{Kind: yaml.ScalarNode, Value: "apiVersion"},
+		{Kind: yaml.ScalarNode, Value: APIVersion},
+	}, doc.Content[0].Content...)
+	changes = append(changes, "set apiVersion to "+APIVersion)
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return nil, nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, nil, err
+	}
+	return buf.Bytes(), changes, nil
+}
+
+// decode decodes a config file into c, reading the values of the settings
+// referencing Secrets or files. The files of the current schema are
+// decoded strictly, failing with a *yaml.TypeError listing the keys which
+// are not settings; the ones of the legacy schema are migrated first, and
+// their unknown keys returned instead. The settings read are kept in c.
+func decode(b []byte, c *Config) (legacy []string, err error) {
+	doc, version, err := parseDocument(b)
+	if err != nil || doc == nil {
+		return nil, err
+	}
+	switch version {
+	case APIVersion, "":
+	default:
+		return nil, fmt.Errorf("unsupported apiVersion %q, must be %s", version, APIVersion)
+	}
+	// the legacy keys are renamed first, for the references of their
+	// settings to be resolved
+	if version == "" {
+		legacy = migrate(doc.Content[0])
+	}
+	if c.refs, err = resolveReferences(doc); err != nil {
+		return nil, err
+	}
+	if version == APIVersion {
+		return nil, decodeStrict(doc, c)
+	}
+
+	if err := decodeStrict(doc, &Config{refs: c.refs}); err != nil {
+		typeErr, ok := err.(*yaml.TypeError)
+		if !ok {
+			return nil, err
+		}
+		for _, e := range typeErr.Errors {
+			legacy = append(legacy, "ignored "+e)
+		}
+	}
+	return legacy, doc.Decode(c)
+}
+
+// decodeStrict decodes the document into c, failing on the keys which are
+// not settings.
+func decodeStrict(doc *yaml.Node, c *Config) error {
+	b, err := yaml.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	dec.KnownFields(true)
+	err = dec.Decode(c)
+	if err == io.EOF {
+		err = nil
+	}
+	// the resources are decoded by their own decoder, which doesn't know
+	// about strict decoding
+	if unknown := c.Resource.unknown; len(unknown) > 0 {
+		c.Resource.unknown = nil
+		typeErr, ok := err.(*yaml.TypeError)
+		if err != nil && !ok {
+			return err
+		}
+		if typeErr == nil {
+			typeErr = &yaml.TypeError{}
+		}
+		typeErr.Errors = append(typeErr.Errors, unknown...)
+		return typeErr
+	}
+	return err
+}
+
+// parseDocument parses a config file, returning its apiVersion. The
+// document is nil for an empty file.
+func parseDocument(b []byte) (*yaml.Node, string, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return nil, "", err
+	}
+	if len(doc.Content) == 0 {
+		return nil, "", nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, "", fmt.Errorf("line %d: the config must be a mapping", root.Line)
+	}
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == "apiVersion" {
+			return &doc, root.Content[i+1].Value, nil
+		}
+	}
+	return &doc, "", nil
+}
+
+// migrate renames the legacy keys of the config, returning the changes.
+func migrate(root *yaml.Node) []string {
+	var changes []string
+	rename := func(section *yaml.Node, path, name string) {
+		if section.Kind != yaml.MappingNode {
+			return
+		}
+		keys := map[string]bool{}
+		for i := 0; i < len(section.Content); i += 2 {
+			keys[section.Content[i].Value] = true
+		}
+		for i := 0; i < len(section.Content); i += 2 {
+			key := section.Content[i]
+			current, ok := legacyKeys[name][key.Value]
+			if !ok || keys[current] {
+				continue
+			}
+			changes = append(changes, fmt.Sprintf("line %d: renamed %s%s to %s%s", key.Line, path, key.Value, path, current))
+			key.Value = current
+			keys[current] = true
+		}
+	}
+
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i].Value, root.Content[i+1]
+		switch key {
+		case "handler", "resource":
+			rename(value, key+".", key)
+		case "handlers":
+			// the instances use the keys of the handler section
+			if value.Kind != yaml.SequenceNode {
+				continue
+			}
+			for j, instance := range value.Content {
+				rename(instance, fmt.Sprintf("handlers[%d].", j), "handler")
+			}
+		}
+	}
+	return changes
+}
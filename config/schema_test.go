@@ -0,0 +1,182 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+var legacyConfig = `handler:
+  msteam:
+    webhookurl: https://outlook.office.com/webhook/XXX
+handlers:
+  - name: teams
+    ms-teams:
+      webhookurl: https://outlook.office.com/webhook/YYY
+resource:
+  po: true
+  deployment: true
+`
+
+func TestMigrate(t *testing.T) {
+	b, changes, err := Migrate([]byte(legacyConfig))
+	if err != nil {
+		t.Fatalf("Migrate(): unexpected error %v", err)
+	}
+	want := []string{
+		"line 2: renamed handler.msteam to handler.msteams",
+		"line 6: renamed handlers[0].ms-teams to handlers[0].msteams",
+		"line 9: renamed resource.po to resource.pod",
+		"set apiVersion to " + APIVersion,
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Fatalf("Migrate(): got changes %q, want %q", changes, want)
+	}
+	if !strings.HasPrefix(string(b), "apiVersion: "+APIVersion+"\n") {
+		t.Fatalf("Migrate(): got\n%s\nwithout the apiVersion first", b)
+	}
+
+	// the migrated file is of the current schema, decoded strictly
+	c := &Config{}
+	legacy, err := decode(b, c)
+	if err != nil || len(legacy) > 0 {
+		t.Fatalf("decode(): got %q, %v for the migrated file", legacy, err)
+	}
+	if c.Handler.MSTeams.WebhookURL != "https://outlook.office.com/webhook/XXX" || len(c.Handlers) != 1 || c.Handlers[0].MSTeams.WebhookURL != "https://outlook.office.com/webhook/YYY" {
+		t.Fatalf("decode(): got the msteams handlers %+v and %+v", c.Handler.MSTeams, c.Handlers)
+	}
+	if !c.Resource.Pod || !c.Resource.Deployment {
+		t.Fatalf("decode(): got the resources %+v", c.Resource)
+	}
+
+	// migrating again changes nothing
+	again, changes, err := Migrate(b)
+	if err != nil || len(changes) > 0 || string(again) != string(b) {
+		t.Fatalf("Migrate(): got %q, %v migrating the migrated file", changes, err)
+	}
+}
+
+func TestMigrateKeepsCurrentKeys(t *testing.T) {
+	doc := "handler:\n  msteam:\n    webhookurl: old\n  msteams:\n    webhookurl: new\n"
+	var node yaml.Node
+	if err := yaml.Unmarshal([]byte(doc), &node); err != nil {
+		t.Fatal(err)
+	}
+	if changes := migrate(node.Content[0]); len(changes) > 0 {
+		t.Fatalf("migrate(): got %q, want the legacy key left as is", changes)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	var Tests = []struct {
+		doc    string
+		err    string
+		legacy []string
+	}{
+		{"", "", nil},
+		{"apiVersion: kubewatch.io/v1\nhandler:\n  slack:\n    token: xoxb\n", "", nil},
+		{"apiVersion: kubewatch.io/v1\nhandler:\n  slack:\n    token: xoxb\n    chanel: '#ops'\n", "line 5: field chanel not found", nil},
+		{"apiVersion: kubewatch.io/v1\nresource:\n  po: true\n", "line 3", nil},
+		{"apiVersion: kubewatch.io/v2\n", `unsupported apiVersion "kubewatch.io/v2"`, nil},
+		{"- handler\n", "line 1: the config must be a mapping", nil},
+		{"handler:\n  msteam:\n    webhookurl: https://example.com\n", "", []string{"line 2: renamed handler.msteam to handler.msteams"}},
+		{"handler:\n  slack:\n    chanel: '#ops'\n", "", []string{"ignored line 3: field chanel not found in type config.Slack"}},
+	}
+
+	for _, tt := range Tests {
+		legacy, err := decode([]byte(tt.doc), &Config{})
+		if tt.err == "" && err != nil {
+			t.Fatalf("decode(%q): unexpected error %v", tt.doc, err)
+		}
+		if tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+			t.Fatalf("decode(%q): got error %v, want %q", tt.doc, err, tt.err)
+		}
+		if !reflect.DeepEqual(legacy, tt.legacy) {
+			t.Fatalf("decode(%q): got the legacy changes %q, want %q", tt.doc, legacy, tt.legacy)
+		}
+	}
+
+	if _, _, err := Migrate([]byte("apiVersion: v1\n")); err == nil || !strings.Contains(err.Error(), "unsupported apiVersion") {
+		t.Fatalf("Migrate(): got error %v for an unsupported apiVersion", err)
+	}
+}
+
+func TestDecodeEventTypes(t *testing.T) {
+	var Tests = []struct {
+		doc     string
+		watched bool
+		want    EventTypes
+		err     string
+	}{
+		{"resource:\n  deployment: true\n", true, allEventTypes, ""},
+		{"resource:\n  deployment: false\n", false, allEventTypes, ""},
+		{"resource:\n  deployment: {create: true, update: false, delete: true}\n", true, EventTypes{Create: true, Delete: true}, ""},
+		{"resource:\n  deployment: {create: false, update: true, delete: false}\n", true, EventTypes{Update: true}, ""},
+		// the event types omitted are selected
+		{"resource:\n  deployment: {update: false}\n", true, EventTypes{Create: true, Delete: true}, ""},
+		{"resource:\n  deployment: {delete: true}\n", true, allEventTypes, ""},
+		{"resource:\n  deployment: {}\n", true, allEventTypes, ""},
+		// the resource is not watched when none is selected
+		{"resource:\n  deployment: {create: false, update: false, delete: false}\n", false, EventTypes{}, ""},
+		{"resource:\n  deployment: {create: true, updated: false}\n", false, EventTypes{}, `line 2: unknown event type "updated" of resource deployment`},
+		{"resource:\n  deployment: {create: yes please}\n", false, EventTypes{}, "cannot unmarshal"},
+	}
+
+	for _, tt := range Tests {
+		c := &Config{}
+		_, err := decode([]byte(tt.doc), c)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("decode(%q): got error %v, want %q", tt.doc, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("decode(%q): unexpected error %v", tt.doc, err)
+		}
+		if c.Resource.Deployment != tt.watched {
+			t.Fatalf("decode(%q): got the deployments watched %v, want %v", tt.doc, c.Resource.Deployment, tt.watched)
+		}
+		if got := c.Resource.EventTypes("deployment"); got != tt.want {
+			t.Fatalf("decode(%q): got the event types %+v, want %+v", tt.doc, got, tt.want)
+		}
+		if got := c.Resource.EventTypes("pod"); got != allEventTypes {
+			t.Fatalf("decode(%q): got the event types %+v of the pods, want all of them", tt.doc, got)
+		}
+
+		// the event types selected are kept when encoded again, the resources
+		// not watched being written as false
+		if !tt.watched {
+			continue
+		}
+		b, err := yaml.Marshal(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded := &Config{}
+		if _, err := decode(b, decoded); err != nil {
+			t.Fatalf("decode(%q): %v", b, err)
+		}
+		if got := decoded.Resource.EventTypes("deployment"); !decoded.Resource.Deployment || got != tt.want {
+			t.Fatalf("decode(%q): got the event types %+v encoded back, want %+v", tt.doc, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SecretKeyRef references a key of a Kubernetes Secret holding a setting,
+// set as {secretKeyRef: {name: kubewatch, key: token}} instead of its value
+type SecretKeyRef struct {
+	// Namespace of the Secret, defaults to the one of kubewatch.
+	Namespace string `json:"namespace" yaml:"namespace,omitempty"`
+	Name      string `json:"name" yaml:"name"`
+	Key       string `json:"key" yaml:"key"`
+}
+
+// References are the Secrets and the files the settings of a config are
+// read from, {fromFile: /path} referencing a file.
+type References struct {
+	Secrets []SecretKeyRef
+	Files   []string
+}
+
+// SecretResolver returns the value of the key of a Secret.
+type SecretResolver func(ref SecretKeyRef) (string, error)
+
+var secretResolver SecretResolver
+
+// SetSecretResolver sets the function reading the Secrets referenced by the
+// settings. Without it, the configs referencing Secrets fail to load.
+func SetSecretResolver(r SecretResolver) {
+	secretResolver = r
+}
+
+// resolveReferences replaces the references of the scalar settings of the
+// document by the values of the Secrets and files, returning them. The
+// mappings of the other settings, e.g. the labels or headers, are never
+// references, even with a single fromFile or secretKeyRef key.
+func resolveReferences(node *yaml.Node) (References, error) {
+	var refs References
+	var resolve func(node *yaml.Node, t reflect.Type) error
+	resolve = func(node *yaml.Node, t reflect.Type) error {
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		switch node.Kind {
+		case yaml.DocumentNode:
+			for _, n := range node.Content {
+				if err := resolve(n, t); err != nil {
+					return err
+				}
+			}
+		case yaml.SequenceNode:
+			if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+				return nil
+			}
+			for _, n := range node.Content {
+				if err := resolve(n, t.Elem()); err != nil {
+					return err
+				}
+			}
+		case yaml.MappingNode:
+			for i := 1; i < len(node.Content); i += 2 {
+				ft, ok := settingType(t, node.Content[i-1].Value)
+				if !ok {
+					continue
+				}
+				if !scalar(ft) {
+					if err := resolve(node.Content[i], ft); err != nil {
+						return err
+					}
+					continue
+				}
+				value, ok, err := refs.resolve(node.Content[i])
+				if err != nil {
+					return fmt.Errorf("line %d: %s: %v", node.Content[i].Line, node.Content[i-1].Value, err)
+				}
+				if ok {
+					*node.Content[i] = yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value, Line: node.Content[i].Line}
+				}
+			}
+		}
+		return nil
+	}
+	err := resolve(node, reflect.TypeOf(Config{}))
+	return refs, err
+}
+
+// settingType returns the type of the value of the key in a mapping decoded
+// into the type t, a struct or a map, false when the key is not a setting.
+func settingType(t reflect.Type, key string) (reflect.Type, bool) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Map:
+		return t.Elem(), true
+	case reflect.Struct:
+	default:
+		return nil, false
+	}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		tag := strings.Split(f.Tag.Get("yaml"), ",")
+		name := tag[0]
+		for _, option := range tag[1:] {
+			if option == "inline" {
+				if ft, ok := settingType(f.Type, key); ok {
+					return ft, true
+				}
+				name = "-"
+			}
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		if name == key {
+			return f.Type, true
+		}
+	}
+	return nil, false
+}
+
+// scalar reports whether the settings of the type are set by scalars, e.g.
+// the strings, numbers and booleans.
+func scalar(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array, reflect.Interface:
+		return false
+	}
+	return true
+}
+
+// resolve returns the value of the node when it is a reference, adding it
+// to refs.
+func (refs *References) resolve(node *yaml.Node) (string, bool, error) {
+	if node.Kind != yaml.MappingNode || len(node.Content) != 2 {
+		return "", false, nil
+	}
+	switch node.Content[0].Value {
+	case "secretKeyRef":
+		var ref SecretKeyRef
+		if err := node.Content[1].Decode(&ref); err != nil {
+			return "", true, err
+		}
+		if ref.Name == "" || ref.Key == "" {
+			return "", true, fmt.Errorf("secretKeyRef needs a name and a key")
+		}
+		if secretResolver == nil {
+			return "", true, fmt.Errorf("can not read Secret %s", ref.Name)
+		}
+		value, err := secretResolver(ref)
+		if err != nil {
+			return "", true, err
+		}
+		refs.Secrets = append(refs.Secrets, ref)
+		return value, true, nil
+	case "fromFile":
+		path := node.Content[1].Value
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return "", true, err
+		}
+		refs.Files = append(refs.Files, path)
+		return strings.TrimRight(string(b), "\r\n"), true, nil
+	}
+	return "", false, nil
+}
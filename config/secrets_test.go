@@ -0,0 +1,123 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestResolveReferences(t *testing.T) {
+	file, err := ioutil.TempFile("", "kubewatch-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	file.WriteString("Bearer XXX\n\n")
+	file.Close()
+
+	SetSecretResolver(func(ref SecretKeyRef) (string, error) {
+		if ref.Name != "kubewatch" {
+			return "", fmt.Errorf("secret %q not found", ref.Name)
+		}
+		return ref.Namespace + "/" + ref.Key, nil
+	})
+	defer SetSecretResolver(nil)
+
+	doc := fmt.Sprintf(`apiVersion: kubewatch.io/v1
+handler:
+  slack:
+    token:
+      secretKeyRef: {name: kubewatch, key: slack-token, namespace: monitoring}
+    channel: "#alerts"
+  webhook:
+    url: https://example.com/kubewatch
+    headers:
+      Authorization:
+        fromFile: %s
+handlers:
+  - name: hook
+    webhook:
+      url: https://example.com/hook
+      headers:
+        fromFile: /nonexistent
+externalLabels:
+  secretKeyRef: payments
+`, file.Name())
+
+	c := &Config{}
+	if _, err := decode([]byte(doc), c); err != nil {
+		t.Fatalf("decode(): unexpected error %v", err)
+	}
+	if c.Handler.Slack.Token != "monitoring/slack-token" {
+		t.Fatalf("decode(): got the token %q, want the one of the Secret", c.Handler.Slack.Token)
+	}
+	if got := c.Handler.Webhook.Headers["Authorization"]; got != "Bearer XXX" {
+		t.Fatalf("decode(): got the header %q, want the content of the file", got)
+	}
+	if got := c.Handlers[0].Webhook.Headers; !reflect.DeepEqual(got, map[string]string{"fromFile": "/nonexistent"}) {
+		t.Fatalf("decode(): got the headers %v, want the header named fromFile", got)
+	}
+	if got := c.ExternalLabels; !reflect.DeepEqual(got, map[string]string{"secretKeyRef": "payments"}) {
+		t.Fatalf("decode(): got the labels %v, want the label named secretKeyRef", got)
+	}
+	want := References{
+		Secrets: []SecretKeyRef{{Namespace: "monitoring", Name: "kubewatch", Key: "slack-token"}},
+		Files:   []string{file.Name()},
+	}
+	if !reflect.DeepEqual(c.References(), want) {
+		t.Fatalf("decode(): got the references %+v, want %+v", c.References(), want)
+	}
+
+	// the settings of the legacy keys are resolved once renamed
+	legacy := fmt.Sprintf("handler:\n  msteam:\n    webhookurl:\n      fromFile: %s\n", file.Name())
+	c = &Config{}
+	if _, err := decode([]byte(legacy), c); err != nil || c.Handler.MSTeams.WebhookURL != "Bearer XXX" {
+		t.Fatalf("decode(): got the webhook url %q, %v, want the content of the file", c.Handler.MSTeams.WebhookURL, err)
+	}
+}
+
+func TestResolveReferencesErrors(t *testing.T) {
+	var Tests = []struct {
+		doc string
+		err string
+	}{
+		{"handler:\n  slack:\n    token:\n      secretKeyRef: {name: kubewatch}\n", "line 4: token: secretKeyRef needs a name and a key"},
+		{"handler:\n  slack:\n    token:\n      secretKeyRef: {name: kubewatch, key: token}\n", "line 4: token: can not read Secret kubewatch"},
+		{"handler:\n  slack:\n    token:\n      secretKeyRef: [kubewatch]\n", "line 4: token"},
+		{"handler:\n  slack:\n    token:\n      fromFile: /nonexistent\n", "line 4: token: open /nonexistent"},
+	}
+
+	for _, tt := range Tests {
+		if _, err := decode([]byte(tt.doc), &Config{}); err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Fatalf("decode(%q): got error %v, want %q", tt.doc, err, tt.err)
+		}
+	}
+
+	SetSecretResolver(func(ref SecretKeyRef) (string, error) {
+		return "", fmt.Errorf("secrets %q is forbidden", ref.Name)
+	})
+	defer SetSecretResolver(nil)
+	doc := "handler:\n  slack:\n    token:\n      secretKeyRef: {name: kubewatch, key: token}\n"
+	if _, err := decode([]byte(doc), &Config{}); err == nil || !strings.Contains(err.Error(), `secrets "kubewatch" is forbidden`) {
+		t.Fatalf("decode(): got error %v, want the one of the resolver", err)
+	}
+}
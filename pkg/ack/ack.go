@@ -0,0 +1,192 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package ack serves the callbacks acknowledging notifications, which mute
+// the notified objects for a while.
+package ack
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/slack-go/slack"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+	"github.com/bitnami-labs/kubewatch/pkg/mute"
+	"github.com/sirupsen/logrus"
+)
+
+// SlackCallbackID identifies the Acknowledge buttons of the Slack messages.
+const SlackCallbackID = "kubewatch_ack"
+
+const defaultDuration = time.Hour
+
+var logger = logrus.WithField("pkg", "ack")
+
+// Target returns the target acknowledging the notification of an event.
+func Target(e event.Event) string {
+	return mute.Rule{Namespace: e.Namespace, Kind: e.Kind, Name: e.Name}.Target()
+}
+
+// Register adds the /ack and /slack/actions endpoints enabled by the config to the mux.
+func Register(mux *http.ServeMux, conf config.Ack) error {
+	duration := defaultDuration
+	if conf.Duration != "" {
+		var err error
+		if duration, err = time.ParseDuration(conf.Duration); err != nil {
+			return fmt.Errorf("invalid ack duration %q: %v", conf.Duration, err)
+		}
+		if duration <= 0 {
+			return fmt.Errorf("invalid ack duration %q: must be positive", conf.Duration)
+		}
+	}
+	if conf.Token != "" {
+		mux.Handle("/ack", &ackHandler{token: conf.Token, duration: duration})
+	}
+	if conf.SlackSigningSecret != "" {
+		mux.Handle("/slack/actions", &slackHandler{secret: conf.SlackSigningSecret, duration: duration})
+	}
+	return nil
+}
+
+// acknowledge mutes the target for the duration.
+func acknowledge(target string, duration time.Duration, by string) (mute.Rule, error) {
+	r, err := mute.ParseTarget(target)
+	if err != nil {
+		return r, err
+	}
+	r.Until = time.Now().Add(duration)
+	mute.Add(r)
+	logger.WithField("by", by).Infof("Muted %s until %s", r.Target(), r.Until.Format(time.RFC3339))
+	return r, nil
+}
+
+// ackHandler mutes the target form value, e.g. /ack?target=default/pod/web,
+// for the duration, which can be overridden by the duration form value.
+type ackHandler struct {
+	token    string
+	duration time.Duration
+}
+
+func (h *ackHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte("Bearer "+h.token)) != 1 {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	duration := h.duration
+	if d := r.FormValue("duration"); d != "" {
+		var err error
+		if duration, err = time.ParseDuration(d); err != nil || duration <= 0 {
+			http.Error(w, fmt.Sprintf("invalid duration %q", d), http.StatusBadRequest)
+			return
+		}
+	}
+	rule, err := acknowledge(r.FormValue("target"), duration, r.RemoteAddr)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	fmt.Fprintf(w, "Muted %s until %s\n", rule.Target(), rule.Until.UTC().Format(time.RFC3339))
+}
+
+// slackHandler mutes the targets of the Acknowledge buttons clicked in Slack.
+type slackHandler struct {
+	secret   string
+	duration time.Duration
+}
+
+func (h *slackHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	verifier, err := slack.NewSecretsVerifier(r.Header, h.secret)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, 1<<20))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	verifier.Write(body)
+	if err := verifier.Ensure(); err != nil {
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var callback slack.InteractionCallback
+	if err := json.Unmarshal([]byte(form.Get("payload")), &callback); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	target, ok := slackTarget(callback)
+	if !ok && callback.Type == slack.InteractionTypeBlockActions {
+		// the other buttons of the Block Kit messages open their URLs
+		return
+	}
+	if !ok {
+		http.Error(w, "unknown action", http.StatusBadRequest)
+		return
+	}
+	reply := ""
+	rule, err := acknowledge(target, h.duration, callback.User.Name)
+	if err != nil {
+		reply = err.Error()
+	} else {
+		reply = fmt.Sprintf("<@%s> muted `%s` until %s", callback.User.ID, rule.Target(), rule.Until.UTC().Format(time.RFC3339))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"response_type":    "in_channel",
+		"replace_original": false,
+		"text":             reply,
+	})
+}
+
+// slackTarget returns the target of the Acknowledge button clicked, in a
+// message formatted as an attachment or with Block Kit.
+func slackTarget(callback slack.InteractionCallback) (string, bool) {
+	if callback.Type == slack.InteractionTypeBlockActions {
+		for _, a := range callback.ActionCallback.BlockActions {
+			if a.ActionID == SlackCallbackID {
+				return a.Value, true
+			}
+		}
+		return "", false
+	}
+	// a click sends the action of the clicked button
+	actions := callback.ActionCallback.AttachmentActions
+	if callback.CallbackID != SlackCallbackID || len(actions) != 1 || actions[0].Name != "ack" {
+		return "", false
+	}
+	return actions[0].Value, true
+}
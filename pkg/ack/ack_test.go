@@ -0,0 +1,153 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ack
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+	"github.com/bitnami-labs/kubewatch/pkg/mute"
+)
+
+func TestAck(t *testing.T) {
+	mux := http.NewServeMux()
+	if err := Register(mux, config.Ack{Token: "secret"}); err != nil {
+		t.Fatal(err)
+	}
+	e := event.Event{Kind: "deployment", Namespace: "default", Name: "web"}
+
+	var Tests = []struct {
+		token  string
+		target string
+		status int
+	}{
+		{"wrong", Target(e), http.StatusUnauthorized},
+		{"secret", "web", http.StatusBadRequest},
+		{"secret", Target(e), http.StatusOK},
+	}
+	for _, tt := range Tests {
+		req := httptest.NewRequest("POST", "/ack?target="+url.QueryEscape(tt.target), nil)
+		req.Header.Set("Authorization", "Bearer "+tt.token)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		if w.Code != tt.status {
+			t.Fatalf("%s %s: got status %d, want %d", tt.token, tt.target, w.Code, tt.status)
+		}
+	}
+	if !mute.Muted(e) {
+		t.Fatal("the acknowledged object is not muted")
+	}
+	mute.Remove(Target(e))
+}
+
+func TestRegisterDuration(t *testing.T) {
+	var Tests = []struct {
+		duration string
+		err      string
+	}{
+		{"", ""},
+		{"4h", ""},
+		{"4 hours", `invalid ack duration "4 hours"`},
+		{"0s", `invalid ack duration "0s": must be positive`},
+		{"-1h", `invalid ack duration "-1h": must be positive`},
+	}
+	for _, tt := range Tests {
+		err := Register(http.NewServeMux(), config.Ack{Token: "secret", Duration: tt.duration})
+		if tt.err == "" && err != nil {
+			t.Fatalf("Register(%q): unexpected error %v", tt.duration, err)
+		}
+		if tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+			t.Fatalf("Register(%q): got error %v, want %q", tt.duration, err, tt.err)
+		}
+	}
+}
+
+func TestSlackAck(t *testing.T) {
+	mux := http.NewServeMux()
+	if err := Register(mux, config.Ack{SlackSigningSecret: "signing", Duration: "10m"}); err != nil {
+		t.Fatal(err)
+	}
+	e := event.Event{Kind: "pod", Namespace: "prod", Name: "api"}
+
+	payload := `{"type":"interactive_message","callback_id":"` + SlackCallbackID + `","user":{"id":"U1","name":"alice"},` +
+		`"actions":[{"name":"ack","type":"button","value":"` + Target(e) + `"}]}`
+
+	for _, secret := range []string{"wrong", "signing"} {
+		w := postSlackAction(mux, secret, payload)
+
+		if secret == "wrong" {
+			if w.Code != http.StatusUnauthorized || mute.Muted(e) {
+				t.Fatalf("invalid signature: got status %d", w.Code)
+			}
+			continue
+		}
+		if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "muted `prod/pod/api`") {
+			t.Fatalf("got %d %s", w.Code, w.Body.String())
+		}
+	}
+	if !mute.Muted(e) {
+		t.Fatal("the acknowledged object is not muted")
+	}
+	mute.Remove(Target(e))
+}
+
+func TestSlackBlockAck(t *testing.T) {
+	mux := http.NewServeMux()
+	if err := Register(mux, config.Ack{SlackSigningSecret: "signing", Duration: "10m"}); err != nil {
+		t.Fatal(err)
+	}
+	e := event.Event{Kind: "pod", Namespace: "prod", Name: "web"}
+
+	link := `{"type":"block_actions","user":{"id":"U1","name":"alice"},"actions":[{"action_id":"kubewatch_link_0","block_id":"b1","type":"button"}]}`
+	if w := postSlackAction(mux, "signing", link); w.Code != http.StatusOK || w.Body.Len() != 0 || mute.Muted(e) {
+		t.Fatalf("link button: got %d %s", w.Code, w.Body.String())
+	}
+
+	payload := `{"type":"block_actions","user":{"id":"U1","name":"alice"},` +
+		`"actions":[{"action_id":"` + SlackCallbackID + `","block_id":"b1","type":"button","value":"` + Target(e) + `"}]}`
+	if w := postSlackAction(mux, "signing", payload); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "muted `prod/pod/web`") {
+		t.Fatalf("got %d %s", w.Code, w.Body.String())
+	}
+	if !mute.Muted(e) {
+		t.Fatal("the acknowledged object is not muted")
+	}
+	mute.Remove(Target(e))
+}
+
+// postSlackAction posts the payload of an interaction signed with the secret.
+func postSlackAction(mux *http.ServeMux, secret, payload string) *httptest.ResponseRecorder {
+	body := url.Values{"payload": {payload}}.Encode()
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + ts + ":" + body))
+	req := httptest.NewRequest("POST", "/slack/actions", strings.NewReader(body))
+	req.Header.Set("X-Slack-Request-Timestamp", ts)
+	req.Header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(mac.Sum(nil)))
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	return w
+}
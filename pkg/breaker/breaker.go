@@ -0,0 +1,111 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package breaker implements the circuit breakers stopping the deliveries to
+// the failing handlers, probing them again after a cooldown.
+package breaker
+
+import (
+	"sync"
+	"time"
+)
+
+// State is the state of the circuit of a breaker.
+type State int
+
+const (
+	// Closed lets the deliveries through.
+	Closed State = iota
+	// Open stops the deliveries until the end of the cooldown.
+	Open
+	// HalfOpen lets a single delivery through, probing the handler.
+	HalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	}
+	return "closed"
+}
+
+// Breaker opens its circuit after a number of consecutive failures, and
+// lets a probe through once the cooldown is over, closing it again when the
+// probe succeeds.
+type Breaker struct {
+	failures int
+	cooldown time.Duration
+
+	mu       sync.Mutex
+	state    State
+	failed   int
+	openedAt time.Time
+}
+
+// New returns a closed breaker opening after the consecutive failures.
+func New(failures int, cooldown time.Duration) *Breaker {
+	return &Breaker{failures: failures, cooldown: cooldown}
+}
+
+// Allow returns whether a delivery may be attempted at now. Once the
+// cooldown of the open circuit is over, the first delivery is allowed as a
+// probe, the others waiting for its result.
+func (b *Breaker) Allow(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case Closed:
+		return true
+	case Open:
+		if now.Sub(b.openedAt) >= b.cooldown {
+			b.state = HalfOpen
+			return true
+		}
+	}
+	return false
+}
+
+// Record records the result of an allowed delivery, returning the state of
+// the circuit and whether it changed.
+func (b *Breaker) Record(ok bool, now time.Time) (State, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	previous := b.state
+	switch {
+	case ok && b.state != Open:
+		b.state, b.failed = Closed, 0
+	case !ok && b.state == HalfOpen:
+		b.state, b.openedAt = Open, now
+	case !ok && b.state == Closed:
+		b.failed++
+		if b.failed >= b.failures {
+			b.state, b.openedAt = Open, now
+		}
+	}
+	return b.state, b.state != previous
+}
+
+// State returns the state of the circuit.
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package breaker
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBreaker(t *testing.T) {
+	now := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	b := New(3, time.Minute)
+
+	var Tests = []struct {
+		after   time.Duration
+		allowed bool
+		ok      bool
+		state   State
+		changed bool
+	}{
+		{0, true, false, Closed, false},
+		{time.Second, true, true, Closed, false},
+		// the failures must be consecutive
+		{2 * time.Second, true, false, Closed, false},
+		{3 * time.Second, true, false, Closed, false},
+		{4 * time.Second, true, false, Open, true},
+		{30 * time.Second, false, false, Open, false},
+		// the probe fails, opening the circuit for another cooldown
+		{64 * time.Second, true, false, Open, true},
+		{2 * time.Minute, false, false, Open, false},
+		{125 * time.Second, true, true, Closed, true},
+		{126 * time.Second, true, false, Closed, false},
+	}
+
+	for i, tt := range Tests {
+		at := now.Add(tt.after)
+		if allowed := b.Allow(at); allowed != tt.allowed {
+			t.Fatalf("%d: Allow(): got %v, want %v", i, allowed, tt.allowed)
+		}
+		if !tt.allowed {
+			if b.State() != tt.state {
+				t.Fatalf("%d: got state %s, want %s", i, b.State(), tt.state)
+			}
+			continue
+		}
+		if state, changed := b.Record(tt.ok, at); state != tt.state || changed != tt.changed {
+			t.Fatalf("%d: Record(%v): got %s, %v, want %s, %v", i, tt.ok, state, changed, tt.state, tt.changed)
+		}
+	}
+}
+
+func TestBreakerProbe(t *testing.T) {
+	now := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	b := New(1, time.Minute)
+	b.Allow(now)
+	b.Record(false, now)
+	// the late results of the deliveries allowed before the circuit opened
+	// leave it as is
+	if state, changed := b.Record(true, now.Add(time.Second)); state != Open || changed {
+		t.Fatalf("late success: got %s, %v", state, changed)
+	}
+	b.Record(false, now.Add(time.Second))
+
+	later := now.Add(time.Minute)
+	if !b.Allow(later) || b.State() != HalfOpen {
+		t.Fatalf("first delivery after the cooldown: got state %s, want a probe", b.State())
+	}
+	if b.Allow(later) {
+		t.Fatal("second delivery after the cooldown: allowed while probing")
+	}
+	if state, changed := b.Record(true, later); state != Closed || !changed {
+		t.Fatalf("probe succeeded: got %s, %v", state, changed)
+	}
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package certs finds the expiry of the certificates of TLS secrets.
+package certs
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// Expiry is the end of the validity of a certificate.
+type Expiry struct {
+	// Subject is the common name of the certificate, or its first DNS name.
+	Subject  string
+	NotAfter time.Time
+}
+
+// FromPEM returns the expiry of the certificate of the PEM bundle expiring
+// first, e.g. the leaf certificate or an intermediate CA of a chain.
+func FromPEM(data []byte) (Expiry, error) {
+	var first *x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return Expiry{}, err
+		}
+		if first == nil || cert.NotAfter.Before(first.NotAfter) {
+			first = cert
+		}
+	}
+	if first == nil {
+		return Expiry{}, errors.New("no certificate found")
+	}
+
+	subject := first.Subject.CommonName
+	if subject == "" && len(first.DNSNames) > 0 {
+		subject = first.DNSNames[0]
+	}
+	return Expiry{Subject: subject, NotAfter: first.NotAfter}, nil
+}
+
+// Days returns the number of days left before the expiry, rounded down,
+// negative once expired.
+func (e Expiry) Days(now time.Time) int {
+	return int(math.Floor(e.NotAfter.Sub(now).Hours() / 24))
+}
+
+// Description describes the expiry in a sentence.
+func (e Expiry) Description(now time.Time) string {
+	var b strings.Builder
+	b.WriteString("Certificate")
+	if e.Subject != "" {
+		fmt.Fprintf(&b, " `%s`", e.Subject)
+	}
+	date := e.NotAfter.UTC().Format("2006-01-02 15:04 MST")
+	switch days := e.Days(now); {
+	case e.NotAfter.Before(now):
+		fmt.Fprintf(&b, " expired on %s", date)
+	case days == 0:
+		fmt.Fprintf(&b, " expires today, on %s", date)
+	case days == 1:
+		fmt.Fprintf(&b, " expires in 1 day, on %s", date)
+	default:
+		fmt.Fprintf(&b, " expires in %d days, on %s", days, date)
+	}
+	return b.String()
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"github.com/bitnami-labs/kubewatch/pkg/handlers"
+	"github.com/bitnami-labs/kubewatch/pkg/handlers/alertmanager"
+	"github.com/bitnami-labs/kubewatch/pkg/handlers/archive"
+	"github.com/bitnami-labs/kubewatch/pkg/handlers/aws"
+	"github.com/bitnami-labs/kubewatch/pkg/handlers/database"
+	"github.com/bitnami-labs/kubewatch/pkg/handlers/datadog"
+	"github.com/bitnami-labs/kubewatch/pkg/handlers/dingtalk"
+	"github.com/bitnami-labs/kubewatch/pkg/handlers/elasticsearch"
+	"github.com/bitnami-labs/kubewatch/pkg/handlers/exec"
+	"github.com/bitnami-labs/kubewatch/pkg/handlers/flock"
+	"github.com/bitnami-labs/kubewatch/pkg/handlers/github"
+	"github.com/bitnami-labs/kubewatch/pkg/handlers/googlechat"
+	"github.com/bitnami-labs/kubewatch/pkg/handlers/grpc"
+	"github.com/bitnami-labs/kubewatch/pkg/handlers/hipchat"
+	"github.com/bitnami-labs/kubewatch/pkg/handlers/jira"
+	"github.com/bitnami-labs/kubewatch/pkg/handlers/kafka"
+	"github.com/bitnami-labs/kubewatch/pkg/handlers/lark"
+	"github.com/bitnami-labs/kubewatch/pkg/handlers/loki"
+	"github.com/bitnami-labs/kubewatch/pkg/handlers/mattermost"
+	"github.com/bitnami-labs/kubewatch/pkg/handlers/mqtt"
+	"github.com/bitnami-labs/kubewatch/pkg/handlers/msteam"
+	"github.com/bitnami-labs/kubewatch/pkg/handlers/nats"
+	"github.com/bitnami-labs/kubewatch/pkg/handlers/ntfy"
+	"github.com/bitnami-labs/kubewatch/pkg/handlers/opsgenie"
+	"github.com/bitnami-labs/kubewatch/pkg/handlers/pubsub"
+	"github.com/bitnami-labs/kubewatch/pkg/handlers/rocketchat"
+	"github.com/bitnami-labs/kubewatch/pkg/handlers/servicenow"
+	"github.com/bitnami-labs/kubewatch/pkg/handlers/slack"
+	"github.com/bitnami-labs/kubewatch/pkg/handlers/smtp"
+	"github.com/bitnami-labs/kubewatch/pkg/handlers/syslog"
+	"github.com/bitnami-labs/kubewatch/pkg/handlers/telegram"
+	"github.com/bitnami-labs/kubewatch/pkg/handlers/webex"
+	"github.com/bitnami-labs/kubewatch/pkg/handlers/webhook"
+	"github.com/bitnami-labs/kubewatch/pkg/handlers/zulip"
+)
+
+// Handlers maps each bundled event handler to a name for easily lookup
+var Handlers = map[string]interface{}{
+	"default":       &handlers.Default{},
+	"slack":         &slack.Slack{},
+	"hipchat":       &hipchat.Hipchat{},
+	"mattermost":    &mattermost.Mattermost{},
+	"flock":         &flock.Flock{},
+	"webhook":       &webhook.Webhook{},
+	"ms-teams":      &msteam.MSTeams{},
+	"smtp":          &smtp.SMTP{},
+	"opsgenie":      &opsgenie.OpsGenie{},
+	"kafka":         &kafka.Kafka{},
+	"nats":          &nats.NATS{},
+	"aws":           &aws.AWS{},
+	"pubsub":        &pubsub.PubSub{},
+	"rocketchat":    &rocketchat.RocketChat{},
+	"telegram":      &telegram.Telegram{},
+	"googlechat":    &googlechat.GoogleChat{},
+	"grpc":          &grpc.GRPC{},
+	"syslog":        &syslog.Syslog{},
+	"elasticsearch": &elasticsearch.Elasticsearch{},
+	"loki":          &loki.Loki{},
+	"exec":          &exec.Exec{},
+	"jira":          &jira.Jira{},
+	"servicenow":    &servicenow.ServiceNow{},
+	"github":        &github.GitHub{},
+	"alertmanager":  &alertmanager.Alertmanager{},
+	"datadog":       &datadog.Datadog{},
+	"webex":         &webex.Webex{},
+	"zulip":         &zulip.Zulip{},
+	"ntfy":          &ntfy.Ntfy{},
+	"mqtt":          &mqtt.MQTT{},
+	"database":      &database.Database{},
+	"archive":       &archive.Archive{},
+	"lark":          &lark.Lark{},
+	"dingtalk":      &dingtalk.DingTalk{},
+}
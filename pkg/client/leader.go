@@ -0,0 +1,125 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/sirupsen/logrus"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+const (
+	defaultLeaseName = "kubewatch"
+	namespaceFile    = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+)
+
+// runLeaderElection calls run once this replica holds the lease and exits
+// when the lease is lost, so a standby replica can take over.
+func runLeaderElection(kubeClient kubernetes.Interface, conf config.LeaderElection, run func()) {
+	name := conf.LeaseName
+	if name == "" {
+		name = defaultLeaseName
+	}
+	namespace := conf.Namespace
+	if namespace == "" {
+		namespace = podNamespace()
+	}
+	identity, err := os.Hostname()
+	if err != nil || identity == "" {
+		identity = string(uuid.NewUUID())
+	}
+
+	lock := &resourcelock.LeaseLock{
+		LeaseMeta: meta_v1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Client: kubeClient.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{
+			Identity: identity,
+		},
+	}
+
+	// once leading, run handles the termination, sending the pending
+	// notifications before the lease is released
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	leading := make(chan struct{})
+	sigterm := make(chan os.Signal, 1)
+	signal.Notify(sigterm, syscall.SIGTERM, syscall.SIGINT)
+	go func() {
+		<-sigterm
+		select {
+		case <-leading:
+		default:
+			cancel()
+		}
+	}()
+
+	logrus.Infof("Waiting for leadership of lease %s/%s as %s", namespace, name, identity)
+	leaderelection.RunOrDie(ctx, leaderelection.LeaderElectionConfig{
+		Lock:            lock,
+		ReleaseOnCancel: true,
+		LeaseDuration:   15 * time.Second,
+		RenewDeadline:   10 * time.Second,
+		RetryPeriod:     2 * time.Second,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(context.Context) {
+				logrus.Infof("Started leading lease %s/%s", namespace, name)
+				close(leading)
+				run()
+				cancel()
+			},
+			OnStoppedLeading: func() {
+				if ctx.Err() == nil {
+					logrus.Fatalf("Lost leadership of lease %s/%s", namespace, name)
+				}
+				logrus.Infof("Released lease %s/%s", namespace, name)
+			},
+			OnNewLeader: func(leader string) {
+				if leader != identity {
+					logrus.Infof("Current leader is %s", leader)
+				}
+			},
+		},
+	})
+}
+
+// podNamespace returns the namespace kubewatch runs in, "default" out of cluster.
+func podNamespace() string {
+	if ns := os.Getenv("POD_NAMESPACE"); ns != "" {
+		return ns
+	}
+	if data, err := ioutil.ReadFile(namespaceFile); err == nil {
+		if ns := strings.TrimSpace(string(data)); ns != "" {
+			return ns
+		}
+	}
+	return "default"
+}
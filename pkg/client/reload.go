@@ -0,0 +1,229 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/controller"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+	"github.com/bitnami-labs/kubewatch/pkg/handlers"
+	"github.com/bitnami-labs/kubewatch/pkg/metrics"
+	"github.com/fsnotify/fsnotify"
+	"github.com/sirupsen/logrus"
+)
+
+// reloadDelay groups the bursts of file changes, e.g. of a ConfigMap update.
+const reloadDelay = time.Second
+
+// watch runs the controllers until the process is terminated, restarting them
+// with new handlers, filters and resources whenever the config file, or the
+// Secrets and files its settings are read from, change. On SIGHUP, the
+// watched objects are listed again.
+// The server, ack, receiver, tracing, dry run, leader election and Slack
+// commands settings are only read at start.
+func watch(conf *config.Config, eventHandler handlers.Handler) {
+	sigterm := make(chan os.Signal, 1)
+	signal.Notify(sigterm, syscall.SIGTERM, syscall.SIGINT)
+	sighup := make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+
+	changes := make(chan struct{}, 1)
+	watchConfigFile(config.FilePath(), changes)
+	watched := map[string]bool{config.FilePath(): true}
+
+	for {
+		stopCh := make(chan struct{})
+		done := make(chan struct{})
+		watchReferences(conf.References(), watched, changes, stopCh)
+		go func(conf *config.Config, eventHandler handlers.Handler) {
+			// the handlers are stopped once the controllers processed their
+			// last events, sending their pending notifications
+			handlersStop := make(chan struct{})
+			var wg sync.WaitGroup
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				handlers.Run(eventHandler, handlersStop)
+			}()
+			setReceivedHandler(eventHandler)
+			controller.Watch(conf, eventHandler, stopCh)
+			setReceivedHandler(nil)
+			close(handlersStop)
+			wg.Wait()
+			close(done)
+		}(conf, eventHandler)
+
+		for reloaded := false; !reloaded; {
+			select {
+			case <-sigterm:
+				logrus.Info("Stopping, sending the pending notifications")
+				close(stopCh)
+				timeout := drainTimeout(conf.Shutdown)
+				select {
+				case <-done:
+				case <-time.After(timeout):
+					logrus.Warnf("Notifications still pending after %s, exiting", timeout)
+				}
+				return
+			case <-sighup:
+				go relist(eventHandler)
+			case <-changes:
+				newConf, newHandler, err := reload(conf)
+				if err != nil {
+					logrus.Errorf("Can not reload config file, keeping the current config: %v", err)
+					metrics.ConfigReloads.WithLabelValues("failure").Inc()
+					continue
+				}
+				close(stopCh)
+				<-done
+				conf, eventHandler = newConf, newHandler
+				reloaded = true
+				logrus.Info("Config file reloaded")
+				metrics.ConfigReloads.WithLabelValues("success").Inc()
+			}
+		}
+	}
+}
+
+// relistTimeout is the time given to the informers to list their objects
+// again.
+const relistTimeout = time.Minute
+
+// relist lists the watched objects again, logging and notifying the report
+// of their reconciliation with the caches of the informers. The informers
+// notify the changes they missed.
+func relist(eventHandler handlers.Handler) {
+	logrus.Info("Listing the watched objects again")
+	reconciliations := controller.Relist(relistTimeout)
+	for _, r := range reconciliations {
+		if r.Err != nil {
+			logrus.Warnf("Can not reconcile %s", r)
+		} else {
+			logrus.Infof("Reconciled %s", r)
+		}
+	}
+	eventHandler.Handle(event.Event{
+		Kind:    event.ReconciliationKind,
+		Reason:  "Reconciled",
+		Status:  "Normal",
+		Details: "Reconciliation of the watched objects:\n" + controller.Report(reconciliations),
+	})
+}
+
+// defaultDrainTimeout is the time given to the pending notifications when
+// the drain timeout isn't set.
+const defaultDrainTimeout = 20 * time.Second
+
+// drainTimeout returns the time given to the pending notifications to be sent
+// on termination.
+func drainTimeout(conf config.Shutdown) time.Duration {
+	if conf.DrainTimeout == "" {
+		return defaultDrainTimeout
+	}
+	d, err := time.ParseDuration(conf.DrainTimeout)
+	if err != nil || d <= 0 {
+		logrus.Warnf("Invalid drain timeout %q, using %s", conf.DrainTimeout, defaultDrainTimeout)
+		return defaultDrainTimeout
+	}
+	return d
+}
+
+// reload loads the config file and initializes its handlers.
+func reload(current *config.Config) (*config.Config, handlers.Handler, error) {
+	conf, err := config.New()
+	if err != nil {
+		return nil, nil, err
+	}
+	conf.CheckMissingResourceEnvvars()
+	conf.Server = current.Server
+	conf.LeaderElection = current.LeaderElection
+	conf.Ack = current.Ack
+	conf.Receiver = current.Receiver
+	conf.Tracing = current.Tracing
+	conf.Proxy = current.Proxy
+	conf.DryRun = current.DryRun
+	if slackBotChanged(current, conf) {
+		logrus.Warn("The settings of the Slack commands changed, restart kubewatch to apply them")
+	}
+	conf.Slackbot = current.Slackbot
+
+	eventHandler, err := buildEventHandler(conf)
+	if err != nil {
+		return nil, nil, err
+	}
+	return conf, eventHandler, nil
+}
+
+// slackBotChanged reports whether the settings of the Slack commands, only
+// read at start, changed.
+func slackBotChanged(current, conf *config.Config) bool {
+	return current.Handler.Slack.Token != conf.Handler.Slack.Token ||
+		current.Handler.Slack.AppToken != conf.Handler.Slack.AppToken ||
+		!reflect.DeepEqual(current.Slackbot, conf.Slackbot)
+}
+
+// watchConfigFile notifies changes when the config file is written or
+// replaced. The directory is watched since mounted ConfigMaps are updated by
+// swapping symlinks.
+func watchConfigFile(path string, changes chan<- struct{}) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		logrus.Warnf("Can not watch config file, reload disabled: %v", err)
+		return
+	}
+	dir := filepath.Dir(path)
+	if err := watcher.Add(dir); err != nil {
+		logrus.Warnf("Can not watch config directory %s, reload disabled: %v", dir, err)
+		watcher.Close()
+		return
+	}
+
+	go func() {
+		var timer <-chan time.Time
+		for {
+			select {
+			case ev, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				name := filepath.Base(ev.Name)
+				if name == filepath.Base(path) || name == "..data" {
+					timer = time.After(reloadDelay)
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				logrus.Warnf("Error watching config file: %v", err)
+			case <-timer:
+				timer = nil
+				select {
+				case changes <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}()
+}
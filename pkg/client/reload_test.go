@@ -0,0 +1,139 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+)
+
+// withConfigFile writes the config file of the test, read from $KW_CONFIG.
+func withConfigFile(t *testing.T, doc string) func() {
+	dir, err := ioutil.TempDir("", "kubewatch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, config.ConfigFileName), []byte(doc), 0600); err != nil {
+		t.Fatal(err)
+	}
+	previous, set := os.LookupEnv("KW_CONFIG")
+	os.Setenv("KW_CONFIG", dir)
+	return func() {
+		if set {
+			os.Setenv("KW_CONFIG", previous)
+		} else {
+			os.Unsetenv("KW_CONFIG")
+		}
+		os.RemoveAll(dir)
+	}
+}
+
+var reloadedConfig = `apiVersion: kubewatch.io/v1
+namespace: shop
+handler:
+  webhook:
+    url: http://127.0.0.1:2
+resource:
+  pod: true
+server:
+  address: ":9090"
+leaderElection:
+  enabled: false
+ack:
+  duration: 2h
+receiver:
+  token: new-token
+tracing:
+  endpoint: collector:4317
+proxy:
+  url: http://proxy.example.com:3128
+dryRun: false
+slackbot:
+  allowedUsers: [U045EF6GH]
+`
+
+func TestReload(t *testing.T) {
+	defer withConfigFile(t, reloadedConfig)()
+	current := &config.Config{
+		Handler:        hookHandler,
+		Resource:       config.Resource{Deployment: true},
+		Server:         config.Server{Address: ":8080"},
+		LeaderElection: config.LeaderElection{Enabled: true},
+		Ack:            config.Ack{Duration: "1h"},
+		Receiver:       config.Receiver{Token: "token"},
+		Tracing:        config.Tracing{Endpoint: "localhost:4317"},
+		Proxy:          config.Proxy{URL: "http://127.0.0.1:3128"},
+		DryRun:         true,
+		Slackbot:       config.Slackbot{AllowedUsers: []string{"U012AB3CD"}},
+	}
+	hook := test.NewGlobal()
+	defer logrus.StandardLogger().ReplaceHooks(logrus.LevelHooks{})
+
+	conf, eventHandler, err := reload(current)
+	if err != nil {
+		t.Fatalf("reload(): %v", err)
+	}
+	if eventHandler == nil {
+		t.Fatal("reload(): no handler")
+	}
+
+	// the settings only read at start are carried over
+	if conf.Server != current.Server || conf.LeaderElection != current.LeaderElection ||
+		!reflect.DeepEqual(conf.Ack, current.Ack) || !reflect.DeepEqual(conf.Receiver, current.Receiver) ||
+		!reflect.DeepEqual(conf.Tracing, current.Tracing) || !reflect.DeepEqual(conf.Proxy, current.Proxy) ||
+		conf.DryRun != current.DryRun || !reflect.DeepEqual(conf.Slackbot, current.Slackbot) {
+		t.Fatalf("reload(): got %+v, want the settings read at start of %+v", conf, current)
+	}
+	// while the others are reloaded
+	if conf.Namespace != "shop" || conf.Handler.Webhook.Url != "http://127.0.0.1:2" || !conf.Resource.Pod || conf.Resource.Deployment {
+		t.Fatalf("reload(): got %+v, want the settings of the config file", conf)
+	}
+
+	var warned bool
+	for _, entry := range hook.AllEntries() {
+		warned = warned || entry.Level == logrus.WarnLevel && entry.Message == "The settings of the Slack commands changed, restart kubewatch to apply them"
+	}
+	if !warned {
+		t.Fatal("reload(): the change of the Slack commands settings was not logged")
+	}
+}
+
+func TestSlackBotChanged(t *testing.T) {
+	var Tests = []struct {
+		conf config.Config
+		want bool
+	}{
+		{config.Config{}, false},
+		{config.Config{Handler: config.Handler{Webhook: config.Webhook{Url: "http://127.0.0.1:2"}}}, false},
+		{config.Config{Handler: config.Handler{Slack: config.Slack{Token: "xoxb-2"}}}, true},
+		{config.Config{Handler: config.Handler{Slack: config.Slack{AppToken: "xapp-2"}}}, true},
+		{config.Config{Slackbot: config.Slackbot{AllowedChannels: []string{"#ops"}}}, true},
+	}
+
+	for _, tt := range Tests {
+		if got := slackBotChanged(&config.Config{}, &tt.conf); got != tt.want {
+			t.Fatalf("slackBotChanged(%+v): got %v, want %v", tt.conf, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+	"github.com/bitnami-labs/kubewatch/pkg/filter"
+	"github.com/bitnami-labs/kubewatch/pkg/handlers"
+	"github.com/bitnami-labs/kubewatch/pkg/schedule"
+	"github.com/bitnami-labs/kubewatch/pkg/utils"
+)
+
+// unrenderedTypes are the handler types not sending their notifications as
+// HTTP requests, or not through the clients of utils.HTTPClient, which can
+// not be rendered without sending them.
+var unrenderedTypes = map[string]bool{
+	"aws":      true,
+	"database": true,
+	"exec":     true,
+	"grpc":     true,
+	"hipchat":  true,
+	"kafka":    true,
+	"mqtt":     true,
+	"nats":     true,
+	"pubsub":   true,
+	"smtp":     true,
+	"syslog":   true,
+}
+
+// renderedResponse is the body of the responses to the requests rendered,
+// accepted by the handlers checking the result of their requests.
+const renderedResponse = `{"ok":true,"code":0,"errcode":0,"StatusCode":0,"success":true}`
+
+// Rendering is the outcome of rendering an event with a handler instance.
+type Rendering struct {
+	Name string
+	// Type of the handler, e.g. slack.
+	Type string
+	// Event is the event as passed to the handlers, with its severity, the
+	// cluster name and the external labels of the config.
+	Event event.Event
+	// Routed are the names of the instances the routes and filters dispatch
+	// the event to.
+	Routed []string
+	// Requests are the HTTP requests of the handler, not sent.
+	Requests []RenderedRequest
+	// Err is the error of the handler rendering the event, e.g. when it
+	// expects another response.
+	Err error
+}
+
+// RenderedRequest is an HTTP request of a handler.
+type RenderedRequest struct {
+	Method string
+	URL    string
+	Header http.Header
+	Body   []byte
+}
+
+// Render initializes the named handler instance, "default" being the handler
+// of the handler section, and returns the HTTP requests it sends for the
+// event without sending them, answering them with a success. The event is
+// classified and routed like the events of the cluster, but the quiet hours,
+// digests, batches, mutes and flapping detection are not applied.
+func Render(conf *config.Config, name string, e event.Event) (*Rendering, error) {
+	var instance *config.HandlerInstance
+	for _, i := range handlerInstances(conf) {
+		if i.Name == name {
+			instance = &i
+			break
+		}
+	}
+	if instance == nil {
+		return nil, fmt.Errorf("unknown handler instance %q", name)
+	}
+	r := &Rendering{Name: name, Type: handlerType(newEventHandler(instance.Handler))}
+	if unrenderedTypes[r.Type] {
+		return nil, fmt.Errorf("the %s handler does not send HTTP requests, its notifications can not be rendered", r.Type)
+	}
+
+	var classified handlers.Handler = handlers.Func(func(e event.Event) {
+		r.Event = e
+	})
+	severity := &handlers.Severity{Rules: conf.Severities}
+	severity.Handler = classified
+	external := &handlers.External{Cluster: conf.ClusterName, Labels: conf.ExternalLabels}
+	external.Handler = severity
+	external.Handle(e)
+
+	routed, err := routedInstances(conf, r.Event)
+	if err != nil {
+		return nil, err
+	}
+	r.Routed = routed
+
+	capture := &captureTransport{}
+	utils.SetTransport(capture)
+	defer utils.SetTransport(nil)
+	h, err := initInstance(conf, *instance)
+	if err != nil {
+		return nil, err
+	}
+	_, r.Err = deliver(h, r.Event)
+	r.Requests = capture.requests
+	return r, nil
+}
+
+// routedInstances returns the names of the instances the routes and filters
+// of the config, and of the tenants of the namespace of the event, dispatch
+// the event to.
+func routedInstances(conf *config.Config, e event.Event) ([]string, error) {
+	group := &handlers.Group{Routes: conf.Routes}
+	for i, route := range conf.Routes {
+		q, err := schedule.New(route.QuietHours)
+		if err != nil {
+			return nil, fmt.Errorf("route %d: %v", i, err)
+		}
+		group.RouteQuietHours = append(group.RouteQuietHours, q)
+	}
+	if _, ok := newEventHandler(conf.Handler).(*handlers.Default); !ok {
+		group.Instances = append(group.Instances, handlers.Instance{Name: "default"})
+	}
+	for _, instance := range conf.Handlers {
+		group.Instances = append(group.Instances, handlers.Instance{Name: instance.Name, Filter: instance.Filter})
+	}
+	routed := group.Routed(e)
+
+	for _, tenant := range conf.Tenants {
+		if !filter.Match(config.Filter{Namespaces: tenant.Namespaces}, e) {
+			continue
+		}
+		tenantConf, err := tenantConfig(conf, tenant)
+		if err != nil {
+			return nil, err
+		}
+		names, err := routedInstances(tenantConf, e)
+		if err != nil {
+			return nil, fmt.Errorf("tenant %q: %v", tenant.Name, err)
+		}
+		routed = append(routed, names...)
+	}
+	return routed, nil
+}
+
+// captureTransport records the requests instead of sending them.
+type captureTransport struct {
+	mu       sync.Mutex
+	requests []RenderedRequest
+}
+
+func (t *captureTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	t.mu.Lock()
+	t.requests = append(t.requests, RenderedRequest{
+		Method: req.Method,
+		URL:    req.URL.String(),
+		Header: req.Header.Clone(),
+		Body:   body,
+	})
+	t.mu.Unlock()
+
+	return &http.Response{
+		Status:     "200 OK",
+		StatusCode: http.StatusOK,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       ioutil.NopCloser(bytes.NewBufferString(renderedResponse)),
+		Request:    req,
+	}, nil
+}
@@ -17,51 +17,754 @@ limitations under the License.
 package client
 
 import (
-	"log"
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/bitnami-labs/kubewatch/config"
-	"github.com/bitnami-labs/kubewatch/pkg/controller"
+	"github.com/bitnami-labs/kubewatch/pkg/ack"
+	"github.com/bitnami-labs/kubewatch/pkg/breaker"
+	"github.com/bitnami-labs/kubewatch/pkg/digest"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
 	"github.com/bitnami-labs/kubewatch/pkg/handlers"
+	"github.com/bitnami-labs/kubewatch/pkg/handlers/alertmanager"
+	"github.com/bitnami-labs/kubewatch/pkg/handlers/archive"
+	"github.com/bitnami-labs/kubewatch/pkg/handlers/aws"
+	"github.com/bitnami-labs/kubewatch/pkg/handlers/database"
+	"github.com/bitnami-labs/kubewatch/pkg/handlers/datadog"
+	"github.com/bitnami-labs/kubewatch/pkg/handlers/dingtalk"
+	"github.com/bitnami-labs/kubewatch/pkg/handlers/elasticsearch"
+	"github.com/bitnami-labs/kubewatch/pkg/handlers/exec"
 	"github.com/bitnami-labs/kubewatch/pkg/handlers/flock"
+	"github.com/bitnami-labs/kubewatch/pkg/handlers/github"
+	"github.com/bitnami-labs/kubewatch/pkg/handlers/googlechat"
+	"github.com/bitnami-labs/kubewatch/pkg/handlers/grpc"
 	"github.com/bitnami-labs/kubewatch/pkg/handlers/hipchat"
+	"github.com/bitnami-labs/kubewatch/pkg/handlers/jira"
+	"github.com/bitnami-labs/kubewatch/pkg/handlers/kafka"
+	"github.com/bitnami-labs/kubewatch/pkg/handlers/lark"
+	"github.com/bitnami-labs/kubewatch/pkg/handlers/loki"
 	"github.com/bitnami-labs/kubewatch/pkg/handlers/mattermost"
+	"github.com/bitnami-labs/kubewatch/pkg/handlers/mqtt"
 	"github.com/bitnami-labs/kubewatch/pkg/handlers/msteam"
+	"github.com/bitnami-labs/kubewatch/pkg/handlers/nats"
+	"github.com/bitnami-labs/kubewatch/pkg/handlers/ntfy"
+	"github.com/bitnami-labs/kubewatch/pkg/handlers/opsgenie"
+	"github.com/bitnami-labs/kubewatch/pkg/handlers/pubsub"
+	"github.com/bitnami-labs/kubewatch/pkg/handlers/rocketchat"
+	"github.com/bitnami-labs/kubewatch/pkg/handlers/servicenow"
 	"github.com/bitnami-labs/kubewatch/pkg/handlers/slack"
 	"github.com/bitnami-labs/kubewatch/pkg/handlers/smtp"
+	"github.com/bitnami-labs/kubewatch/pkg/handlers/syslog"
+	"github.com/bitnami-labs/kubewatch/pkg/handlers/telegram"
+	"github.com/bitnami-labs/kubewatch/pkg/handlers/webex"
 	"github.com/bitnami-labs/kubewatch/pkg/handlers/webhook"
+	"github.com/bitnami-labs/kubewatch/pkg/handlers/zulip"
+	"github.com/bitnami-labs/kubewatch/pkg/health"
+	"github.com/bitnami-labs/kubewatch/pkg/metrics"
+	"github.com/bitnami-labs/kubewatch/pkg/queue"
+	"github.com/bitnami-labs/kubewatch/pkg/receiver"
+	"github.com/bitnami-labs/kubewatch/pkg/schedule"
+	"github.com/bitnami-labs/kubewatch/pkg/slackbot"
+	"github.com/bitnami-labs/kubewatch/pkg/tracing"
+	"github.com/bitnami-labs/kubewatch/pkg/utils"
+	"github.com/sirupsen/logrus"
 )
 
 // Run runs the event loop processing with given handler
 func Run(conf *config.Config) {
 
+	var handlersReady int32
+	if conf.Server.Address != "" {
+		health.AddReadinessCheck("handlers", func() error {
+			if atomic.LoadInt32(&handlersReady) == 0 {
+				return fmt.Errorf("handlers not initialized")
+			}
+			return nil
+		})
+		go serve(conf.Server, conf.Ack, conf.Receiver)
+	} else if conf.Server.Pprof {
+		logrus.Warn("The profiles are served by the HTTP server, set its address to serve them")
+	}
+
+	stopTracing, err := tracing.Start(conf.Tracing)
+	if err != nil {
+		logrus.Fatalf("Can not start tracing: %v", err)
+	}
+	defer stopTracing()
+
+	if err := utils.SetProxy(conf.Proxy); err != nil {
+		logrus.Fatalf("Can not set the proxy: %v", err)
+	}
+
 	var eventHandler = ParseEventHandler(conf)
-	controller.Start(conf, eventHandler)
+	atomic.StoreInt32(&handlersReady, 1)
+
+	if conf.DryRun {
+		logrus.Warn("Dry run, the notifications are logged instead of being sent")
+	}
+
+	start := func() {
+		if !conf.DryRun {
+			startSlackBot(conf.Handler.Slack, conf.Slackbot)
+		}
+		watch(conf, eventHandler)
+	}
+	if conf.LeaderElection.Enabled {
+		runLeaderElection(utils.GetKubeClient(), conf.LeaderElection, start)
+		return
+	}
+	start()
+}
+
+// startSlackBot answers the Slack commands in the background when an app-level
+// token is configured. It is only started by the leader, which sends the events.
+func startSlackBot(conf config.Slack, botConf config.Slackbot) {
+	token, appToken := conf.Token, conf.AppToken
+	if token == "" {
+		token = os.Getenv("KW_SLACK_TOKEN")
+	}
+	if appToken == "" {
+		appToken = os.Getenv("KW_SLACK_APP_TOKEN")
+	}
+	if appToken == "" {
+		return
+	}
+	if token == "" {
+		logrus.Warn("Slack commands need the token of the bot, not starting them")
+		return
+	}
+	go slackbot.Run(context.Background(), token, appToken, botConf)
+}
+
+// serve runs the HTTP server exposing the health probes, the metrics, the
+// acknowledgement callbacks and the inbound webhooks, and the profiles when
+// enabled.
+func serve(server config.Server, ackConf config.Ack, receiverConf config.Receiver) {
+	addr := server.Address
+	mux := http.NewServeMux()
+	health.Register(mux)
+	metrics.Register(mux)
+	if server.Pprof {
+		metrics.RegisterProfiles(mux)
+		logrus.Warnf("Serving the Go profiles on %s/debug/pprof/", addr)
+	}
+	if err := ack.Register(mux, ackConf); err != nil {
+		logrus.Fatal(err)
+	}
+	if err := receiver.Register(mux, receiverConf, sendReceived); err != nil {
+		logrus.Fatal(err)
+	}
+
+	logrus.Infof("Serving health probes and metrics on %s", addr)
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		logrus.Fatalf("Can not start HTTP server: %v", err)
+	}
+}
+
+// received holds the handler of the inbound webhooks, set while the
+// controllers run.
+var received struct {
+	sync.RWMutex
+	handler handlers.Handler
+}
+
+// setReceivedHandler sets the handler of the inbound webhooks, nil to
+// reject them.
+func setReceivedHandler(h handlers.Handler) {
+	received.Lock()
+	defer received.Unlock()
+	received.handler = h
 }
 
-// ParseEventHandler returns the respective handler object specified in the config file.
+// sendReceived handles an event of an inbound webhook, failing when the
+// controllers don't run, e.g. when another replica is the leader.
+func sendReceived(e event.Event) error {
+	received.RLock()
+	defer received.RUnlock()
+	if received.handler == nil {
+		return fmt.Errorf("not handling events")
+	}
+	received.handler.Handle(e)
+	return nil
+}
+
+// ParseEventHandler returns the respective handler object specified in the config file,
+// wrapped to label the events, drop the muted ones and classify the severity of the others.
 func ParseEventHandler(conf *config.Config) handlers.Handler {
+	eventHandler, err := buildEventHandler(conf)
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	return eventHandler
+}
+
+// buildEventHandler initializes the handlers of the config,
+// returning an error instead of exiting when one fails.
+func buildEventHandler(conf *config.Config) (handlers.Handler, error) {
+	eventHandler, err := parseHandlers(conf)
+	if err != nil {
+		return nil, err
+	}
+	classified := &handlers.Severity{Rules: conf.Severities}
+	classified.Handler = eventHandler
+	if eventHandler, err = flapping(conf.Flapping, classified); err != nil {
+		return nil, err
+	}
+	muted := &handlers.Mute{}
+	muted.Handler = eventHandler
+	external := &handlers.External{Cluster: conf.ClusterName, Labels: conf.ExternalLabels}
+	external.Handler = muted
+	return external, nil
+}
+
+// flapping wraps the handler to suppress the events of the flapping objects
+// when enabled.
+func flapping(conf config.Flapping, h handlers.Handler) (handlers.Handler, error) {
+	if !conf.Enabled {
+		return h, nil
+	}
+	f := &handlers.Flap{Threshold: conf.Threshold, Window: 10 * time.Minute}
+	f.Handler = h
+	if f.Threshold == 0 {
+		f.Threshold = 5
+	}
+	if conf.Window != "" {
+		window, err := time.ParseDuration(conf.Window)
+		if err != nil || window <= 0 {
+			return nil, fmt.Errorf("invalid flapping window %q", conf.Window)
+		}
+		f.Window = window
+	}
+	f.StableAfter = f.Window
+	if conf.StableAfter != "" {
+		stableAfter, err := time.ParseDuration(conf.StableAfter)
+		if err != nil || stableAfter <= 0 {
+			return nil, fmt.Errorf("invalid flapping stableAfter %q", conf.StableAfter)
+		}
+		f.StableAfter = stableAfter
+	}
+	return f, nil
+}
+
+// parseHandlers initializes the configured handlers. When tenants are
+// configured, a handlers.Group dispatches the events to the handlers of the
+// handler and handlers sections, when any, and to the group of each tenant,
+// filtered by its namespaces.
+func parseHandlers(conf *config.Config) (handlers.Handler, error) {
+	if len(conf.Tenants) == 0 {
+		return parseInstances(conf)
+	}
+
+	group := &handlers.Group{}
+	if _, ok := newEventHandler(conf.Handler).(*handlers.Default); !ok || len(conf.Handlers) > 0 {
+		h, err := parseInstances(conf)
+		if err != nil {
+			return nil, err
+		}
+		group.Instances = append(group.Instances, handlers.Instance{Name: "global", Handler: h})
+	}
+	names := map[string]bool{}
+	for _, tenant := range conf.Tenants {
+		if names[tenant.Name] {
+			return nil, fmt.Errorf("duplicate tenant name %q", tenant.Name)
+		}
+		names[tenant.Name] = true
+		tenantConf, err := tenantConfig(conf, tenant)
+		if err != nil {
+			return nil, err
+		}
+		h, err := parseInstances(tenantConf)
+		if err != nil {
+			return nil, fmt.Errorf("tenant %q: %v", tenant.Name, err)
+		}
+		group.Instances = append(group.Instances, handlers.Instance{
+			Name:    "tenant " + tenant.Name,
+			Handler: h,
+			Filter:  config.Filter{Namespaces: tenant.Namespaces},
+		})
+	}
+	return group, nil
+}
+
+// tenantConfig returns a copy of the config carrying the handler instances
+// and routes of the tenant instead of its own, the instances being named
+// after the tenant. It fails when a filter of the tenant matches other
+// namespaces than the ones of the tenant.
+func tenantConfig(conf *config.Config, tenant config.Tenant) (*config.Config, error) {
+	if tenant.Name == "" {
+		return nil, fmt.Errorf("tenants must have a name")
+	}
+	if len(tenant.Namespaces) == 0 {
+		return nil, fmt.Errorf("tenant %q has no namespaces", tenant.Name)
+	}
+	for _, namespace := range tenant.Namespaces {
+		if namespace == "" {
+			return nil, fmt.Errorf("tenant %q has an empty namespace", tenant.Name)
+		}
+	}
+	if len(tenant.Handlers) == 0 {
+		return nil, fmt.Errorf("tenant %q has no handlers", tenant.Name)
+	}
+	scoped := func(f config.Filter) error {
+		for _, namespace := range f.Namespaces {
+			if !containsFold(tenant.Namespaces, namespace) {
+				return fmt.Errorf("namespace %q is not a namespace of the tenant", namespace)
+			}
+		}
+		return nil
+	}
+
+	tenantConf := *conf
+	tenantConf.Handler = config.Handler{}
+	tenantConf.QuietHours = config.QuietHours{}
+	tenantConf.Digest = config.Digest{}
+	tenantConf.Batch = config.Batch{}
+	tenantConf.CircuitBreaker = config.CircuitBreaker{}
+	tenantConf.Tenants = nil
+	tenantConf.Handlers = make([]config.HandlerInstance, len(tenant.Handlers))
+	for i, instance := range tenant.Handlers {
+		if err := scoped(instance.Filter); err != nil {
+			return nil, fmt.Errorf("handler instance %q: %v", tenant.Name+"/"+instance.Name, err)
+		}
+		if instance.Name != "" {
+			instance.Name = tenant.Name + "/" + instance.Name
+		}
+		tenantConf.Handlers[i] = instance
+	}
+	tenantConf.Routes = make([]config.Route, len(tenant.Routes))
+	for i, route := range tenant.Routes {
+		if err := scoped(route.Filter); err != nil {
+			return nil, fmt.Errorf("tenant %q: routes[%d]: %v", tenant.Name, i, err)
+		}
+		targets := make([]string, len(route.Handlers))
+		for j, name := range route.Handlers {
+			targets[j] = tenant.Name + "/" + name
+		}
+		route.Handlers = targets
+		tenantConf.Routes[i] = route
+	}
+	return &tenantConf, nil
+}
+
+// containsFold reports whether the list contains the value, ignoring the
+// case like the filters.
+func containsFold(list []string, value string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseInstances initializes the configured handler. When named handler
+// instances or routes are configured, a handlers.Group dispatching to all of
+// them is returned instead.
+func parseInstances(conf *config.Config) (handlers.Handler, error) {
+
+	queued, err := queueWrapper(conf.Queue)
+	if err != nil {
+		return nil, err
+	}
+	dispatched, err := dispatchWrapper(conf.Dispatch)
+	if err != nil {
+		return nil, err
+	}
+
+	// wrap initializes the handler of the instance from a copy of the config
+	// carrying its own handler settings, and wraps it with the delivery
+	// settings of the instance.
+	wrap := func(instance config.HandlerInstance, h handlers.Handler) (handlers.Handler, error) {
+		instanceConf := *conf
+		instanceConf.Handler = instance.Handler
+		h, err := initHandler(&instanceConf, instance.Name, h)
+		if err != nil {
+			return nil, fmt.Errorf("handler instance %q: %v", instance.Name, err)
+		}
+		if h, err = batched(conf, instance.Name, instance.Batch, h); err != nil {
+			return nil, err
+		}
+		if h, err = guarded(conf, instance.Name, instance.CircuitBreaker, h); err != nil {
+			return nil, err
+		}
+		// in dry run, the handlers replaced don't report their deliveries
+		if !conf.DryRun {
+			if h, err = queued(instance.Name, h); err != nil {
+				return nil, err
+			}
+		}
+		if h, err = scheduled(instance.Name, instance.QuietHours, instance.Digest, h); err != nil {
+			return nil, err
+		}
+		return dispatched(instance.Name, h), nil
+	}
+
+	var eventHandler = newEventHandler(conf.Handler)
+	defaultInstance := config.HandlerInstance{
+		Name:           "default",
+		Handler:        conf.Handler,
+		QuietHours:     conf.QuietHours,
+		Digest:         conf.Digest,
+		Batch:          conf.Batch,
+		CircuitBreaker: conf.CircuitBreaker,
+	}
+	if len(conf.Handlers) == 0 && len(conf.Routes) == 0 {
+		return wrap(defaultInstance, eventHandler)
+	}
+
+	group := &handlers.Group{Routes: conf.Routes}
+	for i, route := range conf.Routes {
+		q, err := schedule.New(route.QuietHours)
+		if err != nil {
+			return nil, fmt.Errorf("route %d: %v", i, err)
+		}
+		group.RouteQuietHours = append(group.RouteQuietHours, q)
+	}
+	names := map[string]bool{}
+	if _, ok := eventHandler.(*handlers.Default); !ok {
+		h, err := wrap(defaultInstance, eventHandler)
+		if err != nil {
+			return nil, err
+		}
+		group.Instances = append(group.Instances, handlers.Instance{Name: "default", Handler: h})
+		names["default"] = true
+	}
+
+	for _, instance := range conf.Handlers {
+		if instance.Name == "" {
+			return nil, fmt.Errorf("handler instances must have a name")
+		}
+		if names[instance.Name] {
+			return nil, fmt.Errorf("duplicate handler instance name %q", instance.Name)
+		}
+		names[instance.Name] = true
+
+		h := newEventHandler(instance.Handler)
+		if _, ok := h.(*handlers.Default); ok {
+			return nil, fmt.Errorf("handler instance %q has no handler configured", instance.Name)
+		}
+		if h, err = wrap(instance, h); err != nil {
+			return nil, err
+		}
+		group.Instances = append(group.Instances, handlers.Instance{
+			Name:    instance.Name,
+			Handler: h,
+			Filter:  instance.Filter,
+		})
+	}
+
+	for _, route := range conf.Routes {
+		for _, name := range route.Handlers {
+			if !names[name] {
+				return nil, fmt.Errorf("route refers to unknown handler instance %q", name)
+			}
+		}
+	}
+	return group, nil
+}
+
+// initHandler initializes the handler of the named instance. In dry run, a
+// handler logging the events replaces it.
+func initHandler(conf *config.Config, name string, h handlers.Handler) (handlers.Handler, error) {
+	if conf.DryRun {
+		return &handlers.DryRun{Name: name, Type: handlerType(h)}, nil
+	}
+	if err := h.Init(conf); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// handlerType returns the type of the handler, e.g. "slack".
+func handlerType(h handlers.Handler) string {
+	return strings.ToLower(reflect.TypeOf(h).Elem().Name())
+}
+
+// defaultBatchSize is the number of events sending a batch early.
+const defaultBatchSize = 20
+
+// batched wraps the handler to send its events in batches, when configured.
+// In dry run, the events are logged one by one.
+func batched(conf *config.Config, name string, batchConf config.Batch, h handlers.Handler) (handlers.Handler, error) {
+	interval, maxEvents, err := batchSettings(batchConf)
+	if err != nil {
+		return nil, fmt.Errorf("handler instance %q: %v", name, err)
+	}
+	if interval == 0 || conf.DryRun {
+		return h, nil
+	}
+	sender, ok := h.(handlers.BatchSender)
+	if !ok {
+		return nil, fmt.Errorf("handler instance %q: the %s handler can not send batches", name, handlerType(h))
+	}
+	return &handlers.Batch{Name: name, Interval: interval, MaxEvents: maxEvents, Sender: sender}, nil
+}
+
+// defaultCooldown is the time the circuit of a failing handler stays open
+// when its cooldown isn't set.
+const defaultCooldown = time.Minute
+
+// guarded traces the deliveries of the handler and, when configured, gives
+// up the ones exceeding the timeout and stops them while they keep failing.
+// Only the handlers reporting their failed deliveries can be guarded. In dry
+// run, the events are only traced.
+func guarded(conf *config.Config, name string, breakerConf config.CircuitBreaker, h handlers.Handler) (handlers.Handler, error) {
+	timeout, failures, cooldown, err := circuitBreakerSettings(breakerConf)
+	if err != nil {
+		return nil, fmt.Errorf("handler instance %q: %v", name, err)
+	}
+	traced := handlers.Trace(name, h)
+	if (timeout == 0 && failures == 0) || conf.DryRun {
+		return traced, nil
+	}
+	sender, ok := traced.(handlers.Sender)
+	if !ok {
+		return nil, fmt.Errorf("handler instance %q: the %s handler does not report its failed deliveries, it can not have a circuit breaker", name, handlerType(h))
+	}
+	b := &handlers.Breaker{Name: name, Sender: sender, Timeout: timeout}
+	if failures > 0 {
+		b.Circuit = breaker.New(failures, cooldown)
+	}
+	return b, nil
+}
+
+// queueWrapper returns a function wrapping the handlers able to report failed
+// deliveries in a queue stored in a subdirectory named after the handler
+// instance. Handlers are returned as is when the queue is disabled, and with
+// a warning when they don't report their failed deliveries.
+func queueWrapper(conf config.Queue) (func(string, handlers.Handler) (handlers.Handler, error), error) {
+	if conf.Dir == "" {
+		return func(name string, h handlers.Handler) (handlers.Handler, error) {
+			return h, nil
+		}, nil
+	}
+
+	maxSize, ttl, retryInterval := conf.MaxSize, 24*time.Hour, 30*time.Second
+	if maxSize == 0 {
+		maxSize = 1000
+	}
+	var err error
+	if conf.TTL != "" {
+		if ttl, err = time.ParseDuration(conf.TTL); err != nil {
+			return nil, fmt.Errorf("invalid queue ttl %q: %v", conf.TTL, err)
+		}
+	}
+	if conf.RetryInterval != "" {
+		if retryInterval, err = time.ParseDuration(conf.RetryInterval); err != nil {
+			return nil, fmt.Errorf("invalid queue retry interval %q: %v", conf.RetryInterval, err)
+		}
+	}
+	if retryInterval <= 0 {
+		return nil, fmt.Errorf("invalid queue retry interval %q: must be positive", conf.RetryInterval)
+	}
 
-	var eventHandler handlers.Handler
+	return func(name string, h handlers.Handler) (handlers.Handler, error) {
+		sender, ok := h.(handlers.Sender)
+		if !ok {
+			logrus.WithField("handler", name).Warn("The handler does not report its failed deliveries, they are not queued")
+			return h, nil
+		}
+		q, err := queue.Open(name, filepath.Join(conf.Dir, name), maxSize, ttl)
+		if err != nil {
+			return nil, fmt.Errorf("handler instance %q: %v", name, err)
+		}
+		return &handlers.Queued{Name: name, Sender: sender, Queue: q, RetryInterval: retryInterval}, nil
+	}, nil
+}
+
+// defaultDispatchQueueSize is the number of events waiting per handler
+// instance when the dispatch queue size isn't set.
+const defaultDispatchQueueSize = 1000
+
+// dispatchWrapper returns a function wrapping the handlers to receive their
+// events through their own queue and workers.
+func dispatchWrapper(conf config.Dispatch) (func(string, handlers.Handler) handlers.Handler, error) {
+	if conf.Workers < 0 {
+		return nil, fmt.Errorf("invalid dispatch workers %d, must be positive", conf.Workers)
+	}
+	if conf.QueueSize < 0 {
+		return nil, fmt.Errorf("invalid dispatch queue size %d, must be positive", conf.QueueSize)
+	}
+	if conf.Overflow != "" && conf.Overflow != "block" && conf.Overflow != "drop" {
+		return nil, fmt.Errorf("invalid dispatch overflow %q, must be block or drop", conf.Overflow)
+	}
+	queueSize := conf.QueueSize
+	if queueSize == 0 {
+		queueSize = defaultDispatchQueueSize
+	}
+	return func(name string, h handlers.Handler) handlers.Handler {
+		a := &handlers.Async{Name: name, Workers: conf.Workers, QueueSize: queueSize, Drop: conf.Overflow == "drop"}
+		a.Handler = h
+		return a
+	}, nil
+}
+
+// scheduled wraps the handler to summarize its events in periodic digests
+// and to drop the events suppressed by its quiet hours, when configured.
+func scheduled(name string, quietHours config.QuietHours, digestConf config.Digest, h handlers.Handler) (handlers.Handler, error) {
+	interval, err := digestInterval(digestConf)
+	if err != nil {
+		return nil, fmt.Errorf("handler instance %q: %v", name, err)
+	}
+	if interval > 0 {
+		d := &handlers.Digest{Name: name, Interval: interval}
+		d.Handler = h
+		h = d
+	}
+
+	q, err := schedule.New(quietHours)
+	if err != nil {
+		return nil, fmt.Errorf("handler instance %q: %v", name, err)
+	}
+	if q == nil {
+		return h, nil
+	}
+	quiet := &handlers.Quiet{Name: name, QuietHours: q}
+	quiet.Handler = h
+	if quietHours.Digest {
+		quiet.Digest = &digest.Digest{}
+	}
+	return quiet, nil
+}
+
+// batchSettings returns the interval and the size of the batches, a zero
+// interval when disabled.
+func batchSettings(conf config.Batch) (time.Duration, int, error) {
+	if conf.Interval == "" {
+		return 0, 0, nil
+	}
+	interval, err := time.ParseDuration(conf.Interval)
+	if err != nil || interval <= 0 {
+		return 0, 0, fmt.Errorf("invalid batch interval %q, must be a positive duration", conf.Interval)
+	}
+	maxEvents := conf.MaxEvents
+	if maxEvents == 0 {
+		maxEvents = defaultBatchSize
+	}
+	if maxEvents < 0 {
+		return 0, 0, fmt.Errorf("invalid batch maxEvents %d, must be positive", conf.MaxEvents)
+	}
+	return interval, maxEvents, nil
+}
+
+// circuitBreakerSettings returns the timeout of the deliveries, zero when
+// unlimited, and the failures opening the circuit and its cooldown, zero
+// failures when disabled.
+func circuitBreakerSettings(conf config.CircuitBreaker) (time.Duration, int, time.Duration, error) {
+	var timeout time.Duration
+	if conf.Timeout != "" {
+		var err error
+		if timeout, err = time.ParseDuration(conf.Timeout); err != nil || timeout <= 0 {
+			return 0, 0, 0, fmt.Errorf("invalid circuit breaker timeout %q, must be a positive duration", conf.Timeout)
+		}
+	}
+	if conf.Failures < 0 {
+		return 0, 0, 0, fmt.Errorf("invalid circuit breaker failures %d, must be positive", conf.Failures)
+	}
+	cooldown := defaultCooldown
+	if conf.Cooldown != "" {
+		var err error
+		if cooldown, err = time.ParseDuration(conf.Cooldown); err != nil || cooldown <= 0 {
+			return 0, 0, 0, fmt.Errorf("invalid circuit breaker cooldown %q, must be a positive duration", conf.Cooldown)
+		}
+	}
+	return timeout, conf.Failures, cooldown, nil
+}
+
+// digestInterval returns the interval of the digests, zero when disabled.
+func digestInterval(conf config.Digest) (time.Duration, error) {
+	if conf.Interval == "" {
+		return 0, nil
+	}
+	interval, err := time.ParseDuration(conf.Interval)
+	if err != nil {
+		return 0, fmt.Errorf("invalid digest interval %q: %v", conf.Interval, err)
+	}
+	if interval <= 0 {
+		return 0, fmt.Errorf("invalid digest interval %q: must be positive", conf.Interval)
+	}
+	return interval, nil
+}
+
+// newEventHandler returns an uninitialized handler for the first handler type
+// configured in the given handler settings.
+func newEventHandler(h config.Handler) handlers.Handler {
 	switch {
-	case len(conf.Handler.Slack.Channel) > 0 || len(conf.Handler.Slack.Token) > 0:
-		eventHandler = new(slack.Slack)
-	case len(conf.Handler.Hipchat.Room) > 0 || len(conf.Handler.Hipchat.Token) > 0:
-		eventHandler = new(hipchat.Hipchat)
-	case len(conf.Handler.Mattermost.Channel) > 0 || len(conf.Handler.Mattermost.Url) > 0:
-		eventHandler = new(mattermost.Mattermost)
-	case len(conf.Handler.Flock.Url) > 0:
-		eventHandler = new(flock.Flock)
-	case len(conf.Handler.Webhook.Url) > 0:
-		eventHandler = new(webhook.Webhook)
-	case len(conf.Handler.MSTeams.WebhookURL) > 0:
-		eventHandler = new(msteam.MSTeams)
-	case len(conf.Handler.SMTP.Smarthost) > 0 || len(conf.Handler.SMTP.To) > 0:
-		eventHandler = new(smtp.SMTP)
+	case len(h.Slack.Channel) > 0 || len(h.Slack.Token) > 0:
+		return new(slack.Slack)
+	case len(h.Hipchat.Room) > 0 || len(h.Hipchat.Token) > 0:
+		return new(hipchat.Hipchat)
+	case len(h.Mattermost.Channel) > 0 || len(h.Mattermost.Url) > 0:
+		return new(mattermost.Mattermost)
+	case len(h.Flock.Url) > 0:
+		return new(flock.Flock)
+	case len(h.Webhook.Url) > 0:
+		return new(webhook.Webhook)
+	case len(h.MSTeams.WebhookURL) > 0:
+		return new(msteam.MSTeams)
+	case len(h.SMTP.Smarthost) > 0 || len(h.SMTP.To) > 0:
+		return new(smtp.SMTP)
+	case len(h.OpsGenie.APIKey) > 0:
+		return new(opsgenie.OpsGenie)
+	case len(h.Kafka.Brokers) > 0 || len(h.Kafka.Topic) > 0:
+		return new(kafka.Kafka)
+	case len(h.NATS.Url) > 0:
+		return new(nats.NATS)
+	case len(h.AWS.ARN) > 0:
+		return new(aws.AWS)
+	case len(h.PubSub.Topic) > 0:
+		return new(pubsub.PubSub)
+	case len(h.RocketChat.Url) > 0:
+		return new(rocketchat.RocketChat)
+	case len(h.Telegram.Token) > 0:
+		return new(telegram.Telegram)
+	case len(h.GoogleChat.Url) > 0:
+		return new(googlechat.GoogleChat)
+	case len(h.GRPC.Address) > 0:
+		return new(grpc.GRPC)
+	case len(h.Syslog.Address) > 0:
+		return new(syslog.Syslog)
+	case len(h.Elasticsearch.Url) > 0:
+		return new(elasticsearch.Elasticsearch)
+	case len(h.Loki.Url) > 0:
+		return new(loki.Loki)
+	case len(h.Exec.Command) > 0:
+		return new(exec.Exec)
+	case len(h.Jira.Url) > 0:
+		return new(jira.Jira)
+	case len(h.ServiceNow.Url) > 0:
+		return new(servicenow.ServiceNow)
+	case len(h.GitHub.Repository) > 0 || h.GitHub.DeploymentStatuses:
+		return new(github.GitHub)
+	case len(h.Alertmanager.Url) > 0:
+		return new(alertmanager.Alertmanager)
+	case len(h.Datadog.APIKey) > 0 || len(h.Datadog.StatsdAddress) > 0:
+		return new(datadog.Datadog)
+	case len(h.Webex.Token) > 0:
+		return new(webex.Webex)
+	case len(h.Zulip.Url) > 0:
+		return new(zulip.Zulip)
+	case len(h.Ntfy.Topic) > 0:
+		return new(ntfy.Ntfy)
+	case len(h.MQTT.Broker) > 0:
+		return new(mqtt.MQTT)
+	case len(h.Database.DSN) > 0:
+		return new(database.Database)
+	case len(h.Archive.Bucket) > 0:
+		return new(archive.Archive)
+	case len(h.Lark.WebhookURL) > 0:
+		return new(lark.Lark)
+	case len(h.DingTalk.WebhookURL) > 0:
+		return new(dingtalk.DingTalk)
 	default:
-		eventHandler = new(handlers.Default)
+		return new(handlers.Default)
 	}
-	if err := eventHandler.Init(conf); err != nil {
-		log.Fatal(err)
-	}
-	return eventHandler
 }
@@ -0,0 +1,218 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+	"github.com/bitnami-labs/kubewatch/pkg/handlers"
+)
+
+var hookHandler = config.Handler{Webhook: config.Webhook{Url: "http://127.0.0.1:1"}}
+
+func TestTenantConfig(t *testing.T) {
+	var Tests = []struct {
+		tenant config.Tenant
+		err    string
+	}{
+		{config.Tenant{Namespaces: []string{"a"}, Handlers: []config.HandlerInstance{{Name: "hook", Handler: hookHandler}}}, "tenants must have a name"},
+		{config.Tenant{Name: "team-a", Handlers: []config.HandlerInstance{{Name: "hook", Handler: hookHandler}}}, "has no namespaces"},
+		{config.Tenant{Name: "team-a", Namespaces: []string{"a", ""}, Handlers: []config.HandlerInstance{{Name: "hook", Handler: hookHandler}}}, "has an empty namespace"},
+		{config.Tenant{Name: "team-a", Namespaces: []string{"a"}}, "has no handlers"},
+		{config.Tenant{
+			Name:       "team-a",
+			Namespaces: []string{"a"},
+			Handlers:   []config.HandlerInstance{{Name: "hook", Handler: hookHandler, Filter: config.Filter{Namespaces: []string{"a", "b"}}}},
+		}, `handler instance "team-a/hook": namespace "b" is not a namespace of the tenant`},
+		{config.Tenant{
+			Name:       "team-a",
+			Namespaces: []string{"a"},
+			Handlers:   []config.HandlerInstance{{Name: "hook", Handler: hookHandler}},
+			Routes:     []config.Route{{Filter: config.Filter{Namespaces: []string{"kube-system"}}, Handlers: []string{"hook"}}},
+		}, `tenant "team-a": routes[0]: namespace "kube-system" is not a namespace of the tenant`},
+		{config.Tenant{
+			Name:       "team-a",
+			Namespaces: []string{"a"},
+			Handlers:   []config.HandlerInstance{{Name: "hook", Handler: hookHandler, Filter: config.Filter{Namespaces: []string{"A"}}}},
+			Routes:     []config.Route{{Filter: config.Filter{Namespaces: []string{"a"}}, Handlers: []string{"hook"}}},
+		}, ""},
+	}
+
+	for _, tt := range Tests {
+		_, err := tenantConfig(&config.Config{}, tt.tenant)
+		if tt.err == "" && err != nil {
+			t.Fatalf("tenantConfig(%+v): unexpected error %v", tt.tenant, err)
+		}
+		if tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+			t.Fatalf("tenantConfig(%+v): got error %v, want %q", tt.tenant, err, tt.err)
+		}
+	}
+}
+
+func TestTenantConfigNames(t *testing.T) {
+	conf := &config.Config{
+		Handler:  hookHandler,
+		Handlers: []config.HandlerInstance{{Name: "ops", Handler: hookHandler}},
+		Routes:   []config.Route{{Handlers: []string{"ops"}}},
+	}
+	tenant := config.Tenant{
+		Name:       "team-a",
+		Namespaces: []string{"a"},
+		Handlers:   []config.HandlerInstance{{Name: "hook", Handler: hookHandler}, {Name: "chat", Handler: hookHandler}},
+		Routes:     []config.Route{{Handlers: []string{"hook", "chat"}}},
+	}
+
+	tenantConf, err := tenantConfig(conf, tenant)
+	if err != nil {
+		t.Fatalf("tenantConfig(): unexpected error %v", err)
+	}
+	if tenantConf.Handler.Webhook.Url != "" {
+		t.Fatalf("tenantConfig(): got the handler section %+v, want none", tenantConf.Handler)
+	}
+	var names []string
+	for _, instance := range tenantConf.Handlers {
+		names = append(names, instance.Name)
+	}
+	if want := []string{"team-a/hook", "team-a/chat"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("tenantConfig(): got handlers %v, want %v", names, want)
+	}
+	if len(tenantConf.Routes) != 1 {
+		t.Fatalf("tenantConfig(): got routes %+v, want the tenant ones", tenantConf.Routes)
+	}
+	if want := []string{"team-a/hook", "team-a/chat"}; !reflect.DeepEqual(tenantConf.Routes[0].Handlers, want) {
+		t.Fatalf("tenantConfig(): got route targets %v, want %v", tenantConf.Routes[0].Handlers, want)
+	}
+	if !reflect.DeepEqual(conf.Routes[0].Handlers, []string{"ops"}) {
+		t.Fatalf("tenantConfig(): the routes of the config were changed to %v", conf.Routes[0].Handlers)
+	}
+}
+
+func TestParseHandlersTenants(t *testing.T) {
+	conf := &config.Config{
+		DryRun:   true,
+		Handlers: []config.HandlerInstance{{Name: "ops", Handler: hookHandler}},
+		Tenants: []config.Tenant{
+			{
+				Name:       "team-a",
+				Namespaces: []string{"a"},
+				Handlers: []config.HandlerInstance{
+					{Name: "hook", Handler: hookHandler},
+					{Name: "alerts", Handler: hookHandler, Filter: config.Filter{Namespaces: []string{"A"}, Kinds: []string{"pod"}}},
+				},
+			},
+			{
+				Name:       "team-b",
+				Namespaces: []string{"b", "b-staging"},
+				Handlers:   []config.HandlerInstance{{Name: "hook", Handler: hookHandler}, {Name: "chat", Handler: hookHandler}},
+				Routes:     []config.Route{{Filter: config.Filter{Namespaces: []string{"b"}}, Handlers: []string{"chat"}}},
+			},
+		},
+	}
+
+	h, err := parseHandlers(conf)
+	if err != nil {
+		t.Fatalf("parseHandlers(): unexpected error %v", err)
+	}
+
+	var Tests = []struct {
+		e    event.Event
+		want []string
+	}{
+		{event.Event{Namespace: "a", Kind: "pod"}, []string{"ops", "team-a/hook", "team-a/alerts"}},
+		{event.Event{Namespace: "a", Kind: "deployment"}, []string{"ops", "team-a/hook"}},
+		{event.Event{Namespace: "b", Kind: "pod"}, []string{"ops", "team-b/chat"}},
+		{event.Event{Namespace: "b-staging", Kind: "pod"}, []string{"ops"}},
+		{event.Event{Namespace: "c", Kind: "pod"}, []string{"ops"}},
+		{event.Event{Namespace: "", Kind: "node"}, []string{"ops"}},
+	}
+
+	for _, tt := range Tests {
+		if got := routed(h, tt.e); !reflect.DeepEqual(got, tt.want) {
+			t.Fatalf("parseHandlers(): event %+v routed to %v, want %v", tt.e, got, tt.want)
+		}
+	}
+
+	conf.Tenants[0].Handlers[1].Filter.Namespaces = []string{"b"}
+	if _, err := parseHandlers(conf); err == nil {
+		t.Fatalf("parseHandlers(): no error for a tenant filter matching the namespace of another tenant")
+	}
+}
+
+// routed returns the names of the handler instances the event is
+// dispatched to, walking the groups of the tenants.
+func routed(h handlers.Handler, e event.Event) []string {
+	group, ok := h.(*handlers.Group)
+	if !ok {
+		return nil
+	}
+	var names []string
+	for _, name := range group.Routed(e) {
+		for _, i := range group.Instances {
+			if i.Name != name {
+				continue
+			}
+			if _, ok := i.Handler.(*handlers.Group); ok {
+				names = append(names, routed(i.Handler, e)...)
+			} else {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+func TestCircuitBreakerSenders(t *testing.T) {
+	teams := config.Handler{MSTeams: config.MSTeams{WebhookURL: "http://127.0.0.1:1"}}
+	circuitBreaker := config.CircuitBreaker{Timeout: "10s", Failures: 3}
+
+	var Tests = []struct {
+		handler config.Handler
+		dryRun  bool
+		wantErr bool
+	}{
+		{hookHandler, false, false},
+		{teams, false, true},
+		{teams, true, false},
+	}
+
+	for _, tt := range Tests {
+		conf := &config.Config{
+			DryRun:   tt.dryRun,
+			Handlers: []config.HandlerInstance{{Name: "test", Handler: tt.handler, CircuitBreaker: circuitBreaker}},
+		}
+		_, err := parseHandlers(conf)
+		if tt.wantErr && (err == nil || !strings.Contains(err.Error(), "can not have a circuit breaker")) {
+			t.Fatalf("parseHandlers(%+v): got error %v, want a circuit breaker error", tt.handler, err)
+		}
+		if !tt.wantErr && err != nil {
+			t.Fatalf("parseHandlers(%+v): unexpected error %v", tt.handler, err)
+		}
+	}
+
+	conf := &config.Config{Handlers: []config.HandlerInstance{{Name: "test", Handler: teams, CircuitBreaker: circuitBreaker}}}
+	var found bool
+	for _, err := range Validate(conf) {
+		found = found || strings.Contains(err.Error(), "the msteams handler does not report its failed deliveries")
+	}
+	if !found {
+		t.Fatalf("Validate(): no error for the circuit breaker of the msteams handler, got %v", Validate(conf))
+	}
+}
@@ -0,0 +1,124 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/utils"
+	"github.com/sirupsen/logrus"
+	api_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	api_watch "k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+// secretsClient is the client reading the Secrets referenced by the
+// settings, created when first needed.
+var secretsClient struct {
+	sync.Once
+	kubernetes.Interface
+}
+
+// ResolveSecret returns the value of the key of a Secret referenced by the
+// settings, defaulting to the namespace of kubewatch.
+func ResolveSecret(ref config.SecretKeyRef) (string, error) {
+	secretsClient.Do(func() {
+		secretsClient.Interface = utils.GetKubeClient()
+	})
+	namespace := ref.Namespace
+	if namespace == "" {
+		namespace = podNamespace()
+	}
+	secret, err := secretsClient.CoreV1().Secrets(namespace).Get(ref.Name, meta_v1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("can not read Secret %s/%s: %v", namespace, ref.Name, err)
+	}
+	value, ok := secret.Data[ref.Key]
+	if !ok {
+		return "", fmt.Errorf("Secret %s/%s has no key %q", namespace, ref.Name, ref.Key)
+	}
+	return string(value), nil
+}
+
+// watchReferences notifies changes when the Secrets or the files the settings
+// are read from change, until stopCh is closed. The files already watched
+// are skipped, their watches lasting as long as the process.
+func watchReferences(refs config.References, watched map[string]bool, changes chan<- struct{}, stopCh <-chan struct{}) {
+	for _, path := range refs.Files {
+		if !watched[path] {
+			watched[path] = true
+			watchConfigFile(path, changes)
+		}
+	}
+
+	secrets := map[string]bool{}
+	for _, ref := range refs.Secrets {
+		namespace := ref.Namespace
+		if namespace == "" {
+			namespace = podNamespace()
+		}
+		key := namespace + "/" + ref.Name
+		if secrets[key] {
+			continue
+		}
+		secrets[key] = true
+		go watchSecret(namespace, ref.Name, changes, stopCh)
+	}
+}
+
+// watchSecret notifies changes when the Secret is updated or deleted.
+func watchSecret(namespace, name string, changes chan<- struct{}, stopCh <-chan struct{}) {
+	selector := fields.OneTermEqualSelector("metadata.name", name).String()
+	notify := func() {
+		logrus.Infof("Secret %s/%s changed, reloading the config", namespace, name)
+		select {
+		case changes <- struct{}{}:
+		default:
+		}
+	}
+	_, informer := cache.NewInformer(
+		&cache.ListWatch{
+			ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
+				options.FieldSelector = selector
+				return secretsClient.CoreV1().Secrets(namespace).List(options)
+			},
+			WatchFunc: func(options meta_v1.ListOptions) (api_watch.Interface, error) {
+				options.FieldSelector = selector
+				return secretsClient.CoreV1().Secrets(namespace).Watch(options)
+			},
+		},
+		&api_v1.Secret{},
+		0, //Skip resync
+		cache.ResourceEventHandlerFuncs{
+			UpdateFunc: func(old, new interface{}) {
+				if old.(*api_v1.Secret).ResourceVersion != new.(*api_v1.Secret).ResourceVersion {
+					notify()
+				}
+			},
+			DeleteFunc: func(obj interface{}) {
+				notify()
+			},
+		},
+	)
+	informer.Run(stopCh)
+}
@@ -0,0 +1,159 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+	"github.com/bitnami-labs/kubewatch/pkg/handlers"
+	"github.com/google/uuid"
+)
+
+// testTimeout bounds the wait for the handlers batching their events to
+// deliver the test event.
+const testTimeout = 30 * time.Second
+
+// TestResult is the outcome of sending the test event to a handler.
+type TestResult struct {
+	Name string
+	// Err is the error initializing the handler or delivering the event.
+	Err error
+	// Confirmed tells whether the handler reports its deliveries. The other
+	// handlers only log their failures.
+	Confirmed bool
+}
+
+// TestEvent returns the synthetic event sent by SendTest.
+func TestEvent(conf *config.Config) event.Event {
+	return event.Event{
+		ID:             uuid.New().String(),
+		Cluster:        conf.ClusterName,
+		Namespace:      "default",
+		Kind:           "pod",
+		Name:           "kubewatch-test",
+		Reason:         "created",
+		Status:         "Normal",
+		Severity:       "info",
+		ExternalLabels: conf.ExternalLabels,
+		Details:        "This is a test notification sent by kubewatch",
+	}
+}
+
+// SendTest initializes the handler instances of the config having the given
+// names, or all of them when no name is given, and sends each one the test
+// event. The handler of the handler section is named "default". The filters,
+// routes and quiet hours are not applied.
+func SendTest(conf *config.Config, names []string) ([]TestResult, error) {
+	instances := handlerInstances(conf)
+	if len(instances) == 0 {
+		return nil, fmt.Errorf("no handler configured")
+	}
+
+	if len(names) > 0 {
+		byName := map[string]config.HandlerInstance{}
+		for _, instance := range instances {
+			byName[instance.Name] = instance
+		}
+		var selected []config.HandlerInstance
+		for _, name := range names {
+			instance, ok := byName[name]
+			if !ok {
+				return nil, fmt.Errorf("unknown handler instance %q", name)
+			}
+			selected = append(selected, instance)
+		}
+		instances = selected
+	}
+
+	var results []TestResult
+	for _, instance := range instances {
+		results = append(results, sendTest(conf, instance))
+	}
+	return results, nil
+}
+
+// handlerInstances returns the handler instances of the config, starting with
+// the one of the handler section, named "default", when configured, and
+// ending with the ones of the valid tenants, named after their tenant.
+func handlerInstances(conf *config.Config) []config.HandlerInstance {
+	var instances []config.HandlerInstance
+	if _, ok := newEventHandler(conf.Handler).(*handlers.Default); !ok {
+		instances = append(instances, config.HandlerInstance{Name: "default", Handler: conf.Handler})
+	}
+	instances = append(instances, conf.Handlers...)
+	for _, tenant := range conf.Tenants {
+		if tenantConf, err := tenantConfig(conf, tenant); err == nil {
+			instances = append(instances, tenantConf.Handlers...)
+		}
+	}
+	return instances
+}
+
+// sendTest initializes the handler of the instance and sends it the test event.
+func sendTest(conf *config.Config, instance config.HandlerInstance) TestResult {
+	result := TestResult{Name: instance.Name}
+
+	h, err := initInstance(conf, instance)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	result.Confirmed, result.Err = deliver(h, TestEvent(conf))
+	return result
+}
+
+// deliver passes the event to the initialized handler, reporting whether it
+// confirms its deliveries and the error delivering the event.
+func deliver(h handlers.Handler, e event.Event) (bool, error) {
+	if sender, ok := h.(handlers.Sender); ok {
+		return true, sender.Send(e)
+	}
+
+	// the batching handlers deliver their pending events when stopped
+	stopCh, done := make(chan struct{}), make(chan struct{})
+	go func() {
+		handlers.Run(h, stopCh)
+		close(done)
+	}()
+	h.Handle(e)
+	close(stopCh)
+	select {
+	case <-done:
+	case <-time.After(testTimeout):
+		return false, fmt.Errorf("timed out after %s", testTimeout)
+	}
+	return false, nil
+}
+
+// initInstance initializes the handler of the instance from a copy of the
+// config carrying the settings of the instance.
+func initInstance(conf *config.Config, instance config.HandlerInstance) (handlers.Handler, error) {
+	h := newEventHandler(instance.Handler)
+	if _, ok := h.(*handlers.Default); ok {
+		return nil, fmt.Errorf("no handler configured")
+	}
+	instanceConf := *conf
+	instanceConf.Handler = instance.Handler
+	if err := h.Init(&instanceConf); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
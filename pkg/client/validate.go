@@ -0,0 +1,356 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"fmt"
+	"net"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/controller"
+	"github.com/bitnami-labs/kubewatch/pkg/crash"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+	"github.com/bitnami-labs/kubewatch/pkg/filter"
+	"github.com/bitnami-labs/kubewatch/pkg/handlers"
+	"github.com/bitnami-labs/kubewatch/pkg/receiver"
+	"github.com/bitnami-labs/kubewatch/pkg/schedule"
+	"github.com/bitnami-labs/kubewatch/pkg/severity"
+	"github.com/bitnami-labs/kubewatch/pkg/slackbot"
+	"github.com/bitnami-labs/kubewatch/pkg/utils"
+	"k8s.io/apimachinery/pkg/fields"
+)
+
+// Validate checks the settings of the config, returning the problems found.
+// The handlers are initialized to check their settings, which makes the
+// handlers connecting at startup, e.g. nats, connect to their server.
+func Validate(conf *config.Config) []error {
+	var errs []error
+	add := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	names := map[string]bool{}
+	if types := handlerTypes(conf.Handler); len(types) > 0 || (len(conf.Handlers) == 0 && len(conf.Tenants) == 0) {
+		names["default"] = true
+		for _, err := range validateInstance(conf, config.HandlerInstance{Name: "default", Handler: conf.Handler, QuietHours: conf.QuietHours, Digest: conf.Digest, Batch: conf.Batch, CircuitBreaker: conf.CircuitBreaker}) {
+			add("handler: %v", err)
+		}
+	}
+	errs = append(errs, validateRouting(conf, names)...)
+
+	tenants := map[string]bool{}
+	for i, tenant := range conf.Tenants {
+		if tenant.Name != "" && tenants[tenant.Name] {
+			add("tenants[%d]: duplicate name %q", i, tenant.Name)
+			continue
+		}
+		tenants[tenant.Name] = true
+		tenantConf, err := tenantConfig(conf, tenant)
+		if err != nil {
+			add("tenants[%d]: %v", i, err)
+			continue
+		}
+		for _, err := range validateRouting(tenantConf, map[string]bool{}) {
+			add("tenant %q: %v", tenant.Name, err)
+		}
+	}
+
+	for i, rule := range conf.Severities {
+		if !severity.Valid(rule.Severity) {
+			add("severities[%d]: invalid severity %q, must be one of critical, warning or info", i, rule.Severity)
+		}
+		if err := validateFilter(rule.Filter); err != nil {
+			add("severities[%d]: %v", i, err)
+		}
+	}
+
+	var keys []string
+	for key := range conf.Selectors {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		s := conf.Selectors[key]
+		if !config.IsResourceKey(key) {
+			add("selectors: unknown resource %q", key)
+		}
+		if _, err := fields.ParseSelector(s.Field); err != nil {
+			add("selectors: %s: %v", key, err)
+		}
+		for _, name := range s.Names {
+			if _, err := path.Match(name, ""); err != nil {
+				add("selectors: %s: invalid name pattern %q", key, name)
+			}
+		}
+	}
+
+	for _, key := range conf.Informers.MetadataOnly {
+		if !controller.SupportsMetadataOnly(key) {
+			add("informers: the %q resource can not be watched through its metadata only", key)
+		}
+	}
+
+	for _, err := range controller.ValidateSettings(conf.Controller) {
+		add("controller: %v", err)
+	}
+
+	if !event.ValidPayloadVersion(conf.PayloadVersion) {
+		add("invalid payloadVersion %q, must be %s or %s", conf.PayloadVersion, event.PayloadV1, event.PayloadV2)
+	}
+
+	for _, t := range conf.Events.Types {
+		if !strings.EqualFold(t, "Normal") && !strings.EqualFold(t, "Warning") {
+			add("events: invalid type %q, must be Normal or Warning", t)
+		}
+	}
+
+	if _, err := crash.NewRedactor(conf.Crashes.Redact); err != nil {
+		add("crashes: %v", err)
+	}
+	if d := conf.Owners.Interval; d != "" {
+		if interval, err := time.ParseDuration(d); err != nil || interval <= 0 {
+			add("owners: invalid interval %q, must be a positive duration", d)
+		}
+	}
+	if d := conf.Rollouts.Timeout; d != "" {
+		if timeout, err := time.ParseDuration(d); err != nil || timeout <= 0 {
+			add("rollouts: invalid timeout %q, must be a positive duration", d)
+		}
+	}
+	if conf.Flapping.Threshold < 0 || conf.Flapping.Threshold == 1 {
+		add("flapping: invalid threshold %d, must be at least 2", conf.Flapping.Threshold)
+	}
+	if d := conf.Flapping.Window; d != "" {
+		if window, err := time.ParseDuration(d); err != nil || window <= 0 {
+			add("flapping: invalid window %q, must be a positive duration", d)
+		}
+	}
+	if d := conf.Flapping.StableAfter; d != "" {
+		if stableAfter, err := time.ParseDuration(d); err != nil || stableAfter <= 0 {
+			add("flapping: invalid stableAfter %q, must be a positive duration", d)
+		}
+	}
+	switch conf.Permissions.OnDenied {
+	case "", "warn", "skip", "fail":
+	default:
+		add("permissions: invalid onDenied %q, must be warn, skip or fail", conf.Permissions.OnDenied)
+	}
+	if u := conf.Proxy.URL; u != "" {
+		if _, err := utils.ParseProxyURL(u); err != nil {
+			add("proxy: %v", err)
+		}
+	}
+	for _, host := range conf.Proxy.NoProxy {
+		if _, _, err := net.ParseCIDR(host); err != nil && strings.Contains(host, "/") {
+			add("proxy: invalid noProxy range %q", host)
+		}
+	}
+	known := map[string]bool{}
+	v := reflect.TypeOf(config.Handler{})
+	for i := 0; i < v.NumField(); i++ {
+		known[strings.ToLower(v.Field(i).Name)] = true
+	}
+	var handlerKeys []string
+	for handler := range conf.Proxy.Handlers {
+		handlerKeys = append(handlerKeys, handler)
+	}
+	sort.Strings(handlerKeys)
+	for _, handler := range handlerKeys {
+		if !known[strings.ToLower(handler)] {
+			add("proxy: unknown handler type %q", handler)
+		} else if u := conf.Proxy.Handlers[handler]; u != "direct" {
+			if _, err := utils.ParseProxyURL(u); err != nil {
+				add("proxy: %s: %v", handler, err)
+			}
+		}
+	}
+	if d := conf.Certificates.Interval; d != "" {
+		if interval, err := time.ParseDuration(d); err != nil || interval <= 0 {
+			add("certificates: invalid interval %q, must be a positive duration", d)
+		}
+	}
+	if conf.Certificates.WarningDays < 0 || conf.Certificates.CriticalDays < 0 {
+		add("certificates: the warningDays and criticalDays can not be negative")
+	}
+	if d := conf.Volumes.PendingAfter; d != "" {
+		if pendingAfter, err := time.ParseDuration(d); err != nil || pendingAfter <= 0 {
+			add("volumes: invalid pendingAfter %q, must be a positive duration", d)
+		}
+	}
+	if d := conf.Volumes.Interval; d != "" {
+		if interval, err := time.ParseDuration(d); err != nil || interval <= 0 {
+			add("volumes: invalid interval %q, must be a positive duration", d)
+		}
+	}
+	if d := conf.Startup.NotifyCreatedWithin; d != "" {
+		if _, err := time.ParseDuration(d); err != nil {
+			add("startup: invalid notifyCreatedWithin %q: %v", d, err)
+		}
+	}
+	for _, user := range conf.Slackbot.AllowedUsers {
+		if !slackbot.IsUserID(user) {
+			add("slackbot: invalid allowed user %q, must be a user ID, e.g. U012AB3CD", user)
+		}
+	}
+	if _, err := queueWrapper(conf.Queue); err != nil {
+		add("queue: %v", err)
+	}
+	if _, err := dispatchWrapper(conf.Dispatch); err != nil {
+		add("dispatch: %v", err)
+	}
+	if conf.Ack.Duration != "" {
+		if d, err := time.ParseDuration(conf.Ack.Duration); err != nil {
+			add("ack: invalid duration %q: %v", conf.Ack.Duration, err)
+		} else if d <= 0 {
+			add("ack: invalid duration %q: must be positive", conf.Ack.Duration)
+		}
+	}
+	for _, source := range conf.Receiver.Sources {
+		if !receiver.Valid(source) {
+			add("receiver: unknown source %q, must be alertmanager, harbor or argocd", source)
+		}
+	}
+	if conf.Receiver.Token != "" && conf.Server.Address == "" {
+		add("receiver: the server address must be set")
+	}
+	if d := conf.Shutdown.DrainTimeout; d != "" {
+		if timeout, err := time.ParseDuration(d); err != nil || timeout <= 0 {
+			add("shutdown: invalid drainTimeout %q, must be a positive duration", d)
+		}
+	}
+	if conf.Tracing.SampleRatio < 0 || conf.Tracing.SampleRatio > 1 {
+		add("tracing: invalid sample ratio %v, must be between 0 and 1", conf.Tracing.SampleRatio)
+	}
+
+	clusters := map[string]bool{}
+	for i, cluster := range conf.Clusters {
+		if cluster.Name == "" {
+			add("clusters[%d]: missing name", i)
+		} else if clusters[cluster.Name] {
+			add("clusters[%d]: duplicate name %q", i, cluster.Name)
+		}
+		clusters[cluster.Name] = true
+	}
+	return errs
+}
+
+// validateRouting checks the handler instances and the routes of the config,
+// the routes referring to them or to the named instances given.
+func validateRouting(conf *config.Config, names map[string]bool) []error {
+	var errs []error
+	add := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	for i, instance := range conf.Handlers {
+		if instance.Name == "" {
+			add("handlers[%d]: missing name", i)
+			continue
+		}
+		if names[instance.Name] {
+			add("handlers[%d]: duplicate name %q", i, instance.Name)
+			continue
+		}
+		names[instance.Name] = true
+		for _, err := range validateInstance(conf, instance) {
+			add("handler instance %q: %v", instance.Name, err)
+		}
+	}
+
+	for i, route := range conf.Routes {
+		if len(route.Handlers) == 0 {
+			add("routes[%d]: no handlers", i)
+		}
+		for _, name := range route.Handlers {
+			if !names[name] {
+				add("routes[%d]: unknown handler instance %q", i, name)
+			}
+		}
+		if err := validateFilter(route.Filter); err != nil {
+			add("routes[%d]: %v", i, err)
+		}
+		if _, err := schedule.New(route.QuietHours); err != nil {
+			add("routes[%d]: %v", i, err)
+		}
+	}
+	return errs
+}
+
+// validateInstance checks the settings of a handler instance.
+func validateInstance(conf *config.Config, instance config.HandlerInstance) []error {
+	var errs []error
+	if types := handlerTypes(instance.Handler); len(types) > 1 {
+		errs = append(errs, fmt.Errorf("several handlers configured (%s), only one is used", strings.Join(types, ", ")))
+	}
+	if _, err := initInstance(conf, instance); err != nil {
+		errs = append(errs, err)
+	}
+	if err := validateFilter(instance.Filter); err != nil {
+		errs = append(errs, err)
+	}
+	if _, err := digestInterval(instance.Digest); err != nil {
+		errs = append(errs, err)
+	}
+	if interval, _, err := batchSettings(instance.Batch); err != nil {
+		errs = append(errs, err)
+	} else if h := newEventHandler(instance.Handler); interval > 0 {
+		if _, ok := h.(handlers.BatchSender); !ok {
+			errs = append(errs, fmt.Errorf("the %s handler can not send batches", handlerType(h)))
+		}
+	}
+	if _, err := schedule.New(instance.QuietHours); err != nil {
+		errs = append(errs, err)
+	}
+	if timeout, failures, _, err := circuitBreakerSettings(instance.CircuitBreaker); err != nil {
+		errs = append(errs, err)
+	} else if h := newEventHandler(instance.Handler); timeout > 0 || failures > 0 {
+		if _, ok := h.(handlers.Sender); !ok {
+			errs = append(errs, fmt.Errorf("the %s handler does not report its failed deliveries, it can not have a circuit breaker", handlerType(h)))
+		}
+	}
+	return errs
+}
+
+// validateFilter checks the event types and the severities of the filter.
+func validateFilter(f config.Filter) error {
+	if err := filter.Validate(f); err != nil {
+		return err
+	}
+	for _, s := range f.Severities {
+		if !severity.Valid(s) {
+			return fmt.Errorf("invalid severity %q, must be one of critical, warning or info", s)
+		}
+	}
+	return nil
+}
+
+// handlerTypes returns the keys of the handler types having settings.
+func handlerTypes(h config.Handler) []string {
+	var types []string
+	v := reflect.ValueOf(h)
+	for i := 0; i < v.NumField(); i++ {
+		if !v.Field(i).IsZero() {
+			types = append(types, strings.ToLower(v.Type().Field(i).Name))
+		}
+	}
+	return types
+}
@@ -0,0 +1,213 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"time"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/certs"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+	"github.com/bitnami-labs/kubewatch/pkg/handlers"
+	"github.com/sirupsen/logrus"
+
+	api_v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Reasons of the notifications of the certificates.
+const (
+	certificateExpiring = "CertificateExpiring"
+	certificateExpired  = "CertificateExpired"
+)
+
+// certManagerVersions are the API versions of the cert-manager
+// Certificates, tried in order.
+var certManagerVersions = []string{"cert-manager.io/v1", "cert-manager.io/v1alpha2"}
+
+// certManagerAnnotation is set by cert-manager on the secrets of its
+// Certificates, which are notified instead.
+const certManagerAnnotation = "cert-manager.io/certificate-name"
+
+// certificateChecker notifies the certificates of the TLS secrets and the
+// cert-manager Certificates close to their expiry, on a schedule.
+type certificateChecker struct {
+	client       kubernetes.Interface
+	namespace    string
+	interval     time.Duration
+	warningDays  int
+	criticalDays int
+	certManager  bool
+	// notifier notifies the events of the checker, without informer
+	notifier *Controller
+}
+
+// newCertificateChecker returns the checker of the certificates of the
+// cluster, with the defaults of the settings.
+func newCertificateChecker(client kubernetes.Interface, cluster string, conf *config.Config, eventHandler handlers.Handler) *certificateChecker {
+	logger := logrus.WithField("pkg", "kubewatch-certificate")
+	if cluster != "" {
+		logger = logger.WithField("cluster", cluster)
+	}
+	settings := conf.Certificates
+	interval := 24 * time.Hour
+	if settings.Interval != "" {
+		d, err := time.ParseDuration(settings.Interval)
+		if err != nil || d <= 0 {
+			logger.Warnf("Invalid certificates interval %q, checking daily", settings.Interval)
+		} else {
+			interval = d
+		}
+	}
+	c := &certificateChecker{
+		client:       client,
+		namespace:    conf.Namespace,
+		interval:     interval,
+		warningDays:  settings.WarningDays,
+		criticalDays: settings.CriticalDays,
+		certManager:  !settings.IgnoreCertManager,
+		notifier: &Controller{
+			logger:       logger,
+			cluster:      cluster,
+			eventHandler: eventHandler,
+			enrich:       conf.Enrich,
+			optIn:        conf.OptIn,
+		},
+	}
+	if c.warningDays == 0 {
+		c.warningDays = 30
+	}
+	if c.criticalDays == 0 {
+		c.criticalDays = 7
+	}
+	return c
+}
+
+// Run checks the certificates at once, then every interval until stopCh is
+// closed.
+func (c *certificateChecker) Run(stopCh <-chan struct{}) {
+	c.notifier.logger.Infof("Checking the expiry of the certificates every %s", c.interval)
+	wait.Until(c.check, c.interval, stopCh)
+}
+
+// check notifies the certificates expiring within the warning days.
+func (c *certificateChecker) check() {
+	now := time.Now()
+	secrets, err := c.client.CoreV1().Secrets(c.namespace).List(meta_v1.ListOptions{
+		FieldSelector: "type=" + string(api_v1.SecretTypeTLS),
+	})
+	if err != nil {
+		c.notifier.logger.Errorf("Cannot list the TLS secrets: %v", err)
+	} else {
+		for i := range secrets.Items {
+			secret := &secrets.Items[i]
+			if c.certManager && secret.Annotations[certManagerAnnotation] != "" {
+				continue
+			}
+			expiry, err := certs.FromPEM(secret.Data[api_v1.TLSCertKey])
+			if err != nil {
+				c.notifier.logger.Warnf("Cannot read the certificate of secret %s/%s: %v", secret.Namespace, secret.Name, err)
+				continue
+			}
+			c.notify(event.Event{Kind: "secret", Namespace: secret.Namespace, Name: secret.Name, Labels: secret.Labels, Object: snapshot(secret)}, expiry, now)
+		}
+	}
+
+	if c.certManager {
+		c.checkCertManager(now)
+	}
+}
+
+// checkCertManager notifies the cert-manager Certificates expiring within
+// the warning days, by the expiry of their status.
+func (c *certificateChecker) checkCertManager(now time.Time) {
+	list, err := c.listCertManager()
+	if err != nil {
+		c.notifier.logger.Errorf("Cannot list the cert-manager Certificates: %v", err)
+		return
+	}
+	for i := range list.Items {
+		cert := &list.Items[i]
+		notAfter, _, _ := unstructured.NestedString(cert.Object, "status", "notAfter")
+		if notAfter == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, notAfter)
+		if err != nil {
+			c.notifier.logger.Warnf("Invalid notAfter %q of certificate %s/%s", notAfter, cert.GetNamespace(), cert.GetName())
+			continue
+		}
+		subject, _, _ := unstructured.NestedString(cert.Object, "spec", "commonName")
+		if subject == "" {
+			if names, _, _ := unstructured.NestedStringSlice(cert.Object, "spec", "dnsNames"); len(names) > 0 {
+				subject = names[0]
+			}
+		}
+		c.notify(event.Event{Kind: "certificate", Namespace: cert.GetNamespace(), Name: cert.GetName(), Labels: cert.GetLabels(), Object: cert},
+			certs.Expiry{Subject: subject, NotAfter: t}, now)
+	}
+}
+
+// listCertManager lists the Certificates with the first API version served
+// by the cluster, none when cert-manager is not installed.
+func (c *certificateChecker) listCertManager() (*unstructured.UnstructuredList, error) {
+	rest := c.client.Discovery().RESTClient()
+	if rest == nil {
+		return &unstructured.UnstructuredList{}, nil
+	}
+	for _, version := range certManagerVersions {
+		path := "/apis/" + version
+		if c.namespace != "" {
+			path += "/namespaces/" + c.namespace
+		}
+		data, err := rest.Get().AbsPath(path, "certificates").Do().Raw()
+		if errors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		list := &unstructured.UnstructuredList{}
+		if err := list.UnmarshalJSON(data); err != nil {
+			return nil, err
+		}
+		return list, nil
+	}
+	return &unstructured.UnstructuredList{}, nil
+}
+
+// notify notifies the certificate of the object when it expires within the
+// warning days, as a danger within the critical days.
+func (c *certificateChecker) notify(e event.Event, expiry certs.Expiry, now time.Time) {
+	days := expiry.Days(now)
+	if days >= c.warningDays {
+		return
+	}
+	e.Reason, e.Status = certificateExpiring, "Warning"
+	if days < c.criticalDays {
+		e.Status = "Danger"
+	}
+	if expiry.NotAfter.Before(now) {
+		e.Reason = certificateExpired
+	}
+	e.Details = expiry.Description(now)
+	c.notifier.handle(e, now)
+}
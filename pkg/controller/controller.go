@@ -17,31 +17,46 @@ limitations under the License.
 package controller
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/signal"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/crash"
 	"github.com/bitnami-labs/kubewatch/pkg/event"
+	"github.com/bitnami-labs/kubewatch/pkg/filter"
 	"github.com/bitnami-labs/kubewatch/pkg/handlers"
+	"github.com/bitnami-labs/kubewatch/pkg/health"
+	"github.com/bitnami-labs/kubewatch/pkg/metrics"
+	"github.com/bitnami-labs/kubewatch/pkg/nodes"
+	"github.com/bitnami-labs/kubewatch/pkg/tracing"
 	"github.com/bitnami-labs/kubewatch/pkg/utils"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
 
 	apps_v1 "k8s.io/api/apps/v1"
+	autoscaling_v1 "k8s.io/api/autoscaling/v1"
 	batch_v1 "k8s.io/api/batch/v1"
+	batch_v1beta1 "k8s.io/api/batch/v1beta1"
 	api_v1 "k8s.io/api/core/v1"
 	ext_v1beta1 "k8s.io/api/extensions/v1beta1"
+	networking_v1 "k8s.io/api/networking/v1"
 	rbac_v1beta1 "k8s.io/api/rbac/v1beta1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/rest"
+	metadata_client "k8s.io/client-go/metadata"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/util/workqueue"
 )
@@ -50,105 +65,307 @@ const maxRetries = 5
 
 var serverStartTime time.Time
 
+// secretDiffIgnoreFields keep the values of secrets out of the update diffs,
+// whatever the diff settings.
+var secretDiffIgnoreFields = []string{
+	"data",
+	"stringData",
+	"metadata.annotations.kubectl.kubernetes.io/last-applied-configuration",
+}
+
 // Event indicate the informerEvent
 type Event struct {
 	key          string
 	eventType    string
 	namespace    string
 	resourceType string
+	// obj is the new state of an updated object, or the last known state
+	// of a deleted object, which is no longer in the store
+	obj interface{}
+	// oldObj is the previous state of an updated object
+	oldObj interface{}
+	// received is when the informer notified the change
+	received time.Time
+	// initialList tells whether the object was found by the initial listing
+	initialList bool
 }
 
 // Controller object
 type Controller struct {
 	logger       *logrus.Entry
+	cluster      string
+	resourceType string
 	clientset    kubernetes.Interface
 	queue        workqueue.RateLimitingInterface
+	// maxRetries is the number of times the handling of an event is retried
+	maxRetries   int
 	informer     cache.SharedIndexInformer
 	eventHandler handlers.Handler
+	diffIgnore   []string
+	// ignoreStatus skips the updates which only changed the status
+	ignoreStatus bool
+	events       config.Events
+	// createdWithin is how long before the start the objects notified as
+	// created may have been created
+	createdWithin time.Duration
+	// skipInitialList skips the objects found by the initial listing
+	skipInitialList bool
+	// listed is set once the initial listing is done
+	listed  int32
+	crashes config.Crashes
+	// redactor removes the secrets from the logs of the crashed containers
+	redactor *crash.Redactor
+	// nodes selects the changes of the health of the nodes notified
+	nodes nodes.Options
+	// eventTypes selects the created, updated and deleted objects notified
+	eventTypes config.EventTypes
+	enrich     config.Enrich
+	// optIn only notifies the objects annotated with notifyAnnotation
+	optIn bool
+	// relister lists the objects of the informer again on demand
+	relister *relister
+	// rollouts notifies the outcome of the rollouts instead of the updates
+	// of the deployments and stateful sets, when enabled
+	rollouts *rolloutTracker
+}
+
+// resourceKeys are the keys of the resource settings of the resource types.
+var resourceKeys = map[string]string{
+	"pod":                       "pod",
+	"daemon set":                "daemonset",
+	"replica set":               "replicaset",
+	"service":                   "services",
+	"deployment":                "deployment",
+	"namespace":                 "namespace",
+	"replication controller":    "replicationcontroller",
+	"job":                       "job",
+	"node":                      "node",
+	"service account":           "serviceaccount",
+	"cluster role":              "clusterrole",
+	"persistent volume":         "persistentvolume",
+	"persistent volume claim":   "persistentvolumeclaim",
+	"secret":                    "secret",
+	"configmap":                 "configmap",
+	"ingress":                   "ingress",
+	"stateful set":              "statefulset",
+	"cron job":                  "cronjob",
+	"horizontal pod autoscaler": "horizontalpodautoscaler",
+	"network policy":            "networkpolicy",
+	"event":                     "event",
 }
 
 // Start prepares watchers and run their controllers, then waits for process termination signals
 func Start(conf *config.Config, eventHandler handlers.Handler) {
-	var kubeClient kubernetes.Interface
+	stopCh := make(chan struct{})
+	go func() {
+		sigterm := make(chan os.Signal, 1)
+		signal.Notify(sigterm, syscall.SIGTERM)
+		signal.Notify(sigterm, syscall.SIGINT)
+		<-sigterm
+		close(stopCh)
+	}()
+	Watch(conf, eventHandler, stopCh)
+}
 
-	if _, err := rest.InClusterConfig(); err != nil {
-		kubeClient = utils.GetClientOutOfCluster()
-	} else {
-		kubeClient = utils.GetClient()
+// Watch prepares watchers and run their controllers until stopCh is closed,
+// returning once all of them stopped. The configured clusters are watched
+// instead of the current one when any.
+func Watch(conf *config.Config, eventHandler handlers.Handler, stopCh <-chan struct{}) {
+	if len(conf.Clusters) == 0 {
+		watchCluster(utils.GetKubeClient(), newMetadataClient(conf, utils.GetKubeMetadataClient), newDynamicClient(conf, utils.GetKubeDynamicClient), "", conf, eventHandler, stopCh)
+		return
 	}
 
-	// Adding Default Critical Alerts
-	// For Capturing Critical Event NodeNotReady in Nodes
-	nodeNotReadyInformer := cache.NewSharedIndexInformer(
-		&cache.ListWatch{
-			ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
-				options.FieldSelector = "involvedObject.kind=Node,type=Normal,reason=NodeNotReady"
-				return kubeClient.CoreV1().Events(conf.Namespace).List(options)
-			},
-			WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
-				options.FieldSelector = "involvedObject.kind=Node,type=Normal,reason=NodeNotReady"
-				return kubeClient.CoreV1().Events(conf.Namespace).Watch(options)
-			},
-		},
-		&api_v1.Event{},
-		0, //Skip resync
-		cache.Indexers{},
-	)
+	var wg sync.WaitGroup
+	for _, cluster := range conf.Clusters {
+		kubeClient, err := utils.GetClusterClient(cluster.Kubeconfig, cluster.Context)
+		if err != nil {
+			logrus.Errorf("Can not watch cluster %s: %v", cluster.Name, err)
+			continue
+		}
+		metadataClient := newMetadataClient(conf, func() (metadata_client.Interface, error) {
+			return utils.GetClusterMetadataClient(cluster.Kubeconfig, cluster.Context)
+		})
+		dynamicClient := newDynamicClient(conf, func() (dynamic.Interface, error) {
+			return utils.GetClusterDynamicClient(cluster.Kubeconfig, cluster.Context)
+		})
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			watchCluster(kubeClient, metadataClient, dynamicClient, name, conf, &clusterHandler{Cluster: name, Handler: eventHandler}, stopCh)
+		}(cluster.Name)
+	}
+	wg.Wait()
+}
 
-	nodeNotReadyController := newResourceController(kubeClient, eventHandler, nodeNotReadyInformer, "NodeNotReady")
-	stopNodeNotReadyCh := make(chan struct{})
-	defer close(stopNodeNotReadyCh)
+// clusterHandler sets the name of the cluster on the events.
+type clusterHandler struct {
+	Cluster string
+	handlers.Handler
+}
 
-	go nodeNotReadyController.Run(stopNodeNotReadyCh)
+func (h *clusterHandler) Handle(e event.Event) {
+	e.Cluster = h.Cluster
+	h.Handler.Handle(e)
+}
 
-	// For Capturing Critical Event NodeReady in Nodes
-	nodeReadyInformer := cache.NewSharedIndexInformer(
-		&cache.ListWatch{
-			ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
-				options.FieldSelector = "involvedObject.kind=Node,type=Normal,reason=NodeReady"
-				return kubeClient.CoreV1().Events(conf.Namespace).List(options)
-			},
-			WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
-				options.FieldSelector = "involvedObject.kind=Node,type=Normal,reason=NodeReady"
-				return kubeClient.CoreV1().Events(conf.Namespace).Watch(options)
-			},
-		},
-		&api_v1.Event{},
-		0, //Skip resync
-		cache.Indexers{},
-	)
+// newMetadataClient returns the client of the metadata of the objects returned
+// by get when some resources are watched through their metadata only, those
+// being fully watched otherwise.
+func newMetadataClient(conf *config.Config, get func() (metadata_client.Interface, error)) metadata_client.Interface {
+	if len(conf.Informers.MetadataOnly) == 0 {
+		return nil
+	}
+	client, err := get()
+	if err != nil {
+		logrus.Errorf("Can not create metadata client, watching the full objects: %v", err)
+		return nil
+	}
+	return client
+}
+
+// newDynamicClient returns the client of the Mute objects returned by get when
+// they are enabled.
+func newDynamicClient(conf *config.Config, get func() (dynamic.Interface, error)) dynamic.Interface {
+	if !conf.Mutes.Enabled {
+		return nil
+	}
+	client, err := get()
+	if err != nil {
+		logrus.Errorf("Can not create dynamic client, not watching the Mute objects: %v", err)
+		return nil
+	}
+	return client
+}
 
-	nodeReadyController := newResourceController(kubeClient, eventHandler, nodeReadyInformer, "NodeReady")
-	stopNodeReadyCh := make(chan struct{})
-	defer close(stopNodeReadyCh)
+// watchCluster runs the controllers of a cluster until stopCh is closed.
+// The objects of the resources configured are watched through their metadata
+// only when metadataClient is set, and the Mute objects are watched when
+// dynamicClient is set.
+func watchCluster(kubeClient kubernetes.Interface, metadataClient metadata_client.Interface, dynamicClient dynamic.Interface, cluster string, conf *config.Config, eventHandler handlers.Handler, stopCh <-chan struct{}) {
+	conf, alerts := checkPermissions(kubeClient, cluster, conf)
+
+	if conf.Owners.Collapse {
+		owners := newOwnersHandler(kubeClient, conf, eventHandler, stopCh)
+		defer owners.stop()
+		eventHandler = owners
+	}
 
-	go nodeReadyController.Run(stopNodeReadyCh)
+	var wg sync.WaitGroup
+	run := func(c *Controller) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.Run(stopCh)
+		}()
+	}
 
-	// For Capturing Critical Event NodeRebooted in Nodes
-	nodeRebootedInformer := cache.NewSharedIndexInformer(
-		&cache.ListWatch{
-			ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
-				options.FieldSelector = "involvedObject.kind=Node,type=Warning,reason=Rebooted"
-				return kubeClient.CoreV1().Events(conf.Namespace).List(options)
+	// Adding Default Critical Alerts
+	if alerts {
+		// For Capturing Critical Event NodeNotReady in Nodes
+		nodeNotReadyInformer := cache.NewSharedIndexInformer(
+			&cache.ListWatch{
+				ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
+					options.FieldSelector = "involvedObject.kind=Node,type=Normal,reason=NodeNotReady"
+					return kubeClient.CoreV1().Events(conf.Namespace).List(options)
+				},
+				WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
+					options.FieldSelector = "involvedObject.kind=Node,type=Normal,reason=NodeNotReady"
+					return kubeClient.CoreV1().Events(conf.Namespace).Watch(options)
+				},
 			},
-			WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
-				options.FieldSelector = "involvedObject.kind=Node,type=Warning,reason=Rebooted"
-				return kubeClient.CoreV1().Events(conf.Namespace).Watch(options)
+			&api_v1.Event{},
+			0, //Skip resync
+			cache.Indexers{},
+		)
+
+		nodeNotReadyController := newResourceController(kubeClient, cluster, eventHandler, nodeNotReadyInformer, "NodeNotReady", conf)
+		run(nodeNotReadyController)
+
+		// For Capturing Critical Event NodeReady in Nodes
+		nodeReadyInformer := cache.NewSharedIndexInformer(
+			&cache.ListWatch{
+				ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
+					options.FieldSelector = "involvedObject.kind=Node,type=Normal,reason=NodeReady"
+					return kubeClient.CoreV1().Events(conf.Namespace).List(options)
+				},
+				WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
+					options.FieldSelector = "involvedObject.kind=Node,type=Normal,reason=NodeReady"
+					return kubeClient.CoreV1().Events(conf.Namespace).Watch(options)
+				},
 			},
-		},
-		&api_v1.Event{},
-		0, //Skip resync
-		cache.Indexers{},
-	)
+			&api_v1.Event{},
+			0, //Skip resync
+			cache.Indexers{},
+		)
 
-	nodeRebootedController := newResourceController(kubeClient, eventHandler, nodeRebootedInformer, "NodeRebooted")
-	stopNodeRebootedCh := make(chan struct{})
-	defer close(stopNodeRebootedCh)
+		nodeReadyController := newResourceController(kubeClient, cluster, eventHandler, nodeReadyInformer, "NodeReady", conf)
+		run(nodeReadyController)
 
-	go nodeRebootedController.Run(stopNodeRebootedCh)
+		// For Capturing Critical Event NodeRebooted in Nodes
+		nodeRebootedInformer := cache.NewSharedIndexInformer(
+			&cache.ListWatch{
+				ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
+					options.FieldSelector = "involvedObject.kind=Node,type=Warning,reason=Rebooted"
+					return kubeClient.CoreV1().Events(conf.Namespace).List(options)
+				},
+				WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
+					options.FieldSelector = "involvedObject.kind=Node,type=Warning,reason=Rebooted"
+					return kubeClient.CoreV1().Events(conf.Namespace).Watch(options)
+				},
+			},
+			&api_v1.Event{},
+			0, //Skip resync
+			cache.Indexers{},
+		)
+
+		nodeRebootedController := newResourceController(kubeClient, cluster, eventHandler, nodeRebootedInformer, "NodeRebooted", conf)
+		run(nodeRebootedController)
+	}
 
 	// User Configured Events
 	if conf.Resource.Pod {
+		informer := newInformer(conf, metadataClient, "pod",
+			&cache.ListWatch{
+				ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
+					return kubeClient.CoreV1().Pods(conf.Namespace).List(options)
+				},
+				WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
+					return kubeClient.CoreV1().Pods(conf.Namespace).Watch(options)
+				},
+			},
+			&api_v1.Pod{},
+		)
+
+		c := newResourceController(kubeClient, cluster, eventHandler, informer, "pod", conf)
+		run(c)
+
+		// For Capturing CrashLoopBackOff Events in pods
+		if alerts {
+			backoffInformer := cache.NewSharedIndexInformer(
+				&cache.ListWatch{
+					ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
+						options.FieldSelector = "involvedObject.kind=Pod,type=Warning,reason=BackOff"
+						return kubeClient.CoreV1().Events(conf.Namespace).List(options)
+					},
+					WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
+						options.FieldSelector = "involvedObject.kind=Pod,type=Warning,reason=BackOff"
+						return kubeClient.CoreV1().Events(conf.Namespace).Watch(options)
+					},
+				},
+				&api_v1.Event{},
+				0, //Skip resync
+				cache.Indexers{},
+			)
+
+			backoffcontroller := newResourceController(kubeClient, cluster, eventHandler, backoffInformer, "Backoff", conf)
+			run(backoffcontroller)
+		}
+
+	}
+
+	// For Capturing the crashes of the containers of pods
+	if conf.Crashes.Enabled {
 		informer := cache.NewSharedIndexInformer(
 			&cache.ListWatch{
 				ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
@@ -163,39 +380,70 @@ func Start(conf *config.Config, eventHandler handlers.Handler) {
 			cache.Indexers{},
 		)
 
-		c := newResourceController(kubeClient, eventHandler, informer, "pod")
-		stopCh := make(chan struct{})
-		defer close(stopCh)
+		c := newResourceController(kubeClient, cluster, eventHandler, informer, "crash", conf)
+		run(c)
+	}
 
-		go c.Run(stopCh)
+	// For Checking the expiry of the certificates on a schedule
+	if conf.Certificates.Enabled {
+		checker := newCertificateChecker(kubeClient, cluster, conf, eventHandler)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			checker.Run(stopCh)
+		}()
+	}
 
-		// For Capturing CrashLoopBackOff Events in pods
-		backoffInformer := cache.NewSharedIndexInformer(
+	// For Checking the pending persistent volume claims on a schedule
+	if conf.Volumes.PendingAfter != "" {
+		checker, err := newClaimChecker(kubeClient, cluster, conf, eventHandler)
+		if err != nil {
+			logrus.WithField("cluster", cluster).Warnf("%v, not checking the pending claims", err)
+		} else {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				checker.Run(stopCh)
+			}()
+		}
+	}
+
+	// For Applying the Mute objects
+	if conf.Mutes.Enabled && dynamicClient != nil {
+		if muteServed(kubeClient) {
+			mutes := newMuteWatcher(dynamicClient, cluster, conf)
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				mutes.Run(stopCh)
+			}()
+		} else {
+			logrus.WithField("cluster", cluster).Warnf("The %s resource is not served, install the CRD of the Mute objects to apply them", muteResource.GroupResource())
+		}
+	}
+
+	// For Capturing the changes of the health of nodes
+	if conf.Nodes.Enabled {
+		informer := cache.NewSharedIndexInformer(
 			&cache.ListWatch{
 				ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
-					options.FieldSelector = "involvedObject.kind=Pod,type=Warning,reason=BackOff"
-					return kubeClient.CoreV1().Events(conf.Namespace).List(options)
+					return kubeClient.CoreV1().Nodes().List(options)
 				},
 				WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
-					options.FieldSelector = "involvedObject.kind=Pod,type=Warning,reason=BackOff"
-					return kubeClient.CoreV1().Events(conf.Namespace).Watch(options)
+					return kubeClient.CoreV1().Nodes().Watch(options)
 				},
 			},
-			&api_v1.Event{},
+			&api_v1.Node{},
 			0, //Skip resync
 			cache.Indexers{},
 		)
 
-		backoffcontroller := newResourceController(kubeClient, eventHandler, backoffInformer, "Backoff")
-		stopBackoffCh := make(chan struct{})
-		defer close(stopBackoffCh)
-
-		go backoffcontroller.Run(stopBackoffCh)
-
+		c := newResourceController(kubeClient, cluster, eventHandler, informer, "node health", conf)
+		run(c)
 	}
 
 	if conf.Resource.DaemonSet {
-		informer := cache.NewSharedIndexInformer(
+		informer := newInformer(conf, metadataClient, "daemon set",
 			&cache.ListWatch{
 				ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
 					return kubeClient.AppsV1().DaemonSets(conf.Namespace).List(options)
@@ -205,19 +453,14 @@ func Start(conf *config.Config, eventHandler handlers.Handler) {
 				},
 			},
 			&apps_v1.DaemonSet{},
-			0, //Skip resync
-			cache.Indexers{},
 		)
 
-		c := newResourceController(kubeClient, eventHandler, informer, "daemon set")
-		stopCh := make(chan struct{})
-		defer close(stopCh)
-
-		go c.Run(stopCh)
+		c := newResourceController(kubeClient, cluster, eventHandler, informer, "daemon set", conf)
+		run(c)
 	}
 
 	if conf.Resource.ReplicaSet {
-		informer := cache.NewSharedIndexInformer(
+		informer := newInformer(conf, metadataClient, "replica set",
 			&cache.ListWatch{
 				ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
 					return kubeClient.AppsV1().ReplicaSets(conf.Namespace).List(options)
@@ -227,19 +470,14 @@ func Start(conf *config.Config, eventHandler handlers.Handler) {
 				},
 			},
 			&apps_v1.ReplicaSet{},
-			0, //Skip resync
-			cache.Indexers{},
 		)
 
-		c := newResourceController(kubeClient, eventHandler, informer, "replica set")
-		stopCh := make(chan struct{})
-		defer close(stopCh)
-
-		go c.Run(stopCh)
+		c := newResourceController(kubeClient, cluster, eventHandler, informer, "replica set", conf)
+		run(c)
 	}
 
 	if conf.Resource.Services {
-		informer := cache.NewSharedIndexInformer(
+		informer := newInformer(conf, metadataClient, "service",
 			&cache.ListWatch{
 				ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
 					return kubeClient.CoreV1().Services(conf.Namespace).List(options)
@@ -249,19 +487,14 @@ func Start(conf *config.Config, eventHandler handlers.Handler) {
 				},
 			},
 			&api_v1.Service{},
-			0, //Skip resync
-			cache.Indexers{},
 		)
 
-		c := newResourceController(kubeClient, eventHandler, informer, "service")
-		stopCh := make(chan struct{})
-		defer close(stopCh)
-
-		go c.Run(stopCh)
+		c := newResourceController(kubeClient, cluster, eventHandler, informer, "service", conf)
+		run(c)
 	}
 
 	if conf.Resource.Deployment {
-		informer := cache.NewSharedIndexInformer(
+		informer := newInformer(conf, metadataClient, "deployment",
 			&cache.ListWatch{
 				ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
 					return kubeClient.AppsV1().Deployments(conf.Namespace).List(options)
@@ -271,19 +504,14 @@ func Start(conf *config.Config, eventHandler handlers.Handler) {
 				},
 			},
 			&apps_v1.Deployment{},
-			0, //Skip resync
-			cache.Indexers{},
 		)
 
-		c := newResourceController(kubeClient, eventHandler, informer, "deployment")
-		stopCh := make(chan struct{})
-		defer close(stopCh)
-
-		go c.Run(stopCh)
+		c := newResourceController(kubeClient, cluster, eventHandler, informer, "deployment", conf)
+		run(c)
 	}
 
 	if conf.Resource.Namespace {
-		informer := cache.NewSharedIndexInformer(
+		informer := newInformer(conf, metadataClient, "namespace",
 			&cache.ListWatch{
 				ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
 					return kubeClient.CoreV1().Namespaces().List(options)
@@ -293,19 +521,14 @@ func Start(conf *config.Config, eventHandler handlers.Handler) {
 				},
 			},
 			&api_v1.Namespace{},
-			0, //Skip resync
-			cache.Indexers{},
 		)
 
-		c := newResourceController(kubeClient, eventHandler, informer, "namespace")
-		stopCh := make(chan struct{})
-		defer close(stopCh)
-
-		go c.Run(stopCh)
+		c := newResourceController(kubeClient, cluster, eventHandler, informer, "namespace", conf)
+		run(c)
 	}
 
 	if conf.Resource.ReplicationController {
-		informer := cache.NewSharedIndexInformer(
+		informer := newInformer(conf, metadataClient, "replication controller",
 			&cache.ListWatch{
 				ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
 					return kubeClient.CoreV1().ReplicationControllers(conf.Namespace).List(options)
@@ -315,19 +538,14 @@ func Start(conf *config.Config, eventHandler handlers.Handler) {
 				},
 			},
 			&api_v1.ReplicationController{},
-			0, //Skip resync
-			cache.Indexers{},
 		)
 
-		c := newResourceController(kubeClient, eventHandler, informer, "replication controller")
-		stopCh := make(chan struct{})
-		defer close(stopCh)
-
-		go c.Run(stopCh)
+		c := newResourceController(kubeClient, cluster, eventHandler, informer, "replication controller", conf)
+		run(c)
 	}
 
 	if conf.Resource.Job {
-		informer := cache.NewSharedIndexInformer(
+		informer := newInformer(conf, metadataClient, "job",
 			&cache.ListWatch{
 				ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
 					return kubeClient.BatchV1().Jobs(conf.Namespace).List(options)
@@ -337,19 +555,14 @@ func Start(conf *config.Config, eventHandler handlers.Handler) {
 				},
 			},
 			&batch_v1.Job{},
-			0, //Skip resync
-			cache.Indexers{},
 		)
 
-		c := newResourceController(kubeClient, eventHandler, informer, "job")
-		stopCh := make(chan struct{})
-		defer close(stopCh)
-
-		go c.Run(stopCh)
+		c := newResourceController(kubeClient, cluster, eventHandler, informer, "job", conf)
+		run(c)
 	}
 
 	if conf.Resource.Node {
-		informer := cache.NewSharedIndexInformer(
+		informer := newInformer(conf, metadataClient, "node",
 			&cache.ListWatch{
 				ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
 					return kubeClient.CoreV1().Nodes().List(options)
@@ -359,19 +572,14 @@ func Start(conf *config.Config, eventHandler handlers.Handler) {
 				},
 			},
 			&api_v1.Node{},
-			0, //Skip resync
-			cache.Indexers{},
 		)
 
-		c := newResourceController(kubeClient, eventHandler, informer, "node")
-		stopCh := make(chan struct{})
-		defer close(stopCh)
-
-		go c.Run(stopCh)
+		c := newResourceController(kubeClient, cluster, eventHandler, informer, "node", conf)
+		run(c)
 	}
 
 	if conf.Resource.ServiceAccount {
-		informer := cache.NewSharedIndexInformer(
+		informer := newInformer(conf, metadataClient, "service account",
 			&cache.ListWatch{
 				ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
 					return kubeClient.CoreV1().ServiceAccounts(conf.Namespace).List(options)
@@ -381,19 +589,14 @@ func Start(conf *config.Config, eventHandler handlers.Handler) {
 				},
 			},
 			&api_v1.ServiceAccount{},
-			0, //Skip resync
-			cache.Indexers{},
 		)
 
-		c := newResourceController(kubeClient, eventHandler, informer, "service account")
-		stopCh := make(chan struct{})
-		defer close(stopCh)
-
-		go c.Run(stopCh)
+		c := newResourceController(kubeClient, cluster, eventHandler, informer, "service account", conf)
+		run(c)
 	}
 
 	if conf.Resource.ClusterRole {
-		informer := cache.NewSharedIndexInformer(
+		informer := newInformer(conf, metadataClient, "cluster role",
 			&cache.ListWatch{
 				ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
 					return kubeClient.RbacV1beta1().ClusterRoles().List(options)
@@ -403,19 +606,14 @@ func Start(conf *config.Config, eventHandler handlers.Handler) {
 				},
 			},
 			&rbac_v1beta1.ClusterRole{},
-			0, //Skip resync
-			cache.Indexers{},
 		)
 
-		c := newResourceController(kubeClient, eventHandler, informer, "cluster role")
-		stopCh := make(chan struct{})
-		defer close(stopCh)
-
-		go c.Run(stopCh)
+		c := newResourceController(kubeClient, cluster, eventHandler, informer, "cluster role", conf)
+		run(c)
 	}
 
 	if conf.Resource.PersistentVolume {
-		informer := cache.NewSharedIndexInformer(
+		informer := newInformer(conf, metadataClient, "persistent volume",
 			&cache.ListWatch{
 				ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
 					return kubeClient.CoreV1().PersistentVolumes().List(options)
@@ -425,19 +623,31 @@ func Start(conf *config.Config, eventHandler handlers.Handler) {
 				},
 			},
 			&api_v1.PersistentVolume{},
-			0, //Skip resync
-			cache.Indexers{},
 		)
 
-		c := newResourceController(kubeClient, eventHandler, informer, "persistent volume")
-		stopCh := make(chan struct{})
-		defer close(stopCh)
+		c := newResourceController(kubeClient, cluster, eventHandler, informer, "persistent volume", conf)
+		run(c)
+	}
 
-		go c.Run(stopCh)
+	if conf.Resource.PersistentVolumeClaim {
+		informer := newInformer(conf, metadataClient, "persistent volume claim",
+			&cache.ListWatch{
+				ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
+					return kubeClient.CoreV1().PersistentVolumeClaims(conf.Namespace).List(options)
+				},
+				WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
+					return kubeClient.CoreV1().PersistentVolumeClaims(conf.Namespace).Watch(options)
+				},
+			},
+			&api_v1.PersistentVolumeClaim{},
+		)
+
+		c := newResourceController(kubeClient, cluster, eventHandler, informer, "persistent volume claim", conf)
+		run(c)
 	}
 
 	if conf.Resource.Secret {
-		informer := cache.NewSharedIndexInformer(
+		informer := newInformer(conf, metadataClient, "secret",
 			&cache.ListWatch{
 				ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
 					return kubeClient.CoreV1().Secrets(conf.Namespace).List(options)
@@ -447,19 +657,14 @@ func Start(conf *config.Config, eventHandler handlers.Handler) {
 				},
 			},
 			&api_v1.Secret{},
-			0, //Skip resync
-			cache.Indexers{},
 		)
 
-		c := newResourceController(kubeClient, eventHandler, informer, "secret")
-		stopCh := make(chan struct{})
-		defer close(stopCh)
-
-		go c.Run(stopCh)
+		c := newResourceController(kubeClient, cluster, eventHandler, informer, "secret", conf)
+		run(c)
 	}
 
 	if conf.Resource.ConfigMap {
-		informer := cache.NewSharedIndexInformer(
+		informer := newInformer(conf, metadataClient, "configmap",
 			&cache.ListWatch{
 				ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
 					return kubeClient.CoreV1().ConfigMaps(conf.Namespace).List(options)
@@ -469,19 +674,14 @@ func Start(conf *config.Config, eventHandler handlers.Handler) {
 				},
 			},
 			&api_v1.ConfigMap{},
-			0, //Skip resync
-			cache.Indexers{},
 		)
 
-		c := newResourceController(kubeClient, eventHandler, informer, "configmap")
-		stopCh := make(chan struct{})
-		defer close(stopCh)
-
-		go c.Run(stopCh)
+		c := newResourceController(kubeClient, cluster, eventHandler, informer, "configmap", conf)
+		run(c)
 	}
 
 	if conf.Resource.Ingress {
-		informer := cache.NewSharedIndexInformer(
+		informer := newInformer(conf, metadataClient, "ingress",
 			&cache.ListWatch{
 				ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
 					return kubeClient.ExtensionsV1beta1().Ingresses(conf.Namespace).List(options)
@@ -491,51 +691,156 @@ func Start(conf *config.Config, eventHandler handlers.Handler) {
 				},
 			},
 			&ext_v1beta1.Ingress{},
-			0, //Skip resync
-			cache.Indexers{},
 		)
 
-		c := newResourceController(kubeClient, eventHandler, informer, "ingress")
-		stopCh := make(chan struct{})
-		defer close(stopCh)
+		c := newResourceController(kubeClient, cluster, eventHandler, informer, "ingress", conf)
+		run(c)
+	}
 
-		go c.Run(stopCh)
+	if conf.Resource.StatefulSet {
+		informer := newInformer(conf, metadataClient, "stateful set",
+			&cache.ListWatch{
+				ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
+					return kubeClient.AppsV1().StatefulSets(conf.Namespace).List(options)
+				},
+				WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
+					return kubeClient.AppsV1().StatefulSets(conf.Namespace).Watch(options)
+				},
+			},
+			&apps_v1.StatefulSet{},
+		)
+
+		c := newResourceController(kubeClient, cluster, eventHandler, informer, "stateful set", conf)
+		run(c)
+	}
+
+	if conf.Resource.CronJob {
+		informer := newInformer(conf, metadataClient, "cron job",
+			&cache.ListWatch{
+				ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
+					return kubeClient.BatchV1beta1().CronJobs(conf.Namespace).List(options)
+				},
+				WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
+					return kubeClient.BatchV1beta1().CronJobs(conf.Namespace).Watch(options)
+				},
+			},
+			&batch_v1beta1.CronJob{},
+		)
+
+		c := newResourceController(kubeClient, cluster, eventHandler, informer, "cron job", conf)
+		run(c)
+	}
+
+	if conf.Resource.HorizontalPodAutoscaler {
+		informer := newInformer(conf, metadataClient, "horizontal pod autoscaler",
+			&cache.ListWatch{
+				ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
+					return kubeClient.AutoscalingV1().HorizontalPodAutoscalers(conf.Namespace).List(options)
+				},
+				WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
+					return kubeClient.AutoscalingV1().HorizontalPodAutoscalers(conf.Namespace).Watch(options)
+				},
+			},
+			&autoscaling_v1.HorizontalPodAutoscaler{},
+		)
+
+		c := newResourceController(kubeClient, cluster, eventHandler, informer, "horizontal pod autoscaler", conf)
+		run(c)
+	}
+
+	if conf.Resource.NetworkPolicy {
+		informer := newInformer(conf, metadataClient, "network policy",
+			&cache.ListWatch{
+				ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
+					return kubeClient.NetworkingV1().NetworkPolicies(conf.Namespace).List(options)
+				},
+				WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
+					return kubeClient.NetworkingV1().NetworkPolicies(conf.Namespace).Watch(options)
+				},
+			},
+			&networking_v1.NetworkPolicy{},
+		)
+
+		c := newResourceController(kubeClient, cluster, eventHandler, informer, "network policy", conf)
+		run(c)
+	}
+
+	if conf.Resource.Event {
+		informer := newInformer(conf, metadataClient, "event",
+			&cache.ListWatch{
+				ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
+					return kubeClient.CoreV1().Events(conf.Namespace).List(options)
+				},
+				WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
+					return kubeClient.CoreV1().Events(conf.Namespace).Watch(options)
+				},
+			},
+			&api_v1.Event{},
+		)
+
+		c := newResourceController(kubeClient, cluster, eventHandler, informer, "event", conf)
+		run(c)
 	}
 
-	sigterm := make(chan os.Signal, 1)
-	signal.Notify(sigterm, syscall.SIGTERM)
-	signal.Notify(sigterm, syscall.SIGINT)
-	<-sigterm
+	wg.Wait()
 }
 
-func newResourceController(client kubernetes.Interface, eventHandler handlers.Handler, informer cache.SharedIndexInformer, resourceType string) *Controller {
-	queue := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
-	var newEvent Event
-	var err error
+func newResourceController(client kubernetes.Interface, cluster string, eventHandler handlers.Handler, informer cache.SharedIndexInformer, resourceType string, conf *config.Config) *Controller {
+	logger := logrus.WithField("pkg", "kubewatch-"+resourceType)
+	if cluster != "" {
+		logger = logger.WithField("cluster", cluster)
+	}
+	settings, err := newSettings(conf.Controller.For(resourceKeys[resourceType]))
+	if err != nil {
+		logger.Warnf("Invalid controller settings, retrying as by default: %v", err)
+		settings, _ = newSettings(config.Controller{})
+	}
+	queue := workqueue.NewRateLimitingQueue(settings.rateLimiter)
+	// the handlers are only called once the controller below runs
+	var c *Controller
 	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc: func(obj interface{}) {
+			var newEvent Event
+			var err error
 			newEvent.key, err = cache.MetaNamespaceKeyFunc(obj)
 			newEvent.eventType = "create"
+			newEvent.received = time.Now()
 			newEvent.resourceType = resourceType
+			newEvent.initialList = atomic.LoadInt32(&c.listed) == 0
 			logrus.WithField("pkg", "kubewatch-"+resourceType).Infof("Processing add to %v: %s", resourceType, newEvent.key)
 			if err == nil {
 				queue.Add(newEvent)
 			}
 		},
 		UpdateFunc: func(old, new interface{}) {
+			if resynced(old, new) {
+				return
+			}
+			var newEvent Event
+			var err error
 			newEvent.key, err = cache.MetaNamespaceKeyFunc(old)
 			newEvent.eventType = "update"
+			newEvent.received = time.Now()
 			newEvent.resourceType = resourceType
+			newEvent.obj = new
+			newEvent.oldObj = old
 			logrus.WithField("pkg", "kubewatch-"+resourceType).Infof("Processing update to %v: %s", resourceType, newEvent.key)
 			if err == nil {
 				queue.Add(newEvent)
 			}
 		},
 		DeleteFunc: func(obj interface{}) {
+			var newEvent Event
+			var err error
 			newEvent.key, err = cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
 			newEvent.eventType = "delete"
+			newEvent.received = time.Now()
 			newEvent.resourceType = resourceType
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
 			newEvent.namespace = utils.GetObjectMetaData(obj).Namespace
+			newEvent.obj = obj
 			logrus.WithField("pkg", "kubewatch-"+resourceType).Infof("Processing delete to %v: %s", resourceType, newEvent.key)
 			if err == nil {
 				queue.Add(newEvent)
@@ -543,19 +848,82 @@ func newResourceController(client kubernetes.Interface, eventHandler handlers.Ha
 		},
 	})
 
-	return &Controller{
-		logger:       logrus.WithField("pkg", "kubewatch-"+resourceType),
-		clientset:    client,
-		informer:     informer,
-		queue:        queue,
-		eventHandler: eventHandler,
+	var createdWithin time.Duration
+	if conf.Startup.NotifyCreatedWithin != "" {
+		d, err := time.ParseDuration(conf.Startup.NotifyCreatedWithin)
+		if err != nil {
+			logger.Warnf("Invalid startup notifyCreatedWithin %q, only notifying the objects created after the start: %v", conf.Startup.NotifyCreatedWithin, err)
+		}
+		createdWithin = d
 	}
+	redactor, err := crash.NewRedactor(conf.Crashes.Redact)
+	if err != nil {
+		logger.Warnf("%v, only removing the usual forms of secrets from the logs", err)
+		redactor, _ = crash.NewRedactor(nil)
+	}
+	c = &Controller{
+		logger:          logger,
+		cluster:         cluster,
+		resourceType:    resourceType,
+		clientset:       client,
+		informer:        informer,
+		queue:           queue,
+		maxRetries:      settings.maxRetries,
+		eventHandler:    eventHandler,
+		diffIgnore:      diffIgnoreFields(conf.Diff, resourceType),
+		ignoreStatus:    ignoresStatusUpdates(conf.Diff.IgnoreStatusUpdates, resourceType),
+		events:          conf.Events,
+		createdWithin:   createdWithin,
+		skipInitialList: conf.Startup.SkipInitialList,
+		crashes:         conf.Crashes,
+		redactor:        redactor,
+		nodes: nodes.Options{
+			Conditions:    conf.Nodes.Conditions,
+			IgnoreCordons: conf.Nodes.IgnoreCordons,
+			IgnoreTaints:  conf.Nodes.IgnoreTaints,
+		},
+		eventTypes: conf.Resource.EventTypes(resourceKeys[resourceType]),
+		enrich:     conf.Enrich,
+		optIn:      conf.OptIn,
+	}
+	if ri, ok := informer.(*relistingInformer); ok {
+		c.relister = ri.relister
+	}
+	if conf.Rollouts.Enabled && (resourceType == "deployment" || resourceType == "stateful set") {
+		var timeout time.Duration
+		if conf.Rollouts.Timeout != "" {
+			if timeout, err = time.ParseDuration(conf.Rollouts.Timeout); err != nil {
+				logger.Warnf("Invalid rollouts timeout %q, waiting for the progress deadlines: %v", conf.Rollouts.Timeout, err)
+				timeout = 0
+			}
+		}
+		c.rollouts = newRolloutTracker(c, timeout)
+	}
+	health.AddReadinessCheck(c.checkName(), func() error {
+		if !c.HasSynced() {
+			return fmt.Errorf("%s cache not synced", resourceType)
+		}
+		return nil
+	})
+	return c
+}
+
+// resynced reports whether the update of the object is a resync of the
+// informer, the object being unchanged.
+func resynced(old, new interface{}) bool {
+	o, err := meta.Accessor(old)
+	if err != nil {
+		return false
+	}
+	n, err := meta.Accessor(new)
+	return err == nil && o.GetResourceVersion() != "" && o.GetResourceVersion() == n.GetResourceVersion()
 }
 
 // Run starts the kubewatch controller
 func (c *Controller) Run(stopCh <-chan struct{}) {
 	defer utilruntime.HandleCrash()
 	defer c.queue.ShutDown()
+	defer health.RemoveReadinessCheck(c.checkName())
 
 	c.logger.Info("Starting kubewatch controller")
 	serverStartTime = time.Now().Local()
@@ -567,9 +935,47 @@ func (c *Controller) Run(stopCh <-chan struct{}) {
 		return
 	}
 
+	atomic.StoreInt32(&c.listed, 1)
 	c.logger.Info("Kubewatch controller synced and ready")
+	go wait.Until(c.recordCacheSize, cacheSizeInterval, stopCh)
+	defer metrics.InformerObjects.DeleteLabelValues(c.cluster, c.resourceType)
+	running.Lock()
+	running.controllers[c] = true
+	running.Unlock()
+	defer func() {
+		running.Lock()
+		delete(running.controllers, c)
+		running.Unlock()
+	}()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer utilruntime.HandleCrash()
+		c.runWorker()
+	}()
+
+	// the events queued when stopping are still processed before returning
+	<-stopCh
+	c.queue.ShutDown()
+	<-done
+}
+
+// cacheSizeInterval is how often the number of objects in the cache of the
+// informers is recorded.
+const cacheSizeInterval = 30 * time.Second
 
-	wait.Until(c.runWorker, time.Second, stopCh)
+// recordCacheSize records the number of objects in the cache of the informer.
+func (c *Controller) recordCacheSize() {
+	metrics.InformerObjects.WithLabelValues(c.cluster, c.resourceType).Set(float64(len(c.informer.GetStore().ListKeys())))
+}
+
+// checkName is the name of the readiness check of the controller.
+func (c *Controller) checkName() string {
+	if c.cluster != "" {
+		return "informer " + c.cluster + " " + c.resourceType
+	}
+	return "informer " + c.resourceType
 }
 
 // HasSynced is required for the cache.Controller interface.
@@ -599,7 +1005,7 @@ func (c *Controller) processNextItem() bool {
 	if err == nil {
 		// No error, reset the ratelimit counters
 		c.queue.Forget(newEvent)
-	} else if c.queue.NumRequeues(newEvent) < maxRetries {
+	} else if c.queue.NumRequeues(newEvent) < c.maxRetries {
 		c.logger.Errorf("Error processing %s (will retry): %v", newEvent.(Event).key, err)
 		c.queue.AddRateLimited(newEvent)
 	} else {
@@ -619,10 +1025,13 @@ func (c *Controller) processNextItem() bool {
 */
 
 func (c *Controller) processItem(newEvent Event) error {
-	obj, _, err := c.informer.GetIndexer().GetByKey(newEvent.key)
+	obj, exists, err := c.informer.GetIndexer().GetByKey(newEvent.key)
 	if err != nil {
 		return fmt.Errorf("Error fetching object with key %s from store: %v", newEvent.key, err)
 	}
+	if !exists && newEvent.obj != nil {
+		obj = newEvent.obj
+	}
 	// get object's metedata
 	objectMeta := utils.GetObjectMetaData(obj)
 
@@ -636,12 +1045,43 @@ func (c *Controller) processItem(newEvent Event) error {
 		newEvent.key = substring[1]
 	}
 
+	if newEvent.initialList && c.skipInitialList {
+		c.logger.Debugf("Skipping %s found by the initial listing", newEvent.key)
+		return nil
+	}
+
+	if !c.eventTypes.Selected(newEvent.eventType) {
+		c.logger.Debugf("Skipping %s of %s, not selected", newEvent.eventType, newEvent.key)
+		return nil
+	}
+
+	if newEvent.resourceType == "event" {
+		if newEvent.eventType != "delete" {
+			c.processKubeEvent(obj, newEvent.received)
+		}
+		return nil
+	}
+
+	if newEvent.resourceType == "crash" {
+		if newEvent.eventType == "update" {
+			c.processCrashes(newEvent.oldObj, newEvent.obj, newEvent.received)
+		}
+		return nil
+	}
+
+	if newEvent.resourceType == "node health" {
+		if newEvent.eventType == "update" {
+			c.processNodeHealth(newEvent.oldObj, newEvent.obj, newEvent.received)
+		}
+		return nil
+	}
+
 	// process events based on its type
 	switch newEvent.eventType {
 	case "create":
 		// compare CreationTimestamp and serverStartTime and alert only on latest events
 		// Could be Replaced by using Delta or DeltaFIFO
-		if objectMeta.CreationTimestamp.Sub(serverStartTime).Seconds() > 0 {
+		if objectMeta.CreationTimestamp.Time.After(c.notifiedSince()) {
 			switch newEvent.resourceType {
 			case "NodeNotReady":
 				status = "Danger"
@@ -660,14 +1100,37 @@ func (c *Controller) processItem(newEvent Event) error {
 				Kind:      newEvent.resourceType,
 				Status:    status,
 				Reason:    "Created",
+				Operation: "create",
+				Labels:    objectMeta.Labels,
+				Object:    snapshot(obj),
 			}
-			c.eventHandler.Handle(kbEvent)
+			describeVolume(&kbEvent, nil, obj)
+			c.handle(kbEvent, newEvent.received)
 			return nil
 		}
 	case "update":
-		/* TODOs
-		- enahace update event processing in such a way that, it send alerts about what got changed.
-		*/
+		rollout := c.rollouts != nil && c.rollouts.started(newEvent.oldObj, newEvent.obj)
+		if c.rollouts != nil && !rollout && newEvent.obj != nil {
+			c.rollouts.progress(newEvent.obj)
+		}
+		if c.ignoreStatus && newEvent.oldObj != nil && newEvent.obj != nil {
+			statusOnly, err := event.StatusOnly(newEvent.oldObj, newEvent.obj)
+			if err != nil {
+				c.logger.Warnf("Cannot compute diff of %s: %v", newEvent.key, err)
+			}
+			if statusOnly {
+				c.logger.Debugf("Skipping status update of %s", newEvent.key)
+				return nil
+			}
+		}
+		var diff []event.Change
+		var images []event.ImageChange
+		if newEvent.oldObj != nil && newEvent.obj != nil {
+			if diff, err = event.Diff(newEvent.oldObj, newEvent.obj, c.diffIgnore); err != nil {
+				c.logger.Warnf("Cannot compute diff of %s: %v", newEvent.key, err)
+			}
+			images = event.ImageChanges(newEvent.oldObj, newEvent.obj)
+		}
 		switch newEvent.resourceType {
 		case "Backoff":
 			status = "Danger"
@@ -680,19 +1143,289 @@ func (c *Controller) processItem(newEvent Event) error {
 			Kind:      newEvent.resourceType,
 			Status:    status,
 			Reason:    "Updated",
+			Operation: "update",
+			Labels:    objectMeta.Labels,
+			Diff:      diff,
+			Images:    images,
+			Object:    snapshot(obj),
+			OldObject: snapshot(newEvent.oldObj),
 		}
-		c.eventHandler.Handle(kbEvent)
+		describeVolume(&kbEvent, newEvent.oldObj, obj)
+		if rollout {
+			c.rollouts.start(kbEvent, newEvent.obj, newEvent.received)
+			return nil
+		}
+		c.handle(kbEvent, newEvent.received)
 		return nil
 	case "delete":
+		if c.rollouts != nil {
+			c.rollouts.forget(newEvent.namespace + "/" + newEvent.key)
+		}
 		kbEvent := event.Event{
 			Name:      newEvent.key,
 			Namespace: newEvent.namespace,
 			Kind:      newEvent.resourceType,
 			Status:    "Danger",
 			Reason:    "Deleted",
+			Operation: "delete",
+			Labels:    objectMeta.Labels,
+			Object:    snapshot(obj),
 		}
-		c.eventHandler.Handle(kbEvent)
+		describeVolume(&kbEvent, nil, obj)
+		c.handle(kbEvent, newEvent.received)
 		return nil
 	}
 	return nil
 }
+
+// snapshot returns the object to attach to an event, without the values of
+// secrets. The objects of the informer caches must not be modified.
+func snapshot(obj interface{}) interface{} {
+	secret, ok := obj.(*api_v1.Secret)
+	if !ok {
+		return obj
+	}
+	secret = secret.DeepCopy()
+	secret.Data = nil
+	secret.StringData = nil
+	delete(secret.Annotations, "kubectl.kubernetes.io/last-applied-configuration")
+	return secret
+}
+
+// diffIgnoreFields returns the fields left out of the update diffs of the
+// resource type: the configured ones and, for secrets, their values, only
+// the metadata of secrets being reported.
+func diffIgnoreFields(conf config.Diff, resourceType string) []string {
+	if resourceType != "secret" {
+		return conf.IgnoreFields
+	}
+	return append(append([]string{}, conf.IgnoreFields...), secretDiffIgnoreFields...)
+}
+
+// ignoresStatusUpdates reports whether the kinds include the resource type.
+func ignoresStatusUpdates(kinds []string, resourceType string) bool {
+	for _, k := range kinds {
+		if k == "*" || strings.EqualFold(k, resourceType) {
+			return true
+		}
+	}
+	return false
+}
+
+// processCrashes notifies the crashes of the containers of an updated pod,
+// with the last logs of the crashed containers.
+func (c *Controller) processCrashes(oldObj, newObj interface{}, received time.Time) {
+	old, ok := oldObj.(*api_v1.Pod)
+	if !ok {
+		return
+	}
+	pod, ok := newObj.(*api_v1.Pod)
+	if !ok {
+		return
+	}
+
+	lines, maxBytes := int64(c.crashes.LogLines), c.crashes.MaxLogBytes
+	if lines == 0 {
+		lines = 20
+	}
+	if maxBytes <= 0 {
+		maxBytes = 2048
+	}
+	for _, cr := range crash.Detect(old, pod) {
+		details := cr.Description()
+		if lines > 0 {
+			logs, err := crash.Logs(c.clientset, pod, cr.Container, lines)
+			if err != nil {
+				c.logger.Warnf("Cannot get the logs of container %s of pod %s/%s: %v", cr.Container, pod.Namespace, pod.Name, err)
+			} else if logs = strings.TrimRight(crash.Truncate(c.redactor.Redact(logs), maxBytes), "\n"); logs != "" {
+				details += "\nLast logs:\n```\n" + logs + "\n```"
+			}
+		}
+		c.handle(event.Event{
+			Name:      pod.Name,
+			Namespace: pod.Namespace,
+			Kind:      "pod",
+			Host:      pod.Spec.NodeName,
+			Status:    "Danger",
+			Reason:    cr.Reason,
+			Operation: "update",
+			Labels:    pod.Labels,
+			Details:   details,
+			Object:    snapshot(pod),
+		}, received)
+	}
+}
+
+// processNodeHealth notifies the changes of the health of an updated node:
+// the transitions of its conditions, its cordon and its taints.
+func (c *Controller) processNodeHealth(oldObj, newObj interface{}, received time.Time) {
+	old, ok := oldObj.(*api_v1.Node)
+	if !ok {
+		return
+	}
+	node, ok := newObj.(*api_v1.Node)
+	if !ok {
+		return
+	}
+
+	for _, change := range nodes.Detect(old, node, c.nodes) {
+		status := "Danger"
+		switch {
+		case change.Healthy:
+			status = "Normal"
+		case change.Reason == nodes.Cordoned || change.Reason == nodes.TaintAdded:
+			status = "Warning"
+		}
+		c.handle(event.Event{
+			Name:      node.Name,
+			Kind:      "node",
+			Host:      node.Name,
+			Status:    status,
+			Reason:    change.Reason,
+			Operation: "update",
+			Labels:    node.Labels,
+			Details:   change.Description,
+			Object:    snapshot(node),
+		}, received)
+	}
+}
+
+// notifiedSince returns the creation time of the oldest objects notified
+// as created.
+func (c *Controller) notifiedSince() time.Time {
+	return serverStartTime.Add(-c.createdWithin)
+}
+
+// processKubeEvent forwards a new or recurring Kubernetes Event about an
+// object, when selected by the events configuration.
+func (c *Controller) processKubeEvent(obj interface{}, received time.Time) {
+	ev, ok := obj.(*api_v1.Event)
+	if !ok {
+		return
+	}
+	// skip the events which occurred before kubewatch started, less createdWithin
+	last := ev.LastTimestamp.Time
+	if last.IsZero() {
+		last = ev.EventTime.Time
+	}
+	if last.IsZero() {
+		last = ev.CreationTimestamp.Time
+	}
+	if last.Before(c.notifiedSince()) {
+		return
+	}
+	if !filter.MatchKubeEvent(c.events, ev.Reason, ev.Type) {
+		return
+	}
+
+	status := "Normal"
+	if ev.Type == api_v1.EventTypeWarning {
+		status = "Warning"
+	}
+	namespace := ev.InvolvedObject.Namespace
+	if namespace == "" {
+		namespace = ev.Namespace
+	}
+	c.handle(event.Event{
+		Name:      ev.InvolvedObject.Name,
+		Namespace: namespace,
+		Kind:      strings.ToLower(ev.InvolvedObject.Kind),
+		Component: ev.Source.Component,
+		Host:      ev.Source.Host,
+		Status:    status,
+		Reason:    ev.Reason,
+		Details:   ev.Message,
+		Ref: &event.ObjectReference{
+			APIVersion:      ev.InvolvedObject.APIVersion,
+			Kind:            ev.InvolvedObject.Kind,
+			Namespace:       namespace,
+			Name:            ev.InvolvedObject.Name,
+			UID:             string(ev.InvolvedObject.UID),
+			ResourceVersion: ev.InvolvedObject.ResourceVersion,
+		},
+		Timestamp: last,
+	}, received)
+}
+
+// handle passes the event to the handler within a span covering its
+// handling, from the time the informer received it.
+func (c *Controller) handle(e event.Event, received time.Time) {
+	ctx, span := tracing.Tracer().Start(context.Background(), "kubewatch.event",
+		trace.WithTimestamp(received),
+		trace.WithAttributes(tracing.Attributes(e)...),
+	)
+	defer span.End()
+	span.AddEvent("received", trace.WithTimestamp(received))
+	if obj, ok := e.Object.(meta_v1.Object); ok {
+		if !c.notified(obj) {
+			c.logger.WithFields(e.LogFields()).Debug("Skipping event, not notified by the annotations of the object")
+			span.AddEvent("ignored")
+			return
+		}
+		e.Metadata = metadata(c.enrich, obj)
+		e.Channels = channels(obj)
+	}
+	if e.Ref == nil {
+		e.Ref = event.Reference(e.Object)
+	}
+	if e.Timestamp.IsZero() {
+		e.Timestamp = received
+	}
+	c.eventHandler.Handle(e.WithContext(ctx))
+}
+
+const (
+	// ignoreAnnotation set to "true" skips the events of an object.
+	ignoreAnnotation = "kubewatch.io/ignore"
+	// notifyAnnotation set to "true" notifies the events of an object when
+	// opting in, and set to "false" skips them otherwise.
+	notifyAnnotation = "kubewatch.io/notify"
+)
+
+// notified reports whether the annotations of the object let its events be
+// notified.
+func (c *Controller) notified(obj meta_v1.Object) bool {
+	annotations := obj.GetAnnotations()
+	if annotations[ignoreAnnotation] == "true" || annotations[notifyAnnotation] == "false" {
+		return false
+	}
+	return !c.optIn || annotations[notifyAnnotation] == "true"
+}
+
+// channels returns the channels set by the kubewatch.io/<handler>-channel
+// annotations of the object, by handler, e.g. slack: "#team-payments".
+func channels(obj meta_v1.Object) map[string]string {
+	var m map[string]string
+	for k, v := range obj.GetAnnotations() {
+		if !strings.HasPrefix(k, "kubewatch.io/") || !strings.HasSuffix(k, "-channel") || v == "" {
+			continue
+		}
+		handler := strings.TrimSuffix(strings.TrimPrefix(k, "kubewatch.io/"), "-channel")
+		if m == nil {
+			m = map[string]string{}
+		}
+		m[handler] = v
+	}
+	return m
+}
+
+// metadata returns the labels and annotations of the object selected by the
+// enrich settings, nil when it has none of them.
+func metadata(conf config.Enrich, obj meta_v1.Object) map[string]string {
+	var m map[string]string
+	add := func(keys []string, values map[string]string) {
+		for _, k := range keys {
+			v, ok := values[k]
+			if !ok {
+				continue
+			}
+			if m == nil {
+				m = map[string]string{}
+			}
+			m[k] = v
+		}
+	}
+	add(conf.Labels, obj.GetLabels())
+	add(conf.Annotations, obj.GetAnnotations())
+	return m
+}
@@ -0,0 +1,57 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+
+	api_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSecretDiff(t *testing.T) {
+	old := &api_v1.Secret{
+		ObjectMeta: meta_v1.ObjectMeta{Name: "db", Annotations: map[string]string{"kubectl.kubernetes.io/last-applied-configuration": `{"data":{"password":"b2xk"}}`}},
+		Data:       map[string][]byte{"password": []byte("old")},
+		StringData: map[string]string{"user": "old"},
+	}
+	updated := &api_v1.Secret{
+		ObjectMeta: meta_v1.ObjectMeta{Name: "db", Labels: map[string]string{"team": "payments"}, Annotations: map[string]string{"kubectl.kubernetes.io/last-applied-configuration": `{"data":{"password":"bmV3"}}`}},
+		Data:       map[string][]byte{"password": []byte("new"), "token": []byte("new")},
+		StringData: map[string]string{"user": "new"},
+	}
+
+	for _, conf := range []config.Diff{{}, {IgnoreFields: []string{"metadata.labels"}}} {
+		changes, err := event.Diff(old, updated, diffIgnoreFields(conf, "secret"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, c := range changes {
+			if !strings.HasPrefix(c.Path, "metadata.labels") {
+				t.Fatalf("Diff(%+v): got the change %+v of a secret", conf, c)
+			}
+		}
+	}
+
+	if got := diffIgnoreFields(config.Diff{IgnoreFields: []string{"status"}}, "config map"); len(got) != 1 {
+		t.Fatalf("diffIgnoreFields(): got %v for a config map, want the configured fields", got)
+	}
+}
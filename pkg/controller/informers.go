@@ -0,0 +1,180 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"github.com/bitnami-labs/kubewatch/config"
+	"k8s.io/apimachinery/pkg/api/meta"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	metadata_client "k8s.io/client-go/metadata"
+	"k8s.io/client-go/tools/cache"
+)
+
+// metadataResources are the resources of the resource types which can be
+// watched through their metadata only. The events are not, as their
+// reasons and messages are notified.
+var metadataResources = map[string]schema.GroupVersionResource{
+	"pod":                       {Version: "v1", Resource: "pods"},
+	"daemon set":                {Group: "apps", Version: "v1", Resource: "daemonsets"},
+	"replica set":               {Group: "apps", Version: "v1", Resource: "replicasets"},
+	"service":                   {Version: "v1", Resource: "services"},
+	"deployment":                {Group: "apps", Version: "v1", Resource: "deployments"},
+	"namespace":                 {Version: "v1", Resource: "namespaces"},
+	"replication controller":    {Version: "v1", Resource: "replicationcontrollers"},
+	"job":                       {Group: "batch", Version: "v1", Resource: "jobs"},
+	"node":                      {Version: "v1", Resource: "nodes"},
+	"service account":           {Version: "v1", Resource: "serviceaccounts"},
+	"cluster role":              {Group: "rbac.authorization.k8s.io", Version: "v1beta1", Resource: "clusterroles"},
+	"persistent volume":         {Version: "v1", Resource: "persistentvolumes"},
+	"persistent volume claim":   {Version: "v1", Resource: "persistentvolumeclaims"},
+	"secret":                    {Version: "v1", Resource: "secrets"},
+	"configmap":                 {Version: "v1", Resource: "configmaps"},
+	"ingress":                   {Group: "extensions", Version: "v1beta1", Resource: "ingresses"},
+	"stateful set":              {Group: "apps", Version: "v1", Resource: "statefulsets"},
+	"cron job":                  {Group: "batch", Version: "v1beta1", Resource: "cronjobs"},
+	"horizontal pod autoscaler": {Group: "autoscaling", Version: "v1", Resource: "horizontalpodautoscalers"},
+	"network policy":            {Group: "networking.k8s.io", Version: "v1", Resource: "networkpolicies"},
+}
+
+// SupportsMetadataOnly reports whether the objects of the resource, e.g.
+// "secret", can be watched through their metadata only.
+func SupportsMetadataOnly(key string) bool {
+	for resourceType, k := range resourceKeys {
+		if k == key {
+			_, ok := metadataResources[resourceType]
+			return ok
+		}
+	}
+	return false
+}
+
+// newInformer returns the informer of the objects of the resource type
+// listed and watched by lw, or of their metadata only when configured and
+// metadataClient is set. The objects are selected by the selector of the
+// resource and their managed fields dropped before they are cached, and
+// resynced with the period configured for the resource.
+func newInformer(conf *config.Config, metadataClient metadata_client.Interface, resourceType string, lw *cache.ListWatch, objType runtime.Object) cache.SharedIndexInformer {
+	if gvr, ok := metadataResources[resourceType]; ok && metadataClient != nil && watchesMetadataOnly(conf.Informers, resourceKeys[resourceType]) {
+		namespace := conf.Namespace
+		if isClusterScoped(resourceType) {
+			namespace = ""
+		}
+		client := metadataClient.Resource(gvr).Namespace(namespace)
+		lw = &cache.ListWatch{
+			ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
+				return client.List(options)
+			},
+			WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
+				return client.Watch(options)
+			},
+		}
+		objType = &meta_v1.PartialObjectMetadata{}
+	}
+	lw = selected(conf, resourceType, lw)
+	if !conf.Informers.KeepManagedFields {
+		lw = transformed(lw, nil, dropManagedFields)
+	}
+	// the invalid settings are reported by the controller
+	settings, _ := newSettings(conf.Controller.For(resourceKeys[resourceType]))
+	r := &relister{lw: lw}
+	informer := cache.NewSharedIndexInformer(r, objType, settings.resyncPeriod, cache.Indexers{})
+	r.store = informer.GetStore()
+	return &relistingInformer{SharedIndexInformer: informer, relister: r}
+}
+
+// relistingInformer is an informer whose objects can be listed again, see
+// Relist.
+type relistingInformer struct {
+	cache.SharedIndexInformer
+	relister *relister
+}
+
+// watchesMetadataOnly reports whether the objects of the resource of the
+// key are watched through their metadata only.
+func watchesMetadataOnly(conf config.Informers, key string) bool {
+	for _, k := range conf.MetadataOnly {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// isClusterScoped reports whether the objects of the resource type belong
+// to no namespace.
+func isClusterScoped(resourceType string) bool {
+	switch resourceType {
+	case "namespace", "node", "cluster role", "persistent volume":
+		return true
+	}
+	return false
+}
+
+// dropManagedFields removes the managed fields of the object, which are
+// never notified, keeping it.
+func dropManagedFields(obj runtime.Object) bool {
+	if o, err := meta.Accessor(obj); err == nil {
+		o.SetManagedFields(nil)
+	}
+	return true
+}
+
+// transformed returns the list and watch functions of lw, setting the list
+// options with options and keeping the objects received for which keep
+// returns true, which may modify them.
+func transformed(lw *cache.ListWatch, options func(*meta_v1.ListOptions), keep func(runtime.Object) bool) *cache.ListWatch {
+	return &cache.ListWatch{
+		ListFunc: func(opts meta_v1.ListOptions) (runtime.Object, error) {
+			if options != nil {
+				options(&opts)
+			}
+			list, err := lw.ListFunc(opts)
+			if err != nil || keep == nil {
+				return list, err
+			}
+			items, err := meta.ExtractList(list)
+			if err != nil {
+				return nil, err
+			}
+			kept := items[:0]
+			for _, item := range items {
+				if keep(item) {
+					kept = append(kept, item)
+				}
+			}
+			return list, meta.SetList(list, kept)
+		},
+		WatchFunc: func(opts meta_v1.ListOptions) (watch.Interface, error) {
+			if options != nil {
+				options(&opts)
+			}
+			w, err := lw.WatchFunc(opts)
+			if err != nil || keep == nil {
+				return w, err
+			}
+			return watch.Filter(w, func(e watch.Event) (watch.Event, bool) {
+				if e.Type == watch.Error || e.Type == watch.Bookmark {
+					return e, true
+				}
+				return e, keep(e.Object)
+			}), nil
+		},
+	}
+}
@@ -0,0 +1,140 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bitnami-labs/kubewatch/config"
+
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
+	metadata_fake "k8s.io/client-go/metadata/fake"
+	"k8s.io/client-go/tools/cache"
+)
+
+// runController runs the controller until the returned function is called,
+// which waits for Run to return, once its initial listing is done.
+func runController(t *testing.T, c *Controller) func() {
+	stopCh, done := make(chan struct{}), make(chan struct{})
+	go func() {
+		c.Run(stopCh)
+		close(done)
+	}()
+	for deadline := time.Now().Add(5 * time.Second); atomic.LoadInt32(&c.listed) == 0; time.Sleep(5 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("the controller did not list the objects")
+		}
+	}
+	return func() {
+		close(stopCh)
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("Run() did not return")
+		}
+	}
+}
+
+// secretMetadata returns the metadata of a secret with managed fields.
+func secretMetadata(name string, created time.Time) *meta_v1.PartialObjectMetadata {
+	return &meta_v1.PartialObjectMetadata{
+		TypeMeta: meta_v1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:              name,
+			Namespace:         "shop",
+			Labels:            map[string]string{"app": "web"},
+			CreationTimestamp: meta_v1.NewTime(created),
+			ManagedFields:     []meta_v1.ManagedFieldsEntry{{Manager: "kubectl", Operation: meta_v1.ManagedFieldsOperationApply}},
+		},
+	}
+}
+
+func TestMetadataOnlyInformer(t *testing.T) {
+	scheme := runtime.NewScheme()
+	meta_v1.AddMetaToScheme(scheme)
+	metadataClient := metadata_fake.NewSimpleMetadataClient(scheme, secretMetadata("existing", time.Now().Add(-time.Hour)))
+	conf := &config.Config{Informers: config.Informers{MetadataOnly: []string{"secret"}}}
+
+	// the typed listing fails, the objects must be listed through their metadata
+	unused := &cache.ListWatch{
+		ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
+			t.Fatal("the secrets were listed in full")
+			return nil, nil
+		},
+		WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
+			t.Fatal("the secrets were watched in full")
+			return nil, nil
+		},
+	}
+	informer := newInformer(conf, metadataClient, "secret", unused, nil)
+	next := &recorder{}
+	c := newResourceController(fake.NewSimpleClientset(), "", next, informer, "secret", conf)
+	stop := runController(t, c)
+	defer stop()
+
+	obj, ok, err := informer.GetStore().GetByKey("shop/existing")
+	if err != nil || !ok {
+		t.Fatalf("the listed secret is not cached: %v", err)
+	}
+	if cached := obj.(*meta_v1.PartialObjectMetadata); len(cached.ManagedFields) > 0 {
+		t.Fatalf("the managed fields of the cached secret were kept: %+v", cached.ManagedFields)
+	}
+
+	gvr := metadataResources["secret"]
+	created := secretMetadata("db", time.Now().Add(time.Second))
+	if _, err := metadataClient.Resource(gvr).Namespace("shop").(metadata_fake.MetadataClient).CreateFake(created, meta_v1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	events := next.wait(1)
+	if len(events) != 1 {
+		t.Fatalf("got %d events, want the creation of the secret: %+v", len(events), events)
+	}
+	e := events[0]
+	if e.Kind != "secret" || e.Name != "db" || e.Namespace != "shop" || e.Labels["app"] != "web" || e.Operation != "create" {
+		t.Fatalf("got the event %+v, want the creation of shop/db with its labels", e)
+	}
+	if o, ok := e.Object.(*meta_v1.PartialObjectMetadata); !ok || len(o.ManagedFields) > 0 {
+		t.Fatalf("got the object %#v, want the metadata without managed fields", e.Object)
+	}
+}
+
+func TestKeepManagedFields(t *testing.T) {
+	scheme := runtime.NewScheme()
+	meta_v1.AddMetaToScheme(scheme)
+	metadataClient := metadata_fake.NewSimpleMetadataClient(scheme, secretMetadata("existing", time.Now()))
+	conf := &config.Config{Informers: config.Informers{MetadataOnly: []string{"secret"}, KeepManagedFields: true}}
+
+	informer := newInformer(conf, metadataClient, "secret", nil, nil)
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go informer.Run(stopCh)
+	if !cache.WaitForCacheSync(stopCh, informer.HasSynced) {
+		t.Fatal("the informer did not sync")
+	}
+	obj, ok, err := informer.GetStore().GetByKey("shop/existing")
+	if err != nil || !ok {
+		t.Fatalf("the listed secret is not cached: %v", err)
+	}
+	if len(obj.(*meta_v1.PartialObjectMetadata).ManagedFields) == 0 {
+		t.Fatal("the managed fields of the cached secret were dropped")
+	}
+}
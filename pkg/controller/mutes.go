@@ -0,0 +1,153 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/mute"
+	"github.com/sirupsen/logrus"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+// muteResource is the resource of the Mute objects.
+var muteResource = schema.GroupVersionResource{Group: "kubewatch.io", Version: "v1alpha1", Resource: "mutes"}
+
+// muteWatcher applies the rules of the Mute objects of a cluster, which
+// silence the notifications of the objects they select at dispatch time.
+type muteWatcher struct {
+	logger  *logrus.Entry
+	cluster string
+	// namespace of the Mutes allowed to mute any namespace
+	namespace string
+	informer  cache.SharedIndexInformer
+
+	mu      sync.Mutex
+	sources map[string]bool
+}
+
+// newMuteWatcher returns the watcher of the Mutes of the namespace watched,
+// all of them by default.
+func newMuteWatcher(client dynamic.Interface, cluster string, conf *config.Config) *muteWatcher {
+	logger := logrus.WithField("pkg", "kubewatch-mute")
+	if cluster != "" {
+		logger = logger.WithField("cluster", cluster)
+	}
+	w := &muteWatcher{
+		logger:    logger,
+		cluster:   cluster,
+		namespace: conf.Mutes.Namespace,
+		informer:  dynamicinformer.NewFilteredDynamicInformer(client, muteResource, conf.Namespace, 0, cache.Indexers{}, nil).Informer(),
+		sources:   map[string]bool{},
+	}
+	w.informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: w.apply,
+		UpdateFunc: func(old, new interface{}) {
+			w.apply(new)
+		},
+		DeleteFunc: w.remove,
+	})
+	return w
+}
+
+// muteServed reports whether the API server serves the Mutes, i.e. whether
+// their CRD is installed.
+func muteServed(kubeClient kubernetes.Interface) bool {
+	resources, err := kubeClient.Discovery().ServerResourcesForGroupVersion(muteResource.GroupVersion().String())
+	if err != nil {
+		return false
+	}
+	for _, r := range resources.APIResources {
+		if r.Name == muteResource.Resource {
+			return true
+		}
+	}
+	return false
+}
+
+// Run applies the Mutes until stopCh is closed, then removes their rules.
+func (w *muteWatcher) Run(stopCh <-chan struct{}) {
+	w.logger.Info("Watching the Mute objects")
+	w.informer.Run(stopCh)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for source := range w.sources {
+		mute.Undeclare(w.cluster, source)
+	}
+}
+
+// apply declares the rule of a Mute, logging it for the audit of the
+// notifications silenced.
+func (w *muteWatcher) apply(obj interface{}) {
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return
+	}
+	source := u.GetNamespace() + "/" + u.GetName()
+	r, err := mute.FromObject(u, w.namespace)
+	if err != nil {
+		w.logger.Warnf("Ignoring Mute %s: %v", source, err)
+		w.forget(source)
+		return
+	}
+	r.Cluster = w.cluster
+
+	w.mu.Lock()
+	w.sources[source] = true
+	w.mu.Unlock()
+	mute.Declare(r)
+
+	logger := w.logger.WithField("mute", source)
+	if r.Reason != "" {
+		logger = logger.WithField("reason", r.Reason)
+	}
+	if !time.Now().Before(r.Until) {
+		logger.Infof("Mute of %s expired at %s", r.Target(), r.Until.Format(time.RFC3339))
+		return
+	}
+	logger.Infof("Muted %s until %s", r.Target(), r.Until.Format(time.RFC3339))
+}
+
+// remove removes the rule of a deleted Mute.
+func (w *muteWatcher) remove(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return
+	}
+	source := u.GetNamespace() + "/" + u.GetName()
+	w.forget(source)
+	w.logger.WithField("mute", source).Info("Unmuted, the Mute was deleted")
+}
+
+func (w *muteWatcher) forget(source string) {
+	w.mu.Lock()
+	delete(w.sources, source)
+	w.mu.Unlock()
+	mute.Undeclare(w.cluster, source)
+}
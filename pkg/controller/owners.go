@@ -0,0 +1,270 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+	"github.com/bitnami-labs/kubewatch/pkg/handlers"
+	"github.com/sirupsen/logrus"
+	apps_v1 "k8s.io/api/apps/v1"
+	batch_v1 "k8s.io/api/batch/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+// defaultOwnersInterval is the period summarized by the notifications of
+// the root owners.
+const defaultOwnersInterval = time.Minute
+
+// maxOwnerDepth bounds the walk up the owner references.
+const maxOwnerDepth = 10
+
+// ownerKinds are the kinds of the events of the owners, by Kubernetes kind.
+var ownerKinds = map[string]string{
+	"Deployment":            "deployment",
+	"ReplicaSet":            "replica set",
+	"StatefulSet":           "stateful set",
+	"DaemonSet":             "daemon set",
+	"ReplicationController": "replication controller",
+	"Job":                   "job",
+	"CronJob":               "cron job",
+}
+
+// ownerReasons are the reasons of the notifications of the root owners, by
+// kind of event, "Owned objects changed" by default.
+var ownerReasons = map[string]string{
+	"deployment":   "Rolling update",
+	"stateful set": "Rolling update",
+	"daemon set":   "Rolling update",
+	"cron job":     "Jobs run",
+}
+
+// ownersHandler attributes the events of the owned objects to their root
+// owner, found by walking the controller references through the caches of
+// the intermediate owners, e.g. the replica sets between pods and
+// deployments. The events of the objects of a root owner are summarized in
+// a single notification per interval.
+type ownersHandler struct {
+	handlers.Handler
+	interval time.Duration
+	// caches of the intermediate owners, by Kubernetes kind
+	caches map[string]cache.Indexer
+
+	mu      sync.Mutex
+	pending map[string]*ownedChanges
+	stopped bool
+}
+
+// ownedChanges are the events of the objects of a root owner during an
+// interval.
+type ownedChanges struct {
+	root   event.Event
+	counts map[string]int
+	timer  *time.Timer
+}
+
+// collapsedReasons are the reasons of the events collapsed, the crashes of
+// the containers being still notified on their own.
+var collapsedReasons = map[string]bool{
+	"Created": true,
+	"Updated": true,
+	"Deleted": true,
+}
+
+// Handle collapses the events of owned objects, passing the others through.
+func (h *ownersHandler) Handle(e event.Event) {
+	obj, ok := e.Object.(meta_v1.Object)
+	if !ok || !collapsedReasons[e.Reason] {
+		h.Handler.Handle(e)
+		return
+	}
+	owner := h.root(obj)
+	if owner == nil {
+		h.Handler.Handle(e)
+		return
+	}
+
+	kind, name := owner.Kind, owner.Name
+	if k, ok := ownerKinds[kind]; ok {
+		kind = k
+	} else {
+		kind = strings.ToLower(kind)
+	}
+	key := strings.Join([]string{e.Namespace, kind, name}, "/")
+	change := fmt.Sprintf("%s %s", e.Kind, strings.ToLower(e.Reason))
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.stopped {
+		return
+	}
+	p, ok := h.pending[key]
+	if !ok {
+		reason, ok := ownerReasons[kind]
+		if !ok {
+			reason = "Owned objects changed"
+		}
+		p = &ownedChanges{
+			root: event.Event{
+				Namespace: e.Namespace,
+				Kind:      kind,
+				Name:      name,
+				Reason:    reason,
+				Status:    "Warning",
+				Labels:    e.Labels,
+				Ref: &event.ObjectReference{
+					APIVersion: owner.APIVersion,
+					Kind:       owner.Kind,
+					Namespace:  e.Namespace,
+					Name:       name,
+					UID:        string(owner.UID),
+				},
+			},
+			counts: map[string]int{},
+		}
+		p.timer = time.AfterFunc(h.interval, func() { h.flush(key) })
+		h.pending[key] = p
+	}
+	p.counts[change]++
+}
+
+// root returns the reference to the root owner of the object, nil when it
+// has no controller.
+func (h *ownersHandler) root(obj meta_v1.Object) (owner *meta_v1.OwnerReference) {
+	for i := 0; i < maxOwnerDepth; i++ {
+		ref := meta_v1.GetControllerOf(obj)
+		if ref == nil {
+			break
+		}
+		owner = ref
+		indexer, ok := h.caches[ref.Kind]
+		if !ok {
+			break
+		}
+		item, exists, err := indexer.GetByKey(obj.GetNamespace() + "/" + ref.Name)
+		if err != nil || !exists {
+			break
+		}
+		if obj, ok = item.(meta_v1.Object); !ok {
+			break
+		}
+	}
+	return owner
+}
+
+// flush notifies the changes of a root owner.
+func (h *ownersHandler) flush(key string) {
+	h.mu.Lock()
+	p, ok := h.pending[key]
+	delete(h.pending, key)
+	h.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	changes := make([]string, 0, len(p.counts))
+	for change, n := range p.counts {
+		changes = append(changes, fmt.Sprintf("%s: %d", change, n))
+	}
+	sort.Strings(changes)
+	e := p.root
+	e.Details = strings.Join(changes, "\n")
+	h.Handler.Handle(e)
+}
+
+// newOwnersHandler returns the handler collapsing the events of the owned
+// objects of the cluster, watching the caches of the intermediate owners
+// until stopCh is closed.
+func newOwnersHandler(kubeClient kubernetes.Interface, conf *config.Config, eventHandler handlers.Handler, stopCh <-chan struct{}) *ownersHandler {
+	interval := defaultOwnersInterval
+	if conf.Owners.Interval != "" {
+		d, err := time.ParseDuration(conf.Owners.Interval)
+		if err != nil || d <= 0 {
+			logrus.Warnf("Invalid owners interval %q, using %s", conf.Owners.Interval, interval)
+		} else {
+			interval = d
+		}
+	}
+
+	replicaSets := cache.NewSharedIndexInformer(
+		&cache.ListWatch{
+			ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
+				return kubeClient.AppsV1().ReplicaSets(conf.Namespace).List(options)
+			},
+			WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
+				return kubeClient.AppsV1().ReplicaSets(conf.Namespace).Watch(options)
+			},
+		},
+		&apps_v1.ReplicaSet{},
+		0, //Skip resync
+		cache.Indexers{},
+	)
+	jobs := cache.NewSharedIndexInformer(
+		&cache.ListWatch{
+			ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
+				return kubeClient.BatchV1().Jobs(conf.Namespace).List(options)
+			},
+			WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
+				return kubeClient.BatchV1().Jobs(conf.Namespace).Watch(options)
+			},
+		},
+		&batch_v1.Job{},
+		0, //Skip resync
+		cache.Indexers{},
+	)
+	go replicaSets.Run(stopCh)
+	go jobs.Run(stopCh)
+	if !cache.WaitForCacheSync(stopCh, replicaSets.HasSynced, jobs.HasSynced) {
+		logrus.Warn("Timed out waiting for the caches of the owners to sync")
+	}
+
+	return &ownersHandler{
+		Handler:  eventHandler,
+		interval: interval,
+		caches: map[string]cache.Indexer{
+			"ReplicaSet": replicaSets.GetIndexer(),
+			"Job":        jobs.GetIndexer(),
+		},
+		pending: map[string]*ownedChanges{},
+	}
+}
+
+// stop notifies the pending changes, once the controllers stopped.
+func (h *ownersHandler) stop() {
+	h.mu.Lock()
+	h.stopped = true
+	var keys []string
+	for key, p := range h.pending {
+		p.timer.Stop()
+		keys = append(keys, key)
+	}
+	h.mu.Unlock()
+
+	for _, key := range keys {
+		h.flush(key)
+	}
+}
@@ -0,0 +1,159 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+	apps_v1 "k8s.io/api/apps/v1"
+	api_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+)
+
+// recorder records the events it handles.
+type recorder struct {
+	mu     sync.Mutex
+	events []event.Event
+}
+
+func (r *recorder) Init(c *config.Config) error { return nil }
+
+func (r *recorder) Handle(e event.Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, e)
+}
+
+// wait returns the events once n of them were handled, or after a second.
+func (r *recorder) wait(n int) []event.Event {
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		r.mu.Lock()
+		if len(r.events) >= n {
+			r.mu.Unlock()
+			break
+		}
+		r.mu.Unlock()
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]event.Event{}, r.events...)
+}
+
+func controllerRef(kind, name string) []meta_v1.OwnerReference {
+	controller := true
+	return []meta_v1.OwnerReference{{APIVersion: "apps/v1", Kind: kind, Name: name, UID: types.UID("uid-" + name), Controller: &controller}}
+}
+
+// newTestOwnersHandler returns an owners handler knowing the replica set
+// web-5d4f of the deployment web.
+func newTestOwnersHandler(t *testing.T, next *recorder, interval time.Duration) *ownersHandler {
+	replicaSets := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	rs := &apps_v1.ReplicaSet{ObjectMeta: meta_v1.ObjectMeta{Namespace: "shop", Name: "web-5d4f", OwnerReferences: controllerRef("Deployment", "web")}}
+	if err := replicaSets.Add(rs); err != nil {
+		t.Fatal(err)
+	}
+	return &ownersHandler{
+		Handler:  next,
+		interval: interval,
+		caches:   map[string]cache.Indexer{"ReplicaSet": replicaSets},
+		pending:  map[string]*ownedChanges{},
+	}
+}
+
+func pod(name string) *api_v1.Pod {
+	return &api_v1.Pod{ObjectMeta: meta_v1.ObjectMeta{Namespace: "shop", Name: name, OwnerReferences: controllerRef("ReplicaSet", "web-5d4f")}}
+}
+
+func TestOwnersCollapse(t *testing.T) {
+	next := &recorder{}
+	h := newTestOwnersHandler(t, next, 50*time.Millisecond)
+	rs := &apps_v1.ReplicaSet{ObjectMeta: meta_v1.ObjectMeta{Namespace: "shop", Name: "web-5d4f", OwnerReferences: controllerRef("Deployment", "web")}}
+
+	h.Handle(event.Event{Namespace: "shop", Kind: "pod", Name: "web-5d4f-a", Reason: "Created", Object: pod("web-5d4f-a")})
+	h.Handle(event.Event{Namespace: "shop", Kind: "pod", Name: "web-5d4f-b", Reason: "Created", Object: pod("web-5d4f-b")})
+	h.Handle(event.Event{Namespace: "shop", Kind: "pod", Name: "web-5d4f-z", Reason: "Deleted", Object: pod("web-5d4f-z")})
+	h.Handle(event.Event{Namespace: "shop", Kind: "replica set", Name: "web-5d4f", Reason: "Updated", Object: rs})
+
+	events := next.wait(1)
+	if len(events) != 1 {
+		t.Fatalf("got %d notifications, want 1: %+v", len(events), events)
+	}
+	e := events[0]
+	if e.Kind != "deployment" || e.Name != "web" || e.Namespace != "shop" || e.Reason != "Rolling update" {
+		t.Fatalf("got the notification %s/%s/%s %s, want the one of the deployment", e.Namespace, e.Kind, e.Name, e.Reason)
+	}
+	if want := "pod created: 2\npod deleted: 1\nreplica set updated: 1"; e.Details != want {
+		t.Fatalf("got the details %q, want %q", e.Details, want)
+	}
+	if e.Ref == nil || e.Ref.Kind != "Deployment" || e.Ref.UID != "uid-web" {
+		t.Fatalf("got the reference %+v, want the one of the deployment", e.Ref)
+	}
+
+	// the next interval starts with the next event
+	h.Handle(event.Event{Namespace: "shop", Kind: "pod", Name: "web-5d4f-c", Reason: "Created", Object: pod("web-5d4f-c")})
+	if events := next.wait(2); len(events) != 2 || events[1].Details != "pod created: 1" {
+		t.Fatalf("got the notifications %+v, want a second one", events)
+	}
+}
+
+func TestOwnersPassThrough(t *testing.T) {
+	next := &recorder{}
+	h := newTestOwnersHandler(t, next, time.Hour)
+
+	orphan := &api_v1.Pod{ObjectMeta: meta_v1.ObjectMeta{Namespace: "shop", Name: "debug"}}
+	h.Handle(event.Event{Namespace: "shop", Kind: "pod", Name: "web-5d4f-a", Reason: "CrashLoopBackOff", Object: pod("web-5d4f-a")})
+	h.Handle(event.Event{Namespace: "shop", Kind: "pod", Name: "debug", Reason: "Created", Object: orphan})
+	h.Handle(event.Event{Namespace: "shop", Kind: "pod", Name: "web-5d4f-a", Reason: "Created"})
+
+	events := next.wait(3)
+	if len(events) != 3 {
+		t.Fatalf("got %d events, want the 3 passed through: %+v", len(events), events)
+	}
+	if events[0].Reason != "CrashLoopBackOff" || events[0].Kind != "pod" {
+		t.Fatalf("got %+v, want the crash of the pod", events[0])
+	}
+	if len(h.pending) != 0 {
+		t.Fatalf("got pending changes %v", h.pending)
+	}
+}
+
+func TestOwnersStop(t *testing.T) {
+	next := &recorder{}
+	h := newTestOwnersHandler(t, next, time.Hour)
+
+	h.Handle(event.Event{Namespace: "shop", Kind: "pod", Name: "web-5d4f-a", Reason: "Deleted", Object: pod("web-5d4f-a")})
+	if events := next.wait(0); len(events) != 0 {
+		t.Fatalf("got %+v before the end of the interval", events)
+	}
+	h.stop()
+	events := next.wait(1)
+	if len(events) != 1 || events[0].Name != "web" || events[0].Details != "pod deleted: 1" {
+		t.Fatalf("got %+v, want the pending changes flushed", events)
+	}
+
+	h.Handle(event.Event{Namespace: "shop", Kind: "pod", Name: "web-5d4f-b", Reason: "Created", Object: pod("web-5d4f-b")})
+	h.stop()
+	if events := next.wait(2); len(events) != 1 {
+		t.Fatalf("got %+v, want the events after stop dropped", events)
+	}
+}
@@ -0,0 +1,226 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/metrics"
+	"github.com/sirupsen/logrus"
+
+	authorization_v1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+)
+
+// clusterResources are the resource types not namespaced.
+var clusterResources = map[string]bool{
+	"namespace":         true,
+	"node":              true,
+	"cluster role":      true,
+	"persistent volume": true,
+}
+
+// Permission is an access to a resource needed by kubewatch, e.g. to watch
+// the deployments of a namespace.
+type Permission struct {
+	// Key of the resource, e.g. "deployment"
+	Key string
+	// Verb needed, "list" or "watch"
+	Verb     string
+	Group    string
+	Resource string
+	// Namespace of the objects, unset for all of them or the resources
+	// not namespaced
+	Namespace string
+	// Cluster is set for the resources not namespaced
+	Cluster bool
+}
+
+// String returns the permission as reported, e.g. "watch deployments.apps
+// in namespace shop".
+func (p Permission) String() string {
+	resource := p.Resource
+	if p.Group != "" {
+		resource += "." + p.Group
+	}
+	switch {
+	case p.Cluster:
+		return fmt.Sprintf("%s %s", p.Verb, resource)
+	case p.Namespace == "":
+		return fmt.Sprintf("%s %s in all namespaces", p.Verb, resource)
+	}
+	return fmt.Sprintf("%s %s in namespace %s", p.Verb, resource, p.Namespace)
+}
+
+// requiredPermissions returns the permissions needed to watch the
+// resources of conf, including the events of the default alerts and the
+// objects of the crashes, node health, certificate expiry and pending claim
+// checks and the Mutes when enabled.
+func requiredPermissions(conf *config.Config) []Permission {
+	var perms []Permission
+	seen := map[Permission]bool{}
+	need := func(resourceType string, verbs ...string) {
+		gvr, ok := metadataResources[resourceType]
+		key := resourceKeys[resourceType]
+		switch {
+		case !ok && resourceType == "event":
+			gvr = schema.GroupVersionResource{Version: "v1", Resource: "events"}
+		case resourceType == "mute":
+			gvr, key = muteResource, "mute"
+		}
+		for _, verb := range verbs {
+			p := Permission{
+				Key:       key,
+				Verb:      verb,
+				Group:     gvr.Group,
+				Resource:  gvr.Resource,
+				Namespace: conf.Namespace,
+				Cluster:   clusterResources[resourceType],
+			}
+			if p.Cluster {
+				p.Namespace = ""
+			}
+			if !seen[p] {
+				seen[p] = true
+				perms = append(perms, p)
+			}
+		}
+	}
+
+	var resourceTypes []string
+	for resourceType := range resourceKeys {
+		resourceTypes = append(resourceTypes, resourceType)
+	}
+	sort.Strings(resourceTypes)
+	for _, resourceType := range resourceTypes {
+		if conf.Resource.Watched(resourceKeys[resourceType]) {
+			need(resourceType, "list", "watch")
+		}
+	}
+	need("event", "list", "watch")
+	if conf.Crashes.Enabled {
+		need("pod", "list", "watch")
+	}
+	if conf.Nodes.Enabled {
+		need("node", "list", "watch")
+	}
+	if conf.Certificates.Enabled {
+		need("secret", "list")
+	}
+	if conf.Volumes.PendingAfter != "" {
+		need("persistent volume claim", "list")
+	}
+	if conf.Mutes.Enabled {
+		need("mute", "list", "watch")
+	}
+	return perms
+}
+
+// DeniedPermissions returns the permissions needed to watch the resources of
+// conf which are denied to kubewatch, as reviewed by the API server.
+func DeniedPermissions(kubeClient kubernetes.Interface, conf *config.Config) ([]Permission, error) {
+	var denied []Permission
+	for _, p := range requiredPermissions(conf) {
+		review, err := kubeClient.AuthorizationV1().SelfSubjectAccessReviews().Create(&authorization_v1.SelfSubjectAccessReview{
+			Spec: authorization_v1.SelfSubjectAccessReviewSpec{
+				ResourceAttributes: &authorization_v1.ResourceAttributes{
+					Namespace: p.Namespace,
+					Verb:      p.Verb,
+					Group:     p.Group,
+					Resource:  p.Resource,
+				},
+			},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("can not review the permission to %s: %v", p, err)
+		}
+		if !review.Status.Allowed {
+			denied = append(denied, p)
+		}
+	}
+	return denied, nil
+}
+
+// checkPermissions checks that kubewatch can list and watch the resources of
+// conf in the cluster, as configured on denial: logging the permissions
+// denied, exiting with their report, or returning the settings without the
+// resources denied. It also reports whether the events of the default
+// alerts can be watched.
+func checkPermissions(kubeClient kubernetes.Interface, cluster string, conf *config.Config) (*config.Config, bool) {
+	logger := logrus.WithField("pkg", "kubewatch-permissions")
+	if cluster != "" {
+		logger = logger.WithField("cluster", cluster)
+	}
+	denied, err := DeniedPermissions(kubeClient, conf)
+	if err != nil {
+		logger.Warnf("Can not check the permissions: %v", err)
+		return conf, true
+	}
+	for _, p := range denied {
+		metrics.PermissionDenied.WithLabelValues(cluster, p.Key, p.Verb).Set(1)
+	}
+	if len(denied) == 0 {
+		return conf, true
+	}
+
+	report := make([]string, len(denied))
+	for i, p := range denied {
+		report[i] = "  - " + p.String()
+	}
+	switch conf.Permissions.OnDenied {
+	case "fail":
+		logger.Fatalf("Permissions denied, grant them to kubewatch or stop watching their resources:\n%s", strings.Join(report, "\n"))
+	case "skip":
+	default:
+		logger.Warnf("Permissions denied, their resources will not be notified:\n%s", strings.Join(report, "\n"))
+		return conf, true
+	}
+
+	c := *conf
+	alerts := true
+	for _, p := range denied {
+		if c.Resource.Watched(p.Key) {
+			c.Resource.Unwatch(p.Key)
+			logger.Warnf("Not watching the %s resource, kubewatch is denied to %s", p.Key, p)
+		}
+		switch {
+		case p.Key == "event" && alerts:
+			alerts = false
+			logger.Warnf("Not notifying the default node and pod alerts, kubewatch is denied to %s", p)
+		case p.Key == "pod" && c.Crashes.Enabled:
+			c.Crashes.Enabled = false
+			logger.Warnf("Not notifying the crashes, kubewatch is denied to %s", p)
+		case p.Key == "node" && c.Nodes.Enabled:
+			c.Nodes.Enabled = false
+			logger.Warnf("Not notifying the node health, kubewatch is denied to %s", p)
+		case p.Key == "secret" && p.Verb == "list" && c.Certificates.Enabled:
+			c.Certificates.Enabled = false
+			logger.Warnf("Not checking the certificate expiry, kubewatch is denied to %s", p)
+		case p.Key == "persistentvolumeclaim" && p.Verb == "list" && c.Volumes.PendingAfter != "":
+			c.Volumes.PendingAfter = ""
+			logger.Warnf("Not checking the pending claims, kubewatch is denied to %s", p)
+		case p.Key == "mute" && c.Mutes.Enabled:
+			c.Mutes.Enabled = false
+			logger.Warnf("Not applying the Mute objects, kubewatch is denied to %s", p)
+		}
+	}
+	return &c, alerts
+}
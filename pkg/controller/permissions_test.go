@@ -0,0 +1,132 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	authorization_v1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8s_testing "k8s.io/client-go/testing"
+)
+
+// reviewingClient returns a client denying the permissions of the denied
+// verbs and resources, e.g. "watch deployments", and recording the reviews.
+func reviewingClient(denied ...string) (*fake.Clientset, *[]string) {
+	var mu sync.Mutex
+	var reviewed []string
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("create", "selfsubjectaccessreviews", func(action k8s_testing.Action) (bool, runtime.Object, error) {
+		review := action.(k8s_testing.CreateAction).GetObject().(*authorization_v1.SelfSubjectAccessReview)
+		attrs := review.Spec.ResourceAttributes
+		permission := attrs.Verb + " " + attrs.Resource
+		if attrs.Namespace != "" {
+			permission += " in " + attrs.Namespace
+		}
+		mu.Lock()
+		reviewed = append(reviewed, permission)
+		mu.Unlock()
+		review.Status.Allowed = true
+		for _, d := range denied {
+			if d == attrs.Verb+" "+attrs.Resource {
+				review.Status.Allowed = false
+			}
+		}
+		return true, review, nil
+	})
+	return client, &reviewed
+}
+
+func TestCheckPermissionsAllowed(t *testing.T) {
+	client, reviewed := reviewingClient()
+	conf := &config.Config{Namespace: "shop", Resource: config.Resource{Deployment: true, Node: true}}
+
+	checked, alerts := checkPermissions(client, "", conf)
+	if checked != conf || !alerts {
+		t.Fatalf("checkPermissions(): got %+v and alerts %v, want the config and the alerts", checked, alerts)
+	}
+	want := []string{
+		"list deployments in shop", "watch deployments in shop",
+		"list nodes", "watch nodes",
+		"list events in shop", "watch events in shop",
+	}
+	if !reflect.DeepEqual(*reviewed, want) {
+		t.Fatalf("checkPermissions(): reviewed %q, want %q", *reviewed, want)
+	}
+}
+
+func TestCheckPermissionsDenied(t *testing.T) {
+	var Tests = []struct {
+		onDenied  string
+		denied    []string
+		resources config.Resource
+		alerts    bool
+		crashes   bool
+	}{
+		// the resources are still watched, the denials being logged
+		{"", []string{"watch deployments", "list events"}, config.Resource{Deployment: true, Pod: true}, true, true},
+		{"warn", []string{"watch pods"}, config.Resource{Deployment: true, Pod: true}, true, true},
+		{"skip", []string{"watch deployments"}, config.Resource{Pod: true}, true, true},
+		{"skip", []string{"list events"}, config.Resource{Deployment: true, Pod: true}, false, true},
+		{"skip", []string{"watch pods"}, config.Resource{Deployment: true}, true, false},
+	}
+
+	for _, tt := range Tests {
+		client, _ := reviewingClient(tt.denied...)
+		conf := &config.Config{
+			Resource:    config.Resource{Deployment: true, Pod: true},
+			Crashes:     config.Crashes{Enabled: true},
+			Permissions: config.Permissions{OnDenied: tt.onDenied},
+		}
+		checked, alerts := checkPermissions(client, "test", conf)
+		if !reflect.DeepEqual(checked.Resource, tt.resources) || alerts != tt.alerts || checked.Crashes.Enabled != tt.crashes {
+			t.Fatalf("checkPermissions(%s, %v): got %+v, alerts %v and crashes %v, want %+v, %v and %v",
+				tt.onDenied, tt.denied, checked.Resource, alerts, checked.Crashes.Enabled, tt.resources, tt.alerts, tt.crashes)
+		}
+		if !conf.Resource.Deployment || !conf.Resource.Pod || !conf.Crashes.Enabled {
+			t.Fatalf("checkPermissions(%s, %v): the config was modified", tt.onDenied, tt.denied)
+		}
+	}
+
+	if got := testutil.ToFloat64(metrics.PermissionDenied.WithLabelValues("test", "pod", "watch")); got != 1 {
+		t.Fatalf("got %v for the metric of the permission denied, want 1", got)
+	}
+}
+
+func TestCheckPermissionsError(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("create", "selfsubjectaccessreviews", func(action k8s_testing.Action) (bool, runtime.Object, error) {
+		return true, &authorization_v1.SelfSubjectAccessReview{}, errors.New("unavailable")
+	})
+	conf := &config.Config{Resource: config.Resource{Deployment: true}, Permissions: config.Permissions{OnDenied: "skip"}}
+
+	if _, err := DeniedPermissions(client, conf); err == nil {
+		t.Fatal("DeniedPermissions(): no error when the reviews fail")
+	}
+	// the resources are watched when the permissions can not be checked
+	if checked, alerts := checkPermissions(client, "", conf); checked != conf || !alerts {
+		t.Fatalf("checkPermissions(): got %+v and alerts %v, want the config and the alerts", checked, alerts)
+	}
+}
@@ -0,0 +1,303 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+)
+
+// Reconciliation compares the objects of a resource listed again from the
+// API server with the ones of the cache of its informer. The changes the
+// informer missed, e.g. during an API server disruption, are notified once
+// it caught up with the listing.
+type Reconciliation struct {
+	Cluster  string
+	Resource string
+	// Listed is the number of objects listed, Cached of objects cached.
+	Listed, Cached int
+	// Created, Updated and Deleted count the listed objects missing from
+	// the cache, the ones with another resource version in the cache, and
+	// the cached objects missing from the listing.
+	Created, Updated, Deleted int
+	// Err is the error of the listing, or of a timeout waiting for it.
+	Err error
+}
+
+// String returns a summary of the reconciliation, e.g.
+// "pod: 12 listed, 11 cached, 1 created, 0 updated, 0 deleted".
+func (r Reconciliation) String() string {
+	name := r.Resource
+	if r.Cluster != "" {
+		name = r.Cluster + " " + name
+	}
+	if r.Err != nil {
+		return fmt.Sprintf("%s: %v", name, r.Err)
+	}
+	return fmt.Sprintf("%s: %d listed, %d cached, %d created, %d updated, %d deleted",
+		name, r.Listed, r.Cached, r.Created, r.Updated, r.Deleted)
+}
+
+// running are the controllers being run, which Relist re-lists.
+var running = struct {
+	sync.Mutex
+	controllers map[*Controller]bool
+}{controllers: map[*Controller]bool{}}
+
+// Relist makes the informers of the running controllers list their objects
+// again, returning the reconciliations of the listings with their caches,
+// sorted by cluster and resource. The informers which don't list them
+// within the timeout are reported with an error.
+func Relist(timeout time.Duration) []Reconciliation {
+	running.Lock()
+	var controllers []*Controller
+	for c := range running.controllers {
+		if c.relister != nil {
+			controllers = append(controllers, c)
+		}
+	}
+	running.Unlock()
+
+	results := make([]chan Reconciliation, len(controllers))
+	for i, c := range controllers {
+		results[i] = make(chan Reconciliation, 1)
+		c.relister.relist(results[i])
+	}
+	deadline := time.After(timeout)
+	reconciliations := make([]Reconciliation, len(controllers))
+	for i, c := range controllers {
+		select {
+		case reconciliations[i] = <-results[i]:
+		case <-deadline:
+			reconciliations[i].Err = fmt.Errorf("not listed within %s", timeout)
+			// the other ones are only taken if already listed
+			deadline = closed
+		}
+		reconciliations[i].Cluster = c.cluster
+		reconciliations[i].Resource = c.resourceType
+	}
+	sort.Slice(reconciliations, func(i, j int) bool {
+		a, b := reconciliations[i], reconciliations[j]
+		if a.Cluster != b.Cluster {
+			return a.Cluster < b.Cluster
+		}
+		return a.Resource < b.Resource
+	})
+	return reconciliations
+}
+
+// closed is a closed channel of time, for deadlines already passed.
+var closed = func() <-chan time.Time {
+	ch := make(chan time.Time)
+	close(ch)
+	return ch
+}()
+
+// relister lists and watches the objects of an informer, ending its watch
+// like an expired one when asked to list them again. The informer then
+// lists them and notifies the changes it missed, e.g. the deletions as
+// DeletedFinalStateUnknown.
+type relister struct {
+	lw    cache.ListerWatcher
+	store cache.Store
+
+	mu sync.Mutex
+	// watch is the current watch, nil when none
+	watch *relistWatch
+	// requested receive the reconciliation of the next listing
+	requested []chan<- Reconciliation
+}
+
+// relist requests a listing of the objects, sending its reconciliation to
+// result, which must be buffered.
+func (r *relister) relist(result chan<- Reconciliation) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requested = append(r.requested, result)
+	if r.watch != nil {
+		r.watch.expire()
+		r.watch = nil
+	}
+}
+
+// List lists the objects, reconciling them with the cache when requested.
+func (r *relister) List(options meta_v1.ListOptions) (runtime.Object, error) {
+	list, err := r.lw.List(options)
+
+	r.mu.Lock()
+	requested := r.requested
+	r.requested = nil
+	r.mu.Unlock()
+	if len(requested) > 0 {
+		reconciliation := reconcile(list, err, r.store)
+		for _, result := range requested {
+			result <- reconciliation
+		}
+	}
+	return list, err
+}
+
+// Watch watches the objects, until the next requested listing.
+func (r *relister) Watch(options meta_v1.ListOptions) (watch.Interface, error) {
+	w, err := r.lw.Watch(options)
+	if err != nil {
+		return nil, err
+	}
+	rw := newRelistWatch(w)
+	r.mu.Lock()
+	r.watch = rw
+	r.mu.Unlock()
+	return rw, nil
+}
+
+// reconcile compares the listed objects with the cached ones.
+func reconcile(list runtime.Object, err error, store cache.Store) Reconciliation {
+	if err != nil {
+		return Reconciliation{Err: err}
+	}
+	items, err := meta.ExtractList(list)
+	if err != nil {
+		return Reconciliation{Err: err}
+	}
+
+	cached := map[string]string{}
+	for _, obj := range store.List() {
+		if key, err := cache.MetaNamespaceKeyFunc(obj); err == nil {
+			cached[key] = resourceVersion(obj)
+		}
+	}
+	r := Reconciliation{Listed: len(items), Cached: len(cached)}
+	for _, item := range items {
+		key, err := cache.MetaNamespaceKeyFunc(item)
+		if err != nil {
+			continue
+		}
+		version, ok := cached[key]
+		switch {
+		case !ok:
+			r.Created++
+		case version != resourceVersion(item):
+			r.Updated++
+		}
+		delete(cached, key)
+	}
+	r.Deleted = len(cached)
+	return r
+}
+
+// resourceVersion returns the resource version of the object.
+func resourceVersion(obj interface{}) string {
+	o, err := meta.Accessor(obj)
+	if err != nil {
+		return ""
+	}
+	return o.GetResourceVersion()
+}
+
+// expiredEvent ends a watch like an expired resource version, making the
+// reflector of the informer list the objects again.
+var expiredEvent = watch.Event{
+	Type: watch.Error,
+	Object: &meta_v1.Status{
+		Status:  meta_v1.StatusFailure,
+		Code:    410,
+		Reason:  meta_v1.StatusReasonExpired,
+		Message: "re-list requested",
+	},
+}
+
+// relistWatch passes the events of a watch until it expires.
+type relistWatch struct {
+	watch.Interface
+
+	result     chan watch.Event
+	expired    chan struct{}
+	stopped    chan struct{}
+	expireOnce sync.Once
+	stopOnce   sync.Once
+}
+
+func newRelistWatch(w watch.Interface) *relistWatch {
+	rw := &relistWatch{
+		Interface: w,
+		result:    make(chan watch.Event),
+		expired:   make(chan struct{}),
+		stopped:   make(chan struct{}),
+	}
+	go func() {
+		defer close(rw.result)
+		events := w.ResultChan()
+		for {
+			select {
+			case <-rw.expired:
+				select {
+				case rw.result <- expiredEvent:
+				case <-rw.stopped:
+				}
+				return
+			case e, ok := <-events:
+				if !ok {
+					return
+				}
+				select {
+				case rw.result <- e:
+				case <-rw.stopped:
+					return
+				}
+			case <-rw.stopped:
+				return
+			}
+		}
+	}()
+	return rw
+}
+
+// ResultChan returns the channel of the events.
+func (rw *relistWatch) ResultChan() <-chan watch.Event {
+	return rw.result
+}
+
+// Stop stops the watch.
+func (rw *relistWatch) Stop() {
+	rw.stopOnce.Do(func() {
+		close(rw.stopped)
+		rw.Interface.Stop()
+	})
+}
+
+// expire ends the watch with expiredEvent.
+func (rw *relistWatch) expire() {
+	rw.expireOnce.Do(func() { close(rw.expired) })
+}
+
+// Report returns the summary of the reconciliations, one per line.
+func Report(reconciliations []Reconciliation) string {
+	lines := make([]string, 0, len(reconciliations))
+	for _, r := range reconciliations {
+		lines = append(lines, "- "+r.String())
+	}
+	return strings.Join(lines, "\n")
+}
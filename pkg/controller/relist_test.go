@@ -0,0 +1,124 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bitnami-labs/kubewatch/config"
+
+	api_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+)
+
+// silentPods lists its pods and watches none of their changes, like a
+// watch missing them during an API server disruption.
+type silentPods struct {
+	mu      sync.Mutex
+	pods    map[string]*api_v1.Pod
+	watches int
+}
+
+func (s *silentPods) add(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pods[name] = &api_v1.Pod{ObjectMeta: meta_v1.ObjectMeta{
+		Namespace:         "shop",
+		Name:              name,
+		ResourceVersion:   strconv.Itoa(len(s.pods) + 1),
+		CreationTimestamp: meta_v1.NewTime(time.Now().Add(time.Hour)),
+	}}
+}
+
+func (s *silentPods) listWatch() *cache.ListWatch {
+	return &cache.ListWatch{
+		ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			list := &api_v1.PodList{ListMeta: meta_v1.ListMeta{ResourceVersion: strconv.Itoa(len(s.pods))}}
+			for _, pod := range s.pods {
+				list.Items = append(list.Items, *pod)
+			}
+			return list, nil
+		},
+		WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			s.watches++
+			return watch.NewFake(), nil
+		},
+	}
+}
+
+// watching waits until the pods are watched n times.
+func (s *silentPods) watching(t *testing.T, n int) {
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(5 * time.Millisecond) {
+		s.mu.Lock()
+		watches := s.watches
+		s.mu.Unlock()
+		if watches >= n {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the pods are not watched")
+		}
+	}
+}
+
+func TestRelist(t *testing.T) {
+	pods := &silentPods{pods: map[string]*api_v1.Pod{}}
+	pods.add("web")
+	conf := &config.Config{}
+	next := &recorder{}
+	c := newResourceController(fake.NewSimpleClientset(), "", next, newInformer(conf, nil, "pod", pods.listWatch(), &api_v1.Pod{}), "pod", conf)
+	stop := runController(t, c)
+	defer stop()
+	pods.watching(t, 1)
+	if events := next.wait(1); len(events) != 1 || events[0].Name != "web" {
+		t.Fatalf("got the events %+v, want the creation of web", events)
+	}
+
+	// the creation of db is missed by the watch
+	pods.add("db")
+	reconciliations := Relist(5 * time.Second)
+	want := Reconciliation{Resource: "pod", Listed: 2, Cached: 1, Created: 1}
+	if len(reconciliations) != 1 || reconciliations[0] != want {
+		t.Fatalf("Relist(): got %+v, want %+v", reconciliations, want)
+	}
+	events := next.wait(2)
+	if len(events) != 2 || events[1].Name != "db" || events[1].Operation != "create" {
+		t.Fatalf("got the events %+v, want the creation of db", events)
+	}
+
+	// relisting again finds no changes, and notifies none
+	pods.watching(t, 2)
+	want = Reconciliation{Resource: "pod", Listed: 2, Cached: 2}
+	if reconciliations := Relist(5 * time.Second); len(reconciliations) != 1 || reconciliations[0] != want {
+		t.Fatalf("Relist(): got %+v, want %+v", reconciliations, want)
+	}
+	pods.watching(t, 3)
+	if events := next.wait(3); len(events) != 2 {
+		t.Fatalf("got the events %+v after relisting unchanged pods, want none", events[2:])
+	}
+}
@@ -0,0 +1,200 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+	"github.com/bitnami-labs/kubewatch/pkg/rollout"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// Reasons of the notifications of the rollouts.
+const (
+	rolledOut    = "RolledOut"
+	rolloutStuck = "RolloutStuck"
+)
+
+// rolloutTracker waits for the rollouts of the updated deployments and
+// stateful sets, notifying their outcome instead of the updates: once
+// their status reports them complete, or as stuck past their deadline.
+type rolloutTracker struct {
+	c *Controller
+	// timeout is the deadline of the rollouts, the progress deadline of
+	// the deployments when 0
+	timeout time.Duration
+
+	mu       sync.Mutex
+	rollouts map[string]*trackedRollout
+}
+
+// trackedRollout is the rollout of a generation of a workload.
+type trackedRollout struct {
+	// event is the update starting the rollout
+	event      event.Event
+	start      time.Time
+	generation int64
+	timer      *time.Timer
+	// stuck is set once notified as stuck, its completion being notified
+	// still
+	stuck bool
+}
+
+func newRolloutTracker(c *Controller, timeout time.Duration) *rolloutTracker {
+	return &rolloutTracker{c: c, timeout: timeout, rollouts: map[string]*trackedRollout{}}
+}
+
+// started reports whether the update of the workload starts a rollout,
+// changing its spec.
+func (t *rolloutTracker) started(oldObj, newObj interface{}) bool {
+	o, ok := oldObj.(meta_v1.Object)
+	if !ok || !rollout.Trackable(newObj) {
+		return false
+	}
+	n := newObj.(meta_v1.Object)
+	return n.GetGeneration() != o.GetGeneration()
+}
+
+// start tracks the rollout started by the update event of the workload,
+// replacing the rollout of a previous generation.
+func (t *rolloutTracker) start(e event.Event, obj interface{}, received time.Time) {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		return
+	}
+	timeout := t.timeout
+	if timeout == 0 {
+		timeout = rollout.Deadline(obj)
+	}
+	generation := obj.(meta_v1.Object).GetGeneration()
+
+	t.mu.Lock()
+	if r := t.rollouts[key]; r != nil {
+		r.timer.Stop()
+	}
+	t.rollouts[key] = &trackedRollout{
+		event:      e,
+		start:      received,
+		generation: generation,
+		timer:      time.AfterFunc(timeout, func() { t.expire(key, generation) }),
+	}
+	t.mu.Unlock()
+	t.c.logger.Debugf("Tracking rollout of generation %d of %s", generation, key)
+
+	t.progress(obj)
+}
+
+// progress notifies the rollout of the workload once complete, or as stuck
+// once the deployment controller gave up.
+func (t *rolloutTracker) progress(obj interface{}) {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		return
+	}
+	t.mu.Lock()
+	r := t.rollouts[key]
+	if r == nil || obj.(meta_v1.Object).GetGeneration() != r.generation {
+		t.mu.Unlock()
+		return
+	}
+	p := rollout.Of(obj)
+	switch {
+	case p.Complete:
+		r.timer.Stop()
+		delete(t.rollouts, key)
+	case p.Failed && !r.stuck:
+		r.stuck = true
+	default:
+		t.mu.Unlock()
+		return
+	}
+	t.mu.Unlock()
+
+	t.notify(r, obj, p)
+}
+
+// expire notifies the rollout of the generation of the workload as stuck
+// when still not complete at its deadline.
+func (t *rolloutTracker) expire(key string, generation int64) {
+	obj, exists, err := t.c.informer.GetIndexer().GetByKey(key)
+	if err != nil || !exists {
+		t.forget(key)
+		return
+	}
+	t.mu.Lock()
+	r := t.rollouts[key]
+	if r == nil || r.generation != generation || r.stuck {
+		t.mu.Unlock()
+		return
+	}
+	p := rollout.Of(obj)
+	if p.Complete {
+		delete(t.rollouts, key)
+	} else {
+		r.stuck = true
+	}
+	t.mu.Unlock()
+
+	t.notify(r, obj, p)
+}
+
+// forget stops tracking the rollout of a deleted workload.
+func (t *rolloutTracker) forget(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if r := t.rollouts[key]; r != nil {
+		r.timer.Stop()
+		delete(t.rollouts, key)
+	}
+}
+
+// notify notifies the outcome of the rollout, with the changes of its
+// update.
+func (t *rolloutTracker) notify(r *trackedRollout, obj interface{}, p rollout.Progress) {
+	now := time.Now()
+	e := r.event
+	e.Object = snapshot(obj)
+	e.Ref = nil
+	e.Timestamp = time.Time{}
+	if p.Complete {
+		e.Reason, e.Status = rolledOut, "Normal"
+		e.Details = rollout.Succeeded(now.Sub(r.start))
+	} else {
+		e.Reason, e.Status = rolloutStuck, "Danger"
+		e.Details = rollout.Stuck(now.Sub(r.start), p, t.podsReason(obj))
+	}
+	t.c.handle(e, now)
+}
+
+// podsReason returns the most common reason the pods of the workload are
+// not available, empty when unknown.
+func (t *rolloutTracker) podsReason(obj interface{}) string {
+	selector, err := rollout.Selector(obj)
+	if err != nil {
+		return ""
+	}
+	o := obj.(meta_v1.Object)
+	pods, err := t.c.clientset.CoreV1().Pods(o.GetNamespace()).List(meta_v1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		t.c.logger.Warnf("Cannot list the pods of %s/%s: %v", o.GetNamespace(), o.GetName(), err)
+		return ""
+	}
+	return rollout.PodsReason(pods.Items)
+}
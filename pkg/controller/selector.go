@@ -0,0 +1,61 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"path"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"k8s.io/apimachinery/pkg/api/meta"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/cache"
+)
+
+// selected restricts the objects listed and watched of the resource type to
+// the ones of its selector, if any. The names are matched as the objects are
+// received, so the other objects never reach the cache of the informer.
+func selected(conf *config.Config, resourceType string, lw *cache.ListWatch) *cache.ListWatch {
+	s, ok := conf.Selectors[resourceKeys[resourceType]]
+	if !ok {
+		return lw
+	}
+	var keep func(runtime.Object) bool
+	if len(s.Names) > 0 {
+		keep = func(obj runtime.Object) bool {
+			return matchesNames(s.Names, obj)
+		}
+	}
+	return transformed(lw, func(options *meta_v1.ListOptions) {
+		options.FieldSelector = s.Field
+	}, keep)
+}
+
+// matchesNames reports whether the name of the object matches one of the
+// patterns.
+func matchesNames(patterns []string, obj runtime.Object) bool {
+	o, err := meta.Accessor(obj)
+	if err != nil {
+		return true
+	}
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, o.GetName()); ok {
+			return true
+		}
+	}
+	return false
+}
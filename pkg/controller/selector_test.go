@@ -0,0 +1,151 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/bitnami-labs/kubewatch/config"
+
+	api_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+)
+
+func namedPod(name string) *api_v1.Pod {
+	return &api_v1.Pod{ObjectMeta: meta_v1.ObjectMeta{Name: name, Namespace: "default"}}
+}
+
+func TestMatchesNames(t *testing.T) {
+	var Tests = []struct {
+		patterns []string
+		name     string
+		want     bool
+	}{
+		{[]string{"web-*"}, "web-1", true},
+		{[]string{"web-*"}, "web", false},
+		{[]string{"web-*"}, "db-1", false},
+		{[]string{"web", "db-?"}, "db-1", true},
+		{[]string{"web", "db-?"}, "db-10", false},
+		{[]string{"web"}, "web", true},
+		{nil, "web", false},
+	}
+
+	for _, tt := range Tests {
+		if got := matchesNames(tt.patterns, namedPod(tt.name)); got != tt.want {
+			t.Fatalf("matchesNames(%v, %s): got %v, want %v", tt.patterns, tt.name, got, tt.want)
+		}
+	}
+}
+
+// recordingListWatch lists the pods and watches the fake watcher, recording
+// the options of the last list and watch.
+type recordingListWatch struct {
+	pods    []string
+	watcher *watch.FakeWatcher
+	options []meta_v1.ListOptions
+}
+
+func (r *recordingListWatch) listWatch() *cache.ListWatch {
+	return &cache.ListWatch{
+		ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
+			r.options = append(r.options, options)
+			list := &api_v1.PodList{}
+			for _, name := range r.pods {
+				list.Items = append(list.Items, *namedPod(name))
+			}
+			return list, nil
+		},
+		WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
+			r.options = append(r.options, options)
+			return r.watcher, nil
+		},
+	}
+}
+
+func TestSelected(t *testing.T) {
+	var Tests = []struct {
+		selectors map[string]config.Selector
+		field     string
+		listed    []string
+		watched   []string
+	}{
+		// no selector of the resource
+		{nil, "", []string{"web-1", "db-1", "web-2"}, []string{"web-3", "db-2"}},
+		{map[string]config.Selector{"deployment": {Names: []string{"web-*"}}}, "", []string{"web-1", "db-1", "web-2"}, []string{"web-3", "db-2"}},
+		// an empty selector
+		{map[string]config.Selector{"pod": {}}, "", []string{"web-1", "db-1", "web-2"}, []string{"web-3", "db-2"}},
+		{map[string]config.Selector{"pod": {Field: "spec.nodeName=node-1"}}, "spec.nodeName=node-1", []string{"web-1", "db-1", "web-2"}, []string{"web-3", "db-2"}},
+		{map[string]config.Selector{"pod": {Names: []string{"web-*"}}}, "", []string{"web-1", "web-2"}, []string{"web-3"}},
+		{map[string]config.Selector{"pod": {Field: "spec.nodeName=node-1", Names: []string{"db-1", "db-2"}}}, "spec.nodeName=node-1", []string{"db-1"}, []string{"db-2"}},
+		{map[string]config.Selector{"pod": {Names: []string{"cache"}}}, "", nil, nil},
+	}
+
+	for _, tt := range Tests {
+		r := &recordingListWatch{pods: []string{"web-1", "db-1", "web-2"}, watcher: watch.NewFake()}
+		lw := selected(&config.Config{Selectors: tt.selectors}, "pod", r.listWatch())
+
+		list, err := lw.List(meta_v1.ListOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var listed []string
+		for _, pod := range list.(*api_v1.PodList).Items {
+			listed = append(listed, pod.Name)
+		}
+		if !reflect.DeepEqual(listed, tt.listed) {
+			t.Fatalf("selected(%v): listed %v, want %v", tt.selectors, listed, tt.listed)
+		}
+
+		w, err := lw.Watch(meta_v1.ListOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		go func() {
+			r.watcher.Add(namedPod("web-3"))
+			r.watcher.Add(namedPod("db-2"))
+			r.watcher.Stop()
+		}()
+		var watched []string
+		timeout := time.After(5 * time.Second)
+	watching:
+		for {
+			select {
+			case e, ok := <-w.ResultChan():
+				if !ok {
+					break watching
+				}
+				watched = append(watched, e.Object.(*api_v1.Pod).Name)
+			case <-timeout:
+				t.Fatalf("selected(%v): the watch did not stop", tt.selectors)
+			}
+		}
+		if !reflect.DeepEqual(watched, tt.watched) {
+			t.Fatalf("selected(%v): watched %v, want %v", tt.selectors, watched, tt.watched)
+		}
+
+		for _, options := range r.options {
+			if options.FieldSelector != tt.field {
+				t.Fatalf("selected(%v): got the field selector %q, want %q", tt.selectors, options.FieldSelector, tt.field)
+			}
+		}
+	}
+}
@@ -0,0 +1,124 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"golang.org/x/time/rate"
+	"k8s.io/client-go/util/workqueue"
+)
+
+// The defaults of the retries of the controllers, the ones of
+// workqueue.DefaultControllerRateLimiter.
+const (
+	defaultRetryBaseDelay = 5 * time.Millisecond
+	defaultRetryMaxDelay  = 1000 * time.Second
+	defaultRetryRate      = 10
+	defaultRetryBurst     = 100
+)
+
+// settings are the resyncs and the retries of a controller.
+type settings struct {
+	resyncPeriod time.Duration
+	maxRetries   int
+	rateLimiter  workqueue.RateLimiter
+}
+
+// newSettings returns the settings of a controller, the defaults for the
+// ones not set.
+func newSettings(c config.Controller) (settings, error) {
+	s := settings{maxRetries: maxRetries}
+	if c.MaxRetries < 0 {
+		return s, fmt.Errorf("invalid maxRetries %d, must not be negative", c.MaxRetries)
+	}
+	if c.MaxRetries > 0 {
+		s.maxRetries = c.MaxRetries
+	}
+	var err error
+	if s.resyncPeriod, err = parseDelay("resyncPeriod", c.ResyncPeriod, 0); err != nil {
+		return s, err
+	}
+	baseDelay, err := parseDelay("retryBaseDelay", c.RetryBaseDelay, defaultRetryBaseDelay)
+	if err != nil {
+		return s, err
+	}
+	maxDelay, err := parseDelay("retryMaxDelay", c.RetryMaxDelay, defaultRetryMaxDelay)
+	if err != nil {
+		return s, err
+	}
+	if maxDelay < baseDelay {
+		return s, fmt.Errorf("retryMaxDelay %v is shorter than retryBaseDelay %v", maxDelay, baseDelay)
+	}
+	retryRate, burst := c.RetryRate, c.RetryBurst
+	if retryRate < 0 || burst < 0 {
+		return s, fmt.Errorf("invalid retryRate %v or retryBurst %d, must not be negative", retryRate, burst)
+	}
+	if retryRate == 0 {
+		retryRate = defaultRetryRate
+	}
+	if burst == 0 {
+		burst = defaultRetryBurst
+	}
+	s.rateLimiter = workqueue.NewMaxOfRateLimiter(
+		workqueue.NewItemExponentialFailureRateLimiter(baseDelay, maxDelay),
+		&workqueue.BucketRateLimiter{Limiter: rate.NewLimiter(rate.Limit(retryRate), burst)},
+	)
+	return s, nil
+}
+
+// parseDelay parses the duration of the setting, def when not set.
+func parseDelay(setting, value string, def time.Duration) (time.Duration, error) {
+	if value == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid %s %q, must be a positive duration", setting, value)
+	}
+	return d, nil
+}
+
+// ValidateSettings checks the settings of the controllers and of their
+// overrides per resource.
+func ValidateSettings(c config.Controller) []error {
+	var errs []error
+	if _, err := newSettings(c); err != nil {
+		errs = append(errs, err)
+	}
+	var keys []string
+	for key := range c.Resources {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if !config.IsResourceKey(key) {
+			errs = append(errs, fmt.Errorf("unknown resource %q", key))
+			continue
+		}
+		if len(c.Resources[key].Resources) > 0 {
+			errs = append(errs, fmt.Errorf("%s: resources can not be overridden per resource", key))
+		}
+		if _, err := newSettings(c.For(key)); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %v", key, err))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestNewSettings(t *testing.T) {
+	var Tests = []struct {
+		conf       config.Controller
+		resync     time.Duration
+		maxRetries int
+		firstRetry time.Duration
+	}{
+		{config.Controller{}, 0, 5, defaultRetryBaseDelay},
+		{config.Controller{ResyncPeriod: "30m", MaxRetries: 10, RetryBaseDelay: "100ms"}, 30 * time.Minute, 10, 100 * time.Millisecond},
+		{config.Controller{ResyncPeriod: "0s", RetryBaseDelay: "1s", RetryMaxDelay: "1s"}, 0, 5, time.Second},
+	}
+
+	for _, tt := range Tests {
+		s, err := newSettings(tt.conf)
+		if err != nil {
+			t.Fatalf("newSettings(%+v): unexpected error %v", tt.conf, err)
+		}
+		if s.resyncPeriod != tt.resync || s.maxRetries != tt.maxRetries {
+			t.Fatalf("newSettings(%+v): got a resync period of %s and %d retries, want %s and %d", tt.conf, s.resyncPeriod, s.maxRetries, tt.resync, tt.maxRetries)
+		}
+		if got := s.rateLimiter.When("key"); got != tt.firstRetry {
+			t.Fatalf("newSettings(%+v): got a first retry after %s, want %s", tt.conf, got, tt.firstRetry)
+		}
+	}
+}
+
+func TestNewSettingsErrors(t *testing.T) {
+	var Tests = []struct {
+		conf config.Controller
+		err  string
+	}{
+		{config.Controller{MaxRetries: -1}, "invalid maxRetries -1"},
+		{config.Controller{ResyncPeriod: "-1m"}, `invalid resyncPeriod "-1m"`},
+		{config.Controller{ResyncPeriod: "often"}, `invalid resyncPeriod "often"`},
+		{config.Controller{RetryBaseDelay: "-5ms"}, `invalid retryBaseDelay "-5ms"`},
+		{config.Controller{RetryMaxDelay: "soon"}, `invalid retryMaxDelay "soon"`},
+		{config.Controller{RetryBaseDelay: "1m", RetryMaxDelay: "1s"}, "retryMaxDelay 1s is shorter than retryBaseDelay 1m0s"},
+		{config.Controller{RetryRate: -1}, "invalid retryRate -1"},
+		{config.Controller{RetryBurst: -1}, "or retryBurst -1"},
+	}
+
+	for _, tt := range Tests {
+		if _, err := newSettings(tt.conf); err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Fatalf("newSettings(%+v): got error %v, want %q", tt.conf, err, tt.err)
+		}
+	}
+}
+
+func TestSettingsPerResource(t *testing.T) {
+	conf := config.Controller{
+		ResyncPeriod: "30m",
+		MaxRetries:   3,
+		Resources: map[string]config.Controller{
+			"pod": {ResyncPeriod: "5m"},
+		},
+	}
+
+	pod, err := newSettings(conf.For("pod"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pod.resyncPeriod != 5*time.Minute || pod.maxRetries != 3 {
+		t.Fatalf("newSettings(pod): got a resync period of %s and %d retries, want 5m and the 3 of the controllers", pod.resyncPeriod, pod.maxRetries)
+	}
+	deployment, err := newSettings(conf.For("deployment"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deployment.resyncPeriod != 30*time.Minute {
+		t.Fatalf("newSettings(deployment): got a resync period of %s, want the 30m of the controllers", deployment.resyncPeriod)
+	}
+
+	// the controllers fall back to the defaults on invalid settings
+	conf.Resources["deployment"] = config.Controller{MaxRetries: -1}
+	c := newResourceController(fake.NewSimpleClientset(), "", &recorder{}, newInformer(&config.Config{}, nil, "pod", nil, nil), "deployment", &config.Config{Controller: conf})
+	if c.maxRetries != maxRetries {
+		t.Fatalf("newResourceController(): got %d retries for invalid settings, want the default %d", c.maxRetries, maxRetries)
+	}
+	c = newResourceController(fake.NewSimpleClientset(), "", &recorder{}, newInformer(&config.Config{}, nil, "pod", nil, nil), "pod", &config.Config{Controller: conf})
+	if c.maxRetries != 3 {
+		t.Fatalf("newResourceController(): got %d retries for the pods, want 3", c.maxRetries)
+	}
+}
+
+func TestValidateSettings(t *testing.T) {
+	conf := config.Controller{
+		Resources: map[string]config.Controller{
+			"pod":        {ResyncPeriod: "-1m"},
+			"deployment": {Resources: map[string]config.Controller{"pod": {}}},
+			"widget":     {},
+			"secret":     {RetryRate: -1},
+			"configmap":  {MaxRetries: 2},
+		},
+	}
+
+	var got []string
+	for _, err := range ValidateSettings(conf) {
+		got = append(got, err.Error())
+	}
+	want := []string{
+		"deployment: resources can not be overridden per resource",
+		"pod: invalid resyncPeriod",
+		"secret: invalid retryRate -1",
+		`unknown resource "widget"`,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("ValidateSettings(): got %q, want %q", got, want)
+	}
+	for i := range want {
+		if !strings.Contains(got[i], want[i]) {
+			t.Fatalf("ValidateSettings(): got %q, want %q", got, want)
+		}
+	}
+
+	if errs := ValidateSettings(config.Controller{}); len(errs) > 0 {
+		t.Fatalf("ValidateSettings(): got %v for the defaults", errs)
+	}
+	if errs := ValidateSettings(config.Controller{MaxRetries: -1}); len(errs) != 1 || !strings.HasPrefix(errs[0].Error(), "invalid maxRetries") {
+		t.Fatalf("ValidateSettings(): got %v, want the invalid maxRetries of the controllers", errs)
+	}
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+
+	api_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+)
+
+// createdPod returns a pod created at the given time.
+func createdPod(name string, created time.Time) *api_v1.Pod {
+	return &api_v1.Pod{ObjectMeta: meta_v1.ObjectMeta{
+		Namespace:         "shop",
+		Name:              name,
+		CreationTimestamp: meta_v1.NewTime(created),
+	}}
+}
+
+// startupCreations runs a controller listing the pods created at the given
+// times before the start, then watching the creation of the pod "last", and
+// returns the names of the pods notified as created.
+func startupCreations(t *testing.T, startup config.Startup, created map[string]time.Duration) []string {
+	now := time.Now()
+	watcher := watch.NewFake()
+	lw := &cache.ListWatch{
+		ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
+			list := &api_v1.PodList{}
+			for name, before := range created {
+				list.Items = append(list.Items, *createdPod(name, now.Add(-before)))
+			}
+			return list, nil
+		},
+		WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
+			return watcher, nil
+		},
+	}
+	conf := &config.Config{Startup: startup}
+	next := &recorder{}
+	c := newResourceController(fake.NewSimpleClientset(), "", next, newInformer(conf, nil, "pod", lw, &api_v1.Pod{}), "pod", conf)
+	stop := runController(t, c)
+	defer stop()
+
+	// the single worker processes the listed pods before the last one
+	watcher.Add(createdPod("last", time.Now().Add(time.Minute)))
+	var events []event.Event
+	for deadline := time.Now().Add(5 * time.Second); len(events) == 0 || events[len(events)-1].Name != "last"; {
+		if time.Now().After(deadline) {
+			t.Fatalf("the creation of last was not notified, got the events %+v", events)
+		}
+		events = next.wait(len(events) + 1)
+	}
+	var names []string
+	for _, e := range events[:len(events)-1] {
+		names = append(names, e.Name)
+	}
+	return names
+}
+
+func TestNotifyCreatedWithin(t *testing.T) {
+	var Tests = []struct {
+		createdWithin string
+		before        time.Duration
+		notified      bool
+	}{
+		// only the objects created after the start are notified by default
+		{"", 0, false},
+		{"", -time.Minute, true},
+		{"1h", time.Hour - time.Minute, true},
+		{"1h", time.Hour, false},
+		{"1h", time.Hour + time.Minute, false},
+		{"10m", 9 * time.Minute, true},
+		{"10m", 10 * time.Minute, false},
+		// an invalid window notifies the objects created after the start
+		{"1 hour", time.Minute, false},
+		{"1 hour", -time.Minute, true},
+	}
+
+	for _, tt := range Tests {
+		got := startupCreations(t, config.Startup{NotifyCreatedWithin: tt.createdWithin}, map[string]time.Duration{"web": tt.before})
+		if notified := len(got) == 1; notified != tt.notified {
+			t.Fatalf("notifyCreatedWithin %q, created %v before the start: got notified %v, want %v", tt.createdWithin, tt.before, notified, tt.notified)
+		}
+	}
+}
+
+func TestSkipInitialList(t *testing.T) {
+	var Tests = []struct {
+		startup config.Startup
+		want    []string
+	}{
+		{config.Startup{}, []string{"new"}},
+		{config.Startup{NotifyCreatedWithin: "1h"}, []string{"new", "recent"}},
+		// the objects listed are skipped, even when created within the window
+		// or after the start, while the ones watched afterwards are notified
+		{config.Startup{SkipInitialList: true}, nil},
+		{config.Startup{SkipInitialList: true, NotifyCreatedWithin: "1h"}, nil},
+	}
+
+	created := map[string]time.Duration{"old": 2 * time.Hour, "recent": time.Minute, "new": -time.Minute}
+	for _, tt := range Tests {
+		got := startupCreations(t, tt.startup, created)
+		sort.Strings(got)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Fatalf("startup %+v: got the creations of %v, want %v", tt.startup, got, tt.want)
+		}
+	}
+}
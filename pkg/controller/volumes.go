@@ -0,0 +1,139 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+	"github.com/bitnami-labs/kubewatch/pkg/handlers"
+	"github.com/bitnami-labs/kubewatch/pkg/volumes"
+	"github.com/sirupsen/logrus"
+
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+)
+
+// describeVolume sets the details of the events of the persistent volumes
+// and claims, and notifies the updates changing their phase by the change,
+// e.g. ClaimBound, instead of Updated.
+func describeVolume(e *event.Event, oldObj, obj interface{}) {
+	details := volumes.Describe(obj)
+	if details == "" {
+		return
+	}
+	e.Details = details
+	if change, ok := volumes.Detect(oldObj, obj); ok {
+		e.Reason, e.Status = change.Reason, change.Status
+		e.Details = change.Description + "\n" + details
+	}
+}
+
+// claimChecker notifies the persistent volume claims still pending after
+// a threshold, on a schedule.
+type claimChecker struct {
+	client       kubernetes.Interface
+	namespace    string
+	pendingAfter time.Duration
+	interval     time.Duration
+	// notified are the claims notified as pending, until they are bound or
+	// deleted
+	notified map[types.UID]bool
+	// notifier notifies the events of the checker, without informer
+	notifier *Controller
+}
+
+// newClaimChecker returns the checker of the pending claims of the cluster,
+// with the defaults of the settings.
+func newClaimChecker(client kubernetes.Interface, cluster string, conf *config.Config, eventHandler handlers.Handler) (*claimChecker, error) {
+	logger := logrus.WithField("pkg", "kubewatch-volume")
+	if cluster != "" {
+		logger = logger.WithField("cluster", cluster)
+	}
+	settings := conf.Volumes
+	pendingAfter, err := time.ParseDuration(settings.PendingAfter)
+	if err != nil || pendingAfter <= 0 {
+		return nil, fmt.Errorf("invalid volumes pendingAfter %q", settings.PendingAfter)
+	}
+	interval := time.Minute
+	if settings.Interval != "" {
+		d, err := time.ParseDuration(settings.Interval)
+		if err != nil || d <= 0 {
+			logger.Warnf("Invalid volumes interval %q, checking every minute", settings.Interval)
+		} else {
+			interval = d
+		}
+	}
+	return &claimChecker{
+		client:       client,
+		namespace:    conf.Namespace,
+		pendingAfter: pendingAfter,
+		interval:     interval,
+		notified:     map[types.UID]bool{},
+		notifier: &Controller{
+			logger:       logger,
+			cluster:      cluster,
+			eventHandler: eventHandler,
+			enrich:       conf.Enrich,
+			optIn:        conf.OptIn,
+		},
+	}, nil
+}
+
+// Run checks the claims at once, then every interval until stopCh is
+// closed.
+func (c *claimChecker) Run(stopCh <-chan struct{}) {
+	c.notifier.logger.Infof("Checking the claims pending for more than %s every %s", c.pendingAfter, c.interval)
+	wait.Until(c.check, c.interval, stopCh)
+}
+
+// check notifies once the claims pending for more than the threshold.
+func (c *claimChecker) check() {
+	now := time.Now()
+	claims, err := c.client.CoreV1().PersistentVolumeClaims(c.namespace).List(meta_v1.ListOptions{})
+	if err != nil {
+		c.notifier.logger.Errorf("Cannot list the persistent volume claims: %v", err)
+		return
+	}
+	pending := map[types.UID]bool{}
+	for i := range claims.Items {
+		pvc := &claims.Items[i]
+		d, ok := volumes.Pending(pvc, c.pendingAfter, now)
+		if !ok {
+			continue
+		}
+		pending[pvc.UID] = true
+		if c.notified[pvc.UID] {
+			continue
+		}
+		c.notifier.handle(event.Event{
+			Kind:      "persistent volume claim",
+			Namespace: pvc.Namespace,
+			Name:      pvc.Name,
+			Status:    "Warning",
+			Reason:    volumes.ClaimPending,
+			Labels:    pvc.Labels,
+			Details:   volumes.PendingDescription(pvc, d) + "\n" + volumes.Describe(pvc),
+			Object:    snapshot(pvc),
+		}, now)
+	}
+	c.notified = pending
+}
@@ -0,0 +1,179 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"errors"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+	"github.com/bitnami-labs/kubewatch/pkg/handlers"
+	"k8s.io/client-go/kubernetes"
+	metadata_client "k8s.io/client-go/metadata"
+	"k8s.io/client-go/rest"
+)
+
+// Watcher watches the resources of a cluster, passing their events to the
+// handlers, callbacks and channels of its options. It lets Go programs embed
+// kubewatch without its bundled handlers:
+//
+//	w, err := controller.NewWatcher(restConfig,
+//		controller.WithResources("deployment", "pod"),
+//		controller.WithNamespace("shop"),
+//		controller.WithCallback(func(e event.Event) {
+//			fmt.Println(e.Message())
+//		}),
+//	)
+//	if err != nil {
+//		return err
+//	}
+//	w.Run(stopCh)
+type Watcher struct {
+	conf           *config.Config
+	client         kubernetes.Interface
+	metadataClient metadata_client.Interface
+	filter         config.Filter
+	handlers       []handlers.Handler
+}
+
+// Option configures a Watcher.
+type Option func(w *Watcher) error
+
+// WithConfig sets the settings of the watcher, as read from a kubewatch
+// config file, e.g. its resources, selectors and severities. Its handlers
+// are ignored, and the options set after it override it.
+func WithConfig(conf *config.Config) Option {
+	return func(w *Watcher) error {
+		c := *conf
+		w.conf = &c
+		return nil
+	}
+}
+
+// WithResources watches the resources of the keys, e.g. "deployment" or
+// "pod", besides the ones already set.
+func WithResources(keys ...string) Option {
+	return func(w *Watcher) error {
+		for _, key := range keys {
+			if err := w.conf.Resource.Watch(key); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// WithNamespace only watches the objects of the namespace.
+func WithNamespace(namespace string) Option {
+	return func(w *Watcher) error {
+		w.conf.Namespace = namespace
+		return nil
+	}
+}
+
+// WithFilter only passes the events matching the filter.
+func WithFilter(f config.Filter) Option {
+	return func(w *Watcher) error {
+		w.filter = f
+		return nil
+	}
+}
+
+// WithHandler passes the events to the handler, which is initialized by the
+// caller beforehand.
+func WithHandler(h handlers.Handler) Option {
+	return func(w *Watcher) error {
+		w.handlers = append(w.handlers, h)
+		return nil
+	}
+}
+
+// WithCallback calls the function with each event, from the goroutines of
+// the controllers of the resources; it must not block them for long.
+func WithCallback(f func(e event.Event)) Option {
+	return WithHandler(handlers.Func(f))
+}
+
+// WithChannel sends each event to the channel, blocking the controllers of
+// the resources while it is full. The channel is not closed by the watcher.
+func WithChannel(ch chan<- event.Event) Option {
+	return WithHandler(handlers.Func(func(e event.Event) {
+		ch <- e
+	}))
+}
+
+// WithClientset watches the cluster of the clientset instead of the one of
+// the rest config, e.g. a fake clientset in tests.
+func WithClientset(client kubernetes.Interface) Option {
+	return func(w *Watcher) error {
+		w.client = client
+		return nil
+	}
+}
+
+// NewWatcher returns a watcher of the cluster of the rest config, configured
+// by the options.
+func NewWatcher(restConfig *rest.Config, opts ...Option) (*Watcher, error) {
+	w := &Watcher{conf: &config.Config{}}
+	for _, opt := range opts {
+		if err := opt(w); err != nil {
+			return nil, err
+		}
+	}
+	if len(w.handlers) == 0 {
+		return nil, errors.New("no handler, callback or channel to pass the events to")
+	}
+
+	if w.client == nil {
+		client, err := kubernetes.NewForConfig(restConfig)
+		if err != nil {
+			return nil, err
+		}
+		w.client = client
+	}
+	if len(w.conf.Informers.MetadataOnly) > 0 && restConfig != nil {
+		metadataClient, err := metadata_client.NewForConfig(restConfig)
+		if err != nil {
+			return nil, err
+		}
+		w.metadataClient = metadataClient
+	}
+	return w, nil
+}
+
+// Run watches the resources until stopCh is closed, returning once the
+// pending events are passed to the handlers.
+func (w *Watcher) Run(stopCh <-chan struct{}) {
+	group := &handlers.Group{}
+	for _, h := range w.handlers {
+		group.Instances = append(group.Instances, handlers.Instance{Handler: h, Filter: w.filter})
+	}
+	severity := &handlers.Severity{Rules: w.conf.Severities}
+	severity.Handler = group
+	eventHandler := &handlers.External{Cluster: w.conf.ClusterName, Labels: w.conf.ExternalLabels}
+	eventHandler.Handler = severity
+
+	handlersStop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		handlers.Run(eventHandler, handlersStop)
+	}()
+	watchCluster(w.client, w.metadataClient, nil, "", w.conf, eventHandler, stopCh)
+	close(handlersStop)
+	<-done
+}
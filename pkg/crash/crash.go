@@ -0,0 +1,173 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package crash detects the restarts of the containers of pods and gets
+// the logs of the crashed containers.
+package crash
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	api_v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Reasons of the crashes.
+const (
+	Restarted        = "Restarted"
+	CrashLoopBackOff = "CrashLoopBackOff"
+)
+
+// Crash is a restart of a container, or its transition to CrashLoopBackOff.
+type Crash struct {
+	Container string
+	// Reason is Restarted or CrashLoopBackOff.
+	Reason       string
+	RestartCount int32
+	// ExitCode and TerminationReason (e.g. "OOMKilled" or "Error") of the
+	// last termination of the container, when known.
+	ExitCode          int32
+	TerminationReason string
+}
+
+// Detect returns the crashes of the containers between two versions of a pod.
+func Detect(old, new *api_v1.Pod) []Crash {
+	oldStatuses := map[string]api_v1.ContainerStatus{}
+	for _, s := range statuses(old) {
+		oldStatuses[s.Name] = s
+	}
+
+	var crashes []Crash
+	for _, s := range statuses(new) {
+		o, ok := oldStatuses[s.Name]
+		if !ok {
+			continue
+		}
+		crash := Crash{Container: s.Name, RestartCount: s.RestartCount}
+		switch {
+		case waitingReason(s) == CrashLoopBackOff && waitingReason(o) != CrashLoopBackOff:
+			crash.Reason = CrashLoopBackOff
+		case s.RestartCount > o.RestartCount:
+			crash.Reason = Restarted
+		default:
+			continue
+		}
+		if t := s.LastTerminationState.Terminated; t != nil {
+			crash.ExitCode = t.ExitCode
+			crash.TerminationReason = t.Reason
+		}
+		crashes = append(crashes, crash)
+	}
+	return crashes
+}
+
+// Description describes the crash in a sentence.
+func (c Crash) Description() string {
+	var b strings.Builder
+	if c.Reason == CrashLoopBackOff {
+		fmt.Fprintf(&b, "Container `%s` is in CrashLoopBackOff", c.Container)
+	} else {
+		fmt.Fprintf(&b, "Container `%s` restarted", c.Container)
+	}
+	if c.TerminationReason != "" {
+		fmt.Fprintf(&b, " (exit code %d, %s)", c.ExitCode, c.TerminationReason)
+	}
+	fmt.Fprintf(&b, ", %d restarts", c.RestartCount)
+	return b.String()
+}
+
+func statuses(pod *api_v1.Pod) []api_v1.ContainerStatus {
+	return append(append([]api_v1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+}
+
+func waitingReason(s api_v1.ContainerStatus) string {
+	if s.State.Waiting == nil {
+		return ""
+	}
+	return s.State.Waiting.Reason
+}
+
+// Logs returns the last lines of the logs of the previous instance of the
+// container, which crashed.
+func Logs(client kubernetes.Interface, pod *api_v1.Pod, container string, lines int64) (string, error) {
+	b, err := client.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &api_v1.PodLogOptions{
+		Container: container,
+		Previous:  true,
+		TailLines: &lines,
+	}).Do().Raw()
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// Truncate returns the last maxBytes of the logs, starting at a line.
+func Truncate(logs string, maxBytes int) string {
+	if len(logs) <= maxBytes {
+		return logs
+	}
+	logs = logs[len(logs)-maxBytes:]
+	if i := strings.IndexByte(logs, '\n'); i >= 0 {
+		logs = logs[i+1:]
+	}
+	return logs
+}
+
+// Redacted replaces the secrets found in the logs.
+const Redacted = "[REDACTED]"
+
+// defaultRedactions match the usual forms of secrets in logs, keeping the
+// name of the secret.
+var defaultRedactions = []struct {
+	re   *regexp.Regexp
+	repl string
+}{
+	{regexp.MustCompile(`(?i)(bearer\s+)[a-z0-9._~+/=-]+`), "${1}" + Redacted},
+	{regexp.MustCompile(`(?i)((?:password|passwd|secret|token|api[_-]?key|access[_-]?key)["']?\s*[:=]\s*["']?)[^\s"',;&]+`), "${1}" + Redacted},
+	{regexp.MustCompile(`(://[^/\s:@]+:)[^/\s@]+@`), "${1}" + Redacted + "@"},
+}
+
+// Redactor removes secrets from logs.
+type Redactor struct {
+	patterns []*regexp.Regexp
+}
+
+// NewRedactor returns a Redactor replacing the matches of the given regular
+// expressions, in addition to the usual forms of passwords, tokens and keys.
+func NewRedactor(patterns []string) (*Redactor, error) {
+	r := &Redactor{}
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q: %v", p, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	return r, nil
+}
+
+// Redact returns the logs without the secrets they contain.
+func (r *Redactor) Redact(logs string) string {
+	for _, d := range defaultRedactions {
+		logs = d.re.ReplaceAllString(logs, d.repl)
+	}
+	for _, re := range r.patterns {
+		logs = re.ReplaceAllString(logs, Redacted)
+	}
+	return logs
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package crash
+
+import (
+	"reflect"
+	"testing"
+
+	api_v1 "k8s.io/api/core/v1"
+)
+
+func pod(statuses ...api_v1.ContainerStatus) *api_v1.Pod {
+	return &api_v1.Pod{Status: api_v1.PodStatus{ContainerStatuses: statuses}}
+}
+
+func TestDetect(t *testing.T) {
+	running := api_v1.ContainerStatus{Name: "app", RestartCount: 1}
+	restarted := api_v1.ContainerStatus{
+		Name:                 "app",
+		RestartCount:         2,
+		LastTerminationState: api_v1.ContainerState{Terminated: &api_v1.ContainerStateTerminated{ExitCode: 137, Reason: "OOMKilled"}},
+	}
+	backoff := restarted
+	backoff.State = api_v1.ContainerState{Waiting: &api_v1.ContainerStateWaiting{Reason: CrashLoopBackOff}}
+
+	var Tests = []struct {
+		old, new *api_v1.Pod
+		want     []Crash
+	}{
+		{pod(running), pod(running), nil},
+		{pod(running), pod(restarted), []Crash{{Container: "app", Reason: Restarted, RestartCount: 2, ExitCode: 137, TerminationReason: "OOMKilled"}}},
+		{pod(running), pod(backoff), []Crash{{Container: "app", Reason: CrashLoopBackOff, RestartCount: 2, ExitCode: 137, TerminationReason: "OOMKilled"}}},
+		{pod(backoff), pod(backoff), nil},
+		{pod(), pod(restarted), nil},
+	}
+	for _, tt := range Tests {
+		if got := Detect(tt.old, tt.new); !reflect.DeepEqual(got, tt.want) {
+			t.Fatalf("Detect(): got %+v, want %+v", got, tt.want)
+		}
+	}
+
+	c := Crash{Container: "app", Reason: Restarted, RestartCount: 2, ExitCode: 137, TerminationReason: "OOMKilled"}
+	if got, want := c.Description(), "Container `app` restarted (exit code 137, OOMKilled), 2 restarts"; got != want {
+		t.Fatalf("Description() = %q, want %q", got, want)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	if got := Truncate("a\nb\n", 10); got != "a\nb\n" {
+		t.Fatalf("Truncate() = %q", got)
+	}
+	if got := Truncate("first line\nsecond\nthird\n", 12); got != "third\n" {
+		t.Fatalf("Truncate() = %q, want %q", got, "third\n")
+	}
+}
+
+func TestRedact(t *testing.T) {
+	r, err := NewRedactor([]string{`card=\d+`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var Tests = []struct {
+		logs, want string
+	}{
+		{"connecting to db", "connecting to db"},
+		{"password=hunter2 user=bob", "password=[REDACTED] user=bob"},
+		{`{"api_key": "abc123"}`, `{"api_key": "[REDACTED]"}`},
+		{"Authorization: Bearer eyJhbGciOi.x-y", "Authorization: Bearer [REDACTED]"},
+		{"dial postgres://bob:hunter2@db:5432/app", "dial postgres://bob:[REDACTED]@db:5432/app"},
+		{"paid with card=4111111111111111", "paid with [REDACTED]"},
+	}
+	for _, tt := range Tests {
+		if got := r.Redact(tt.logs); got != tt.want {
+			t.Fatalf("Redact(%q) = %q, want %q", tt.logs, got, tt.want)
+		}
+	}
+
+	if _, err := NewRedactor([]string{"("}); err == nil {
+		t.Fatal("NewRedactor(): expected an error for an invalid pattern")
+	}
+}
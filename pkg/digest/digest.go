@@ -0,0 +1,202 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package digest summarizes the events of a period in a single event.
+package digest
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+	"github.com/bitnami-labs/kubewatch/pkg/severity"
+)
+
+// maxNames is the number of object names listed per kind and reason.
+const maxNames = 10
+
+// severityRanks orders the severities of the digests.
+var severityRanks = map[string]int{
+	severity.Info:     1,
+	severity.Warning:  2,
+	severity.Critical: 3,
+}
+
+// group collects the events of a kind in a namespace.
+type group struct {
+	cluster, namespace, kind string
+	// names of the objects by reason, in order, and the number of events
+	// about each of them
+	reasons map[string][]string
+	counts  map[string]map[string]int
+}
+
+// Digest collects events until they are summarized. It is safe for
+// concurrent use.
+type Digest struct {
+	mu       sync.Mutex
+	groups   map[string]*group
+	count    int
+	severity string
+	external map[string]string
+}
+
+// Add collects an event.
+func (d *Digest) Add(e event.Event) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.groups == nil {
+		d.groups = map[string]*group{}
+	}
+	key := e.Cluster + "/" + e.Namespace + "/" + e.Kind
+	g, ok := d.groups[key]
+	if !ok {
+		g = &group{
+			cluster:   e.Cluster,
+			namespace: e.Namespace,
+			kind:      e.Kind,
+			reasons:   map[string][]string{},
+			counts:    map[string]map[string]int{},
+		}
+		d.groups[key] = g
+	}
+	if g.counts[e.Reason] == nil {
+		g.counts[e.Reason] = map[string]int{}
+	}
+	if g.counts[e.Reason][e.Name] == 0 {
+		g.reasons[e.Reason] = append(g.reasons[e.Reason], e.Name)
+	}
+	g.counts[e.Reason][e.Name]++
+
+	d.count++
+	if severityRanks[e.Severity] > severityRanks[d.severity] {
+		d.severity = e.Severity
+	}
+	if d.external == nil {
+		d.external = e.ExternalLabels
+	}
+}
+
+// Flush returns an event summarizing the collected events, described as
+// happening during the given period, e.g. "the last 15m", and starts
+// collecting again. It returns false when no events were collected.
+func (d *Digest) Flush(period string) (event.Event, bool) {
+	d.mu.Lock()
+	groups, count, sev, external := d.groups, d.count, d.severity, d.external
+	d.groups, d.count, d.severity, d.external = nil, 0, "", nil
+	d.mu.Unlock()
+
+	if count == 0 {
+		return event.Event{}, false
+	}
+
+	sorted := make([]*group, 0, len(groups))
+	clusters := map[string]bool{}
+	for _, g := range groups {
+		sorted = append(sorted, g)
+		clusters[g.cluster] = true
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if a.cluster != b.cluster {
+			return a.cluster < b.cluster
+		}
+		if a.namespace != b.namespace {
+			return a.namespace < b.namespace
+		}
+		return a.kind < b.kind
+	})
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Kubewatch digest of %s: %d events", period, count)
+	var last string
+	for _, g := range sorted {
+		scope := "cluster-wide"
+		if g.namespace != "" {
+			scope = fmt.Sprintf("namespace `%s`", g.namespace)
+		}
+		if len(clusters) > 1 && g.cluster != "" {
+			scope = fmt.Sprintf("cluster `%s`, %s", g.cluster, scope)
+		}
+		if scope != last {
+			fmt.Fprintf(&b, "\n%s:", scope)
+			last = scope
+		}
+		fmt.Fprintf(&b, "\n- `%s`: %s", g.kind, g.summary())
+	}
+
+	e := event.Event{
+		Kind:           event.DigestKind,
+		Reason:         "Digest",
+		Severity:       sev,
+		Details:        b.String(),
+		ExternalLabels: external,
+	}
+	if len(clusters) == 1 {
+		e.Cluster = sorted[0].cluster
+	}
+	return e, true
+}
+
+// summary lists the objects of the group by reason, e.g.
+// "created web, api; updated web (3)".
+func (g *group) summary() string {
+	reasons := make([]string, 0, len(g.reasons))
+	for r := range g.reasons {
+		reasons = append(reasons, r)
+	}
+	sort.Slice(reasons, func(i, j int) bool {
+		return reasonRank(reasons[i]) < reasonRank(reasons[j]) ||
+			reasonRank(reasons[i]) == reasonRank(reasons[j]) && reasons[i] < reasons[j]
+	})
+
+	parts := make([]string, 0, len(reasons))
+	for _, r := range reasons {
+		names := g.reasons[r]
+		listed := make([]string, 0, maxNames)
+		for i, name := range names {
+			if i == maxNames {
+				break
+			}
+			if n := g.counts[r][name]; n > 1 {
+				name = fmt.Sprintf("%s (%d)", name, n)
+			}
+			listed = append(listed, name)
+		}
+		part := strings.ToLower(r) + " " + strings.Join(listed, ", ")
+		if len(names) > maxNames {
+			part += fmt.Sprintf(" and %d more", len(names)-maxNames)
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// reasonRank lists creations, updates and deletions before the other reasons.
+func reasonRank(reason string) int {
+	switch reason {
+	case "Created":
+		return 0
+	case "Updated":
+		return 1
+	case "Deleted":
+		return 2
+	}
+	return 3
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package digest
+
+import (
+	"testing"
+
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+)
+
+func TestFlush(t *testing.T) {
+	var d Digest
+	if _, ok := d.Flush("the last 15m"); ok {
+		t.Fatal("Flush() of an empty digest returned an event")
+	}
+
+	for _, e := range []event.Event{
+		{Namespace: "default", Kind: "deployment", Name: "web", Reason: "Updated", Severity: "warning"},
+		{Namespace: "default", Kind: "deployment", Name: "web", Reason: "Updated", Severity: "warning"},
+		{Namespace: "default", Kind: "deployment", Name: "api", Reason: "Created", Severity: "info"},
+		{Namespace: "default", Kind: "pod", Name: "web-1", Reason: "Deleted", Severity: "critical"},
+		{Kind: "node", Name: "node-1", Reason: "Updated", Severity: "warning"},
+	} {
+		d.Add(e)
+	}
+
+	e, ok := d.Flush("the last 15m")
+	if !ok {
+		t.Fatal("Flush() returned no event")
+	}
+	want := "Kubewatch digest of the last 15m: 5 events" +
+		"\ncluster-wide:" +
+		"\n- `node`: updated node-1" +
+		"\nnamespace `default`:" +
+		"\n- `deployment`: created api; updated web (2)" +
+		"\n- `pod`: deleted web-1"
+	if e.Kind != event.DigestKind || e.Details != want {
+		t.Fatalf("Flush() = %+v, want details %q", e, want)
+	}
+	if e.Message() != want {
+		t.Fatalf("Message() = %q", e.Message())
+	}
+	if e.Severity != "critical" {
+		t.Fatalf("severity %q, want the highest one", e.Severity)
+	}
+
+	if _, ok := d.Flush("the last 15m"); ok {
+		t.Fatal("Flush() did not reset the digest")
+	}
+}
+
+func TestFlushNames(t *testing.T) {
+	var d Digest
+	for _, name := range []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k", "l"} {
+		d.Add(event.Event{Namespace: "ns", Kind: "pod", Name: name, Reason: "Created"})
+	}
+	e, _ := d.Flush("the quiet hours")
+	want := "Kubewatch digest of the quiet hours: 12 events" +
+		"\nnamespace `ns`:" +
+		"\n- `pod`: created a, b, c, d, e, f, g, h, i, j and 2 more"
+	if e.Details != want {
+		t.Fatalf("Flush() details %q, want %q", e.Details, want)
+	}
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package event
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// DefaultDiffIgnoreFields are never reported as changes since they change on every update.
+var DefaultDiffIgnoreFields = []string{
+	"metadata.resourceVersion",
+	"metadata.managedFields",
+}
+
+// Change describes a field which differs between the old and new version of an object.
+// Values are JSON encoded, an empty value means the field is absent.
+type Change struct {
+	Path string `json:"path"`
+	Old  string `json:"old,omitempty"`
+	New  string `json:"new,omitempty"`
+}
+
+// Diff returns the fields changed between two versions of an object, sorted by path.
+// Fields matching DefaultDiffIgnoreFields or the given ignore list, either exactly
+// or as a parent, are skipped.
+func Diff(oldObj, newObj interface{}, ignore []string) ([]Change, error) {
+	oldMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(oldObj)
+	if err != nil {
+		return nil, err
+	}
+	newMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(newObj)
+	if err != nil {
+		return nil, err
+	}
+
+	d := differ{ignore: append(append([]string{}, DefaultDiffIgnoreFields...), ignore...)}
+	d.diff("", oldMap, newMap)
+	sort.Slice(d.changes, func(i, j int) bool { return d.changes[i].Path < d.changes[j].Path })
+	return d.changes, nil
+}
+
+// StatusOnly reports whether an update only changed the status of an object,
+// besides the fields of DefaultDiffIgnoreFields.
+func StatusOnly(oldObj, newObj interface{}) (bool, error) {
+	changes, err := Diff(oldObj, newObj, []string{"status"})
+	if err != nil {
+		return false, err
+	}
+	return len(changes) == 0, nil
+}
+
+type differ struct {
+	ignore  []string
+	changes []Change
+}
+
+func (d *differ) diff(path string, oldValue, newValue interface{}) {
+	if d.ignored(path) || reflect.DeepEqual(oldValue, newValue) {
+		return
+	}
+
+	oldMap, oldIsMap := oldValue.(map[string]interface{})
+	newMap, newIsMap := newValue.(map[string]interface{})
+	if oldIsMap && newIsMap {
+		for k, v := range oldMap {
+			d.diff(join(path, k), v, newMap[k])
+		}
+		for k, v := range newMap {
+			if _, ok := oldMap[k]; !ok {
+				d.diff(join(path, k), nil, v)
+			}
+		}
+		return
+	}
+
+	oldSlice, oldIsSlice := oldValue.([]interface{})
+	newSlice, newIsSlice := newValue.([]interface{})
+	if oldIsSlice && newIsSlice && len(oldSlice) == len(newSlice) {
+		for i := range oldSlice {
+			d.diff(fmt.Sprintf("%s[%d]", path, i), oldSlice[i], newSlice[i])
+		}
+		return
+	}
+
+	d.changes = append(d.changes, Change{Path: path, Old: encode(oldValue), New: encode(newValue)})
+}
+
+func (d *differ) ignored(path string) bool {
+	for _, i := range d.ignore {
+		if path == i || strings.HasPrefix(path, i+".") || strings.HasPrefix(path, i+"[") {
+			return true
+		}
+	}
+	return false
+}
+
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func encode(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package event
+
+import (
+	"reflect"
+	"testing"
+
+	apps_v1 "k8s.io/api/apps/v1"
+	api_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func deployment(image string, replicas int32, resourceVersion string) *apps_v1.Deployment {
+	return &apps_v1.Deployment{
+		ObjectMeta: meta_v1.ObjectMeta{Name: "app", ResourceVersion: resourceVersion},
+		Spec: apps_v1.DeploymentSpec{
+			Replicas: &replicas,
+			Template: api_v1.PodTemplateSpec{
+				Spec: api_v1.PodSpec{
+					Containers: []api_v1.Container{{Name: "app", Image: image}},
+				},
+			},
+		},
+	}
+}
+
+func TestDiff(t *testing.T) {
+	var Tests = []struct {
+		old, new *apps_v1.Deployment
+		ignore   []string
+		want     []Change
+	}{
+		{deployment("app:1", 1, "1"), deployment("app:1", 1, "2"), nil, nil},
+		{
+			deployment("app:1", 1, "1"), deployment("app:2", 3, "2"), nil,
+			[]Change{
+				{Path: "spec.replicas", Old: "1", New: "3"},
+				{Path: "spec.template.spec.containers[0].image", Old: `"app:1"`, New: `"app:2"`},
+			},
+		},
+		{
+			deployment("app:1", 1, "1"), deployment("app:2", 3, "2"), []string{"spec.replicas"},
+			[]Change{
+				{Path: "spec.template.spec.containers[0].image", Old: `"app:1"`, New: `"app:2"`},
+			},
+		},
+	}
+
+	for _, tt := range Tests {
+		got, err := Diff(tt.old, tt.new, tt.ignore)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Fatalf("Diff(): got %+v, want %+v", got, tt.want)
+		}
+	}
+}
+
+func TestStatusOnly(t *testing.T) {
+	old := deployment("app:1", 1, "1")
+	scaled := deployment("app:1", 1, "2")
+	scaled.Status.ReadyReplicas = 1
+	updated := deployment("app:2", 1, "3")
+	updated.Status.ReadyReplicas = 1
+
+	var Tests = []struct {
+		new  *apps_v1.Deployment
+		want bool
+	}{
+		{deployment("app:1", 1, "2"), true},
+		{scaled, true},
+		{updated, false},
+	}
+
+	for _, tt := range Tests {
+		got, err := StatusOnly(old, tt.new)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Fatalf("StatusOnly(%+v): got %v, want %v", tt.new, got, tt.want)
+		}
+	}
+}
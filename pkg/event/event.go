@@ -14,13 +14,21 @@ limitations under the License.
 package event
 
 import (
+	"context"
 	"fmt"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/bitnami-labs/kubewatch/pkg/utils"
+	"github.com/sirupsen/logrus"
 	apps_v1 "k8s.io/api/apps/v1"
+	autoscaling_v1 "k8s.io/api/autoscaling/v1"
 	batch_v1 "k8s.io/api/batch/v1"
+	batch_v1beta1 "k8s.io/api/batch/v1beta1"
 	api_v1 "k8s.io/api/core/v1"
 	ext_v1beta1 "k8s.io/api/extensions/v1beta1"
+	networking_v1 "k8s.io/api/networking/v1"
 	rbac_v1beta1 "k8s.io/api/rbac/v1beta1"
 )
 
@@ -28,15 +36,61 @@ import (
 // Events from different endpoints need to be casted to KubewatchEvent
 // before being able to be handled by handler
 type Event struct {
-	Namespace string
-	Kind      string
-	Component string
-	Host      string
-	Reason    string
-	Status    string
-	Name      string
+	// ID identifies the event in the logs of its deliveries and in the
+	// payloads of some handlers, assigned when it enters the handlers.
+	ID string `json:"id,omitempty"`
+	// Cluster is the name of the cluster of the event, when configured.
+	Cluster   string            `json:"cluster,omitempty"`
+	Namespace string            `json:"namespace"`
+	Kind      string            `json:"kind"`
+	Component string            `json:"component,omitempty"`
+	Host      string            `json:"host,omitempty"`
+	Reason    string            `json:"reason"`
+	Status    string            `json:"status"`
+	Name      string            `json:"name"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	// ExternalLabels are the static labels of the config, e.g. env: prod.
+	ExternalLabels map[string]string `json:"externalLabels,omitempty"`
+	// Severity is "critical", "warning" or "info".
+	Severity string `json:"severity,omitempty"`
+	// Details is the message of a Kubernetes Event.
+	Details string `json:"details,omitempty"`
+	// Diff lists the fields changed by an update, when known.
+	Diff []Change `json:"diff,omitempty"`
+	// Images lists the container images changed by an update of a workload.
+	Images []ImageChange `json:"images,omitempty"`
+	// Metadata are the labels and annotations of the object selected by the
+	// enrich settings, e.g. team: payments.
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// Channels are the channels the object asks to be notified in, by
+	// handler, e.g. slack: "#team-payments".
+	Channels map[string]string `json:"channels,omitempty"`
+	// Operation is the change of the object notified: "create", "update" or
+	// "delete"; it is empty for the Kubernetes Events and the digests.
+	Operation string `json:"operation,omitempty"`
+	// Ref identifies the object of the event, when known.
+	Ref *ObjectReference `json:"ref,omitempty"`
+	// Timestamp is when the event occurred, when known.
+	Timestamp time.Time `json:"timestamp"`
+	// Object is the Kubernetes object of the event, when known, with the
+	// values of secrets removed. It is not part of the serialized event.
+	Object interface{} `json:"-"`
+	// OldObject is the Kubernetes object before an update, when known, with
+	// the values of secrets removed. It is not part of the serialized event.
+	OldObject interface{} `json:"-"`
+
+	// ctx carries the trace of the event through the handlers.
+	ctx context.Context
 }
 
+// DigestKind is the kind of the events summarizing other events, whose
+// Details hold the summary.
+const DigestKind = "digest"
+
+// ReconciliationKind is the kind of the reports of the listings of the
+// watched objects requested with SIGHUP, whose Details hold the report.
+const ReconciliationKind = "reconciliation"
+
 var m = map[string]string{
 	"created": "Normal",
 	"deleted": "Danger",
@@ -46,10 +100,12 @@ var m = map[string]string{
 // New create new KubewatchEvent
 func New(obj interface{}, action string) Event {
 	var namespace, kind, component, host, reason, status, name string
+	var labels map[string]string
 
 	objectMeta := utils.GetObjectMetaData(obj)
 	namespace = objectMeta.Namespace
 	name = objectMeta.Name
+	labels = objectMeta.Labels
 	reason = action
 	status = m[action]
 
@@ -66,6 +122,8 @@ func New(obj interface{}, action string) Event {
 		kind = "ingress"
 	case *api_v1.PersistentVolume:
 		kind = "persistent volume"
+	case *api_v1.PersistentVolumeClaim:
+		kind = "persistent volume claim"
 	case *api_v1.Pod:
 		kind = "pod"
 		host = object.Spec.NodeName
@@ -86,10 +144,19 @@ func New(obj interface{}, action string) Event {
 		kind = "cluster role"
 	case *api_v1.ServiceAccount:
 		kind = "service account"
+	case *apps_v1.StatefulSet:
+		kind = "stateful set"
+	case *batch_v1beta1.CronJob:
+		kind = "cron job"
+	case *autoscaling_v1.HorizontalPodAutoscaler:
+		kind = "horizontal pod autoscaler"
+	case *networking_v1.NetworkPolicy:
+		kind = "network policy"
 	case Event:
 		name = object.Name
 		kind = object.Kind
 		namespace = object.Namespace
+		labels = object.Labels
 	}
 
 	kbEvent := Event{
@@ -100,13 +167,118 @@ func New(obj interface{}, action string) Event {
 		Reason:    reason,
 		Status:    status,
 		Name:      name,
+		Labels:    labels,
 	}
 	return kbEvent
 }
 
-// Message returns event message in standard format.
+// Context returns the context of the event, carrying its trace.
+func (e *Event) Context() context.Context {
+	if e.ctx == nil {
+		return context.Background()
+	}
+	return e.ctx
+}
+
+// WithContext returns a copy of the event with its context set to ctx.
+func (e *Event) WithContext(ctx context.Context) Event {
+	c := *e
+	c.ctx = ctx
+	return c
+}
+
+// Channel returns the channel of the handler the object asks to be notified
+// in, or the one configured.
+func (e *Event) Channel(handler, configured string) string {
+	if channel := e.Channels[handler]; channel != "" {
+		return channel
+	}
+	return configured
+}
+
+// NamespaceChannel returns the channel of the namespace of the event in
+// channels, e.g. payments: "#payments-alerts", or the one configured.
+func (e *Event) NamespaceChannel(channels map[string]string, configured string) string {
+	if channel := channels[e.Namespace]; channel != "" && e.Namespace != "" {
+		return channel
+	}
+	return configured
+}
+
+// LogFields returns the metadata of the event as structured log fields.
+func (e *Event) LogFields() logrus.Fields {
+	fields := logrus.Fields{
+		"kind":   e.Kind,
+		"name":   e.Name,
+		"reason": e.Reason,
+	}
+	if e.Namespace != "" {
+		fields["namespace"] = e.Namespace
+	}
+	if e.Cluster != "" {
+		fields["cluster"] = e.Cluster
+	}
+	if e.Severity != "" {
+		fields["severity"] = e.Severity
+	}
+	if e.ID != "" {
+		fields["event_id"] = e.ID
+	}
+	return fields
+}
+
+// Message returns event message in standard format, prefixed with the
+// cluster and the external labels when known, and followed by the metadata.
 // included as a part of event packege to enhance code resuablity across handlers.
-func (e *Event) Message() (msg string) {
+func (e *Event) Message() string {
+	var prefix []string
+	if e.Cluster != "" {
+		prefix = append(prefix, e.Cluster)
+	}
+	labels := make([]string, 0, len(e.ExternalLabels))
+	for k, v := range e.ExternalLabels {
+		labels = append(labels, k+"="+v)
+	}
+	sort.Strings(labels)
+	prefix = append(prefix, labels...)
+	if len(prefix) > 0 {
+		return fmt.Sprintf("[%s] %s", strings.Join(prefix, " "), e.message()+e.metadataMessage())
+	}
+	return e.message() + e.metadataMessage()
+}
+
+// metadataMessage lists the metadata, one per line, sorted by key.
+func (e *Event) metadataMessage() string {
+	keys := make([]string, 0, len(e.Metadata))
+	for k := range e.Metadata {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	for _, k := range keys {
+		fmt.Fprintf(&b, "\n%s: %s", k, e.Metadata[k])
+	}
+	return b.String()
+}
+
+func (e *Event) message() (msg string) {
+	if e.Kind == DigestKind || e.Kind == ReconciliationKind {
+		return e.Details
+	}
+	if e.Details != "" && e.Namespace == "" {
+		// cluster scoped objects, e.g. nodes
+		return fmt.Sprintf("A `%s` `%s` reported `%s`:\n%s", e.Kind, e.Name, e.Reason, e.Details)
+	}
+	if e.Details != "" {
+		return fmt.Sprintf(
+			"A `%s` `%s` in namespace `%s` reported `%s`:\n%s",
+			e.Kind,
+			e.Name,
+			e.Namespace,
+			e.Reason,
+			e.Details,
+		)
+	}
 	// using switch over if..else, since the format could vary based on the kind of the object in future.
 	switch e.Kind {
 	case "namespace":
@@ -158,5 +330,50 @@ func (e *Event) Message() (msg string) {
 			e.Name,
 		)
 	}
-	return msg
+	return msg + e.imagesMessage() + e.diffMessage()
+}
+
+// imagesMessage lists the changed images, one per line.
+func (e *Event) imagesMessage() string {
+	var b strings.Builder
+	for _, c := range e.Images {
+		fmt.Fprintf(&b, "\nimage `%s` → `%s` (container `%s`)", orNone(c.Old), orNone(c.New), c.Container)
+	}
+	return b.String()
+}
+
+// maxDiffLines is the number of changes listed in messages.
+const maxDiffLines = 10
+
+// diffMessage lists the changed fields, one per line, except the images
+// listed by imagesMessage.
+func (e *Event) diffMessage() string {
+	diff := e.Diff
+	if len(e.Images) > 0 {
+		diff = nil
+		for _, c := range e.Diff {
+			if !isImagePath(c.Path) {
+				diff = append(diff, c)
+			}
+		}
+	}
+	if len(diff) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	for i, c := range diff {
+		if i == maxDiffLines {
+			fmt.Fprintf(&b, "\n... and %d more changes", len(diff)-maxDiffLines)
+			break
+		}
+		fmt.Fprintf(&b, "\n`%s`: %s → %s", c.Path, orNone(c.Old), orNone(c.New))
+	}
+	return b.String()
+}
+
+func orNone(v string) string {
+	if v == "" {
+		return "<none>"
+	}
+	return v
 }
@@ -0,0 +1,57 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package event
+
+import "testing"
+
+func TestMessageCluster(t *testing.T) {
+	e := Event{Kind: "namespace", Name: "team-a", Reason: "Created"}
+	if got, want := e.Message(), "A namespace `team-a` has been `Created`"; got != want {
+		t.Fatalf("Message() = %q, want %q", got, want)
+	}
+	e.Cluster = "prod"
+	if got, want := e.Message(), "[prod] A namespace `team-a` has been `Created`"; got != want {
+		t.Fatalf("Message() = %q, want %q", got, want)
+	}
+	e.ExternalLabels = map[string]string{"region": "eu-west-1", "env": "prod"}
+	if got, want := e.Message(), "[prod env=prod region=eu-west-1] A namespace `team-a` has been `Created`"; got != want {
+		t.Fatalf("Message() = %q, want %q", got, want)
+	}
+	if e.LogFields()["cluster"] != "prod" {
+		t.Fatalf("LogFields() = %v", e.LogFields())
+	}
+}
+
+func TestMessageDetails(t *testing.T) {
+	e := Event{Kind: "pod", Namespace: "prod", Name: "api", Reason: "Restarted", Details: "Container `api` restarted"}
+	if got, want := e.Message(), "A `pod` `api` in namespace `prod` reported `Restarted`:\nContainer `api` restarted"; got != want {
+		t.Fatalf("Message() = %q, want %q", got, want)
+	}
+	e = Event{Kind: "node", Name: "worker-3", Reason: "Cordoned", Details: "Node was cordoned"}
+	if got, want := e.Message(), "A `node` `worker-3` reported `Cordoned`:\nNode was cordoned"; got != want {
+		t.Fatalf("Message() = %q, want %q", got, want)
+	}
+}
+
+func TestMessageMetadata(t *testing.T) {
+	e := Event{Kind: "namespace", Name: "team-a", Reason: "Created", Cluster: "prod"}
+	e.Metadata = map[string]string{"team": "payments", "runbook": "https://runbooks.example.com/team-a"}
+	want := "[prod] A namespace `team-a` has been `Created`\nrunbook: https://runbooks.example.com/team-a\nteam: payments"
+	if got := e.Message(); got != want {
+		t.Fatalf("Message() = %q, want %q", got, want)
+	}
+}
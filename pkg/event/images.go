@@ -0,0 +1,88 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package event
+
+import (
+	"strings"
+
+	apps_v1 "k8s.io/api/apps/v1"
+	api_v1 "k8s.io/api/core/v1"
+	ext_v1beta1 "k8s.io/api/extensions/v1beta1"
+)
+
+// ImageChange is a container image changed by an update of a workload.
+// An empty image means the container was added or removed.
+type ImageChange struct {
+	Container string `json:"container"`
+	Old       string `json:"old,omitempty"`
+	New       string `json:"new,omitempty"`
+}
+
+// ImageChanges returns the container images changed between two versions
+// of a deployment, stateful set or daemon set, in the order of the init
+// containers then of the containers; nil for the other objects.
+func ImageChanges(oldObj, newObj interface{}) []ImageChange {
+	oldSpec, newSpec := podSpec(oldObj), podSpec(newObj)
+	if oldSpec == nil || newSpec == nil {
+		return nil
+	}
+
+	oldImages := map[string]string{}
+	for _, c := range containers(oldSpec) {
+		oldImages[c.Name] = c.Image
+	}
+	var changes []ImageChange
+	for _, c := range containers(newSpec) {
+		old, ok := oldImages[c.Name]
+		delete(oldImages, c.Name)
+		if ok && old == c.Image {
+			continue
+		}
+		changes = append(changes, ImageChange{Container: c.Name, Old: old, New: c.Image})
+	}
+	for _, c := range containers(oldSpec) {
+		if _, removed := oldImages[c.Name]; removed {
+			changes = append(changes, ImageChange{Container: c.Name, Old: c.Image})
+		}
+	}
+	return changes
+}
+
+// podSpec returns the spec of the pods of a workload.
+func podSpec(obj interface{}) *api_v1.PodSpec {
+	switch o := obj.(type) {
+	case *apps_v1.Deployment:
+		return &o.Spec.Template.Spec
+	case *apps_v1.StatefulSet:
+		return &o.Spec.Template.Spec
+	case *apps_v1.DaemonSet:
+		return &o.Spec.Template.Spec
+	case *ext_v1beta1.DaemonSet:
+		return &o.Spec.Template.Spec
+	}
+	return nil
+}
+
+func containers(spec *api_v1.PodSpec) []api_v1.Container {
+	return append(append([]api_v1.Container{}, spec.InitContainers...), spec.Containers...)
+}
+
+// isImagePath reports whether a diff path is the image of a container.
+func isImagePath(path string) bool {
+	return strings.HasSuffix(path, "].image") &&
+		(strings.Contains(path, ".containers[") || strings.Contains(path, ".initContainers["))
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package event
+
+import (
+	"reflect"
+	"testing"
+
+	api_v1 "k8s.io/api/core/v1"
+)
+
+func TestImageChanges(t *testing.T) {
+	if got := ImageChanges(deployment("app:1", 1, "1"), deployment("app:1", 3, "2")); got != nil {
+		t.Fatalf("ImageChanges(): got %v, want none", got)
+	}
+
+	old := deployment("app:1.2.3", 1, "1")
+	old.Spec.Template.Spec.Containers = append(old.Spec.Template.Spec.Containers, api_v1.Container{Name: "proxy", Image: "envoy:1"})
+	new := deployment("app:1.2.4", 1, "2")
+	new.Spec.Template.Spec.InitContainers = []api_v1.Container{{Name: "migrate", Image: "app:1.2.4"}}
+	want := []ImageChange{
+		{Container: "migrate", New: "app:1.2.4"},
+		{Container: "app", Old: "app:1.2.3", New: "app:1.2.4"},
+		{Container: "proxy", Old: "envoy:1"},
+	}
+	if got := ImageChanges(old, new); !reflect.DeepEqual(got, want) {
+		t.Fatalf("ImageChanges(): got %v, want %v", got, want)
+	}
+
+	if got := ImageChanges(&api_v1.Pod{}, &api_v1.Pod{}); got != nil {
+		t.Fatalf("ImageChanges(pod): got %v, want none", got)
+	}
+}
+
+func TestMessageImages(t *testing.T) {
+	e := Event{
+		Kind:      "deployment",
+		Namespace: "prod",
+		Name:      "app",
+		Reason:    "Updated",
+		Diff: []Change{
+			{Path: "spec.replicas", Old: "1", New: "3"},
+			{Path: "spec.template.spec.containers[0].image", Old: `"app:1.2.3"`, New: `"app:1.2.4"`},
+		},
+		Images: []ImageChange{{Container: "app", Old: "app:1.2.3", New: "app:1.2.4"}},
+	}
+	want := "A `deployment` in namespace `prod` has been `Updated`:\n`app`" +
+		"\nimage `app:1.2.3` → `app:1.2.4` (container `app`)" +
+		"\n`spec.replicas`: 1 → 3"
+	if got := e.Message(); got != want {
+		t.Fatalf("Message() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,157 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package event
+
+import (
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+// Versions of the schema of the events serialized in the payloads of the
+// handlers.
+const (
+	PayloadV1 = "v1"
+	PayloadV2 = "v2"
+)
+
+// ValidPayloadVersion reports whether the payload version is known, the
+// empty one meaning v1.
+func ValidPayloadVersion(version string) bool {
+	return version == "" || version == PayloadV1 || version == PayloadV2
+}
+
+// ObjectReference identifies the object of an event.
+type ObjectReference struct {
+	APIVersion      string `json:"apiVersion,omitempty"`
+	Kind            string `json:"kind,omitempty"`
+	Namespace       string `json:"namespace,omitempty"`
+	Name            string `json:"name"`
+	UID             string `json:"uid,omitempty"`
+	ResourceVersion string `json:"resourceVersion,omitempty"`
+}
+
+// Reference returns the reference of the Kubernetes object, or nil when
+// obj is not one. The kind and API version are the ones of the object, or
+// the ones its type is registered with when the informers left them unset.
+func Reference(obj interface{}) *ObjectReference {
+	o, err := meta.Accessor(obj)
+	if err != nil {
+		return nil
+	}
+	ref := &ObjectReference{
+		Namespace:       o.GetNamespace(),
+		Name:            o.GetName(),
+		UID:             string(o.GetUID()),
+		ResourceVersion: o.GetResourceVersion(),
+	}
+	if ro, ok := obj.(runtime.Object); ok {
+		gvk := ro.GetObjectKind().GroupVersionKind()
+		if gvk.Kind == "" || gvk.Kind == "PartialObjectMetadata" {
+			if gvks, _, err := scheme.Scheme.ObjectKinds(ro); err == nil && len(gvks) > 0 {
+				gvk = gvks[0]
+			}
+		}
+		if gvk.Kind != "PartialObjectMetadata" {
+			ref.APIVersion, ref.Kind = gvk.GroupVersion().String(), gvk.Kind
+		}
+	}
+	return ref
+}
+
+// V1 serializes an event in the schema v1: the fields of the event except
+// the ones added with the schema v2, which it shadows.
+type V1 struct {
+	*Event
+	Operation omitted `json:"operation,omitempty"`
+	Ref       omitted `json:"ref,omitempty"`
+	Timestamp omitted `json:"timestamp,omitempty"`
+}
+
+// omitted is always nil, omitting the fields it shadows.
+type omitted *struct{}
+
+// V2 is an event in the schema v2, referencing its object in full.
+type V2 struct {
+	// SchemaVersion is always "v2".
+	SchemaVersion string `json:"schemaVersion"`
+	// ID identifies the event, when assigned.
+	ID string `json:"id,omitempty"`
+	// Timestamp is when the event occurred, or when it was serialized when
+	// unknown.
+	Timestamp time.Time `json:"timestamp"`
+	// Operation is "create", "update" or "delete" for the changes of objects.
+	Operation      string            `json:"operation,omitempty"`
+	Reason         string            `json:"reason"`
+	Object         ObjectReference   `json:"object"`
+	Cluster        string            `json:"cluster,omitempty"`
+	Severity       string            `json:"severity,omitempty"`
+	Status         string            `json:"status,omitempty"`
+	Component      string            `json:"component,omitempty"`
+	Host           string            `json:"host,omitempty"`
+	Message        string            `json:"message"`
+	Details        string            `json:"details,omitempty"`
+	Labels         map[string]string `json:"labels,omitempty"`
+	ExternalLabels map[string]string `json:"externalLabels,omitempty"`
+	Metadata       map[string]string `json:"metadata,omitempty"`
+	Diff           []Change          `json:"diff,omitempty"`
+	Images         []ImageChange     `json:"images,omitempty"`
+}
+
+// V2 returns the event in the schema v2. Without a reference, its object
+// is identified by the kind, namespace and name of the event.
+func (e *Event) V2() V2 {
+	object := ObjectReference{Kind: e.Kind, Namespace: e.Namespace, Name: e.Name}
+	if e.Ref != nil {
+		object = *e.Ref
+	}
+	timestamp := e.Timestamp
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+	return V2{
+		SchemaVersion:  PayloadV2,
+		ID:             e.ID,
+		Timestamp:      timestamp,
+		Operation:      e.Operation,
+		Reason:         e.Reason,
+		Object:         object,
+		Cluster:        e.Cluster,
+		Severity:       e.Severity,
+		Status:         e.Status,
+		Component:      e.Component,
+		Host:           e.Host,
+		Message:        e.Message(),
+		Details:        e.Details,
+		Labels:         e.Labels,
+		ExternalLabels: e.ExternalLabels,
+		Metadata:       e.Metadata,
+		Diff:           e.Diff,
+		Images:         e.Images,
+	}
+}
+
+// Payload returns the event to serialize in the payloads of the schema
+// version, v1 unless it is v2.
+func (e *Event) Payload(version string) interface{} {
+	if version == PayloadV2 {
+		return e.V2()
+	}
+	return V1{Event: e}
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package event
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	apps_v1 "k8s.io/api/apps/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestReference(t *testing.T) {
+	d := &apps_v1.Deployment{ObjectMeta: meta_v1.ObjectMeta{
+		Namespace:       "prod",
+		Name:            "app",
+		UID:             "1234",
+		ResourceVersion: "42",
+	}}
+	want := &ObjectReference{
+		APIVersion:      "apps/v1",
+		Kind:            "Deployment",
+		Namespace:       "prod",
+		Name:            "app",
+		UID:             "1234",
+		ResourceVersion: "42",
+	}
+	if got := Reference(d); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Reference(): got %+v, want %+v", got, want)
+	}
+	if got := Reference(nil); got != nil {
+		t.Fatalf("Reference(nil): got %+v", got)
+	}
+}
+
+func TestPayload(t *testing.T) {
+	e := Event{
+		Namespace: "prod",
+		Kind:      "deployment",
+		Name:      "app",
+		Reason:    "Deleted",
+		Operation: "delete",
+		Ref:       &ObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "prod", Name: "app", UID: "1234"},
+		Timestamp: time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	v1, err := json.Marshal(e.Payload(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(v1, &fields); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"operation", "ref", "timestamp"} {
+		if _, ok := fields[key]; ok {
+			t.Errorf("v1: unexpected %s in %s", key, v1)
+		}
+	}
+	if fields["name"] != "app" || fields["reason"] != "Deleted" {
+		t.Errorf("v1: got %s", v1)
+	}
+
+	v2, ok := e.Payload(PayloadV2).(V2)
+	if !ok {
+		t.Fatalf("v2: got %T", e.Payload(PayloadV2))
+	}
+	if v2.SchemaVersion != "v2" || v2.Operation != "delete" || v2.Object != *e.Ref || !v2.Timestamp.Equal(e.Timestamp) || v2.Message != e.Message() {
+		t.Errorf("v2: got %+v", v2)
+	}
+
+	e.Ref = nil
+	if got, want := e.V2().Object, (ObjectReference{Kind: "deployment", Namespace: "prod", Name: "app"}); got != want {
+		t.Errorf("v2 without reference: got %+v, want %+v", got, want)
+	}
+}
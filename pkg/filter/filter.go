@@ -0,0 +1,256 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package filter decides whether an event matches a configured filter.
+package filter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/checker/decls"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/ext"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/proto"
+)
+
+// eventTypes maps the event types used in filters to event reasons.
+var eventTypes = map[string]string{
+	"create": "Created",
+	"update": "Updated",
+	"delete": "Deleted",
+}
+
+// programs caches the compiled expressions of the filters by source.
+var programs sync.Map
+
+// env is the CEL environment of the expressions, declaring their
+// variables with dynamic types and the string extensions, e.g. lowerAscii().
+var env, envErr = cel.NewEnv(
+	ext.Strings(),
+	cel.Declarations(
+		decls.NewVar("event", decls.Dyn),
+		decls.NewVar("object", decls.Dyn),
+		decls.NewVar("oldObject", decls.Dyn),
+	),
+)
+
+// Validate returns an error when the filter uses an unknown event type or
+// an invalid expression.
+func Validate(f config.Filter) error {
+	for _, t := range f.Types {
+		if _, ok := eventTypes[strings.ToLower(t)]; !ok {
+			return fmt.Errorf("invalid event type %q, must be one of create, update or delete", t)
+		}
+	}
+	if f.Expression != "" {
+		if _, err := program(f.Expression); err != nil {
+			return fmt.Errorf("invalid expression %q: %v", f.Expression, err)
+		}
+	}
+	return nil
+}
+
+// Match reports whether the event satisfies every non-empty criterion of the filter.
+func Match(f config.Filter, e event.Event) bool {
+	return matchAny(f.Namespaces, e.Namespace) &&
+		matchAny(f.Kinds, e.Kind) &&
+		matchAny(f.Reasons, e.Reason) &&
+		matchTypes(f.Types, e.Reason) &&
+		matchLabels(f.Labels, e.Labels) &&
+		matchAny(f.Severities, e.Severity) &&
+		matchExpression(f.Expression, e)
+}
+
+// MatchKubeEvent reports whether a Kubernetes Event with the given reason and
+// type (Warning or Normal) is selected by the events configuration.
+func MatchKubeEvent(f config.Events, reason, eventType string) bool {
+	return matchAny(f.Reasons, reason) && matchAny(f.Types, eventType)
+}
+
+func matchTypes(types []string, reason string) bool {
+	if len(types) == 0 {
+		return true
+	}
+	for _, t := range types {
+		if strings.EqualFold(eventTypes[strings.ToLower(t)], reason) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchExpression reports whether the event satisfies the expression, the
+// ones failing to compile or evaluate matching no event.
+func matchExpression(src string, e event.Event) bool {
+	if src == "" {
+		return true
+	}
+	p, err := program(src)
+	if err != nil {
+		return false
+	}
+	v, _, err := p.Eval(vars(e))
+	if err != nil {
+		logrus.Debugf("Expression %q not matching %s %s: %v", src, e.Kind, e.Name, err)
+		return false
+	}
+	ok, isBool := v.(types.Bool)
+	if !isBool {
+		logrus.Debugf("Expression %q not matching %s %s: returned %s, not a bool", src, e.Kind, e.Name, v.Type().TypeName())
+		return false
+	}
+	return bool(ok)
+}
+
+// program returns the compiled expression of the source, which must return
+// a bool.
+func program(src string) (cel.Program, error) {
+	if p, ok := programs.Load(src); ok {
+		return p.(cel.Program), nil
+	}
+	if envErr != nil {
+		return nil, envErr
+	}
+	ast, issues := env.Compile(src)
+	if issues != nil && issues.Err() != nil {
+		return nil, issues.Err()
+	}
+	if t := ast.ResultType(); !proto.Equal(t, decls.Bool) && !proto.Equal(t, decls.Dyn) {
+		return nil, fmt.Errorf("expression returns %s, not a bool", cel.FormatType(t))
+	}
+	p, err := env.Program(ast)
+	if err != nil {
+		return nil, err
+	}
+	programs.Store(src, p)
+	return p, nil
+}
+
+// vars returns the variables of the expressions for the event: the event,
+// with its operation as type, and its objects, converted when used.
+func vars(e event.Event) map[string]interface{} {
+	return map[string]interface{}{
+		"event": map[string]interface{}{
+			"cluster":        e.Cluster,
+			"namespace":      e.Namespace,
+			"kind":           e.Kind,
+			"name":           e.Name,
+			"reason":         e.Reason,
+			"status":         e.Status,
+			"type":           e.Operation,
+			"severity":       e.Severity,
+			"details":        e.Details,
+			"component":      e.Component,
+			"host":           e.Host,
+			"labels":         value(e.Labels),
+			"externalLabels": value(e.ExternalLabels),
+			"metadata":       value(e.Metadata),
+		},
+		"object":    lazyValue(e.Object),
+		"oldObject": lazyValue(e.OldObject),
+	}
+}
+
+// lazyValue returns the function converting the object once, when called.
+func lazyValue(obj interface{}) func() interface{} {
+	var once sync.Once
+	var v interface{}
+	return func() interface{} {
+		once.Do(func() {
+			v = value(obj)
+		})
+		return v
+	}
+}
+
+// value returns v as a value of the expressions: the numbers as int64 or
+// float64, the slices as []interface{}, the maps as map[string]interface{}
+// and the structs, e.g. Kubernetes objects, as their JSON encoding decoded.
+func value(v interface{}) interface{} {
+	switch v := v.(type) {
+	case nil, bool, int64, float64, string:
+		return v
+	case map[string]string:
+		m := make(map[string]interface{}, len(v))
+		for k, s := range v {
+			m[k] = s
+		}
+		return m
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			m[k] = value(e)
+		}
+		return m
+	case []interface{}:
+		l := make([]interface{}, len(v))
+		for i, e := range v {
+			l[i] = value(e)
+		}
+		return l
+	}
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr && rv.IsNil() {
+		return nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	var decoded interface{}
+	if err := d.Decode(&decoded); err != nil {
+		return nil
+	}
+	return value(decoded)
+}
+
+func matchLabels(selector, labels map[string]string) bool {
+	for k, v := range selector {
+		if got, ok := labels[k]; !ok || got != v {
+			return false
+		}
+	}
+	return true
+}
+
+// matchAny reports whether value is in list, ignoring case. An empty list matches any value.
+func matchAny(list []string, value string) bool {
+	if len(list) == 0 {
+		return true
+	}
+	for _, v := range list {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filter
+
+import (
+	"testing"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+
+	apps_v1 "k8s.io/api/apps/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var replicas int32 = 3
+
+func TestMatch(t *testing.T) {
+	e := event.Event{Namespace: "prod", Kind: "deployment", Reason: "Updated", Labels: map[string]string{"team": "payments"}, Severity: "warning"}
+
+	var Tests = []struct {
+		filter config.Filter
+		want   bool
+	}{
+		{config.Filter{}, true},
+		{config.Filter{Namespaces: []string{"staging", "prod"}}, true},
+		{config.Filter{Namespaces: []string{"staging"}}, false},
+		{config.Filter{Kinds: []string{"Deployment"}, Reasons: []string{"updated"}}, true},
+		{config.Filter{Kinds: []string{"deployment"}, Reasons: []string{"Deleted"}}, false},
+		{config.Filter{Types: []string{"create", "update"}}, true},
+		{config.Filter{Types: []string{"delete"}}, false},
+		{config.Filter{Labels: map[string]string{"team": "payments"}}, true},
+		{config.Filter{Labels: map[string]string{"team": "search"}}, false},
+		{config.Filter{Labels: map[string]string{"tier": "frontend"}}, false},
+		{config.Filter{Severities: []string{"critical", "warning"}}, true},
+		{config.Filter{Severities: []string{"critical"}}, false},
+	}
+
+	for _, tt := range Tests {
+		if got := Match(tt.filter, e); got != tt.want {
+			t.Fatalf("Match(%+v): got %v, want %v", tt.filter, got, tt.want)
+		}
+	}
+}
+
+func TestMatchKubeEvent(t *testing.T) {
+	var Tests = []struct {
+		events config.Events
+		want   bool
+	}{
+		{config.Events{}, true},
+		{config.Events{Reasons: []string{"OOMKilling", "FailedScheduling"}}, true},
+		{config.Events{Reasons: []string{"BackOff"}}, false},
+		{config.Events{Types: []string{"warning"}}, true},
+		{config.Events{Types: []string{"Normal"}}, false},
+	}
+
+	for _, tt := range Tests {
+		if got := MatchKubeEvent(tt.events, "FailedScheduling", "Warning"); got != tt.want {
+			t.Fatalf("MatchKubeEvent(%+v): got %v, want %v", tt.events, got, tt.want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := Validate(config.Filter{Types: []string{"create", "Delete"}}); err != nil {
+		t.Fatalf("Validate(): unexpected error %v", err)
+	}
+	if err := Validate(config.Filter{Types: []string{"created"}}); err == nil {
+		t.Fatalf("Validate(): expected an error for an unknown event type")
+	}
+	if err := Validate(config.Filter{Expression: "event.type == 'create"}); err == nil {
+		t.Fatalf("Validate(): expected an error for an invalid expression")
+	}
+	for _, src := range []string{"evnt.type == 'create'", "event.name.unknown()", "1 + 2", "'a' + 'b'"} {
+		if err := Validate(config.Filter{Expression: src}); err == nil {
+			t.Fatalf("Validate(%s): expected an error", src)
+		}
+	}
+}
+
+func TestMatchExpression(t *testing.T) {
+	e := event.Event{
+		Namespace: "shop",
+		Kind:      "deployment",
+		Reason:    "Updated",
+		Operation: "update",
+		Labels:    map[string]string{"tier": "frontend"},
+		Object: &apps_v1.Deployment{
+			ObjectMeta: meta_v1.ObjectMeta{Name: "web", Labels: map[string]string{"tier": "frontend"}},
+			Spec:       apps_v1.DeploymentSpec{Replicas: &replicas},
+		},
+	}
+
+	var Tests = []struct {
+		expression string
+		want       bool
+	}{
+		{"object.metadata.labels['tier'] == 'frontend' && event.type != 'update'", false},
+		{"object.metadata.labels['tier'] == 'frontend' && event.type == 'update'", true},
+		{"object.spec.replicas > 2 && event.labels.tier == 'frontend'", true},
+		{"object.metadata.labels['team'] == 'payments'", false},
+		{"oldObject.spec.replicas < 2", false},
+		{"has(object.metadata.annotations) && object.metadata.annotations['team'] == 'payments'", false},
+		{"event.kind.upperAscii() == 'DEPLOYMENT' && event.namespace.startsWith('sh')", true},
+		{"object.metadata.name.matches('^w.b$') && 'tier' in event.labels", true},
+		{"event.labels.exists(k, event.labels[k] == 'frontend') && size(event.labels) == 1", true},
+		{"event.type in ['create', 'delete']", false},
+		{"object.metadata.annotations.team == 'payments' || true", true},
+		{"event.namespace", false},
+	}
+
+	for _, tt := range Tests {
+		if got := Match(config.Filter{Expression: tt.expression}, e); got != tt.want {
+			t.Fatalf("Match(%s): got %v, want %v", tt.expression, got, tt.want)
+		}
+	}
+}
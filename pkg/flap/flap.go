@@ -0,0 +1,127 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package flap detects the objects flapping, changing repeatedly within a
+// time window, and their return to stability.
+package flap
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Observation is the state of an object after one of its events.
+type Observation struct {
+	// Flapping is set while the events of the object are suppressed.
+	Flapping bool
+	// Started is set for the event making the object flapping.
+	Started bool
+	// Count is the number of events of the object within the window.
+	Count int
+}
+
+// Stabilized is an object which stopped flapping.
+type Stabilized struct {
+	Key string
+	// Suppressed is the number of events suppressed while it was flapping.
+	Suppressed int
+	// Since is when it started flapping.
+	Since time.Time
+}
+
+// object is the recent history of the events of an object.
+type object struct {
+	times      []time.Time
+	flapping   bool
+	since      time.Time
+	suppressed int
+}
+
+// Detector tracks the events of the objects by key, the objects having
+// threshold events within the window being flapping until they have none
+// for stableAfter.
+type Detector struct {
+	threshold   int
+	window      time.Duration
+	stableAfter time.Duration
+
+	mu      sync.Mutex
+	objects map[string]*object
+}
+
+// New returns a detector of the objects having threshold events within the
+// window, stable again after stableAfter without events.
+func New(threshold int, window, stableAfter time.Duration) *Detector {
+	return &Detector{threshold: threshold, window: window, stableAfter: stableAfter, objects: map[string]*object{}}
+}
+
+// Observe records an event of the object of the key at now.
+func (d *Detector) Observe(key string, now time.Time) Observation {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	o, ok := d.objects[key]
+	if !ok {
+		o = &object{}
+		d.objects[key] = o
+	}
+	o.times = append(prune(o.times, now.Add(-d.window)), now)
+	if o.flapping {
+		o.suppressed++
+		return Observation{Flapping: true, Count: len(o.times)}
+	}
+	if len(o.times) < d.threshold {
+		return Observation{Count: len(o.times)}
+	}
+	o.flapping = true
+	o.since = now
+	o.suppressed = 1
+	return Observation{Flapping: true, Started: true, Count: len(o.times)}
+}
+
+// Stable returns the flapping objects without events for stableAfter at
+// now, which are no longer flapping, and forgets the objects without events
+// within the window.
+func (d *Detector) Stable(now time.Time) []Stabilized {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var stable []Stabilized
+	for key, o := range d.objects {
+		last := o.times[len(o.times)-1]
+		switch {
+		case o.flapping && now.Sub(last) >= d.stableAfter:
+			stable = append(stable, Stabilized{Key: key, Suppressed: o.suppressed, Since: o.since})
+			delete(d.objects, key)
+		case !o.flapping && now.Sub(last) >= d.window:
+			delete(d.objects, key)
+		}
+	}
+	sort.Slice(stable, func(i, j int) bool {
+		return stable[i].Key < stable[j].Key
+	})
+	return stable
+}
+
+// prune drops the times before start.
+func prune(times []time.Time, start time.Time) []time.Time {
+	i := 0
+	for i < len(times) && times[i].Before(start) {
+		i++
+	}
+	return append(times[:0], times[i:]...)
+}
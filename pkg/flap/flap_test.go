@@ -0,0 +1,68 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flap
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDetector(t *testing.T) {
+	d := New(3, 10*time.Minute, 5*time.Minute)
+	start := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time {
+		return start.Add(time.Duration(minutes) * time.Minute)
+	}
+
+	var Tests = []struct {
+		key     string
+		minutes int
+		want    Observation
+	}{
+		{"pod/web", 0, Observation{Count: 1}},
+		{"pod/web", 1, Observation{Count: 2}},
+		{"pod/api", 1, Observation{Count: 1}},
+		// the first event is out of the window
+		{"pod/web", 11, Observation{Count: 2}},
+		{"pod/web", 11, Observation{Flapping: true, Started: true, Count: 3}},
+		{"pod/web", 13, Observation{Flapping: true, Count: 3}},
+		{"pod/web", 14, Observation{Flapping: true, Count: 4}},
+	}
+
+	for _, tt := range Tests {
+		if got := d.Observe(tt.key, at(tt.minutes)); got != tt.want {
+			t.Fatalf("Observe(%s, %d): got %+v, want %+v", tt.key, tt.minutes, got, tt.want)
+		}
+	}
+
+	if got := d.Stable(at(18)); len(got) != 0 {
+		t.Fatalf("Stable() before stableAfter: got %+v", got)
+	}
+	want := []Stabilized{{Key: "pod/web", Suppressed: 3, Since: at(11)}}
+	if got := d.Stable(at(19)); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Stable(): got %+v, want %+v", got, want)
+	}
+	if len(d.objects) != 0 {
+		t.Fatalf("objects not forgotten: %v", d.objects)
+	}
+
+	// stable again, the object starts over
+	if got := d.Observe("pod/web", at(20)); got != (Observation{Count: 1}) {
+		t.Fatalf("Observe() after stabilizing: got %+v", got)
+	}
+}
@@ -0,0 +1,271 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package alertmanager
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+	"github.com/bitnami-labs/kubewatch/pkg/metrics"
+	"github.com/bitnami-labs/kubewatch/pkg/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// logger logs the notifications sent by the handler.
+var logger = logrus.WithField("handler", "alertmanager")
+
+const (
+	alertsPath       = "/api/v2/alerts"
+	defaultAlertName = "KubewatchEvent"
+	defaultDuration  = time.Hour
+	requestTimeout   = 10 * time.Second
+)
+
+var alertmanagerErrMsg = `
+%s
+
+You need to set the Alertmanager url,
+using "--url/-u", or using environment variables:
+
+export KW_ALERTMANAGER_URL=http://alertmanager.monitoring:9093
+
+Command line flags will override environment variables
+
+`
+
+// Alertmanager handler implements handler.Handler interface,
+// Notify event as Alertmanager alerts
+type Alertmanager struct {
+	Url       string
+	Username  string
+	Password  string
+	Token     string
+	AlertName string
+	Labels    map[string]string
+	Duration  time.Duration
+
+	client *http.Client
+
+	// created holds the labels of the firing alerts of the created objects,
+	// resolved when they are deleted
+	mu      sync.Mutex
+	created map[string]map[string]string
+}
+
+// Alert is an alert of the Alertmanager API
+// The Documentation is in https://github.com/prometheus/alertmanager/blob/main/api/v2/openapi.yaml
+type Alert struct {
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	StartsAt    string            `json:"startsAt,omitempty"`
+	EndsAt      string            `json:"endsAt,omitempty"`
+}
+
+// Init prepares Alertmanager configuration
+func (a *Alertmanager) Init(c *config.Config) error {
+	conf := c.Handler.Alertmanager
+	url := conf.Url
+
+	if url == "" {
+		url = os.Getenv("KW_ALERTMANAGER_URL")
+	}
+
+	a.Url = strings.TrimSuffix(url, "/")
+	a.Username = conf.BasicAuth.Username
+	a.Password = conf.BasicAuth.Password
+	a.Token = conf.Token
+	a.Labels = conf.Labels
+	a.created = map[string]map[string]string{}
+
+	a.AlertName = conf.AlertName
+	if a.AlertName == "" {
+		a.AlertName = defaultAlertName
+	}
+
+	a.Duration = defaultDuration
+	if conf.Duration != "" {
+		d, err := time.ParseDuration(conf.Duration)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("invalid alertmanager duration %q", conf.Duration)
+		}
+		a.Duration = d
+	}
+
+	client, err := utils.HTTPClient("alertmanager", conf.TLS)
+	if err != nil {
+		return err
+	}
+	client.Timeout = requestTimeout
+	a.client = client
+
+	return checkMissingAlertmanagerVars(a)
+}
+
+// Handle handles an event.
+func (a *Alertmanager) Handle(e event.Event) {
+	if err := a.Send(e); err != nil {
+		logger.WithFields(e.LogFields()).Error(err)
+	}
+}
+
+// Send fires an alert for the event, resolving the alert of the creation of
+// a deleted object, returning an error when Alertmanager doesn't accept them.
+func (a *Alertmanager) Send(e event.Event) error {
+	now := time.Now().UTC()
+	alert := a.prepareAlert(e, now)
+	alerts := []Alert{alert}
+
+	key := objectKey(e)
+	var resolved map[string]string
+	if e.Reason == "Deleted" {
+		a.mu.Lock()
+		resolved = a.created[key]
+		a.mu.Unlock()
+		if resolved == nil {
+			// the creation was sent before a restart, with the default severity of creations
+			created := e
+			created.Reason, created.Severity = "Created", "info"
+			resolved = a.labels(created)
+		}
+		alerts = append(alerts, Alert{Labels: resolved, EndsAt: now.Format(time.RFC3339)})
+	}
+
+	if err := a.post(alerts); err != nil {
+		metrics.Notifications.WithLabelValues("alertmanager", "failure").Inc()
+		return err
+	}
+
+	a.mu.Lock()
+	switch e.Reason {
+	case "Created":
+		a.created[key] = alert.Labels
+	case "Deleted":
+		delete(a.created, key)
+	}
+	a.mu.Unlock()
+
+	metrics.Notifications.WithLabelValues("alertmanager", "success").Inc()
+	logger.WithFields(e.LogFields()).Info("Alert successfully sent to Alertmanager")
+	return nil
+}
+
+func checkMissingAlertmanagerVars(a *Alertmanager) error {
+	if a.Url == "" {
+		return fmt.Errorf(alertmanagerErrMsg, "Missing Alertmanager url")
+	}
+
+	return nil
+}
+
+// objectKey identifies the object of the event.
+func objectKey(e event.Event) string {
+	key := strings.Join([]string{e.Kind, e.Namespace, e.Name}, "/")
+	if e.Cluster != "" {
+		key = e.Cluster + "/" + key
+	}
+	return key
+}
+
+// labels returns the labels identifying the alert of the event: its name,
+// the object, the reason and the severity, with the external labels of the
+// events and the labels of the config. Empty labels are left out.
+func (a *Alertmanager) labels(e event.Event) map[string]string {
+	labels := map[string]string{}
+	for k, v := range e.ExternalLabels {
+		labels[k] = v
+	}
+	for k, v := range a.Labels {
+		labels[k] = v
+	}
+	for k, v := range map[string]string{
+		"alertname": a.AlertName,
+		"cluster":   e.Cluster,
+		"namespace": e.Namespace,
+		"kind":      e.Kind,
+		"name":      e.Name,
+		"reason":    e.Reason,
+		"severity":  e.Severity,
+	} {
+		if v != "" {
+			labels[k] = v
+		}
+	}
+	return labels
+}
+
+// invalidNameChars are the characters not allowed in annotation names.
+var invalidNameChars = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+// prepareAlert returns the alert of the event, firing for the duration.
+func (a *Alertmanager) prepareAlert(e event.Event, now time.Time) Alert {
+	annotations := map[string]string{"summary": e.Message()}
+	// annotation names follow the rules of label names, e.g. team or
+	// runbook_example_com_url
+	for k, v := range e.Metadata {
+		annotations[invalidNameChars.ReplaceAllString(k, "_")] = v
+	}
+	if e.Details != "" {
+		annotations["description"] = e.Details
+	}
+	return Alert{
+		Labels:      a.labels(e),
+		Annotations: annotations,
+		StartsAt:    now.Format(time.RFC3339),
+		EndsAt:      now.Add(a.Duration).Format(time.RFC3339),
+	}
+}
+
+func (a *Alertmanager) post(alerts []Alert) error {
+	message, err := json.Marshal(alerts)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", a.Url+alertsPath, bytes.NewBuffer(message))
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Content-Type", "application/json")
+	if a.Username != "" {
+		req.SetBasicAuth(a.Username, a.Password)
+	} else if a.Token != "" {
+		req.Header.Add("Authorization", "Bearer "+a.Token)
+	}
+
+	res, err := a.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode/100 != 2 {
+		body, _ := ioutil.ReadAll(res.Body)
+		return fmt.Errorf("Failed sending alerts to Alertmanager. Alertmanager http response: %s, %s", res.Status, string(body))
+	}
+
+	return nil
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package alertmanager
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+)
+
+func TestAlertmanagerInit(t *testing.T) {
+	a := &Alertmanager{}
+	expectedError := fmt.Errorf(alertmanagerErrMsg, "Missing Alertmanager url")
+
+	var Tests = []struct {
+		alertmanager config.Alertmanager
+		err          error
+	}{
+		{config.Alertmanager{Url: "http://alertmanager:9093"}, nil},
+		{config.Alertmanager{}, expectedError},
+		{config.Alertmanager{Url: "http://alertmanager:9093", Duration: "forever"}, fmt.Errorf("invalid alertmanager duration %q", "forever")},
+	}
+
+	for _, tt := range Tests {
+		c := &config.Config{}
+		c.Handler.Alertmanager = tt.alertmanager
+		if err := a.Init(c); !reflect.DeepEqual(err, tt.err) {
+			t.Fatalf("Init(): %v", err)
+		}
+	}
+}
+
+func TestAlertmanagerSend(t *testing.T) {
+	var posts [][]Alert
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != alertsPath {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+			t.Errorf("unexpected authorization %q", got)
+		}
+		var alerts []Alert
+		if err := json.NewDecoder(r.Body).Decode(&alerts); err != nil {
+			t.Error(err)
+		}
+		posts = append(posts, alerts)
+	}))
+	defer ts.Close()
+
+	c := &config.Config{}
+	c.Handler.Alertmanager = config.Alertmanager{Url: ts.URL, Token: "secret", Labels: map[string]string{"team": "platform"}, Duration: "2h"}
+	a := &Alertmanager{}
+	if err := a.Init(c); err != nil {
+		t.Fatal(err)
+	}
+
+	created := event.Event{Cluster: "prod", Namespace: "shop", Kind: "deployment", Name: "api", Reason: "Created", Severity: "warning"}
+	if err := a.Send(created); err != nil {
+		t.Fatal(err)
+	}
+	labels := map[string]string{
+		"alertname": "KubewatchEvent",
+		"cluster":   "prod",
+		"namespace": "shop",
+		"kind":      "deployment",
+		"name":      "api",
+		"reason":    "Created",
+		"severity":  "warning",
+		"team":      "platform",
+	}
+	if len(posts) != 1 || len(posts[0]) != 1 || !reflect.DeepEqual(posts[0][0].Labels, labels) {
+		t.Fatalf("got alerts %+v, want labels %v", posts, labels)
+	}
+	startsAt, _ := time.Parse(time.RFC3339, posts[0][0].StartsAt)
+	endsAt, _ := time.Parse(time.RFC3339, posts[0][0].EndsAt)
+	if endsAt.Sub(startsAt) != 2*time.Hour {
+		t.Fatalf("got alert from %s to %s, want 2h", posts[0][0].StartsAt, posts[0][0].EndsAt)
+	}
+
+	// the deletion resolves the alert of the creation, with its severity
+	deleted := created
+	deleted.Reason, deleted.Severity = "Deleted", "critical"
+	if err := a.Send(deleted); err != nil {
+		t.Fatal(err)
+	}
+	if len(posts) != 2 || len(posts[1]) != 2 {
+		t.Fatalf("got alerts %+v, want the deletion and the resolved creation", posts)
+	}
+	if posts[1][0].Labels["reason"] != "Deleted" || !reflect.DeepEqual(posts[1][1].Labels, labels) {
+		t.Fatalf("got alerts %+v, want the deletion and the resolved creation", posts[1])
+	}
+	if endsAt, _ := time.Parse(time.RFC3339, posts[1][1].EndsAt); time.Since(endsAt) > time.Minute {
+		t.Fatalf("the creation is resolved at %s", posts[1][1].EndsAt)
+	}
+}
@@ -0,0 +1,297 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package archive implements a handler archiving events to object storage:
+Amazon S3, Google Cloud Storage or Azure Blob Storage.
+
+The events are written as gzip compressed NDJSON files, one per cluster and
+period, uploaded at the end of the period.
+*/
+package archive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+	"github.com/bitnami-labs/kubewatch/pkg/metrics"
+	"github.com/sirupsen/logrus"
+)
+
+// logger logs the notifications sent by the handler.
+var logger = logrus.WithField("handler", "archive")
+
+const (
+	defaultPrefix    = "kubewatch/{year}/{month}/{day}/"
+	defaultInterval  = time.Hour
+	defaultMaxEvents = 100000
+	// maxPending is the number of files kept for a retry when their upload
+	// fails, the oldest being dropped beyond it.
+	maxPending    = 24
+	uploadTimeout = time.Minute
+)
+
+var archiveErrMsg = `
+%s
+
+You need to set the storage provider and bucket,
+using "--provider/-p" and "--bucket/-b", or using environment variables:
+
+export KW_ARCHIVE_PROVIDER=s3
+export KW_ARCHIVE_BUCKET=audit-bucket
+
+Command line flags will override environment variables
+
+`
+
+// uploader uploads the files to a storage service.
+type uploader interface {
+	upload(ctx context.Context, key string, data []byte) error
+}
+
+// Archive handler implements handler.Handler interface,
+// Upload the events as compressed NDJSON files to object storage
+type Archive struct {
+	Provider       string
+	Bucket         string
+	Prefix         string
+	Interval       time.Duration
+	MaxEvents      int
+	PayloadVersion string
+
+	uploader uploader
+	host     string
+
+	// mu guards the batches of the current period, by cluster, and the
+	// files whose upload failed.
+	mu      sync.Mutex
+	batches map[string]*batch
+	pending []file
+}
+
+// batch is a file being written.
+type batch struct {
+	cluster string
+	start   time.Time
+	events  int
+	buf     bytes.Buffer
+	gz      *gzip.Writer
+}
+
+// file is a file to upload.
+type file struct {
+	key    string
+	data   []byte
+	events int
+}
+
+// Init prepares Archive configuration
+func (a *Archive) Init(c *config.Config) error {
+	a.PayloadVersion = c.PayloadVersion
+	conf := c.Handler.Archive
+	provider := conf.Provider
+	bucket := conf.Bucket
+
+	if provider == "" {
+		provider = os.Getenv("KW_ARCHIVE_PROVIDER")
+	}
+
+	if bucket == "" {
+		bucket = os.Getenv("KW_ARCHIVE_BUCKET")
+	}
+
+	prefix := conf.Prefix
+	if prefix == "" {
+		prefix = defaultPrefix
+	}
+
+	a.Provider = provider
+	a.Bucket = bucket
+	a.Prefix = prefix
+
+	if err := checkMissingArchiveVars(a); err != nil {
+		return err
+	}
+
+	a.Interval = defaultInterval
+	if conf.Interval != "" {
+		interval, err := time.ParseDuration(conf.Interval)
+		if err != nil || interval <= 0 {
+			return fmt.Errorf("archive: invalid interval %q, must be a positive duration", conf.Interval)
+		}
+		a.Interval = interval
+	}
+	a.MaxEvents = conf.MaxEvents
+	if a.MaxEvents <= 0 {
+		a.MaxEvents = defaultMaxEvents
+	}
+
+	var err error
+	switch provider {
+	case "s3":
+		a.uploader, err = newS3(conf)
+	case "gcs":
+		a.uploader, err = newGCS(conf)
+	case "azure":
+		a.uploader, err = newAzure(conf)
+	default:
+		return fmt.Errorf("archive: unsupported provider %q, must be s3, gcs or azure", provider)
+	}
+	if err != nil {
+		return err
+	}
+
+	// the files of the replicas and restarts are told apart by the host
+	a.host, _ = os.Hostname()
+	if a.host == "" {
+		a.host = "kubewatch"
+	}
+	a.batches = map[string]*batch{}
+	return nil
+}
+
+// Handle adds the event to the file of its cluster, uploading it once it
+// holds MaxEvents.
+func (a *Archive) Handle(e event.Event) {
+	data, err := json.Marshal(e.Payload(a.PayloadVersion))
+	if err != nil {
+		logger.WithFields(e.LogFields()).Error(err)
+		return
+	}
+
+	a.mu.Lock()
+	b := a.batches[e.Cluster]
+	if b == nil {
+		b = &batch{cluster: e.Cluster, start: time.Now().UTC()}
+		b.gz = gzip.NewWriter(&b.buf)
+		a.batches[e.Cluster] = b
+	}
+	b.gz.Write(append(data, '\n'))
+	b.events++
+	var full []file
+	if b.events >= a.MaxEvents {
+		delete(a.batches, e.Cluster)
+		full = []file{a.close(b)}
+	}
+	a.mu.Unlock()
+
+	logger.WithFields(e.LogFields()).Debug("Event added to archive file")
+	if full != nil {
+		a.upload(full)
+	}
+}
+
+// Run uploads the files at the end of each period, and the last ones once
+// stopCh is closed.
+func (a *Archive) Run(stopCh <-chan struct{}) {
+	for {
+		now := time.Now()
+		timer := time.NewTimer(now.Truncate(a.Interval).Add(a.Interval).Sub(now))
+		select {
+		case <-stopCh:
+			timer.Stop()
+			a.flush()
+			return
+		case <-timer.C:
+			a.flush()
+		}
+	}
+}
+
+// flush uploads the files of the period, and the ones whose upload failed.
+func (a *Archive) flush() {
+	a.mu.Lock()
+	files := a.pending
+	a.pending = nil
+	for cluster, b := range a.batches {
+		files = append(files, a.close(b))
+		delete(a.batches, cluster)
+	}
+	a.mu.Unlock()
+
+	a.upload(files)
+}
+
+// upload uploads the files, keeping the failed ones for a retry.
+func (a *Archive) upload(files []file) {
+	var failed []file
+	for _, f := range files {
+		ctx, cancel := context.WithTimeout(context.Background(), uploadTimeout)
+		err := a.uploader.upload(ctx, f.key, f.data)
+		cancel()
+		if err != nil {
+			metrics.Notifications.WithLabelValues("archive", "failure").Inc()
+			logger.Errorf("Failed uploading %s to %s bucket %s, will retry: %v", f.key, a.Provider, a.Bucket, err)
+			failed = append(failed, f)
+			continue
+		}
+		metrics.Notifications.WithLabelValues("archive", "success").Inc()
+		logger.Infof("Archive of %d events successfully uploaded to %s bucket %s as %s", f.events, a.Provider, a.Bucket, f.key)
+	}
+	if len(failed) == 0 {
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.pending = append(a.pending, failed...)
+	if dropped := len(a.pending) - maxPending; dropped > 0 {
+		for _, f := range a.pending[:dropped] {
+			logger.Errorf("Dropping archive %s of %d events after failed uploads", f.key, f.events)
+		}
+		a.pending = a.pending[dropped:]
+	}
+}
+
+// close completes the file of the batch.
+func (a *Archive) close(b *batch) file {
+	b.gz.Close()
+	return file{key: a.key(b.cluster, b.start), data: b.buf.Bytes(), events: b.events}
+}
+
+// key returns the name of the file of the cluster started at the time: the
+// expanded prefix followed by the time and the host, e.g.
+// kubewatch/2020/06/01/20200601T120000Z-kubewatch-5d8f7.ndjson.gz.
+func (a *Archive) key(cluster string, start time.Time) string {
+	if cluster == "" {
+		cluster = "_"
+	}
+	prefix := strings.NewReplacer(
+		"{cluster}", cluster,
+		"{year}", start.Format("2006"),
+		"{month}", start.Format("01"),
+		"{day}", start.Format("02"),
+		"{hour}", start.Format("15"),
+	).Replace(a.Prefix)
+	return fmt.Sprintf("%s%s-%s.ndjson.gz", prefix, start.Format("20060102T150405Z"), a.host)
+}
+
+func checkMissingArchiveVars(a *Archive) error {
+	if a.Provider == "" || a.Bucket == "" {
+		return fmt.Errorf(archiveErrMsg, "Missing archive provider or bucket")
+	}
+
+	return nil
+}
@@ -0,0 +1,242 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+)
+
+func TestArchiveInit(t *testing.T) {
+	a := &Archive{}
+	expectedError := fmt.Errorf(archiveErrMsg, "Missing archive provider or bucket")
+
+	var Tests = []struct {
+		archive config.Archive
+		err     error
+	}{
+		{config.Archive{Provider: "s3", Bucket: "audit", Region: "eu-west-1"}, nil},
+		{config.Archive{Provider: "gcs", Bucket: "audit", Interval: "15m"}, nil},
+		{config.Archive{Provider: "azure", Bucket: "audit", Account: "kubewatch", AccountKey: "c2VjcmV0"}, nil},
+		{config.Archive{Provider: "azure", Bucket: "audit", Account: "kubewatch", SASToken: "?sv=2020-04-08&sig=x"}, nil},
+		{config.Archive{Provider: "azure", Bucket: "audit", AccountKey: "c2VjcmV0"}, errors.New("archive: missing azure storage account")},
+		{config.Archive{Provider: "azure", Bucket: "audit", Account: "kubewatch"}, errors.New("archive: missing azure storage account key or sas token")},
+		{config.Archive{Provider: "azure", Bucket: "audit", Account: "kubewatch", AccountKey: "secret!"}, errors.New("archive: invalid azure storage account key, must be base64 encoded")},
+		{config.Archive{Provider: "s3", Bucket: "audit", Interval: "-1h"}, fmt.Errorf("archive: invalid interval %q, must be a positive duration", "-1h")},
+		{config.Archive{Provider: "ftp", Bucket: "audit"}, fmt.Errorf("archive: unsupported provider %q, must be s3, gcs or azure", "ftp")},
+		{config.Archive{Bucket: "audit"}, expectedError},
+		{config.Archive{}, expectedError},
+	}
+
+	for _, tt := range Tests {
+		c := &config.Config{}
+		c.Handler.Archive = tt.archive
+		if err := a.Init(c); !reflect.DeepEqual(err, tt.err) {
+			t.Fatalf("Init(): %v", err)
+		}
+	}
+}
+
+// fakeUploader records the uploaded files, failing while err is set.
+type fakeUploader struct {
+	files map[string][]byte
+	err   error
+}
+
+func (u *fakeUploader) upload(ctx context.Context, key string, data []byte) error {
+	if u.err != nil {
+		return u.err
+	}
+	u.files[key] = data
+	return nil
+}
+
+// lines returns the lines of the compressed file.
+func lines(t *testing.T, data []byte) []string {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lines []string
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		lines = append(lines, s.Text())
+	}
+	return lines
+}
+
+func TestArchiveHandle(t *testing.T) {
+	u := &fakeUploader{files: map[string][]byte{}, err: errors.New("unavailable")}
+	a := &Archive{
+		Prefix:    "audit/{cluster}/{year}-{month}-{day}/{hour}/",
+		Interval:  time.Hour,
+		MaxEvents: 2,
+		uploader:  u,
+		host:      "kubewatch-0",
+		batches:   map[string]*batch{},
+	}
+
+	a.Handle(event.Event{Cluster: "prod", Kind: "pod", Name: "web-1", Reason: "Created"})
+	a.Handle(event.Event{Kind: "pod", Name: "web-2", Reason: "Created"})
+	// the full file of prod fails to upload, and is kept
+	a.Handle(event.Event{Cluster: "prod", Kind: "pod", Name: "web-3", Reason: "Deleted"})
+	if len(a.pending) != 1 || len(a.batches) != 1 {
+		t.Fatalf("got %d pending files and %d batches", len(a.pending), len(a.batches))
+	}
+
+	u.err = nil
+	a.flush()
+	if len(a.pending) != 0 || len(a.batches) != 0 || len(u.files) != 2 {
+		t.Fatalf("got %d pending files, %d batches and %d uploads", len(a.pending), len(a.batches), len(u.files))
+	}
+	for key, data := range u.files {
+		day := time.Now().UTC().Format("2006-01-02")
+		if !strings.HasSuffix(key, "-kubewatch-0.ndjson.gz") || !strings.Contains(key, "/"+day+"/") {
+			t.Fatalf("got key %q", key)
+		}
+		var names []string
+		for _, line := range lines(t, data) {
+			var e event.Event
+			if err := json.Unmarshal([]byte(line), &e); err != nil {
+				t.Fatal(err)
+			}
+			names = append(names, e.Name)
+		}
+		switch {
+		case strings.HasPrefix(key, "audit/prod/"):
+			if !reflect.DeepEqual(names, []string{"web-1", "web-3"}) {
+				t.Fatalf("got events %v in %s", names, key)
+			}
+		case strings.HasPrefix(key, "audit/_/"):
+			if !reflect.DeepEqual(names, []string{"web-2"}) {
+				t.Fatalf("got events %v in %s", names, key)
+			}
+		default:
+			t.Fatalf("got key %q", key)
+		}
+	}
+}
+
+func TestGCSUpload(t *testing.T) {
+	var got *http.Request
+	var body []byte
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		body, _ = ioutil.ReadAll(r.Body)
+	}))
+	defer ts.Close()
+
+	u := &gcsUploader{endpoint: ts.URL, bucket: "audit", client: http.DefaultClient}
+	if err := u.upload(context.Background(), "kubewatch/2020/06/01/a.ndjson.gz", []byte("data")); err != nil {
+		t.Fatal(err)
+	}
+	if got.Method != http.MethodPost || got.URL.Path != "/upload/storage/v1/b/audit/o" ||
+		got.URL.Query().Get("name") != "kubewatch/2020/06/01/a.ndjson.gz" || got.URL.Query().Get("uploadType") != "media" {
+		t.Fatalf("got request %s %s", got.Method, got.URL)
+	}
+	if got.Header.Get("Content-Type") != contentType || string(body) != "data" {
+		t.Fatalf("got content %s %q", got.Header.Get("Content-Type"), body)
+	}
+}
+
+func TestAzureUpload(t *testing.T) {
+	var got *http.Request
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer ts.Close()
+
+	conf := config.Archive{Bucket: "audit", Account: "kubewatch", AccountKey: "c2VjcmV0", Endpoint: ts.URL}
+	u, err := newAzure(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := u.upload(context.Background(), "kubewatch/2020/a b.ndjson.gz", []byte("data")); err != nil {
+		t.Fatal(err)
+	}
+	if got.Method != http.MethodPut || got.URL.EscapedPath() != "/audit/kubewatch/2020/a%20b.ndjson.gz" ||
+		got.Header.Get("x-ms-blob-type") != "BlockBlob" || !strings.HasPrefix(got.Header.Get("Authorization"), "SharedKey kubewatch:") {
+		t.Fatalf("got request %s %s %v", got.Method, got.URL, got.Header)
+	}
+
+	conf.AccountKey, conf.SASToken = "", "?sv=2020-04-08&sig=x"
+	if u, err = newAzure(conf); err != nil {
+		t.Fatal(err)
+	}
+	if err := u.upload(context.Background(), "a.ndjson.gz", []byte("data")); err != nil {
+		t.Fatal(err)
+	}
+	if got.URL.RawQuery != "sv=2020-04-08&sig=x" || got.Header.Get("Authorization") != "" {
+		t.Fatalf("got request %s %v", got.URL, got.Header)
+	}
+}
+
+func TestStringToSign(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodPut, "https://kubewatch.blob.core.windows.net/audit/a.ndjson.gz?timeout=30", nil)
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("x-ms-version", azureVersion)
+	req.Header.Set("x-ms-date", "Mon, 01 Jun 2020 12:00:00 GMT")
+	req.Header.Set("x-ms-blob-type", "BlockBlob")
+
+	expected := "PUT\n\n\n4\n\napplication/gzip\n\n\n\n\n\n\n" +
+		"x-ms-blob-type:BlockBlob\nx-ms-date:Mon, 01 Jun 2020 12:00:00 GMT\nx-ms-version:2020-04-08\n" +
+		"/kubewatch/audit/a.ndjson.gz\ntimeout:30"
+	if got := stringToSign(req, "kubewatch", 4); got != expected {
+		t.Fatalf("got %q", got)
+	}
+}
+
+func TestS3Upload(t *testing.T) {
+	var got *http.Request
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+	}))
+	defer ts.Close()
+
+	os.Setenv("AWS_ACCESS_KEY_ID", "AKID")
+	os.Setenv("AWS_SECRET_ACCESS_KEY", "SECRET")
+	defer os.Unsetenv("AWS_ACCESS_KEY_ID")
+	defer os.Unsetenv("AWS_SECRET_ACCESS_KEY")
+
+	u, err := newS3(config.Archive{Bucket: "audit", Region: "us-east-1", Endpoint: ts.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := u.upload(context.Background(), "kubewatch/a.ndjson.gz", []byte("data")); err != nil {
+		t.Fatal(err)
+	}
+	if got.Method != http.MethodPut || got.URL.Path != "/audit/kubewatch/a.ndjson.gz" || got.Header.Get("Content-Type") != contentType {
+		t.Fatalf("got request %s %s %v", got.Method, got.URL, got.Header)
+	}
+}
@@ -0,0 +1,184 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package archive
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/utils"
+)
+
+// azureVersion is the version of the Blob service REST API.
+const azureVersion = "2020-04-08"
+
+// azureUploader uploads the files as block blobs of an Azure Storage
+// container, authenticated with the account key or a SAS token.
+type azureUploader struct {
+	endpoint  string
+	account   string
+	container string
+	key       []byte
+	sasToken  string
+	client    *http.Client
+}
+
+func newAzure(conf config.Archive) (uploader, error) {
+	account, accountKey, sasToken := conf.Account, conf.AccountKey, conf.SASToken
+	if accountKey == "" {
+		accountKey = os.Getenv("KW_ARCHIVE_ACCOUNT_KEY")
+	}
+	if sasToken == "" {
+		sasToken = os.Getenv("KW_ARCHIVE_SAS_TOKEN")
+	}
+	if account == "" {
+		return nil, errors.New("archive: missing azure storage account")
+	}
+	if accountKey == "" && sasToken == "" {
+		return nil, errors.New("archive: missing azure storage account key or sas token")
+	}
+
+	client, err := utils.HTTPClient("archive", config.TLS{})
+	if err != nil {
+		return nil, err
+	}
+	u := &azureUploader{
+		endpoint:  "https://" + account + ".blob.core.windows.net",
+		account:   account,
+		container: conf.Bucket,
+		sasToken:  strings.TrimPrefix(sasToken, "?"),
+		client:    client,
+	}
+	if conf.Endpoint != "" {
+		u.endpoint = strings.TrimSuffix(conf.Endpoint, "/")
+	}
+	if sasToken == "" {
+		key, err := base64.StdEncoding.DecodeString(accountKey)
+		if err != nil {
+			return nil, errors.New("archive: invalid azure storage account key, must be base64 encoded")
+		}
+		u.key = key
+	}
+	return u, nil
+}
+
+// upload puts the file as a block blob,
+// https://docs.microsoft.com/en-us/rest/api/storageservices/put-blob.
+func (u *azureUploader) upload(ctx context.Context, key string, data []byte) error {
+	segments := strings.Split(key, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	target := u.endpoint + "/" + url.PathEscape(u.container) + "/" + strings.Join(segments, "/")
+	if u.sasToken != "" {
+		target += "?" + u.sasToken
+	}
+	req, err := http.NewRequest(http.MethodPut, target, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("x-ms-blob-type", "BlockBlob")
+	req.Header.Set("x-ms-date", time.Now().UTC().Format(http.TimeFormat))
+	req.Header.Set("x-ms-version", azureVersion)
+	if u.key != nil {
+		req.Header.Set("Authorization", "SharedKey "+u.account+":"+u.sign(req, len(data)))
+	}
+
+	res, err := u.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode/100 != 2 {
+		resBody, _ := ioutil.ReadAll(res.Body)
+		return fmt.Errorf("Azure Blob Storage http response: %s, %s", res.Status, string(resBody))
+	}
+	return nil
+}
+
+// sign returns the Shared Key signature of the request,
+// https://docs.microsoft.com/en-us/rest/api/storageservices/authorize-with-shared-key.
+func (u *azureUploader) sign(req *http.Request, length int) string {
+	mac := hmac.New(sha256.New, u.key)
+	mac.Write([]byte(stringToSign(req, u.account, length)))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// stringToSign returns the string signed with the Shared Key of the account.
+func stringToSign(req *http.Request, account string, length int) string {
+	contentLength := ""
+	if length > 0 {
+		contentLength = strconv.Itoa(length)
+	}
+	h := req.Header
+	lines := []string{
+		req.Method,
+		h.Get("Content-Encoding"),
+		h.Get("Content-Language"),
+		contentLength,
+		h.Get("Content-MD5"),
+		h.Get("Content-Type"),
+		// the date is the x-ms-date header
+		"",
+		h.Get("If-Modified-Since"),
+		h.Get("If-Match"),
+		h.Get("If-None-Match"),
+		h.Get("If-Unmodified-Since"),
+		h.Get("Range"),
+	}
+
+	var headers []string
+	for name := range h {
+		if name := strings.ToLower(name); strings.HasPrefix(name, "x-ms-") {
+			headers = append(headers, name)
+		}
+	}
+	sort.Strings(headers)
+	for _, name := range headers {
+		lines = append(lines, name+":"+strings.TrimSpace(h.Get(name)))
+	}
+
+	resource := "/" + account + req.URL.EscapedPath()
+	query := req.URL.Query()
+	var params []string
+	for name := range query {
+		params = append(params, name)
+	}
+	sort.Strings(params)
+	for _, name := range params {
+		values := query[name]
+		sort.Strings(values)
+		resource += "\n" + strings.ToLower(name) + ":" + strings.Join(values, ",")
+	}
+	return strings.Join(append(lines, resource), "\n")
+}
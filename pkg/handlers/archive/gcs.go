@@ -0,0 +1,106 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package archive
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/utils"
+)
+
+const (
+	defaultGCSEndpoint = "https://storage.googleapis.com"
+	gcsScope           = "https://www.googleapis.com/auth/devstorage.read_write"
+	// contentType is the type of the files, which are stored compressed.
+	contentType = "application/gzip"
+)
+
+// gcsUploader uploads the files to a Cloud Storage bucket, with the
+// application default credentials, which include GKE workload identity.
+type gcsUploader struct {
+	endpoint string
+	bucket   string
+
+	once      sync.Once
+	client    *http.Client
+	clientErr error
+}
+
+func newGCS(conf config.Archive) (uploader, error) {
+	endpoint := conf.Endpoint
+	if endpoint == "" {
+		endpoint = defaultGCSEndpoint
+	}
+	return &gcsUploader{endpoint: strings.TrimSuffix(endpoint, "/"), bucket: conf.Bucket}, nil
+}
+
+// httpClient returns a client authenticated with the application default credentials.
+func (u *gcsUploader) httpClient() (*http.Client, error) {
+	u.once.Do(func() {
+		if u.client != nil {
+			return
+		}
+		// the tokens are fetched with the proxied client too
+		base, err := utils.HTTPClient("archive", config.TLS{})
+		if err != nil {
+			u.clientErr = err
+			return
+		}
+		ctx := context.WithValue(context.Background(), oauth2.HTTPClient, base)
+		u.client, u.clientErr = google.DefaultClient(ctx, gcsScope)
+	})
+	return u.client, u.clientErr
+}
+
+// upload uploads the file with the simple media upload of the JSON API,
+// https://cloud.google.com/storage/docs/uploading-objects.
+func (u *gcsUploader) upload(ctx context.Context, key string, data []byte) error {
+	client, err := u.httpClient()
+	if err != nil {
+		return err
+	}
+
+	target := fmt.Sprintf("%s/upload/storage/v1/b/%s/o?uploadType=media&name=%s",
+		u.endpoint, url.PathEscape(u.bucket), url.QueryEscape(key))
+	req, err := http.NewRequest(http.MethodPost, target, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	res, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode/100 != 2 {
+		resBody, _ := ioutil.ReadAll(res.Body)
+		return fmt.Errorf("Cloud Storage http response: %s, %s", res.Status, string(resBody))
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package archive
+
+import (
+	"bytes"
+	"context"
+
+	sdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/utils"
+)
+
+// s3Uploader uploads the files to an S3 bucket, with the credentials of the
+// AWS SDK default chain.
+type s3Uploader struct {
+	client *s3.S3
+	bucket string
+}
+
+func newS3(conf config.Archive) (uploader, error) {
+	client, err := utils.HTTPClient("archive", config.TLS{})
+	if err != nil {
+		return nil, err
+	}
+	c := sdk.NewConfig().WithRegion(conf.Region).WithHTTPClient(client)
+	if conf.Endpoint != "" {
+		// the S3 compatible storages, e.g. MinIO, mostly lack the
+		// virtual hosted buckets
+		c = c.WithEndpoint(conf.Endpoint).WithS3ForcePathStyle(true)
+	}
+	sess, err := session.NewSession(c)
+	if err != nil {
+		return nil, err
+	}
+	return &s3Uploader{client: s3.New(sess), bucket: conf.Bucket}, nil
+}
+
+func (u *s3Uploader) upload(ctx context.Context, key string, data []byte) error {
+	_, err := u.client.PutObjectWithContext(ctx, &s3.PutObjectInput{
+		Bucket:      sdk.String(u.bucket),
+		Key:         sdk.String(key),
+		Body:        bytes.NewReader(data),
+		ContentType: sdk.String(contentType),
+	})
+	return err
+}
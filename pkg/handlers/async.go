@@ -0,0 +1,144 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handlers
+
+import (
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+	"github.com/bitnami-labs/kubewatch/pkg/metrics"
+	"github.com/sirupsen/logrus"
+)
+
+// Async implements the Handler interface, queueing the events for Workers
+// goroutines sending them to the wrapped handler, so a slow handler doesn't
+// delay the others. The events of an object are always sent by the same
+// worker, in order. When the queue of a worker is full, the events are
+// dropped if Drop is set, otherwise Handle waits for room
+type Async struct {
+	wrapper
+	Name string
+	// Workers is the number of events sent concurrently (default 1).
+	Workers int
+	// QueueSize is the number of events waiting, shared between the workers.
+	QueueSize int
+	Drop      bool
+
+	once   sync.Once
+	queues []chan event.Event
+
+	// mu guards the queues from being closed while Handle adds an event
+	mu      sync.RWMutex
+	running bool
+}
+
+// Handle queues the event for its worker. Before Run, e.g. when testing the
+// handlers, and once stopped, there are no workers and the event is sent
+// right away.
+func (a *Async) Handle(e event.Event) {
+	a.once.Do(a.init)
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if !a.running {
+		a.Handler.Handle(e)
+		return
+	}
+	q := a.queues[a.worker(e)]
+	select {
+	case q <- e:
+		metrics.DispatchQueueLength.WithLabelValues(a.Name).Inc()
+		return
+	default:
+	}
+
+	if a.Drop {
+		logrus.WithField("handler", a.Name).WithFields(e.LogFields()).Warn("Dispatch queue full, dropping the event")
+		metrics.DispatchDropped.WithLabelValues(a.Name).Inc()
+		return
+	}
+	start := time.Now()
+	q <- e
+	metrics.DispatchQueueLength.WithLabelValues(a.Name).Inc()
+	metrics.DispatchBlocked.WithLabelValues(a.Name).Add(time.Since(start).Seconds())
+}
+
+// Run runs the workers and the background work of the wrapped handler until
+// stopCh is closed. The queued events are sent before the wrapped handler is
+// stopped.
+func (a *Async) Run(stopCh <-chan struct{}) {
+	a.once.Do(a.init)
+
+	handlerStop, handlerDone := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(handlerDone)
+		Run(a.Handler, handlerStop)
+	}()
+
+	// the events sent right away are sent before the queued ones
+	a.mu.Lock()
+	a.running = true
+	a.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, q := range a.queues {
+		wg.Add(1)
+		go func(q <-chan event.Event) {
+			defer wg.Done()
+			for e := range q {
+				metrics.DispatchQueueLength.WithLabelValues(a.Name).Dec()
+				a.Handler.Handle(e)
+			}
+		}(q)
+	}
+
+	<-stopCh
+	a.mu.Lock()
+	a.running = false
+	for _, q := range a.queues {
+		close(q)
+	}
+	a.mu.Unlock()
+	wg.Wait()
+
+	close(handlerStop)
+	<-handlerDone
+}
+
+// init creates the queues of the workers.
+func (a *Async) init() {
+	workers := a.Workers
+	if workers < 1 {
+		workers = 1
+	}
+	size := a.QueueSize / workers
+	if size < 1 {
+		size = 1
+	}
+	for i := 0; i < workers; i++ {
+		a.queues = append(a.queues, make(chan event.Event, size))
+	}
+}
+
+// worker returns the index of the worker sending the events of the object.
+func (a *Async) worker(e event.Event) int {
+	h := fnv.New32a()
+	h.Write([]byte(e.Kind + "/" + e.Namespace + "/" + e.Name))
+	return int(h.Sum32() % uint32(len(a.queues)))
+}
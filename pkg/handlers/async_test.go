@@ -0,0 +1,172 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+)
+
+// start runs the handler until the returned function is called, which waits
+// for Run to return.
+func start(t *testing.T, a *Async) func() {
+	stopCh, done := make(chan struct{}), make(chan struct{})
+	go func() {
+		a.Run(stopCh)
+		close(done)
+	}()
+	for running := false; !running; time.Sleep(time.Millisecond) {
+		a.mu.RLock()
+		running = a.running
+		a.mu.RUnlock()
+	}
+	return func() {
+		close(stopCh)
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("Run() did not return")
+		}
+	}
+}
+
+func TestAsyncOrder(t *testing.T) {
+	var mu sync.Mutex
+	received := map[string][]int{}
+	a := &Async{Name: "test", Workers: 4, QueueSize: 8, wrapper: wrapper{Func(func(e event.Event) {
+		time.Sleep(time.Duration(len(e.Name)) * 100 * time.Microsecond)
+		n, _ := strconv.Atoi(e.Details)
+		mu.Lock()
+		received[e.Name] = append(received[e.Name], n)
+		mu.Unlock()
+	})}}
+	stop := start(t, a)
+
+	names := []string{"a", "bb", "ccc", "dddd", "eeeee", "ffffff"}
+	for i := 0; i < 50; i++ {
+		for _, name := range names {
+			a.Handle(event.Event{Kind: "pod", Namespace: "default", Name: name, Details: strconv.Itoa(i)})
+		}
+	}
+	stop()
+
+	for _, name := range names {
+		got := received[name]
+		if len(got) != 50 {
+			t.Fatalf("%s: got %d events, want 50", name, len(got))
+		}
+		for i, n := range got {
+			if n != i {
+				t.Fatalf("%s: got the events in the order %v", name, got)
+			}
+		}
+	}
+}
+
+func TestAsyncDrop(t *testing.T) {
+	release := make(chan struct{})
+	handled := make(chan string, 10)
+	a := &Async{Name: "test", Workers: 1, QueueSize: 2, Drop: true, wrapper: wrapper{Func(func(e event.Event) {
+		handled <- e.Name
+		<-release
+	})}}
+	stop := start(t, a)
+
+	a.Handle(event.Event{Name: "sending"})
+	<-handled
+	for i := 0; i < 4; i++ {
+		// two events fill the queue, the others are dropped without blocking
+		a.Handle(event.Event{Name: fmt.Sprintf("queued-%d", i)})
+	}
+	close(release)
+	stop()
+
+	close(handled)
+	var got []string
+	for name := range handled {
+		got = append(got, name)
+	}
+	if len(got) != 2 || got[0] != "queued-0" || got[1] != "queued-1" {
+		t.Fatalf("got the events %v, want queued-0 and queued-1", got)
+	}
+}
+
+func TestAsyncStop(t *testing.T) {
+	release := make(chan struct{})
+	var mu sync.Mutex
+	var got []string
+	a := &Async{Name: "test", Workers: 2, QueueSize: 10, wrapper: wrapper{Func(func(e event.Event) {
+		<-release
+		mu.Lock()
+		got = append(got, e.Name)
+		mu.Unlock()
+	})}}
+	stop := start(t, a)
+
+	for i := 0; i < 6; i++ {
+		a.Handle(event.Event{Name: fmt.Sprintf("pod-%d", i)})
+	}
+	stopped := make(chan struct{})
+	go func() {
+		stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		t.Fatal("Run() returned before sending the queued events")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	<-stopped
+
+	if len(got) != 6 {
+		t.Fatalf("got the events %v, want the 6 queued ones", got)
+	}
+	// once stopped, the events are sent right away
+	a.Handle(event.Event{Name: "late"})
+	if len(got) != 7 {
+		t.Fatalf("got the events %v, want the late one sent", got)
+	}
+}
+
+func TestAsyncNotRunning(t *testing.T) {
+	var got []string
+	a := &Async{Name: "test", QueueSize: 1, wrapper: wrapper{Func(func(e event.Event) {
+		got = append(got, e.Name)
+	})}}
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 3; i++ {
+			a.Handle(event.Event{Name: fmt.Sprintf("pod-%d", i)})
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Handle() blocked without Run")
+	}
+	if len(got) != 3 {
+		t.Fatalf("got the events %v, want the 3 sent right away", got)
+	}
+}
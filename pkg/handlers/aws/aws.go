@@ -0,0 +1,221 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package aws implements a handler publishing events to an AWS SNS topic or SQS queue.
+
+Credentials are looked up by the AWS SDK default chain: environment variables,
+IAM roles for service accounts (IRSA) and instance profiles.
+*/
+package aws
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	sdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/arn"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/aws/aws-sdk-go/service/sqs"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+	"github.com/sirupsen/logrus"
+)
+
+// logger logs the notifications sent by the handler.
+var logger = logrus.WithField("handler", "aws")
+
+var awsErrMsg = `
+%s
+
+You need to set the ARN of an SNS topic or SQS queue,
+using "--arn/-a", or using environment variables:
+
+export KW_AWS_ARN=topic_or_queue_arn
+
+Command line flags will override environment variables
+
+`
+
+// AWS handler implements handler.Handler interface,
+// Publish events to an SNS topic or SQS queue
+type AWS struct {
+	ARN            string
+	Region         string
+	PayloadVersion string
+
+	arn      arn.ARN
+	queueURL string
+	sns      *sns.SNS
+	sqs      *sqs.SQS
+}
+
+// Init prepares AWS configuration
+func (a *AWS) Init(c *config.Config) error {
+	a.PayloadVersion = c.PayloadVersion
+	resourceARN := c.Handler.AWS.ARN
+	region := c.Handler.AWS.Region
+
+	if resourceARN == "" {
+		resourceARN = os.Getenv("KW_AWS_ARN")
+	}
+
+	a.ARN = resourceARN
+
+	if err := checkMissingAWSVars(a); err != nil {
+		return err
+	}
+
+	parsed, err := arn.Parse(a.ARN)
+	if err != nil {
+		return fmt.Errorf("invalid aws arn %q: %v", a.ARN, err)
+	}
+	if parsed.Service != "sns" && parsed.Service != "sqs" {
+		return fmt.Errorf("unsupported aws arn %q, must be an SNS topic or SQS queue", a.ARN)
+	}
+	a.arn = parsed
+
+	if region == "" {
+		region = parsed.Region
+	}
+	a.Region = region
+
+	sess, err := session.NewSession(sdk.NewConfig().WithRegion(a.Region))
+	if err != nil {
+		return err
+	}
+
+	switch parsed.Service {
+	case "sns":
+		a.sns = sns.New(sess)
+	case "sqs":
+		a.sqs = sqs.New(sess)
+		a.queueURL = fmt.Sprintf("%s/%s/%s", a.sqs.Endpoint, parsed.AccountID, parsed.Resource)
+	}
+
+	return nil
+}
+
+// Handle handles an event.
+func (a *AWS) Handle(e event.Event) {
+	body, err := json.Marshal(e.Payload(a.PayloadVersion))
+	if err != nil {
+		logger.WithFields(e.LogFields()).Error(err)
+		return
+	}
+
+	if a.sns != nil {
+		err = a.publish(e, string(body))
+	} else {
+		err = a.send(e, string(body))
+	}
+	if err != nil {
+		logger.WithFields(e.LogFields()).Errorf("Failed sending to %s: %v", a.ARN, err)
+		return
+	}
+
+	logger.WithFields(e.LogFields()).Infof("Message successfully sent to %s", a.ARN)
+}
+
+func checkMissingAWSVars(a *AWS) error {
+	if a.ARN == "" {
+		return fmt.Errorf(awsErrMsg, "Missing aws arn")
+	}
+
+	return nil
+}
+
+func (a *AWS) publish(e event.Event, body string) error {
+	input := &sns.PublishInput{
+		TopicArn:          sdk.String(a.ARN),
+		Message:           sdk.String(body),
+		MessageAttributes: map[string]*sns.MessageAttributeValue{},
+	}
+	for k, v := range attributes(e) {
+		input.MessageAttributes[k] = &sns.MessageAttributeValue{DataType: sdk.String("String"), StringValue: sdk.String(v)}
+	}
+	if a.fifo() {
+		input.MessageGroupId = sdk.String(groupID(e))
+		input.MessageDeduplicationId = sdk.String(deduplicationID(e))
+	}
+	_, err := a.sns.Publish(input)
+	return err
+}
+
+func (a *AWS) send(e event.Event, body string) error {
+	input := &sqs.SendMessageInput{
+		QueueUrl:          sdk.String(a.queueURL),
+		MessageBody:       sdk.String(body),
+		MessageAttributes: map[string]*sqs.MessageAttributeValue{},
+	}
+	for k, v := range attributes(e) {
+		input.MessageAttributes[k] = &sqs.MessageAttributeValue{DataType: sdk.String("String"), StringValue: sdk.String(v)}
+	}
+	if a.fifo() {
+		input.MessageGroupId = sdk.String(groupID(e))
+		input.MessageDeduplicationId = sdk.String(deduplicationID(e))
+	}
+	_, err := a.sqs.SendMessage(input)
+	return err
+}
+
+// fifo reports whether the topic or queue is FIFO, which requires a message group.
+func (a *AWS) fifo() bool {
+	return strings.HasSuffix(a.arn.Resource, ".fifo")
+}
+
+// attributes returns the message attributes subscribers can filter on;
+// attributes can't be empty, so missing values are omitted.
+func attributes(e event.Event) map[string]string {
+	attrs := map[string]string{}
+	for k, v := range map[string]string{"kind": e.Kind, "namespace": e.Namespace, "reason": e.Reason} {
+		if v != "" {
+			attrs[k] = v
+		}
+	}
+	return attrs
+}
+
+// groupID keeps the events of an object ordered in FIFO topics and queues.
+func groupID(e event.Event) string {
+	return strings.Join([]string{e.Kind, e.Namespace, e.Name}, "/")
+}
+
+// deduplicationID identifies the event in FIFO topics and queues, so the
+// retries of a delivery are dropped: by its ID when set, otherwise by its
+// object, reason and the resource version of the object.
+func deduplicationID(e event.Event) string {
+	identity := e.ID
+	if identity == "" {
+		ref := e.Ref
+		if ref == nil {
+			ref = event.Reference(e.Object)
+		}
+		var resourceVersion string
+		if ref != nil {
+			resourceVersion = ref.ResourceVersion
+		}
+		identity = strings.Join([]string{e.Kind, e.Namespace, e.Name, e.Reason, resourceVersion}, "/")
+	}
+	sum := sha256.Sum256([]byte(identity))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+	api_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestAWSInit(t *testing.T) {
+	s := &AWS{}
+	expectedError := fmt.Errorf(awsErrMsg, "Missing aws arn")
+
+	var Tests = []struct {
+		aws config.AWS
+		err error
+	}{
+		{config.AWS{ARN: "arn:aws:sns:eu-west-1:123456789012:kubewatch"}, nil},
+		{config.AWS{ARN: "arn:aws:sqs:eu-west-1:123456789012:kubewatch.fifo"}, nil},
+		{config.AWS{ARN: "arn:aws:s3:::bucket"}, fmt.Errorf("unsupported aws arn %q, must be an SNS topic or SQS queue", "arn:aws:s3:::bucket")},
+		{config.AWS{}, expectedError},
+	}
+
+	for _, tt := range Tests {
+		c := &config.Config{}
+		c.Handler.AWS = tt.aws
+		if err := s.Init(c); !reflect.DeepEqual(err, tt.err) {
+			t.Fatalf("Init(): %v", err)
+		}
+	}
+}
+
+func TestQueueURL(t *testing.T) {
+	s := &AWS{}
+	c := &config.Config{}
+	c.Handler.AWS = config.AWS{ARN: "arn:aws:sqs:eu-west-1:123456789012:kubewatch"}
+	if err := s.Init(c); err != nil {
+		t.Fatal(err)
+	}
+	if want := "https://sqs.eu-west-1.amazonaws.com/123456789012/kubewatch"; s.queueURL != want {
+		t.Fatalf("got %q, want %q", s.queueURL, want)
+	}
+}
+
+func TestDeduplicationID(t *testing.T) {
+	pod := &api_v1.Pod{ObjectMeta: meta_v1.ObjectMeta{Namespace: "shop", Name: "web", ResourceVersion: "42"}}
+	e := event.Event{Kind: "pod", Namespace: "shop", Name: "web", Reason: "Updated", Object: pod}
+
+	id := deduplicationID(e)
+	time.Sleep(time.Millisecond)
+	if again := deduplicationID(e); again != id {
+		t.Fatalf("got %q then %q for the same event", id, again)
+	}
+	if len(id) > 128 {
+		t.Fatalf("got %q, longer than the 128 characters allowed", id)
+	}
+
+	updated := e
+	updated.Object = &api_v1.Pod{ObjectMeta: meta_v1.ObjectMeta{Namespace: "shop", Name: "web", ResourceVersion: "43"}}
+	deleted := e
+	deleted.Reason = "Deleted"
+	referenced := e
+	referenced.Object = nil
+	referenced.Ref = &event.ObjectReference{Kind: "Pod", Namespace: "shop", Name: "web", ResourceVersion: "43"}
+	for _, other := range []event.Event{updated, deleted} {
+		if deduplicationID(other) == id {
+			t.Fatalf("got the same id for %+v", other)
+		}
+	}
+	if deduplicationID(referenced) != deduplicationID(updated) {
+		t.Fatal("got different ids for the resource version of the reference and of the object")
+	}
+
+	// the id of the event identifies it, whatever its object
+	withID := e
+	withID.ID = "6f1e0b2c"
+	sameID := updated
+	sameID.ID = "6f1e0b2c"
+	if deduplicationID(withID) == id || deduplicationID(withID) != deduplicationID(sameID) {
+		t.Fatal("the id of the event is not used")
+	}
+}
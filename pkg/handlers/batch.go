@@ -0,0 +1,90 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handlers
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+	"github.com/bitnami-labs/kubewatch/pkg/tracing"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Batch implements the Handler interface, accumulating the events for
+// Interval, starting with the first one, or until MaxEvents are received,
+// and sending them in a single message of the wrapped handler
+type Batch struct {
+	wrapper
+	Name      string
+	Interval  time.Duration
+	MaxEvents int
+	Sender    BatchSender
+
+	mu     sync.Mutex
+	events []event.Event
+	timer  *time.Timer
+}
+
+// Handle adds the event to the batch, sending it once full.
+func (b *Batch) Handle(e event.Event) {
+	b.mu.Lock()
+	b.events = append(b.events, e)
+	full := len(b.events) >= b.MaxEvents
+	if len(b.events) == 1 && !full {
+		b.timer = time.AfterFunc(b.Interval, b.flush)
+	}
+	b.mu.Unlock()
+	tracing.AddEvent(e, "added to batch", attribute.String("kubewatch.handler", b.Name))
+
+	if full {
+		b.flush()
+	}
+}
+
+// Run sends the last batch once stopCh is closed, while running the
+// background work of the wrapped handler.
+func (b *Batch) Run(stopCh <-chan struct{}) {
+	Run(b.Sender, stopCh)
+	<-stopCh
+	b.flush()
+}
+
+// flush sends the events of the batch, a single event as usual.
+func (b *Batch) flush() {
+	b.mu.Lock()
+	events := b.events
+	b.events = nil
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	b.mu.Unlock()
+
+	switch len(events) {
+	case 0:
+		return
+	case 1:
+		b.Sender.Handle(events[0])
+		return
+	}
+	logrus.WithField("handler", b.Name).Debugf("Sending batch of %d events", len(events))
+	if err := b.Sender.SendBatch(events); err != nil {
+		logrus.WithField("handler", b.Name).Errorf("Can not send batch of %d events: %v", len(events), err)
+	}
+}
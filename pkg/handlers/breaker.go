@@ -0,0 +1,113 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handlers
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bitnami-labs/kubewatch/pkg/breaker"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+	"github.com/bitnami-labs/kubewatch/pkg/health"
+	"github.com/bitnami-labs/kubewatch/pkg/metrics"
+	"github.com/sirupsen/logrus"
+)
+
+// Breaker implements the Sender interface, giving up the deliveries of the
+// wrapped sender taking longer than Timeout, and stopping them while the
+// Circuit, when set, is open
+type Breaker struct {
+	wrapper
+	Name    string
+	Sender  Sender
+	Timeout time.Duration
+	Circuit *breaker.Breaker
+}
+
+// Handle handles an event.
+func (b *Breaker) Handle(e event.Event) {
+	if err := b.Send(e); err != nil {
+		logrus.WithField("handler", b.Name).WithFields(e.LogFields()).Warnf("Delivery failed: %v", err)
+	}
+}
+
+// Send sends an event, failing right away while the circuit is open.
+func (b *Breaker) Send(e event.Event) error {
+	if b.Circuit == nil {
+		return b.send(e)
+	}
+	if !b.Circuit.Allow(time.Now()) {
+		return fmt.Errorf("circuit breaker open, %s deliveries stopped", b.Name)
+	}
+
+	err := b.send(e)
+	if state, changed := b.Circuit.Record(err == nil, time.Now()); changed {
+		logger := logrus.WithField("handler", b.Name)
+		switch state {
+		case breaker.Open:
+			logger.Warnf("Circuit breaker open, stopping the deliveries: %v", err)
+			metrics.CircuitOpen.WithLabelValues(b.Name).Set(1)
+		case breaker.Closed:
+			logger.Info("Circuit breaker closed, the deliveries resume")
+			metrics.CircuitOpen.WithLabelValues(b.Name).Set(0)
+		}
+	}
+	return err
+}
+
+// send sends an event with the wrapped sender, giving up after the timeout.
+// The delivery given up goes on in the background, so it may still be
+// received.
+func (b *Breaker) send(e event.Event) error {
+	if b.Timeout == 0 {
+		return b.Sender.Send(e)
+	}
+
+	result := make(chan error, 1)
+	go func() {
+		result <- b.Sender.Send(e)
+	}()
+	timer := time.NewTimer(b.Timeout)
+	defer timer.Stop()
+	select {
+	case err := <-result:
+		return err
+	case <-timer.C:
+		metrics.DeliveryTimeouts.WithLabelValues(b.Name).Inc()
+		return fmt.Errorf("delivery timed out after %s", b.Timeout)
+	}
+}
+
+// Run reports the state of the circuit in the readiness checks and the
+// metrics, and runs the background work of the wrapped handler until stopCh
+// is closed.
+func (b *Breaker) Run(stopCh <-chan struct{}) {
+	if b.Circuit != nil {
+		name := "handler-" + b.Name
+		health.AddReadinessCheck(name, func() error {
+			if state := b.Circuit.State(); state != breaker.Closed {
+				return fmt.Errorf("circuit breaker %s", state)
+			}
+			return nil
+		})
+		defer health.RemoveReadinessCheck(name)
+		metrics.CircuitOpen.WithLabelValues(b.Name).Set(0)
+		defer metrics.CircuitOpen.DeleteLabelValues(b.Name)
+	}
+	Run(b.Sender, stopCh)
+	<-stopCh
+}
@@ -0,0 +1,270 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package datadog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+	"github.com/bitnami-labs/kubewatch/pkg/metrics"
+	"github.com/bitnami-labs/kubewatch/pkg/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// logger logs the notifications sent by the handler.
+var logger = logrus.WithField("handler", "datadog")
+
+const (
+	defaultSite    = "datadoghq.com"
+	eventsPath     = "/api/v1/events"
+	requestTimeout = 10 * time.Second
+	// maxTextSize is the size limit of the text of the events.
+	maxTextSize = 4000
+)
+
+// alertTypes maps event severities to the alert types of the Datadog events.
+var alertTypes = map[string]string{
+	"critical": "error",
+	"warning":  "warning",
+	"info":     "info",
+}
+
+var datadogErrMsg = `
+%s
+
+You need to set the Datadog API key or the DogStatsD address,
+using "--api-key/-k" or "--statsd-address", or using environment variables:
+
+export KW_DATADOG_API_KEY=datadog_api_key
+export KW_DATADOG_STATSD_ADDRESS=localhost:8125
+
+Command line flags will override environment variables
+
+`
+
+// Datadog handler implements handler.Handler interface,
+// Notify event as Datadog events
+type Datadog struct {
+	APIKey        string
+	Url           string
+	StatsdAddress string
+	Tags          []string
+
+	client *http.Client
+}
+
+// Event is an event of the Datadog Events API
+// The Documentation is in https://docs.datadoghq.com/api/latest/events/#post-an-event
+type Event struct {
+	Title          string   `json:"title"`
+	Text           string   `json:"text"`
+	DateHappened   int64    `json:"date_happened,omitempty"`
+	Host           string   `json:"host,omitempty"`
+	AggregationKey string   `json:"aggregation_key,omitempty"`
+	AlertType      string   `json:"alert_type,omitempty"`
+	Tags           []string `json:"tags,omitempty"`
+}
+
+// Init prepares Datadog configuration
+func (d *Datadog) Init(c *config.Config) error {
+	conf := c.Handler.Datadog
+	apiKey := conf.APIKey
+	statsdAddress := conf.StatsdAddress
+
+	if apiKey == "" {
+		apiKey = os.Getenv("KW_DATADOG_API_KEY")
+	}
+
+	if statsdAddress == "" {
+		statsdAddress = os.Getenv("KW_DATADOG_STATSD_ADDRESS")
+	}
+
+	site := conf.Site
+	if site == "" {
+		site = defaultSite
+	}
+
+	d.APIKey = apiKey
+	d.StatsdAddress = statsdAddress
+	d.Url = "https://api." + site
+	d.Tags = conf.Tags
+
+	client, err := utils.HTTPClient("datadog", conf.TLS)
+	if err != nil {
+		return err
+	}
+	client.Timeout = requestTimeout
+	d.client = client
+
+	return checkMissingDatadogVars(d)
+}
+
+// Handle handles an event.
+func (d *Datadog) Handle(e event.Event) {
+	if err := d.Send(e); err != nil {
+		logger.WithFields(e.LogFields()).Error(err)
+	}
+}
+
+// Send posts the Datadog event of the event, through DogStatsD when its
+// address is set or else through the API, returning an error when it can't
+// be sent.
+func (d *Datadog) Send(e event.Event) error {
+	ddEvent := prepareEvent(e, d.Tags)
+
+	var err error
+	if d.StatsdAddress != "" {
+		err = d.sendStatsd(ddEvent)
+	} else {
+		err = d.post(ddEvent)
+	}
+	if err != nil {
+		metrics.Notifications.WithLabelValues("datadog", "failure").Inc()
+		return err
+	}
+
+	metrics.Notifications.WithLabelValues("datadog", "success").Inc()
+	logger.WithFields(e.LogFields()).Info("Datadog event successfully sent")
+	return nil
+}
+
+func checkMissingDatadogVars(d *Datadog) error {
+	if d.APIKey == "" && d.StatsdAddress == "" {
+		return fmt.Errorf(datadogErrMsg, "Missing Datadog API key or DogStatsD address")
+	}
+
+	return nil
+}
+
+// prepareEvent returns the Datadog event of the event, tagged with its
+// kind, namespace, cluster, reason and severity, its external labels and the
+// tags of the config. The events of an object share their aggregation key.
+func prepareEvent(e event.Event, tags []string) *Event {
+	title := fmt.Sprintf("%s %s %s", e.Kind, e.Name, strings.ToLower(e.Reason))
+	if e.Cluster != "" {
+		title = "[" + e.Cluster + "] " + title
+	}
+
+	var eventTags []string
+	for k, v := range map[string]string{
+		"kind":      e.Kind,
+		"namespace": e.Namespace,
+		"cluster":   e.Cluster,
+		"reason":    e.Reason,
+		"severity":  e.Severity,
+	} {
+		if v != "" {
+			eventTags = append(eventTags, k+":"+v)
+		}
+	}
+	for k, v := range e.ExternalLabels {
+		eventTags = append(eventTags, k+":"+v)
+	}
+	sort.Strings(eventTags)
+	eventTags = append(eventTags, tags...)
+
+	alertType, ok := alertTypes[e.Severity]
+	if !ok {
+		alertType = alertTypes["info"]
+	}
+
+	text := e.Message()
+	if len(text) > maxTextSize {
+		text = text[:maxTextSize-3] + "..."
+	}
+
+	key := strings.Join([]string{e.Kind, e.Namespace, e.Name}, "/")
+	if e.Cluster != "" {
+		key = e.Cluster + "/" + key
+	}
+
+	return &Event{
+		Title:          title,
+		Text:           text,
+		DateHappened:   time.Now().Unix(),
+		Host:           e.Host,
+		AggregationKey: key,
+		AlertType:      alertType,
+		Tags:           eventTags,
+	}
+}
+
+// statsdDatagram returns the DogStatsD datagram of the event
+// The Documentation is in https://docs.datadoghq.com/developers/dogstatsd/datagram_shell/?tab=events
+func statsdDatagram(ddEvent *Event) string {
+	// newlines are escaped in the text, and pipes aren't allowed in the title
+	title := strings.Replace(ddEvent.Title, "|", "", -1)
+	text := strings.Replace(ddEvent.Text, "\n", "\\n", -1)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "_e{%d,%d}:%s|%s|d:%d", len(title), len(text), title, text, ddEvent.DateHappened)
+	if ddEvent.Host != "" {
+		fmt.Fprintf(&b, "|h:%s", ddEvent.Host)
+	}
+	fmt.Fprintf(&b, "|k:%s|t:%s", ddEvent.AggregationKey, ddEvent.AlertType)
+	if len(ddEvent.Tags) > 0 {
+		fmt.Fprintf(&b, "|#%s", strings.Join(ddEvent.Tags, ","))
+	}
+	return b.String()
+}
+
+func (d *Datadog) sendStatsd(ddEvent *Event) error {
+	conn, err := net.DialTimeout("udp", d.StatsdAddress, requestTimeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_, err = conn.Write([]byte(statsdDatagram(ddEvent)))
+	return err
+}
+
+func (d *Datadog) post(ddEvent *Event) error {
+	message, err := json.Marshal(ddEvent)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", d.Url+eventsPath, bytes.NewBuffer(message))
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("DD-API-KEY", d.APIKey)
+
+	res, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode/100 != 2 {
+		body, _ := ioutil.ReadAll(res.Body)
+		return fmt.Errorf("Failed sending event to Datadog. Datadog http response: %s, %s", res.Status, string(body))
+	}
+
+	return nil
+}
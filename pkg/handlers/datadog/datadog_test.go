@@ -0,0 +1,135 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package datadog
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+)
+
+func TestDatadogInit(t *testing.T) {
+	d := &Datadog{}
+	expectedError := fmt.Errorf(datadogErrMsg, "Missing Datadog API key or DogStatsD address")
+
+	var Tests = []struct {
+		datadog config.Datadog
+		err     error
+	}{
+		{config.Datadog{APIKey: "foo"}, nil},
+		{config.Datadog{StatsdAddress: "localhost:8125"}, nil},
+		{config.Datadog{}, expectedError},
+	}
+
+	for _, tt := range Tests {
+		c := &config.Config{}
+		c.Handler.Datadog = tt.datadog
+		if err := d.Init(c); !reflect.DeepEqual(err, tt.err) {
+			t.Fatalf("Init(): %v", err)
+		}
+	}
+
+	c := &config.Config{}
+	c.Handler.Datadog = config.Datadog{APIKey: "foo", Site: "datadoghq.eu"}
+	if err := d.Init(c); err != nil || d.Url != "https://api.datadoghq.eu" {
+		t.Fatalf("Init(): got url %s, %v", d.Url, err)
+	}
+}
+
+func TestDatadogAPI(t *testing.T) {
+	var events []Event
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != eventsPath || r.Header.Get("DD-API-KEY") != "foo" {
+			t.Errorf("unexpected request %s with key %q", r.URL.Path, r.Header.Get("DD-API-KEY"))
+		}
+		var e Event
+		if err := json.NewDecoder(r.Body).Decode(&e); err != nil {
+			t.Error(err)
+		}
+		events = append(events, e)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer ts.Close()
+
+	c := &config.Config{}
+	c.Handler.Datadog = config.Datadog{APIKey: "foo", Tags: []string{"team:platform"}}
+	d := &Datadog{}
+	if err := d.Init(c); err != nil {
+		t.Fatal(err)
+	}
+	d.Url = ts.URL
+
+	e := event.Event{Cluster: "prod", Namespace: "shop", Kind: "deployment", Name: "api", Reason: "Deleted", Severity: "critical"}
+	if err := d.Send(e); err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("got %d events, want 1", len(events))
+	}
+	got := events[0]
+	got.DateHappened = 0
+	want := Event{
+		Title:          "[prod] deployment api deleted",
+		Text:           e.Message(),
+		AggregationKey: "prod/deployment/shop/api",
+		AlertType:      "error",
+		Tags:           []string{"cluster:prod", "kind:deployment", "namespace:shop", "reason:Deleted", "severity:critical", "team:platform"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestDatadogStatsd(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	c := &config.Config{}
+	c.Handler.Datadog = config.Datadog{StatsdAddress: conn.LocalAddr().String()}
+	d := &Datadog{}
+	if err := d.Init(c); err != nil {
+		t.Fatal(err)
+	}
+	e := event.Event{Namespace: "shop", Kind: "pod", Name: "api-1", Host: "node-1", Reason: "Created", Severity: "info"}
+	if err := d.Send(e); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := make([]byte, 8192)
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	datagram := string(buf[:n])
+	text := strings.Replace(e.Message(), "\n", "\\n", -1)
+	prefix := fmt.Sprintf("_e{%d,%d}:pod api-1 created|%s|d:", len("pod api-1 created"), len(text), text)
+	suffix := "|h:node-1|k:pod/shop/api-1|t:info|#kind:pod,namespace:shop,reason:Created,severity:info"
+	if !strings.HasPrefix(datagram, prefix) || !strings.HasSuffix(datagram, suffix) {
+		t.Fatalf("got datagram %q", datagram)
+	}
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handlers
+
+import (
+	"time"
+
+	"github.com/bitnami-labs/kubewatch/pkg/digest"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+	"github.com/bitnami-labs/kubewatch/pkg/tracing"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Digest implements the Handler interface, collecting the events and
+// passing a summary of them to the wrapped handler every Interval
+type Digest struct {
+	wrapper
+	Name     string
+	Interval time.Duration
+
+	digest digest.Digest
+}
+
+// Handle handles an event. Digests, e.g. of quiet hours, are passed as is.
+func (d *Digest) Handle(e event.Event) {
+	if e.Kind == event.DigestKind {
+		d.Handler.Handle(e)
+		return
+	}
+	d.digest.Add(e)
+	tracing.AddEvent(e, "added to digest", attribute.String("kubewatch.handler", d.Name))
+}
+
+// Run sends the digests every Interval, and the last one once stopCh is
+// closed, while running the background work of the wrapped handler.
+func (d *Digest) Run(stopCh <-chan struct{}) {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		Run(d.Handler, stopCh)
+	}()
+
+	ticker := time.NewTicker(d.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stopCh:
+			d.flush()
+			<-done
+			return
+		case <-ticker.C:
+			d.flush()
+		}
+	}
+}
+
+func (d *Digest) flush() {
+	e, ok := d.digest.Flush("the last " + d.Interval.String())
+	if !ok {
+		return
+	}
+	logrus.WithField("handler", d.Name).Debug("Sending digest")
+	d.Handler.Handle(e)
+}
@@ -0,0 +1,295 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dingtalk
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+	"github.com/bitnami-labs/kubewatch/pkg/metrics"
+	"github.com/bitnami-labs/kubewatch/pkg/severity"
+	"github.com/bitnami-labs/kubewatch/pkg/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// logger logs the notifications sent by the handler.
+var logger = logrus.WithField("handler", "dingtalk")
+
+const requestTimeout = 10 * time.Second
+
+var dingtalkErrMsg = `
+%s
+
+You need to set the webhook url of the robot for DingTalk notify,
+using "--url/-u", or using environment variables:
+
+export KW_DINGTALK_WEBHOOK_URL=dingtalk_webhook_url
+export KW_DINGTALK_SECRET=dingtalk_signing_secret
+
+Command line flags will override environment variables
+
+`
+
+// DingTalk handler implements handler.Handler interface,
+// Notify event to a DingTalk group with a robot
+type DingTalk struct {
+	WebhookURL string
+	Secret     string
+	At         At
+	// Mention are the severities of the events mentioning the members.
+	Mention []string
+
+	client *http.Client
+	now    func() time.Time
+}
+
+// Message is a markdown message of a robot
+// The Documentation is in https://open.dingtalk.com/document/robots/custom-robot-access
+type Message struct {
+	MsgType  string   `json:"msgtype"`
+	Markdown Markdown `json:"markdown"`
+	At       *At      `json:"at,omitempty"`
+}
+
+// Markdown is the content of a markdown message
+type Markdown struct {
+	Title string `json:"title"`
+	Text  string `json:"text"`
+}
+
+// At are the members mentioned by a message
+type At struct {
+	AtMobiles []string `json:"atMobiles,omitempty"`
+	AtUserIds []string `json:"atUserIds,omitempty"`
+	IsAtAll   bool     `json:"isAtAll,omitempty"`
+}
+
+// response is the result of the webhook, an error when its errcode is not 0
+type response struct {
+	ErrCode int    `json:"errcode"`
+	ErrMsg  string `json:"errmsg"`
+}
+
+// Init prepares DingTalk configuration
+func (d *DingTalk) Init(c *config.Config) error {
+	conf := c.Handler.DingTalk
+	webhookURL := conf.WebhookURL
+	secret := conf.Secret
+
+	if webhookURL == "" {
+		webhookURL = os.Getenv("KW_DINGTALK_WEBHOOK_URL")
+	}
+
+	if secret == "" {
+		secret = os.Getenv("KW_DINGTALK_SECRET")
+	}
+
+	mention := conf.MentionSeverities
+	if len(mention) == 0 {
+		mention = []string{severity.Critical}
+	}
+	for _, s := range mention {
+		if !severity.Valid(s) {
+			return fmt.Errorf("invalid dingtalk mention severity %q, must be one of critical, warning or info", s)
+		}
+	}
+
+	d.WebhookURL = webhookURL
+	d.Secret = secret
+	d.At = At{AtMobiles: conf.AtMobiles, AtUserIds: conf.AtUserIds, IsAtAll: conf.AtAll}
+	d.Mention = mention
+	d.now = time.Now
+
+	client, err := utils.HTTPClient("dingtalk", conf.TLS)
+	if err != nil {
+		return err
+	}
+	client.Timeout = requestTimeout
+	d.client = client
+
+	return checkMissingDingTalkVars(d)
+}
+
+// Handle handles an event.
+func (d *DingTalk) Handle(e event.Event) {
+	if err := d.Send(e); err != nil {
+		logger.WithFields(e.LogFields()).Error(err)
+	}
+}
+
+// Send posts the message of the event to the group, returning an error when
+// it is not delivered.
+func (d *DingTalk) Send(e event.Event) error {
+	var at *At
+	if d.mentions(e) {
+		at = &d.At
+	}
+	message := prepareMessage(e, at)
+
+	if err := d.post(message); err != nil {
+		metrics.Notifications.WithLabelValues("dingtalk", "failure").Inc()
+		return err
+	}
+
+	metrics.Notifications.WithLabelValues("dingtalk", "success").Inc()
+	logger.WithFields(e.LogFields()).Info("Message successfully sent to DingTalk")
+	return nil
+}
+
+func checkMissingDingTalkVars(d *DingTalk) error {
+	if d.WebhookURL == "" {
+		return fmt.Errorf(dingtalkErrMsg, "Missing DingTalk webhook url")
+	}
+
+	return nil
+}
+
+// mentions returns whether the event mentions the members, when some are
+// configured and its severity is one of the mention severities.
+func (d *DingTalk) mentions(e event.Event) bool {
+	if len(d.At.AtMobiles) == 0 && len(d.At.AtUserIds) == 0 && !d.At.IsAtAll {
+		return false
+	}
+	for _, s := range d.Mention {
+		if strings.EqualFold(s, e.Severity) {
+			return true
+		}
+	}
+	return false
+}
+
+// sign returns the signature of the messages sent at the timestamp in
+// milliseconds, the HMAC-SHA256 of the timestamp and the secret keyed by the
+// secret.
+func sign(secret string, timestamp int64) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d\n%s", timestamp, secret)
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// prepareMessage returns the markdown message of the event, listing its
+// fields and changes, followed by the mentions since the members are only
+// notified when named in the text.
+func prepareMessage(e event.Event, at *At) *Message {
+	title := fmt.Sprintf("%s %s %s", e.Kind, e.Name, strings.ToLower(e.Reason))
+	if e.Cluster != "" {
+		title = "[" + e.Cluster + "] " + title
+	}
+
+	lines := []string{"#### " + title, "", e.Message(), ""}
+	for _, f := range []struct{ name, value string }{
+		{"Kind", e.Kind},
+		{"Name", e.Name},
+		{"Namespace", e.Namespace},
+		{"Reason", e.Reason},
+		{"Severity", e.Severity},
+		{"Host", e.Host},
+	} {
+		if f.value != "" {
+			lines = append(lines, fmt.Sprintf("- **%s**: %s", f.name, f.value))
+		}
+	}
+	if e.Details != "" {
+		lines = append(lines, "", "> "+e.Details)
+	}
+	if len(e.Diff) > 0 {
+		lines = append(lines, "")
+		for _, c := range e.Diff {
+			lines = append(lines, fmt.Sprintf("- `%s`: %s → %s", c.Path, c.Old, c.New))
+		}
+	}
+	if at != nil {
+		var mentions []string
+		for _, m := range at.AtMobiles {
+			mentions = append(mentions, "@"+m)
+		}
+		for _, id := range at.AtUserIds {
+			mentions = append(mentions, "@"+id)
+		}
+		if at.IsAtAll {
+			mentions = append(mentions, "@all")
+		}
+		lines = append(lines, "", strings.Join(mentions, " "))
+	}
+
+	return &Message{
+		MsgType:  "markdown",
+		Markdown: Markdown{Title: title, Text: strings.Join(lines, "\n")},
+		At:       at,
+	}
+}
+
+func (d *DingTalk) post(m *Message) error {
+	message, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	target := d.WebhookURL
+	if d.Secret != "" {
+		timestamp := d.now().UnixNano() / int64(time.Millisecond)
+		params := url.Values{}
+		params.Set("timestamp", strconv.FormatInt(timestamp, 10))
+		params.Set("sign", sign(d.Secret, timestamp))
+		separator := "?"
+		if strings.Contains(target, "?") {
+			separator = "&"
+		}
+		target += separator + params.Encode()
+	}
+
+	req, err := http.NewRequest("POST", target, bytes.NewBuffer(message))
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Content-Type", "application/json")
+
+	res, err := d.client.Do(req)
+	if err != nil {
+		// the url, holding the access token, is part of the error
+		if urlErr, ok := err.(*url.Error); ok {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("Failed posting to DingTalk: %v", err)
+	}
+	defer res.Body.Close()
+
+	body, _ := ioutil.ReadAll(res.Body)
+	if res.StatusCode/100 != 2 {
+		return fmt.Errorf("Failed posting to DingTalk. DingTalk http response: %s, %s", res.Status, string(body))
+	}
+	// the errors, e.g. of a wrong signature, are reported with a 200
+	var r response
+	if err := json.Unmarshal(body, &r); err == nil && r.ErrCode != 0 {
+		return fmt.Errorf("Failed posting to DingTalk. DingTalk response: %d, %s", r.ErrCode, r.ErrMsg)
+	}
+
+	return nil
+}
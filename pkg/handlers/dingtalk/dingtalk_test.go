@@ -0,0 +1,130 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dingtalk
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+)
+
+func TestDingTalkInit(t *testing.T) {
+	d := &DingTalk{}
+	expectedError := fmt.Errorf(dingtalkErrMsg, "Missing DingTalk webhook url")
+
+	var Tests = []struct {
+		dingtalk config.DingTalk
+		err      error
+	}{
+		{config.DingTalk{WebhookURL: "foo"}, nil},
+		{config.DingTalk{WebhookURL: "foo", Secret: "bar", AtMobiles: []string{"13800000000"}, MentionSeverities: []string{"critical", "warning"}}, nil},
+		{config.DingTalk{}, expectedError},
+		{config.DingTalk{WebhookURL: "foo", MentionSeverities: []string{"urgent"}}, fmt.Errorf("invalid dingtalk mention severity %q, must be one of critical, warning or info", "urgent")},
+	}
+
+	for _, tt := range Tests {
+		c := &config.Config{}
+		c.Handler.DingTalk = tt.dingtalk
+		if err := d.Init(c); !reflect.DeepEqual(err, tt.err) {
+			t.Fatalf("Init(): %v", err)
+		}
+	}
+}
+
+func TestSign(t *testing.T) {
+	if got := sign("foo", 1599360473000); got != "53pTwU5SQHY0H/NG62dHqjX008cHlg9ramvWQ7WS1XY=" {
+		t.Fatalf("got %s", got)
+	}
+}
+
+func TestPrepareMessage(t *testing.T) {
+	e := event.Event{Kind: "deployment", Name: "web", Namespace: "prod", Reason: "Updated", Severity: "warning", Cluster: "eu",
+		Diff: []event.Change{{Path: "spec.replicas", Old: "1", New: "2"}}}
+
+	m := prepareMessage(e, nil)
+	if m.MsgType != "markdown" || m.Markdown.Title != "[eu] deployment web updated" || m.At != nil {
+		t.Fatalf("got %+v", m)
+	}
+	for _, want := range []string{"#### [eu] deployment web updated\n", "- **Namespace**: prod\n", "- `spec.replicas`: 1 → 2"} {
+		if !strings.Contains(m.Markdown.Text, want) {
+			t.Fatalf("text %q does not contain %q", m.Markdown.Text, want)
+		}
+	}
+
+	m = prepareMessage(e, &At{AtMobiles: []string{"13800000000"}, AtUserIds: []string{"ops"}})
+	if !strings.HasSuffix(m.Markdown.Text, "\n@13800000000 @ops") || m.At.AtMobiles[0] != "13800000000" {
+		t.Fatalf("mentions: got %+v", m)
+	}
+}
+
+func TestDingTalkSend(t *testing.T) {
+	var got Message
+	var query map[string][]string
+	reply := `{"errcode":0,"errmsg":"ok"}`
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		got = Message{}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Error(err)
+		}
+		w.Write([]byte(reply))
+	}))
+	defer ts.Close()
+
+	c := &config.Config{}
+	c.Handler.DingTalk = config.DingTalk{WebhookURL: ts.URL + "/robot/send?access_token=foo", Secret: "foo", AtUserIds: []string{"ops"}}
+	d := &DingTalk{}
+	if err := d.Init(c); err != nil {
+		t.Fatal(err)
+	}
+	d.now = func() time.Time { return time.Unix(1599360473, 0) }
+
+	if err := d.Send(event.Event{Kind: "pod", Name: "web", Reason: "BackOff", Severity: "critical"}); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]string{
+		"access_token": {"foo"},
+		"timestamp":    {"1599360473000"},
+		"sign":         {"53pTwU5SQHY0H/NG62dHqjX008cHlg9ramvWQ7WS1XY="},
+	}
+	if !reflect.DeepEqual(query, want) {
+		t.Fatalf("query: got %v, want %v", query, want)
+	}
+	if got.At == nil || got.At.AtUserIds[0] != "ops" {
+		t.Fatalf("critical event: got %+v", got)
+	}
+
+	if err := d.Send(event.Event{Kind: "pod", Name: "web", Reason: "Created", Severity: "info"}); err != nil {
+		t.Fatal(err)
+	}
+	if got.At != nil {
+		t.Fatalf("info event: got mentions %+v", got.At)
+	}
+
+	reply = `{"errcode":310000,"errmsg":"sign not match"}`
+	if err := d.Send(event.Event{Kind: "pod", Name: "web"}); err == nil || !strings.Contains(err.Error(), "sign not match") {
+		t.Fatalf("Send(): expected an error for a rejected signature, got %v", err)
+	}
+}
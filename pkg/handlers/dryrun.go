@@ -0,0 +1,42 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handlers
+
+import (
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+	"github.com/sirupsen/logrus"
+)
+
+// DryRun implements the Handler interface, logging the notifications of a
+// handler instance instead of sending them
+type DryRun struct {
+	Name string
+	// Type of the replaced handler, e.g. slack.
+	Type string
+}
+
+// Init does nothing, the replaced handler is not initialized.
+func (d *DryRun) Init(c *config.Config) error {
+	return nil
+}
+
+// Handle logs the message of the event.
+func (d *DryRun) Handle(e event.Event) {
+	logrus.WithFields(e.LogFields()).WithField("handler", d.Name).WithField("type", d.Type).
+		Infof("Dry run, not sending: %s", e.Message())
+}
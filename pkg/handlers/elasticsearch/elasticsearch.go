@@ -0,0 +1,320 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package elasticsearch
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+	"github.com/bitnami-labs/kubewatch/pkg/metrics"
+	"github.com/bitnami-labs/kubewatch/pkg/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// logger logs the notifications sent by the handler.
+var logger = logrus.WithField("handler", "elasticsearch")
+
+const (
+	defaultIndex         = "kubewatch"
+	defaultBatchSize     = 100
+	defaultFlushInterval = 5 * time.Second
+	requestTimeout       = 30 * time.Second
+	// maxErrorBody limits the response body kept in the error messages.
+	maxErrorBody = 512
+)
+
+var elasticsearchErrMsg = `
+%s
+
+You need to set the Elasticsearch or OpenSearch URL,
+using "--url/-u", or using environment variables:
+
+export KW_ELASTICSEARCH_URL=https://elasticsearch:9200
+export KW_ELASTICSEARCH_API_KEY=api_key (optional)
+
+Command line flags will override environment variables
+
+`
+
+// Elasticsearch handler implements handler.Handler interface,
+// Index events into Elasticsearch or OpenSearch with bulk requests
+type Elasticsearch struct {
+	Url            string
+	Index          string
+	Username       string
+	Password       string
+	APIKey         string
+	BatchSize      int
+	FlushInterval  time.Duration
+	PayloadVersion string
+
+	client *http.Client
+
+	// mu guards the pending documents
+	mu      sync.Mutex
+	pending []document
+}
+
+// Document is the indexed form of an event.
+type Document struct {
+	Timestamp time.Time         `json:"@timestamp"`
+	Cluster   string            `json:"cluster,omitempty"`
+	Namespace string            `json:"namespace,omitempty"`
+	Kind      string            `json:"kind"`
+	Name      string            `json:"name"`
+	Reason    string            `json:"reason"`
+	Status    string            `json:"status,omitempty"`
+	Severity  string            `json:"severity,omitempty"`
+	Component string            `json:"component,omitempty"`
+	Host      string            `json:"host,omitempty"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	// ExternalLabels are the static labels of the kubewatch config.
+	ExternalLabels map[string]string `json:"externalLabels,omitempty"`
+	Details        string            `json:"details,omitempty"`
+	Diff           []event.Change    `json:"diff,omitempty"`
+	// Images lists the container images changed by an update of a workload.
+	Images []event.ImageChange `json:"images,omitempty"`
+	// Metadata are the labels and annotations of the object selected by the
+	// enrich settings.
+	Metadata map[string]string `json:"metadata,omitempty"`
+	Message  string            `json:"message"`
+	// Object is the snapshot of the Kubernetes object.
+	Object interface{} `json:"object,omitempty"`
+}
+
+// DocumentV2 is the indexed form of an event with the payload version v2,
+// where object references the Kubernetes object and snapshot holds it.
+type DocumentV2 struct {
+	Timestamp time.Time `json:"@timestamp"`
+	event.V2
+	Snapshot interface{} `json:"snapshot,omitempty"`
+}
+
+// document is a pending document with the index it goes to.
+type document struct {
+	index string
+	doc   interface{}
+}
+
+// Init prepares Elasticsearch configuration
+func (s *Elasticsearch) Init(c *config.Config) error {
+	s.PayloadVersion = c.PayloadVersion
+	conf := c.Handler.Elasticsearch
+	url := conf.Url
+	apiKey := conf.APIKey
+
+	if url == "" {
+		url = os.Getenv("KW_ELASTICSEARCH_URL")
+	}
+
+	if apiKey == "" {
+		apiKey = os.Getenv("KW_ELASTICSEARCH_API_KEY")
+	}
+
+	s.Url = strings.TrimSuffix(url, "/")
+	s.APIKey = apiKey
+	s.Username = conf.BasicAuth.Username
+	s.Password = conf.BasicAuth.Password
+
+	s.Index = conf.Index
+	if s.Index == "" {
+		s.Index = defaultIndex
+	}
+	s.BatchSize = conf.BatchSize
+	if s.BatchSize <= 0 {
+		s.BatchSize = defaultBatchSize
+	}
+	s.FlushInterval = defaultFlushInterval
+	if conf.FlushInterval != "" {
+		interval, err := time.ParseDuration(conf.FlushInterval)
+		if err != nil {
+			return fmt.Errorf("invalid Elasticsearch flush interval %q: %v", conf.FlushInterval, err)
+		}
+		s.FlushInterval = interval
+	}
+
+	client, err := utils.HTTPClient("elasticsearch", conf.TLS)
+	if err != nil {
+		return err
+	}
+	client.Timeout = requestTimeout
+	s.client = client
+
+	return checkMissingElasticsearchVars(s)
+}
+
+// Handle handles an event. The event is indexed with the next bulk request,
+// once BatchSize events are pending or after FlushInterval.
+func (s *Elasticsearch) Handle(e event.Event) {
+	now := time.Now().UTC()
+	d := document{
+		index: s.Index + "-" + now.Format("2006.01.02"),
+		doc: Document{
+			Timestamp:      now,
+			Cluster:        e.Cluster,
+			Namespace:      e.Namespace,
+			Kind:           e.Kind,
+			Name:           e.Name,
+			Reason:         e.Reason,
+			Status:         e.Status,
+			Severity:       e.Severity,
+			Component:      e.Component,
+			Host:           e.Host,
+			Labels:         e.Labels,
+			Details:        e.Details,
+			ExternalLabels: e.ExternalLabels,
+			Diff:           e.Diff,
+			Images:         e.Images,
+			Metadata:       e.Metadata,
+			Message:        e.Message(),
+			Object:         e.Object,
+		},
+	}
+	if s.PayloadVersion == event.PayloadV2 {
+		d.doc = DocumentV2{Timestamp: now, V2: e.V2(), Snapshot: e.Object}
+	}
+
+	s.mu.Lock()
+	s.pending = append(s.pending, d)
+	full := len(s.pending) >= s.BatchSize
+	s.mu.Unlock()
+
+	if full {
+		s.flush()
+	}
+}
+
+// Run indexes the pending events every FlushInterval until stopCh is
+// closed, then indexes the last ones.
+func (s *Elasticsearch) Run(stopCh <-chan struct{}) {
+	ticker := time.NewTicker(s.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stopCh:
+			s.flush()
+			return
+		case <-ticker.C:
+			s.flush()
+		}
+	}
+}
+
+// flush indexes the pending events with a bulk request.
+func (s *Elasticsearch) flush() {
+	s.mu.Lock()
+	docs := s.pending
+	s.pending = nil
+	s.mu.Unlock()
+
+	if len(docs) == 0 {
+		return
+	}
+	failed, err := s.bulk(docs)
+	if err != nil {
+		metrics.Notifications.WithLabelValues("elasticsearch", "failure").Add(float64(len(docs)))
+		logger.Errorf("Failed indexing %d events: %v", len(docs), err)
+		return
+	}
+	if failed > 0 {
+		metrics.Notifications.WithLabelValues("elasticsearch", "failure").Add(float64(failed))
+		logger.Errorf("Failed indexing %d of %d events", failed, len(docs))
+	}
+	metrics.Notifications.WithLabelValues("elasticsearch", "success").Add(float64(len(docs) - failed))
+	logger.Infof("%d events successfully indexed into %s", len(docs)-failed, s.Url)
+}
+
+// bulkResponse is the part of the bulk API response telling the failures.
+type bulkResponse struct {
+	Errors bool `json:"errors"`
+	Items  []map[string]struct {
+		Status int `json:"status"`
+	} `json:"items"`
+}
+
+// bulk sends the documents with a bulk request, returning how many of
+// them failed to be indexed.
+func (s *Elasticsearch) bulk(docs []document) (int, error) {
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	for _, d := range docs {
+		action := map[string]interface{}{"index": map[string]string{"_index": d.index}}
+		if err := enc.Encode(action); err != nil {
+			return 0, err
+		}
+		if err := enc.Encode(d.doc); err != nil {
+			return 0, err
+		}
+	}
+
+	req, err := http.NewRequest("POST", s.Url+"/_bulk", &body)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	if s.APIKey != "" {
+		req.Header.Set("Authorization", "ApiKey "+s.APIKey)
+	} else if s.Username != "" {
+		req.SetBasicAuth(s.Username, s.Password)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return 0, fmt.Errorf("%s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+
+	var result bulkResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return 0, fmt.Errorf("invalid bulk response: %v", err)
+	}
+	if !result.Errors {
+		return 0, nil
+	}
+	failed := 0
+	for _, item := range result.Items {
+		for _, r := range item {
+			if r.Status < 200 || r.Status > 299 {
+				failed++
+			}
+		}
+	}
+	return failed, nil
+}
+
+func checkMissingElasticsearchVars(s *Elasticsearch) error {
+	if s.Url == "" {
+		return fmt.Errorf(elasticsearchErrMsg, "Missing Elasticsearch URL")
+	}
+
+	return nil
+}
@@ -0,0 +1,160 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package elasticsearch
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+	api_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestElasticsearchInit(t *testing.T) {
+	s := &Elasticsearch{}
+	expectedError := fmt.Errorf(elasticsearchErrMsg, "Missing Elasticsearch URL")
+
+	var Tests = []struct {
+		elasticsearch config.Elasticsearch
+		err           error
+	}{
+		{config.Elasticsearch{Url: "http://localhost:9200"}, nil},
+		{config.Elasticsearch{}, expectedError},
+	}
+
+	for _, tt := range Tests {
+		c := &config.Config{}
+		c.Handler.Elasticsearch = tt.elasticsearch
+		if err := s.Init(c); !reflect.DeepEqual(err, tt.err) {
+			t.Fatalf("Init(): %v", err)
+		}
+	}
+}
+
+func TestElasticsearchBulk(t *testing.T) {
+	var lines []string
+	var auth string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/_bulk" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		auth = r.Header.Get("Authorization")
+		scanner := bufio.NewScanner(r.Body)
+		scanner.Buffer(nil, 1<<20)
+		for scanner.Scan() {
+			lines = append(lines, scanner.Text())
+		}
+		w.Write([]byte(`{"errors":false,"items":[{"index":{"status":201}},{"index":{"status":201}}]}`))
+	}))
+	defer ts.Close()
+
+	s := &Elasticsearch{}
+	c := &config.Config{}
+	c.Handler.Elasticsearch = config.Elasticsearch{Url: ts.URL, APIKey: "key", BatchSize: 2}
+	if err := s.Init(c); err != nil {
+		t.Fatal(err)
+	}
+
+	pod := &api_v1.Pod{ObjectMeta: meta_v1.ObjectMeta{Name: "web", Namespace: "default"}}
+	s.Handle(event.Event{Kind: "pod", Name: "web", Namespace: "default", Reason: "Created", Object: pod})
+	if len(lines) != 0 {
+		t.Fatalf("indexed before the batch is full: %v", lines)
+	}
+	s.Handle(event.Event{Kind: "pod", Name: "web", Namespace: "default", Reason: "Deleted"})
+
+	if auth != "ApiKey key" {
+		t.Fatalf("Authorization: %q", auth)
+	}
+	if len(lines) != 4 {
+		t.Fatalf("expected 2 actions and 2 documents, got %v", lines)
+	}
+	index := "kubewatch-" + time.Now().UTC().Format("2006.01.02")
+	if lines[0] != `{"index":{"_index":"`+index+`"}}` {
+		t.Fatalf("action: %s", lines[0])
+	}
+	var doc struct {
+		Kind   string `json:"kind"`
+		Reason string `json:"reason"`
+		Object struct {
+			Metadata struct {
+				Name string `json:"name"`
+			} `json:"metadata"`
+		} `json:"object"`
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.Kind != "pod" || doc.Reason != "Created" || doc.Object.Metadata.Name != "web" {
+		t.Fatalf("document: %s", lines[1])
+	}
+	if strings.Contains(lines[3], `"object"`) {
+		t.Fatalf("document without object: %s", lines[3])
+	}
+}
+
+func TestElasticsearchRun(t *testing.T) {
+	indexed := make(chan int, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, password, _ := r.BasicAuth()
+		if user != "kubewatch" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		n := 0
+		scanner := bufio.NewScanner(r.Body)
+		for scanner.Scan() {
+			n++
+		}
+		w.Write([]byte(`{"errors":false,"items":[]}`))
+		indexed <- n / 2
+	}))
+	defer ts.Close()
+
+	s := &Elasticsearch{}
+	c := &config.Config{}
+	c.Handler.Elasticsearch = config.Elasticsearch{
+		Url:           ts.URL,
+		BasicAuth:     config.BasicAuth{Username: "kubewatch", Password: "secret"},
+		FlushInterval: "10ms",
+	}
+	if err := s.Init(c); err != nil {
+		t.Fatal(err)
+	}
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go s.Run(stopCh)
+	s.Handle(event.Event{Kind: "pod", Name: "web", Reason: "Created"})
+
+	select {
+	case n := <-indexed:
+		if n != 1 {
+			t.Fatalf("indexed %d events", n)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("events not indexed")
+	}
+}
@@ -0,0 +1,229 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package exec
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	osexec "os/exec"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+	"github.com/bitnami-labs/kubewatch/pkg/metrics"
+	"github.com/sirupsen/logrus"
+)
+
+// logger logs the notifications sent by the handler.
+var logger = logrus.WithField("handler", "exec")
+
+const (
+	defaultTimeout = 10 * time.Second
+	// maxErrorOutput limits the output of the command kept for the error
+	// messages, the last bytes being kept.
+	maxErrorOutput = 512
+)
+
+// waitDelay limits the wait for the output of the processes of a timed out
+// command once they are killed.
+var waitDelay = 5 * time.Second
+
+var execErrMsg = `
+%s
+
+You need to set the command handling the events,
+using "--command/-c", or using environment variables:
+
+export KW_EXEC_COMMAND=/path/to/command
+
+Command line flags will override environment variables
+
+`
+
+// Exec handler implements handler.Handler interface,
+// Pipe events as JSON to a command
+type Exec struct {
+	Command        string
+	Args           []string
+	Env            []string
+	Timeout        time.Duration
+	PayloadVersion string
+}
+
+// Payload is the JSON document written on the stdin of the command with
+// the payload version v1; with v2, it is the event in the schema v2.
+type Payload struct {
+	event.V1
+	Message string    `json:"message"`
+	Time    time.Time `json:"time"`
+}
+
+// Init prepares Exec configuration
+func (x *Exec) Init(c *config.Config) error {
+	x.PayloadVersion = c.PayloadVersion
+	conf := c.Handler.Exec
+	command := conf.Command
+
+	if command == "" {
+		command = os.Getenv("KW_EXEC_COMMAND")
+	}
+
+	x.Command = command
+	x.Args = conf.Args
+
+	names := make([]string, 0, len(conf.Env))
+	for k := range conf.Env {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	x.Env = nil
+	for _, k := range names {
+		x.Env = append(x.Env, k+"="+conf.Env[k])
+	}
+
+	x.Timeout = defaultTimeout
+	if conf.Timeout != "" {
+		timeout, err := time.ParseDuration(conf.Timeout)
+		if err != nil {
+			return fmt.Errorf("invalid exec timeout %q: %v", conf.Timeout, err)
+		}
+		if timeout <= 0 {
+			return fmt.Errorf("invalid exec timeout %q: must be positive", conf.Timeout)
+		}
+		x.Timeout = timeout
+	}
+
+	return checkMissingExecVars(x)
+}
+
+// Handle handles an event.
+func (x *Exec) Handle(e event.Event) {
+	if err := x.Send(e); err != nil {
+		logger.WithFields(e.LogFields()).Error(err)
+	}
+}
+
+// Send runs the command with the event, returning an error when it fails or
+// times out.
+func (x *Exec) Send(e event.Event) error {
+	var doc interface{} = Payload{V1: event.V1{Event: &e}, Message: e.Message(), Time: time.Now()}
+	if x.PayloadVersion == event.PayloadV2 {
+		doc = e.V2()
+	}
+	payload, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+
+	cmd := osexec.Command(x.Command, x.Args...)
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Env = append(append(os.Environ(), x.Env...), eventEnv(e)...)
+	output := &tail{size: maxErrorOutput}
+	cmd.Stdout = output
+	cmd.Stderr = output
+	setProcessGroup(cmd)
+
+	if err := run(cmd, x.Timeout); err != nil {
+		metrics.Notifications.WithLabelValues("exec", "failure").Inc()
+		return fmt.Errorf("Command %s failed: %v: %s", x.Command, err, bytes.TrimSpace(output.bytes()))
+	}
+
+	metrics.Notifications.WithLabelValues("exec", "success").Inc()
+	logger.WithFields(e.LogFields()).Infof("Event successfully handled by %s", x.Command)
+	return nil
+}
+
+// run runs the command, killing its process group, and so the processes it
+// started, once the timeout is elapsed. The processes having left the group
+// may keep the output open, so run only waits for it for waitDelay.
+func run(cmd *osexec.Cmd, timeout time.Duration) error {
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+		killProcessGroup(cmd)
+		select {
+		case <-done:
+		case <-time.After(waitDelay):
+		}
+		return fmt.Errorf("timed out after %s", timeout)
+	}
+}
+
+// tail keeps the last bytes written to it, up to its size. The output may
+// still be written once run gave up waiting for it.
+type tail struct {
+	size int
+
+	mu  sync.Mutex
+	buf []byte
+}
+
+func (t *tail) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(p) >= t.size {
+		t.buf = append(t.buf[:0], p[len(p)-t.size:]...)
+		return len(p), nil
+	}
+	if drop := len(t.buf) + len(p) - t.size; drop > 0 {
+		t.buf = append(t.buf[:0], t.buf[drop:]...)
+	}
+	t.buf = append(t.buf, p...)
+	return len(p), nil
+}
+
+// bytes returns a copy of the bytes kept.
+func (t *tail) bytes() []byte {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]byte(nil), t.buf...)
+}
+
+// eventEnv returns the environment variables describing the event.
+func eventEnv(e event.Event) []string {
+	return []string{
+		"KW_EVENT_CLUSTER=" + e.Cluster,
+		"KW_EVENT_NAMESPACE=" + e.Namespace,
+		"KW_EVENT_KIND=" + e.Kind,
+		"KW_EVENT_NAME=" + e.Name,
+		"KW_EVENT_REASON=" + e.Reason,
+		"KW_EVENT_SEVERITY=" + e.Severity,
+	}
+}
+
+func checkMissingExecVars(x *Exec) error {
+	if x.Command == "" {
+		return fmt.Errorf(execErrMsg, "Missing command")
+	}
+
+	return nil
+}
@@ -0,0 +1,191 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package exec
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	osexec "os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+)
+
+func TestExecInit(t *testing.T) {
+	x := &Exec{}
+	expectedError := fmt.Errorf(execErrMsg, "Missing command")
+
+	var Tests = []struct {
+		exec config.Exec
+		err  error
+	}{
+		{config.Exec{Command: "/bin/true"}, nil},
+		{config.Exec{}, expectedError},
+		{config.Exec{Command: "/bin/true", Timeout: "0s"}, fmt.Errorf("invalid exec timeout %q: must be positive", "0s")},
+		{config.Exec{Command: "/bin/true", Timeout: "-1s"}, fmt.Errorf("invalid exec timeout %q: must be positive", "-1s")},
+	}
+
+	for _, tt := range Tests {
+		c := &config.Config{}
+		c.Handler.Exec = tt.exec
+		if err := x.Init(c); !reflect.DeepEqual(err, tt.err) {
+			t.Fatalf("Init(): %v", err)
+		}
+	}
+}
+
+func TestExecSend(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kubewatch-exec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	out := filepath.Join(dir, "event.json")
+	x := &Exec{}
+	c := &config.Config{}
+	c.Handler.Exec = config.Exec{
+		Command: "sh",
+		Args:    []string{"-c", `cat > "$OUT" && echo "$KW_EVENT_KIND/$KW_EVENT_NAME $TEAM" >> "$OUT.env"`},
+		Env:     map[string]string{"OUT": out, "TEAM": "payments"},
+	}
+	if err := x.Init(c); err != nil {
+		t.Fatal(err)
+	}
+
+	e := event.Event{Kind: "pod", Name: "web", Namespace: "default", Reason: "Created"}
+	if err := x.Send(e); err != nil {
+		t.Fatalf("Send(): %v", err)
+	}
+
+	data, err := ioutil.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var payload Payload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.Kind != "pod" || payload.Name != "web" || payload.Message != e.Message() {
+		t.Fatalf("payload: %s", data)
+	}
+	env, err := ioutil.ReadFile(out + ".env")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(string(env)); got != "pod/web payments" {
+		t.Fatalf("environment: %q", got)
+	}
+}
+
+func TestExecFailure(t *testing.T) {
+	var Tests = []struct {
+		exec config.Exec
+		err  string
+	}{
+		{config.Exec{Command: "sh", Args: []string{"-c", "echo unreachable >&2; exit 3"}}, "exit status 3: unreachable"},
+		{config.Exec{Command: "sleep", Args: []string{"5"}, Timeout: "100ms"}, "timed out after 100ms"},
+	}
+
+	for _, tt := range Tests {
+		x := &Exec{}
+		c := &config.Config{}
+		c.Handler.Exec = tt.exec
+		if err := x.Init(c); err != nil {
+			t.Fatal(err)
+		}
+		err := x.Send(event.Event{Kind: "pod", Name: "web"})
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Fatalf("Send(): %v, want %q", err, tt.err)
+		}
+	}
+}
+
+func TestExecTimeoutKillsProcessGroup(t *testing.T) {
+	x := &Exec{}
+	c := &config.Config{}
+	// the background sleep keeps the output open after sh is killed
+	c.Handler.Exec = config.Exec{Command: "sh", Args: []string{"-c", "sleep 30 & sleep 30"}, Timeout: "100ms"}
+	if err := x.Init(c); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	err := x.Send(event.Event{Kind: "pod", Name: "web"})
+	if err == nil || !strings.Contains(err.Error(), "timed out after 100ms") {
+		t.Fatalf("Send(): %v, want a timeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("Send() returned after %s, the processes of the command were not killed", elapsed)
+	}
+}
+
+func TestExecTimeoutOutputHeldOpen(t *testing.T) {
+	if _, err := osexec.LookPath("setsid"); err != nil {
+		t.Skip("setsid is not installed")
+	}
+	defer func(d time.Duration) { waitDelay = d }(waitDelay)
+	waitDelay = 100 * time.Millisecond
+
+	x := &Exec{}
+	c := &config.Config{}
+	// the sleep of its own session is not killed and keeps the output open
+	c.Handler.Exec = config.Exec{Command: "sh", Args: []string{"-c", "setsid sleep 3 & sleep 30"}, Timeout: "100ms"}
+	if err := x.Init(c); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	err := x.Send(event.Event{Kind: "pod", Name: "web"})
+	if err == nil || !strings.Contains(err.Error(), "timed out after 100ms") {
+		t.Fatalf("Send(): %v, want a timeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("Send() returned after %s, waiting for the output held open", elapsed)
+	}
+}
+
+func TestTail(t *testing.T) {
+	x := &Exec{}
+	c := &config.Config{}
+	c.Handler.Exec = config.Exec{Command: "sh", Args: []string{"-c", "yes | head -c 1000000; echo unreachable >&2; exit 1"}}
+	if err := x.Init(c); err != nil {
+		t.Fatal(err)
+	}
+	err := x.Send(event.Event{Kind: "pod", Name: "web"})
+	if err == nil || !strings.HasSuffix(err.Error(), "unreachable") || len(err.Error()) > maxErrorOutput+100 {
+		t.Fatalf("Send(): got an error of %d bytes, want the last %d bytes of the output", len(err.Error()), maxErrorOutput)
+	}
+
+	w := &tail{size: 4}
+	for _, s := range []string{"ab", "cde", "", "f", "ghijkl"} {
+		w.Write([]byte(s))
+	}
+	if string(w.buf) != "ijkl" {
+		t.Fatalf("tail: got %q, want %q", w.buf, "ijkl")
+	}
+	w.Write([]byte("m"))
+	if string(w.buf) != "jklm" {
+		t.Fatalf("tail: got %q, want %q", w.buf, "jklm")
+	}
+}
@@ -0,0 +1,53 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handlers
+
+import (
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+	"github.com/google/uuid"
+)
+
+// External implements the Handler interface, assigning the ID of each event
+// and setting the cluster name and the external labels of the config on it
+// before passing it to the wrapped handler
+type External struct {
+	wrapper
+	Cluster string
+	Labels  map[string]string
+}
+
+// Handle handles an event. The cluster set when watching several clusters
+// is kept, and so is the ID of an event having one.
+func (x *External) Handle(e event.Event) {
+	if e.ID == "" {
+		e.ID = uuid.New().String()
+	}
+	if e.Cluster == "" {
+		e.Cluster = x.Cluster
+	}
+	if len(x.Labels) > 0 {
+		labels := make(map[string]string, len(x.Labels)+len(e.ExternalLabels))
+		for k, v := range x.Labels {
+			labels[k] = v
+		}
+		for k, v := range e.ExternalLabels {
+			labels[k] = v
+		}
+		e.ExternalLabels = labels
+	}
+	x.Handler.Handle(e)
+}
@@ -0,0 +1,142 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handlers
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+	"github.com/bitnami-labs/kubewatch/pkg/flap"
+	"github.com/bitnami-labs/kubewatch/pkg/tracing"
+	"github.com/sirupsen/logrus"
+)
+
+// Reasons of the notifications of the flapping objects.
+const (
+	Flapping   = "Flapping"
+	Stabilized = "Stabilized"
+)
+
+// flapCheckInterval is how often the flapping objects are checked for
+// stability.
+const flapCheckInterval = 30 * time.Second
+
+// Flap implements the Handler interface, suppressing the events of the
+// objects flapping: the event making an object flapping is replaced by a
+// Flapping notification, the next ones are dropped, and a Stabilized
+// notification is sent once the object has no events for StableAfter.
+type Flap struct {
+	wrapper
+	Threshold   int
+	Window      time.Duration
+	StableAfter time.Duration
+
+	once     sync.Once
+	detector *flap.Detector
+	// last are the last events of the flapping objects by key
+	mu   sync.Mutex
+	last map[string]event.Event
+}
+
+// Handle handles an event. The events not about an object, e.g. the
+// digests, are passed as is.
+func (f *Flap) Handle(e event.Event) {
+	if e.Name == "" || e.Kind == event.DigestKind || e.Kind == event.ReconciliationKind {
+		f.Handler.Handle(e)
+		return
+	}
+	f.init()
+
+	key := e.Cluster + "/" + e.Kind + "/" + e.Namespace + "/" + e.Name
+	o := f.detector.Observe(key, time.Now())
+	if !o.Flapping {
+		f.Handler.Handle(e)
+		return
+	}
+
+	f.mu.Lock()
+	f.last[key] = e
+	f.mu.Unlock()
+	if !o.Started {
+		logrus.WithFields(e.LogFields()).Debug("Event of a flapping object suppressed")
+		tracing.AddEvent(e, "suppressed as flapping")
+		return
+	}
+	logrus.WithFields(e.LogFields()).Infof("Object flapping, %d events in %s", o.Count, f.Window)
+	f.Handler.Handle(flapEvent(e, Flapping, "Warning", fmt.Sprintf("%d events in %s, the next ones are suppressed until it is stable for %s", o.Count, f.Window, f.StableAfter)))
+}
+
+// Run checks the flapping objects for stability every flapCheckInterval,
+// while running the background work of the wrapped handler.
+func (f *Flap) Run(stopCh <-chan struct{}) {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		Run(f.Handler, stopCh)
+	}()
+
+	ticker := time.NewTicker(flapCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stopCh:
+			<-done
+			return
+		case <-ticker.C:
+			f.check(time.Now())
+		}
+	}
+}
+
+// check notifies the flapping objects stable at now.
+func (f *Flap) check(now time.Time) {
+	f.init()
+	for _, s := range f.detector.Stable(now) {
+		f.mu.Lock()
+		e, ok := f.last[s.Key]
+		delete(f.last, s.Key)
+		f.mu.Unlock()
+		if !ok {
+			continue
+		}
+		details := fmt.Sprintf("Stable for %s after flapping since %s, %d events suppressed", f.StableAfter, s.Since.UTC().Format(time.RFC3339), s.Suppressed)
+		f.Handler.Handle(flapEvent(e, Stabilized, "Normal", details))
+	}
+}
+
+func (f *Flap) init() {
+	f.once.Do(func() {
+		f.detector = flap.New(f.Threshold, f.Window, f.StableAfter)
+		f.last = map[string]event.Event{}
+	})
+}
+
+// flapEvent returns the notification of the flapping of the object of the
+// last event.
+func flapEvent(e event.Event, reason, status, details string) event.Event {
+	e.Reason = reason
+	e.Status = status
+	e.Details = details
+	e.Severity = ""
+	e.Operation = ""
+	e.Diff = nil
+	e.Images = nil
+	e.Timestamp = time.Now()
+	return e
+}
@@ -18,18 +18,21 @@ package flock
 
 import (
 	"fmt"
-	"log"
 	"os"
 
 	"bytes"
 	"encoding/json"
 	"net/http"
-	"time"
 
 	"github.com/bitnami-labs/kubewatch/config"
 	"github.com/bitnami-labs/kubewatch/pkg/event"
+	"github.com/bitnami-labs/kubewatch/pkg/utils"
+	"github.com/sirupsen/logrus"
 )
 
+// logger logs the notifications sent by the handler.
+var logger = logrus.WithField("handler", "flock")
+
 var flockColors = map[string]string{
 	"Normal":  "#00FF00",
 	"Warning": "#FFFF00",
@@ -51,7 +54,8 @@ Command line flags will override environment variables
 // Flock handler implements handler.Handler interface,
 // Notify event to Flock channel
 type Flock struct {
-	Url string
+	Url    string
+	client *http.Client
 }
 
 // FlockMessage struct
@@ -82,6 +86,11 @@ func (f *Flock) Init(c *config.Config) error {
 	}
 
 	f.Url = url
+	client, err := utils.HTTPClient("flock", c.Handler.Flock.TLS)
+	if err != nil {
+		return err
+	}
+	f.client = client
 
 	return checkMissingFlockVars(f)
 }
@@ -90,13 +99,13 @@ func (f *Flock) Init(c *config.Config) error {
 func (f *Flock) Handle(e event.Event) {
 	flockMessage := prepareFlockMessage(e, f)
 
-	err := postMessage(f.Url, flockMessage)
+	err := postMessage(f.client, f.Url, flockMessage)
 	if err != nil {
-		log.Printf("%s\n", err)
+		logger.WithFields(e.LogFields()).Error(err)
 		return
 	}
 
-	log.Printf("Message successfully sent to channel %s at %s", f.Url, time.Now())
+	logger.WithFields(e.LogFields()).Infof("Message successfully sent to channel %s", f.Url)
 }
 
 func checkMissingFlockVars(s *Flock) error {
@@ -120,7 +129,7 @@ func prepareFlockMessage(e event.Event, f *Flock) *FlockMessage {
 	}
 }
 
-func postMessage(url string, flockMessage *FlockMessage) error {
+func postMessage(client *http.Client, url string, flockMessage *FlockMessage) error {
 	message, err := json.Marshal(flockMessage)
 	if err != nil {
 		return err
@@ -132,7 +141,6 @@ func postMessage(url string, flockMessage *FlockMessage) error {
 	}
 	req.Header.Add("Content-Type", "application/json")
 
-	client := &http.Client{}
 	_, err = client.Do(req)
 	if err != nil {
 		return err
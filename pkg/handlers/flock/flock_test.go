@@ -43,4 +43,10 @@ func TestFlockInit(t *testing.T) {
 			t.Fatalf("Init(): %v", err)
 		}
 	}
+
+	c := &config.Config{}
+	c.Handler.Flock = config.Flock{Url: "foo", TLS: config.TLS{CAFile: "/nonexistent"}}
+	if err := s.Init(c); err == nil {
+		t.Fatal("Init(): expected an error for a missing CA file")
+	}
 }
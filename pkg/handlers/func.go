@@ -0,0 +1,36 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handlers
+
+import (
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+)
+
+// Func implements the Handler interface, calling the function with each
+// event; it lets the programs embedding kubewatch handle the events
+type Func func(e event.Event)
+
+// Init does nothing.
+func (f Func) Init(c *config.Config) error {
+	return nil
+}
+
+// Handle handles an event.
+func (f Func) Handle(e event.Event) {
+	f(e)
+}
@@ -0,0 +1,306 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package github
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+	"github.com/bitnami-labs/kubewatch/pkg/metrics"
+	"github.com/bitnami-labs/kubewatch/pkg/utils"
+	"github.com/sirupsen/logrus"
+	apps_v1 "k8s.io/api/apps/v1"
+)
+
+// logger logs the notifications sent by the handler.
+var logger = logrus.WithField("handler", "github")
+
+const (
+	defaultURL       = "https://api.github.com"
+	defaultEventType = "kubewatch"
+	requestTimeout   = 10 * time.Second
+
+	// RepositoryAnnotation is the annotation of the deployments naming the
+	// repository of their GitHub deployment, as owner/repo.
+	RepositoryAnnotation = "kubewatch.io/github-repository"
+	// DeploymentIDAnnotation is the annotation of the deployments holding the
+	// ID of their GitHub deployment.
+	DeploymentIDAnnotation = "kubewatch.io/github-deployment-id"
+)
+
+var githubErrMsg = `
+%s
+
+You need to set the GitHub token, or the app settings,
+and the repository or deploymentStatuses,
+using "--token/-t" and "--repository/-r", or using environment variables:
+
+export KW_GITHUB_TOKEN=github_token
+export KW_GITHUB_REPOSITORY=owner/repo
+
+Command line flags will override environment variables
+
+`
+
+// GitHub handler implements handler.Handler interface,
+// Notify event as repository_dispatch events and deployment statuses
+type GitHub struct {
+	Url                string
+	Token              string
+	Repository         string
+	EventType          string
+	DeploymentStatuses bool
+
+	app    *appToken
+	client *http.Client
+
+	// posted holds the last status posted for each GitHub deployment,
+	// which is only posted once
+	mu     sync.Mutex
+	posted map[string]string
+}
+
+// Dispatch is the request body of the repository_dispatch API
+// The Documentation is in https://docs.github.com/en/rest/reference/repos#create-a-repository-dispatch-event
+type Dispatch struct {
+	EventType     string        `json:"event_type"`
+	ClientPayload ClientPayload `json:"client_payload"`
+}
+
+// ClientPayload describes the event to the workflows, in less than the 10
+// top-level properties allowed
+type ClientPayload struct {
+	Cluster        string            `json:"cluster,omitempty"`
+	Namespace      string            `json:"namespace"`
+	Kind           string            `json:"kind"`
+	Name           string            `json:"name"`
+	Reason         string            `json:"reason"`
+	Severity       string            `json:"severity,omitempty"`
+	Message        string            `json:"message"`
+	Labels         map[string]string `json:"labels,omitempty"`
+	ExternalLabels map[string]string `json:"externalLabels,omitempty"`
+	Metadata       map[string]string `json:"metadata,omitempty"`
+}
+
+// DeploymentStatus is the request body of the create deployment status API
+type DeploymentStatus struct {
+	State       string `json:"state"`
+	Description string `json:"description,omitempty"`
+}
+
+// Init prepares GitHub configuration
+func (g *GitHub) Init(c *config.Config) error {
+	conf := c.Handler.GitHub
+	url := conf.Url
+	token := conf.Token
+	repository := conf.Repository
+
+	if url == "" {
+		url = defaultURL
+	}
+
+	if token == "" {
+		token = os.Getenv("KW_GITHUB_TOKEN")
+	}
+
+	if repository == "" {
+		repository = os.Getenv("KW_GITHUB_REPOSITORY")
+	}
+
+	g.Url = strings.TrimSuffix(url, "/")
+	g.Token = token
+	g.Repository = repository
+	g.DeploymentStatuses = conf.DeploymentStatuses
+	g.posted = map[string]string{}
+
+	g.EventType = conf.EventType
+	if g.EventType == "" {
+		g.EventType = defaultEventType
+	}
+
+	client, err := utils.HTTPClient("github", conf.TLS)
+	if err != nil {
+		return err
+	}
+	client.Timeout = requestTimeout
+	g.client = client
+
+	g.app = nil
+	if conf.App.ID != 0 {
+		if conf.App.InstallationID == 0 || conf.App.PrivateKeyFile == "" {
+			return fmt.Errorf("the github app needs an installationID and a privateKeyFile")
+		}
+		data, err := ioutil.ReadFile(conf.App.PrivateKeyFile)
+		if err != nil {
+			return err
+		}
+		key, err := parsePrivateKey(data)
+		if err != nil {
+			return fmt.Errorf("invalid github app private key %s: %v", conf.App.PrivateKeyFile, err)
+		}
+		g.app = &appToken{url: g.Url, appID: conf.App.ID, installationID: conf.App.InstallationID, key: key, client: client}
+	}
+
+	return checkMissingGitHubVars(g)
+}
+
+// Handle handles an event.
+func (g *GitHub) Handle(e event.Event) {
+	if err := g.Send(e); err != nil {
+		logger.WithFields(e.LogFields()).Error(err)
+	}
+}
+
+// Send fires a repository_dispatch event for the event, and posts the
+// status of the GitHub deployment of a rolled out deployment, returning an
+// error when GitHub doesn't accept them.
+func (g *GitHub) Send(e event.Event) error {
+	if g.Repository != "" {
+		body := &Dispatch{EventType: g.EventType, ClientPayload: ClientPayload{
+			Cluster:        e.Cluster,
+			Namespace:      e.Namespace,
+			Kind:           e.Kind,
+			Name:           e.Name,
+			Reason:         e.Reason,
+			Severity:       e.Severity,
+			Message:        e.Message(),
+			Labels:         e.Labels,
+			ExternalLabels: e.ExternalLabels,
+			Metadata:       e.Metadata,
+		}}
+		if err := g.post("/repos/"+g.Repository+"/dispatches", body); err != nil {
+			metrics.Notifications.WithLabelValues("github", "failure").Inc()
+			return err
+		}
+		metrics.Notifications.WithLabelValues("github", "success").Inc()
+		logger.WithFields(e.LogFields()).Infof("repository_dispatch event successfully sent to %s", g.Repository)
+	}
+
+	if !g.DeploymentStatuses {
+		return nil
+	}
+	repository, id, state := deploymentStatus(e)
+	if state == "" {
+		return nil
+	}
+	key := repository + "/" + id
+	g.mu.Lock()
+	posted := g.posted[key] == state
+	g.mu.Unlock()
+	if posted {
+		return nil
+	}
+
+	status := &DeploymentStatus{State: state, Description: e.Message()}
+	if len(status.Description) > 140 {
+		status.Description = status.Description[:137] + "..."
+	}
+	if err := g.post("/repos/"+repository+"/deployments/"+id+"/statuses", status); err != nil {
+		metrics.Notifications.WithLabelValues("github", "failure").Inc()
+		return err
+	}
+	g.mu.Lock()
+	g.posted[key] = state
+	g.mu.Unlock()
+	metrics.Notifications.WithLabelValues("github", "success").Inc()
+	logger.WithFields(e.LogFields()).Infof("Status %s of deployment %s successfully posted to %s", state, id, repository)
+	return nil
+}
+
+func checkMissingGitHubVars(g *GitHub) error {
+	if (g.Token == "" && g.app == nil) || (g.Repository == "" && !g.DeploymentStatuses) {
+		return fmt.Errorf(githubErrMsg, "Missing GitHub token or repository")
+	}
+
+	return nil
+}
+
+// deploymentStatus returns the GitHub deployment of the annotated
+// deployment of the event, and its state once the rollout is over: success
+// when all the replicas are updated and available, failure when it exceeded
+// its progress deadline.
+func deploymentStatus(e event.Event) (repository, id, state string) {
+	d, ok := e.Object.(*apps_v1.Deployment)
+	if !ok || e.Reason == "Deleted" {
+		return "", "", ""
+	}
+	repository, id = d.Annotations[RepositoryAnnotation], d.Annotations[DeploymentIDAnnotation]
+	if repository == "" || id == "" {
+		return "", "", ""
+	}
+
+	for _, c := range d.Status.Conditions {
+		if c.Type == apps_v1.DeploymentProgressing && c.Reason == "ProgressDeadlineExceeded" {
+			return repository, id, "failure"
+		}
+	}
+	if d.Status.ObservedGeneration < d.Generation {
+		return repository, id, ""
+	}
+	replicas := int32(1)
+	if d.Spec.Replicas != nil {
+		replicas = *d.Spec.Replicas
+	}
+	if d.Status.UpdatedReplicas == replicas && d.Status.Replicas == replicas && d.Status.AvailableReplicas == replicas {
+		return repository, id, "success"
+	}
+	return repository, id, ""
+}
+
+func (g *GitHub) post(path string, body interface{}) error {
+	message, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	token := g.Token
+	if g.app != nil {
+		if token, err = g.app.Token(); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequest("POST", g.Url+path, bytes.NewBuffer(message))
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("Accept", "application/vnd.github.v3+json")
+	req.Header.Add("Authorization", "token "+token)
+
+	res, err := g.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode/100 != 2 {
+		body, _ := ioutil.ReadAll(res.Body)
+		return fmt.Errorf("Failed sending request to GitHub. GitHub http response: %s, %s", res.Status, string(body))
+	}
+
+	return nil
+}
@@ -0,0 +1,228 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package github
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+	apps_v1 "k8s.io/api/apps/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGitHubInit(t *testing.T) {
+	g := &GitHub{}
+	expectedError := fmt.Errorf(githubErrMsg, "Missing GitHub token or repository")
+
+	var Tests = []struct {
+		github config.GitHub
+		err    error
+	}{
+		{config.GitHub{Token: "foo", Repository: "owner/repo"}, nil},
+		{config.GitHub{Token: "foo", DeploymentStatuses: true}, nil},
+		{config.GitHub{Token: "foo"}, expectedError},
+		{config.GitHub{Repository: "owner/repo"}, expectedError},
+		{config.GitHub{}, expectedError},
+		{config.GitHub{Repository: "owner/repo", App: config.GitHubApp{ID: 1}}, fmt.Errorf("the github app needs an installationID and a privateKeyFile")},
+	}
+
+	for _, tt := range Tests {
+		c := &config.Config{}
+		c.Handler.GitHub = tt.github
+		if err := g.Init(c); !reflect.DeepEqual(err, tt.err) {
+			t.Fatalf("Init(): %v", err)
+		}
+	}
+
+	c := &config.Config{}
+	c.Handler.GitHub = config.GitHub{Token: "foo", Repository: "owner/repo", TLS: config.TLS{CAFile: "/nonexistent"}}
+	if err := g.Init(c); err == nil {
+		t.Fatal("Init(): expected an error for a missing CA file")
+	}
+}
+
+func TestGitHubSend(t *testing.T) {
+	var (
+		dispatches []Dispatch
+		statuses   []DeploymentStatus
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "token foo" {
+			t.Errorf("unexpected authorization %q", got)
+		}
+		switch r.URL.Path {
+		case "/repos/owner/repo/dispatches":
+			var d Dispatch
+			if err := json.NewDecoder(r.Body).Decode(&d); err != nil {
+				t.Error(err)
+			}
+			dispatches = append(dispatches, d)
+			w.WriteHeader(http.StatusNoContent)
+		case "/repos/owner/app/deployments/42/statuses":
+			var s DeploymentStatus
+			if err := json.NewDecoder(r.Body).Decode(&s); err != nil {
+				t.Error(err)
+			}
+			statuses = append(statuses, s)
+			w.WriteHeader(http.StatusCreated)
+		default:
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+	}))
+	defer ts.Close()
+
+	c := &config.Config{}
+	c.Handler.GitHub = config.GitHub{Url: ts.URL, Token: "foo", Repository: "owner/repo", DeploymentStatuses: true}
+	g := &GitHub{}
+	if err := g.Init(c); err != nil {
+		t.Fatal(err)
+	}
+
+	replicas := int32(2)
+	d := &apps_v1.Deployment{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:        "api",
+			Namespace:   "shop",
+			Generation:  3,
+			Annotations: map[string]string{RepositoryAnnotation: "owner/app", DeploymentIDAnnotation: "42"},
+		},
+		Spec:   apps_v1.DeploymentSpec{Replicas: &replicas},
+		Status: apps_v1.DeploymentStatus{ObservedGeneration: 3, Replicas: 3, UpdatedReplicas: 1, AvailableReplicas: 2},
+	}
+	e := event.Event{Cluster: "prod", Namespace: "shop", Kind: "deployment", Name: "api", Reason: "Updated", Object: d}
+
+	if err := g.Send(e); err != nil {
+		t.Fatal(err)
+	}
+	want := Dispatch{EventType: "kubewatch", ClientPayload: ClientPayload{
+		Cluster: "prod", Namespace: "shop", Kind: "deployment", Name: "api", Reason: "Updated", Message: e.Message(),
+	}}
+	if len(dispatches) != 1 || !reflect.DeepEqual(dispatches[0], want) {
+		t.Fatalf("got dispatches %+v, want %+v", dispatches, want)
+	}
+	if len(statuses) != 0 {
+		t.Fatalf("got statuses %+v during the rollout", statuses)
+	}
+
+	// The rollout is over: the success is posted once.
+	d.Status = apps_v1.DeploymentStatus{ObservedGeneration: 3, Replicas: 2, UpdatedReplicas: 2, AvailableReplicas: 2}
+	for i := 0; i < 2; i++ {
+		if err := g.Send(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(statuses) != 1 || statuses[0].State != "success" {
+		t.Fatalf("got statuses %+v, want a single success", statuses)
+	}
+}
+
+func TestDeploymentStatus(t *testing.T) {
+	annotations := map[string]string{RepositoryAnnotation: "owner/app", DeploymentIDAnnotation: "42"}
+	var Tests = []struct {
+		meta   meta_v1.ObjectMeta
+		status apps_v1.DeploymentStatus
+		state  string
+	}{
+		{meta_v1.ObjectMeta{Annotations: annotations}, apps_v1.DeploymentStatus{Replicas: 1, UpdatedReplicas: 1, AvailableReplicas: 1}, "success"},
+		{meta_v1.ObjectMeta{}, apps_v1.DeploymentStatus{Replicas: 1, UpdatedReplicas: 1, AvailableReplicas: 1}, ""},
+		{meta_v1.ObjectMeta{Annotations: annotations, Generation: 2}, apps_v1.DeploymentStatus{ObservedGeneration: 1, Replicas: 1, UpdatedReplicas: 1, AvailableReplicas: 1}, ""},
+		{meta_v1.ObjectMeta{Annotations: annotations}, apps_v1.DeploymentStatus{Replicas: 1, AvailableReplicas: 1, Conditions: []apps_v1.DeploymentCondition{
+			{Type: apps_v1.DeploymentProgressing, Reason: "ProgressDeadlineExceeded"},
+		}}, "failure"},
+	}
+
+	for _, tt := range Tests {
+		e := event.Event{Reason: "Updated", Object: &apps_v1.Deployment{ObjectMeta: tt.meta, Status: tt.status}}
+		if _, _, state := deploymentStatus(e); state != tt.state {
+			t.Errorf("deploymentStatus(%+v) = %q, want %q", tt.status, state, tt.state)
+		}
+	}
+}
+
+func TestGitHubApp(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, err := ioutil.TempDir("", "github")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	keyFile := filepath.Join(dir, "app.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	if err := ioutil.WriteFile(keyFile, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tokens := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/app/installations/7/access_tokens":
+			parts := strings.Split(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "), ".")
+			if len(parts) != 3 {
+				t.Fatalf("unexpected authorization %q", r.Header.Get("Authorization"))
+			}
+			claims, _ := base64.RawURLEncoding.DecodeString(parts[1])
+			var c map[string]int64
+			if err := json.Unmarshal(claims, &c); err != nil || c["iss"] != 1 {
+				t.Errorf("unexpected claims %s", claims)
+			}
+			tokens++
+			json.NewEncoder(w).Encode(installationToken{Token: "installation", ExpiresAt: time.Now().Add(time.Hour)})
+		case "/repos/owner/repo/dispatches":
+			if got := r.Header.Get("Authorization"); got != "token installation" {
+				t.Errorf("unexpected authorization %q", got)
+			}
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+	}))
+	defer ts.Close()
+
+	c := &config.Config{}
+	c.Handler.GitHub = config.GitHub{Url: ts.URL, Repository: "owner/repo", App: config.GitHubApp{ID: 1, InstallationID: 7, PrivateKeyFile: keyFile}}
+	g := &GitHub{}
+	if err := g.Init(c); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := g.Send(event.Event{Kind: "pod", Name: "foo", Reason: "Created"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if tokens != 1 {
+		t.Fatalf("got %d installation tokens, want 1", tokens)
+	}
+}
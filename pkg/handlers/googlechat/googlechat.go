@@ -0,0 +1,202 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package googlechat
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+	"github.com/bitnami-labs/kubewatch/pkg/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// logger logs the notifications sent by the handler.
+var logger = logrus.WithField("handler", "googlechat")
+
+const iconUrl = "https://raw.githubusercontent.com/kubernetes/kubernetes/master/logo/logo.png"
+
+var googlechatErrMsg = `
+%s
+
+You need to set the Google Chat webhook url for Google Chat notify,
+using "--url/-u", or using environment variables:
+
+export KW_GOOGLECHAT_URL=googlechat_webhook_url
+
+Command line flags will override environment variables
+
+`
+
+// GoogleChat handler implements handler.Handler interface,
+// Notify event to a Google Chat space
+type GoogleChat struct {
+	Url    string
+	client *http.Client
+}
+
+// GoogleChatMessage is a card message
+type GoogleChatMessage struct {
+	Text  string `json:"text"`
+	Cards []Card `json:"cards"`
+}
+
+// Card of a message
+type Card struct {
+	Header   CardHeader `json:"header"`
+	Sections []Section  `json:"sections"`
+}
+
+// CardHeader is the header of a card
+type CardHeader struct {
+	Title    string `json:"title"`
+	Subtitle string `json:"subtitle,omitempty"`
+	ImageUrl string `json:"imageUrl"`
+}
+
+// Section of a card
+type Section struct {
+	Widgets []Widget `json:"widgets"`
+}
+
+// Widget of a section
+type Widget struct {
+	KeyValue *KeyValue `json:"keyValue,omitempty"`
+}
+
+// KeyValue widget
+type KeyValue struct {
+	TopLabel         string `json:"topLabel"`
+	Content          string `json:"content"`
+	ContentMultiline bool   `json:"contentMultiline,omitempty"`
+}
+
+// Init prepares Google Chat configuration
+func (g *GoogleChat) Init(c *config.Config) error {
+	url := c.Handler.GoogleChat.Url
+
+	if url == "" {
+		url = os.Getenv("KW_GOOGLECHAT_URL")
+	}
+
+	g.Url = url
+	client, err := utils.HTTPClient("googlechat", c.Handler.GoogleChat.TLS)
+	if err != nil {
+		return err
+	}
+	g.client = client
+
+	return checkMissingGoogleChatVars(g)
+}
+
+// Handle handles an event.
+func (g *GoogleChat) Handle(e event.Event) {
+	if err := g.Send(e); err != nil {
+		logger.WithFields(e.LogFields()).Error(err)
+	}
+}
+
+// Send sends an event, returning an error when it is not delivered.
+func (g *GoogleChat) Send(e event.Event) error {
+	googlechatMessage := prepareGoogleChatMessage(e, time.Now())
+
+	if err := postMessage(g.client, g.Url, googlechatMessage); err != nil {
+		return err
+	}
+
+	logger.WithFields(e.LogFields()).Info("Message successfully sent to Google Chat")
+	return nil
+}
+
+func checkMissingGoogleChatVars(g *GoogleChat) error {
+	if g.Url == "" {
+		return fmt.Errorf(googlechatErrMsg, "Missing Google Chat webhook url")
+	}
+
+	return nil
+}
+
+func keyValue(label, content string) Widget {
+	return Widget{KeyValue: &KeyValue{TopLabel: label, Content: content}}
+}
+
+func prepareGoogleChatMessage(e event.Event, now time.Time) *GoogleChatMessage {
+	header := CardHeader{
+		Title:    fmt.Sprintf("%s %s", e.Kind, e.Name),
+		ImageUrl: iconUrl,
+	}
+	if e.Namespace != "" {
+		header.Subtitle = "namespace " + e.Namespace
+	}
+
+	widgets := []Widget{keyValue("Reason", e.Reason)}
+	if e.Severity != "" {
+		widgets = append(widgets, keyValue("Severity", e.Severity))
+	}
+	if e.Details != "" {
+		w := keyValue("Details", e.Details)
+		w.KeyValue.ContentMultiline = true
+		widgets = append(widgets, w)
+	}
+	widgets = append(widgets, keyValue("Time", now.UTC().Format(time.RFC3339)))
+
+	sections := []Section{{Widgets: widgets}}
+	if len(e.Diff) > 0 {
+		var changes []Widget
+		for _, c := range e.Diff {
+			changes = append(changes, keyValue(c.Path, fmt.Sprintf("%s → %s", c.Old, c.New)))
+		}
+		sections = append(sections, Section{Widgets: changes})
+	}
+
+	return &GoogleChatMessage{
+		Text:  e.Message(),
+		Cards: []Card{{Header: header, Sections: sections}},
+	}
+}
+
+func postMessage(client *http.Client, url string, googlechatMessage *GoogleChatMessage) error {
+	message, err := json.Marshal(googlechatMessage)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(message))
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Content-Type", "application/json; charset=UTF-8")
+
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(res.Body)
+		return fmt.Errorf("Failed posting to Google Chat. Google Chat http response: %s, %s", res.Status, string(body))
+	}
+
+	return nil
+}
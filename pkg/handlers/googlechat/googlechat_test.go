@@ -0,0 +1,97 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package googlechat
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+)
+
+func TestGoogleChatInit(t *testing.T) {
+	s := &GoogleChat{}
+	expectedError := fmt.Errorf(googlechatErrMsg, "Missing Google Chat webhook url")
+
+	var Tests = []struct {
+		googlechat config.GoogleChat
+		err        error
+	}{
+		{config.GoogleChat{Url: "foo"}, nil},
+		{config.GoogleChat{}, expectedError},
+	}
+
+	for _, tt := range Tests {
+		c := &config.Config{}
+		c.Handler.GoogleChat = tt.googlechat
+		if err := s.Init(c); !reflect.DeepEqual(err, tt.err) {
+			t.Fatalf("Init(): %v", err)
+		}
+	}
+
+	c := &config.Config{}
+	c.Handler.GoogleChat = config.GoogleChat{Url: "foo", TLS: config.TLS{CAFile: "/nonexistent"}}
+	if err := s.Init(c); err == nil {
+		t.Fatal("Init(): expected an error for a missing CA file")
+	}
+}
+
+func TestPrepareGoogleChatMessage(t *testing.T) {
+	now := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	e := event.Event{Kind: "deployment", Name: "web", Namespace: "prod", Reason: "Updated",
+		Diff: []event.Change{{Path: "spec.replicas", Old: "1", New: "2"}}}
+
+	card := prepareGoogleChatMessage(e, now).Cards[0]
+	if card.Header.Title != "deployment web" || card.Header.Subtitle != "namespace prod" {
+		t.Fatalf("header: got %+v", card.Header)
+	}
+	want := []Widget{keyValue("Reason", "Updated"), keyValue("Time", "2020-06-01T12:00:00Z")}
+	if !reflect.DeepEqual(card.Sections[0].Widgets, want) {
+		t.Fatalf("widgets: got %+v", card.Sections[0].Widgets)
+	}
+	if len(card.Sections) != 2 || card.Sections[1].Widgets[0].KeyValue.Content != "1 → 2" {
+		t.Fatalf("diff section: got %+v", card.Sections)
+	}
+}
+
+func TestGoogleChatHandle(t *testing.T) {
+	var got GoogleChatMessage
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer ts.Close()
+
+	c := &config.Config{}
+	c.Handler.GoogleChat = config.GoogleChat{Url: ts.URL}
+	s := &GoogleChat{}
+	if err := s.Init(c); err != nil {
+		t.Fatal(err)
+	}
+	s.Handle(event.Event{Kind: "pod", Name: "web", Reason: "Created"})
+
+	if len(got.Cards) != 1 || got.Cards[0].Header.Title != "pod web" {
+		t.Fatalf("cards: got %+v", got.Cards)
+	}
+}
@@ -0,0 +1,135 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handlers
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+	"github.com/bitnami-labs/kubewatch/pkg/filter"
+	"github.com/bitnami-labs/kubewatch/pkg/schedule"
+	"github.com/bitnami-labs/kubewatch/pkg/tracing"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Instance is an initialized handler which only receives the events
+// matching its filter.
+type Instance struct {
+	Name    string
+	Handler Handler
+	Filter  config.Filter
+}
+
+// Group implements the Handler interface,
+// dispatching each event to the matching instances selected by the routes
+type Group struct {
+	Instances []Instance
+	Routes    []config.Route
+	// RouteQuietHours are the parsed quiet hours of the routes, in order.
+	RouteQuietHours []*schedule.QuietHours
+}
+
+// Init does nothing, the instances are initialized individually
+// before being added to the group.
+func (g *Group) Init(c *config.Config) error {
+	return nil
+}
+
+// Handle handles an event.
+func (g *Group) Handle(e event.Event) {
+	matched := g.match(e)
+	names := make([]string, len(matched))
+	for i, instance := range matched {
+		names[i] = instance.Name
+	}
+	tracing.AddEvent(e, "routed", attribute.StringSlice("kubewatch.handlers", names))
+	logrus.WithFields(e.LogFields()).WithField("handlers", names).Debug("Event routed")
+	for _, i := range matched {
+		i.Handler.Handle(e)
+	}
+}
+
+// Routed returns the names of the instances the event is dispatched to, in
+// order.
+func (g *Group) Routed(e event.Event) []string {
+	var names []string
+	for _, i := range g.match(e) {
+		names = append(names, i.Name)
+	}
+	return names
+}
+
+// match returns the instances selected by the routes whose filter matches
+// the event.
+func (g *Group) match(e event.Event) []Instance {
+	targets := g.route(e)
+	var matched []Instance
+	for _, i := range g.Instances {
+		if targets != nil && !targets[i.Name] {
+			continue
+		}
+		if filter.Match(i.Filter, e) {
+			matched = append(matched, i)
+		}
+	}
+	return matched
+}
+
+// Run runs the background work of the instances.
+func (g *Group) Run(stopCh <-chan struct{}) {
+	var wg sync.WaitGroup
+	for _, i := range g.Instances {
+		wg.Add(1)
+		go func(h Handler) {
+			defer wg.Done()
+			Run(h, stopCh)
+		}(i.Handler)
+	}
+	wg.Wait()
+}
+
+// route returns the names of the instances selected by the routing rules
+// for the given event, or nil when no routes are configured.
+func (g *Group) route(e event.Event) map[string]bool {
+	if len(g.Routes) == 0 {
+		return nil
+	}
+	targets := map[string]bool{}
+	now := time.Now()
+	for i, r := range g.Routes {
+		if !filter.Match(r.Filter, e) {
+			continue
+		}
+		if i < len(g.RouteQuietHours) && g.RouteQuietHours[i].Suppresses(e, now) {
+			logrus.WithFields(e.LogFields()).Debugf("Event suppressed by the quiet hours of route %d", i)
+			if !r.Continue {
+				break
+			}
+			continue
+		}
+		for _, name := range r.Handlers {
+			targets[name] = true
+		}
+		if !r.Continue {
+			break
+		}
+	}
+	return targets
+}
@@ -0,0 +1,223 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	kubewatchv1 "github.com/bitnami-labs/kubewatch/api/kubewatch/v1"
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+	"github.com/bitnami-labs/kubewatch/pkg/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// logger logs the notifications sent by the handler.
+var logger = logrus.WithField("handler", "grpc")
+
+// ackTimeout is the time the server has to acknowledge an event.
+const ackTimeout = 10 * time.Second
+
+var grpcErrMsg = `
+%s
+
+You need to set the address of the gRPC EventService server,
+using "--address/-a", or using environment variables:
+
+export KW_GRPC_ADDRESS=host:port
+export KW_GRPC_TOKEN=token (optional)
+
+Command line flags will override environment variables
+
+`
+
+// GRPC handler implements handler.Handler interface,
+// Stream events to a kubewatch.v1.EventService server
+type GRPC struct {
+	Address string
+	Token   string
+
+	conn   *grpc.ClientConn
+	client kubewatchv1.EventServiceClient
+
+	// mu guards the stream, which is opened by the first event sent
+	mu     sync.Mutex
+	stream kubewatchv1.EventService_PublishClient
+	cancel context.CancelFunc
+}
+
+// Init prepares gRPC configuration
+func (g *GRPC) Init(c *config.Config) error {
+	conf := c.Handler.GRPC
+	address := conf.Address
+	token := conf.Token
+
+	if address == "" {
+		address = os.Getenv("KW_GRPC_ADDRESS")
+	}
+
+	if token == "" {
+		token = os.Getenv("KW_GRPC_TOKEN")
+	}
+
+	g.Address = address
+	g.Token = token
+
+	if err := checkMissingGRPCVars(g); err != nil {
+		return err
+	}
+
+	opts := []grpc.DialOption{grpc.WithInsecure()}
+	if utils.TLSEnabled(conf.TLS) {
+		tlsConfig, err := utils.TLSConfig(conf.TLS)
+		if err != nil {
+			return err
+		}
+		opts = []grpc.DialOption{grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig))}
+	}
+
+	// connects in the background, reconnecting as needed
+	conn, err := grpc.Dial(g.Address, opts...)
+	if err != nil {
+		return err
+	}
+	g.conn = conn
+	g.client = kubewatchv1.NewEventServiceClient(conn)
+	return nil
+}
+
+// Handle handles an event.
+func (g *GRPC) Handle(e event.Event) {
+	if err := g.Send(e); err != nil {
+		logger.WithFields(e.LogFields()).Error(err)
+	}
+}
+
+// Send sends an event on the stream and waits for its acknowledgement,
+// returning an error when it is not delivered. A broken stream is opened
+// again once before giving up.
+func (g *GRPC) Send(e event.Event) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	msg := prepareEvent(e, time.Now())
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		if g.stream == nil {
+			if err = g.open(); err != nil {
+				continue
+			}
+		}
+		if err = g.publish(msg); err == nil {
+			logger.WithFields(e.LogFields()).Infof("Message successfully sent to %s", g.Address)
+			return nil
+		}
+		g.cancel()
+		g.stream, g.cancel = nil, nil
+	}
+	return fmt.Errorf("Failed streaming to %s: %v", g.Address, err)
+}
+
+// open opens the Publish stream.
+func (g *GRPC) open() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	if g.Token != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+g.Token)
+	}
+	stream, err := g.client.Publish(ctx)
+	if err != nil {
+		cancel()
+		return err
+	}
+	g.stream, g.cancel = stream, cancel
+	return nil
+}
+
+// publish sends an event and waits for its acknowledgement. The stream is
+// cancelled when the acknowledgement does not arrive within ackTimeout.
+func (g *GRPC) publish(msg *kubewatchv1.Event) error {
+	timer := time.AfterFunc(ackTimeout, g.cancel)
+	defer timer.Stop()
+
+	err := g.stream.Send(msg)
+	if err != nil && err != io.EOF {
+		return err
+	}
+	// on io.EOF the server ended the stream, Recv returns its status
+	if _, recvErr := g.stream.Recv(); recvErr != nil {
+		return recvErr
+	}
+	return err
+}
+
+// Run closes the stream and the connection once stopCh is closed, after the
+// event being sent.
+func (g *GRPC) Run(stopCh <-chan struct{}) {
+	<-stopCh
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.stream != nil {
+		g.stream.CloseSend()
+		g.cancel()
+		g.stream, g.cancel = nil, nil
+	}
+	g.conn.Close()
+}
+
+func checkMissingGRPCVars(g *GRPC) error {
+	if g.Address == "" {
+		return fmt.Errorf(grpcErrMsg, "Missing gRPC server address")
+	}
+
+	return nil
+}
+
+func prepareEvent(e event.Event, now time.Time) *kubewatchv1.Event {
+	msg := &kubewatchv1.Event{
+		Namespace:      e.Namespace,
+		Kind:           e.Kind,
+		Component:      e.Component,
+		Host:           e.Host,
+		Reason:         e.Reason,
+		Status:         e.Status,
+		Name:           e.Name,
+		Labels:         e.Labels,
+		Severity:       e.Severity,
+		Details:        e.Details,
+		Time:           timestamppb.New(now),
+		Cluster:        e.Cluster,
+		ExternalLabels: e.ExternalLabels,
+		Metadata:       e.Metadata,
+	}
+	for _, c := range e.Diff {
+		msg.Diff = append(msg.Diff, &kubewatchv1.Change{Path: c.Path, Old: c.Old, New: c.New})
+	}
+	for _, c := range e.Images {
+		msg.Images = append(msg.Images, &kubewatchv1.ImageChange{Container: c.Container, Old: c.Old, New: c.New})
+	}
+	return msg
+}
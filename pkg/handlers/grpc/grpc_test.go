@@ -0,0 +1,137 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package grpc
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	kubewatchv1 "github.com/bitnami-labs/kubewatch/api/kubewatch/v1"
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+)
+
+func TestGRPCInit(t *testing.T) {
+	s := &GRPC{}
+	expectedError := fmt.Errorf(grpcErrMsg, "Missing gRPC server address")
+
+	var Tests = []struct {
+		grpc config.GRPC
+		err  error
+	}{
+		{config.GRPC{Address: "localhost:9090"}, nil},
+		{config.GRPC{}, expectedError},
+	}
+
+	for _, tt := range Tests {
+		c := &config.Config{}
+		c.Handler.GRPC = tt.grpc
+		if err := s.Init(c); !reflect.DeepEqual(err, tt.err) {
+			t.Fatalf("Init(): %v", err)
+		}
+	}
+}
+
+// eventServer receives the events of the streams authenticated with its token.
+type eventServer struct {
+	kubewatchv1.UnimplementedEventServiceServer
+	token  string
+	events chan *kubewatchv1.Event
+}
+
+func (s *eventServer) Publish(stream kubewatchv1.EventService_PublishServer) error {
+	md, _ := metadata.FromIncomingContext(stream.Context())
+	if auth := md.Get("authorization"); len(auth) != 1 || auth[0] != "Bearer "+s.token {
+		return status.Error(codes.Unauthenticated, "invalid token")
+	}
+	var received uint64
+	for {
+		e, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		received++
+		s.events <- e
+		if err := stream.Send(&kubewatchv1.PublishResponse{Received: received}); err != nil {
+			return err
+		}
+	}
+}
+
+func TestGRPCSend(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer()
+	es := &eventServer{token: "secret", events: make(chan *kubewatchv1.Event, 10)}
+	kubewatchv1.RegisterEventServiceServer(srv, es)
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	c := &config.Config{}
+	c.Handler.GRPC = config.GRPC{Address: lis.Addr().String(), Token: "secret"}
+	s := &GRPC{}
+	if err := s.Init(c); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"foo", "bar"} {
+		e := event.Event{Kind: "pod", Name: name, Namespace: "default", Reason: "Created",
+			Diff: []event.Change{{Path: "spec.replicas", Old: "1", New: "2"}}}
+		if err := s.Send(e); err != nil {
+			t.Fatal(err)
+		}
+		got := <-es.events
+		if got.Name != name || got.Kind != "pod" || len(got.Diff) != 1 || got.Diff[0].New != "2" {
+			t.Fatalf("received %v", got)
+		}
+	}
+
+	// stopping closes the stream and the connection
+	stopCh := make(chan struct{})
+	close(stopCh)
+	s.Run(stopCh)
+	if state := s.conn.GetState(); state != connectivity.Shutdown {
+		t.Fatalf("Run(): got connection state %s", state)
+	}
+	if err := s.Send(event.Event{Kind: "pod", Name: "baz"}); err == nil {
+		t.Fatal("Send(): expected an error once stopped")
+	}
+
+	c.Handler.GRPC.Token = "wrong"
+	s = &GRPC{}
+	if err := s.Init(c); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Send(event.Event{Kind: "pod", Name: "foo"}); err == nil || !strings.Contains(err.Error(), "invalid token") {
+		t.Fatalf("Send(): expected an authentication error, got %v", err)
+	}
+}
@@ -19,13 +19,6 @@ package handlers
 import (
 	"github.com/bitnami-labs/kubewatch/config"
 	"github.com/bitnami-labs/kubewatch/pkg/event"
-	"github.com/bitnami-labs/kubewatch/pkg/handlers/flock"
-	"github.com/bitnami-labs/kubewatch/pkg/handlers/hipchat"
-	"github.com/bitnami-labs/kubewatch/pkg/handlers/mattermost"
-	"github.com/bitnami-labs/kubewatch/pkg/handlers/msteam"
-	"github.com/bitnami-labs/kubewatch/pkg/handlers/slack"
-	"github.com/bitnami-labs/kubewatch/pkg/handlers/smtp"
-	"github.com/bitnami-labs/kubewatch/pkg/handlers/webhook"
 )
 
 // Handler is implemented by any handler.
@@ -35,16 +28,47 @@ type Handler interface {
 	Handle(e event.Event)
 }
 
-// Map maps each event handler function to a name for easily lookup
-var Map = map[string]interface{}{
-	"default":    &Default{},
-	"slack":      &slack.Slack{},
-	"hipchat":    &hipchat.Hipchat{},
-	"mattermost": &mattermost.Mattermost{},
-	"flock":      &flock.Flock{},
-	"webhook":    &webhook.Webhook{},
-	"ms-teams":   &msteam.MSTeams{},
-	"smtp":       &smtp.SMTP{},
+// Sender is implemented by the handlers reporting whether an event was
+// delivered, which lets the undelivered ones be queued and replayed.
+type Sender interface {
+	Handler
+	Send(e event.Event) error
+}
+
+// BatchSender is implemented by the handlers able to send several events in
+// a single message, which lets them be batched.
+type BatchSender interface {
+	Handler
+	SendBatch(events []event.Event) error
+}
+
+// Runner is implemented by the handlers doing background work, e.g.
+// replaying queued events, while the controllers run.
+type Runner interface {
+	Run(stopCh <-chan struct{})
+}
+
+// Run runs the background work of the handler, if any, until stopCh is closed.
+func Run(h Handler, stopCh <-chan struct{}) {
+	if r, ok := h.(Runner); ok {
+		r.Run(stopCh)
+	}
+}
+
+// wrapper is embedded by the handlers wrapping another one. The wrappers
+// of a Sender keep it in their own field and only use its Init.
+type wrapper struct {
+	Handler
+}
+
+// Init does nothing, the wrapped handler is initialized beforehand.
+func (w wrapper) Init(c *config.Config) error {
+	return nil
+}
+
+// Run runs the background work of the wrapped handler.
+func (w wrapper) Run(stopCh <-chan struct{}) {
+	Run(w.Handler, stopCh)
 }
 
 // Default handler implements Handler interface,
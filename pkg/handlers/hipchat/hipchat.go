@@ -18,7 +18,6 @@ package hipchat
 
 import (
 	"fmt"
-	"log"
 	"os"
 
 	hipchat "github.com/tbruyelle/hipchat-go/hipchat"
@@ -27,8 +26,12 @@ import (
 
 	"github.com/bitnami-labs/kubewatch/config"
 	"github.com/bitnami-labs/kubewatch/pkg/event"
+	"github.com/sirupsen/logrus"
 )
 
+// logger logs the notifications sent by the handler.
+var logger = logrus.WithField("handler", "hipchat")
+
 var hipchatColors = map[string]hipchat.Color{
 	"Normal":  hipchat.ColorGreen,
 	"Warning": hipchat.ColorYellow,
@@ -97,11 +100,11 @@ func (s *Hipchat) Handle(e event.Event) {
 	_, err := client.Room.Notification(s.Room, &notificationRequest)
 
 	if err != nil {
-		log.Printf("%s\n", err)
+		logger.WithFields(e.LogFields()).Error(err)
 		return
 	}
 
-	log.Printf("Message successfully sent to room %s", s.Room)
+	logger.WithFields(e.LogFields()).Infof("Message successfully sent to room %s", s.Room)
 }
 
 func checkMissingHipchatVars(s *Hipchat) error {
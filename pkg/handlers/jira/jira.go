@@ -0,0 +1,293 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jira
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+	"github.com/bitnami-labs/kubewatch/pkg/metrics"
+	"github.com/bitnami-labs/kubewatch/pkg/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// logger logs the notifications sent by the handler.
+var logger = logrus.WithField("handler", "jira")
+
+const (
+	defaultIssueType = "Task"
+	requestTimeout   = 10 * time.Second
+	// objectLabelPrefix prefixes the label identifying the object of an issue.
+	objectLabelPrefix = "kubewatch-"
+)
+
+var jiraErrMsg = `
+%s
+
+You need to set the Jira url, project and token,
+using "--url/-u", "--project/-p" and "--token/-t", or using environment variables:
+
+export KW_JIRA_URL=https://mycompany.atlassian.net
+export KW_JIRA_PROJECT=OPS
+export KW_JIRA_TOKEN=jira_api_token
+
+Command line flags will override environment variables
+
+`
+
+// Jira handler implements handler.Handler interface,
+// Notify event as Jira issues, commenting on the open issue of an object
+type Jira struct {
+	Url       string
+	Project   string
+	IssueType string
+	Labels    []string
+	Username  string
+	Token     string
+	Dedup     string
+
+	client *http.Client
+}
+
+// Issue is the request body of the Jira create issue API
+// The Documentation is in https://developer.atlassian.com/cloud/jira/platform/rest/v2/api-group-issues/#api-rest-api-2-issue-post
+type Issue struct {
+	Fields IssueFields `json:"fields"`
+}
+
+// IssueFields are the fields of a created issue
+type IssueFields struct {
+	Project     Key      `json:"project"`
+	IssueType   Name     `json:"issuetype"`
+	Summary     string   `json:"summary"`
+	Description string   `json:"description"`
+	Labels      []string `json:"labels,omitempty"`
+}
+
+// Key refers to a Jira entity by its key
+type Key struct {
+	Key string `json:"key"`
+}
+
+// Name refers to a Jira entity by its name
+type Name struct {
+	Name string `json:"name"`
+}
+
+// Comment is the request body of the Jira add comment API
+type Comment struct {
+	Body string `json:"body"`
+}
+
+// searchResult is the part of the search API response listing the issues.
+type searchResult struct {
+	Issues []Key `json:"issues"`
+}
+
+// Init prepares Jira configuration
+func (j *Jira) Init(c *config.Config) error {
+	conf := c.Handler.Jira
+	url := conf.Url
+	project := conf.Project
+	token := conf.Token
+
+	if url == "" {
+		url = os.Getenv("KW_JIRA_URL")
+	}
+
+	if project == "" {
+		project = os.Getenv("KW_JIRA_PROJECT")
+	}
+
+	if token == "" {
+		token = os.Getenv("KW_JIRA_TOKEN")
+	}
+
+	j.Url = strings.TrimSuffix(url, "/")
+	j.Project = project
+	j.Token = token
+	j.Username = conf.Username
+	j.Labels = conf.Labels
+
+	j.IssueType = conf.IssueType
+	if j.IssueType == "" {
+		j.IssueType = defaultIssueType
+	}
+
+	j.Dedup = conf.Dedup
+	switch j.Dedup {
+	case "":
+		j.Dedup = "comment"
+	case "comment", "none":
+	default:
+		return fmt.Errorf("invalid jira dedup %q, must be comment or none", conf.Dedup)
+	}
+
+	client, err := utils.HTTPClient("jira", conf.TLS)
+	if err != nil {
+		return err
+	}
+	client.Timeout = requestTimeout
+	j.client = client
+
+	return checkMissingJiraVars(j)
+}
+
+// Handle handles an event.
+func (j *Jira) Handle(e event.Event) {
+	if err := j.Send(e); err != nil {
+		logger.WithFields(e.LogFields()).Error(err)
+	}
+}
+
+// Send creates an issue for the event, or comments on the open issue of
+// its object, returning an error when Jira doesn't accept it.
+func (j *Jira) Send(e event.Event) error {
+	label := objectLabel(e)
+
+	var issue string
+	if j.Dedup == "comment" {
+		var err error
+		if issue, err = j.openIssue(label); err != nil {
+			metrics.Notifications.WithLabelValues("jira", "failure").Inc()
+			return err
+		}
+	}
+
+	if issue != "" {
+		if err := j.post("/rest/api/2/issue/"+issue+"/comment", Comment{Body: e.Message()}, nil); err != nil {
+			metrics.Notifications.WithLabelValues("jira", "failure").Inc()
+			return err
+		}
+		metrics.Notifications.WithLabelValues("jira", "success").Inc()
+		logger.WithFields(e.LogFields()).Infof("Comment successfully added to Jira issue %s", issue)
+		return nil
+	}
+
+	var created Key
+	if err := j.post("/rest/api/2/issue", prepareIssue(e, j, label), &created); err != nil {
+		metrics.Notifications.WithLabelValues("jira", "failure").Inc()
+		return err
+	}
+	metrics.Notifications.WithLabelValues("jira", "success").Inc()
+	logger.WithFields(e.LogFields()).Infof("Jira issue %s successfully created", created.Key)
+	return nil
+}
+
+func checkMissingJiraVars(j *Jira) error {
+	if j.Url == "" || j.Project == "" || j.Token == "" {
+		return fmt.Errorf(jiraErrMsg, "Missing Jira url, project or token")
+	}
+
+	return nil
+}
+
+// objectLabel returns the label identifying the issues of the object of the
+// event, Jira labels not allowing spaces.
+func objectLabel(e event.Event) string {
+	sum := sha1.Sum([]byte(strings.Join([]string{e.Cluster, e.Namespace, e.Kind, e.Name}, "/")))
+	return objectLabelPrefix + hex.EncodeToString(sum[:8])
+}
+
+func prepareIssue(e event.Event, j *Jira, label string) *Issue {
+	summary := fmt.Sprintf("%s %s %s", e.Kind, e.Name, strings.ToLower(e.Reason))
+	if e.Namespace != "" {
+		summary = fmt.Sprintf("%s %s/%s %s", e.Kind, e.Namespace, e.Name, strings.ToLower(e.Reason))
+	}
+	if e.Cluster != "" {
+		summary = "[" + e.Cluster + "] " + summary
+	}
+
+	return &Issue{Fields: IssueFields{
+		Project:     Key{Key: j.Project},
+		IssueType:   Name{Name: j.IssueType},
+		Summary:     summary,
+		Description: e.Message(),
+		Labels:      append(append([]string{}, j.Labels...), label),
+	}}
+}
+
+// openIssue returns the key of the most recent open issue with the label,
+// empty when there is none.
+func (j *Jira) openIssue(label string) (string, error) {
+	jql := fmt.Sprintf(`project = "%s" AND labels = "%s" AND statusCategory != Done ORDER BY created DESC`, j.Project, label)
+	query := url.Values{"jql": {jql}, "fields": {"key"}, "maxResults": {"1"}}
+	req, err := http.NewRequest("GET", j.Url+"/rest/api/2/search?"+query.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+
+	var result searchResult
+	if err := j.do(req, &result); err != nil {
+		return "", err
+	}
+	if len(result.Issues) == 0 {
+		return "", nil
+	}
+	return result.Issues[0].Key, nil
+}
+
+func (j *Jira) post(path string, body, response interface{}) error {
+	message, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", j.Url+path, bytes.NewBuffer(message))
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Content-Type", "application/json")
+	return j.do(req, response)
+}
+
+// do sends the authenticated request, decoding the response into
+// response when not nil.
+func (j *Jira) do(req *http.Request, response interface{}) error {
+	req.Header.Add("Accept", "application/json")
+	if j.Username != "" {
+		req.SetBasicAuth(j.Username, j.Token)
+	} else {
+		req.Header.Add("Authorization", "Bearer "+j.Token)
+	}
+
+	res, err := j.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode/100 != 2 {
+		body, _ := ioutil.ReadAll(res.Body)
+		return fmt.Errorf("Failed sending request to Jira. Jira http response: %s, %s", res.Status, string(body))
+	}
+	if response == nil {
+		return nil
+	}
+	return json.NewDecoder(res.Body).Decode(response)
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jira
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+)
+
+func TestJiraInit(t *testing.T) {
+	j := &Jira{}
+	expectedError := fmt.Errorf(jiraErrMsg, "Missing Jira url, project or token")
+
+	var Tests = []struct {
+		jira config.Jira
+		err  error
+	}{
+		{config.Jira{Url: "https://jira", Project: "OPS", Token: "token"}, nil},
+		{config.Jira{Url: "https://jira", Project: "OPS"}, expectedError},
+		{config.Jira{}, expectedError},
+		{config.Jira{Url: "https://jira", Project: "OPS", Token: "token", Dedup: "always"}, fmt.Errorf("invalid jira dedup %q, must be comment or none", "always")},
+	}
+
+	for _, tt := range Tests {
+		c := &config.Config{}
+		c.Handler.Jira = tt.jira
+		if err := j.Init(c); !reflect.DeepEqual(err, tt.err) {
+			t.Fatalf("Init(): %v", err)
+		}
+	}
+}
+
+func TestJiraSend(t *testing.T) {
+	var (
+		open     []Key
+		created  []Issue
+		comments []string
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, token, ok := r.BasicAuth(); !ok || user != "bot@example.com" || token != "token" {
+			t.Errorf("unexpected credentials %q %q", user, token)
+		}
+		switch {
+		case r.URL.Path == "/rest/api/2/search":
+			if jql := r.URL.Query().Get("jql"); !strings.Contains(jql, `labels = "kubewatch-`) {
+				t.Errorf("unexpected jql %q", jql)
+			}
+			json.NewEncoder(w).Encode(searchResult{Issues: open})
+		case r.URL.Path == "/rest/api/2/issue":
+			var issue Issue
+			if err := json.NewDecoder(r.Body).Decode(&issue); err != nil {
+				t.Error(err)
+			}
+			created = append(created, issue)
+			open = []Key{{Key: "OPS-1"}}
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(Key{Key: "OPS-1"})
+		case r.URL.Path == "/rest/api/2/issue/OPS-1/comment":
+			var comment Comment
+			if err := json.NewDecoder(r.Body).Decode(&comment); err != nil {
+				t.Error(err)
+			}
+			comments = append(comments, comment.Body)
+			w.WriteHeader(http.StatusCreated)
+		default:
+			t.Errorf("unexpected path %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	c := &config.Config{}
+	c.Handler.Jira = config.Jira{Url: ts.URL, Project: "OPS", Username: "bot@example.com", Token: "token", Labels: []string{"k8s"}}
+	j := &Jira{}
+	if err := j.Init(c); err != nil {
+		t.Fatal(err)
+	}
+
+	e := event.Event{Namespace: "prod", Kind: "deployment", Name: "api", Reason: "Deleted"}
+	if err := j.Send(e); err != nil {
+		t.Fatal(err)
+	}
+	if err := j.Send(e); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(created) != 1 || len(comments) != 1 {
+		t.Fatalf("got %d issues and %d comments, want 1 of each", len(created), len(comments))
+	}
+	fields := created[0].Fields
+	if fields.Summary != "deployment prod/api deleted" || fields.IssueType.Name != "Task" || fields.Project.Key != "OPS" {
+		t.Fatalf("unexpected issue %+v", fields)
+	}
+	if len(fields.Labels) != 2 || fields.Labels[0] != "k8s" || fields.Labels[1] != objectLabel(e) {
+		t.Fatalf("unexpected labels %v", fields.Labels)
+	}
+	if comments[0] != e.Message() {
+		t.Fatalf("unexpected comment %q", comments[0])
+	}
+}
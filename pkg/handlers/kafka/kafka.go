@@ -0,0 +1,201 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+	"github.com/bitnami-labs/kubewatch/pkg/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// logger logs the notifications sent by the handler.
+var logger = logrus.WithField("handler", "kafka")
+
+var kafkaErrMsg = `
+%s
+
+You need to set the Kafka brokers and topic,
+using "--brokers/-b" and "--topic/-t", or using environment variables:
+
+export KW_KAFKA_BROKERS=broker1:9092,broker2:9092
+export KW_KAFKA_TOPIC=kafka_topic
+
+Command line flags will override environment variables
+
+`
+
+// Kafka handler implements handler.Handler interface,
+// Publish events to a Kafka topic
+type Kafka struct {
+	Brokers        []string
+	Topic          string
+	Key            string
+	PayloadVersion string
+
+	writer *kafka.Writer
+}
+
+// Init prepares Kafka configuration
+func (k *Kafka) Init(c *config.Config) error {
+	k.PayloadVersion = c.PayloadVersion
+	conf := c.Handler.Kafka
+	brokers := conf.Brokers
+	topic := conf.Topic
+
+	if len(brokers) == 0 && os.Getenv("KW_KAFKA_BROKERS") != "" {
+		brokers = strings.Split(os.Getenv("KW_KAFKA_BROKERS"), ",")
+	}
+
+	if topic == "" {
+		topic = os.Getenv("KW_KAFKA_TOPIC")
+	}
+
+	k.Brokers = brokers
+	k.Topic = topic
+	k.Key = conf.Key
+
+	if err := checkMissingKafkaVars(k); err != nil {
+		return err
+	}
+
+	switch k.Key {
+	case "", "name", "namespace", "kind":
+	default:
+		return fmt.Errorf("invalid kafka key %q, must be one of namespace, kind or name", k.Key)
+	}
+
+	var batchTimeout time.Duration
+	if conf.BatchTimeout != "" {
+		d, err := time.ParseDuration(conf.BatchTimeout)
+		if err != nil {
+			return fmt.Errorf("invalid kafka batchTimeout: %v", err)
+		}
+		batchTimeout = d
+	}
+
+	transport := &kafka.Transport{ClientID: "kubewatch"}
+	if utils.TLSEnabled(conf.TLS) {
+		tlsConfig, err := utils.TLSConfig(conf.TLS)
+		if err != nil {
+			return err
+		}
+		transport.TLS = tlsConfig
+	}
+	mechanism, err := saslMechanism(conf.SASL)
+	if err != nil {
+		return err
+	}
+	transport.SASL = mechanism
+
+	k.writer = &kafka.Writer{
+		Addr:         kafka.TCP(k.Brokers...),
+		Topic:        k.Topic,
+		Balancer:     &kafka.Hash{},
+		BatchSize:    conf.BatchSize,
+		BatchTimeout: batchTimeout,
+		RequiredAcks: kafka.RequireOne,
+		Async:        true,
+		Transport:    transport,
+		Completion: func(messages []kafka.Message, err error) {
+			if err != nil {
+				logger.WithField("topic", k.Topic).Errorf("Failed publishing %d messages to kafka topic %s: %v", len(messages), k.Topic, err)
+			}
+		},
+	}
+
+	return nil
+}
+
+// Handle handles an event.
+func (k *Kafka) Handle(e event.Event) {
+	value, err := json.Marshal(e.Payload(k.PayloadVersion))
+	if err != nil {
+		logger.WithFields(e.LogFields()).Error(err)
+		return
+	}
+
+	// the writer is asynchronous: messages are batched and delivery errors
+	// are reported by the completion callback
+	err = k.writer.WriteMessages(context.Background(), kafka.Message{
+		Key:   []byte(messageKey(e, k.Key)),
+		Value: value,
+		Time:  time.Now(),
+	})
+	if err != nil {
+		logger.WithFields(e.LogFields()).Error(err)
+		return
+	}
+
+	logger.WithFields(e.LogFields()).Infof("Message successfully queued to kafka topic %s", k.Topic)
+}
+
+// Run publishes the messages still batched and closes the writer once
+// stopCh is closed.
+func (k *Kafka) Run(stopCh <-chan struct{}) {
+	<-stopCh
+	if err := k.writer.Close(); err != nil {
+		logger.WithField("topic", k.Topic).Errorf("Failed flushing the messages to kafka topic %s: %v", k.Topic, err)
+	}
+}
+
+func checkMissingKafkaVars(k *Kafka) error {
+	if len(k.Brokers) == 0 || k.Topic == "" {
+		return fmt.Errorf(kafkaErrMsg, "Missing kafka brokers or topic")
+	}
+
+	return nil
+}
+
+// messageKey returns the partitioning key of the event.
+func messageKey(e event.Event, key string) string {
+	switch key {
+	case "namespace":
+		return e.Namespace
+	case "kind":
+		return e.Kind
+	default:
+		return strings.Join([]string{e.Kind, e.Namespace, e.Name}, "/")
+	}
+}
+
+func saslMechanism(c config.KafkaSASL) (sasl.Mechanism, error) {
+	switch strings.ToLower(c.Mechanism) {
+	case "":
+		return nil, nil
+	case "plain":
+		return plain.Mechanism{Username: c.Username, Password: c.Password}, nil
+	case "scram-sha-256":
+		return scram.Mechanism(scram.SHA256, c.Username, c.Password)
+	case "scram-sha-512":
+		return scram.Mechanism(scram.SHA512, c.Username, c.Password)
+	default:
+		return nil, fmt.Errorf("unsupported kafka SASL mechanism %q", c.Mechanism)
+	}
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kafka
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+)
+
+func TestKafkaInit(t *testing.T) {
+	s := &Kafka{}
+	expectedError := fmt.Errorf(kafkaErrMsg, "Missing kafka brokers or topic")
+
+	var Tests = []struct {
+		kafka config.Kafka
+		err   error
+	}{
+		{config.Kafka{Brokers: []string{"localhost:9092"}, Topic: "events"}, nil},
+		{config.Kafka{Brokers: []string{"localhost:9092"}}, expectedError},
+		{config.Kafka{Topic: "events"}, expectedError},
+		{config.Kafka{}, expectedError},
+	}
+
+	for _, tt := range Tests {
+		c := &config.Config{}
+		c.Handler.Kafka = tt.kafka
+		if err := s.Init(c); !reflect.DeepEqual(err, tt.err) {
+			t.Fatalf("Init(): %v", err)
+		}
+	}
+}
+
+func TestMessageKey(t *testing.T) {
+	e := event.Event{Kind: "pod", Namespace: "default", Name: "foo"}
+
+	var Tests = []struct {
+		key  string
+		want string
+	}{
+		{"", "pod/default/foo"},
+		{"name", "pod/default/foo"},
+		{"namespace", "default"},
+		{"kind", "pod"},
+	}
+
+	for _, tt := range Tests {
+		if got := messageKey(e, tt.key); got != tt.want {
+			t.Fatalf("messageKey(%q): got %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestKafkaRun(t *testing.T) {
+	c := &config.Config{}
+	c.Handler.Kafka = config.Kafka{Brokers: []string{"127.0.0.1:1"}, Topic: "events"}
+	k := &Kafka{}
+	if err := k.Init(c); err != nil {
+		t.Fatal(err)
+	}
+
+	stopCh := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		k.Run(stopCh)
+		close(done)
+	}()
+	close(stopCh)
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run(): writer not closed once stopped")
+	}
+}
@@ -0,0 +1,313 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lark
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+	"github.com/bitnami-labs/kubewatch/pkg/metrics"
+	"github.com/bitnami-labs/kubewatch/pkg/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// logger logs the notifications sent by the handler.
+var logger = logrus.WithField("handler", "lark")
+
+const requestTimeout = 10 * time.Second
+
+// statusColors maps event statuses to the colors of the headers of the cards.
+var statusColors = map[string]string{
+	"Normal":  "green",
+	"Warning": "orange",
+	"Danger":  "red",
+}
+
+// templates are the colors of the headers of the cards.
+var templates = map[string]bool{
+	"blue": true, "wathet": true, "turquoise": true, "green": true, "yellow": true, "orange": true,
+	"red": true, "carmine": true, "violet": true, "purple": true, "indigo": true, "grey": true,
+}
+
+var larkErrMsg = `
+%s
+
+You need to set the webhook url of the custom bot for Lark notify,
+using "--url/-u", or using environment variables:
+
+export KW_LARK_WEBHOOK_URL=lark_webhook_url
+export KW_LARK_SECRET=lark_signing_secret
+
+Command line flags will override environment variables
+
+`
+
+// Lark handler implements handler.Handler interface,
+// Notify event to a Lark (Feishu) group with a custom bot
+type Lark struct {
+	WebhookURL string
+	Secret     string
+	Colors     map[string]string
+
+	client *http.Client
+	now    func() time.Time
+}
+
+// Message is a message of a custom bot
+// The Documentation is in https://open.larksuite.com/document/client-docs/bot-v3/add-custom-bot
+type Message struct {
+	Timestamp string `json:"timestamp,omitempty"`
+	Sign      string `json:"sign,omitempty"`
+	MsgType   string `json:"msg_type"`
+	Card      Card   `json:"card"`
+}
+
+// Card is an interactive message card
+type Card struct {
+	Config   CardConfig `json:"config"`
+	Header   Header     `json:"header"`
+	Elements []Element  `json:"elements"`
+}
+
+// CardConfig contains the display settings of a card
+type CardConfig struct {
+	WideScreenMode bool `json:"wide_screen_mode"`
+}
+
+// Header is the colored title of a card
+type Header struct {
+	Title    Text   `json:"title"`
+	Template string `json:"template,omitempty"`
+}
+
+// Element is a div, hr or note element of a card
+type Element struct {
+	Tag      string  `json:"tag"`
+	Text     *Text   `json:"text,omitempty"`
+	Fields   []Field `json:"fields,omitempty"`
+	Elements []Text  `json:"elements,omitempty"`
+}
+
+// Field of a div
+type Field struct {
+	IsShort bool `json:"is_short"`
+	Text    Text `json:"text"`
+}
+
+// Text is a plain_text or lark_md text
+type Text struct {
+	Tag     string `json:"tag"`
+	Content string `json:"content"`
+}
+
+// response is the result of the webhook, an error when its code is not 0,
+// with the fields of the v2 webhooks or of the older ones
+type response struct {
+	Code          int    `json:"code"`
+	Msg           string `json:"msg"`
+	StatusCode    int    `json:"StatusCode"`
+	StatusMessage string `json:"StatusMessage"`
+}
+
+// Init prepares Lark configuration
+func (l *Lark) Init(c *config.Config) error {
+	conf := c.Handler.Lark
+	url := conf.WebhookURL
+	secret := conf.Secret
+
+	if url == "" {
+		url = os.Getenv("KW_LARK_WEBHOOK_URL")
+	}
+
+	if secret == "" {
+		secret = os.Getenv("KW_LARK_SECRET")
+	}
+
+	for reason, color := range conf.Colors {
+		if !templates[color] {
+			return fmt.Errorf("invalid lark color %q of %s", color, reason)
+		}
+	}
+
+	l.WebhookURL = url
+	l.Secret = secret
+	l.Colors = conf.Colors
+	l.now = time.Now
+
+	client, err := utils.HTTPClient("lark", conf.TLS)
+	if err != nil {
+		return err
+	}
+	client.Timeout = requestTimeout
+	l.client = client
+
+	return checkMissingLarkVars(l)
+}
+
+// Handle handles an event.
+func (l *Lark) Handle(e event.Event) {
+	if err := l.Send(e); err != nil {
+		logger.WithFields(e.LogFields()).Error(err)
+	}
+}
+
+// Send posts the card of the event to the group, returning an error when it
+// is not delivered.
+func (l *Lark) Send(e event.Event) error {
+	message := prepareMessage(e, l.color(e))
+	if l.Secret != "" {
+		timestamp := l.now().Unix()
+		message.Timestamp = strconv.FormatInt(timestamp, 10)
+		message.Sign = sign(l.Secret, timestamp)
+	}
+
+	if err := l.post(message); err != nil {
+		metrics.Notifications.WithLabelValues("lark", "failure").Inc()
+		return err
+	}
+
+	metrics.Notifications.WithLabelValues("lark", "success").Inc()
+	logger.WithFields(e.LogFields()).Info("Message successfully sent to Lark")
+	return nil
+}
+
+func checkMissingLarkVars(l *Lark) error {
+	if l.WebhookURL == "" {
+		return fmt.Errorf(larkErrMsg, "Missing Lark webhook url")
+	}
+
+	return nil
+}
+
+// color returns the color of the header of the card of the event, the one
+// configured for its reason or else the one of its status.
+func (l *Lark) color(e event.Event) string {
+	if color, ok := l.Colors[e.Reason]; ok {
+		return color
+	}
+	if color, ok := statusColors[e.Status]; ok {
+		return color
+	}
+	return "blue"
+}
+
+// sign returns the signature of the messages sent at the timestamp, the
+// HMAC-SHA256 of an empty message keyed by the timestamp and the secret.
+func sign(secret string, timestamp int64) string {
+	mac := hmac.New(sha256.New, []byte(fmt.Sprintf("%d\n%s", timestamp, secret)))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// prepareMessage returns the interactive card of the event, with the fields
+// of the event, its changes and the cluster in a note.
+func prepareMessage(e event.Event, color string) *Message {
+	title := fmt.Sprintf("%s %s %s", e.Kind, e.Name, strings.ToLower(e.Reason))
+	if e.Cluster != "" {
+		title = "[" + e.Cluster + "] " + title
+	}
+
+	var fields []Field
+	for _, f := range []struct{ name, value string }{
+		{"Kind", e.Kind},
+		{"Name", e.Name},
+		{"Namespace", e.Namespace},
+		{"Reason", e.Reason},
+		{"Severity", e.Severity},
+		{"Host", e.Host},
+	} {
+		if f.value != "" {
+			fields = append(fields, Field{IsShort: true, Text: Text{Tag: "lark_md", Content: fmt.Sprintf("**%s**\n%s", f.name, f.value)}})
+		}
+	}
+
+	elements := []Element{
+		{Tag: "div", Text: &Text{Tag: "lark_md", Content: e.Message()}},
+		{Tag: "div", Fields: fields},
+	}
+	if e.Details != "" {
+		elements = append(elements, Element{Tag: "div", Text: &Text{Tag: "plain_text", Content: e.Details}})
+	}
+	if len(e.Diff) > 0 {
+		var changes []string
+		for _, c := range e.Diff {
+			changes = append(changes, fmt.Sprintf("**%s**: %s → %s", c.Path, c.Old, c.New))
+		}
+		elements = append(elements, Element{Tag: "hr"}, Element{Tag: "div", Text: &Text{Tag: "lark_md", Content: strings.Join(changes, "\n")}})
+	}
+	if e.Cluster != "" {
+		elements = append(elements, Element{Tag: "note", Elements: []Text{{Tag: "plain_text", Content: "cluster " + e.Cluster}}})
+	}
+
+	return &Message{
+		MsgType: "interactive",
+		Card: Card{
+			Config:   CardConfig{WideScreenMode: true},
+			Header:   Header{Title: Text{Tag: "plain_text", Content: title}, Template: color},
+			Elements: elements,
+		},
+	}
+}
+
+func (l *Lark) post(m *Message) error {
+	message, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", l.WebhookURL, bytes.NewBuffer(message))
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Content-Type", "application/json")
+
+	res, err := l.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	body, _ := ioutil.ReadAll(res.Body)
+	if res.StatusCode/100 != 2 {
+		return fmt.Errorf("Failed posting to Lark. Lark http response: %s, %s", res.Status, string(body))
+	}
+	// the errors, e.g. of a wrong signature, are reported with a 200
+	var r response
+	if err := json.Unmarshal(body, &r); err != nil {
+		return nil
+	}
+	code, msg := r.Code, r.Msg
+	if code == 0 {
+		code, msg = r.StatusCode, r.StatusMessage
+	}
+	if code != 0 {
+		return fmt.Errorf("Failed posting to Lark. Lark response: %d, %s", code, msg)
+	}
+
+	return nil
+}
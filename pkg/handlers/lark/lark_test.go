@@ -0,0 +1,126 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lark
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+)
+
+func TestLarkInit(t *testing.T) {
+	l := &Lark{}
+	expectedError := fmt.Errorf(larkErrMsg, "Missing Lark webhook url")
+
+	var Tests = []struct {
+		lark config.Lark
+		err  error
+	}{
+		{config.Lark{WebhookURL: "foo"}, nil},
+		{config.Lark{WebhookURL: "foo", Secret: "bar", Colors: map[string]string{"Deleted": "carmine"}}, nil},
+		{config.Lark{}, expectedError},
+		{config.Lark{WebhookURL: "foo", Colors: map[string]string{"Deleted": "pink"}}, fmt.Errorf("invalid lark color %q of %s", "pink", "Deleted")},
+	}
+
+	for _, tt := range Tests {
+		c := &config.Config{}
+		c.Handler.Lark = tt.lark
+		if err := l.Init(c); !reflect.DeepEqual(err, tt.err) {
+			t.Fatalf("Init(): %v", err)
+		}
+	}
+}
+
+func TestSign(t *testing.T) {
+	if got := sign("foo", 1599360473); got != "S8Q0BSVS96wPiXeFJLPgOFvgnPIQUcgBR+HUoABaYao=" {
+		t.Fatalf("got %s", got)
+	}
+}
+
+func TestPrepareMessage(t *testing.T) {
+	e := event.Event{Kind: "deployment", Name: "web", Namespace: "prod", Reason: "Updated", Status: "Warning", Cluster: "eu",
+		Diff: []event.Change{{Path: "spec.replicas", Old: "1", New: "2"}}}
+
+	l := &Lark{Colors: map[string]string{"Deleted": "carmine"}}
+	m := prepareMessage(e, l.color(e))
+	if m.MsgType != "interactive" || m.Card.Header.Title.Content != "[eu] deployment web updated" || m.Card.Header.Template != "orange" {
+		t.Fatalf("header: got %+v", m.Card.Header)
+	}
+	elements := m.Card.Elements
+	if len(elements) != 5 || elements[0].Text.Content != e.Message() || len(elements[1].Fields) != 4 {
+		t.Fatalf("elements: got %+v", elements)
+	}
+	if elements[1].Fields[0].Text.Content != "**Kind**\ndeployment" || elements[3].Text.Content != "**spec.replicas**: 1 → 2" {
+		t.Fatalf("fields and changes: got %+v", elements)
+	}
+	if elements[4].Tag != "note" || elements[4].Elements[0].Content != "cluster eu" {
+		t.Fatalf("note: got %+v", elements[4])
+	}
+
+	for e, want := range map[*event.Event]string{
+		{Reason: "Deleted", Status: "Danger"}: "carmine",
+		{Reason: "Created", Status: "Normal"}: "green",
+		{Reason: "BackOff"}:                   "blue",
+	} {
+		if got := l.color(*e); got != want {
+			t.Fatalf("color of %+v: got %s, want %s", e, got, want)
+		}
+	}
+}
+
+func TestLarkSend(t *testing.T) {
+	var got Message
+	reply := `{"code":0,"msg":"success","data":{}}`
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Error(err)
+		}
+		w.Write([]byte(reply))
+	}))
+	defer ts.Close()
+
+	c := &config.Config{}
+	c.Handler.Lark = config.Lark{WebhookURL: ts.URL, Secret: "foo"}
+	l := &Lark{}
+	if err := l.Init(c); err != nil {
+		t.Fatal(err)
+	}
+	l.now = func() time.Time { return time.Unix(1599360473, 0) }
+	e := event.Event{Kind: "pod", Name: "web", Reason: "Created", Status: "Normal"}
+	if err := l.Send(e); err != nil {
+		t.Fatal(err)
+	}
+	if got.Timestamp != "1599360473" || got.Sign != "S8Q0BSVS96wPiXeFJLPgOFvgnPIQUcgBR+HUoABaYao=" || got.Card.Header.Template != "green" {
+		t.Fatalf("got %+v", got)
+	}
+
+	reply = `{"code":19021,"msg":"sign match fail or timestamp is not within one hour from current time"}`
+	if err := l.Send(e); err == nil {
+		t.Fatal("Send(): expected an error for a rejected signature")
+	}
+	reply = `{"StatusCode":9499,"StatusMessage":"Bad Request"}`
+	if err := l.Send(e); err == nil {
+		t.Fatal("Send(): expected an error for a rejected message")
+	}
+}
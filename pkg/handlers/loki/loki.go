@@ -0,0 +1,343 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package loki
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+	"github.com/bitnami-labs/kubewatch/pkg/metrics"
+	"github.com/bitnami-labs/kubewatch/pkg/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// logger logs the notifications sent by the handler.
+var logger = logrus.WithField("handler", "loki")
+
+const (
+	pushPath             = "/loki/api/v1/push"
+	defaultBatchSize     = 100
+	defaultFlushInterval = 5 * time.Second
+	requestTimeout       = 30 * time.Second
+	// maxErrorBody limits the response body kept in the error messages.
+	maxErrorBody = 512
+	// maxBatches limits the entries kept while Loki is unavailable or rate
+	// limiting, as a number of batches.
+	maxBatches = 10
+)
+
+var lokiErrMsg = `
+%s
+
+You need to set the Loki URL,
+using "--url/-u", or using environment variables:
+
+export KW_LOKI_URL=http://loki:3100
+export KW_LOKI_TENANT_ID=tenant (optional)
+
+Command line flags will override environment variables
+
+`
+
+// Loki handler implements handler.Handler interface,
+// Push events as log lines to the Loki push API
+type Loki struct {
+	Url            string
+	Labels         map[string]string
+	TenantID       string
+	Username       string
+	Password       string
+	BatchSize      int
+	FlushInterval  time.Duration
+	PayloadVersion string
+
+	client *http.Client
+
+	// pushMu keeps one push at a time, so the entries stay in order
+	pushMu sync.Mutex
+	// mu guards the pending entries and retryAt
+	mu      sync.Mutex
+	pending []entry
+	// retryAt is when a failed or rate limited push can be retried
+	retryAt time.Time
+}
+
+// entry is a log line with the labels of its stream.
+type entry struct {
+	labels map[string]string
+	time   time.Time
+	line   string
+}
+
+// Init prepares Loki configuration
+func (l *Loki) Init(c *config.Config) error {
+	l.PayloadVersion = c.PayloadVersion
+	conf := c.Handler.Loki
+	url := conf.Url
+	tenantID := conf.TenantID
+
+	if url == "" {
+		url = os.Getenv("KW_LOKI_URL")
+	}
+
+	if tenantID == "" {
+		tenantID = os.Getenv("KW_LOKI_TENANT_ID")
+	}
+
+	l.Url = strings.TrimSuffix(strings.TrimSuffix(url, "/"), pushPath)
+	l.Labels = conf.Labels
+	l.TenantID = tenantID
+	l.Username = conf.BasicAuth.Username
+	l.Password = conf.BasicAuth.Password
+
+	l.BatchSize = conf.BatchSize
+	if l.BatchSize <= 0 {
+		l.BatchSize = defaultBatchSize
+	}
+	l.FlushInterval = defaultFlushInterval
+	if conf.FlushInterval != "" {
+		interval, err := time.ParseDuration(conf.FlushInterval)
+		if err != nil {
+			return fmt.Errorf("invalid Loki flush interval %q: %v", conf.FlushInterval, err)
+		}
+		l.FlushInterval = interval
+	}
+
+	client, err := utils.HTTPClient("loki", conf.TLS)
+	if err != nil {
+		return err
+	}
+	client.Timeout = requestTimeout
+	l.client = client
+
+	return checkMissingLokiVars(l)
+}
+
+// Handle handles an event. The event is pushed with the next request, once
+// BatchSize events are pending or after FlushInterval.
+func (l *Loki) Handle(e event.Event) {
+	var doc interface{} = struct {
+		event.V1
+		Message string `json:"message"`
+	}{event.V1{Event: &e}, e.Message()}
+	if l.PayloadVersion == event.PayloadV2 {
+		doc = e.V2()
+	}
+	line, err := json.Marshal(doc)
+	if err != nil {
+		logger.WithFields(e.LogFields()).Error(err)
+		return
+	}
+
+	labels := map[string]string{}
+	for k, v := range e.ExternalLabels {
+		labels[k] = v
+	}
+	for k, v := range l.Labels {
+		labels[k] = v
+	}
+	if e.Cluster != "" {
+		labels["cluster"] = e.Cluster
+	}
+	if e.Namespace != "" {
+		labels["namespace"] = e.Namespace
+	}
+	labels["kind"] = e.Kind
+	labels["event_type"] = strings.ToLower(e.Reason)
+
+	l.mu.Lock()
+	l.pending = append(l.pending, entry{labels: labels, time: time.Now(), line: string(line)})
+	if dropped := len(l.pending) - maxBatches*l.BatchSize; dropped > 0 {
+		l.pending = l.pending[dropped:]
+		metrics.Notifications.WithLabelValues("loki", "failure").Add(float64(dropped))
+		logger.Warnf("Dropped %d entries, Loki is unavailable", dropped)
+	}
+	full := len(l.pending) >= l.BatchSize
+	l.mu.Unlock()
+
+	if full {
+		l.flush()
+	}
+}
+
+// Run pushes the pending entries every FlushInterval until stopCh is
+// closed, then pushes the last ones.
+func (l *Loki) Run(stopCh <-chan struct{}) {
+	ticker := time.NewTicker(l.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stopCh:
+			l.flush()
+			return
+		case <-ticker.C:
+			l.flush()
+		}
+	}
+}
+
+// flush pushes the pending entries, BatchSize at a time. The entries Loki
+// fails to accept for a transient reason, e.g. rate limiting, are kept
+// until retryAt.
+func (l *Loki) flush() {
+	l.pushMu.Lock()
+	defer l.pushMu.Unlock()
+
+	for {
+		l.mu.Lock()
+		if len(l.pending) == 0 || time.Now().Before(l.retryAt) {
+			l.mu.Unlock()
+			return
+		}
+		n := len(l.pending)
+		if n > l.BatchSize {
+			n = l.BatchSize
+		}
+		batch := l.pending[:n:n]
+		l.pending = l.pending[n:]
+		l.mu.Unlock()
+
+		retryAfter, err := l.push(batch)
+		if err == nil {
+			metrics.Notifications.WithLabelValues("loki", "success").Add(float64(len(batch)))
+			logger.Infof("%d entries successfully pushed to %s", len(batch), l.Url)
+			continue
+		}
+		if retryAfter == 0 {
+			metrics.Notifications.WithLabelValues("loki", "failure").Add(float64(len(batch)))
+			logger.Errorf("Failed pushing %d entries: %v", len(batch), err)
+			continue
+		}
+
+		logger.Warnf("Failed pushing %d entries, retrying in %s: %v", len(batch), retryAfter, err)
+		l.mu.Lock()
+		l.pending = append(batch, l.pending...)
+		l.retryAt = time.Now().Add(retryAfter)
+		l.mu.Unlock()
+		return
+	}
+}
+
+type pushRequest struct {
+	Streams []stream `json:"streams"`
+}
+
+type stream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+// push sends the entries to the push API. The returned delay is non zero
+// when the request can be retried: after the Retry-After of a 429 response,
+// or after FlushInterval when Loki is unavailable.
+func (l *Loki) push(entries []entry) (time.Duration, error) {
+	streams := map[string]*stream{}
+	var keys []string
+	for _, e := range entries {
+		key := streamKey(e.labels)
+		s, ok := streams[key]
+		if !ok {
+			s = &stream{Stream: e.labels}
+			streams[key] = s
+			keys = append(keys, key)
+		}
+		s.Values = append(s.Values, [2]string{strconv.FormatInt(e.time.UnixNano(), 10), e.line})
+	}
+	var req pushRequest
+	for _, key := range keys {
+		req.Streams = append(req.Streams, *streams[key])
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return 0, err
+	}
+	httpReq, err := http.NewRequest("POST", l.Url+pushPath, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if l.TenantID != "" {
+		httpReq.Header.Set("X-Scope-OrgID", l.TenantID)
+	}
+	if l.Username != "" {
+		httpReq.SetBasicAuth(l.Username, l.Password)
+	}
+
+	resp, err := l.client.Do(httpReq)
+	if err != nil {
+		return l.FlushInterval, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+		io.Copy(ioutil.Discard, resp.Body)
+		return 0, nil
+	}
+	msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	err = fmt.Errorf("%s: %s", resp.Status, bytes.TrimSpace(msg))
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return retryAfter(resp.Header.Get("Retry-After"), l.FlushInterval), err
+	case resp.StatusCode >= 500:
+		return l.FlushInterval, err
+	}
+	return 0, err
+}
+
+// retryAfter parses a Retry-After header, in seconds or as a date.
+func retryAfter(value string, fallback time.Duration) time.Duration {
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if d := time.Until(date); d > 0 {
+			return d
+		}
+	}
+	return fallback
+}
+
+// streamKey identifies the stream of a label set.
+func streamKey(labels map[string]string) string {
+	pairs := make([]string, 0, len(labels))
+	for k, v := range labels {
+		pairs = append(pairs, strconv.Quote(k)+"="+strconv.Quote(v))
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func checkMissingLokiVars(l *Loki) error {
+	if l.Url == "" {
+		return fmt.Errorf(lokiErrMsg, "Missing Loki URL")
+	}
+
+	return nil
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package loki
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+)
+
+func TestLokiInit(t *testing.T) {
+	l := &Loki{}
+	expectedError := fmt.Errorf(lokiErrMsg, "Missing Loki URL")
+
+	var Tests = []struct {
+		loki config.Loki
+		err  error
+	}{
+		{config.Loki{Url: "http://localhost:3100"}, nil},
+		{config.Loki{}, expectedError},
+	}
+
+	for _, tt := range Tests {
+		c := &config.Config{}
+		c.Handler.Loki = tt.loki
+		if err := l.Init(c); !reflect.DeepEqual(err, tt.err) {
+			t.Fatalf("Init(): %v", err)
+		}
+	}
+}
+
+func TestLokiPush(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests int
+		pushed   []pushRequest
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != pushPath {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if tenant := r.Header.Get("X-Scope-OrgID"); tenant != "team" {
+			t.Errorf("X-Scope-OrgID: %q", tenant)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		requests++
+		if requests == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		var req pushRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+		}
+		pushed = append(pushed, req)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	l := &Loki{}
+	c := &config.Config{}
+	c.Handler.Loki = config.Loki{
+		Url:       ts.URL + "/",
+		Labels:    map[string]string{"cluster": "prod"},
+		TenantID:  "team",
+		BatchSize: 2,
+	}
+	if err := l.Init(c); err != nil {
+		t.Fatal(err)
+	}
+
+	l.Handle(event.Event{Kind: "pod", Name: "web", Namespace: "default", Reason: "Created"})
+	l.Handle(event.Event{Kind: "pod", Name: "db", Namespace: "default", Reason: "Created"})
+	// rate limited, the entries are kept until the Retry-After delay
+	l.Handle(event.Event{Kind: "pod", Name: "web", Namespace: "default", Reason: "Deleted"})
+	l.flush()
+	mu.Lock()
+	if requests != 1 || len(l.pending) != 3 {
+		t.Fatalf("%d requests, %d pending entries", requests, len(l.pending))
+	}
+	mu.Unlock()
+
+	time.Sleep(time.Until(l.retryAt))
+	l.flush()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(pushed) != 2 || len(l.pending) != 0 {
+		t.Fatalf("%d pushes, %d pending entries", len(pushed), len(l.pending))
+	}
+	first := pushed[0].Streams
+	if len(first) != 1 || len(first[0].Values) != 2 {
+		t.Fatalf("first push: %+v", first)
+	}
+	expected := map[string]string{"cluster": "prod", "namespace": "default", "kind": "pod", "event_type": "created"}
+	if !reflect.DeepEqual(first[0].Stream, expected) {
+		t.Fatalf("labels: %v", first[0].Stream)
+	}
+	var line struct {
+		Name    string `json:"name"`
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal([]byte(first[0].Values[0][1]), &line); err != nil {
+		t.Fatal(err)
+	}
+	if line.Name != "web" || line.Message == "" {
+		t.Fatalf("line: %s", first[0].Values[0][1])
+	}
+	if pushed[1].Streams[0].Stream["event_type"] != "deleted" {
+		t.Fatalf("second push: %+v", pushed[1].Streams)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	if d := retryAfter("3", time.Second); d != 3*time.Second {
+		t.Fatalf("retryAfter(3) = %s", d)
+	}
+	if d := retryAfter("", time.Second); d != time.Second {
+		t.Fatalf("retryAfter() = %s", d)
+	}
+	date := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+	if d := retryAfter(date, time.Second); d < 50*time.Second || d > time.Minute {
+		t.Fatalf("retryAfter(%s) = %s", date, d)
+	}
+}
@@ -18,29 +18,46 @@ package mattermost
 
 import (
 	"fmt"
-	"log"
+	"io/ioutil"
 	"os"
 
 	"bytes"
 	"encoding/json"
 	"net/http"
-	"time"
 
 	"github.com/bitnami-labs/kubewatch/config"
 	"github.com/bitnami-labs/kubewatch/pkg/event"
+	"github.com/bitnami-labs/kubewatch/pkg/utils"
+	"github.com/sirupsen/logrus"
 )
 
+// logger logs the notifications sent by the handler.
+var logger = logrus.WithField("handler", "mattermost")
+
+// mattermostColors are the colors of the Slack "good", "warning" and
+// "danger" attachments.
 var mattermostColors = map[string]string{
-	"Normal":  "#00FF00",
-	"Warning": "#FFFF00",
-	"Danger":  "#FF0000",
+	"Normal":  "#2eb886",
+	"Warning": "#daa038",
+	"Danger":  "#a30200",
+}
+
+// mattermostSeverityColors maps event severities to attachment colors,
+// taking precedence over the status colors.
+var mattermostSeverityColors = map[string]string{
+	"critical": "#a30200",
+	"warning":  "#daa038",
+	"info":     "#2eb886",
 }
 
+const defaultIconUrl = "https://raw.githubusercontent.com/kubernetes/kubernetes/master/logo/logo_with_border.png"
+
 var mattermostErrMsg = `
 %s
 
-You need to set Mattermost url, channel and username for Mattermost notify,
-using "--channel/-c", "--url/-u" and "--username/-n", or using environment variables:
+You need to set the Mattermost incoming webhook url for Mattermost notify,
+using "--url/-u", optionally overriding its channel and username with
+"--channel/-c" and "--username/-n", or using environment variables:
 
 export KW_MATTERMOST_CHANNEL=mattermost_channel
 export KW_MATTERMOST_URL=mattermost_url
@@ -56,12 +73,17 @@ type Mattermost struct {
 	Channel  string
 	Url      string
 	Username string
+	IconUrl  string
+	// NamespaceChannels are the channels of the events by namespace
+	NamespaceChannels map[string]string
+
+	client *http.Client
 }
 
 // MattermostMessage struct for messages
 type MattermostMessage struct {
-	Channel      string                         `json:"channel"`
-	Username     string                         `json:"username"`
+	Channel      string                         `json:"channel,omitempty"`
+	Username     string                         `json:"username,omitempty"`
 	IconUrl      string                         `json:"icon_url"`
 	Text         string                         `json:"text"`
 	Attachements []MattermostMessageAttachement `json:"attachments"`
@@ -69,8 +91,18 @@ type MattermostMessage struct {
 
 // MattermostMessageAttachement for message attachments
 type MattermostMessageAttachement struct {
+	Fallback string                   `json:"fallback"`
+	Title    string                   `json:"title"`
+	Text     string                   `json:"text"`
+	Color    string                   `json:"color"`
+	Fields   []MattermostMessageField `json:"fields"`
+}
+
+// MattermostMessageField for attachment fields
+type MattermostMessageField struct {
+	Short bool   `json:"short"`
 	Title string `json:"title"`
-	Color string `json:"color"`
+	Value string `json:"value"`
 }
 
 // Init prepares Mattermost configuration
@@ -92,48 +124,85 @@ func (m *Mattermost) Init(c *config.Config) error {
 	}
 
 	m.Channel = channel
+	m.NamespaceChannels = c.Handler.Mattermost.NamespaceChannels
 	m.Url = url
 	m.Username = username
+	m.IconUrl = c.Handler.Mattermost.IconUrl
+	if m.IconUrl == "" {
+		m.IconUrl = defaultIconUrl
+	}
+
+	if err := checkMissingMattermostVars(m); err != nil {
+		return err
+	}
 
-	return checkMissingMattermostVars(m)
+	client, err := utils.HTTPClient("mattermost", c.Handler.Mattermost.TLS)
+	if err != nil {
+		return fmt.Errorf("mattermost tls: %v", err)
+	}
+	m.client = client
+	return nil
 }
 
 // Handle handles an event.
 func (m *Mattermost) Handle(e event.Event) {
+	if err := m.Send(e); err != nil {
+		logger.WithFields(e.LogFields()).Error(err)
+	}
+}
+
+// Send sends an event, returning an error when it is not delivered.
+func (m *Mattermost) Send(e event.Event) error {
 	mattermostMessage := prepareMattermostMessage(e, m)
 
-	err := postMessage(m.Url, mattermostMessage)
-	if err != nil {
-		log.Printf("%s\n", err)
-		return
+	if err := postMessage(m.client, m.Url, mattermostMessage); err != nil {
+		return err
 	}
 
-	log.Printf("Message successfully sent to channel %s at %s", m.Channel, time.Now())
+	logger.WithFields(e.LogFields()).Info("Message successfully sent to Mattermost")
+	return nil
 }
 
 func checkMissingMattermostVars(s *Mattermost) error {
-	if s.Channel == "" || s.Url == "" || s.Username == "" {
-		return fmt.Errorf(mattermostErrMsg, "Missing Mattermost channel, url or username")
+	if s.Url == "" {
+		return fmt.Errorf(mattermostErrMsg, "Missing Mattermost url")
 	}
 
 	return nil
 }
 
 func prepareMattermostMessage(e event.Event, m *Mattermost) *MattermostMessage {
+	color, ok := mattermostSeverityColors[e.Severity]
+	if !ok {
+		color = mattermostColors[e.Status]
+	}
+
+	fields := []MattermostMessageField{
+		{Short: true, Title: "Kind", Value: e.Kind},
+		{Short: true, Title: "Name", Value: e.Name},
+	}
+	if e.Namespace != "" {
+		fields = append(fields, MattermostMessageField{Short: true, Title: "Namespace", Value: e.Namespace})
+	}
+	fields = append(fields, MattermostMessageField{Short: true, Title: "Reason", Value: e.Reason})
+
 	return &MattermostMessage{
-		Channel:  m.Channel,
+		Channel:  e.Channel("mattermost", e.NamespaceChannel(m.NamespaceChannels, m.Channel)),
 		Username: m.Username,
-		IconUrl:  "https://raw.githubusercontent.com/kubernetes/kubernetes/master/logo/logo_with_border.png",
+		IconUrl:  m.IconUrl,
 		Attachements: []MattermostMessageAttachement{
 			{
-				Title: e.Message(),
-				Color: mattermostColors[e.Status],
+				Fallback: e.Message(),
+				Title:    "kubewatch",
+				Text:     e.Message(),
+				Color:    color,
+				Fields:   fields,
 			},
 		},
 	}
 }
 
-func postMessage(url string, mattermostMessage *MattermostMessage) error {
+func postMessage(client *http.Client, url string, mattermostMessage *MattermostMessage) error {
 	message, err := json.Marshal(mattermostMessage)
 	if err != nil {
 		return err
@@ -145,11 +214,16 @@ func postMessage(url string, mattermostMessage *MattermostMessage) error {
 	}
 	req.Header.Add("Content-Type", "application/json")
 
-	client := &http.Client{}
-	_, err = client.Do(req)
+	res, err := client.Do(req)
 	if err != nil {
 		return err
 	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(res.Body)
+		return fmt.Errorf("Failed posting to Mattermost. Mattermost http response: %s, %s", res.Status, string(body))
+	}
 
 	return nil
 }
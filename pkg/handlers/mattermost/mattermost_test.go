@@ -17,26 +17,30 @@ limitations under the License.
 package mattermost
 
 import (
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"reflect"
 	"testing"
 
 	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
 )
 
 func TestMattermostInit(t *testing.T) {
 	s := &Mattermost{}
-	expectedError := fmt.Errorf(mattermostErrMsg, "Missing Mattermost channel, url or username")
+	expectedError := fmt.Errorf(mattermostErrMsg, "Missing Mattermost url")
 
 	var Tests = []struct {
 		mattermost config.Mattermost
 		err        error
 	}{
 		{config.Mattermost{Url: "foo", Channel: "bar", Username: "username"}, nil},
-		{config.Mattermost{Url: "foo", Channel: "bar"}, expectedError},
-		{config.Mattermost{Url: "foo", Username: "username"}, expectedError},
+		{config.Mattermost{Url: "foo", Channel: "bar"}, nil},
+		{config.Mattermost{Url: "foo", Username: "username"}, nil},
 		{config.Mattermost{Channel: "foo", Username: "username"}, expectedError},
-		{config.Mattermost{Url: "foo"}, expectedError},
+		{config.Mattermost{Url: "foo"}, nil},
 		{config.Mattermost{Channel: "bar"}, expectedError},
 		{config.Mattermost{Username: "bar"}, expectedError},
 		{config.Mattermost{}, expectedError},
@@ -50,3 +54,44 @@ func TestMattermostInit(t *testing.T) {
 		}
 	}
 }
+
+func TestMattermostHandle(t *testing.T) {
+	var got MattermostMessage
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer ts.Close()
+
+	c := &config.Config{}
+	c.Handler.Mattermost = config.Mattermost{Url: ts.URL, Channel: "alerts", IconUrl: "https://example.com/icon.png"}
+	m := &Mattermost{}
+	if err := m.Init(c); err != nil {
+		t.Fatal(err)
+	}
+	m.Handle(event.Event{Kind: "pod", Name: "web", Namespace: "default", Reason: "Updated", Status: "Warning"})
+
+	if got.Channel != "alerts" || got.IconUrl != "https://example.com/icon.png" {
+		t.Fatalf("channel and icon: got %q, %q", got.Channel, got.IconUrl)
+	}
+	if len(got.Attachements) != 1 || got.Attachements[0].Color != mattermostColors["Warning"] {
+		t.Fatalf("attachments: got %+v", got.Attachements)
+	}
+}
+
+func TestMattermostChannelOfObject(t *testing.T) {
+	m := &Mattermost{Channel: "alerts", NamespaceChannels: map[string]string{"payments": "payments-alerts"}}
+	e := event.Event{Kind: "pod", Name: "web", Namespace: "shop", Reason: "Created", Status: "Normal"}
+	if got := prepareMattermostMessage(e, m).Channel; got != "alerts" {
+		t.Fatalf("channel: got %q, want alerts", got)
+	}
+	e.Namespace = "payments"
+	if got := prepareMattermostMessage(e, m).Channel; got != "payments-alerts" {
+		t.Fatalf("channel: got %q, want payments-alerts", got)
+	}
+	e.Channels = map[string]string{"slack": "#payments", "mattermost": "payments"}
+	if got := prepareMattermostMessage(e, m).Channel; got != "payments" {
+		t.Fatalf("channel: got %q, want payments", got)
+	}
+}
@@ -0,0 +1,276 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mqtt
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+	"github.com/bitnami-labs/kubewatch/pkg/metrics"
+	"github.com/bitnami-labs/kubewatch/pkg/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// logger logs the notifications sent by the handler.
+var logger = logrus.WithField("handler", "mqtt")
+
+const (
+	defaultTopic   = "kubewatch/{namespace}/{kind}"
+	requestTimeout = 10 * time.Second
+	// keepAlive is the interval of the pings detecting the lost connections.
+	keepAlive = 30 * time.Second
+	// quiesce is the time left to the in-flight publications on shutdown.
+	quiesce = time.Second
+)
+
+var mqttErrMsg = `
+%s
+
+You need to set the MQTT broker url,
+using "--broker/-b", or using environment variables:
+
+export KW_MQTT_BROKER=tcp://mosquitto:1883
+
+Command line flags will override environment variables
+
+`
+
+// levelReplacer replaces the characters which aren't allowed in a topic level.
+var levelReplacer = strings.NewReplacer("/", "_", "+", "_", "#", "_")
+
+// MQTT handler implements handler.Handler interface,
+// Publish events to an MQTT broker
+type MQTT struct {
+	Broker         string
+	ClientID       string
+	Username       string
+	Password       string
+	Topic          string
+	QoS            byte
+	Retain         bool
+	PayloadVersion string
+
+	address   string
+	tlsConfig *tls.Config
+
+	// mu guards the connection of the client, opened by the first
+	// publication, the client reconnecting by itself afterwards.
+	mu     sync.Mutex
+	client paho.Client
+}
+
+// Init prepares MQTT configuration
+func (m *MQTT) Init(c *config.Config) error {
+	m.PayloadVersion = c.PayloadVersion
+	conf := c.Handler.MQTT
+	broker := conf.Broker
+	username := conf.Username
+	password := conf.Password
+
+	if broker == "" {
+		broker = os.Getenv("KW_MQTT_BROKER")
+	}
+
+	if username == "" {
+		username = os.Getenv("KW_MQTT_USERNAME")
+	}
+
+	if password == "" {
+		password = os.Getenv("KW_MQTT_PASSWORD")
+	}
+
+	topic := conf.Topic
+	if topic == "" {
+		topic = defaultTopic
+	}
+
+	clientID := conf.ClientID
+	if clientID == "" {
+		hostname, _ := os.Hostname()
+		clientID = "kubewatch-" + hostname
+	}
+
+	m.Broker = broker
+	m.ClientID = clientID
+	m.Username = username
+	m.Password = password
+	m.Topic = topic
+	m.Retain = conf.Retain
+
+	if err := checkMissingMQTTVars(m); err != nil {
+		return err
+	}
+
+	if conf.QoS < 0 || conf.QoS > 2 {
+		return fmt.Errorf("mqtt: invalid qos %d, must be 0, 1 or 2", conf.QoS)
+	}
+	m.QoS = byte(conf.QoS)
+
+	u, err := url.Parse(broker)
+	if err != nil {
+		return fmt.Errorf("mqtt: invalid broker url %q: %v", broker, err)
+	}
+	secure := utils.TLSEnabled(conf.TLS)
+	port := "1883"
+	switch u.Scheme {
+	case "tcp", "mqtt":
+	case "ssl", "tls", "mqtts":
+		secure = true
+	default:
+		return fmt.Errorf("mqtt: unsupported scheme of broker url %q, must be tcp, mqtt, ssl, tls or mqtts", broker)
+	}
+	if secure {
+		port = "8883"
+		if m.tlsConfig, err = utils.TLSConfig(conf.TLS); err != nil {
+			return err
+		}
+		m.tlsConfig.ServerName = u.Hostname()
+	}
+	if u.Port() != "" {
+		port = u.Port()
+	}
+	m.address = net.JoinHostPort(u.Hostname(), port)
+
+	scheme := "tcp"
+	if secure {
+		scheme = "ssl"
+	}
+	// the session is kept by the broker and the unacknowledged publications
+	// by the store, to send them again once reconnected
+	opts := paho.NewClientOptions().
+		AddBroker(scheme + "://" + m.address).
+		SetClientID(m.ClientID).
+		SetUsername(m.Username).
+		SetPassword(m.Password).
+		SetTLSConfig(m.tlsConfig).
+		SetCleanSession(false).
+		SetStore(paho.NewMemoryStore()).
+		SetAutoReconnect(true).
+		SetKeepAlive(keepAlive).
+		SetConnectTimeout(requestTimeout).
+		SetWriteTimeout(requestTimeout).
+		SetConnectionLostHandler(func(_ paho.Client, err error) {
+			logger.Warnf("Lost the connection to the MQTT broker, reconnecting: %v", err)
+		})
+	m.client = paho.NewClient(opts)
+
+	return nil
+}
+
+// Handle handles an event.
+func (m *MQTT) Handle(e event.Event) {
+	if err := m.Send(e); err != nil {
+		logger.WithFields(e.LogFields()).Error(err)
+	}
+}
+
+// Send publishes the event as JSON to its topic, returning an error when it
+// is not acknowledged with the QoS 1 and 2, or not sent with the QoS 0.
+func (m *MQTT) Send(e event.Event) error {
+	data, err := json.Marshal(e.Payload(m.PayloadVersion))
+	if err != nil {
+		return err
+	}
+
+	topic := expandTopic(m.Topic, e)
+	if err := m.publish(topic, data); err != nil {
+		metrics.Notifications.WithLabelValues("mqtt", "failure").Inc()
+		return fmt.Errorf("Failed publishing to MQTT topic %s: %v", topic, err)
+	}
+
+	metrics.Notifications.WithLabelValues("mqtt", "success").Inc()
+	logger.WithFields(e.LogFields()).Infof("Message successfully published to MQTT topic %s", topic)
+	return nil
+}
+
+// Run disconnects from the broker once stopCh is closed, leaving some time
+// to the in-flight publications.
+func (m *MQTT) Run(stopCh <-chan struct{}) {
+	<-stopCh
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.client.IsConnected() {
+		m.client.Disconnect(uint(quiesce / time.Millisecond))
+	}
+}
+
+// publish publishes the payload, connecting to the broker first if needed.
+// While the client reconnects, the publications are sent once connected.
+func (m *MQTT) publish(topic string, payload []byte) error {
+	if err := m.connect(); err != nil {
+		return err
+	}
+	return wait(m.client.Publish(topic, m.QoS, m.Retain, payload))
+}
+
+// connect connects the client to the broker, unless connected or
+// reconnecting.
+func (m *MQTT) connect() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.client.IsConnected() {
+		return nil
+	}
+	return wait(m.client.Connect())
+}
+
+// wait waits for the completion of the token, up to the request timeout.
+func wait(t paho.Token) error {
+	if !t.WaitTimeout(requestTimeout) {
+		return fmt.Errorf("no reply of the broker after %v", requestTimeout)
+	}
+	return t.Error()
+}
+
+func checkMissingMQTTVars(m *MQTT) error {
+	if m.Broker == "" {
+		return fmt.Errorf(mqttErrMsg, "Missing MQTT broker url")
+	}
+
+	return nil
+}
+
+// expandTopic replaces the placeholders of the topic template by the event values.
+func expandTopic(topic string, e event.Event) string {
+	return strings.NewReplacer(
+		"{cluster}", level(e.Cluster),
+		"{namespace}", level(e.Namespace),
+		"{kind}", level(e.Kind),
+		"{name}", level(e.Name),
+		"{reason}", level(e.Reason),
+	).Replace(topic)
+}
+
+// level returns a valid topic level for the given value; empty values, like
+// the namespace of cluster scoped objects, become "_".
+func level(value string) string {
+	if value == "" {
+		return "_"
+	}
+	return levelReplacer.Replace(value)
+}
@@ -0,0 +1,247 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mqtt
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+)
+
+func TestMQTTInit(t *testing.T) {
+	m := &MQTT{}
+	expectedError := fmt.Errorf(mqttErrMsg, "Missing MQTT broker url")
+
+	var Tests = []struct {
+		mqtt config.MQTT
+		err  error
+	}{
+		{config.MQTT{Broker: "tcp://mosquitto:1883"}, nil},
+		{config.MQTT{Broker: "mqtts://broker.example.com", QoS: 1}, nil},
+		{config.MQTT{Broker: "tcp://mosquitto", QoS: 3}, fmt.Errorf("mqtt: invalid qos %d, must be 0, 1 or 2", 3)},
+		{config.MQTT{Broker: "ws://mosquitto"}, fmt.Errorf("mqtt: unsupported scheme of broker url %q, must be tcp, mqtt, ssl, tls or mqtts", "ws://mosquitto")},
+		{config.MQTT{}, expectedError},
+	}
+
+	for _, tt := range Tests {
+		c := &config.Config{}
+		c.Handler.MQTT = tt.mqtt
+		if err := m.Init(c); !reflect.DeepEqual(err, tt.err) {
+			t.Fatalf("Init(): %v", err)
+		}
+	}
+
+	c := &config.Config{}
+	c.Handler.MQTT = config.MQTT{Broker: "ssl://broker.example.com"}
+	if err := m.Init(c); err != nil || m.address != "broker.example.com:8883" || m.tlsConfig == nil {
+		t.Fatalf("Init(): got address %s, %v", m.address, err)
+	}
+}
+
+func TestExpandTopic(t *testing.T) {
+	e := event.Event{Namespace: "shop", Kind: "stateful set", Name: "db/0", Reason: "Updated"}
+	if got := expandTopic("k8s/{cluster}/{namespace}/{kind}/{name}/{reason}", e); got != "k8s/_/shop/stateful set/db_0/Updated" {
+		t.Fatalf("got %q", got)
+	}
+}
+
+// The types of the MQTT 3.1.1 control packets handled by the test broker
+const (
+	connectPacket    = 1
+	connackPacket    = 2
+	publishPacket    = 3
+	pubackPacket     = 4
+	pubrecPacket     = 5
+	pubrelPacket     = 6
+	pubcompPacket    = 7
+	pingreqPacket    = 12
+	pingrespPacket   = 13
+	disconnectPacket = 14
+)
+
+// packet is an MQTT control packet.
+type packet struct {
+	// header is the first byte of the packet, its type and flags.
+	header byte
+	body   []byte
+}
+
+func (p packet) kind() byte {
+	return p.header >> 4
+}
+
+// readPacket reads a control packet.
+func readPacket(r *bufio.Reader) (packet, error) {
+	header, err := r.ReadByte()
+	if err != nil {
+		return packet{}, err
+	}
+	length, multiplier := 0, 1
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return packet{}, err
+		}
+		length += int(b&0x7f) * multiplier
+		multiplier *= 128
+		if b&0x80 == 0 {
+			break
+		}
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return packet{}, err
+	}
+	return packet{header: header, body: body}, nil
+}
+
+// writePacket writes a control packet with a body shorter than 128 bytes.
+func writePacket(w io.Writer, p packet) error {
+	_, err := w.Write(append([]byte{p.header, byte(len(p.body))}, p.body...))
+	return err
+}
+
+// appendString appends the length prefixed UTF-8 string.
+func appendString(b []byte, s string) []byte {
+	b = append(b, byte(len(s)>>8), byte(len(s)))
+	return append(b, s...)
+}
+
+// broker accepts the connections, acknowledging their publications with the
+// QoS 1 and 2, and returns their packets. The first connection is closed
+// once its first publication is acknowledged, for the client to reconnect.
+func broker(l net.Listener, packets chan<- packet) {
+	for first := true; ; first = false {
+		nc, err := l.Accept()
+		if err != nil {
+			return
+		}
+		go serve(nc, first, packets)
+	}
+}
+
+func serve(nc net.Conn, once bool, packets chan<- packet) {
+	defer nc.Close()
+	r := bufio.NewReader(nc)
+	for {
+		p, err := readPacket(r)
+		if err != nil {
+			return
+		}
+		if p.kind() != pingreqPacket {
+			packets <- p
+		}
+		switch p.kind() {
+		case connectPacket:
+			writePacket(nc, packet{header: connackPacket << 4, body: []byte{0, 0}})
+		case pingreqPacket:
+			writePacket(nc, packet{header: pingrespPacket << 4})
+		case publishPacket:
+			qos := p.header >> 1 & 0x03
+			if qos == 0 {
+				continue
+			}
+			n := binary.BigEndian.Uint16(p.body)
+			id := p.body[2+n : 4+n]
+			if qos == 1 {
+				writePacket(nc, packet{header: pubackPacket << 4, body: id})
+			} else {
+				writePacket(nc, packet{header: pubrecPacket << 4, body: id})
+			}
+		case pubrelPacket:
+			writePacket(nc, packet{header: pubcompPacket << 4, body: p.body})
+			if once {
+				return
+			}
+		}
+	}
+}
+
+func TestMQTTSend(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	packets := make(chan packet, 10)
+	go broker(l, packets)
+
+	c := &config.Config{}
+	c.Handler.MQTT = config.MQTT{Broker: "tcp://" + l.Addr().String(), ClientID: "kw", Username: "user", Password: "secret", QoS: 2, Retain: true}
+	m := &MQTT{}
+	if err := m.Init(c); err != nil {
+		t.Fatal(err)
+	}
+	e := event.Event{Namespace: "shop", Kind: "pod", Name: "web", Reason: "Created"}
+	if err := m.Send(e); err != nil {
+		t.Fatal(err)
+	}
+
+	connect := <-packets
+	want := append(appendString(nil, "MQTT"), 4, 0xc0, 0, byte(keepAlive/time.Second))
+	want = appendString(appendString(appendString(want, "kw"), "user"), "secret")
+	if connect.kind() != connectPacket || !reflect.DeepEqual(connect.body, want) {
+		t.Fatalf("connect: got %v", connect)
+	}
+
+	publish := <-packets
+	if publish.header != publishPacket<<4|2<<1|1 {
+		t.Fatalf("publish: got header %x", publish.header)
+	}
+	topic := appendString(nil, "kubewatch/shop/pod")
+	if !reflect.DeepEqual(publish.body[:len(topic)], topic) {
+		t.Fatalf("publish: got topic %q", publish.body[2:len(topic)])
+	}
+	var got event.Event
+	if err := json.Unmarshal(publish.body[len(topic)+2:], &got); err != nil || !reflect.DeepEqual(got, e) {
+		t.Fatalf("publish: got %+v, %v", got, err)
+	}
+	if pubrel := <-packets; pubrel.kind() != pubrelPacket {
+		t.Fatalf("pubrel: got %v", pubrel)
+	}
+
+	// the broker closed the first connection, the client reconnects by itself
+	if reconnect := <-packets; reconnect.kind() != connectPacket {
+		t.Fatalf("reconnect: got %v", reconnect)
+	}
+	e.Reason = "Deleted"
+	if err := m.Send(e); err != nil {
+		t.Fatal(err)
+	}
+	if publish := <-packets; publish.kind() != publishPacket {
+		t.Fatalf("publish: got %v", publish)
+	}
+	if pubrel := <-packets; pubrel.kind() != pubrelPacket {
+		t.Fatalf("pubrel: got %v", pubrel)
+	}
+
+	stopCh := make(chan struct{})
+	close(stopCh)
+	m.Run(stopCh)
+	if disconnect := <-packets; disconnect.kind() != disconnectPacket {
+		t.Fatalf("disconnect: got %v", disconnect)
+	}
+}
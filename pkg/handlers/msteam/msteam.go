@@ -21,14 +21,18 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
-	"log"
 	"net/http"
 	"os"
 
 	"github.com/bitnami-labs/kubewatch/config"
 	"github.com/bitnami-labs/kubewatch/pkg/event"
+	"github.com/bitnami-labs/kubewatch/pkg/utils"
+	"github.com/sirupsen/logrus"
 )
 
+// logger logs the notifications sent by the handler.
+var logger = logrus.WithField("handler", "msteams")
+
 var msteamsErrMsg = `
 %s
 
@@ -84,6 +88,7 @@ type TeamsMessageCardSectionFacts struct {
 type MSTeams struct {
 	// TeamsWebhookURL is the webhook url of the Teams connector
 	TeamsWebhookURL string
+	client          *http.Client
 }
 
 // sendCard sends the JSON Encoded TeamsMessageCard to the webhook URL
@@ -92,7 +97,7 @@ func sendCard(ms *MSTeams, card *TeamsMessageCard) (*http.Response, error) {
 	if err := json.NewEncoder(buffer).Encode(card); err != nil {
 		return nil, fmt.Errorf("Failed encoding message card: %v", err)
 	}
-	res, err := http.Post(ms.TeamsWebhookURL, "application/json", buffer)
+	res, err := ms.client.Post(ms.TeamsWebhookURL, "application/json", buffer)
 	if err != nil {
 		return nil, fmt.Errorf("Failed sending to webhook url %s. Got the error: %v",
 			ms.TeamsWebhookURL, err)
@@ -123,7 +128,12 @@ func (ms *MSTeams) Init(c *config.Config) error {
 		return fmt.Errorf(msteamsErrMsg, "Missing MS teams webhook URL")
 	}
 
+	client, err := utils.HTTPClient("msteams", c.Handler.MSTeams.TLS)
+	if err != nil {
+		return err
+	}
 	ms.TeamsWebhookURL = webhookURL
+	ms.client = client
 	return nil
 }
 
@@ -145,9 +155,9 @@ func (ms *MSTeams) Handle(e event.Event) {
 	card.Sections = append(card.Sections, s)
 
 	if _, err := sendCard(ms, card); err != nil {
-		log.Printf("%s\n", err)
+		logger.WithFields(e.LogFields()).Error(err)
 		return
 	}
 
-	log.Printf("Message successfully sent to MS Teams")
+	logger.WithFields(e.LogFields()).Info("Message successfully sent to MS Teams")
 }
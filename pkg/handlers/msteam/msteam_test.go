@@ -32,6 +32,12 @@ func TestInit(t *testing.T) {
 			t.Fatalf("Init(): %v", err)
 		}
 	}
+
+	c := &config.Config{}
+	c.Handler.MSTeams = config.MSTeams{WebhookURL: "somepath", TLS: config.TLS{CAFile: "/nonexistent"}}
+	if err := s.Init(c); err == nil {
+		t.Fatal("Init(): expected an error for a missing CA file")
+	}
 }
 
 // Tests ObjectCreated() by passing v1.Pod
@@ -66,7 +72,7 @@ func TestObjectCreated(t *testing.T) {
 		}
 	}))
 
-	ms := &MSTeams{TeamsWebhookURL: ts.URL}
+	ms := &MSTeams{TeamsWebhookURL: ts.URL, client: ts.Client()}
 	p := event.Event{
 		Name:      "foo",
 		Kind:      "pod",
@@ -110,7 +116,7 @@ func TestObjectDeleted(t *testing.T) {
 		}
 	}))
 
-	ms := &MSTeams{TeamsWebhookURL: ts.URL}
+	ms := &MSTeams{TeamsWebhookURL: ts.URL, client: ts.Client()}
 
 	p := event.Event{
 		Name:      "foo",
@@ -155,7 +161,7 @@ func TestObjectUpdated(t *testing.T) {
 		}
 	}))
 
-	ms := &MSTeams{TeamsWebhookURL: ts.URL}
+	ms := &MSTeams{TeamsWebhookURL: ts.URL, client: ts.Client()}
 
 	oldP := event.Event{
 		Name:      "foo",
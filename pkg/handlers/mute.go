@@ -0,0 +1,40 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handlers
+
+import (
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+	"github.com/bitnami-labs/kubewatch/pkg/mute"
+	"github.com/bitnami-labs/kubewatch/pkg/tracing"
+	"github.com/sirupsen/logrus"
+)
+
+// Mute implements the Handler interface,
+// dropping the events muted at runtime before they reach the wrapped handler
+type Mute struct {
+	wrapper
+}
+
+// Handle handles an event.
+func (m *Mute) Handle(e event.Event) {
+	if mute.Muted(e) {
+		logrus.WithFields(e.LogFields()).Debug("Event muted")
+		tracing.AddEvent(e, "muted")
+		return
+	}
+	m.Handler.Handle(e)
+}
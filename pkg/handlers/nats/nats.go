@@ -0,0 +1,201 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nats
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/nats-io/nats.go"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+	"github.com/bitnami-labs/kubewatch/pkg/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// logger logs the notifications sent by the handler.
+var logger = logrus.WithField("handler", "nats")
+
+const defaultSubject = "kubewatch.{namespace}.{kind}"
+
+var natsErrMsg = `
+%s
+
+You need to set the NATS server url,
+using "--url/-u", or using environment variables:
+
+export KW_NATS_URL=nats_url
+
+Command line flags will override environment variables
+
+`
+
+// tokenReplacer replaces the characters which aren't allowed in a subject token.
+var tokenReplacer = strings.NewReplacer(" ", "_", ".", "_", "*", "_", ">", "_")
+
+// NATS handler implements handler.Handler interface,
+// Publish events to a NATS subject
+type NATS struct {
+	Url            string
+	Subject        string
+	JetStream      bool
+	PayloadVersion string
+
+	conn *nats.Conn
+	js   nats.JetStreamContext
+	// closed is closed once the connection is
+	closed chan struct{}
+}
+
+// Init prepares NATS configuration
+func (n *NATS) Init(c *config.Config) error {
+	n.PayloadVersion = c.PayloadVersion
+	conf := c.Handler.NATS
+	url := conf.Url
+	subject := conf.Subject
+
+	if url == "" {
+		url = os.Getenv("KW_NATS_URL")
+	}
+
+	if subject == "" {
+		subject = os.Getenv("KW_NATS_SUBJECT")
+		if subject == "" {
+			subject = defaultSubject
+		}
+	}
+
+	n.Url = url
+	n.Subject = subject
+	n.JetStream = conf.JetStream
+
+	if err := checkMissingNATSVars(n); err != nil {
+		return err
+	}
+
+	closed := make(chan struct{})
+	opts := []nats.Option{
+		nats.Name("kubewatch"),
+		nats.RetryOnFailedConnect(true),
+		nats.MaxReconnects(-1),
+		nats.ClosedHandler(func(*nats.Conn) { close(closed) }),
+	}
+	if conf.Credentials != "" {
+		opts = append(opts, nats.UserCredentials(conf.Credentials))
+	}
+	if conf.NKeyFile != "" {
+		opt, err := nats.NkeyOptionFromSeed(conf.NKeyFile)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, opt)
+	}
+	if conf.Username != "" {
+		opts = append(opts, nats.UserInfo(conf.Username, conf.Password))
+	}
+	if conf.Token != "" {
+		opts = append(opts, nats.Token(conf.Token))
+	}
+	if utils.TLSEnabled(conf.TLS) {
+		tlsConfig, err := utils.TLSConfig(conf.TLS)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, nats.Secure(tlsConfig))
+	}
+
+	conn, err := nats.Connect(n.Url, opts...)
+	if err != nil {
+		return err
+	}
+	n.conn = conn
+	n.closed = closed
+
+	if n.JetStream {
+		js, err := conn.JetStream()
+		if err != nil {
+			return err
+		}
+		n.js = js
+	}
+
+	return nil
+}
+
+// Handle handles an event.
+func (n *NATS) Handle(e event.Event) {
+	data, err := json.Marshal(e.Payload(n.PayloadVersion))
+	if err != nil {
+		logger.WithFields(e.LogFields()).Error(err)
+		return
+	}
+
+	subject := expandSubject(n.Subject, e)
+	if n.js != nil {
+		_, err = n.js.Publish(subject, data)
+	} else {
+		err = n.conn.Publish(subject, data)
+	}
+	if err != nil {
+		logger.WithFields(e.LogFields()).Errorf("Failed publishing to NATS subject %s: %v", subject, err)
+		return
+	}
+
+	logger.WithFields(e.LogFields()).Infof("Message successfully published to NATS subject %s", subject)
+}
+
+// Run drains the connection once stopCh is closed, publishing the pending
+// messages before closing it.
+func (n *NATS) Run(stopCh <-chan struct{}) {
+	<-stopCh
+	if err := n.conn.Drain(); err != nil {
+		// e.g. while reconnecting, the pending messages are lost
+		logger.Warnf("Failed draining the NATS connection: %v", err)
+		n.conn.Close()
+	}
+	<-n.closed
+}
+
+func checkMissingNATSVars(n *NATS) error {
+	if n.Url == "" {
+		return fmt.Errorf(natsErrMsg, "Missing NATS url")
+	}
+
+	return nil
+}
+
+// expandSubject replaces the placeholders of the subject template by the event values.
+func expandSubject(subject string, e event.Event) string {
+	return strings.NewReplacer(
+		"{namespace}", token(e.Namespace),
+		"{kind}", token(e.Kind),
+		"{name}", token(e.Name),
+		"{reason}", token(e.Reason),
+	).Replace(subject)
+}
+
+// token returns a valid subject token for the given value;
+// empty values, like the namespace of cluster scoped objects, become "_".
+func token(value string) string {
+	if value == "" {
+		return "_"
+	}
+	return tokenReplacer.Replace(value)
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nats
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+)
+
+func TestNATSInit(t *testing.T) {
+	s := &NATS{}
+	expectedError := fmt.Errorf(natsErrMsg, "Missing NATS url")
+
+	c := &config.Config{}
+	if err := s.Init(c); !reflect.DeepEqual(err, expectedError) {
+		t.Fatalf("Init(): %v", err)
+	}
+}
+
+func TestExpandSubject(t *testing.T) {
+	var Tests = []struct {
+		subject string
+		event   event.Event
+		want    string
+	}{
+		{defaultSubject, event.Event{Namespace: "default", Kind: "pod"}, "kubewatch.default.pod"},
+		{defaultSubject, event.Event{Kind: "daemon set"}, "kubewatch._.daemon_set"},
+		{"k8s.{kind}.{name}.{reason}", event.Event{Kind: "node", Name: "ip-10.0.0.1", Reason: "Created"}, "k8s.node.ip-10_0_0_1.Created"},
+	}
+
+	for _, tt := range Tests {
+		if got := expandSubject(tt.subject, tt.event); got != tt.want {
+			t.Fatalf("expandSubject(%q): got %q, want %q", tt.subject, got, tt.want)
+		}
+	}
+}
+
+func TestNATSRun(t *testing.T) {
+	c := &config.Config{}
+	c.Handler.NATS = config.NATS{Url: "nats://127.0.0.1:1"}
+	n := &NATS{}
+	if err := n.Init(c); err != nil {
+		t.Fatal(err)
+	}
+
+	// the connection is closed even while connecting
+	stopCh := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		n.Run(stopCh)
+		close(done)
+	}()
+	close(stopCh)
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run(): connection not closed once stopped")
+	}
+	if !n.conn.IsClosed() {
+		t.Fatal("Run(): connection not closed")
+	}
+}
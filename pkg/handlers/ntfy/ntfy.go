@@ -0,0 +1,236 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ntfy
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+	"github.com/bitnami-labs/kubewatch/pkg/metrics"
+	"github.com/bitnami-labs/kubewatch/pkg/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// logger logs the notifications sent by the handler.
+var logger = logrus.WithField("handler", "ntfy")
+
+const (
+	defaultUrl     = "https://ntfy.sh"
+	requestTimeout = 10 * time.Second
+)
+
+// defaultPriorities maps event severities to the priorities of the
+// notifications.
+var defaultPriorities = map[string]string{
+	"critical": "urgent",
+	"warning":  "high",
+	"info":     "default",
+}
+
+// priorities are the priorities of ntfy, by name and by number.
+var priorities = map[string]bool{
+	"min": true, "low": true, "default": true, "high": true, "urgent": true, "max": true,
+	"1": true, "2": true, "3": true, "4": true, "5": true,
+}
+
+// tags maps event severities to the emojis tagging the notifications.
+var tags = map[string]string{
+	"critical": "rotating_light",
+	"warning":  "warning",
+	"info":     "information_source",
+}
+
+var ntfyErrMsg = `
+%s
+
+You need to set the ntfy topic for ntfy notify,
+using "--topic/-t", or using environment variables:
+
+export KW_NTFY_TOPIC=ntfy_topic
+
+Command line flags will override environment variables
+
+`
+
+// Ntfy handler implements handler.Handler interface,
+// Notify event as push notifications of a ntfy topic
+type Ntfy struct {
+	Url        string
+	Topic      string
+	Token      string
+	Username   string
+	Password   string
+	Priorities map[string]string
+
+	click  *template.Template
+	client *http.Client
+}
+
+// Init prepares ntfy configuration
+func (n *Ntfy) Init(c *config.Config) error {
+	conf := c.Handler.Ntfy
+	topic := conf.Topic
+	token := conf.Token
+
+	if topic == "" {
+		topic = os.Getenv("KW_NTFY_TOPIC")
+	}
+
+	if token == "" {
+		token = os.Getenv("KW_NTFY_TOKEN")
+	}
+
+	url := conf.Url
+	if url == "" {
+		url = defaultUrl
+	}
+
+	n.Url = strings.TrimSuffix(url, "/")
+	n.Topic = topic
+	n.Token = token
+	n.Username = conf.Username
+	n.Password = conf.Password
+	n.Priorities = map[string]string{}
+	for severity, priority := range defaultPriorities {
+		n.Priorities[severity] = priority
+	}
+	for severity, priority := range conf.Priorities {
+		if !priorities[priority] {
+			return fmt.Errorf("ntfy: invalid priority %q of %s, must be one of min, low, default, high, urgent or 1 to 5", priority, severity)
+		}
+		n.Priorities[severity] = priority
+	}
+
+	n.click = nil
+	if conf.Click != "" {
+		click, err := template.New("click").Parse(conf.Click)
+		if err != nil {
+			return fmt.Errorf("ntfy `click` conf field is invalid: %v", err)
+		}
+		n.click = click
+	}
+
+	client, err := utils.HTTPClient("ntfy", conf.TLS)
+	if err != nil {
+		return err
+	}
+	client.Timeout = requestTimeout
+	n.client = client
+
+	return checkMissingNtfyVars(n)
+}
+
+// Handle handles an event.
+func (n *Ntfy) Handle(e event.Event) {
+	if err := n.Send(e); err != nil {
+		logger.WithFields(e.LogFields()).Error(err)
+	}
+}
+
+// Send publishes the notification of the event to the topic, returning an
+// error when it is not delivered.
+func (n *Ntfy) Send(e event.Event) error {
+	req, err := n.prepareRequest(e)
+	if err == nil {
+		err = n.publish(req)
+	}
+	if err != nil {
+		metrics.Notifications.WithLabelValues("ntfy", "failure").Inc()
+		return err
+	}
+
+	metrics.Notifications.WithLabelValues("ntfy", "success").Inc()
+	logger.WithFields(e.LogFields()).Info("Notification successfully published to ntfy")
+	return nil
+}
+
+func checkMissingNtfyVars(n *Ntfy) error {
+	if n.Topic == "" {
+		return fmt.Errorf(ntfyErrMsg, "Missing ntfy topic")
+	}
+
+	return nil
+}
+
+// prepareRequest returns the request publishing the message of the event,
+// titled with the object and its reason, with the priority of its severity,
+// tagged with their emoji and the kind, and opening the click url.
+func (n *Ntfy) prepareRequest(e event.Event) (*http.Request, error) {
+	req, err := http.NewRequest("POST", n.Url+"/"+n.Topic, strings.NewReader(e.Message()))
+	if err != nil {
+		return nil, err
+	}
+
+	title := fmt.Sprintf("%s %s %s", e.Kind, e.Name, strings.ToLower(e.Reason))
+	if e.Cluster != "" {
+		title = "[" + e.Cluster + "] " + title
+	}
+	req.Header.Set("Title", title)
+
+	severity := e.Severity
+	if severity == "" {
+		severity = "info"
+	}
+	req.Header.Set("Priority", n.Priorities[severity])
+
+	var t []string
+	if tag, ok := tags[severity]; ok {
+		t = append(t, tag)
+	}
+	if e.Kind != "" {
+		t = append(t, e.Kind)
+	}
+	req.Header.Set("Tags", strings.Join(t, ","))
+
+	if n.click != nil {
+		var b bytes.Buffer
+		if err := n.click.Execute(&b, e); err != nil {
+			return nil, fmt.Errorf("Can not format ntfy click url: %v", err)
+		}
+		req.Header.Set("Click", b.String())
+	}
+
+	if n.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+n.Token)
+	} else if n.Username != "" {
+		req.SetBasicAuth(n.Username, n.Password)
+	}
+	return req, nil
+}
+
+func (n *Ntfy) publish(req *http.Request) error {
+	res, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(res.Body)
+		return fmt.Errorf("Failed publishing to ntfy. ntfy http response: %s, %s", res.Status, string(body))
+	}
+
+	return nil
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ntfy
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+)
+
+func TestNtfyInit(t *testing.T) {
+	n := &Ntfy{}
+	expectedError := fmt.Errorf(ntfyErrMsg, "Missing ntfy topic")
+
+	var Tests = []struct {
+		ntfy config.Ntfy
+		err  error
+	}{
+		{config.Ntfy{Topic: "foo"}, nil},
+		{config.Ntfy{Topic: "foo", Priorities: map[string]string{"info": "low", "warning": "5"}}, nil},
+		{config.Ntfy{Topic: "foo", Priorities: map[string]string{"info": "loud"}},
+			fmt.Errorf("ntfy: invalid priority %q of %s, must be one of min, low, default, high, urgent or 1 to 5", "loud", "info")},
+		{config.Ntfy{}, expectedError},
+	}
+
+	for _, tt := range Tests {
+		c := &config.Config{}
+		c.Handler.Ntfy = tt.ntfy
+		if err := n.Init(c); !reflect.DeepEqual(err, tt.err) {
+			t.Fatalf("Init(): %v", err)
+		}
+	}
+
+	c := &config.Config{}
+	c.Handler.Ntfy = config.Ntfy{Topic: "foo", Click: "{{.Name"}
+	if err := n.Init(c); err == nil {
+		t.Fatal("Init(): no error for an invalid click template")
+	}
+}
+
+func TestNtfySend(t *testing.T) {
+	var got *http.Request
+	var body string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		got, body = r, string(b)
+	}))
+	defer ts.Close()
+
+	c := &config.Config{}
+	c.Handler.Ntfy = config.Ntfy{
+		Url:        ts.URL,
+		Topic:      "alerts",
+		Token:      "tk_foo",
+		Priorities: map[string]string{"warning": "default"},
+		Click:      "https://grafana.example.com/d/pods?var-namespace={{.Namespace}}",
+	}
+	n := &Ntfy{}
+	if err := n.Init(c); err != nil {
+		t.Fatal(err)
+	}
+
+	e := event.Event{Cluster: "prod", Namespace: "shop", Kind: "pod", Name: "web", Reason: "Deleted", Severity: "critical"}
+	if err := n.Send(e); err != nil {
+		t.Fatal(err)
+	}
+	if got.URL.Path != "/alerts" || body != e.Message() {
+		t.Fatalf("got %s %q", got.URL.Path, body)
+	}
+	want := map[string]string{
+		"Title":         "[prod] pod web deleted",
+		"Priority":      "urgent",
+		"Tags":          "rotating_light,pod",
+		"Click":         "https://grafana.example.com/d/pods?var-namespace=shop",
+		"Authorization": "Bearer tk_foo",
+	}
+	for k, v := range want {
+		if got.Header.Get(k) != v {
+			t.Errorf("header %s: got %q, want %q", k, got.Header.Get(k), v)
+		}
+	}
+
+	e.Severity = "warning"
+	if err := n.Send(e); err != nil {
+		t.Fatal(err)
+	}
+	if got.Header.Get("Priority") != "default" || got.Header.Get("Tags") != "warning,pod" {
+		t.Fatalf("got priority %q and tags %q", got.Header.Get("Priority"), got.Header.Get("Tags"))
+	}
+}
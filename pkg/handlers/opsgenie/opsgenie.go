@@ -0,0 +1,228 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package opsgenie
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+	"github.com/bitnami-labs/kubewatch/pkg/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// logger logs the notifications sent by the handler.
+var logger = logrus.WithField("handler", "opsgenie")
+
+const defaultURL = "https://api.opsgenie.com/v2/alerts"
+
+// defaultPriorities maps event statuses to alert priorities,
+// used when no priority is configured for an event.
+var defaultPriorities = map[string]string{
+	"Normal":  "P5",
+	"Warning": "P3",
+	"Danger":  "P2",
+}
+
+// severityPriorities maps event severities to alert priorities,
+// taking precedence over the status priorities.
+var severityPriorities = map[string]string{
+	"critical": "P1",
+	"warning":  "P3",
+	"info":     "P5",
+}
+
+var opsgenieErrMsg = `
+%s
+
+You need to set the OpsGenie API key,
+using "--apikey/-k", or using environment variables:
+
+export KW_OPSGENIE_APIKEY=opsgenie_api_key
+
+Command line flags will override environment variables
+
+`
+
+// OpsGenie handler implements handler.Handler interface,
+// Notify event as OpsGenie alerts
+type OpsGenie struct {
+	APIKey     string
+	Url        string
+	Teams      []string
+	Priority   string
+	Priorities map[string]string
+	client     *http.Client
+}
+
+// Alert is the request body of the OpsGenie create alert API
+// The Documentation is in https://docs.opsgenie.com/docs/alert-api#create-alert
+type Alert struct {
+	Message     string            `json:"message"`
+	Alias       string            `json:"alias"`
+	Description string            `json:"description,omitempty"`
+	Responders  []Responder       `json:"responders,omitempty"`
+	Tags        []string          `json:"tags,omitempty"`
+	Details     map[string]string `json:"details,omitempty"`
+	Entity      string            `json:"entity,omitempty"`
+	Source      string            `json:"source"`
+	Priority    string            `json:"priority,omitempty"`
+}
+
+// Responder is a team an alert is routed to
+type Responder struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// Init prepares OpsGenie configuration
+func (o *OpsGenie) Init(c *config.Config) error {
+	apiKey := c.Handler.OpsGenie.APIKey
+	url := c.Handler.OpsGenie.Url
+
+	if apiKey == "" {
+		apiKey = os.Getenv("KW_OPSGENIE_APIKEY")
+	}
+
+	if url == "" {
+		url = defaultURL
+	}
+
+	o.APIKey = apiKey
+	o.Url = url
+	o.Teams = c.Handler.OpsGenie.Teams
+	o.Priority = c.Handler.OpsGenie.Priority
+	o.Priorities = c.Handler.OpsGenie.Priorities
+	client, err := utils.HTTPClient("opsgenie", c.Handler.OpsGenie.TLS)
+	if err != nil {
+		return err
+	}
+	o.client = client
+
+	return checkMissingOpsGenieVars(o)
+}
+
+// Handle handles an event.
+func (o *OpsGenie) Handle(e event.Event) {
+	alert := prepareAlert(e, o)
+
+	if err := postAlert(o, alert); err != nil {
+		logger.WithFields(e.LogFields()).Error(err)
+		return
+	}
+
+	logger.WithFields(e.LogFields()).Infof("Alert %s successfully sent to OpsGenie", alert.Alias)
+}
+
+func checkMissingOpsGenieVars(o *OpsGenie) error {
+	if o.APIKey == "" {
+		return fmt.Errorf(opsgenieErrMsg, "Missing OpsGenie API key")
+	}
+
+	return nil
+}
+
+// priority returns the configured priority for the event, looking up
+// "kind/reason", then "kind", then "reason" in the priority mapping.
+func (o *OpsGenie) priority(e event.Event) string {
+	for _, key := range []string{e.Kind + "/" + e.Reason, e.Kind, e.Reason} {
+		if p, ok := o.Priorities[key]; ok {
+			return p
+		}
+	}
+	if o.Priority != "" {
+		return o.Priority
+	}
+	if p, ok := severityPriorities[e.Severity]; ok {
+		return p
+	}
+	return defaultPriorities[e.Status]
+}
+
+func prepareAlert(e event.Event, o *OpsGenie) *Alert {
+	// alerts sharing the same alias are deduplicated by OpsGenie while open,
+	// so that a flapping object doesn't open a new alert on every event
+	alias := strings.Join([]string{e.Kind, e.Namespace, e.Name}, "/")
+	if e.Cluster != "" {
+		alias = e.Cluster + "/" + alias
+	}
+
+	alert := &Alert{
+		Message:     fmt.Sprintf("%s %s %s", e.Kind, e.Name, strings.ToLower(e.Reason)),
+		Alias:       alias,
+		Description: e.Message(),
+		Tags:        []string{e.Kind, e.Reason},
+		Details: map[string]string{
+			"cluster":   e.Cluster,
+			"kind":      e.Kind,
+			"name":      e.Name,
+			"namespace": e.Namespace,
+			"reason":    e.Reason,
+			"status":    e.Status,
+			"severity":  e.Severity,
+		},
+		Entity:   alias,
+		Source:   "kubewatch",
+		Priority: o.priority(e),
+	}
+	for k, v := range e.Metadata {
+		alert.Details[k] = v
+	}
+	for k, v := range e.ExternalLabels {
+		alert.Details[k] = v
+	}
+	if e.Namespace != "" {
+		alert.Tags = append(alert.Tags, e.Namespace)
+	}
+	for _, team := range o.Teams {
+		alert.Responders = append(alert.Responders, Responder{Name: team, Type: "team"})
+	}
+	return alert
+}
+
+func postAlert(o *OpsGenie, alert *Alert) error {
+	message, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", o.Url, bytes.NewBuffer(message))
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("Authorization", "GenieKey "+o.APIKey)
+
+	res, err := o.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode/100 != 2 {
+		body, _ := ioutil.ReadAll(res.Body)
+		return fmt.Errorf("Failed sending alert to OpsGenie. OpsGenie http response: %s, %s", res.Status, string(body))
+	}
+
+	return nil
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package opsgenie
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+)
+
+func TestOpsGenieInit(t *testing.T) {
+	s := &OpsGenie{}
+	expectedError := fmt.Errorf(opsgenieErrMsg, "Missing OpsGenie API key")
+
+	var Tests = []struct {
+		opsgenie config.OpsGenie
+		err      error
+	}{
+		{config.OpsGenie{APIKey: "foo"}, nil},
+		{config.OpsGenie{}, expectedError},
+	}
+
+	for _, tt := range Tests {
+		c := &config.Config{}
+		c.Handler.OpsGenie = tt.opsgenie
+		if err := s.Init(c); !reflect.DeepEqual(err, tt.err) {
+			t.Fatalf("Init(): %v", err)
+		}
+	}
+
+	c := &config.Config{}
+	c.Handler.OpsGenie = config.OpsGenie{APIKey: "foo", TLS: config.TLS{CAFile: "/nonexistent"}}
+	if err := s.Init(c); err == nil {
+		t.Fatal("Init(): expected an error for a missing CA file")
+	}
+}
+
+func TestOpsGenieHandle(t *testing.T) {
+	var got Alert
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if auth := r.Header.Get("Authorization"); auth != "GenieKey foo" {
+			t.Errorf("unexpected Authorization header %q", auth)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("%v", err)
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer ts.Close()
+
+	o := &OpsGenie{}
+	c := &config.Config{}
+	c.Handler.OpsGenie = config.OpsGenie{
+		APIKey:     "foo",
+		Url:        ts.URL,
+		Teams:      []string{"sre"},
+		Priorities: map[string]string{"deployment/Deleted": "P1"},
+	}
+	if err := o.Init(c); err != nil {
+		t.Fatal(err)
+	}
+	o.Handle(event.Event{Kind: "deployment", Namespace: "prod", Name: "app", Reason: "Deleted", Status: "Danger"})
+
+	if got.Alias != "deployment/prod/app" {
+		t.Errorf("unexpected alias %q", got.Alias)
+	}
+	if got.Priority != "P1" {
+		t.Errorf("unexpected priority %q", got.Priority)
+	}
+	if want := []Responder{{Name: "sre", Type: "team"}}; !reflect.DeepEqual(got.Responders, want) {
+		t.Errorf("unexpected responders %v", got.Responders)
+	}
+}
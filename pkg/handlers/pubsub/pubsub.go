@@ -0,0 +1,242 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package pubsub implements a handler publishing events to a Google Cloud Pub/Sub topic.
+
+Credentials are looked up as Application Default Credentials, which includes
+GKE workload identity.
+*/
+package pubsub
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+	"github.com/sirupsen/logrus"
+)
+
+// logger logs the notifications sent by the handler.
+var logger = logrus.WithField("handler", "pubsub")
+
+const (
+	defaultEndpoint = "https://pubsub.googleapis.com"
+	pubsubScope     = "https://www.googleapis.com/auth/pubsub"
+)
+
+var pubsubErrMsg = `
+%s
+
+You need to set the Pub/Sub topic,
+using "--topic/-t", or using environment variables:
+
+export KW_PUBSUB_TOPIC=pubsub_topic
+
+Command line flags will override environment variables
+
+`
+
+// PubSub handler implements handler.Handler interface,
+// Publish events to a Google Cloud Pub/Sub topic
+type PubSub struct {
+	Project        string
+	Topic          string
+	OrderingKey    string
+	Attributes     map[string]string
+	Endpoint       string
+	PayloadVersion string
+
+	once      sync.Once
+	client    *http.Client
+	clientErr error
+}
+
+// PublishRequest is the request body of the Pub/Sub publish API
+// The Documentation is in https://cloud.google.com/pubsub/docs/reference/rest/v1/projects.topics/publish
+type PublishRequest struct {
+	Messages []Message `json:"messages"`
+}
+
+// Message is a Pub/Sub message; Data is base64 encoded by encoding/json
+type Message struct {
+	Data        []byte            `json:"data"`
+	Attributes  map[string]string `json:"attributes,omitempty"`
+	OrderingKey string            `json:"orderingKey,omitempty"`
+}
+
+// Init prepares Pub/Sub configuration
+func (p *PubSub) Init(c *config.Config) error {
+	p.PayloadVersion = c.PayloadVersion
+	conf := c.Handler.PubSub
+	project := conf.Project
+	topic := conf.Topic
+
+	if project == "" {
+		project = os.Getenv("KW_PUBSUB_PROJECT")
+	}
+
+	if topic == "" {
+		topic = os.Getenv("KW_PUBSUB_TOPIC")
+	}
+
+	p.Project = project
+	p.Topic = topic
+	p.OrderingKey = conf.OrderingKey
+	p.Attributes = conf.Attributes
+	p.Endpoint = conf.Endpoint
+	if p.Endpoint == "" {
+		p.Endpoint = defaultEndpoint
+	}
+
+	if err := checkMissingPubSubVars(p); err != nil {
+		return err
+	}
+
+	switch p.OrderingKey {
+	case "", "name", "namespace", "kind":
+	default:
+		return fmt.Errorf("invalid pubsub orderingKey %q, must be one of namespace, kind or name", p.OrderingKey)
+	}
+
+	// topics can be given as full resource names
+	if strings.HasPrefix(p.Topic, "projects/") {
+		return nil
+	}
+	if p.Project == "" {
+		creds, err := google.FindDefaultCredentials(context.Background(), pubsubScope)
+		if err != nil {
+			return err
+		}
+		if creds.ProjectID == "" {
+			return fmt.Errorf("cannot find the Google Cloud project, please set the pubsub project")
+		}
+		p.Project = creds.ProjectID
+	}
+	p.Topic = fmt.Sprintf("projects/%s/topics/%s", p.Project, p.Topic)
+
+	return nil
+}
+
+// Handle handles an event.
+func (p *PubSub) Handle(e event.Event) {
+	data, err := json.Marshal(e.Payload(p.PayloadVersion))
+	if err != nil {
+		logger.WithFields(e.LogFields()).Error(err)
+		return
+	}
+
+	msg := Message{
+		Data:        data,
+		Attributes:  attributes(e, p.Attributes),
+		OrderingKey: orderingKey(e, p.OrderingKey),
+	}
+	if err := p.publish(msg); err != nil {
+		logger.WithFields(e.LogFields()).Errorf("Failed publishing to %s: %v", p.Topic, err)
+		return
+	}
+
+	logger.WithFields(e.LogFields()).Infof("Message successfully published to %s", p.Topic)
+}
+
+func checkMissingPubSubVars(p *PubSub) error {
+	if p.Topic == "" {
+		return fmt.Errorf(pubsubErrMsg, "Missing pubsub topic")
+	}
+
+	return nil
+}
+
+// httpClient returns a client authenticated with the application default credentials.
+func (p *PubSub) httpClient() (*http.Client, error) {
+	p.once.Do(func() {
+		if p.client != nil {
+			return
+		}
+		ctx := context.Background()
+		ts, err := google.DefaultTokenSource(ctx, pubsubScope)
+		if err != nil {
+			p.clientErr = err
+			return
+		}
+		p.client = oauth2.NewClient(ctx, ts)
+	})
+	return p.client, p.clientErr
+}
+
+func (p *PubSub) publish(msg Message) error {
+	client, err := p.httpClient()
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(PublishRequest{Messages: []Message{msg}})
+	if err != nil {
+		return err
+	}
+
+	url := fmt.Sprintf("%s/v1/%s:publish", strings.TrimSuffix(p.Endpoint, "/"), p.Topic)
+	res, err := client.Post(url, "application/json", bytes.NewBuffer(body))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode/100 != 2 {
+		resBody, _ := ioutil.ReadAll(res.Body)
+		return fmt.Errorf("Pub/Sub http response: %s, %s", res.Status, string(resBody))
+	}
+	return nil
+}
+
+// attributes returns the message attributes subscribers can filter on;
+// empty values are omitted.
+func attributes(e event.Event, static map[string]string) map[string]string {
+	attrs := map[string]string{}
+	for k, v := range static {
+		attrs[k] = v
+	}
+	for k, v := range map[string]string{"kind": e.Kind, "namespace": e.Namespace, "reason": e.Reason} {
+		if v != "" {
+			attrs[k] = v
+		}
+	}
+	return attrs
+}
+
+func orderingKey(e event.Event, key string) string {
+	switch key {
+	case "namespace":
+		return e.Namespace
+	case "kind":
+		return e.Kind
+	case "name":
+		return strings.Join([]string{e.Kind, e.Namespace, e.Name}, "/")
+	default:
+		return ""
+	}
+}
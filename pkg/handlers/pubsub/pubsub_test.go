@@ -0,0 +1,90 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+)
+
+func TestPubSubInit(t *testing.T) {
+	s := &PubSub{}
+	expectedError := fmt.Errorf(pubsubErrMsg, "Missing pubsub topic")
+
+	var Tests = []struct {
+		pubsub config.PubSub
+		err    error
+	}{
+		{config.PubSub{Project: "foo", Topic: "bar"}, nil},
+		{config.PubSub{Topic: "projects/foo/topics/bar"}, nil},
+		{config.PubSub{Project: "foo"}, expectedError},
+	}
+
+	for _, tt := range Tests {
+		c := &config.Config{}
+		c.Handler.PubSub = tt.pubsub
+		if err := s.Init(c); !reflect.DeepEqual(err, tt.err) {
+			t.Fatalf("Init(): %v", err)
+		}
+	}
+}
+
+func TestPubSubHandle(t *testing.T) {
+	var got PublishRequest
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/projects/foo/topics/bar:publish" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("%v", err)
+		}
+	}))
+	defer ts.Close()
+
+	p := &PubSub{client: ts.Client()}
+	c := &config.Config{}
+	c.Handler.PubSub = config.PubSub{
+		Project:     "foo",
+		Topic:       "bar",
+		OrderingKey: "namespace",
+		Attributes:  map[string]string{"cluster": "prod"},
+		Endpoint:    ts.URL,
+	}
+	if err := p.Init(c); err != nil {
+		t.Fatal(err)
+	}
+	p.Handle(event.Event{Kind: "pod", Namespace: "default", Name: "foo", Reason: "Created"})
+
+	if len(got.Messages) != 1 {
+		t.Fatalf("unexpected messages %v", got.Messages)
+	}
+	msg := got.Messages[0]
+	if msg.OrderingKey != "default" {
+		t.Errorf("unexpected ordering key %q", msg.OrderingKey)
+	}
+	want := map[string]string{"cluster": "prod", "kind": "pod", "namespace": "default", "reason": "Created"}
+	if !reflect.DeepEqual(msg.Attributes, want) {
+		t.Errorf("unexpected attributes %v", msg.Attributes)
+	}
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handlers
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+	"github.com/bitnami-labs/kubewatch/pkg/queue"
+	"github.com/bitnami-labs/kubewatch/pkg/tracing"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Queued implements the Handler interface, queueing the events the wrapped
+// sender fails to deliver and replaying them in order once it recovers
+type Queued struct {
+	wrapper
+	Name          string
+	Sender        Sender
+	Queue         *queue.Queue
+	RetryInterval time.Duration
+
+	// mu keeps the events in order between Handle and the replays
+	mu sync.Mutex
+}
+
+// Handle handles an event. It is queued when the sender fails, or when
+// older events are still queued.
+func (q *Queued) Handle(e event.Event) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	logger := logrus.WithField("handler", q.Name).WithFields(e.LogFields())
+	if q.Queue.Len() == 0 {
+		err := q.Sender.Send(e)
+		if err == nil {
+			return
+		}
+		logger.Warnf("Delivery failed, queueing the event: %v", err)
+	}
+	if err := q.Queue.Push(e); err != nil {
+		logger.Errorf("Can not queue the event: %v", err)
+		return
+	}
+	tracing.AddEvent(e, "queued", attribute.String("kubewatch.handler", q.Name))
+}
+
+// Run replays the queued events every RetryInterval until stopCh is closed,
+// while running the background work of the wrapped sender.
+func (q *Queued) Run(stopCh <-chan struct{}) {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		Run(q.Sender, stopCh)
+	}()
+
+	ticker := time.NewTicker(q.RetryInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stopCh:
+			<-done
+			return
+		case <-ticker.C:
+			q.replay()
+		}
+	}
+}
+
+// replay sends the queued events, oldest first, until one fails.
+func (q *Queued) replay() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for {
+		item, ok := q.Queue.Peek()
+		if !ok {
+			return
+		}
+		logger := logrus.WithField("handler", q.Name).WithFields(item.Event.LogFields())
+		if err := q.Sender.Send(item.Event); err != nil {
+			logger.Debugf("Replay failed, %d events still queued: %v", q.Queue.Len(), err)
+			return
+		}
+		logger.Infof("Replayed event queued at %s", item.Queued.Format(time.RFC3339))
+		if err := q.Queue.Remove(item.ID); err != nil {
+			logger.Errorf("Can not remove the replayed event from the queue: %v", err)
+			return
+		}
+	}
+}
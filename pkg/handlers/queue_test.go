@@ -0,0 +1,81 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handlers
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+	"github.com/bitnami-labs/kubewatch/pkg/queue"
+)
+
+// runnerSender is a sender doing background work, reporting when its Run
+// starts and returns.
+type runnerSender struct {
+	started, stopped chan struct{}
+}
+
+func (s *runnerSender) Init(c *config.Config) error { return nil }
+func (s *runnerSender) Handle(e event.Event)        {}
+func (s *runnerSender) Send(e event.Event) error    { return nil }
+
+func (s *runnerSender) Run(stopCh <-chan struct{}) {
+	close(s.started)
+	<-stopCh
+	close(s.stopped)
+}
+
+func TestQueuedRunsSender(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kubewatch-queue")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	q, err := queue.Open("test", dir, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sender := &runnerSender{started: make(chan struct{}), stopped: make(chan struct{})}
+	queued := &Queued{Name: "test", Sender: sender, Queue: q, RetryInterval: time.Hour}
+	stopCh, done := make(chan struct{}), make(chan struct{})
+	go func() {
+		Run(queued, stopCh)
+		close(done)
+	}()
+
+	select {
+	case <-sender.started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run() of the sender was not called")
+	}
+	close(stopCh)
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run() did not return")
+	}
+	select {
+	case <-sender.stopped:
+	default:
+		t.Fatal("Run() returned before the one of the sender")
+	}
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handlers
+
+import (
+	"time"
+
+	"github.com/bitnami-labs/kubewatch/pkg/digest"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+	"github.com/bitnami-labs/kubewatch/pkg/schedule"
+	"github.com/bitnami-labs/kubewatch/pkg/tracing"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// quietCheckInterval is how often the end of the quiet hours is checked,
+// to send the digest of the suppressed events.
+const quietCheckInterval = time.Minute
+
+// Quiet implements the Handler interface, dropping the events suppressed by
+// the quiet hours before passing the others to the wrapped handler. With a
+// Digest, the suppressed events are summarized once the quiet hours are over.
+type Quiet struct {
+	wrapper
+	Name       string
+	QuietHours *schedule.QuietHours
+	Digest     *digest.Digest
+}
+
+// Handle handles an event.
+func (q *Quiet) Handle(e event.Event) {
+	if q.QuietHours.Suppresses(e, time.Now()) {
+		logrus.WithField("handler", q.Name).WithFields(e.LogFields()).Debug("Event suppressed by quiet hours")
+		tracing.AddEvent(e, "suppressed by quiet hours", attribute.String("kubewatch.handler", q.Name))
+		if q.Digest != nil {
+			q.Digest.Add(e)
+		}
+		return
+	}
+	q.Handler.Handle(e)
+}
+
+// Run runs the background work of the wrapped handler, sending the digest
+// of the suppressed events after the quiet hours.
+func (q *Quiet) Run(stopCh <-chan struct{}) {
+	if q.Digest == nil {
+		Run(q.Handler, stopCh)
+		return
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		Run(q.Handler, stopCh)
+	}()
+
+	ticker := time.NewTicker(quietCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stopCh:
+			<-done
+			return
+		case now := <-ticker.C:
+			if q.QuietHours.Active(now) {
+				continue
+			}
+			if e, ok := q.Digest.Flush("the quiet hours"); ok {
+				q.Handler.Handle(e)
+			}
+		}
+	}
+}
@@ -0,0 +1,206 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rocketchat
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+	"github.com/bitnami-labs/kubewatch/pkg/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// logger logs the notifications sent by the handler.
+var logger = logrus.WithField("handler", "rocketchat")
+
+var rocketchatColors = map[string]string{
+	"Normal":  "#2eb886",
+	"Warning": "#daa038",
+	"Danger":  "#a30200",
+}
+
+var rocketchatErrMsg = `
+%s
+
+You need to set the Rocket.Chat incoming webhook url for Rocket.Chat notify,
+using "--url/-u", or using environment variables:
+
+export KW_ROCKETCHAT_URL=rocketchat_webhook_url
+
+Command line flags will override environment variables
+
+`
+
+// RocketChat handler implements handler.Handler interface,
+// Notify event to a Rocket.Chat incoming webhook
+type RocketChat struct {
+	Url      string
+	Channel  string
+	Username string
+	IconUrl  string
+	// NamespaceChannels are the channels of the events by namespace
+	NamespaceChannels map[string]string
+
+	client *http.Client
+}
+
+// RocketChatMessage struct for messages
+type RocketChatMessage struct {
+	Text        string                 `json:"text"`
+	Channel     string                 `json:"channel,omitempty"`
+	Username    string                 `json:"username,omitempty"`
+	IconUrl     string                 `json:"icon_url,omitempty"`
+	Attachments []RocketChatAttachment `json:"attachments"`
+}
+
+// RocketChatAttachment for message attachments
+type RocketChatAttachment struct {
+	Title  string            `json:"title"`
+	Text   string            `json:"text"`
+	Color  string            `json:"color"`
+	Fields []RocketChatField `json:"fields"`
+}
+
+// RocketChatField for attachment fields
+type RocketChatField struct {
+	Short bool   `json:"short"`
+	Title string `json:"title"`
+	Value string `json:"value"`
+}
+
+// Init prepares Rocket.Chat configuration
+func (r *RocketChat) Init(c *config.Config) error {
+	url := c.Handler.RocketChat.Url
+	channel := c.Handler.RocketChat.Channel
+	username := c.Handler.RocketChat.Username
+
+	if url == "" {
+		url = os.Getenv("KW_ROCKETCHAT_URL")
+	}
+
+	if channel == "" {
+		channel = os.Getenv("KW_ROCKETCHAT_CHANNEL")
+	}
+
+	if username == "" {
+		username = os.Getenv("KW_ROCKETCHAT_USERNAME")
+	}
+
+	r.Url = url
+	r.Channel = channel
+	r.NamespaceChannels = c.Handler.RocketChat.NamespaceChannels
+	r.Username = username
+	r.IconUrl = c.Handler.RocketChat.IconUrl
+
+	if err := checkMissingRocketChatVars(r); err != nil {
+		return err
+	}
+
+	client, err := utils.HTTPClient("rocketchat", c.Handler.RocketChat.TLS)
+	if err != nil {
+		return fmt.Errorf("rocketchat tls: %v", err)
+	}
+	r.client = client
+	return nil
+}
+
+// Handle handles an event.
+func (r *RocketChat) Handle(e event.Event) {
+	if err := r.Send(e); err != nil {
+		logger.WithFields(e.LogFields()).Error(err)
+	}
+}
+
+// Send sends an event, returning an error when it is not delivered.
+func (r *RocketChat) Send(e event.Event) error {
+	rocketchatMessage := prepareRocketChatMessage(e, r)
+
+	if err := postMessage(r.client, r.Url, rocketchatMessage); err != nil {
+		return err
+	}
+
+	logger.WithFields(e.LogFields()).Info("Message successfully sent to Rocket.Chat")
+	return nil
+}
+
+func checkMissingRocketChatVars(r *RocketChat) error {
+	if r.Url == "" {
+		return fmt.Errorf(rocketchatErrMsg, "Missing Rocket.Chat webhook url")
+	}
+
+	return nil
+}
+
+func prepareRocketChatMessage(e event.Event, r *RocketChat) *RocketChatMessage {
+	fields := []RocketChatField{
+		{Short: true, Title: "Kind", Value: e.Kind},
+		{Short: true, Title: "Name", Value: e.Name},
+	}
+	if e.Namespace != "" {
+		fields = append(fields, RocketChatField{Short: true, Title: "Namespace", Value: e.Namespace})
+	}
+	fields = append(fields, RocketChatField{Short: true, Title: "Reason", Value: e.Reason})
+	if e.Severity != "" {
+		fields = append(fields, RocketChatField{Short: true, Title: "Severity", Value: e.Severity})
+	}
+
+	return &RocketChatMessage{
+		Channel:  e.Channel("rocketchat", e.NamespaceChannel(r.NamespaceChannels, r.Channel)),
+		Username: r.Username,
+		IconUrl:  r.IconUrl,
+		Attachments: []RocketChatAttachment{
+			{
+				Title:  "kubewatch",
+				Text:   e.Message(),
+				Color:  rocketchatColors[e.Status],
+				Fields: fields,
+			},
+		},
+	}
+}
+
+func postMessage(client *http.Client, url string, rocketchatMessage *RocketChatMessage) error {
+	message, err := json.Marshal(rocketchatMessage)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(message))
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Content-Type", "application/json")
+
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode/100 != 2 {
+		body, _ := ioutil.ReadAll(res.Body)
+		return fmt.Errorf("Failed posting to Rocket.Chat. Rocket.Chat http response: %s, %s", res.Status, string(body))
+	}
+
+	return nil
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rocketchat
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+)
+
+func TestRocketChatInit(t *testing.T) {
+	s := &RocketChat{}
+	expectedError := fmt.Errorf(rocketchatErrMsg, "Missing Rocket.Chat webhook url")
+
+	var Tests = []struct {
+		rocketchat config.RocketChat
+		err        error
+	}{
+		{config.RocketChat{Url: "foo"}, nil},
+		{config.RocketChat{Url: "foo", Channel: "#alerts", Username: "kubewatch"}, nil},
+		{config.RocketChat{Channel: "#alerts"}, expectedError},
+		{config.RocketChat{}, expectedError},
+	}
+
+	for _, tt := range Tests {
+		c := &config.Config{}
+		c.Handler.RocketChat = tt.rocketchat
+		if err := s.Init(c); !reflect.DeepEqual(err, tt.err) {
+			t.Fatalf("Init(): %v", err)
+		}
+	}
+}
+
+func TestRocketChatHandle(t *testing.T) {
+	var got RocketChatMessage
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer ts.Close()
+
+	c := &config.Config{}
+	c.Handler.RocketChat = config.RocketChat{Url: ts.URL, Channel: "#alerts", NamespaceChannels: map[string]string{"payments": "#payments-alerts"}}
+	s := &RocketChat{}
+	if err := s.Init(c); err != nil {
+		t.Fatal(err)
+	}
+	s.Handle(event.Event{Kind: "pod", Name: "api", Namespace: "payments", Reason: "Created", Status: "Normal"})
+	if got.Channel != "#payments-alerts" {
+		t.Fatalf("channel of the namespace: got %q", got.Channel)
+	}
+	s.Handle(event.Event{Kind: "pod", Name: "web", Namespace: "default", Reason: "Deleted", Status: "Danger"})
+
+	if got.Channel != "#alerts" {
+		t.Fatalf("channel: got %q", got.Channel)
+	}
+	if len(got.Attachments) != 1 || got.Attachments[0].Color != rocketchatColors["Danger"] {
+		t.Fatalf("attachments: got %+v", got.Attachments)
+	}
+	if n := len(got.Attachments[0].Fields); n != 4 {
+		t.Fatalf("fields: got %d, want 4", n)
+	}
+}
@@ -0,0 +1,299 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package servicenow
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+	"github.com/bitnami-labs/kubewatch/pkg/metrics"
+	"github.com/bitnami-labs/kubewatch/pkg/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// logger logs the notifications sent by the handler.
+var logger = logrus.WithField("handler", "servicenow")
+
+const (
+	incidentPath   = "/api/now/table/incident"
+	eventPath      = "/api/global/em/jsonv2"
+	requestTimeout = 10 * time.Second
+)
+
+// defaultLevels maps event severities to the urgency and impact of the
+// incidents, used when none is configured for a severity.
+var defaultLevels = map[string]string{
+	"critical": "1",
+	"warning":  "2",
+	"info":     "3",
+}
+
+// eventSeverities maps event severities to the severities of the
+// Event Management events, from 1 (critical) to 5 (info).
+var eventSeverities = map[string]string{
+	"critical": "1",
+	"warning":  "4",
+	"info":     "5",
+}
+
+var servicenowErrMsg = `
+%s
+
+You need to set the ServiceNow url and credentials,
+using "--url/-u", "--username" and "--password", or using environment variables:
+
+export KW_SERVICENOW_URL=https://mycompany.service-now.com
+export KW_SERVICENOW_USERNAME=kubewatch
+export KW_SERVICENOW_PASSWORD=servicenow_password
+
+Command line flags will override environment variables
+
+`
+
+// ServiceNow handler implements handler.Handler interface,
+// Notify event as ServiceNow incidents or events
+type ServiceNow struct {
+	Url             string
+	Username        string
+	Password        string
+	Mode            string
+	AssignmentGroup string
+	Urgency         map[string]string
+	Impact          map[string]string
+
+	client *http.Client
+}
+
+// Incident is the request body of the Table API creating an incident
+type Incident struct {
+	ShortDescription string `json:"short_description"`
+	Description      string `json:"description"`
+	Urgency          string `json:"urgency,omitempty"`
+	Impact           string `json:"impact,omitempty"`
+	AssignmentGroup  string `json:"assignment_group,omitempty"`
+	CorrelationID    string `json:"correlation_id"`
+	CorrelationName  string `json:"correlation_display"`
+}
+
+// Events is the request body of the Event Management API
+// The Documentation is in https://docs.servicenow.com/bundle/quebec-it-operations-management/page/product/event-management/task/send-events-via-web-service.html
+type Events struct {
+	Records []Event `json:"records"`
+}
+
+// Event is an Event Management event
+type Event struct {
+	Source         string `json:"source"`
+	Node           string `json:"node,omitempty"`
+	Type           string `json:"type"`
+	Resource       string `json:"resource"`
+	MetricName     string `json:"metric_name"`
+	MessageKey     string `json:"message_key"`
+	Severity       string `json:"severity"`
+	Description    string `json:"description"`
+	AdditionalInfo string `json:"additional_info,omitempty"`
+}
+
+// Init prepares ServiceNow configuration
+func (s *ServiceNow) Init(c *config.Config) error {
+	conf := c.Handler.ServiceNow
+	url := conf.Url
+	username := conf.BasicAuth.Username
+	password := conf.BasicAuth.Password
+
+	if url == "" {
+		url = os.Getenv("KW_SERVICENOW_URL")
+	}
+
+	if username == "" {
+		username = os.Getenv("KW_SERVICENOW_USERNAME")
+	}
+
+	if password == "" {
+		password = os.Getenv("KW_SERVICENOW_PASSWORD")
+	}
+
+	s.Url = strings.TrimSuffix(url, "/")
+	s.Username = username
+	s.Password = password
+	s.AssignmentGroup = conf.AssignmentGroup
+	s.Urgency = conf.Urgency
+	s.Impact = conf.Impact
+
+	s.Mode = conf.Mode
+	switch s.Mode {
+	case "":
+		s.Mode = "incident"
+	case "incident", "event":
+	default:
+		return fmt.Errorf("invalid servicenow mode %q, must be incident or event", conf.Mode)
+	}
+
+	client, err := utils.HTTPClient("servicenow", conf.TLS)
+	if err != nil {
+		return err
+	}
+	client.Timeout = requestTimeout
+	s.client = client
+
+	return checkMissingServiceNowVars(s)
+}
+
+// Handle handles an event.
+func (s *ServiceNow) Handle(e event.Event) {
+	if err := s.Send(e); err != nil {
+		logger.WithFields(e.LogFields()).Error(err)
+	}
+}
+
+// Send creates an incident or an event for the event, returning an error
+// when ServiceNow doesn't accept it.
+func (s *ServiceNow) Send(e event.Event) error {
+	path, body := incidentPath, interface{}(prepareIncident(e, s))
+	if s.Mode == "event" {
+		path, body = eventPath, &Events{Records: []Event{prepareEvent(e)}}
+	}
+
+	if err := s.post(path, body); err != nil {
+		metrics.Notifications.WithLabelValues("servicenow", "failure").Inc()
+		return err
+	}
+
+	metrics.Notifications.WithLabelValues("servicenow", "success").Inc()
+	logger.WithFields(e.LogFields()).Infof("ServiceNow %s successfully created", s.Mode)
+	return nil
+}
+
+func checkMissingServiceNowVars(s *ServiceNow) error {
+	if s.Url == "" || s.Username == "" || s.Password == "" {
+		return fmt.Errorf(servicenowErrMsg, "Missing ServiceNow url, username or password")
+	}
+
+	return nil
+}
+
+// objectKey identifies the object of the event.
+func objectKey(e event.Event) string {
+	key := strings.Join([]string{e.Kind, e.Namespace, e.Name}, "/")
+	if e.Cluster != "" {
+		key = e.Cluster + "/" + key
+	}
+	return key
+}
+
+// level returns the urgency or impact of the severity.
+func level(levels map[string]string, severity string) string {
+	if l, ok := levels[severity]; ok {
+		return l
+	}
+	if l, ok := defaultLevels[severity]; ok {
+		return l
+	}
+	return defaultLevels["info"]
+}
+
+func prepareIncident(e event.Event, s *ServiceNow) *Incident {
+	summary := fmt.Sprintf("%s %s %s", e.Kind, e.Name, strings.ToLower(e.Reason))
+	if e.Cluster != "" {
+		summary = "[" + e.Cluster + "] " + summary
+	}
+	return &Incident{
+		ShortDescription: summary,
+		Description:      e.Message(),
+		Urgency:          level(s.Urgency, e.Severity),
+		Impact:           level(s.Impact, e.Severity),
+		AssignmentGroup:  s.AssignmentGroup,
+		CorrelationID:    objectKey(e),
+		CorrelationName:  "kubewatch",
+	}
+}
+
+func prepareEvent(e event.Event) Event {
+	severity, ok := eventSeverities[e.Severity]
+	if !ok {
+		severity = eventSeverities["info"]
+	}
+
+	info := map[string]string{}
+	for k, v := range e.ExternalLabels {
+		info[k] = v
+	}
+	for k, v := range e.Labels {
+		info[k] = v
+	}
+	var additionalInfo string
+	if len(info) > 0 {
+		b, _ := json.Marshal(info)
+		additionalInfo = string(b)
+	}
+
+	node := e.Cluster
+	if node == "" {
+		node = e.Host
+	}
+	resource := e.Name
+	if e.Namespace != "" {
+		resource = e.Namespace + "/" + e.Name
+	}
+	return Event{
+		Source:         "kubewatch",
+		Node:           node,
+		Type:           e.Kind,
+		Resource:       resource,
+		MetricName:     e.Reason,
+		MessageKey:     objectKey(e),
+		Severity:       severity,
+		Description:    e.Message(),
+		AdditionalInfo: additionalInfo,
+	}
+}
+
+func (s *ServiceNow) post(path string, body interface{}) error {
+	message, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", s.Url+path, bytes.NewBuffer(message))
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("Accept", "application/json")
+	req.SetBasicAuth(s.Username, s.Password)
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode/100 != 2 {
+		body, _ := ioutil.ReadAll(res.Body)
+		return fmt.Errorf("Failed sending %s to ServiceNow. ServiceNow http response: %s, %s", s.Mode, res.Status, string(body))
+	}
+
+	return nil
+}
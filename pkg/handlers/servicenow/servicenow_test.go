@@ -0,0 +1,126 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package servicenow
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+)
+
+func TestServiceNowInit(t *testing.T) {
+	s := &ServiceNow{}
+	expectedError := fmt.Errorf(servicenowErrMsg, "Missing ServiceNow url, username or password")
+	auth := config.BasicAuth{Username: "kubewatch", Password: "secret"}
+
+	var Tests = []struct {
+		servicenow config.ServiceNow
+		err        error
+	}{
+		{config.ServiceNow{Url: "https://snow", BasicAuth: auth}, nil},
+		{config.ServiceNow{Url: "https://snow"}, expectedError},
+		{config.ServiceNow{}, expectedError},
+		{config.ServiceNow{Url: "https://snow", BasicAuth: auth, Mode: "alert"}, fmt.Errorf("invalid servicenow mode %q, must be incident or event", "alert")},
+	}
+
+	for _, tt := range Tests {
+		c := &config.Config{}
+		c.Handler.ServiceNow = tt.servicenow
+		if err := s.Init(c); !reflect.DeepEqual(err, tt.err) {
+			t.Fatalf("Init(): %v", err)
+		}
+	}
+}
+
+func TestServiceNowSend(t *testing.T) {
+	var (
+		incidents []Incident
+		events    []Events
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, password, ok := r.BasicAuth(); !ok || user != "kubewatch" || password != "secret" {
+			t.Errorf("unexpected credentials %q %q", user, password)
+		}
+		switch r.URL.Path {
+		case incidentPath:
+			var incident Incident
+			if err := json.NewDecoder(r.Body).Decode(&incident); err != nil {
+				t.Error(err)
+			}
+			incidents = append(incidents, incident)
+		case eventPath:
+			var e Events
+			if err := json.NewDecoder(r.Body).Decode(&e); err != nil {
+				t.Error(err)
+			}
+			events = append(events, e)
+		default:
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer ts.Close()
+
+	e := event.Event{Cluster: "prod", Namespace: "shop", Kind: "deployment", Name: "api", Reason: "Deleted", Severity: "critical"}
+
+	c := &config.Config{}
+	c.Handler.ServiceNow = config.ServiceNow{
+		Url:             ts.URL,
+		BasicAuth:       config.BasicAuth{Username: "kubewatch", Password: "secret"},
+		AssignmentGroup: "SRE",
+		Impact:          map[string]string{"critical": "2"},
+	}
+	s := &ServiceNow{}
+	if err := s.Init(c); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Send(e); err != nil {
+		t.Fatal(err)
+	}
+	want := Incident{
+		ShortDescription: "[prod] deployment api deleted",
+		Description:      e.Message(),
+		Urgency:          "1",
+		Impact:           "2",
+		AssignmentGroup:  "SRE",
+		CorrelationID:    "prod/deployment/shop/api",
+		CorrelationName:  "kubewatch",
+	}
+	if len(incidents) != 1 || !reflect.DeepEqual(incidents[0], want) {
+		t.Fatalf("got incidents %+v, want %+v", incidents, want)
+	}
+
+	c.Handler.ServiceNow.Mode = "event"
+	if err := s.Init(c); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Send(e); err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || len(events[0].Records) != 1 {
+		t.Fatalf("got events %+v", events)
+	}
+	if r := events[0].Records[0]; r.Severity != "1" || r.Node != "prod" || r.Resource != "shop/api" || r.MessageKey != "prod/deployment/shop/api" {
+		t.Fatalf("unexpected event %+v", r)
+	}
+}
@@ -0,0 +1,39 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handlers
+
+import (
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+	"github.com/bitnami-labs/kubewatch/pkg/severity"
+	"github.com/bitnami-labs/kubewatch/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Severity implements the Handler interface,
+// setting the severity of each event before passing it to the wrapped handler
+type Severity struct {
+	wrapper
+	Rules []config.SeverityRule
+}
+
+// Handle handles an event.
+func (s *Severity) Handle(e event.Event) {
+	e.Severity = severity.Classify(s.Rules, e)
+	tracing.AddEvent(e, "classified", attribute.String("kubewatch.severity", e.Severity))
+	s.Handler.Handle(e)
+}
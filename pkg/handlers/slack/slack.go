@@ -17,22 +17,61 @@ limitations under the License.
 package slack
 
 import (
+	"bytes"
 	"fmt"
-	"log"
 	"os"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
 
 	"github.com/slack-go/slack"
 
 	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/ack"
 	"github.com/bitnami-labs/kubewatch/pkg/event"
+	"github.com/bitnami-labs/kubewatch/pkg/utils"
+	"github.com/sirupsen/logrus"
 )
 
+// logger logs the notifications sent by the handler.
+var logger = logrus.WithField("handler", "slack")
+
 var slackColors = map[string]string{
 	"Normal":  "good",
 	"Warning": "warning",
 	"Danger":  "danger",
 }
 
+// slackSeverityColors maps event severities to attachment colors,
+// taking precedence over the status colors.
+var slackSeverityColors = map[string]string{
+	"critical": "danger",
+	"warning":  "warning",
+	"info":     "good",
+}
+
+// slackEmojis prefix the headers of the messages formatted with Block Kit,
+// by severity or else status.
+var slackEmojis = map[string]string{
+	"critical": ":red_circle:",
+	"warning":  ":warning:",
+	"info":     ":information_source:",
+	"Danger":   ":red_circle:",
+	"Warning":  ":warning:",
+	"Normal":   ":white_check_mark:",
+}
+
+const defaultThreadWindow = time.Hour
+
+// Limits of Block Kit.
+const (
+	maxBlocks       = 50
+	maxHeaderLength = 150
+	maxTextLength   = 3000
+	maxButtons      = 25
+)
+
 var slackErrMsg = `
 %s
 
@@ -52,6 +91,38 @@ type Slack struct {
 	Token   string
 	Channel string
 	Title   string
+	// NamespaceChannels are the channels of the events by namespace
+	NamespaceChannels map[string]string
+
+	Threads      bool
+	ThreadWindow time.Duration
+	UpdateParent bool
+	// Ack adds an Acknowledge button to the messages
+	Ack bool
+	// Blocks formats the messages with Block Kit instead of an attachment
+	Blocks bool
+
+	actions []action
+
+	api *slack.Client
+
+	// threads maps the objects to the thread of their last messages
+	mu      sync.Mutex
+	threads map[string]thread
+}
+
+// action is a button opening the URL of its template executed with the
+// event.
+type action struct {
+	text string
+	url  *template.Template
+}
+
+// thread is the first message posted about an object.
+type thread struct {
+	channelID string
+	ts        string
+	last      time.Time
 }
 
 // Init prepares slack configuration
@@ -78,24 +149,201 @@ func (s *Slack) Init(c *config.Config) error {
 	s.Token = token
 	s.Channel = channel
 	s.Title = title
+	s.NamespaceChannels = c.Handler.Slack.NamespaceChannels
+	s.Threads = c.Handler.Slack.Threads
+	s.UpdateParent = c.Handler.Slack.UpdateParent
+	s.Ack = c.Ack.SlackSigningSecret != ""
+	s.Blocks = c.Handler.Slack.Blocks
+	s.actions = nil
+	for _, a := range c.Handler.Slack.Actions {
+		if a.Text == "" || a.URL == "" {
+			return fmt.Errorf("slack actions need a text and a url")
+		}
+		url, err := template.New(a.Text).Parse(a.URL)
+		if err != nil {
+			return fmt.Errorf("invalid url of the slack action %s: %v", a.Text, err)
+		}
+		s.actions = append(s.actions, action{text: a.Text, url: url})
+	}
+	s.ThreadWindow = defaultThreadWindow
+	if w := c.Handler.Slack.ThreadWindow; w != "" {
+		var err error
+		if s.ThreadWindow, err = time.ParseDuration(w); err != nil {
+			return fmt.Errorf("invalid slack thread window %q: %v", w, err)
+		}
+	}
+
+	if err := checkMissingSlackVars(s); err != nil {
+		return err
+	}
 
-	return checkMissingSlackVars(s)
+	client, err := utils.HTTPClient("slack", c.Handler.Slack.TLS)
+	if err != nil {
+		return err
+	}
+	s.api = slack.New(s.Token, slack.OptionHTTPClient(client))
+	s.threads = map[string]thread{}
+	return nil
 }
 
 // Handle handles the notification.
 func (s *Slack) Handle(e event.Event) {
-	api := slack.New(s.Token)
-	attachment := prepareSlackAttachment(e, s)
+	content := s.content(e)
+	options := append(content, slack.MsgOptionAsUser(true))
 
-	channelID, timestamp, err := api.PostMessage(s.Channel,
-		slack.MsgOptionAttachments(attachment),
-		slack.MsgOptionAsUser(true))
+	channel := e.Channel("slack", e.NamespaceChannel(s.NamespaceChannels, s.Channel))
+	parent, threaded := s.thread(e, time.Now())
+	if threaded {
+		channel = parent.channelID
+		options = append(options, slack.MsgOptionTS(parent.ts))
+	}
+
+	channelID, ts, err := s.api.PostMessage(channel, options...)
 	if err != nil {
-		log.Printf("%s\n", err)
+		logger.WithFields(e.LogFields()).Error(err)
+		return
+	}
+
+	logger.WithFields(e.LogFields()).Infof("Message successfully sent to channel %s", channelID)
+
+	if !s.Threads {
+		return
+	}
+	if !threaded {
+		s.track(e, thread{channelID: channelID, ts: ts, last: time.Now()})
+		return
+	}
+	if s.UpdateParent {
+		if _, _, _, err := s.api.UpdateMessage(parent.channelID, parent.ts, content...); err != nil {
+			logger.WithFields(e.LogFields()).Errorf("Can not update the first message of the thread: %v", err)
+		}
+	}
+}
+
+// maxAttachments is the number of attachments Slack accepts per message.
+const maxAttachments = 100
+
+// SendBatch posts the events in a single message per channel, with an
+// attachment per event. Batched messages aren't threaded.
+func (s *Slack) SendBatch(events []event.Event) error {
+	var channels []string
+	byChannel := map[string][]event.Event{}
+	for _, e := range events {
+		channel := e.Channel("slack", e.NamespaceChannel(s.NamespaceChannels, s.Channel))
+		if _, ok := byChannel[channel]; !ok {
+			channels = append(channels, channel)
+		}
+		byChannel[channel] = append(byChannel[channel], e)
+	}
+	for _, channel := range channels {
+		if err := s.sendBatch(channel, byChannel[channel]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sendBatch posts the events to the channel, in messages of at most
+// maxAttachments attachments, or maxBlocks blocks.
+func (s *Slack) sendBatch(channel string, events []event.Event) error {
+	if s.Blocks {
+		return s.sendBlocksBatch(channel, events)
+	}
+	for len(events) > 0 {
+		n := len(events)
+		if n > maxAttachments {
+			n = maxAttachments
+		}
+		attachments := make([]slack.Attachment, 0, n)
+		for _, e := range events[:n] {
+			attachments = append(attachments, prepareSlackAttachment(e, s))
+		}
+		channelID, _, err := s.api.PostMessage(channel,
+			slack.MsgOptionText(fmt.Sprintf("%d events", n), false),
+			slack.MsgOptionAttachments(attachments...),
+			slack.MsgOptionAsUser(true),
+		)
+		if err != nil {
+			return err
+		}
+		logger.Infof("Batch of %d events successfully sent to channel %s", n, channelID)
+		events = events[n:]
+	}
+	return nil
+}
+
+// sendBlocksBatch posts the events to the channel formatted with Block Kit,
+// separated by dividers, in messages of at most maxBlocks blocks.
+func (s *Slack) sendBlocksBatch(channel string, events []event.Event) error {
+	for len(events) > 0 {
+		var blocks []slack.Block
+		n := 0
+		for _, e := range events {
+			b := s.blocks(e)
+			if n > 0 {
+				b = append([]slack.Block{slack.NewDividerBlock()}, b...)
+			}
+			if n > 0 && len(blocks)+len(b) > maxBlocks {
+				break
+			}
+			blocks = append(blocks, b...)
+			n++
+		}
+		channelID, _, err := s.api.PostMessage(channel,
+			slack.MsgOptionText(fmt.Sprintf("%d events", n), false),
+			slack.MsgOptionBlocks(blocks...),
+			slack.MsgOptionAsUser(true),
+		)
+		if err != nil {
+			return err
+		}
+		logger.Infof("Batch of %d events successfully sent to channel %s", n, channelID)
+		events = events[n:]
+	}
+	return nil
+}
+
+// thread returns the thread of the recent messages about the object of the
+// event, if any, and extends its window. Deleted objects end their thread.
+func (s *Slack) thread(e event.Event, now time.Time) (thread, bool) {
+	if !s.Threads || e.Kind == event.DigestKind {
+		return thread{}, false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, t := range s.threads {
+		if now.Sub(t.last) > s.ThreadWindow {
+			delete(s.threads, key)
+		}
+	}
+
+	key := objectKey(e)
+	t, ok := s.threads[key]
+	if !ok {
+		return thread{}, false
+	}
+	if e.Reason == "Deleted" {
+		delete(s.threads, key)
+	} else {
+		t.last = now
+		s.threads[key] = t
+	}
+	return t, true
+}
+
+// track records the first message about the object of the event.
+func (s *Slack) track(e event.Event, t thread) {
+	if e.Reason == "Deleted" || e.Kind == event.DigestKind {
 		return
 	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.threads[objectKey(e)] = t
+}
 
-	log.Printf("Message successfully sent to channel %s at %s", channelID, timestamp)
+func objectKey(e event.Event) string {
+	return e.Cluster + "/" + e.Kind + "/" + e.Namespace + "/" + e.Name
 }
 
 func checkMissingSlackVars(s *Slack) error {
@@ -117,11 +365,115 @@ func prepareSlackAttachment(e event.Event, s *Slack) slack.Attachment {
 		},
 	}
 
-	if color, ok := slackColors[e.Status]; ok {
+	if color, ok := slackSeverityColors[e.Severity]; ok {
+		attachment.Color = color
+	} else if color, ok := slackColors[e.Status]; ok {
 		attachment.Color = color
 	}
 
 	attachment.MarkdownIn = []string{"fields"}
 
+	if s.Ack {
+		attachment.CallbackID = ack.SlackCallbackID
+		attachment.Actions = []slack.AttachmentAction{{
+			Name:  "ack",
+			Text:  "Acknowledge",
+			Type:  slack.ActionType("button"),
+			Value: ack.Target(e),
+		}}
+	}
+
 	return attachment
 }
+
+// content returns the options setting the content of the message of the
+// event, formatted with Block Kit or as an attachment.
+func (s *Slack) content(e event.Event) []slack.MsgOption {
+	if !s.Blocks {
+		return []slack.MsgOption{slack.MsgOptionAttachments(prepareSlackAttachment(e, s))}
+	}
+	return []slack.MsgOption{
+		// the text is shown by the notifications
+		slack.MsgOptionText(header(e), false),
+		slack.MsgOptionBlocks(s.blocks(e)...),
+	}
+}
+
+// blocks returns the Block Kit blocks of the event: a header, its message
+// and fields, a context with the title, cluster and time, and the buttons.
+func (s *Slack) blocks(e event.Event) []slack.Block {
+	var fields []*slack.TextBlockObject
+	field := func(name, value string) {
+		if value != "" {
+			fields = append(fields, slack.NewTextBlockObject(slack.MarkdownType, fmt.Sprintf("*%s*\n%s", name, value), false, false))
+		}
+	}
+	field("Kind", e.Kind)
+	field("Namespace", e.Namespace)
+	field("Name", e.Name)
+	field("Reason", e.Reason)
+	field("Severity", e.Severity)
+
+	blocks := []slack.Block{
+		slack.NewHeaderBlock(slack.NewTextBlockObject(slack.PlainTextType, truncate(header(e), maxHeaderLength), true, false)),
+		slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, truncate(e.Message(), maxTextLength), false, false), fields, nil),
+	}
+
+	context := []slack.MixedElement{slack.NewTextBlockObject(slack.MarkdownType, s.Title, false, false)}
+	if e.Cluster != "" {
+		context = append(context, slack.NewTextBlockObject(slack.MarkdownType, fmt.Sprintf("cluster *%s*", e.Cluster), false, false))
+	}
+	if !e.Timestamp.IsZero() {
+		t := e.Timestamp.UTC()
+		context = append(context, slack.NewTextBlockObject(slack.MarkdownType, fmt.Sprintf("<!date^%d^{date_short_pretty} {time_secs}|%s>", t.Unix(), t.Format(time.RFC3339)), false, false))
+	}
+	blocks = append(blocks, slack.NewContextBlock("", context...))
+
+	var buttons []slack.BlockElement
+	for i, a := range s.actions {
+		var b bytes.Buffer
+		if err := a.url.Execute(&b, e); err != nil || b.Len() == 0 {
+			logger.WithFields(e.LogFields()).Errorf("Can not format the url of the slack action %s: %v", a.text, err)
+			continue
+		}
+		button := slack.NewButtonBlockElement(fmt.Sprintf("kubewatch_link_%d", i), "", slack.NewTextBlockObject(slack.PlainTextType, a.text, false, false))
+		button.URL = b.String()
+		buttons = append(buttons, button)
+	}
+	if s.Ack {
+		buttons = append(buttons, slack.NewButtonBlockElement(ack.SlackCallbackID, ack.Target(e), slack.NewTextBlockObject(slack.PlainTextType, "Acknowledge", false, false)))
+	}
+	if len(buttons) > maxButtons {
+		buttons = buttons[:maxButtons]
+	}
+	if len(buttons) > 0 {
+		blocks = append(blocks, slack.NewActionBlock("", buttons...))
+	}
+	return blocks
+}
+
+// header returns the header of the message of the event, e.g.
+// ":warning: deployment web Updated".
+func header(e event.Event) string {
+	parts := []string{}
+	if emoji, ok := slackEmojis[e.Severity]; ok {
+		parts = append(parts, emoji)
+	} else if emoji, ok := slackEmojis[e.Status]; ok {
+		parts = append(parts, emoji)
+	}
+	for _, p := range []string{e.Kind, e.Name, e.Reason} {
+		if p != "" {
+			parts = append(parts, p)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// truncate shortens the text to at most n characters.
+func truncate(text string, n int) string {
+	r := []rune(text)
+	if len(r) <= n {
+		return text
+	}
+	return string(r[:n-1]) + "…"
+}
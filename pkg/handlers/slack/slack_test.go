@@ -18,10 +18,16 @@ package slack
 
 import (
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"reflect"
+	"strings"
 	"testing"
 
+	"github.com/slack-go/slack"
+
 	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
 )
 
 func TestSlackInit(t *testing.T) {
@@ -45,4 +51,101 @@ func TestSlackInit(t *testing.T) {
 			t.Fatalf("Init(): %v", err)
 		}
 	}
+
+	c := &config.Config{}
+	c.Handler.Slack = config.Slack{Token: "foo", Channel: "bar", TLS: config.TLS{CAFile: "/nonexistent"}}
+	if err := s.Init(c); err == nil {
+		t.Fatal("Init(): expected an error for a missing CA file")
+	}
+}
+
+func TestSlackThreads(t *testing.T) {
+	var calls []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		calls = append(calls, r.URL.Path+" ts="+r.Form.Get("ts")+" thread_ts="+r.Form.Get("thread_ts"))
+		w.Write([]byte(`{"ok":true,"channel":"C1","ts":"1.1"}`))
+	}))
+	defer ts.Close()
+
+	c := &config.Config{}
+	c.Handler.Slack = config.Slack{Token: "foo", Channel: "bar", Threads: true, UpdateParent: true}
+	s := &Slack{}
+	if err := s.Init(c); err != nil {
+		t.Fatal(err)
+	}
+	s.api = slack.New("foo", slack.OptionAPIURL(ts.URL+"/"))
+
+	s.Handle(event.Event{Kind: "deployment", Namespace: "default", Name: "web", Reason: "Created"})
+	s.Handle(event.Event{Kind: "deployment", Namespace: "default", Name: "web", Reason: "Updated"})
+	s.Handle(event.Event{Kind: "deployment", Namespace: "default", Name: "api", Reason: "Created"})
+	s.Handle(event.Event{Kind: "deployment", Namespace: "default", Name: "web", Reason: "Deleted"})
+	s.Handle(event.Event{Kind: "deployment", Namespace: "default", Name: "web", Reason: "Created"})
+
+	want := []string{
+		"/chat.postMessage ts= thread_ts=",
+		"/chat.postMessage ts= thread_ts=1.1",
+		"/chat.update ts=1.1 thread_ts=",
+		"/chat.postMessage ts= thread_ts=",
+		"/chat.postMessage ts= thread_ts=1.1",
+		"/chat.update ts=1.1 thread_ts=",
+		"/chat.postMessage ts= thread_ts=",
+	}
+	if !reflect.DeepEqual(calls, want) {
+		t.Fatalf("calls: got %q, want %q", calls, want)
+	}
+}
+
+func TestSlackBlocks(t *testing.T) {
+	var blocks []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		blocks = append(blocks, r.Form.Get("blocks"))
+		w.Write([]byte(`{"ok":true,"channel":"C1","ts":"1.1"}`))
+	}))
+	defer ts.Close()
+
+	c := &config.Config{}
+	c.Ack.SlackSigningSecret = "signing"
+	c.Handler.Slack = config.Slack{Token: "foo", Channel: "bar", Blocks: true, Actions: []config.SlackAction{
+		{Text: "Grafana", URL: "https://grafana.example.com/d/pods?var-namespace={{.Namespace}}"},
+	}}
+	s := &Slack{}
+	if err := s.Init(c); err != nil {
+		t.Fatal(err)
+	}
+	s.api = slack.New("foo", slack.OptionAPIURL(ts.URL+"/"))
+
+	s.Handle(event.Event{Cluster: "prod", Kind: "deployment", Namespace: "shop", Name: "web", Reason: "Updated", Severity: "warning"})
+	if len(blocks) != 1 {
+		t.Fatalf("got %d messages", len(blocks))
+	}
+	for _, want := range []string{
+		`{"type":"header","text":{"type":"plain_text","text":":warning: deployment web Updated","emoji":true}}`,
+		`{"type":"mrkdwn","text":"*Namespace*\nshop"}`,
+		`{"type":"mrkdwn","text":"cluster *prod*"}`,
+		`"action_id":"kubewatch_link_0","url":"https://grafana.example.com/d/pods?var-namespace=shop"`,
+		`"action_id":"kubewatch_ack","value":"shop/deployment/web"`,
+	} {
+		if !strings.Contains(blocks[0], want) {
+			t.Fatalf("blocks %s: missing %s", blocks[0], want)
+		}
+	}
+
+	events := make([]event.Event, 20)
+	for i := range events {
+		events[i] = event.Event{Kind: "pod", Namespace: "shop", Name: fmt.Sprint("web-", i), Reason: "Created"}
+	}
+	blocks = nil
+	if err := s.SendBatch(events); err != nil {
+		t.Fatal(err)
+	}
+	if len(blocks) != 2 {
+		t.Fatalf("batch: got %d messages, want 2", len(blocks))
+	}
+
+	c.Handler.Slack.Actions = []config.SlackAction{{Text: "Grafana", URL: "{{.Namespace"}}
+	if err := s.Init(c); err == nil {
+		t.Fatal("Init(): want an error for an invalid url template")
+	}
 }
@@ -25,7 +25,6 @@ import (
 	"context"
 	"crypto/tls"
 	"fmt"
-	"log"
 	"math/rand"
 	"mime"
 	"mime/multipart"
@@ -35,6 +34,7 @@ import (
 	"net/smtp"
 	"net/textproto"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -43,10 +43,18 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
-func sendEmail(conf config.SMTP, msg string) error {
+func sendEmail(conf config.SMTP, msg, html string) error {
 	ctx := context.Background()
 
-	host, port, err := net.SplitHostPort(conf.Smarthost)
+	smarthost := conf.Smarthost
+	if _, _, err := net.SplitHostPort(smarthost); err != nil {
+		port := conf.Port
+		if port == 0 {
+			port = defaultPort
+		}
+		smarthost = net.JoinHostPort(smarthost, strconv.Itoa(port))
+	}
+	host, port, err := net.SplitHostPort(smarthost)
 	if err != nil {
 		return err
 	}
@@ -58,13 +66,13 @@ func sendEmail(conf config.SMTP, msg string) error {
 	)
 
 	tlsConfig := &tls.Config{}
-	if port == "465" {
+	if conf.TLS || port == "465" {
 
 		if tlsConfig.ServerName == "" {
 			tlsConfig.ServerName = host
 		}
 
-		conn, err = tls.Dial("tcp", conf.Smarthost, tlsConfig)
+		conn, err = tls.Dial("tcp", smarthost, tlsConfig)
 		if err != nil {
 			return fmt.Errorf("establish TLS connection to server: %w", err)
 		}
@@ -73,7 +81,7 @@ func sendEmail(conf config.SMTP, msg string) error {
 			d   = net.Dialer{}
 			err error
 		)
-		conn, err = d.DialContext(ctx, "tcp", conf.Smarthost)
+		conn, err = d.DialContext(ctx, "tcp", smarthost)
 		if err != nil {
 			return fmt.Errorf("establish connection to server: %w", err)
 		}
@@ -100,7 +108,7 @@ func sendEmail(conf config.SMTP, msg string) error {
 	// Global Config guarantees RequireTLS is not nil.
 	if conf.RequireTLS {
 		if ok, _ := c.Extension("STARTTLS"); !ok {
-			return fmt.Errorf("'require_tls' is true (default) but %q does not advertise the STARTTLS extension", conf.Smarthost)
+			return fmt.Errorf("'require_tls' is true (default) but %q does not advertise the STARTTLS extension", smarthost)
 		}
 		if tlsConfig.ServerName == "" {
 			tlsConfig.ServerName = host
@@ -150,25 +158,27 @@ func sendEmail(conf config.SMTP, msg string) error {
 	}
 	defer message.Close()
 
-	if conf.Headers == nil {
-		conf.Headers = map[string]string{}
+	// copy the headers, the configured ones are shared between emails
+	headers := map[string]string{}
+	for header, value := range conf.Headers {
+		headers[header] = value
 	}
-	if _, ok := conf.Headers["Subject"]; !ok {
+	if _, ok := headers["Subject"]; !ok {
 		s := conf.Subject
 		if s == "" {
 			s = defaultSubject
 		}
-		conf.Headers["Subject"] = s
+		headers["Subject"] = s
 	}
-	if _, ok := conf.Headers["To"]; !ok {
-		conf.Headers["To"] = conf.To
+	if _, ok := headers["To"]; !ok {
+		headers["To"] = conf.To
 	}
-	if _, ok := conf.Headers["From"]; !ok {
-		conf.Headers["From"] = conf.From
+	if _, ok := headers["From"]; !ok {
+		headers["From"] = conf.From
 	}
 
 	buffer := &bytes.Buffer{}
-	for header, value := range conf.Headers {
+	for header, value := range headers {
 		fmt.Fprintf(buffer, "%s: %s\r\n", header, mime.QEncoding.Encode("utf-8", value))
 	}
 
@@ -176,7 +186,7 @@ func sendEmail(conf config.SMTP, msg string) error {
 	if err != nil {
 		return err
 	}
-	if _, ok := conf.Headers["Message-Id"]; !ok {
+	if _, ok := headers["Message-Id"]; !ok {
 		fmt.Fprintf(buffer, "Message-Id: %s\r\n", fmt.Sprintf("<%d.%d@%s>", time.Now().UnixNano(), rand.Uint64(), hostname))
 	}
 
@@ -209,6 +219,26 @@ func sendEmail(conf config.SMTP, msg string) error {
 		return fmt.Errorf("close text part: %w", err)
 	}
 
+	if html != "" {
+		w, err = multipartWriter.CreatePart(textproto.MIMEHeader{
+			"Content-Transfer-Encoding": {"quoted-printable"},
+			"Content-Type":              {"text/html; charset=UTF-8"},
+		})
+		if err != nil {
+			return fmt.Errorf("create part for html template: %w", err)
+		}
+
+		qw = quotedprintable.NewWriter(w)
+		_, err = qw.Write([]byte(html))
+		if err != nil {
+			return fmt.Errorf("write html part: %w", err)
+		}
+		err = qw.Close()
+		if err != nil {
+			return fmt.Errorf("close html part: %w", err)
+		}
+	}
+
 	err = multipartWriter.Close()
 	if err != nil {
 		return fmt.Errorf("close multipartWriter: %w", err)
@@ -220,7 +250,7 @@ func sendEmail(conf config.SMTP, msg string) error {
 		return fmt.Errorf("write body buffer: %w", err)
 	}
 
-	log.Printf("sending via %s:%s, to: %q, from: %q : %s ", host, port, conf.To, conf.From, msg)
+	logrus.Debugf("sending via %s:%s, to: %q, from: %q : %s ", host, port, conf.To, conf.From, msg)
 	return nil
 }
 
@@ -23,16 +23,47 @@ package smtp
 
 import (
 	"fmt"
-	"log"
-	"time"
+	"html/template"
+	"strings"
 
 	"github.com/bitnami-labs/kubewatch/config"
 	"github.com/bitnami-labs/kubewatch/pkg/event"
 	"github.com/sirupsen/logrus"
 )
 
+// logger logs the notifications sent by the handler.
+var logger = logrus.WithField("handler", "smtp")
+
 const (
 	defaultSubject = "Kubewatch notification"
+	defaultPort    = 25
+
+	// defaultHTMLTemplate summarizes the event in a table.
+	defaultHTMLTemplate = `<html>
+<body>
+<p>{{.Message}}</p>
+<table>
+<tr><th align="left">Kind</th><td>{{.Kind}}</td></tr>
+<tr><th align="left">Name</th><td>{{.Name}}</td></tr>
+{{- if .Namespace}}
+<tr><th align="left">Namespace</th><td>{{.Namespace}}</td></tr>
+{{- end}}
+<tr><th align="left">Reason</th><td>{{.Reason}}</td></tr>
+{{- if .Severity}}
+<tr><th align="left">Severity</th><td>{{.Severity}}</td></tr>
+{{- end}}
+</table>
+{{- if .Diff}}
+<table>
+<tr><th align="left">Field</th><th align="left">Old</th><th align="left">New</th></tr>
+{{- range .Diff}}
+<tr><td><code>{{.Path}}</code></td><td><code>{{.Old}}</code></td><td><code>{{.New}}</code></td></tr>
+{{- end}}
+</table>
+{{- end}}
+</body>
+</html>
+`
 
 	// ConfigExample is an example configuration.
 	ConfigExample = `handler:
@@ -45,13 +76,17 @@ const (
       username: myusername
       password: mypassword
     requireTLS: true
+    # optional, Go html/template executed with the event
+    htmlTemplate: |
+      <p>{{.Message}}</p>
 `
 )
 
 // SMTP handler implements handler.Handler interface,
 // Notify event via email.
 type SMTP struct {
-	cfg config.SMTP
+	cfg  config.SMTP
+	html *template.Template
 }
 
 // Init prepares Webhook configuration
@@ -67,21 +102,60 @@ func (s *SMTP) Init(c *config.Config) error {
 	if s.cfg.Smarthost == "" {
 		return fmt.Errorf("smtp `smarthost` conf field is required")
 	}
+
+	text := s.cfg.HTMLTemplate
+	if text == "" {
+		text = defaultHTMLTemplate
+	}
+	html, err := template.New("html").Parse(text)
+	if err != nil {
+		return fmt.Errorf("smtp `htmlTemplate` conf field is invalid: %v", err)
+	}
+	s.html = html
 	return nil
 }
 
 // Handle handles the notification.
 func (s *SMTP) Handle(e event.Event) {
-	send(s.cfg, e.Message())
-	log.Printf("Message successfully sent to %s at %s ", s.cfg.To, time.Now())
+	cfg := s.cfg
+	cfg.Subject = subject(cfg.Subject, e)
+
+	html, err := s.formatHTML(e)
+	if err != nil {
+		// the plain text body is still sent
+		logrus.Errorf("Can not format html email: %v", err)
+	}
+	if err := sendEmail(cfg, e.Message(), html); err != nil {
+		logrus.Error(err)
+		return
+	}
+	logger.WithFields(e.LogFields()).Infof("Message successfully sent to %s", s.cfg.To)
 }
 
-func formatEmail(e event.Event) (string, error) {
-	return e.Message(), nil
+// formatHTML executes the html template with the event.
+func (s *SMTP) formatHTML(e event.Event) (string, error) {
+	if s.html == nil {
+		return "", nil
+	}
+	var b strings.Builder
+	if err := s.html.Execute(&b, &e); err != nil {
+		return "", err
+	}
+	return b.String(), nil
 }
 
-func send(conf config.SMTP, msg string) {
-	if err := sendEmail(conf, msg); err != nil {
-		logrus.Error(err)
+// subject prefixes the configured subject with the event severity,
+// e.g. "[CRITICAL] Kubewatch notification".
+func subject(s string, e event.Event) string {
+	if s == "" {
+		s = defaultSubject
 	}
+	if e.Severity == "" {
+		return s
+	}
+	return "[" + strings.ToUpper(e.Severity) + "] " + s
+}
+
+func formatEmail(e event.Event) (string, error) {
+	return e.Message(), nil
 }
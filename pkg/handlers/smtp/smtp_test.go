@@ -17,9 +17,105 @@ limitations under the License.
 package smtp
 
 import (
+	"bufio"
+	"net"
+	"strconv"
+	"strings"
 	"testing"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
 )
 
+// serveSMTP accepts a single connection on l, answering the commands of a
+// plain SMTP session, and sends the received DATA to msgs.
+func serveSMTP(t *testing.T, l net.Listener, msgs chan<- string) {
+	conn, err := l.Accept()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	reply := func(s string) { conn.Write([]byte(s + "\r\n")) }
+	reply("220 localhost ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		switch cmd := strings.ToUpper(strings.Fields(line)[0]); cmd {
+		case "EHLO", "HELO":
+			reply("250 localhost")
+		case "DATA":
+			reply("354 go ahead")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil || l == ".\r\n" {
+					break
+				}
+				data.WriteString(l)
+			}
+			msgs <- data.String()
+			reply("250 ok")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
+
 func TestSMTP(t *testing.T) {
-	// TODO(mkmik): setup a in-memory smtp server like https://github.com/bradfitz/go-smtpd
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	msgs := make(chan string, 1)
+	go serveSMTP(t, l, msgs)
+
+	host, port, _ := net.SplitHostPort(l.Addr().String())
+	c := &config.Config{}
+	c.Handler.SMTP = config.SMTP{
+		To:        "team@example.com",
+		From:      "kubewatch@example.com",
+		Smarthost: host,
+		Subject:   "Test notification",
+	}
+	c.Handler.SMTP.Port, _ = strconv.Atoi(port)
+
+	s := &SMTP{}
+	if err := s.Init(c); err != nil {
+		t.Fatal(err)
+	}
+	s.Handle(event.Event{Kind: "pod", Name: "web-<1>", Namespace: "default", Reason: "Created", Severity: "info"})
+
+	msg := <-msgs
+	for _, want := range []string{
+		"Subject: [INFO] Test notification",
+		"Content-Type: text/plain",
+		"Content-Type: text/html",
+		"web-&lt;1&gt;",
+	} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("email does not contain %q:\n%s", want, msg)
+		}
+	}
+}
+
+func TestSMTPInit(t *testing.T) {
+	c := &config.Config{}
+	c.Handler.SMTP = config.SMTP{
+		To:           "team@example.com",
+		From:         "kubewatch@example.com",
+		Smarthost:    "localhost",
+		HTMLTemplate: "{{.Name",
+	}
+	if err := (&SMTP{}).Init(c); err == nil {
+		t.Fatal("Init: expected an error for an invalid html template")
+	}
 }
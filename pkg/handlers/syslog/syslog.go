@@ -0,0 +1,282 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syslog
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+	"github.com/bitnami-labs/kubewatch/pkg/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// logger logs the notifications sent by the handler.
+var logger = logrus.WithField("handler", "syslog")
+
+// sdID is the SD-ID of the structured data of the messages.
+const sdID = "kubewatch@32473"
+
+const dialTimeout = 10 * time.Second
+
+var syslogErrMsg = `
+%s
+
+You need to set the syslog server address,
+using "--address/-a", or using environment variables:
+
+export KW_SYSLOG_ADDRESS=host:port
+export KW_SYSLOG_NETWORK=udp|tcp|tls (optional)
+
+Command line flags will override environment variables
+
+`
+
+var facilities = map[string]int{
+	"kern": 0, "user": 1, "mail": 2, "daemon": 3, "auth": 4, "syslog": 5,
+	"lpr": 6, "news": 7, "uucp": 8, "cron": 9, "authpriv": 10, "ftp": 11,
+	"local0": 16, "local1": 17, "local2": 18, "local3": 19,
+	"local4": 20, "local5": 21, "local6": 22, "local7": 23,
+}
+
+var severities = map[string]int{
+	"emerg": 0, "alert": 1, "crit": 2, "err": 3,
+	"warning": 4, "notice": 5, "info": 6, "debug": 7,
+}
+
+// defaultSeverities maps the event severities to syslog severities.
+var defaultSeverities = map[string]string{
+	"critical": "crit",
+	"warning":  "warning",
+	"info":     "info",
+}
+
+// sdEscaper escapes the characters not allowed in structured data param values.
+var sdEscaper = strings.NewReplacer(`"`, `\"`, `\`, `\\`, `]`, `\]`)
+
+// Syslog handler implements handler.Handler interface,
+// Send events as RFC 5424 messages to a syslog server
+type Syslog struct {
+	Address    string
+	Network    string
+	Facility   int
+	Severities map[string]int
+
+	hostname  string
+	tlsConfig *tls.Config
+
+	// mu guards the connection, opened by the first event sent
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// Init prepares syslog configuration
+func (s *Syslog) Init(c *config.Config) error {
+	conf := c.Handler.Syslog
+	address := conf.Address
+	network := conf.Network
+
+	if address == "" {
+		address = os.Getenv("KW_SYSLOG_ADDRESS")
+	}
+
+	if network == "" {
+		network = os.Getenv("KW_SYSLOG_NETWORK")
+		if network == "" {
+			network = "udp"
+		}
+	}
+
+	s.Address = address
+	s.Network = network
+
+	if err := checkMissingSyslogVars(s); err != nil {
+		return err
+	}
+	if s.Network != "udp" && s.Network != "tcp" && s.Network != "tls" {
+		return fmt.Errorf("invalid syslog network %q, expected udp, tcp or tls", s.Network)
+	}
+
+	facility := conf.Facility
+	if facility == "" {
+		facility = "local0"
+	}
+	var ok bool
+	if s.Facility, ok = facilities[facility]; !ok {
+		return fmt.Errorf("invalid syslog facility %q", facility)
+	}
+
+	s.Severities = map[string]int{}
+	for k, v := range defaultSeverities {
+		s.Severities[k] = severities[v]
+	}
+	for k, v := range conf.Severities {
+		severity, ok := severities[v]
+		if !ok {
+			return fmt.Errorf("invalid syslog severity %q", v)
+		}
+		s.Severities[strings.ToLower(k)] = severity
+	}
+
+	if s.Network == "tls" {
+		tlsConfig, err := utils.TLSConfig(conf.TLS)
+		if err != nil {
+			return err
+		}
+		s.tlsConfig = tlsConfig
+	}
+
+	s.hostname, _ = os.Hostname()
+	return nil
+}
+
+// Handle handles an event.
+func (s *Syslog) Handle(e event.Event) {
+	if err := s.Send(e); err != nil {
+		logger.WithFields(e.LogFields()).Error(err)
+	}
+}
+
+// Send sends an event, returning an error when it is not delivered. A broken
+// connection is opened again once before giving up.
+func (s *Syslog) Send(e event.Event) error {
+	msg := formatMessage(e, s.Facility, s.severity(e), s.hostname, time.Now())
+	if s.Network != "udp" {
+		// octet counting framing of RFC 6587
+		msg = fmt.Sprintf("%d %s", len(msg), msg)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		if s.conn == nil {
+			if s.conn, err = s.dial(); err != nil {
+				continue
+			}
+		}
+		if _, err = s.conn.Write([]byte(msg)); err == nil {
+			logger.WithFields(e.LogFields()).Infof("Message successfully sent to %s", s.Address)
+			return nil
+		}
+		s.conn.Close()
+		s.conn = nil
+	}
+	return fmt.Errorf("Failed sending to syslog server %s: %v", s.Address, err)
+}
+
+func (s *Syslog) dial() (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: dialTimeout}
+	if s.Network == "tls" {
+		return tls.DialWithDialer(dialer, "tcp", s.Address, s.tlsConfig)
+	}
+	return dialer.Dial(s.Network, s.Address)
+}
+
+// severity returns the syslog severity of an event, notice when unknown.
+func (s *Syslog) severity(e event.Event) int {
+	if severity, ok := s.Severities[e.Severity]; ok {
+		return severity
+	}
+	return severities["notice"]
+}
+
+func checkMissingSyslogVars(s *Syslog) error {
+	if s.Address == "" {
+		return fmt.Errorf(syslogErrMsg, "Missing syslog server address")
+	}
+
+	return nil
+}
+
+// formatMessage formats an event as a RFC 5424 message.
+func formatMessage(e event.Event, facility, severity int, hostname string, now time.Time) string {
+	msgID := "-"
+	if e.Reason != "" {
+		msgID = header(e.Reason, 32)
+	}
+	if hostname == "" {
+		hostname = "-"
+	}
+
+	var sd strings.Builder
+	sd.WriteString("[" + sdID)
+	params := [][2]string{
+		{"cluster", e.Cluster},
+		{"namespace", e.Namespace},
+		{"kind", e.Kind},
+		{"name", e.Name},
+		{"reason", e.Reason},
+		{"severity", e.Severity},
+	}
+	labels := make([]string, 0, len(e.ExternalLabels))
+	for k := range e.ExternalLabels {
+		labels = append(labels, k)
+	}
+	sort.Strings(labels)
+	for _, k := range labels {
+		params = append(params, [2]string{sdName(k), e.ExternalLabels[k]})
+	}
+	for _, param := range params {
+		if param[1] != "" {
+			fmt.Fprintf(&sd, ` %s="%s"`, param[0], sdEscaper.Replace(param[1]))
+		}
+	}
+	sd.WriteString("]")
+
+	return fmt.Sprintf("<%d>1 %s %s kubewatch %d %s %s %s",
+		facility*8+severity,
+		now.UTC().Format(time.RFC3339Nano),
+		header(hostname, 255),
+		os.Getpid(),
+		msgID,
+		sd.String(),
+		strings.Replace(e.Message(), "\n", " ", -1),
+	)
+}
+
+// sdName returns a valid structured data param name for a label name.
+func sdName(s string) string {
+	return header(strings.Map(func(r rune) rune {
+		if r == '=' || r == ']' || r == '"' {
+			return '_'
+		}
+		return r
+	}, s), 32)
+}
+
+// header keeps the printable characters allowed in a header field, up to max.
+func header(s string, max int) string {
+	b := make([]byte, 0, len(s))
+	for i := 0; i < len(s) && len(b) < max; i++ {
+		if s[i] > 32 && s[i] < 127 {
+			b = append(b, s[i])
+		}
+	}
+	if len(b) == 0 {
+		return "-"
+	}
+	return string(b)
+}
@@ -0,0 +1,165 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syslog
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+)
+
+func TestSyslogInit(t *testing.T) {
+	s := &Syslog{}
+	expectedError := fmt.Errorf(syslogErrMsg, "Missing syslog server address")
+
+	var Tests = []struct {
+		syslog config.Syslog
+		err    error
+	}{
+		{config.Syslog{Address: "localhost:514"}, nil},
+		{config.Syslog{Address: "localhost:514", Network: "tcp", Facility: "daemon"}, nil},
+		{config.Syslog{Address: "localhost:514", Network: "sctp"}, fmt.Errorf(`invalid syslog network "sctp", expected udp, tcp or tls`)},
+		{config.Syslog{Address: "localhost:514", Facility: "local9"}, fmt.Errorf(`invalid syslog facility "local9"`)},
+		{config.Syslog{Address: "localhost:514", Severities: map[string]string{"info": "loud"}}, fmt.Errorf(`invalid syslog severity "loud"`)},
+		{config.Syslog{}, expectedError},
+	}
+
+	for _, tt := range Tests {
+		c := &config.Config{}
+		c.Handler.Syslog = tt.syslog
+		if err := s.Init(c); !reflect.DeepEqual(err, tt.err) {
+			t.Fatalf("Init(): %v", err)
+		}
+	}
+}
+
+func TestFormatMessage(t *testing.T) {
+	e := event.Event{
+		Namespace: "default",
+		Kind:      "pod",
+		Name:      `web"1]`,
+		Reason:    "Created",
+		Severity:  "info",
+	}
+	now := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	got := formatMessage(e, facilities["local0"], severities["info"], "node 1", now)
+	prefix := `<134>1 2020-06-01T12:00:00Z node1 kubewatch `
+	if !strings.HasPrefix(got, prefix) {
+		t.Fatalf("formatMessage(): %q, expected prefix %q", got, prefix)
+	}
+	sd := ` Created [kubewatch@32473 namespace="default" kind="pod" name="web\"1\]" reason="Created" severity="info"] `
+	if !strings.Contains(got, sd) {
+		t.Fatalf("formatMessage(): %q, expected structured data %q", got, sd)
+	}
+	msg := strings.Replace(e.Message(), "\n", " ", -1)
+	if !strings.HasSuffix(got, msg) {
+		t.Fatalf("formatMessage(): %q, expected message %q", got, msg)
+	}
+}
+
+func TestSyslogUDP(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+
+	s := &Syslog{}
+	c := &config.Config{}
+	c.Handler.Syslog = config.Syslog{
+		Address:    pc.LocalAddr().String(),
+		Severities: map[string]string{"warning": "err"},
+	}
+	if err := s.Init(c); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Send(event.Event{Kind: "pod", Name: "web", Reason: "Updated", Severity: "warning"}); err != nil {
+		t.Fatalf("Send(): %v", err)
+	}
+
+	buf := make([]byte, 2048)
+	pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := pc.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// local0.err
+	if got := string(buf[:n]); !strings.HasPrefix(got, "<131>1 ") {
+		t.Fatalf("received %q", got)
+	}
+}
+
+func TestSyslogTCP(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	received := make(chan string, 2)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		for {
+			var n int
+			if _, err := fmt.Fscanf(r, "%d ", &n); err != nil {
+				return
+			}
+			msg := make([]byte, n)
+			if _, err := io.ReadFull(r, msg); err != nil {
+				return
+			}
+			received <- string(msg)
+		}
+	}()
+
+	s := &Syslog{}
+	c := &config.Config{}
+	c.Handler.Syslog = config.Syslog{Address: l.Addr().String(), Network: "tcp"}
+	if err := s.Init(c); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"web", "db"} {
+		if err := s.Send(event.Event{Kind: "pod", Name: name, Reason: "Created"}); err != nil {
+			t.Fatalf("Send(): %v", err)
+		}
+	}
+
+	for _, name := range []string{"web", "db"} {
+		select {
+		case msg := <-received:
+			if !strings.Contains(msg, `name="`+name+`"`) {
+				t.Fatalf("received %q, expected %s", msg, name)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("message not received")
+		}
+	}
+}
@@ -0,0 +1,190 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package telegram
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+	"github.com/bitnami-labs/kubewatch/pkg/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// logger logs the notifications sent by the handler.
+var logger = logrus.WithField("handler", "telegram")
+
+const defaultApiUrl = "https://api.telegram.org"
+
+var telegramErrMsg = `
+%s
+
+You need to set both the bot token and the chat ids for Telegram notify,
+using "--token/-t" and "--chat/-c", or using environment variables:
+
+export KW_TELEGRAM_TOKEN=telegram_bot_token
+export KW_TELEGRAM_CHAT_ID=telegram_chat_id[,telegram_chat_id...]
+
+Command line flags will override environment variables
+
+`
+
+// markdownReplacer escapes the characters reserved by MarkdownV2.
+var markdownReplacer = strings.NewReplacer(
+	`\`, `\\`, "_", `\_`, "*", `\*`, "[", `\[`, "]", `\]`, "(", `\(`, ")", `\)`,
+	"~", `\~`, "`", "\\`", ">", `\>`, "#", `\#`, "+", `\+`, "-", `\-`, "=", `\=`,
+	"|", `\|`, "{", `\{`, "}", `\}`, ".", `\.`, "!", `\!`,
+)
+
+// codeReplacer escapes the characters reserved inside MarkdownV2 code spans.
+var codeReplacer = strings.NewReplacer(`\`, `\\`, "`", "\\`")
+
+// Telegram handler implements handler.Handler interface,
+// Notify event to Telegram chats through a bot
+type Telegram struct {
+	Token   string
+	ChatIDs []string
+	ApiUrl  string
+	client  *http.Client
+}
+
+// TelegramMessage is the sendMessage request of the Bot API
+type TelegramMessage struct {
+	ChatID                string `json:"chat_id"`
+	Text                  string `json:"text"`
+	ParseMode             string `json:"parse_mode"`
+	DisableWebPagePreview bool   `json:"disable_web_page_preview"`
+}
+
+// telegramResponse is the response of the Bot API
+type telegramResponse struct {
+	Ok          bool   `json:"ok"`
+	Description string `json:"description"`
+}
+
+// Init prepares Telegram configuration
+func (t *Telegram) Init(c *config.Config) error {
+	token := c.Handler.Telegram.Token
+	chatIDs := c.Handler.Telegram.ChatIDs
+
+	if token == "" {
+		token = os.Getenv("KW_TELEGRAM_TOKEN")
+	}
+
+	if len(chatIDs) == 0 {
+		if ids := os.Getenv("KW_TELEGRAM_CHAT_ID"); ids != "" {
+			chatIDs = strings.Split(ids, ",")
+		}
+	}
+
+	t.Token = token
+	t.ChatIDs = chatIDs
+	t.ApiUrl = c.Handler.Telegram.ApiUrl
+	if t.ApiUrl == "" {
+		t.ApiUrl = defaultApiUrl
+	}
+	client, err := utils.HTTPClient("telegram", c.Handler.Telegram.TLS)
+	if err != nil {
+		return err
+	}
+	t.client = client
+
+	return checkMissingTelegramVars(t)
+}
+
+// Handle handles an event.
+func (t *Telegram) Handle(e event.Event) {
+	text := formatMessage(e)
+	for _, id := range t.ChatIDs {
+		msg := &TelegramMessage{
+			ChatID:                strings.TrimSpace(id),
+			Text:                  text,
+			ParseMode:             "MarkdownV2",
+			DisableWebPagePreview: true,
+		}
+		if err := t.sendMessage(msg); err != nil {
+			logger.WithFields(e.LogFields()).Error(err)
+			continue
+		}
+		logger.WithFields(e.LogFields()).Infof("Message successfully sent to chat %s", msg.ChatID)
+	}
+}
+
+func checkMissingTelegramVars(t *Telegram) error {
+	if t.Token == "" || len(t.ChatIDs) == 0 {
+		return fmt.Errorf(telegramErrMsg, "Missing Telegram bot token or chat ids")
+	}
+
+	return nil
+}
+
+// formatMessage formats the event with MarkdownV2, escaping its values.
+func formatMessage(e event.Event) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%s %s*\n", escape(e.Kind), escape(e.Reason))
+	fmt.Fprintf(&b, "`%s`", codeReplacer.Replace(e.Name))
+	if e.Namespace != "" {
+		fmt.Fprintf(&b, " in namespace `%s`", codeReplacer.Replace(e.Namespace))
+	}
+	if e.Details != "" {
+		fmt.Fprintf(&b, "\n%s", escape(e.Details))
+	}
+	for _, c := range e.Diff {
+		fmt.Fprintf(&b, "\n`%s`: %s → %s", codeReplacer.Replace(c.Path), escape(c.Old), escape(c.New))
+	}
+	return b.String()
+}
+
+func escape(s string) string {
+	return markdownReplacer.Replace(s)
+}
+
+func (t *Telegram) sendMessage(msg *TelegramMessage) error {
+	message, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	url := fmt.Sprintf("%s/bot%s/sendMessage", strings.TrimSuffix(t.ApiUrl, "/"), t.Token)
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(message))
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Content-Type", "application/json")
+
+	res, err := t.client.Do(req)
+	if err != nil {
+		// the url, holding the token, is part of the error
+		return fmt.Errorf("Failed sending message to Telegram chat %s", msg.ChatID)
+	}
+	defer res.Body.Close()
+
+	var r telegramResponse
+	if err := json.NewDecoder(res.Body).Decode(&r); err != nil {
+		return fmt.Errorf("Failed sending message to Telegram chat %s: %s", msg.ChatID, res.Status)
+	}
+	if !r.Ok {
+		return fmt.Errorf("Failed sending message to Telegram chat %s: %s", msg.ChatID, r.Description)
+	}
+
+	return nil
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package telegram
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+)
+
+func TestTelegramInit(t *testing.T) {
+	s := &Telegram{}
+	expectedError := fmt.Errorf(telegramErrMsg, "Missing Telegram bot token or chat ids")
+
+	var Tests = []struct {
+		telegram config.Telegram
+		err      error
+	}{
+		{config.Telegram{Token: "foo", ChatIDs: []string{"-100123"}}, nil},
+		{config.Telegram{Token: "foo"}, expectedError},
+		{config.Telegram{ChatIDs: []string{"-100123"}}, expectedError},
+		{config.Telegram{}, expectedError},
+	}
+
+	for _, tt := range Tests {
+		c := &config.Config{}
+		c.Handler.Telegram = tt.telegram
+		if err := s.Init(c); !reflect.DeepEqual(err, tt.err) {
+			t.Fatalf("Init(): %v", err)
+		}
+	}
+
+	c := &config.Config{}
+	c.Handler.Telegram = config.Telegram{Token: "foo", ChatIDs: []string{"-100123"}, TLS: config.TLS{CAFile: "/nonexistent"}}
+	if err := s.Init(c); err == nil {
+		t.Fatal("Init(): expected an error for a missing CA file")
+	}
+}
+
+func TestFormatMessage(t *testing.T) {
+	e := event.Event{Kind: "pod", Name: "web-1.a_b", Namespace: "my-ns", Reason: "Created"}
+	want := "*pod Created*\n`web-1.a_b` in namespace `my-ns`"
+	if got := formatMessage(e); got != want {
+		t.Fatalf("formatMessage: got %q, want %q", got, want)
+	}
+
+	e = event.Event{Kind: "pod", Name: "web", Reason: "BackOff", Details: "Back-off restarting (x2)!"}
+	want = "*pod BackOff*\n`web`\nBack\\-off restarting \\(x2\\)\\!"
+	if got := formatMessage(e); got != want {
+		t.Fatalf("formatMessage: got %q, want %q", got, want)
+	}
+}
+
+func TestTelegramHandle(t *testing.T) {
+	var chats []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/botfoo/sendMessage" {
+			t.Errorf("path: got %s", r.URL.Path)
+		}
+		var msg TelegramMessage
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			t.Error(err)
+		}
+		if msg.ParseMode != "MarkdownV2" {
+			t.Errorf("parse mode: got %q", msg.ParseMode)
+		}
+		chats = append(chats, msg.ChatID)
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer ts.Close()
+
+	c := &config.Config{}
+	c.Handler.Telegram = config.Telegram{Token: "foo", ChatIDs: []string{"1", "2"}, ApiUrl: ts.URL}
+	s := &Telegram{}
+	if err := s.Init(c); err != nil {
+		t.Fatal(err)
+	}
+	s.Handle(event.Event{Kind: "pod", Name: "web", Reason: "Created"})
+
+	sort.Strings(chats)
+	if !reflect.DeepEqual(chats, []string{"1", "2"}) {
+		t.Fatalf("chats: got %v", chats)
+	}
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handlers
+
+import (
+	"time"
+
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+	"github.com/bitnami-labs/kubewatch/pkg/tracing"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Trace wraps the handler to trace the delivery of each event in a child
+// span of the event and to log its delivery record, keeping the Sender
+// interface of the handler.
+func Trace(name string, h Handler) Handler {
+	if s, ok := h.(Sender); ok {
+		return &TracedSender{Name: name, Sender: s}
+	}
+	return &Traced{wrapper: wrapper{h}, Name: name}
+}
+
+// Traced implements the Handler interface,
+// tracing the handling of each event by the wrapped handler
+type Traced struct {
+	wrapper
+	Name string
+}
+
+// Handle handles an event.
+func (t *Traced) Handle(e event.Event) {
+	e, span := startDelivery(e, t.Name)
+	defer span.End()
+	start := time.Now()
+	t.Handler.Handle(e)
+	logDelivery(e, t.Name, deliveryHanded, start, nil)
+}
+
+// TracedSender implements the Sender interface,
+// tracing the delivery of each event by the wrapped sender
+type TracedSender struct {
+	wrapper
+	Name   string
+	Sender Sender
+}
+
+// Handle handles an event.
+func (t *TracedSender) Handle(e event.Event) {
+	e, span := startDelivery(e, t.Name)
+	defer span.End()
+	start := time.Now()
+	t.Sender.Handle(e)
+	logDelivery(e, t.Name, deliveryHanded, start, nil)
+}
+
+// Send sends an event, recording the failures on its span.
+func (t *TracedSender) Send(e event.Event) error {
+	e, span := startDelivery(e, t.Name)
+	defer span.End()
+	start := time.Now()
+	err := t.Sender.Send(e)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		logDelivery(e, t.Name, deliveryFailed, start, err)
+		return err
+	}
+	logDelivery(e, t.Name, deliveryDelivered, start, nil)
+	return nil
+}
+
+// Run runs the background work of the wrapped sender.
+func (t *TracedSender) Run(stopCh <-chan struct{}) {
+	Run(t.Sender, stopCh)
+}
+
+func startDelivery(e event.Event, name string) (event.Event, trace.Span) {
+	ctx, span := tracing.Tracer().Start(e.Context(), "kubewatch.deliver",
+		trace.WithAttributes(attribute.String("kubewatch.handler", name), attribute.String("kubewatch.event_id", e.ID)),
+	)
+	return e.WithContext(ctx), span
+}
+
+// Outcomes of the delivery records.
+const (
+	// deliveryDelivered is the outcome of the events the handler
+	// confirmed.
+	deliveryDelivered = "delivered"
+	// deliveryFailed is the outcome of the events the handler failed to
+	// deliver, which can be retried by the queue.
+	deliveryFailed = "failed"
+	// deliveryHanded is the outcome of the events passed to the handlers
+	// not reporting their deliveries, which only log their failures.
+	deliveryHanded = "handed"
+)
+
+// logDelivery logs the delivery record of an event by a handler instance.
+func logDelivery(e event.Event, name, outcome string, start time.Time, err error) {
+	logger := logrus.WithFields(e.LogFields()).WithFields(logrus.Fields{
+		"pkg":         "kubewatch-delivery",
+		"handler":     name,
+		"outcome":     outcome,
+		"duration_ms": time.Since(start).Milliseconds(),
+	})
+	if err != nil {
+		logger.WithError(err).Warn("Delivery record")
+		return
+	}
+	logger.Info("Delivery record")
+}
@@ -0,0 +1,259 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webex
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+	"github.com/bitnami-labs/kubewatch/pkg/metrics"
+	"github.com/bitnami-labs/kubewatch/pkg/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// logger logs the notifications sent by the handler.
+var logger = logrus.WithField("handler", "webex")
+
+const (
+	defaultUrl     = "https://webexapis.com/v1"
+	messagesPath   = "/messages"
+	requestTimeout = 10 * time.Second
+	// adaptiveCardType is the content type of the adaptive card attachments.
+	adaptiveCardType = "application/vnd.microsoft.card.adaptive"
+)
+
+// colors maps event severities to the colors of the titles of the cards.
+var colors = map[string]string{
+	"critical": "Attention",
+	"warning":  "Warning",
+	"info":     "Good",
+}
+
+var webexErrMsg = `
+%s
+
+You need to set both the bot token and the room id for Webex notify,
+using "--token/-t" and "--room/-r", or using environment variables:
+
+export KW_WEBEX_TOKEN=webex_bot_token
+export KW_WEBEX_ROOM_ID=webex_room_id
+
+Command line flags will override environment variables
+
+`
+
+// Webex handler implements handler.Handler interface,
+// Notify event to a Webex room
+type Webex struct {
+	Token  string
+	RoomID string
+	Url    string
+
+	client *http.Client
+}
+
+// Message is a message of the Webex Messages API
+// The Documentation is in https://developer.webex.com/docs/api/v1/messages/create-a-message
+type Message struct {
+	RoomID      string       `json:"roomId"`
+	Markdown    string       `json:"markdown"`
+	Attachments []Attachment `json:"attachments,omitempty"`
+}
+
+// Attachment of a message
+type Attachment struct {
+	ContentType string       `json:"contentType"`
+	Content     AdaptiveCard `json:"content"`
+}
+
+// AdaptiveCard is the card of an attachment
+// The Documentation is in https://adaptivecards.io/explorer/AdaptiveCard.html
+type AdaptiveCard struct {
+	Schema  string        `json:"$schema"`
+	Type    string        `json:"type"`
+	Version string        `json:"version"`
+	Body    []CardElement `json:"body"`
+}
+
+// CardElement is a TextBlock or a FactSet of a card
+type CardElement struct {
+	Type   string `json:"type"`
+	Text   string `json:"text,omitempty"`
+	Weight string `json:"weight,omitempty"`
+	Size   string `json:"size,omitempty"`
+	Color  string `json:"color,omitempty"`
+	Wrap   bool   `json:"wrap,omitempty"`
+	Facts  []Fact `json:"facts,omitempty"`
+}
+
+// Fact of a FactSet
+type Fact struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+}
+
+// Init prepares Webex configuration
+func (w *Webex) Init(c *config.Config) error {
+	conf := c.Handler.Webex
+	token := conf.Token
+	roomID := conf.RoomID
+
+	if token == "" {
+		token = os.Getenv("KW_WEBEX_TOKEN")
+	}
+
+	if roomID == "" {
+		roomID = os.Getenv("KW_WEBEX_ROOM_ID")
+	}
+
+	url := conf.Url
+	if url == "" {
+		url = defaultUrl
+	}
+
+	w.Token = token
+	w.RoomID = roomID
+	w.Url = strings.TrimSuffix(url, "/")
+
+	client, err := utils.HTTPClient("webex", conf.TLS)
+	if err != nil {
+		return err
+	}
+	client.Timeout = requestTimeout
+	w.client = client
+
+	return checkMissingWebexVars(w)
+}
+
+// Handle handles an event.
+func (w *Webex) Handle(e event.Event) {
+	if err := w.Send(e); err != nil {
+		logger.WithFields(e.LogFields()).Error(err)
+	}
+}
+
+// Send posts the message of the event to the room, or to the one of the
+// kubewatch.io/webex-channel annotation of the object, returning an error
+// when it is not delivered.
+func (w *Webex) Send(e event.Event) error {
+	message := prepareMessage(e, e.Channel("webex", w.RoomID))
+
+	if err := w.post(message); err != nil {
+		metrics.Notifications.WithLabelValues("webex", "failure").Inc()
+		return err
+	}
+
+	metrics.Notifications.WithLabelValues("webex", "success").Inc()
+	logger.WithFields(e.LogFields()).Info("Message successfully sent to Webex")
+	return nil
+}
+
+func checkMissingWebexVars(w *Webex) error {
+	if w.Token == "" || w.RoomID == "" {
+		return fmt.Errorf(webexErrMsg, "Missing Webex bot token or room id")
+	}
+
+	return nil
+}
+
+// prepareMessage returns the markdown message of the event, shown by the
+// clients which can't render its adaptive card listing the fields of the
+// event and its changes.
+func prepareMessage(e event.Event, roomID string) *Message {
+	title := fmt.Sprintf("%s %s %s", e.Kind, e.Name, strings.ToLower(e.Reason))
+	if e.Cluster != "" {
+		title = "[" + e.Cluster + "] " + title
+	}
+
+	var facts []Fact
+	for _, f := range []Fact{
+		{"Kind", e.Kind},
+		{"Name", e.Name},
+		{"Namespace", e.Namespace},
+		{"Reason", e.Reason},
+		{"Severity", e.Severity},
+		{"Host", e.Host},
+	} {
+		if f.Value != "" {
+			facts = append(facts, f)
+		}
+	}
+
+	body := []CardElement{
+		{Type: "TextBlock", Text: title, Weight: "Bolder", Size: "Medium", Color: colors[e.Severity], Wrap: true},
+		{Type: "FactSet", Facts: facts},
+	}
+	if e.Details != "" {
+		body = append(body, CardElement{Type: "TextBlock", Text: e.Details, Wrap: true})
+	}
+	if len(e.Diff) > 0 {
+		var changes []Fact
+		for _, c := range e.Diff {
+			changes = append(changes, Fact{Title: c.Path, Value: fmt.Sprintf("%s → %s", c.Old, c.New)})
+		}
+		body = append(body, CardElement{Type: "FactSet", Facts: changes})
+	}
+
+	return &Message{
+		RoomID:   roomID,
+		Markdown: e.Message(),
+		Attachments: []Attachment{{
+			ContentType: adaptiveCardType,
+			Content: AdaptiveCard{
+				Schema:  "http://adaptivecards.io/schemas/adaptive-card.json",
+				Type:    "AdaptiveCard",
+				Version: "1.2",
+				Body:    body,
+			},
+		}},
+	}
+}
+
+func (w *Webex) post(m *Message) error {
+	message, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", w.Url+messagesPath, bytes.NewBuffer(message))
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("Authorization", "Bearer "+w.Token)
+
+	res, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode/100 != 2 {
+		body, _ := ioutil.ReadAll(res.Body)
+		return fmt.Errorf("Failed posting to Webex. Webex http response: %s, %s", res.Status, string(body))
+	}
+
+	return nil
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webex
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+)
+
+func TestWebexInit(t *testing.T) {
+	w := &Webex{}
+	expectedError := fmt.Errorf(webexErrMsg, "Missing Webex bot token or room id")
+
+	var Tests = []struct {
+		webex config.Webex
+		err   error
+	}{
+		{config.Webex{Token: "foo", RoomID: "bar"}, nil},
+		{config.Webex{Token: "foo"}, expectedError},
+		{config.Webex{RoomID: "bar"}, expectedError},
+		{config.Webex{}, expectedError},
+	}
+
+	for _, tt := range Tests {
+		c := &config.Config{}
+		c.Handler.Webex = tt.webex
+		if err := w.Init(c); !reflect.DeepEqual(err, tt.err) {
+			t.Fatalf("Init(): %v", err)
+		}
+	}
+}
+
+func TestPrepareMessage(t *testing.T) {
+	e := event.Event{Kind: "deployment", Name: "web", Namespace: "prod", Reason: "Updated", Severity: "warning",
+		Diff: []event.Change{{Path: "spec.replicas", Old: "1", New: "2"}}}
+
+	m := prepareMessage(e, "room")
+	if m.RoomID != "room" || m.Markdown != e.Message() {
+		t.Fatalf("got %+v", m)
+	}
+	body := m.Attachments[0].Content.Body
+	if body[0].Text != "deployment web updated" || body[0].Color != "Warning" {
+		t.Fatalf("title: got %+v", body[0])
+	}
+	facts := []Fact{{"Kind", "deployment"}, {"Name", "web"}, {"Namespace", "prod"}, {"Reason", "Updated"}, {"Severity", "warning"}}
+	if !reflect.DeepEqual(body[1].Facts, facts) {
+		t.Fatalf("facts: got %+v", body[1].Facts)
+	}
+	if len(body) != 3 || body[2].Facts[0] != (Fact{"spec.replicas", "1 → 2"}) {
+		t.Fatalf("changes: got %+v", body)
+	}
+}
+
+func TestWebexSend(t *testing.T) {
+	var got Message
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != messagesPath || r.Header.Get("Authorization") != "Bearer foo" {
+			t.Errorf("unexpected request %s with authorization %q", r.URL.Path, r.Header.Get("Authorization"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer ts.Close()
+
+	c := &config.Config{}
+	c.Handler.Webex = config.Webex{Token: "foo", RoomID: "bar", Url: ts.URL + "/"}
+	w := &Webex{}
+	if err := w.Init(c); err != nil {
+		t.Fatal(err)
+	}
+	e := event.Event{Kind: "pod", Name: "web", Reason: "Created", Channels: map[string]string{"webex": "ops"}}
+	if err := w.Send(e); err != nil {
+		t.Fatal(err)
+	}
+
+	if got.RoomID != "ops" || len(got.Attachments) != 1 || got.Attachments[0].ContentType != adaptiveCardType {
+		t.Fatalf("got %+v", got)
+	}
+}
@@ -0,0 +1,139 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+)
+
+// CloudEvents content modes
+// The specification is in https://github.com/cloudevents/spec/blob/v1.0/http-protocol-binding.md
+const (
+	cloudEventsStructured = "structured"
+	cloudEventsBinary     = "binary"
+
+	cloudEventsSpecVersion = "1.0"
+	cloudEventsTypePrefix  = "io.kubewatch"
+)
+
+// CloudEvent is a CloudEvents 1.0 event in structured mode, carrying a WebhookMessage
+type CloudEvent struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Subject         string          `json:"subject,omitempty"`
+	Time            time.Time       `json:"time"`
+	DataContentType string          `json:"datacontenttype"`
+	Data            *WebhookMessage `json:"data"`
+}
+
+// prepareCloudEvent derives the CloudEvents attributes from the kubernetes object:
+// the source is the collection of the object, e.g. "/kubewatch/namespaces/default/deployment",
+// the type is built from the kind and reason, e.g. "io.kubewatch.deployment.updated",
+// and the subject is the name of the object.
+func prepareCloudEvent(e event.Event, webhookMessage *WebhookMessage) *CloudEvent {
+	kind := strings.Replace(strings.ToLower(e.Kind), " ", "", -1)
+
+	source := "/kubewatch"
+	if e.Namespace != "" {
+		source = path.Join(source, "namespaces", e.Namespace)
+	}
+	source = path.Join(source, kind)
+
+	id := e.ID
+	if id == "" {
+		id = uuid.New().String()
+	}
+	return &CloudEvent{
+		SpecVersion:     cloudEventsSpecVersion,
+		ID:              id,
+		Source:          source,
+		Type:            strings.Join([]string{cloudEventsTypePrefix, kind, strings.ToLower(e.Reason)}, "."),
+		Subject:         e.Name,
+		Time:            webhookMessage.Time,
+		DataContentType: "application/json",
+		Data:            webhookMessage,
+	}
+}
+
+func postCloudEvent(m *Webhook, e event.Event, webhookMessage *WebhookMessage) error {
+	ce := prepareCloudEvent(e, webhookMessage)
+
+	var (
+		body []byte
+		err  error
+	)
+	if m.CloudEventsMode == cloudEventsBinary {
+		body, err = json.Marshal(ce.Data)
+	} else {
+		body, err = json.Marshal(ce)
+	}
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", m.Url, bytes.NewBuffer(body))
+	if err != nil {
+		return err
+	}
+	if m.CloudEventsMode == cloudEventsBinary {
+		req.Header.Add("Content-Type", ce.DataContentType)
+		req.Header.Add("ce-specversion", ce.SpecVersion)
+		req.Header.Add("ce-id", ce.ID)
+		req.Header.Add("ce-source", ce.Source)
+		req.Header.Add("ce-type", ce.Type)
+		if ce.Subject != "" {
+			req.Header.Add("ce-subject", ce.Subject)
+		}
+		req.Header.Add("ce-time", ce.Time.Format(time.RFC3339Nano))
+	} else {
+		req.Header.Add("Content-Type", "application/cloudevents+json")
+	}
+	setEventID(req, e)
+
+	return m.send(req)
+}
+
+// postCloudEventBatch posts the events as a batch of structured CloudEvents.
+func postCloudEventBatch(m *Webhook, events []event.Event, webhookMessages []*WebhookMessage) error {
+	batch := make([]*CloudEvent, 0, len(events))
+	for i, e := range events {
+		batch = append(batch, prepareCloudEvent(e, webhookMessages[i]))
+	}
+	body, err := json.Marshal(batch)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", m.Url, bytes.NewBuffer(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Content-Type", "application/cloudevents-batch+json")
+
+	return m.send(req)
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"encoding/json"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+)
+
+// defaultMaxObjectBytes is the default size limit of an embedded object.
+const defaultMaxObjectBytes = 64 << 10
+
+// Objects are the JSON of the object of an event before and after its
+// change, e.g. only the new one for a creation and the old one for a
+// deletion.
+type Objects struct {
+	Old json.RawMessage `json:"old,omitempty"`
+	New json.RawMessage `json:"new,omitempty"`
+	// Omitted lists the objects left out for exceeding the size limit,
+	// "old" or "new".
+	Omitted []string `json:"omitted,omitempty"`
+}
+
+// prepareObjects returns the objects of the event, nil when it has none.
+func prepareObjects(e event.Event, maxBytes int) *Objects {
+	old, new := e.OldObject, e.Object
+	if e.Operation == "delete" {
+		old, new = e.Object, nil
+	}
+	if old == nil && new == nil {
+		return nil
+	}
+
+	objects := &Objects{}
+	encode := func(name string, obj interface{}) json.RawMessage {
+		if obj == nil {
+			return nil
+		}
+		b, err := json.Marshal(manifest(obj, e.Ref))
+		if err != nil {
+			logger.WithFields(e.LogFields()).Warnf("Cannot embed the %s object: %v", name, err)
+			return nil
+		}
+		if len(b) > maxBytes {
+			objects.Omitted = append(objects.Omitted, name)
+			return nil
+		}
+		return b
+	}
+	objects.Old = encode("old", old)
+	objects.New = encode("new", new)
+	return objects
+}
+
+// manifest returns the object with its API version and kind, which the
+// objects of the informer caches usually lack, from the reference. The
+// objects of the caches are copied rather than modified.
+func manifest(obj interface{}, ref *event.ObjectReference) interface{} {
+	o, ok := obj.(runtime.Object)
+	if !ok || ref == nil || ref.Kind == "" || !o.GetObjectKind().GroupVersionKind().Empty() {
+		return obj
+	}
+	o = o.DeepCopyObject()
+	o.GetObjectKind().SetGroupVersionKind(schema.FromAPIVersionAndKind(ref.APIVersion, ref.Kind))
+	return o
+}
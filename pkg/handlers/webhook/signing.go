@@ -0,0 +1,152 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bitnami-labs/kubewatch/config"
+)
+
+// Headers of the signed requests. The signature header lists the HMAC of
+// each key as <key id>=<hex digest>, separated by commas, e.g.
+// "2020-06=5d41...,2020-01=7c2a...". The signed content is the timestamp, a
+// dot and the body, so that a request can not be replayed with another
+// timestamp: receivers verify any of the signatures with the keys they know,
+// then reject the timestamps too far from their clock, e.g. 5 minutes.
+const (
+	TimestampHeader = "X-Kubewatch-Timestamp"
+	SignatureHeader = "X-Kubewatch-Signature"
+)
+
+const (
+	signingScheme        = "v1"
+	signingSignedContent = "{timestamp}.{body}"
+)
+
+// Signing describes the signatures of the request carrying a payload, for
+// the receivers to verify them. It is covered by the signatures like the
+// rest of the body.
+type Signing struct {
+	// Scheme is the version of the signing scheme, "v1".
+	Scheme string `json:"scheme"`
+	// Algorithm of the signatures, "hmac-sha256" or "hmac-sha512".
+	Algorithm       string `json:"algorithm"`
+	TimestampHeader string `json:"timestampHeader"`
+	SignatureHeader string `json:"signatureHeader"`
+	// SignedContent is the template of the signed content.
+	SignedContent string `json:"signedContent"`
+	// KeyIDs are the IDs of the keys signing the request.
+	KeyIDs []string `json:"keyIds"`
+}
+
+// signer signs the requests with the HMACs of its keys.
+type signer struct {
+	hash func() hash.Hash
+	keys []config.SigningKey
+	meta *Signing
+}
+
+// newSigner returns the signer of the settings, nil without keys.
+func newSigner(conf config.WebhookSigning) (*signer, error) {
+	if len(conf.Keys) == 0 {
+		return nil, nil
+	}
+	s := &signer{}
+	switch strings.ToLower(conf.Algorithm) {
+	case "", "sha256":
+		s.hash = sha256.New
+		conf.Algorithm = "sha256"
+	case "sha512":
+		s.hash = sha512.New
+		conf.Algorithm = "sha512"
+	default:
+		return nil, fmt.Errorf("invalid webhook signing algorithm %q, must be sha256 or sha512", conf.Algorithm)
+	}
+
+	seen := map[string]bool{}
+	var ids []string
+	for i, k := range conf.Keys {
+		switch {
+		case k.ID == "":
+			return nil, fmt.Errorf("webhook signing key %d has no id", i+1)
+		case strings.ContainsAny(k.ID, "=, "):
+			return nil, fmt.Errorf("invalid webhook signing key id %q, must not contain '=', ',' or spaces", k.ID)
+		case seen[k.ID]:
+			return nil, fmt.Errorf("duplicate webhook signing key id %q", k.ID)
+		}
+		seen[k.ID] = true
+		secret := os.ExpandEnv(k.Secret)
+		if secret == "" {
+			return nil, fmt.Errorf("webhook signing key %q has no secret", k.ID)
+		}
+		s.keys = append(s.keys, config.SigningKey{ID: k.ID, Secret: secret})
+		ids = append(ids, k.ID)
+	}
+	s.meta = &Signing{
+		Scheme:          signingScheme,
+		Algorithm:       "hmac-" + conf.Algorithm,
+		TimestampHeader: TimestampHeader,
+		SignatureHeader: SignatureHeader,
+		SignedContent:   signingSignedContent,
+		KeyIDs:          ids,
+	}
+	return s, nil
+}
+
+// sign sets the timestamp and the signatures of the body of the request.
+func (s *signer) sign(req *http.Request, now time.Time) error {
+	var body []byte
+	if req.GetBody != nil {
+		r, err := req.GetBody()
+		if err != nil {
+			return err
+		}
+		if body, err = ioutil.ReadAll(r); err != nil {
+			return err
+		}
+	}
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(SignatureHeader, s.signature(timestamp, body))
+	return nil
+}
+
+// signature returns the value of the signature header of the body sent at
+// the timestamp.
+func (s *signer) signature(timestamp string, body []byte) string {
+	signatures := make([]string, len(s.keys))
+	for i, k := range s.keys {
+		mac := hmac.New(s.hash, []byte(k.Secret))
+		mac.Write([]byte(timestamp))
+		mac.Write([]byte("."))
+		mac.Write(body)
+		signatures[i] = k.ID + "=" + hex.EncodeToString(mac.Sum(nil))
+	}
+	return strings.Join(signatures, ",")
+}
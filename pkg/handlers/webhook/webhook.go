@@ -18,16 +18,35 @@ package webhook
 
 import (
 	"fmt"
-	"log"
 	"os"
 
 	"bytes"
 	"encoding/json"
+	"io"
+	"io/ioutil"
 	"net/http"
 	"time"
 
 	"github.com/bitnami-labs/kubewatch/config"
 	"github.com/bitnami-labs/kubewatch/pkg/event"
+	"github.com/bitnami-labs/kubewatch/pkg/metrics"
+	"github.com/bitnami-labs/kubewatch/pkg/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// logger logs the notifications sent by the handler.
+var logger = logrus.WithField("handler", "webhook")
+
+const (
+	defaultTimeout = 10 * time.Second
+	// maxErrorBody is the length of the response body reported in errors.
+	maxErrorBody = 512
+)
+
+// Payload formats
+const (
+	formatKubewatch   = "kubewatch"
+	formatCloudEvents = "cloudevents"
 )
 
 var webhookErrMsg = `
@@ -45,26 +64,62 @@ Command line flags will override environment variables
 // Webhook handler implements handler.Handler interface,
 // Notify event to Webhook channel
 type Webhook struct {
-	Url string
+	Url             string
+	Format          string
+	CloudEventsMode string
+	Headers         map[string]string
+	BearerToken     string
+	BasicAuth       config.BasicAuth
+	IncludeObjects  bool
+	MaxObjectBytes  int
+	PayloadVersion  string
+
+	client *http.Client
+	// signer signs the requests, when keys are configured
+	signer *signer
 }
 
-// WebhookMessage for messages
+// WebhookMessage for messages. With the payload version v2, the event is
+// described by Event instead of EventMeta, Diff and Images.
 type WebhookMessage struct {
-	EventMeta EventMeta `json:"eventmeta"`
-	Text      string    `json:"text"`
-	Time      time.Time `json:"time"`
+	EventMeta *EventMeta     `json:"eventmeta,omitempty"`
+	Event     *event.V2      `json:"event,omitempty"`
+	Text      string         `json:"text"`
+	Time      time.Time      `json:"time"`
+	Diff      []event.Change `json:"diff,omitempty"`
+	// Images lists the container images changed by an update of a workload.
+	Images []event.ImageChange `json:"images,omitempty"`
+	// Objects are the objects before and after the change, when included.
+	Objects *Objects `json:"objects,omitempty"`
+	// Signing describes the signatures of the request, when signed.
+	Signing *Signing `json:"signing,omitempty"`
+}
+
+// WebhookBatch is the message of a batch of events
+type WebhookBatch struct {
+	Events []*WebhookMessage `json:"events"`
 }
 
 // EventMeta containes the meta data about the event occurred
 type EventMeta struct {
+	Cluster   string `json:"cluster,omitempty"`
 	Kind      string `json:"kind"`
 	Name      string `json:"name"`
 	Namespace string `json:"namespace"`
 	Reason    string `json:"reason"`
+	Severity  string `json:"severity,omitempty"`
+	// ID identifies the event, when assigned.
+	ID string `json:"id,omitempty"`
+	// ExternalLabels are the static labels of the config.
+	ExternalLabels map[string]string `json:"externalLabels,omitempty"`
+	// Metadata are the labels and annotations of the object selected by the
+	// enrich settings.
+	Metadata map[string]string `json:"metadata,omitempty"`
 }
 
 // Init prepares Webhook configuration
 func (m *Webhook) Init(c *config.Config) error {
+	m.PayloadVersion = c.PayloadVersion
 	url := c.Handler.Webhook.Url
 
 	if url == "" {
@@ -72,21 +127,121 @@ func (m *Webhook) Init(c *config.Config) error {
 	}
 
 	m.Url = url
+	m.Format = c.Handler.Webhook.Format
+	m.CloudEventsMode = c.Handler.Webhook.CloudEventsMode
+	m.IncludeObjects = c.Handler.Webhook.IncludeObjects
+	m.MaxObjectBytes = c.Handler.Webhook.MaxObjectBytes
+	if m.MaxObjectBytes == 0 {
+		m.MaxObjectBytes = defaultMaxObjectBytes
+	}
+
+	// the credentials are usually given through the environment
+	m.Headers = map[string]string{}
+	for k, v := range c.Handler.Webhook.Headers {
+		m.Headers[k] = os.ExpandEnv(v)
+	}
+	m.BearerToken = os.ExpandEnv(c.Handler.Webhook.BearerToken)
+	m.BasicAuth = config.BasicAuth{
+		Username: os.ExpandEnv(c.Handler.Webhook.BasicAuth.Username),
+		Password: os.ExpandEnv(c.Handler.Webhook.BasicAuth.Password),
+	}
+
+	if err := checkMissingWebhookVars(m); err != nil {
+		return err
+	}
+	if m.BearerToken != "" && m.BasicAuth.Username != "" {
+		return fmt.Errorf("webhook bearerToken and basicAuth are mutually exclusive")
+	}
+
+	client, err := utils.HTTPClient("webhook", c.Handler.Webhook.TLS)
+	if err != nil {
+		return fmt.Errorf("webhook tls: %v", err)
+	}
+	client.Timeout = defaultTimeout
+	if t := c.Handler.Webhook.Timeout; t != "" {
+		if client.Timeout, err = time.ParseDuration(t); err != nil {
+			return fmt.Errorf("invalid webhook timeout %q: %v", t, err)
+		}
+	}
+	m.client = client
 
-	return checkMissingWebhookVars(m)
+	if m.signer, err = newSigner(c.Handler.Webhook.Signing); err != nil {
+		return err
+	}
+	if m.MaxObjectBytes < 0 {
+		return fmt.Errorf("invalid webhook maxObjectBytes %d, must be positive", m.MaxObjectBytes)
+	}
+	switch m.Format {
+	case "", formatKubewatch, formatCloudEvents:
+	default:
+		return fmt.Errorf("invalid webhook format %q, must be %q or %q", m.Format, formatKubewatch, formatCloudEvents)
+	}
+	switch m.CloudEventsMode {
+	case "", cloudEventsStructured, cloudEventsBinary:
+	default:
+		return fmt.Errorf("invalid webhook cloudEventsMode %q, must be %q or %q", m.CloudEventsMode, cloudEventsStructured, cloudEventsBinary)
+	}
+	return nil
 }
 
 // Handle handles an event.
 func (m *Webhook) Handle(e event.Event) {
+	if err := m.Send(e); err != nil {
+		logger.WithFields(e.LogFields()).Error(err)
+	}
+}
+
+// Send sends an event, returning an error when it is not delivered.
+func (m *Webhook) Send(e event.Event) error {
 	webhookMessage := prepareWebhookMessage(e, m)
 
-	err := postMessage(m.Url, webhookMessage)
+	var err error
+	if m.Format == formatCloudEvents {
+		err = postCloudEvent(m, e, webhookMessage)
+	} else {
+		err = m.postMessage(e, webhookMessage)
+	}
+	if err != nil {
+		metrics.Notifications.WithLabelValues("webhook", "failure").Inc()
+		return err
+	}
+
+	logger.WithFields(e.LogFields()).Infof("Message successfully sent to %s", m.Url)
+	metrics.Notifications.WithLabelValues("webhook", "success").Inc()
+	return nil
+}
+
+// SendBatch sends the events in a single message: an object listing their
+// messages in events, or a batch of CloudEvents in structured mode. The
+// events are sent one by one in binary mode.
+func (m *Webhook) SendBatch(events []event.Event) error {
+	if m.Format == formatCloudEvents && m.CloudEventsMode == cloudEventsBinary {
+		for _, e := range events {
+			if err := m.Send(e); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	messages := make([]*WebhookMessage, 0, len(events))
+	for _, e := range events {
+		messages = append(messages, prepareWebhookMessage(e, m))
+	}
+	var err error
+	if m.Format == formatCloudEvents {
+		err = postCloudEventBatch(m, events, messages)
+	} else {
+		err = m.postBatch(&WebhookBatch{Events: messages})
+	}
 	if err != nil {
-		log.Printf("%s\n", err)
-		return
+		metrics.Notifications.WithLabelValues("webhook", "failure").Inc()
+		return err
 	}
 
-	log.Printf("Message successfully sent to %s at %s ", m.Url, time.Now())
+	logger.Infof("Batch of %d events successfully sent to %s", len(events), m.Url)
+	metrics.Notifications.WithLabelValues("webhook", "success").Inc()
+	return nil
 }
 
 func checkMissingWebhookVars(s *Webhook) error {
@@ -98,35 +253,117 @@ func checkMissingWebhookVars(s *Webhook) error {
 }
 
 func prepareWebhookMessage(e event.Event, m *Webhook) *WebhookMessage {
+	var objects *Objects
+	if m.IncludeObjects {
+		objects = prepareObjects(e, m.MaxObjectBytes)
+	}
+	if m.PayloadVersion == event.PayloadV2 {
+		v2 := e.V2()
+		return &WebhookMessage{Event: &v2, Text: v2.Message, Time: time.Now(), Objects: objects, Signing: m.signing()}
+	}
 	return &WebhookMessage{
-		EventMeta: EventMeta{
-			Kind:      e.Kind,
-			Name:      e.Name,
-			Namespace: e.Namespace,
-			Reason:    e.Reason,
+		EventMeta: &EventMeta{
+			Cluster:        e.Cluster,
+			Kind:           e.Kind,
+			Name:           e.Name,
+			Namespace:      e.Namespace,
+			Reason:         e.Reason,
+			Severity:       e.Severity,
+			ID:             e.ID,
+			ExternalLabels: e.ExternalLabels,
+			Metadata:       e.Metadata,
 		},
-		Text: e.Message(),
-		Time: time.Now(),
+		Text:    e.Message(),
+		Time:    time.Now(),
+		Diff:    e.Diff,
+		Images:  e.Images,
+		Objects: objects,
+		Signing: m.signing(),
+	}
+}
+
+// signing returns the description of the signatures of the requests, nil
+// when they are not signed.
+func (m *Webhook) signing() *Signing {
+	if m.signer == nil {
+		return nil
 	}
+	return m.signer.meta
 }
 
-func postMessage(url string, webhookMessage *WebhookMessage) error {
-	message, err := json.Marshal(webhookMessage)
+// postMessage posts the JSON of the message of an event, with its ID.
+func (m *Webhook) postMessage(e event.Event, webhookMessage *WebhookMessage) error {
+	req, err := m.jsonRequest(webhookMessage)
 	if err != nil {
 		return err
 	}
+	setEventID(req, e)
+	return m.send(req)
+}
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(message))
+// postBatch posts the JSON of the message.
+func (m *Webhook) postBatch(body interface{}) error {
+	req, err := m.jsonRequest(body)
 	if err != nil {
 		return err
 	}
+	return m.send(req)
+}
+
+// jsonRequest returns the request posting the JSON of the body.
+func (m *Webhook) jsonRequest(body interface{}) (*http.Request, error) {
+	message, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", m.Url, bytes.NewBuffer(message))
+	if err != nil {
+		return nil, err
+	}
 	req.Header.Add("Content-Type", "application/json")
+	return req, nil
+}
+
+// EventIDHeader is the header of the requests of a single event carrying its
+// ID, to match the deliveries with the logs of kubewatch.
+const EventIDHeader = "X-Kubewatch-Event-Id"
 
-	client := &http.Client{}
-	_, err = client.Do(req)
+func setEventID(req *http.Request, e event.Event) {
+	if e.ID != "" {
+		req.Header.Set(EventIDHeader, e.ID)
+	}
+}
+
+// send adds the configured headers and credentials to the request and sends it.
+func (m *Webhook) send(req *http.Request) error {
+	for k, v := range m.Headers {
+		req.Header.Set(k, v)
+	}
+	if m.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+m.BearerToken)
+	}
+	if m.BasicAuth.Username != "" {
+		req.SetBasicAuth(m.BasicAuth.Username, m.BasicAuth.Password)
+	}
+	if m.signer != nil {
+		if err := m.signer.sign(req, time.Now()); err != nil {
+			return err
+		}
+	}
+
+	res, err := m.client.Do(req)
 	if err != nil {
 		return err
 	}
+	defer res.Body.Close()
+
+	if res.StatusCode/100 != 2 {
+		body, _ := ioutil.ReadAll(io.LimitReader(res.Body, maxErrorBody))
+		return fmt.Errorf("Failed posting to webhook %s. Webhook http response: %s, %s", m.Url, res.Status, string(body))
+	}
+	// drain the body so that the connection is reused
+	io.Copy(ioutil.Discard, res.Body)
 
 	return nil
 }
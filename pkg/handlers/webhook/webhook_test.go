@@ -17,11 +17,27 @@ limitations under the License.
 package webhook
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
 	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
 	"reflect"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+	"github.com/bitnami-labs/kubewatch/pkg/utils"
+	apps_v1 "k8s.io/api/apps/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func TestWebhookInit(t *testing.T) {
@@ -44,3 +60,538 @@ func TestWebhookInit(t *testing.T) {
 		}
 	}
 }
+
+func TestCloudEvents(t *testing.T) {
+	e := event.Event{Namespace: "default", Kind: "daemon set", Name: "foo", Reason: "Updated"}
+
+	var Tests = []struct {
+		mode string
+		want func(r *http.Request, body []byte) error
+	}{
+		{cloudEventsStructured, func(r *http.Request, body []byte) error {
+			var ce CloudEvent
+			if err := json.Unmarshal(body, &ce); err != nil {
+				return err
+			}
+			if got := r.Header.Get("Content-Type"); got != "application/cloudevents+json" {
+				return fmt.Errorf("unexpected content type %q", got)
+			}
+			if ce.Type != "io.kubewatch.daemonset.updated" || ce.Source != "/kubewatch/namespaces/default/daemonset" || ce.Subject != "foo" {
+				return fmt.Errorf("unexpected event %+v", ce)
+			}
+			if ce.Data == nil || ce.Data.EventMeta.Name != "foo" {
+				return fmt.Errorf("unexpected data %+v", ce.Data)
+			}
+			return nil
+		}},
+		{cloudEventsBinary, func(r *http.Request, body []byte) error {
+			var msg WebhookMessage
+			if err := json.Unmarshal(body, &msg); err != nil {
+				return err
+			}
+			if got := r.Header.Get("ce-type"); got != "io.kubewatch.daemonset.updated" {
+				return fmt.Errorf("unexpected ce-type %q", got)
+			}
+			if r.Header.Get("ce-id") == "" || r.Header.Get("ce-specversion") != "1.0" {
+				return fmt.Errorf("missing ce headers %v", r.Header)
+			}
+			if msg.EventMeta.Name != "foo" {
+				return fmt.Errorf("unexpected data %+v", msg)
+			}
+			return nil
+		}},
+	}
+
+	for _, tt := range Tests {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := ioutil.ReadAll(r.Body)
+			if err := tt.want(r, body); err != nil {
+				t.Errorf("%s: %v", tt.mode, err)
+			}
+		}))
+
+		s := &Webhook{}
+		c := &config.Config{}
+		c.Handler.Webhook = config.Webhook{Url: ts.URL, Format: formatCloudEvents, CloudEventsMode: tt.mode}
+		if err := s.Init(c); err != nil {
+			t.Fatal(err)
+		}
+		s.Handle(e)
+		ts.Close()
+	}
+}
+
+func TestWebhookAuth(t *testing.T) {
+	os.Setenv("KW_TEST_WEBHOOK_TOKEN", "s3cr3t")
+	defer os.Unsetenv("KW_TEST_WEBHOOK_TOKEN")
+
+	var Tests = []struct {
+		webhook config.Webhook
+		want    func(r *http.Request) error
+	}{
+		{config.Webhook{Headers: map[string]string{"X-Tenant": "team-a", "X-Token": "${KW_TEST_WEBHOOK_TOKEN}"}}, func(r *http.Request) error {
+			if r.Header.Get("X-Tenant") != "team-a" || r.Header.Get("X-Token") != "s3cr3t" {
+				return fmt.Errorf("unexpected headers %v", r.Header)
+			}
+			return nil
+		}},
+		{config.Webhook{BearerToken: "$KW_TEST_WEBHOOK_TOKEN"}, func(r *http.Request) error {
+			if got := r.Header.Get("Authorization"); got != "Bearer s3cr3t" {
+				return fmt.Errorf("unexpected authorization %q", got)
+			}
+			return nil
+		}},
+		{config.Webhook{BasicAuth: config.BasicAuth{Username: "kubewatch", Password: "$KW_TEST_WEBHOOK_TOKEN"}}, func(r *http.Request) error {
+			if u, p, ok := r.BasicAuth(); !ok || u != "kubewatch" || p != "s3cr3t" {
+				return fmt.Errorf("unexpected basic auth %q %q", u, p)
+			}
+			return nil
+		}},
+	}
+
+	for _, tt := range Tests {
+		var err error
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			err = tt.want(r)
+		}))
+
+		c := &config.Config{}
+		c.Handler.Webhook = tt.webhook
+		c.Handler.Webhook.Url = ts.URL
+		m := &Webhook{}
+		if err := m.Init(c); err != nil {
+			t.Fatal(err)
+		}
+		m.Handle(event.Event{Kind: "pod", Name: "foo", Reason: "Created"})
+		ts.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c := &config.Config{}
+	c.Handler.Webhook = config.Webhook{Url: "foo", BearerToken: "foo", BasicAuth: config.BasicAuth{Username: "bar"}}
+	if err := (&Webhook{}).Init(c); err == nil {
+		t.Fatal("Init(): expected an error for both bearer token and basic auth")
+	}
+}
+
+func TestWebhookEventID(t *testing.T) {
+	for _, format := range []string{"", formatCloudEvents} {
+		var header string
+		var message struct {
+			ID        string     `json:"id"`
+			EventMeta *EventMeta `json:"eventmeta"`
+		}
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header = r.Header.Get(EventIDHeader)
+			json.NewDecoder(r.Body).Decode(&message)
+		}))
+
+		c := &config.Config{}
+		c.Handler.Webhook = config.Webhook{Url: ts.URL, Format: format}
+		m := &Webhook{}
+		if err := m.Init(c); err != nil {
+			t.Fatal(err)
+		}
+		err := m.Send(event.Event{ID: "0b5c2e4e", Kind: "pod", Name: "foo", Reason: "Created"})
+		ts.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if header != "0b5c2e4e" {
+			t.Fatalf("format %q: unexpected %s header %q", format, EventIDHeader, header)
+		}
+		if format == formatCloudEvents && message.ID != "0b5c2e4e" {
+			t.Fatalf("unexpected CloudEvent id %q", message.ID)
+		}
+		if format == "" && (message.EventMeta == nil || message.EventMeta.ID != "0b5c2e4e") {
+			t.Fatalf("unexpected eventmeta %+v", message.EventMeta)
+		}
+	}
+}
+
+func TestWebhookSigning(t *testing.T) {
+	os.Setenv("KW_TEST_SIGNING_SECRET", "new-secret")
+	defer os.Unsetenv("KW_TEST_SIGNING_SECRET")
+
+	for _, algorithm := range []string{"", "sha512"} {
+		var header http.Header
+		var body []byte
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header = r.Header
+			body, _ = ioutil.ReadAll(r.Body)
+		}))
+
+		c := &config.Config{}
+		c.Handler.Webhook = config.Webhook{Url: ts.URL, Signing: config.WebhookSigning{
+			Algorithm: algorithm,
+			Keys: []config.SigningKey{
+				{ID: "2020-06", Secret: "${KW_TEST_SIGNING_SECRET}"},
+				{ID: "2020-01", Secret: "old-secret"},
+			},
+		}}
+		m := &Webhook{}
+		if err := m.Init(c); err != nil {
+			t.Fatal(err)
+		}
+		err := m.Send(event.Event{Kind: "pod", Name: "foo", Reason: "Created"})
+		ts.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// verify as a receiver knowing a single key
+		timestamp := header.Get(TimestampHeader)
+		if _, err := strconv.ParseInt(timestamp, 10, 64); err != nil {
+			t.Fatalf("invalid %s header %q", TimestampHeader, timestamp)
+		}
+		h, name := sha256.New, "hmac-sha256"
+		if algorithm == "sha512" {
+			h, name = sha512.New, "hmac-sha512"
+		}
+		for _, k := range []config.SigningKey{{ID: "2020-06", Secret: "new-secret"}, {ID: "2020-01", Secret: "old-secret"}} {
+			mac := hmac.New(h, []byte(k.Secret))
+			mac.Write([]byte(timestamp + "."))
+			mac.Write(body)
+			want := k.ID + "=" + hex.EncodeToString(mac.Sum(nil))
+			if !strings.Contains(header.Get(SignatureHeader)+",", want+",") {
+				t.Fatalf("%s header %q without the signature %s", SignatureHeader, header.Get(SignatureHeader), want)
+			}
+		}
+
+		var message WebhookMessage
+		if err := json.Unmarshal(body, &message); err != nil {
+			t.Fatal(err)
+		}
+		want := &Signing{
+			Scheme:          "v1",
+			Algorithm:       name,
+			TimestampHeader: TimestampHeader,
+			SignatureHeader: SignatureHeader,
+			SignedContent:   "{timestamp}.{body}",
+			KeyIDs:          []string{"2020-06", "2020-01"},
+		}
+		if !reflect.DeepEqual(message.Signing, want) {
+			t.Fatalf("unexpected signing %+v", message.Signing)
+		}
+	}
+}
+
+func TestWebhookSigningInit(t *testing.T) {
+	var Tests = []struct {
+		signing config.WebhookSigning
+		err     string
+	}{
+		{config.WebhookSigning{Algorithm: "md5", Keys: []config.SigningKey{{ID: "a", Secret: "s"}}}, `invalid webhook signing algorithm "md5", must be sha256 or sha512`},
+		{config.WebhookSigning{Keys: []config.SigningKey{{Secret: "s"}}}, "webhook signing key 1 has no id"},
+		{config.WebhookSigning{Keys: []config.SigningKey{{ID: "a=b", Secret: "s"}}}, `invalid webhook signing key id "a=b", must not contain '=', ',' or spaces`},
+		{config.WebhookSigning{Keys: []config.SigningKey{{ID: "a", Secret: "s"}, {ID: "a", Secret: "t"}}}, `duplicate webhook signing key id "a"`},
+		{config.WebhookSigning{Keys: []config.SigningKey{{ID: "a", Secret: "${KW_TEST_UNSET_SECRET}"}}}, `webhook signing key "a" has no secret`},
+	}
+	for _, tt := range Tests {
+		c := &config.Config{}
+		c.Handler.Webhook = config.Webhook{Url: "http://localhost", Signing: tt.signing}
+		if err := (&Webhook{}).Init(c); err == nil || err.Error() != tt.err {
+			t.Fatalf("Init(): got %v, want %s", err, tt.err)
+		}
+	}
+}
+
+func TestWebhookTLS(t *testing.T) {
+	received := false
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = true
+	}))
+	defer ts.Close()
+
+	caFile, err := ioutil.TempFile("", "kubewatch-ca")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(caFile.Name())
+	pem.Encode(caFile, &pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw})
+	caFile.Close()
+
+	var Tests = []struct {
+		tls  config.TLS
+		want bool
+	}{
+		{config.TLS{}, false},
+		{config.TLS{CAFile: caFile.Name()}, true},
+		{config.TLS{InsecureSkipVerify: true}, true},
+	}
+
+	for _, tt := range Tests {
+		received = false
+		c := &config.Config{}
+		c.Handler.Webhook = config.Webhook{Url: ts.URL, TLS: tt.tls}
+		m := &Webhook{}
+		if err := m.Init(c); err != nil {
+			t.Fatal(err)
+		}
+		m.Handle(event.Event{Kind: "pod", Name: "foo", Reason: "Created"})
+		if received != tt.want {
+			t.Fatalf("TLS %+v: received %v, want %v", tt.tls, received, tt.want)
+		}
+	}
+
+	c := &config.Config{}
+	c.Handler.Webhook = config.Webhook{Url: ts.URL, TLS: config.TLS{CAFile: "/nonexistent"}}
+	if err := (&Webhook{}).Init(c); err == nil {
+		t.Fatal("Init(): expected an error for a missing CA file")
+	}
+}
+
+func TestWebhookProxy(t *testing.T) {
+	direct := false
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		direct = true
+	}))
+	defer ts.Close()
+	proxied := false
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.Host == strings.TrimPrefix(ts.URL, "http://")
+	}))
+	defer proxy.Close()
+	defer utils.SetProxy(config.Proxy{})
+
+	var Tests = []struct {
+		proxy   config.Proxy
+		proxied bool
+	}{
+		{config.Proxy{URL: proxy.URL}, true},
+		{config.Proxy{URL: proxy.URL, NoProxy: []string{"127.0.0.0/8"}}, false},
+		{config.Proxy{URL: proxy.URL, Handlers: map[string]string{"webhook": "direct"}}, false},
+		{config.Proxy{URL: proxy.URL, Handlers: map[string]string{"slack": "direct"}}, true},
+		{config.Proxy{Handlers: map[string]string{"webhook": proxy.URL}}, true},
+		{config.Proxy{NoProxy: []string{"127.0.0.1"}, Handlers: map[string]string{"webhook": proxy.URL}}, false},
+	}
+
+	for _, tt := range Tests {
+		direct, proxied = false, false
+		if err := utils.SetProxy(tt.proxy); err != nil {
+			t.Fatal(err)
+		}
+		c := &config.Config{}
+		c.Handler.Webhook = config.Webhook{Url: ts.URL}
+		m := &Webhook{}
+		if err := m.Init(c); err != nil {
+			t.Fatal(err)
+		}
+		m.Handle(event.Event{Kind: "pod", Name: "foo", Reason: "Created"})
+		if proxied != tt.proxied || direct == tt.proxied {
+			t.Fatalf("proxy %+v: proxied %v, direct %v", tt.proxy, proxied, direct)
+		}
+	}
+
+	if err := utils.SetProxy(config.Proxy{URL: "http://"}); err == nil {
+		t.Fatal("SetProxy(): expected an error for an invalid URL")
+	}
+}
+
+func TestWebhookResponse(t *testing.T) {
+	var Tests = []struct {
+		status int
+		err    bool
+	}{
+		{http.StatusOK, false},
+		{http.StatusAccepted, false},
+		{http.StatusUnauthorized, true},
+		{http.StatusInternalServerError, true},
+	}
+
+	for _, tt := range Tests {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tt.status)
+			w.Write([]byte("denied"))
+		}))
+
+		c := &config.Config{}
+		c.Handler.Webhook = config.Webhook{Url: ts.URL, Timeout: "1s"}
+		m := &Webhook{}
+		if err := m.Init(c); err != nil {
+			t.Fatal(err)
+		}
+		e := event.Event{Kind: "pod", Name: "foo"}
+		err := m.postMessage(e, prepareWebhookMessage(e, m))
+		ts.Close()
+		if (err != nil) != tt.err {
+			t.Fatalf("status %d: got error %v", tt.status, err)
+		}
+		if err != nil && !strings.Contains(err.Error(), "denied") {
+			t.Fatalf("status %d: error does not contain the response body: %v", tt.status, err)
+		}
+	}
+
+	c := &config.Config{}
+	c.Handler.Webhook = config.Webhook{Url: "foo", Timeout: "soon"}
+	if err := (&Webhook{}).Init(c); err == nil {
+		t.Fatal("Init(): expected an error for an invalid timeout")
+	}
+}
+
+func TestWebhookPayloadV2(t *testing.T) {
+	var msg WebhookMessage
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer ts.Close()
+
+	c := &config.Config{PayloadVersion: event.PayloadV2}
+	c.Handler.Webhook = config.Webhook{Url: ts.URL}
+	m := &Webhook{}
+	if err := m.Init(c); err != nil {
+		t.Fatal(err)
+	}
+	e := event.Event{
+		Namespace: "default",
+		Kind:      "pod",
+		Name:      "foo",
+		Reason:    "Updated",
+		Operation: "update",
+		Ref:       &event.ObjectReference{APIVersion: "v1", Kind: "Pod", Namespace: "default", Name: "foo", UID: "1234", ResourceVersion: "7"},
+	}
+	if err := m.Send(e); err != nil {
+		t.Fatal(err)
+	}
+	if msg.EventMeta != nil || msg.Event == nil {
+		t.Fatalf("got %+v", msg)
+	}
+	if msg.Event.Object != *e.Ref || msg.Event.Operation != "update" || msg.Event.SchemaVersion != "v2" {
+		t.Fatalf("got event %+v", msg.Event)
+	}
+}
+
+func TestWebhookObjects(t *testing.T) {
+	var msg WebhookMessage
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		msg = WebhookMessage{}
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer ts.Close()
+
+	replicas := func(n int32) *apps_v1.Deployment {
+		return &apps_v1.Deployment{
+			ObjectMeta: meta_v1.ObjectMeta{Namespace: "default", Name: "web"},
+			Spec:       apps_v1.DeploymentSpec{Replicas: &n},
+		}
+	}
+	e := event.Event{
+		Namespace: "default",
+		Kind:      "deployment",
+		Name:      "web",
+		Reason:    "Updated",
+		Operation: "update",
+		Ref:       &event.ObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "default", Name: "web"},
+		Object:    replicas(3),
+		OldObject: replicas(1),
+	}
+
+	c := &config.Config{}
+	c.Handler.Webhook = config.Webhook{Url: ts.URL, IncludeObjects: true}
+	m := &Webhook{}
+	if err := m.Init(c); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Send(e); err != nil {
+		t.Fatal(err)
+	}
+	if msg.Objects == nil {
+		t.Fatalf("got no objects in %+v", msg)
+	}
+	var old, new apps_v1.Deployment
+	if err := json.Unmarshal(msg.Objects.Old, &old); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(msg.Objects.New, &new); err != nil {
+		t.Fatal(err)
+	}
+	if *old.Spec.Replicas != 1 || *new.Spec.Replicas != 3 || new.Kind != "Deployment" || new.APIVersion != "apps/v1" {
+		t.Fatalf("got objects %s and %s", msg.Objects.Old, msg.Objects.New)
+	}
+	if e.Object.(*apps_v1.Deployment).Kind != "" {
+		t.Fatal("the object of the event was modified")
+	}
+
+	m.MaxObjectBytes = 10
+	if err := m.Send(e); err != nil {
+		t.Fatal(err)
+	}
+	if msg.Objects == nil || msg.Objects.New != nil || !reflect.DeepEqual(msg.Objects.Omitted, []string{"old", "new"}) {
+		t.Fatalf("got objects %+v, want them omitted", msg.Objects)
+	}
+
+	m.IncludeObjects = false
+	if err := m.Send(e); err != nil {
+		t.Fatal(err)
+	}
+	if msg.Objects != nil {
+		t.Fatalf("got objects %+v, want none", msg.Objects)
+	}
+}
+
+func TestWebhookBatch(t *testing.T) {
+	events := []event.Event{
+		{Namespace: "default", Kind: "pod", Name: "foo", Reason: "Deleted"},
+		{Namespace: "default", Kind: "pod", Name: "bar", Reason: "Deleted"},
+	}
+
+	var Tests = []struct {
+		format      string
+		contentType string
+		names       func(body []byte) ([]string, error)
+	}{
+		{formatKubewatch, "application/json", func(body []byte) ([]string, error) {
+			var batch WebhookBatch
+			err := json.Unmarshal(body, &batch)
+			var names []string
+			for _, m := range batch.Events {
+				names = append(names, m.EventMeta.Name)
+			}
+			return names, err
+		}},
+		{formatCloudEvents, "application/cloudevents-batch+json", func(body []byte) ([]string, error) {
+			var batch []CloudEvent
+			err := json.Unmarshal(body, &batch)
+			var names []string
+			for _, ce := range batch {
+				names = append(names, ce.Subject)
+			}
+			return names, err
+		}},
+	}
+
+	for _, tt := range Tests {
+		requests := 0
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			if got := r.Header.Get("Content-Type"); got != tt.contentType {
+				t.Errorf("%s: unexpected content type %q", tt.format, got)
+			}
+			body, _ := ioutil.ReadAll(r.Body)
+			names, err := tt.names(body)
+			if err != nil || !reflect.DeepEqual(names, []string{"foo", "bar"}) {
+				t.Errorf("%s: got events %v, %v", tt.format, names, err)
+			}
+		}))
+
+		s := &Webhook{}
+		c := &config.Config{}
+		c.Handler.Webhook = config.Webhook{Url: ts.URL, Format: tt.format}
+		if err := s.Init(c); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.SendBatch(events); err != nil {
+			t.Fatalf("%s: %v", tt.format, err)
+		}
+		if requests != 1 {
+			t.Fatalf("%s: got %d requests, want 1", tt.format, requests)
+		}
+		ts.Close()
+	}
+}
@@ -0,0 +1,199 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package zulip
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+	"github.com/bitnami-labs/kubewatch/pkg/metrics"
+	"github.com/bitnami-labs/kubewatch/pkg/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// logger logs the notifications sent by the handler.
+var logger = logrus.WithField("handler", "zulip")
+
+const (
+	messagesPath   = "/api/v1/messages"
+	requestTimeout = 10 * time.Second
+	// defaultTopic is the topic of the events of the cluster-scoped objects.
+	defaultTopic = "cluster"
+	// maxTopicSize is the size limit of the topics of Zulip.
+	maxTopicSize = 60
+)
+
+var zulipErrMsg = `
+%s
+
+You need to set the Zulip server url, the bot email, its API key and the
+stream for Zulip notify, using "--url/-u", "--email/-e", "--api-key/-k" and
+"--stream/-s", or using environment variables:
+
+export KW_ZULIP_URL=https://example.zulipchat.com
+export KW_ZULIP_EMAIL=kubewatch-bot@example.zulipchat.com
+export KW_ZULIP_API_KEY=zulip_api_key
+export KW_ZULIP_STREAM=zulip_stream
+
+Command line flags will override environment variables
+
+`
+
+// Zulip handler implements handler.Handler interface,
+// Notify event to a Zulip stream
+type Zulip struct {
+	Url    string
+	Email  string
+	APIKey string
+	Stream string
+	Topic  string
+
+	client *http.Client
+}
+
+// response is the result of a request to the Zulip API.
+type response struct {
+	Result string `json:"result"`
+	Msg    string `json:"msg"`
+}
+
+// Init prepares Zulip configuration
+func (z *Zulip) Init(c *config.Config) error {
+	conf := c.Handler.Zulip
+	serverUrl := conf.Url
+	email := conf.Email
+	apiKey := conf.APIKey
+	stream := conf.Stream
+
+	if serverUrl == "" {
+		serverUrl = os.Getenv("KW_ZULIP_URL")
+	}
+
+	if email == "" {
+		email = os.Getenv("KW_ZULIP_EMAIL")
+	}
+
+	if apiKey == "" {
+		apiKey = os.Getenv("KW_ZULIP_API_KEY")
+	}
+
+	if stream == "" {
+		stream = os.Getenv("KW_ZULIP_STREAM")
+	}
+
+	z.Url = strings.TrimSuffix(serverUrl, "/")
+	z.Email = email
+	z.APIKey = apiKey
+	z.Stream = stream
+	z.Topic = conf.Topic
+
+	client, err := utils.HTTPClient("zulip", conf.TLS)
+	if err != nil {
+		return err
+	}
+	client.Timeout = requestTimeout
+	z.client = client
+
+	return checkMissingZulipVars(z)
+}
+
+// Handle handles an event.
+func (z *Zulip) Handle(e event.Event) {
+	if err := z.Send(e); err != nil {
+		logger.WithFields(e.LogFields()).Error(err)
+	}
+}
+
+// Send posts the message of the event to the stream, or to the one of the
+// kubewatch.io/zulip-channel annotation of the object, in the topic of its
+// namespace unless a topic is configured, returning an error when it is not
+// delivered.
+func (z *Zulip) Send(e event.Event) error {
+	form := url.Values{
+		"type":    {"stream"},
+		"to":      {e.Channel("zulip", z.Stream)},
+		"topic":   {topic(e, z.Topic)},
+		"content": {e.Message()},
+	}
+
+	if err := z.post(form); err != nil {
+		metrics.Notifications.WithLabelValues("zulip", "failure").Inc()
+		return err
+	}
+
+	metrics.Notifications.WithLabelValues("zulip", "success").Inc()
+	logger.WithFields(e.LogFields()).Info("Message successfully sent to Zulip")
+	return nil
+}
+
+func checkMissingZulipVars(z *Zulip) error {
+	if z.Url == "" || z.Email == "" || z.APIKey == "" || z.Stream == "" {
+		return fmt.Errorf(zulipErrMsg, "Missing Zulip server url, bot email, API key or stream")
+	}
+
+	return nil
+}
+
+// topic returns the topic of the event, the configured one or else the
+// namespace of the object, prefixed by its cluster.
+func topic(e event.Event, configured string) string {
+	t := configured
+	if t == "" {
+		t = e.Namespace
+		if t == "" {
+			t = defaultTopic
+		}
+		if e.Cluster != "" {
+			t = e.Cluster + "/" + t
+		}
+	}
+	if len(t) > maxTopicSize {
+		t = t[:maxTopicSize]
+	}
+	return t
+}
+
+func (z *Zulip) post(form url.Values) error {
+	req, err := http.NewRequest("POST", z.Url+messagesPath, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(z.Email, z.APIKey)
+
+	res, err := z.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	body, _ := ioutil.ReadAll(res.Body)
+	var r response
+	if res.StatusCode != http.StatusOK || json.Unmarshal(body, &r) != nil || r.Result != "success" {
+		return fmt.Errorf("Failed posting to Zulip. Zulip http response: %s, %s", res.Status, string(body))
+	}
+
+	return nil
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package zulip
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+)
+
+func TestZulipInit(t *testing.T) {
+	z := &Zulip{}
+	expectedError := fmt.Errorf(zulipErrMsg, "Missing Zulip server url, bot email, API key or stream")
+
+	var Tests = []struct {
+		zulip config.Zulip
+		err   error
+	}{
+		{config.Zulip{Url: "https://example.zulipchat.com", Email: "bot@example.com", APIKey: "foo", Stream: "ops"}, nil},
+		{config.Zulip{Url: "https://example.zulipchat.com", Email: "bot@example.com", APIKey: "foo"}, expectedError},
+		{config.Zulip{Stream: "ops"}, expectedError},
+		{config.Zulip{}, expectedError},
+	}
+
+	for _, tt := range Tests {
+		c := &config.Config{}
+		c.Handler.Zulip = tt.zulip
+		if err := z.Init(c); !reflect.DeepEqual(err, tt.err) {
+			t.Fatalf("Init(): %v", err)
+		}
+	}
+}
+
+func TestTopic(t *testing.T) {
+	var Tests = []struct {
+		e          event.Event
+		configured string
+		want       string
+	}{
+		{event.Event{Namespace: "shop"}, "", "shop"},
+		{event.Event{Namespace: "shop", Cluster: "prod"}, "", "prod/shop"},
+		{event.Event{Kind: "node"}, "", "cluster"},
+		{event.Event{Namespace: "shop"}, "kubewatch", "kubewatch"},
+		{event.Event{Namespace: strings.Repeat("n", 70)}, "", strings.Repeat("n", 60)},
+	}
+
+	for _, tt := range Tests {
+		if got := topic(tt.e, tt.configured); got != tt.want {
+			t.Errorf("topic(%+v, %q): got %q, want %q", tt.e, tt.configured, got, tt.want)
+		}
+	}
+}
+
+func TestZulipSend(t *testing.T) {
+	var got url.Values
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, key, _ := r.BasicAuth(); r.URL.Path != messagesPath || user != "bot@example.com" || key != "foo" {
+			t.Errorf("unexpected request %s of %s", r.URL.Path, user)
+		}
+		if err := r.ParseForm(); err != nil {
+			t.Error(err)
+		}
+		got = r.PostForm
+		if got.Get("to") == "fail" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"result":"error","msg":"Stream 'fail' does not exist"}`)
+			return
+		}
+		fmt.Fprint(w, `{"result":"success","msg":"","id":42}`)
+	}))
+	defer ts.Close()
+
+	c := &config.Config{}
+	c.Handler.Zulip = config.Zulip{Url: ts.URL, Email: "bot@example.com", APIKey: "foo", Stream: "ops"}
+	z := &Zulip{}
+	if err := z.Init(c); err != nil {
+		t.Fatal(err)
+	}
+	e := event.Event{Kind: "pod", Name: "web", Namespace: "shop", Reason: "Created"}
+	if err := z.Send(e); err != nil {
+		t.Fatal(err)
+	}
+	want := url.Values{"type": {"stream"}, "to": {"ops"}, "topic": {"shop"}, "content": {e.Message()}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	e.Channels = map[string]string{"zulip": "fail"}
+	if err := z.Send(e); err == nil {
+		t.Fatal("Send(): no error for an unknown stream")
+	}
+}
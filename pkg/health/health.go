@@ -0,0 +1,104 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package health implements the liveness and readiness probes of kubewatch.
+package health
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Check returns an error when the checked component isn't ready.
+type Check func() error
+
+var (
+	mu     sync.RWMutex
+	checks = map[string]Check{}
+)
+
+// AddReadinessCheck registers a named check which must pass for kubewatch to be ready.
+// Adding a check with the name of an existing one replaces it.
+func AddReadinessCheck(name string, check Check) {
+	mu.Lock()
+	defer mu.Unlock()
+	checks[name] = check
+}
+
+// RemoveReadinessCheck unregisters a check.
+func RemoveReadinessCheck(name string) {
+	mu.Lock()
+	defer mu.Unlock()
+	delete(checks, name)
+}
+
+// Ready runs all the readiness checks and returns the failures by check name.
+func Ready() map[string]error {
+	mu.RLock()
+	defer mu.RUnlock()
+	failures := map[string]error{}
+	for name, check := range checks {
+		if err := check(); err != nil {
+			failures[name] = err
+		}
+	}
+	return failures
+}
+
+// Register adds the /healthz and /readyz endpoints to the mux.
+func Register(mux *http.ServeMux) {
+	mux.HandleFunc("/healthz", healthz)
+	mux.HandleFunc("/readyz", readyz)
+}
+
+// healthz reports that the process is alive and serving.
+func healthz(w http.ResponseWriter, r *http.Request) {
+	fmt.Fprintln(w, "ok")
+}
+
+// readyz lists the result of every readiness check, failing with 503 if any failed.
+func readyz(w http.ResponseWriter, r *http.Request) {
+	failures := Ready()
+
+	mu.RLock()
+	names := make([]string, 0, len(checks))
+	for name := range checks {
+		names = append(names, name)
+	}
+	mu.RUnlock()
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		if err, failed := failures[name]; failed {
+			fmt.Fprintf(&b, "[-]%s failed: %v\n", name, err)
+		} else {
+			fmt.Fprintf(&b, "[+]%s ok\n", name)
+		}
+	}
+
+	if len(failures) > 0 {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprint(w, b.String())
+		fmt.Fprintln(w, "readyz check failed")
+		return
+	}
+	fmt.Fprint(w, b.String())
+	fmt.Fprintln(w, "readyz check passed")
+}
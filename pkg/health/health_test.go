@@ -0,0 +1,52 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package health
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestReadyz(t *testing.T) {
+	mux := http.NewServeMux()
+	Register(mux)
+
+	var synced error = fmt.Errorf("not synced")
+	AddReadinessCheck("handlers", func() error { return nil })
+	AddReadinessCheck("informer pod", func() error { return synced })
+	defer RemoveReadinessCheck("handlers")
+	defer RemoveReadinessCheck("informer pod")
+
+	get := func(path string) int {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		return w.Code
+	}
+
+	if got := get("/healthz"); got != http.StatusOK {
+		t.Fatalf("/healthz: got %d", got)
+	}
+	if got := get("/readyz"); got != http.StatusServiceUnavailable {
+		t.Fatalf("/readyz before sync: got %d", got)
+	}
+	synced = nil
+	if got := get("/readyz"); got != http.StatusOK {
+		t.Fatalf("/readyz after sync: got %d", got)
+	}
+}
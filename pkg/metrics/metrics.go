@@ -0,0 +1,154 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package metrics exposes the Prometheus metrics of kubewatch.
+package metrics
+
+import (
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// ConfigReloads counts the reloads of the config file by result, "success" or "failure".
+var ConfigReloads = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "kubewatch_config_reloads_total",
+	Help: "Number of reloads of the config file, by result.",
+}, []string{"result"})
+
+// Notifications counts the notifications sent by handler and result, "success" or "failure".
+var Notifications = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "kubewatch_notifications_total",
+	Help: "Number of notifications sent, by handler and result.",
+}, []string{"handler", "result"})
+
+// QueueLength is the number of undelivered notifications queued by handler.
+var QueueLength = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "kubewatch_queue_length",
+	Help: "Number of undelivered notifications in the queue, by handler.",
+}, []string{"handler"})
+
+// QueueDropped counts the queued notifications dropped by handler and reason, "full" or "expired".
+var QueueDropped = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "kubewatch_queue_dropped_total",
+	Help: "Number of queued notifications dropped without being delivered, by handler and reason.",
+}, []string{"handler", "reason"})
+
+// DispatchQueueLength is the number of events waiting for the workers by handler.
+var DispatchQueueLength = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "kubewatch_dispatch_queue_length",
+	Help: "Number of events waiting to be sent by the workers, by handler.",
+}, []string{"handler"})
+
+// DispatchBlocked counts the time spent waiting for room in the full dispatch
+// queues by handler.
+var DispatchBlocked = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "kubewatch_dispatch_blocked_seconds_total",
+	Help: "Time spent waiting for room in the full dispatch queue, by handler.",
+}, []string{"handler"})
+
+// DispatchDropped counts the events dropped by handler since its dispatch
+// queue was full.
+var DispatchDropped = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "kubewatch_dispatch_dropped_total",
+	Help: "Number of events dropped since the dispatch queue was full, by handler.",
+}, []string{"handler"})
+
+// CircuitOpen is set to 1 while the circuit breaker of a handler stops its
+// deliveries.
+var CircuitOpen = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "kubewatch_handler_circuit_open",
+	Help: "Whether the circuit breaker of the handler is open, stopping its deliveries, by handler.",
+}, []string{"handler"})
+
+// DeliveryTimeouts counts the deliveries given up after the timeout of their
+// handler.
+var DeliveryTimeouts = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "kubewatch_handler_timeouts_total",
+	Help: "Number of deliveries given up after the timeout of the handler, by handler.",
+}, []string{"handler"})
+
+// Received counts the inbound webhooks by source and result, "success",
+// "failure" or "invalid".
+var Received = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "kubewatch_webhooks_received_total",
+	Help: "Number of inbound webhooks received, by source and result.",
+}, []string{"source", "result"})
+
+// PermissionDenied is set to 1 for the verbs kubewatch is denied on the
+// resources it watches by cluster, resource and verb, checked at startup.
+var PermissionDenied = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "kubewatch_permission_denied",
+	Help: "Whether kubewatch is denied the verb on a resource it watches, by cluster, resource and verb.",
+}, []string{"cluster", "resource", "verb"})
+
+// InformerObjects is the number of objects in the cache of the informers by
+// cluster and resource type.
+var InformerObjects = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "kubewatch_informer_cache_objects",
+	Help: "Number of objects in the cache of the informers, by cluster and resource type.",
+}, []string{"cluster", "resource"})
+
+// BuildInfo is set to 1 with the Go version, the OS and the architecture
+// kubewatch is built for, e.g. arm64.
+var BuildInfo = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "kubewatch_build_info",
+	Help: "Go version, OS and architecture of the kubewatch binary.",
+}, []string{"goversion", "goos", "goarch"})
+
+// MaxProcs is the number of CPUs usable by kubewatch, GOMAXPROCS.
+var MaxProcs = prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+	Name: "kubewatch_gomaxprocs",
+	Help: "Number of CPUs executing kubewatch simultaneously, GOMAXPROCS.",
+}, func() float64 {
+	return float64(runtime.GOMAXPROCS(0))
+})
+
+func init() {
+	prometheus.MustRegister(ConfigReloads)
+	prometheus.MustRegister(Notifications)
+	prometheus.MustRegister(QueueLength)
+	prometheus.MustRegister(QueueDropped)
+	prometheus.MustRegister(DispatchQueueLength)
+	prometheus.MustRegister(DispatchBlocked)
+	prometheus.MustRegister(DispatchDropped)
+	prometheus.MustRegister(CircuitOpen)
+	prometheus.MustRegister(DeliveryTimeouts)
+	prometheus.MustRegister(Received)
+	prometheus.MustRegister(PermissionDenied)
+	prometheus.MustRegister(InformerObjects)
+	prometheus.MustRegister(BuildInfo)
+	prometheus.MustRegister(MaxProcs)
+	BuildInfo.WithLabelValues(runtime.Version(), runtime.GOOS, runtime.GOARCH).Set(1)
+}
+
+// Register adds the /metrics endpoint to the mux.
+func Register(mux *http.ServeMux) {
+	mux.Handle("/metrics", promhttp.Handler())
+}
+
+// RegisterProfiles adds the /debug/pprof/ endpoints of the Go profiles, e.g.
+// heap or goroutine, to the mux.
+func RegisterProfiles(mux *http.ServeMux) {
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+}
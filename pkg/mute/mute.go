@@ -0,0 +1,178 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package mute keeps the runtime rules silencing the events of some objects,
+// e.g. set from interactive commands or declared by Mute objects.
+package mute
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// Rule mutes the events of the objects it matches until a time.
+type Rule struct {
+	// Cluster of the objects, empty for all, set for the Mute objects of
+	// the watched clusters.
+	Cluster string
+	// Namespace of the objects, empty for all.
+	Namespace string
+	// Kind of the objects, e.g. "deployment"; spaces are ignored, so
+	// "statefulset" matches "stateful set". Empty for all.
+	Kind string
+	// Name of the objects, "*" for all.
+	Name string
+	// Labels of the objects, nil for all.
+	Labels labels.Selector
+	Until  time.Time
+	// Source is the Mute object declaring the rule, as namespace/name, and
+	// Reason its reason, empty for the other rules.
+	Source string
+	Reason string
+}
+
+// ParseTarget parses the objects of a rule, as kind/name or
+// namespace/kind/name.
+func ParseTarget(target string) (Rule, error) {
+	parts := strings.Split(target, "/")
+	for _, p := range parts {
+		if p == "" {
+			return Rule{}, fmt.Errorf("invalid target %q", target)
+		}
+	}
+	switch len(parts) {
+	case 2:
+		return Rule{Kind: parts[0], Name: parts[1]}, nil
+	case 3:
+		return Rule{Namespace: parts[0], Kind: parts[1], Name: parts[2]}, nil
+	}
+	return Rule{}, fmt.Errorf("invalid target %q, expected kind/name or namespace/kind/name", target)
+}
+
+// Target returns the objects of the rule in the format of ParseTarget.
+func (r Rule) Target() string {
+	kind, name := r.Kind, r.Name
+	if kind == "" {
+		kind = "*"
+	}
+	if name == "" {
+		name = "*"
+	}
+	target := kind + "/" + name
+	if r.Namespace != "" {
+		target = r.Namespace + "/" + target
+	}
+	if r.Labels != nil && !r.Labels.Empty() {
+		target += " {" + r.Labels.String() + "}"
+	}
+	return target
+}
+
+// Match reports whether the rule mutes the event at the given time.
+func (r Rule) Match(e event.Event, now time.Time) bool {
+	return now.Before(r.Until) &&
+		(r.Cluster == "" || r.Cluster == e.Cluster) &&
+		(r.Namespace == "" || r.Namespace == e.Namespace) &&
+		(r.Kind == "" || normalizeKind(r.Kind) == normalizeKind(e.Kind)) &&
+		(r.Name == "*" || r.Name == "" || r.Name == e.Name) &&
+		(r.Labels == nil || r.Labels.Matches(labels.Set(e.Labels)))
+}
+
+func normalizeKind(kind string) string {
+	return strings.ToLower(strings.Replace(kind, " ", "", -1))
+}
+
+var (
+	mu    sync.RWMutex
+	rules = map[string]Rule{}
+	// declared are the rules of the Mute objects, by object.
+	declared = map[string]Rule{}
+)
+
+// Add adds a rule, replacing the one with the same target.
+func Add(r Rule) {
+	mu.Lock()
+	defer mu.Unlock()
+	rules[r.Target()] = r
+}
+
+// Remove removes the rule of a target, returning false if there was none.
+func Remove(target string) bool {
+	mu.Lock()
+	defer mu.Unlock()
+	_, ok := rules[target]
+	delete(rules, target)
+	return ok
+}
+
+// Declare sets the rule of a Mute object, replacing its previous one.
+func Declare(r Rule) {
+	mu.Lock()
+	defer mu.Unlock()
+	declared[r.Cluster+"/"+r.Source] = r
+}
+
+// Undeclare removes the rule of the Mute object of a cluster, as
+// namespace/name.
+func Undeclare(cluster, source string) {
+	mu.Lock()
+	defer mu.Unlock()
+	delete(declared, cluster+"/"+source)
+}
+
+// Muted reports whether an active rule mutes the event.
+func Muted(e event.Event) bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	now := time.Now()
+	for _, set := range []map[string]Rule{rules, declared} {
+		for _, r := range set {
+			if r.Match(e, now) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Rules returns the active rules sorted by target, dropping the expired ones;
+// the rules of the Mute objects are only dropped with their objects.
+func Rules() []Rule {
+	mu.Lock()
+	defer mu.Unlock()
+	now := time.Now()
+	var active []Rule
+	for target, r := range rules {
+		if !now.Before(r.Until) {
+			delete(rules, target)
+			continue
+		}
+		active = append(active, r)
+	}
+	for _, r := range declared {
+		if now.Before(r.Until) {
+			active = append(active, r)
+		}
+	}
+	sort.Slice(active, func(i, j int) bool { return active[i].Target() < active[j].Target() })
+	return active
+}
@@ -0,0 +1,150 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mute
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestMuted(t *testing.T) {
+	for _, target := range []string{"statefulset/db", "prod/deployment/*"} {
+		r, err := ParseTarget(target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.Until = time.Now().Add(time.Hour)
+		Add(r)
+	}
+	Add(Rule{Kind: "pod", Name: "web", Until: time.Now().Add(-time.Second)})
+
+	var Tests = []struct {
+		event event.Event
+		muted bool
+	}{
+		{event.Event{Kind: "stateful set", Namespace: "default", Name: "db"}, true},
+		{event.Event{Kind: "stateful set", Namespace: "default", Name: "cache"}, false},
+		{event.Event{Kind: "deployment", Namespace: "prod", Name: "web"}, true},
+		{event.Event{Kind: "deployment", Namespace: "dev", Name: "web"}, false},
+		{event.Event{Kind: "pod", Namespace: "default", Name: "web"}, false},
+	}
+	for _, tt := range Tests {
+		if got := Muted(tt.event); got != tt.muted {
+			t.Fatalf("Muted(%+v): got %v", tt.event, got)
+		}
+	}
+
+	if got := len(Rules()); got != 2 {
+		t.Fatalf("Rules(): expected 2 active rules, got %d", got)
+	}
+	if !Remove("statefulset/db") || Muted(Tests[0].event) {
+		t.Fatal("Remove(): the rule still mutes the event")
+	}
+
+	for _, target := range []string{"deployment", "a/b/c/d", "/web"} {
+		if _, err := ParseTarget(target); err == nil {
+			t.Fatalf("ParseTarget(%q): expected an error", target)
+		}
+	}
+}
+
+func mute(namespace string, spec map[string]interface{}, created time.Time) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "kubewatch.io/v1alpha1",
+		"kind":       "Mute",
+		"spec":       spec,
+	}}
+	obj.SetNamespace(namespace)
+	obj.SetName("maintenance")
+	obj.SetCreationTimestamp(meta_v1.NewTime(created))
+	return obj
+}
+
+func TestFromObject(t *testing.T) {
+	now := time.Now()
+	r, err := FromObject(mute("shop", map[string]interface{}{
+		"selector": map[string]interface{}{
+			"kind": "deployment",
+			"labelSelector": map[string]interface{}{
+				"matchLabels": map[string]interface{}{"app": "web"},
+			},
+		},
+		"duration": "2h",
+		"reason":   "maintenance of the database",
+	}, now.Add(-time.Hour)), "kubewatch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Source != "shop/maintenance" || r.Reason != "maintenance of the database" || r.Target() != "shop/deployment/* {app=web}" {
+		t.Fatalf("unexpected rule %+v", r)
+	}
+
+	var Tests = []struct {
+		event event.Event
+		muted bool
+	}{
+		{event.Event{Kind: "deployment", Namespace: "shop", Name: "web", Labels: map[string]string{"app": "web"}}, true},
+		{event.Event{Kind: "deployment", Namespace: "shop", Name: "db", Labels: map[string]string{"app": "db"}}, false},
+		{event.Event{Kind: "deployment", Namespace: "prod", Name: "web", Labels: map[string]string{"app": "web"}}, false},
+		{event.Event{Kind: "pod", Namespace: "shop", Name: "web", Labels: map[string]string{"app": "web"}}, false},
+	}
+	for _, tt := range Tests {
+		if got := r.Match(tt.event, now); got != tt.muted {
+			t.Fatalf("Match(%+v): got %v", tt.event, got)
+		}
+	}
+	if r.Match(Tests[0].event, now.Add(time.Hour)) {
+		t.Fatal("Match(): the rule mutes after its duration")
+	}
+
+	Declare(r)
+	if !Muted(Tests[0].event) {
+		t.Fatal("Declare(): the rule does not mute the event")
+	}
+	Undeclare("", r.Source)
+	if Muted(Tests[0].event) {
+		t.Fatal("Undeclare(): the rule still mutes the event")
+	}
+
+	// The Mutes of the cluster namespace select any namespace, until
+	// deleted by default.
+	r, err = FromObject(mute("kubewatch", map[string]interface{}{}, now), "kubewatch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.Match(Tests[2].event, now.AddDate(10, 0, 0)) {
+		t.Fatalf("unexpected rule %+v", r)
+	}
+
+	for _, spec := range []map[string]interface{}{
+		{"selector": map[string]interface{}{"namespace": "prod"}},
+		{"duration": "2h", "until": "2020-06-01T08:00:00Z"},
+		{"duration": "-1h"},
+		{"until": "tomorrow"},
+		{"selector": map[string]interface{}{"labelSelector": map[string]interface{}{
+			"matchExpressions": []interface{}{map[string]interface{}{"key": "app", "operator": "Like"}},
+		}}},
+	} {
+		if _, err := FromObject(mute("shop", spec, now), "kubewatch"); err == nil {
+			t.Fatalf("FromObject(%v): expected an error", spec)
+		}
+	}
+}
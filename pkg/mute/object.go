@@ -0,0 +1,120 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mute
+
+import (
+	"fmt"
+	"time"
+
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// forever is the end of the rules of the Mute objects without duration,
+// which mute until they are deleted.
+var forever = time.Date(9999, time.December, 31, 0, 0, 0, 0, time.UTC)
+
+// FromObject returns the rule declared by a kubewatch.io Mute object:
+//
+//	spec:
+//	  selector:
+//	    namespace: shop
+//	    kind: deployment
+//	    name: web
+//	    labelSelector:
+//	      matchLabels:
+//	        app: web
+//	  duration: 2h    # from the creation of the Mute, or
+//	  until: "2020-06-01T08:00:00Z"
+//	  reason: maintenance of the database
+//
+// The rule only mutes the objects of the namespace of the Mute, unless it is
+// clusterNamespace, whose Mutes may select any namespace, all of them when
+// empty, and the cluster-scoped objects.
+func FromObject(obj *unstructured.Unstructured, clusterNamespace string) (Rule, error) {
+	r := Rule{
+		Source: obj.GetNamespace() + "/" + obj.GetName(),
+		Until:  forever,
+	}
+	spec, _, err := unstructured.NestedMap(obj.Object, "spec")
+	if err != nil {
+		return Rule{}, err
+	}
+
+	selector, _, err := unstructured.NestedMap(spec, "selector")
+	if err != nil {
+		return Rule{}, err
+	}
+	for _, f := range []struct {
+		field string
+		value *string
+	}{
+		{"namespace", &r.Namespace},
+		{"kind", &r.Kind},
+		{"name", &r.Name},
+	} {
+		if *f.value, _, err = unstructured.NestedString(selector, f.field); err != nil {
+			return Rule{}, err
+		}
+	}
+	if obj.GetNamespace() != clusterNamespace {
+		if r.Namespace != "" && r.Namespace != obj.GetNamespace() {
+			return Rule{}, fmt.Errorf("can not mute the objects of namespace %s, only the Mutes of namespace %s can", r.Namespace, clusterNamespace)
+		}
+		r.Namespace = obj.GetNamespace()
+	}
+	if labelSelector, ok, err := unstructured.NestedMap(selector, "labelSelector"); err != nil {
+		return Rule{}, err
+	} else if ok {
+		var ls meta_v1.LabelSelector
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(labelSelector, &ls); err != nil {
+			return Rule{}, fmt.Errorf("invalid labelSelector: %v", err)
+		}
+		if r.Labels, err = meta_v1.LabelSelectorAsSelector(&ls); err != nil {
+			return Rule{}, fmt.Errorf("invalid labelSelector: %v", err)
+		}
+	}
+
+	duration, _, err := unstructured.NestedString(spec, "duration")
+	if err != nil {
+		return Rule{}, err
+	}
+	until, _, err := unstructured.NestedString(spec, "until")
+	if err != nil {
+		return Rule{}, err
+	}
+	switch {
+	case duration != "" && until != "":
+		return Rule{}, fmt.Errorf("duration and until are exclusive")
+	case duration != "":
+		d, err := time.ParseDuration(duration)
+		if err != nil || d <= 0 {
+			return Rule{}, fmt.Errorf("invalid duration %q", duration)
+		}
+		r.Until = obj.GetCreationTimestamp().Add(d)
+	case until != "":
+		if r.Until, err = time.Parse(time.RFC3339, until); err != nil {
+			return Rule{}, fmt.Errorf("invalid until %q", until)
+		}
+	}
+
+	if r.Reason, _, err = unstructured.NestedString(spec, "reason"); err != nil {
+		return Rule{}, err
+	}
+	return r, nil
+}
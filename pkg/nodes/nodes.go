@@ -0,0 +1,173 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package nodes detects the changes of the health of nodes: the
+// transitions of their conditions, their cordons and their taints.
+package nodes
+
+import (
+	"fmt"
+	"strings"
+
+	api_v1 "k8s.io/api/core/v1"
+)
+
+// Reasons of the changes besides the conditions, which are notified as
+// NodeReady and NodeNotReady for Ready, and by their type, e.g.
+// MemoryPressure, or their type followed by Resolved for the others.
+const (
+	NodeReady    = "NodeReady"
+	NodeNotReady = "NodeNotReady"
+	Resolved     = "Resolved"
+	Cordoned     = "Cordoned"
+	Uncordoned   = "Uncordoned"
+	TaintAdded   = "TaintAdded"
+	TaintRemoved = "TaintRemoved"
+)
+
+// Options select the changes detected.
+type Options struct {
+	// Conditions are the types of the conditions notified, all of them
+	// when empty.
+	Conditions []string
+	// IgnoreCordons and IgnoreTaints leave out the cordons and the taints.
+	IgnoreCordons bool
+	IgnoreTaints  bool
+}
+
+// Change is a change of the health of a node.
+type Change struct {
+	Reason string
+	// Healthy reports whether the node recovered, e.g. it is Ready again.
+	Healthy bool
+	// Description describes the change in a sentence.
+	Description string
+}
+
+// unschedulableTaint is the taint of the cordoned nodes, notified as a
+// cordon.
+const unschedulableTaint = "node.kubernetes.io/unschedulable"
+
+// Detect returns the changes of the health of the node between two of its
+// versions.
+func Detect(old, new *api_v1.Node, opts Options) []Change {
+	var changes []Change
+
+	oldConditions := map[api_v1.NodeConditionType]api_v1.NodeCondition{}
+	for _, c := range old.Status.Conditions {
+		oldConditions[c.Type] = c
+	}
+	for _, c := range new.Status.Conditions {
+		o, ok := oldConditions[c.Type]
+		if !ok || o.Status == c.Status || !selected(opts.Conditions, string(c.Type)) {
+			continue
+		}
+		changes = append(changes, conditionChange(o, c))
+	}
+
+	if !opts.IgnoreCordons && old.Spec.Unschedulable != new.Spec.Unschedulable {
+		if new.Spec.Unschedulable {
+			changes = append(changes, Change{Reason: Cordoned, Description: "Node was cordoned"})
+		} else {
+			changes = append(changes, Change{Reason: Uncordoned, Healthy: true, Description: "Node was uncordoned"})
+		}
+	}
+
+	if !opts.IgnoreTaints {
+		for _, t := range difference(new.Spec.Taints, old.Spec.Taints) {
+			changes = append(changes, Change{Reason: TaintAdded, Description: fmt.Sprintf("Taint `%s` was added", taint(t))})
+		}
+		for _, t := range difference(old.Spec.Taints, new.Spec.Taints) {
+			changes = append(changes, Change{Reason: TaintRemoved, Healthy: true, Description: fmt.Sprintf("Taint `%s` was removed", taint(t))})
+		}
+	}
+	return changes
+}
+
+// conditionChange returns the transition of a condition. Ready is healthy
+// when true, the others, like MemoryPressure, when false.
+func conditionChange(old, new api_v1.NodeCondition) Change {
+	var change Change
+	if new.Type == api_v1.NodeReady {
+		change.Healthy = new.Status == api_v1.ConditionTrue
+		change.Reason = NodeNotReady
+		if change.Healthy {
+			change.Reason = NodeReady
+		}
+	} else {
+		change.Healthy = new.Status == api_v1.ConditionFalse
+		change.Reason = string(new.Type)
+		if change.Healthy {
+			change.Reason += Resolved
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Condition %s changed from %s to %s", new.Type, old.Status, new.Status)
+	if new.Reason != "" {
+		fmt.Fprintf(&b, " (%s)", new.Reason)
+	}
+	if new.Message != "" {
+		fmt.Fprintf(&b, ": %s", new.Message)
+	}
+	change.Description = b.String()
+	return change
+}
+
+// selected reports whether the condition type is one of types, or types
+// is empty.
+func selected(types []string, t string) bool {
+	if len(types) == 0 {
+		return true
+	}
+	for _, s := range types {
+		if strings.EqualFold(s, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// difference returns the taints of a not in b, besides the taint of the
+// cordons.
+func difference(a, b []api_v1.Taint) []api_v1.Taint {
+	var taints []api_v1.Taint
+	for _, t := range a {
+		if t.Key == unschedulableTaint {
+			continue
+		}
+		found := false
+		for _, u := range b {
+			if t.Key == u.Key && t.Value == u.Value && t.Effect == u.Effect {
+				found = true
+				break
+			}
+		}
+		if !found {
+			taints = append(taints, t)
+		}
+	}
+	return taints
+}
+
+// taint formats the taint as kubectl, e.g. dedicated=gpu:NoSchedule.
+func taint(t api_v1.Taint) string {
+	s := t.Key
+	if t.Value != "" {
+		s += "=" + t.Value
+	}
+	return s + ":" + string(t.Effect)
+}
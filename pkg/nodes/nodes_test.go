@@ -0,0 +1,74 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodes
+
+import (
+	"reflect"
+	"testing"
+
+	api_v1 "k8s.io/api/core/v1"
+)
+
+func node(unschedulable bool, taints []api_v1.Taint, conditions ...api_v1.NodeCondition) *api_v1.Node {
+	return &api_v1.Node{
+		Spec:   api_v1.NodeSpec{Unschedulable: unschedulable, Taints: taints},
+		Status: api_v1.NodeStatus{Conditions: conditions},
+	}
+}
+
+func TestDetect(t *testing.T) {
+	ready := api_v1.NodeCondition{Type: api_v1.NodeReady, Status: api_v1.ConditionTrue}
+	notReady := api_v1.NodeCondition{Type: api_v1.NodeReady, Status: api_v1.ConditionUnknown, Reason: "NodeStatusUnknown", Message: "Kubelet stopped posting node status."}
+	noPressure := api_v1.NodeCondition{Type: api_v1.NodeMemoryPressure, Status: api_v1.ConditionFalse}
+	pressure := api_v1.NodeCondition{Type: api_v1.NodeMemoryPressure, Status: api_v1.ConditionTrue, Reason: "KubeletHasInsufficientMemory"}
+	gpu := []api_v1.Taint{{Key: "dedicated", Value: "gpu", Effect: api_v1.TaintEffectNoSchedule}}
+	cordon := []api_v1.Taint{{Key: unschedulableTaint, Effect: api_v1.TaintEffectNoSchedule}}
+
+	var Tests = []struct {
+		old, new *api_v1.Node
+		opts     Options
+		want     []Change
+	}{
+		{node(false, nil, ready, noPressure), node(false, nil, ready, noPressure), Options{}, nil},
+		{node(false, nil, ready), node(false, nil, notReady), Options{}, []Change{
+			{Reason: NodeNotReady, Description: "Condition Ready changed from True to Unknown (NodeStatusUnknown): Kubelet stopped posting node status."},
+		}},
+		{node(false, nil, notReady), node(false, nil, ready), Options{}, []Change{
+			{Reason: NodeReady, Healthy: true, Description: "Condition Ready changed from Unknown to True"},
+		}},
+		{node(false, nil, noPressure), node(false, nil, pressure), Options{}, []Change{
+			{Reason: "MemoryPressure", Description: "Condition MemoryPressure changed from False to True (KubeletHasInsufficientMemory)"},
+		}},
+		{node(false, nil, pressure), node(false, nil, noPressure), Options{}, []Change{
+			{Reason: "MemoryPressureResolved", Healthy: true, Description: "Condition MemoryPressure changed from True to False"},
+		}},
+		{node(false, nil, ready, noPressure), node(false, nil, notReady, pressure), Options{Conditions: []string{"memorypressure"}}, []Change{
+			{Reason: "MemoryPressure", Description: "Condition MemoryPressure changed from False to True (KubeletHasInsufficientMemory)"},
+		}},
+		{node(false, nil), node(true, cordon), Options{}, []Change{{Reason: Cordoned, Description: "Node was cordoned"}}},
+		{node(true, cordon), node(false, nil), Options{}, []Change{{Reason: Uncordoned, Healthy: true, Description: "Node was uncordoned"}}},
+		{node(false, nil), node(true, cordon), Options{IgnoreCordons: true}, nil},
+		{node(false, nil), node(false, gpu), Options{}, []Change{{Reason: TaintAdded, Description: "Taint `dedicated=gpu:NoSchedule` was added"}}},
+		{node(false, gpu), node(false, nil), Options{}, []Change{{Reason: TaintRemoved, Healthy: true, Description: "Taint `dedicated=gpu:NoSchedule` was removed"}}},
+		{node(false, gpu), node(false, nil), Options{IgnoreTaints: true}, nil},
+	}
+	for _, tt := range Tests {
+		if got := Detect(tt.old, tt.new, tt.opts); !reflect.DeepEqual(got, tt.want) {
+			t.Fatalf("Detect(): got %+v, want %+v", got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"encoding/json"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+// snapshot serializes the Kubernetes object of an event with its API version
+// and kind, which the objects of the informer caches usually lack, so that
+// restore returns an object of the same type. It returns nil when obj is not
+// a Kubernetes object.
+func snapshot(obj interface{}) (json.RawMessage, error) {
+	o, ok := obj.(runtime.Object)
+	if !ok || o == nil {
+		return nil, nil
+	}
+	if o.GetObjectKind().GroupVersionKind().Empty() {
+		if gvks, _, err := scheme.Scheme.ObjectKinds(o); err == nil && len(gvks) > 0 {
+			o = o.DeepCopyObject()
+			o.GetObjectKind().SetGroupVersionKind(gvks[0])
+		}
+	}
+	return json.Marshal(o)
+}
+
+// restore returns the object serialized by snapshot, typed when its kind is
+// a built-in one, unstructured otherwise, or nil when there is none.
+func restore(data json.RawMessage) (interface{}, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	var meta struct {
+		APIVersion string `json:"apiVersion"`
+		Kind       string `json:"kind"`
+	}
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, err
+	}
+	if obj, err := scheme.Scheme.New(schema.FromAPIVersionAndKind(meta.APIVersion, meta.Kind)); err == nil {
+		if err := json.Unmarshal(data, obj); err != nil {
+			return nil, err
+		}
+		return obj, nil
+	}
+	u := &unstructured.Unstructured{}
+	if err := u.UnmarshalJSON(data); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
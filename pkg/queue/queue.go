@@ -0,0 +1,186 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package queue stores the events a handler failed to deliver on disk,
+// until they are replayed.
+package queue
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+	"github.com/bitnami-labs/kubewatch/pkg/metrics"
+	"github.com/sirupsen/logrus"
+)
+
+// Item is an event waiting in the queue.
+type Item struct {
+	ID     string      `json:"-"`
+	Queued time.Time   `json:"queued"`
+	Event  event.Event `json:"event"`
+	// Object and OldObject are the objects of the event, which are not part
+	// of the serialized event, with their API version and kind.
+	Object    json.RawMessage `json:"object,omitempty"`
+	OldObject json.RawMessage `json:"oldObject,omitempty"`
+}
+
+// Queue is a FIFO of events persisted in a directory, one file per event,
+// so that it survives restarts. The directory is read on every operation,
+// which lets a new Queue take over the directory of a previous one.
+type Queue struct {
+	name    string
+	dir     string
+	maxSize int
+	ttl     time.Duration
+
+	mu  sync.Mutex
+	seq uint64
+}
+
+// Open returns the queue stored in dir, creating the directory if needed.
+// When maxSize events are queued the oldest one is dropped, and events
+// queued for longer than ttl are dropped; zero values disable the limits.
+// The name identifies the queue in metrics and logs.
+func Open(name, dir string, maxSize int, ttl time.Duration) (*Queue, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("can not create queue directory: %v", err)
+	}
+	q := &Queue{name: name, dir: dir, maxSize: maxSize, ttl: ttl}
+	metrics.QueueLength.WithLabelValues(name).Set(float64(q.Len()))
+	return q, nil
+}
+
+// Len returns the number of queued events.
+func (q *Queue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.files())
+}
+
+// Push adds an event at the end of the queue.
+func (q *Queue) Push(e event.Event) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	files := q.files()
+	for ; q.maxSize > 0 && len(files) >= q.maxSize; files = files[1:] {
+		q.drop(files[0], "full")
+	}
+
+	now := time.Now()
+	item := Item{Queued: now, Event: e}
+	var err error
+	if item.Object, err = snapshot(e.Object); err != nil {
+		return fmt.Errorf("can not store the object: %v", err)
+	}
+	if item.OldObject, err = snapshot(e.OldObject); err != nil {
+		return fmt.Errorf("can not store the old object: %v", err)
+	}
+	data, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+	// names sort in queueing order, the sequence orders events queued at once
+	q.seq++
+	name := fmt.Sprintf("%020d-%06d.json", now.UnixNano(), q.seq%1000000)
+	tmp := filepath.Join(q.dir, name+".tmp")
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, filepath.Join(q.dir, name)); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	metrics.QueueLength.WithLabelValues(q.name).Set(float64(len(files) + 1))
+	return nil
+}
+
+// Peek returns the oldest event of the queue, dropping the expired and
+// unreadable ones, or false when the queue is empty.
+func (q *Queue) Peek() (Item, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, name := range q.files() {
+		var item Item
+		data, err := ioutil.ReadFile(filepath.Join(q.dir, name))
+		if err == nil {
+			err = json.Unmarshal(data, &item)
+		}
+		if err == nil {
+			item.Event.Object, err = restore(item.Object)
+		}
+		if err == nil {
+			item.Event.OldObject, err = restore(item.OldObject)
+		}
+		if err != nil {
+			logrus.WithField("queue", q.name).Warnf("Dropping unreadable queued event %s: %v", name, err)
+			q.drop(name, "unreadable")
+			continue
+		}
+		if q.ttl > 0 && time.Since(item.Queued) > q.ttl {
+			q.drop(name, "expired")
+			continue
+		}
+		item.ID = name
+		return item, true
+	}
+	return Item{}, false
+}
+
+// Remove removes a delivered event from the queue.
+func (q *Queue) Remove(id string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if err := os.Remove(filepath.Join(q.dir, id)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	metrics.QueueLength.WithLabelValues(q.name).Set(float64(len(q.files())))
+	return nil
+}
+
+// drop removes an undelivered event from the queue.
+func (q *Queue) drop(name, reason string) {
+	if err := os.Remove(filepath.Join(q.dir, name)); err != nil && !os.IsNotExist(err) {
+		logrus.WithField("queue", q.name).Warnf("Can not remove queued event %s: %v", name, err)
+	}
+	metrics.QueueDropped.WithLabelValues(q.name, reason).Inc()
+	metrics.QueueLength.WithLabelValues(q.name).Set(float64(len(q.files())))
+}
+
+// files returns the names of the queued events, oldest first.
+func (q *Queue) files() []string {
+	entries, err := ioutil.ReadDir(q.dir)
+	if err != nil {
+		logrus.WithField("queue", q.name).Warnf("Can not read queue directory: %v", err)
+		return nil
+	}
+	var files []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".json") {
+			files = append(files, e.Name())
+		}
+	}
+	return files
+}
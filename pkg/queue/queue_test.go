@@ -0,0 +1,160 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+	api_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestQueue(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kubewatch-queue")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	q, err := Open("test", dir, 2, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a", "b", "c"} {
+		if err := q.Push(event.Event{Kind: "pod", Name: name}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if q.Len() != 2 {
+		t.Fatalf("Len(): expected 2, got %d", q.Len())
+	}
+
+	// a new queue takes over the events of the directory, oldest first
+	q, err = Open("test", dir, 2, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{"b", "c"} {
+		item, ok := q.Peek()
+		if !ok || item.Event.Name != expected {
+			t.Fatalf("Peek(): expected %s, got %+v", expected, item)
+		}
+		if err := q.Remove(item.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, ok := q.Peek(); ok {
+		t.Fatal("Peek(): expected an empty queue")
+	}
+}
+
+func TestQueueTTL(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kubewatch-queue")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	q, err := Open("test", dir, 0, 10*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Push(event.Event{Kind: "pod", Name: "foo"}); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if item, ok := q.Peek(); ok {
+		t.Fatalf("Peek(): expected the event to expire, got %+v", item)
+	}
+	if q.Len() != 0 {
+		t.Fatalf("Len(): expected 0, got %d", q.Len())
+	}
+}
+
+func TestQueueObjects(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kubewatch-queue")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	q, err := Open("test", dir, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pod := func(image string) *api_v1.Pod {
+		return &api_v1.Pod{
+			ObjectMeta: meta_v1.ObjectMeta{Name: "web", Namespace: "default", Labels: map[string]string{"app": "web"}},
+			Spec:       api_v1.PodSpec{Containers: []api_v1.Container{{Name: "web", Image: image}}},
+		}
+	}
+	widget := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "example.com/v1",
+		"kind":       "Widget",
+		"metadata":   map[string]interface{}{"name": "w"},
+		"spec":       map[string]interface{}{"size": "large"},
+	}}
+	current := pod("web:2")
+	events := []event.Event{
+		{Kind: "pod", Name: "web", Operation: "update", Object: current, OldObject: pod("web:1")},
+		{Kind: "widget", Name: "w", Object: widget},
+		{Kind: "digest"},
+	}
+	for _, e := range events {
+		if err := q.Push(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	next := func() event.Event {
+		item, ok := q.Peek()
+		if !ok {
+			t.Fatal("Peek(): expected an event")
+		}
+		if err := q.Remove(item.ID); err != nil {
+			t.Fatal(err)
+		}
+		return item.Event
+	}
+
+	e := next()
+	p, ok := e.Object.(*api_v1.Pod)
+	if !ok || p.Name != "web" || p.Labels["app"] != "web" || p.Spec.Containers[0].Image != "web:2" {
+		t.Fatalf("Peek(): got object %#v, want the pod", e.Object)
+	}
+	if old, ok := e.OldObject.(*api_v1.Pod); !ok || old.Spec.Containers[0].Image != "web:1" {
+		t.Fatalf("Peek(): got old object %#v, want the old pod", e.OldObject)
+	}
+	if current.APIVersion != "" {
+		t.Fatal("Push(): the object of the event was modified")
+	}
+
+	e = next()
+	u, ok := e.Object.(*unstructured.Unstructured)
+	if !ok || u.GetKind() != "Widget" || u.Object["spec"].(map[string]interface{})["size"] != "large" {
+		t.Fatalf("Peek(): got object %#v, want the widget", e.Object)
+	}
+
+	if e = next(); e.Object != nil || e.OldObject != nil {
+		t.Fatalf("Peek(): got objects %v and %v for an event without any", e.Object, e.OldObject)
+	}
+}
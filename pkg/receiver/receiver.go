@@ -0,0 +1,134 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package receiver serves the inbound webhooks of other tools, e.g.
+// Alertmanager, Harbor or Argo CD, turning their notifications into events.
+package receiver
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+	"github.com/bitnami-labs/kubewatch/pkg/metrics"
+	"github.com/sirupsen/logrus"
+)
+
+// maxBodySize limits the size of the received webhooks.
+const maxBodySize = 1 << 20
+
+var logger = logrus.WithField("pkg", "receiver")
+
+// Sender sends the received events through the handlers, returning an error
+// when they can't be handled, e.g. while kubewatch isn't the leader.
+type Sender func(event.Event) error
+
+// parser turns the body of a webhook into events.
+type parser func(body []byte) ([]event.Event, error)
+
+// parsers are the webhooks accepted, by source.
+var parsers = map[string]parser{
+	"alertmanager": parseAlertmanager,
+	"harbor":       parseHarbor,
+	"argocd":       parseArgoCD,
+}
+
+// Valid reports whether source is one of the accepted webhooks.
+func Valid(source string) bool {
+	_, ok := parsers[source]
+	return ok
+}
+
+// Register adds the /receive/<source> endpoints enabled by the config to the
+// mux, sending the received events with send.
+func Register(mux *http.ServeMux, conf config.Receiver, send Sender) error {
+	if conf.Token == "" {
+		return nil
+	}
+	sources := conf.Sources
+	if len(sources) == 0 {
+		for source := range parsers {
+			sources = append(sources, source)
+		}
+	}
+	for _, source := range sources {
+		parse, ok := parsers[source]
+		if !ok {
+			return fmt.Errorf("unknown receiver source %q", source)
+		}
+		mux.Handle("/receive/"+source, &receiveHandler{source: source, token: conf.Token, parse: parse, send: send})
+	}
+	return nil
+}
+
+// receiveHandler sends the events of the webhooks of a source.
+type receiveHandler struct {
+	source string
+	token  string
+	parse  parser
+	send   Sender
+}
+
+func (h *receiveHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte("Bearer "+h.token)) != 1 {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err != nil {
+		metrics.Received.WithLabelValues(h.source, "invalid").Inc()
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	events, err := h.parse(body)
+	if err != nil {
+		metrics.Received.WithLabelValues(h.source, "invalid").Inc()
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	for _, e := range events {
+		e.Component = h.source
+		if err := h.send(e); err != nil {
+			metrics.Received.WithLabelValues(h.source, "failure").Inc()
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+	}
+	metrics.Received.WithLabelValues(h.source, "success").Inc()
+	logger.WithField("source", h.source).Debugf("Received %d events", len(events))
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// status returns the status of the events of a severity of another tool.
+func status(severity string) string {
+	switch strings.ToLower(severity) {
+	case "critical", "error", "high", "page":
+		return "Danger"
+	case "warning", "warn", "medium":
+		return "Warning"
+	}
+	return "Normal"
+}
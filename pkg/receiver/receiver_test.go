@@ -0,0 +1,147 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package receiver
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+)
+
+func TestReceive(t *testing.T) {
+	var events []event.Event
+	ready := true
+	send := func(e event.Event) error {
+		if !ready {
+			return fmt.Errorf("not handling events")
+		}
+		events = append(events, e)
+		return nil
+	}
+	mux := http.NewServeMux()
+	if err := Register(mux, config.Receiver{Token: "secret", Sources: []string{"argocd"}}, send); err != nil {
+		t.Fatal(err)
+	}
+
+	body := `{"app": "shop", "namespace": "prod", "trigger": "on-sync-failed", "syncStatus": "OutOfSync", "message": "boom"}`
+	var Tests = []struct {
+		path   string
+		token  string
+		body   string
+		ready  bool
+		status int
+	}{
+		{"/receive/argocd", "wrong", body, true, http.StatusUnauthorized},
+		{"/receive/alertmanager", "secret", body, true, http.StatusNotFound},
+		{"/receive/argocd", "secret", `{"app": `, true, http.StatusBadRequest},
+		{"/receive/argocd", "secret", body, false, http.StatusServiceUnavailable},
+		{"/receive/argocd", "secret", body, true, http.StatusAccepted},
+	}
+	for _, tt := range Tests {
+		ready = tt.ready
+		req := httptest.NewRequest("POST", tt.path, strings.NewReader(tt.body))
+		req.Header.Set("Authorization", "Bearer "+tt.token)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		if w.Code != tt.status {
+			t.Fatalf("%s %s: got status %d, want %d", tt.path, tt.token, w.Code, tt.status)
+		}
+	}
+
+	want := event.Event{
+		Namespace: "prod",
+		Kind:      "application",
+		Component: "argocd",
+		Name:      "shop",
+		Reason:    "on-sync-failed",
+		Status:    "Danger",
+		Labels:    map[string]string{"syncStatus": "OutOfSync"},
+		Details:   "boom",
+	}
+	if len(events) != 1 || !reflect.DeepEqual(events[0], want) {
+		t.Fatalf("got events %+v, want %+v", events, want)
+	}
+
+	if err := Register(http.NewServeMux(), config.Receiver{Token: "secret", Sources: []string{"jenkins"}}, send); err == nil {
+		t.Fatal("unknown sources must be rejected")
+	}
+}
+
+func TestParseAlertmanager(t *testing.T) {
+	body := `{"version": "4", "status": "firing", "alerts": [
+		{"status": "firing", "labels": {"alertname": "HighLatency", "namespace": "shop", "severity": "critical"},
+		 "annotations": {"summary": "High latency", "description": "p99 over 1s"}},
+		{"status": "resolved", "labels": {"alertname": "DiskFull", "severity": "warning"}}
+	]}`
+	events, err := parseAlertmanager([]byte(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []event.Event{
+		{
+			Namespace: "shop",
+			Kind:      "alert",
+			Name:      "HighLatency",
+			Reason:    "Firing",
+			Status:    "Danger",
+			Labels:    map[string]string{"alertname": "HighLatency", "namespace": "shop", "severity": "critical"},
+			Details:   "High latency\np99 over 1s",
+		},
+		{
+			Kind:   "alert",
+			Name:   "DiskFull",
+			Reason: "Resolved",
+			Status: "Normal",
+			Labels: map[string]string{"alertname": "DiskFull", "severity": "warning"},
+		},
+	}
+	if !reflect.DeepEqual(events, want) {
+		t.Fatalf("got %+v, want %+v", events, want)
+	}
+
+	if _, err := parseAlertmanager([]byte(`{"version": "3"}`)); err == nil {
+		t.Fatal("unsupported versions must be rejected")
+	}
+}
+
+func TestParseHarbor(t *testing.T) {
+	body := `{"type": "SCANNING_FAILED", "operator": "auto", "event_data": {
+		"resources": [{"digest": "sha256:1234", "tag": "1.2.0", "resource_url": "harbor/library/api:1.2.0"}],
+		"repository": {"name": "api", "namespace": "library", "repo_full_name": "library/api"}
+	}}`
+	events, err := parseHarbor([]byte(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []event.Event{{
+		Namespace: "library",
+		Kind:      "image",
+		Name:      "library/api:1.2.0",
+		Reason:    "SCANNING_FAILED",
+		Status:    "Danger",
+		Details:   "harbor/library/api:1.2.0 by auto",
+	}}
+	if !reflect.DeepEqual(events, want) {
+		t.Fatalf("got %+v, want %+v", events, want)
+	}
+}
@@ -0,0 +1,209 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package receiver
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+)
+
+// Alertmanager is the body of the webhooks of Alertmanager
+// The Documentation is in https://prometheus.io/docs/alerting/latest/configuration/#webhook_config
+type Alertmanager struct {
+	Version string  `json:"version"`
+	Status  string  `json:"status"`
+	Alerts  []Alert `json:"alerts"`
+}
+
+// Alert is an alert of an Alertmanager webhook
+type Alert struct {
+	Status      string            `json:"status"`
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations"`
+}
+
+// parseAlertmanager returns an event per alert, named after the alert in
+// the namespace of its namespace label, with the severity of its severity
+// label while firing.
+func parseAlertmanager(body []byte) ([]event.Event, error) {
+	var m Alertmanager
+	if err := json.Unmarshal(body, &m); err != nil {
+		return nil, err
+	}
+	if m.Version != "4" {
+		return nil, fmt.Errorf("unsupported alertmanager webhook version %q", m.Version)
+	}
+
+	events := make([]event.Event, 0, len(m.Alerts))
+	for _, a := range m.Alerts {
+		e := event.Event{
+			Namespace: a.Labels["namespace"],
+			Kind:      "alert",
+			Name:      a.Labels["alertname"],
+			Reason:    strings.Title(a.Status),
+			Status:    "Normal",
+			Labels:    a.Labels,
+			Details:   a.Annotations["summary"],
+		}
+		if d := a.Annotations["description"]; d != "" {
+			if e.Details != "" {
+				e.Details += "\n"
+			}
+			e.Details += d
+		}
+		if a.Status == "firing" {
+			e.Status = status(a.Labels["severity"])
+		}
+		events = append(events, e)
+	}
+	return events, nil
+}
+
+// Harbor is the body of the webhooks of Harbor
+// The Documentation is in https://goharbor.io/docs/main/working-with-projects/project-configuration/configure-webhooks/
+type Harbor struct {
+	Type      string          `json:"type"`
+	Operator  string          `json:"operator"`
+	EventData HarborEventData `json:"event_data"`
+}
+
+// HarborEventData describes the artifacts of a Harbor webhook
+type HarborEventData struct {
+	Resources []struct {
+		Digest      string `json:"digest"`
+		Tag         string `json:"tag"`
+		ResourceURL string `json:"resource_url"`
+	} `json:"resources"`
+	Repository struct {
+		Name         string `json:"name"`
+		Namespace    string `json:"namespace"`
+		RepoFullName string `json:"repo_full_name"`
+	} `json:"repository"`
+}
+
+// harborStatuses are the statuses of the Harbor events which aren't Normal.
+var harborStatuses = map[string]string{
+	"SCANNING_FAILED":  "Danger",
+	"QUOTA_EXCEED":     "Danger",
+	"REPLICATION":      "Normal",
+	"QUOTA_WARNING":    "Warning",
+	"DELETE_ARTIFACT":  "Warning",
+	"SCANNING_STOPPED": "Warning",
+}
+
+// parseHarbor returns an event per artifact, of kind image, named after the
+// repository in the namespace of its project.
+func parseHarbor(body []byte) ([]event.Event, error) {
+	var h Harbor
+	if err := json.Unmarshal(body, &h); err != nil {
+		return nil, err
+	}
+	if h.Type == "" {
+		return nil, fmt.Errorf("missing harbor webhook type")
+	}
+
+	status := harborStatuses[h.Type]
+	if status == "" {
+		status = "Normal"
+	}
+	repository := h.EventData.Repository
+	e := event.Event{
+		Namespace: repository.Namespace,
+		Kind:      "image",
+		Name:      repository.RepoFullName,
+		Reason:    h.Type,
+		Status:    status,
+	}
+	if len(h.EventData.Resources) == 0 {
+		return []event.Event{e}, nil
+	}
+
+	events := make([]event.Event, 0, len(h.EventData.Resources))
+	for _, r := range h.EventData.Resources {
+		e := e
+		if r.Tag != "" {
+			e.Name += ":" + r.Tag
+		}
+		e.Details = r.ResourceURL
+		if e.Details == "" {
+			e.Details = r.Digest
+		}
+		if h.Operator != "" {
+			e.Details += " by " + h.Operator
+		}
+		events = append(events, e)
+	}
+	return events, nil
+}
+
+// ArgoCD is the body of the webhooks of Argo CD notifications, set by the
+// template of the webhook, e.g.
+//
+//	{"app": "{{.app.metadata.name}}", "namespace": "{{.app.spec.destination.namespace}}",
+//	 "trigger": "on-sync-failed", "syncStatus": "{{.app.status.sync.status}}",
+//	 "healthStatus": "{{.app.status.health.status}}", "revision": "{{.app.status.sync.revision}}",
+//	 "message": "{{.app.status.operationState.message}}"}
+type ArgoCD struct {
+	App          string `json:"app"`
+	Namespace    string `json:"namespace"`
+	Trigger      string `json:"trigger"`
+	SyncStatus   string `json:"syncStatus"`
+	HealthStatus string `json:"healthStatus"`
+	Revision     string `json:"revision"`
+	Message      string `json:"message"`
+}
+
+// parseArgoCD returns the event of an application, which is critical when
+// it is degraded, missing or when its sync failed, and a warning while it is
+// progressing or out of sync.
+func parseArgoCD(body []byte) ([]event.Event, error) {
+	var a ArgoCD
+	if err := json.Unmarshal(body, &a); err != nil {
+		return nil, err
+	}
+	if a.App == "" {
+		return nil, fmt.Errorf("missing argocd application")
+	}
+
+	e := event.Event{
+		Namespace: a.Namespace,
+		Kind:      "application",
+		Name:      a.App,
+		Reason:    a.Trigger,
+		Status:    "Normal",
+		Labels:    map[string]string{},
+		Details:   a.Message,
+	}
+	if e.Reason == "" {
+		e.Reason = a.SyncStatus
+	}
+	for k, v := range map[string]string{"syncStatus": a.SyncStatus, "healthStatus": a.HealthStatus, "revision": a.Revision} {
+		if v != "" {
+			e.Labels[k] = v
+		}
+	}
+	switch {
+	case a.HealthStatus == "Degraded" || a.HealthStatus == "Missing" || strings.Contains(a.Trigger, "failed"):
+		e.Status = "Danger"
+	case a.HealthStatus == "Progressing" || a.SyncStatus == "OutOfSync":
+		e.Status = "Warning"
+	}
+	return []event.Event{e}, nil
+}
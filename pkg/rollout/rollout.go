@@ -0,0 +1,193 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package rollout evaluates the progress of the rollouts of deployments and
+// stateful sets.
+package rollout
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	apps_v1 "k8s.io/api/apps/v1"
+	api_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// DefaultDeadline is the time the rollouts of the stateful sets, and of
+// the deployments without progress deadline, have to complete.
+const DefaultDeadline = 10 * time.Minute
+
+// Progress is the progress of the rollout of the current generation of a
+// workload.
+type Progress struct {
+	Complete bool
+	// Failed is set once the deployment controller reported that the
+	// progress deadline of a deployment was exceeded.
+	Failed bool
+	// Desired, Updated and Available count the pods of the workload: the
+	// desired ones, the ones of the current generation, and the available
+	// ones (ready for stateful sets).
+	Desired, Updated, Available int32
+}
+
+// Unavailable returns the number of desired pods not available.
+func (p Progress) Unavailable() int32 {
+	if p.Available >= p.Desired {
+		return 0
+	}
+	return p.Desired - p.Available
+}
+
+// Trackable reports whether the rollouts of the object can be tracked: the
+// ones of deployments, and of stateful sets updated with the RollingUpdate
+// strategy.
+func Trackable(obj interface{}) bool {
+	switch o := obj.(type) {
+	case *apps_v1.Deployment:
+		return true
+	case *apps_v1.StatefulSet:
+		return o.Spec.UpdateStrategy.Type != apps_v1.OnDeleteStatefulSetStrategyType
+	}
+	return false
+}
+
+// Of returns the progress of the rollout of the workload, as reported by
+// its status, like kubectl rollout status.
+func Of(obj interface{}) Progress {
+	switch o := obj.(type) {
+	case *apps_v1.Deployment:
+		return deployment(o)
+	case *apps_v1.StatefulSet:
+		return statefulSet(o)
+	}
+	return Progress{}
+}
+
+func deployment(d *apps_v1.Deployment) Progress {
+	s := d.Status
+	p := Progress{Desired: replicas(d.Spec.Replicas), Updated: s.UpdatedReplicas, Available: s.AvailableReplicas}
+	for _, c := range s.Conditions {
+		if c.Type == apps_v1.DeploymentProgressing && c.Reason == "ProgressDeadlineExceeded" {
+			p.Failed = true
+		}
+	}
+	p.Complete = s.ObservedGeneration >= d.Generation &&
+		s.UpdatedReplicas == p.Desired &&
+		s.Replicas == s.UpdatedReplicas &&
+		s.AvailableReplicas == s.UpdatedReplicas
+	return p
+}
+
+func statefulSet(sts *apps_v1.StatefulSet) Progress {
+	s := sts.Status
+	p := Progress{Desired: replicas(sts.Spec.Replicas), Updated: s.UpdatedReplicas, Available: s.ReadyReplicas}
+	var partition int32
+	if ru := sts.Spec.UpdateStrategy.RollingUpdate; ru != nil && ru.Partition != nil {
+		partition = *ru.Partition
+	}
+	p.Complete = s.ObservedGeneration >= sts.Generation && s.ReadyReplicas >= p.Desired
+	if partition > 0 {
+		p.Complete = p.Complete && s.UpdatedReplicas >= p.Desired-partition
+	} else {
+		p.Complete = p.Complete && s.UpdateRevision == s.CurrentRevision
+	}
+	return p
+}
+
+func replicas(r *int32) int32 {
+	if r == nil {
+		return 1
+	}
+	return *r
+}
+
+// Deadline returns the time the rollout of the workload has to complete,
+// the progress deadline of deployments.
+func Deadline(obj interface{}) time.Duration {
+	if d, ok := obj.(*apps_v1.Deployment); ok && d.Spec.ProgressDeadlineSeconds != nil {
+		return time.Duration(*d.Spec.ProgressDeadlineSeconds) * time.Second
+	}
+	return DefaultDeadline
+}
+
+// Selector returns the selector of the pods of the workload.
+func Selector(obj interface{}) (labels.Selector, error) {
+	switch o := obj.(type) {
+	case *apps_v1.Deployment:
+		return meta_v1.LabelSelectorAsSelector(o.Spec.Selector)
+	case *apps_v1.StatefulSet:
+		return meta_v1.LabelSelectorAsSelector(o.Spec.Selector)
+	}
+	return labels.Nothing(), nil
+}
+
+// PodsReason returns the most common reason the containers of the pods are
+// waiting or terminated with, e.g. ImagePullBackOff, empty when none is.
+func PodsReason(pods []api_v1.Pod) string {
+	counts := map[string]int{}
+	for _, pod := range pods {
+		statuses := append(append([]api_v1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+		for _, s := range statuses {
+			switch {
+			case s.State.Waiting != nil && s.State.Waiting.Reason != "" && s.State.Waiting.Reason != "ContainerCreating" && s.State.Waiting.Reason != "PodInitializing":
+				counts[s.State.Waiting.Reason]++
+			case s.State.Terminated != nil && s.State.Terminated.Reason != "" && s.State.Terminated.Reason != "Completed":
+				counts[s.State.Terminated.Reason]++
+			}
+		}
+		for _, c := range pod.Status.Conditions {
+			if c.Type == api_v1.PodScheduled && c.Status == api_v1.ConditionFalse && c.Reason != "" {
+				counts[c.Reason]++
+			}
+		}
+	}
+
+	reasons := make([]string, 0, len(counts))
+	for r := range counts {
+		reasons = append(reasons, r)
+	}
+	sort.Slice(reasons, func(i, j int) bool {
+		if counts[reasons[i]] != counts[reasons[j]] {
+			return counts[reasons[i]] > counts[reasons[j]]
+		}
+		return reasons[i] < reasons[j]
+	})
+	if len(reasons) == 0 {
+		return ""
+	}
+	return reasons[0]
+}
+
+// Succeeded describes a complete rollout.
+func Succeeded(d time.Duration) string {
+	return fmt.Sprintf("Rolled out successfully in %s", d.Round(time.Second))
+}
+
+// Stuck describes a rollout not complete, with the reason its pods are not
+// available, when known.
+func Stuck(d time.Duration, p Progress, reason string) string {
+	msg := fmt.Sprintf("Rollout stuck after %s: %d/%d pods unavailable", d.Round(time.Second), p.Unavailable(), p.Desired)
+	if p.Updated < p.Desired {
+		msg += fmt.Sprintf(", %d/%d updated", p.Updated, p.Desired)
+	}
+	if reason != "" {
+		msg += ", reason " + reason
+	}
+	return msg
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rollout
+
+import (
+	"testing"
+	"time"
+
+	apps_v1 "k8s.io/api/apps/v1"
+	api_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func int32Ptr(i int32) *int32 {
+	return &i
+}
+
+func TestDeployment(t *testing.T) {
+	d := &apps_v1.Deployment{
+		ObjectMeta: meta_v1.ObjectMeta{Generation: 2},
+		Spec:       apps_v1.DeploymentSpec{Replicas: int32Ptr(5)},
+		Status:     apps_v1.DeploymentStatus{ObservedGeneration: 2, Replicas: 6, UpdatedReplicas: 3, AvailableReplicas: 4},
+	}
+	if !Trackable(d) {
+		t.Fatal("Trackable() = false")
+	}
+	if p := Of(d); p.Complete || p.Failed || p.Unavailable() != 1 {
+		t.Fatalf("Of() = %+v", p)
+	}
+
+	d.Status = apps_v1.DeploymentStatus{ObservedGeneration: 2, Replicas: 5, UpdatedReplicas: 5, AvailableReplicas: 5}
+	if p := Of(d); !p.Complete {
+		t.Fatalf("Of() = %+v, want complete", p)
+	}
+	// the status of the previous generation
+	d.Status.ObservedGeneration = 1
+	if p := Of(d); p.Complete {
+		t.Fatalf("Of() = %+v, want not complete", p)
+	}
+
+	d.Status = apps_v1.DeploymentStatus{ObservedGeneration: 2, Replicas: 5, UpdatedReplicas: 2, AvailableReplicas: 3,
+		Conditions: []apps_v1.DeploymentCondition{{Type: apps_v1.DeploymentProgressing, Reason: "ProgressDeadlineExceeded"}}}
+	if p := Of(d); p.Complete || !p.Failed {
+		t.Fatalf("Of() = %+v, want failed", p)
+	}
+
+	if Deadline(d) != DefaultDeadline {
+		t.Fatalf("Deadline() = %s", Deadline(d))
+	}
+	d.Spec.ProgressDeadlineSeconds = int32Ptr(120)
+	if Deadline(d) != 2*time.Minute {
+		t.Fatalf("Deadline() = %s", Deadline(d))
+	}
+}
+
+func TestStatefulSet(t *testing.T) {
+	sts := &apps_v1.StatefulSet{
+		ObjectMeta: meta_v1.ObjectMeta{Generation: 3},
+		Spec:       apps_v1.StatefulSetSpec{Replicas: int32Ptr(3)},
+		Status:     apps_v1.StatefulSetStatus{ObservedGeneration: 3, ReadyReplicas: 3, UpdatedReplicas: 1, CurrentRevision: "db-1", UpdateRevision: "db-2"},
+	}
+	if p := Of(sts); p.Complete {
+		t.Fatalf("Of() = %+v, want not complete", p)
+	}
+	sts.Status.CurrentRevision = "db-2"
+	if p := Of(sts); !p.Complete {
+		t.Fatalf("Of() = %+v, want complete", p)
+	}
+
+	// with a partition, the pods above it are updated
+	sts.Spec.UpdateStrategy.RollingUpdate = &apps_v1.RollingUpdateStatefulSetStrategy{Partition: int32Ptr(2)}
+	sts.Status.CurrentRevision = "db-1"
+	if p := Of(sts); !p.Complete {
+		t.Fatalf("Of() = %+v, want complete", p)
+	}
+
+	sts.Spec.UpdateStrategy.Type = apps_v1.OnDeleteStatefulSetStrategyType
+	if Trackable(sts) {
+		t.Fatal("Trackable() = true with the OnDelete strategy")
+	}
+}
+
+func TestPodsReason(t *testing.T) {
+	waiting := func(reason string) api_v1.Pod {
+		return api_v1.Pod{Status: api_v1.PodStatus{ContainerStatuses: []api_v1.ContainerStatus{
+			{State: api_v1.ContainerState{Waiting: &api_v1.ContainerStateWaiting{Reason: reason}}},
+		}}}
+	}
+	pods := []api_v1.Pod{waiting("ImagePullBackOff"), waiting("ContainerCreating"), waiting("ImagePullBackOff"), waiting("CrashLoopBackOff")}
+	if got := PodsReason(pods); got != "ImagePullBackOff" {
+		t.Fatalf("PodsReason() = %q", got)
+	}
+	if got := PodsReason(pods[1:2]); got != "" {
+		t.Fatalf("PodsReason() = %q", got)
+	}
+}
+
+func TestDescriptions(t *testing.T) {
+	if got, want := Succeeded(45*time.Second+300*time.Millisecond), "Rolled out successfully in 45s"; got != want {
+		t.Fatalf("Succeeded() = %q, want %q", got, want)
+	}
+	p := Progress{Desired: 5, Updated: 2, Available: 3}
+	if got, want := Stuck(10*time.Minute, p, "ImagePullBackOff"), "Rollout stuck after 10m0s: 2/5 pods unavailable, 2/5 updated, reason ImagePullBackOff"; got != want {
+		t.Fatalf("Stuck() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,195 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package schedule decides whether events are suppressed by quiet hours.
+package schedule
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+)
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// window is a time range, in minutes of the day, on some week days.
+type window struct {
+	days       [7]bool
+	start, end int
+}
+
+// QuietHours are parsed quiet hours. A nil QuietHours suppresses nothing.
+type QuietHours struct {
+	windows  []window
+	location *time.Location
+	allow    []string
+}
+
+// New parses quiet hours, returning nil when no windows are configured.
+func New(c config.QuietHours) (*QuietHours, error) {
+	if len(c.Windows) == 0 {
+		return nil, nil
+	}
+	q := &QuietHours{location: time.UTC, allow: c.Allow}
+	if len(q.allow) == 0 {
+		q.allow = []string{"critical"}
+	}
+	if c.Timezone != "" {
+		location, err := time.LoadLocation(c.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid quiet hours timezone %q: %v", c.Timezone, err)
+		}
+		q.location = location
+	}
+	for _, w := range c.Windows {
+		parsed, err := parseWindow(w)
+		if err != nil {
+			return nil, fmt.Errorf("invalid quiet hours window %q: %v", w, err)
+		}
+		q.windows = append(q.windows, parsed)
+	}
+	return q, nil
+}
+
+// Active reports whether t is in one of the windows.
+func (q *QuietHours) Active(t time.Time) bool {
+	if q == nil {
+		return false
+	}
+	t = t.In(q.location)
+	day := t.Weekday()
+	previous := (day + 6) % 7
+	minute := t.Hour()*60 + t.Minute()
+	for _, w := range q.windows {
+		switch {
+		case w.start <= w.end:
+			if w.days[day] && minute >= w.start && minute < w.end {
+				return true
+			}
+		case w.days[day] && minute >= w.start, w.days[previous] && minute < w.end:
+			// the window goes on until the next day
+			return true
+		}
+	}
+	return false
+}
+
+// Suppresses reports whether the event is suppressed at t: during the
+// windows, unless its severity is allowed.
+func (q *QuietHours) Suppresses(e event.Event, t time.Time) bool {
+	if !q.Active(t) {
+		return false
+	}
+	for _, s := range q.allow {
+		if strings.EqualFold(s, e.Severity) {
+			return false
+		}
+	}
+	return true
+}
+
+// parseWindow parses "[DAYS] [HH:MM-HH:MM]", e.g. "Mon-Fri 19:00-08:00".
+func parseWindow(s string) (window, error) {
+	w := window{end: 24 * 60}
+	fields := strings.Fields(s)
+	if len(fields) == 0 || len(fields) > 2 {
+		return w, fmt.Errorf("expected days and/or a time range")
+	}
+	hasDays := false
+	for _, f := range fields {
+		if strings.Contains(f, ":") {
+			var err error
+			if w.start, w.end, err = parseRange(f); err != nil {
+				return w, err
+			}
+			continue
+		}
+		if hasDays {
+			return w, fmt.Errorf("days given twice")
+		}
+		if err := parseDays(f, &w.days); err != nil {
+			return w, err
+		}
+		hasDays = true
+	}
+	if !hasDays {
+		for i := range w.days {
+			w.days[i] = true
+		}
+	}
+	return w, nil
+}
+
+// parseDays parses a comma separated list of days or day ranges, e.g. "Mon-Fri,Sun".
+func parseDays(s string, days *[7]bool) error {
+	for _, part := range strings.Split(s, ",") {
+		bounds := strings.SplitN(part, "-", 2)
+		first, ok := weekdays[strings.ToLower(bounds[0])]
+		if !ok {
+			return fmt.Errorf("unknown day %q", bounds[0])
+		}
+		last := first
+		if len(bounds) == 2 {
+			if last, ok = weekdays[strings.ToLower(bounds[1])]; !ok {
+				return fmt.Errorf("unknown day %q", bounds[1])
+			}
+		}
+		for d := first; ; d = (d + 1) % 7 {
+			days[d] = true
+			if d == last {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// parseRange parses "HH:MM-HH:MM" into minutes of the day.
+func parseRange(s string) (int, int, error) {
+	bounds := strings.SplitN(s, "-", 2)
+	if len(bounds) != 2 {
+		return 0, 0, fmt.Errorf("expected a time range as HH:MM-HH:MM")
+	}
+	start, err := parseTime(bounds[0])
+	if err != nil {
+		return 0, 0, err
+	}
+	end, err := parseTime(bounds[1])
+	if err != nil {
+		return 0, 0, err
+	}
+	if start == end {
+		return 0, 0, fmt.Errorf("empty time range")
+	}
+	return start, end, nil
+}
+
+func parseTime(s string) (int, error) {
+	if s == "24:00" {
+		return 24 * 60, nil
+	}
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+)
+
+func TestActive(t *testing.T) {
+	q, err := New(config.QuietHours{Windows: []string{"Mon-Fri 19:00-08:00", "Sat,Sun"}, Timezone: "Europe/Paris"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	paris, _ := time.LoadLocation("Europe/Paris")
+
+	var Tests = []struct {
+		time time.Time
+		want bool
+	}{
+		// Wednesday
+		{time.Date(2020, 6, 3, 12, 0, 0, 0, paris), false},
+		{time.Date(2020, 6, 3, 19, 0, 0, 0, paris), true},
+		{time.Date(2020, 6, 3, 7, 59, 0, 0, paris), true},
+		{time.Date(2020, 6, 3, 8, 0, 0, 0, paris), false},
+		// Monday morning, the window of Sunday is a whole day
+		{time.Date(2020, 6, 1, 7, 0, 0, 0, paris), false},
+		// Saturday morning, after Friday evening
+		{time.Date(2020, 6, 6, 7, 0, 0, 0, paris), true},
+		{time.Date(2020, 6, 7, 15, 0, 0, 0, paris), true},
+		// 12:00 UTC is 14:00 in Paris
+		{time.Date(2020, 6, 3, 17, 30, 0, 0, time.UTC), true},
+		{time.Date(2020, 6, 3, 16, 30, 0, 0, time.UTC), false},
+	}
+
+	for _, tt := range Tests {
+		if got := q.Active(tt.time); got != tt.want {
+			t.Errorf("Active(%s) = %v, want %v", tt.time, got, tt.want)
+		}
+	}
+}
+
+func TestSuppresses(t *testing.T) {
+	q, err := New(config.QuietHours{Windows: []string{"00:00-24:00"}, Allow: []string{"critical", "warning"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	if q.Suppresses(event.Event{Severity: "warning"}, now) {
+		t.Error("allowed severity suppressed")
+	}
+	if !q.Suppresses(event.Event{Severity: "info"}, now) {
+		t.Error("info event not suppressed")
+	}
+
+	var none *QuietHours
+	if none.Suppresses(event.Event{Severity: "info"}, now) {
+		t.Error("nil quiet hours suppress events")
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	for _, w := range []string{"Someday", "Mon 9:00", "Mon 25:00-26:00", "Mon Tue", "10:00-10:00", "Mon Tue 10:00-11:00"} {
+		if _, err := New(config.QuietHours{Windows: []string{w}}); err == nil {
+			t.Errorf("New(%q) succeeded", w)
+		}
+	}
+	if _, err := New(config.QuietHours{Windows: []string{"Mon"}, Timezone: "Mars/Olympus"}); err == nil {
+		t.Error("New() succeeded with an unknown timezone")
+	}
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package severity classifies events by importance.
+package severity
+
+import (
+	"strings"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+	"github.com/bitnami-labs/kubewatch/pkg/filter"
+)
+
+// Severities of the events, from the most to the least important.
+const (
+	Critical = "critical"
+	Warning  = "warning"
+	Info     = "info"
+)
+
+// Valid reports whether s is one of the severities, ignoring case.
+func Valid(s string) bool {
+	switch strings.ToLower(s) {
+	case Critical, Warning, Info:
+		return true
+	}
+	return false
+}
+
+// defaultSeverities maps event statuses to severities,
+// used when no rule matches an event.
+var defaultSeverities = map[string]string{
+	"Danger":  Critical,
+	"Warning": Warning,
+	"Normal":  Info,
+}
+
+// Classify returns the severity of the first rule matching the event,
+// or the default severity of the event status.
+func Classify(rules []config.SeverityRule, e event.Event) string {
+	for _, r := range rules {
+		if filter.Match(r.Filter, e) {
+			return strings.ToLower(r.Severity)
+		}
+	}
+	if s, ok := defaultSeverities[e.Status]; ok {
+		return s
+	}
+	return Info
+}
@@ -0,0 +1,49 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package severity
+
+import (
+	"testing"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+)
+
+func TestClassify(t *testing.T) {
+	rules := []config.SeverityRule{
+		{Filter: config.Filter{Kinds: []string{"persistent volume"}, Types: []string{"delete"}}, Severity: "Critical"},
+		{Filter: config.Filter{Kinds: []string{"configmap"}}, Severity: Info},
+	}
+
+	var Tests = []struct {
+		e    event.Event
+		want string
+	}{
+		{event.Event{Kind: "persistent volume", Reason: "Deleted", Status: "Danger"}, Critical},
+		{event.Event{Kind: "configmap", Reason: "Deleted", Status: "Danger"}, Info},
+		{event.Event{Kind: "pod", Reason: "Deleted", Status: "Danger"}, Critical},
+		{event.Event{Kind: "pod", Reason: "Updated", Status: "Warning"}, Warning},
+		{event.Event{Kind: "pod", Reason: "Created", Status: "Normal"}, Info},
+		{event.Event{Kind: "pod"}, Info},
+	}
+
+	for _, tt := range Tests {
+		if got := Classify(rules, tt.e); got != tt.want {
+			t.Fatalf("Classify(%+v): got %q, want %q", tt.e, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package slackbot answers the /kubewatch Slack commands, received through
+// socket mode, to mute objects and report the health of kubewatch.
+package slackbot
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/socketmode"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/health"
+	"github.com/bitnami-labs/kubewatch/pkg/mute"
+	"github.com/bitnami-labs/kubewatch/pkg/utils"
+	"github.com/sirupsen/logrus"
+)
+
+var logger = logrus.WithField("pkg", "slackbot")
+
+const usage = "Usage:\n" +
+	"`/kubewatch mute <kind/name|namespace/kind/name> <duration>` mutes the events of an object, `*` as name for all\n" +
+	"`/kubewatch unmute <kind/name|namespace/kind/name>`\n" +
+	"`/kubewatch status` reports the health of kubewatch and the muted objects"
+
+// Run connects to Slack with the bot and app-level tokens and answers the
+// commands of the allowed users and channels until ctx is done.
+func Run(ctx context.Context, botToken, appToken string, conf config.Slackbot) {
+	httpClient, err := utils.HTTPClient("slack", conf.TLS)
+	if err != nil {
+		logger.Errorf("Can not create the HTTP client: %v", err)
+		return
+	}
+	api := slack.New(botToken, slack.OptionAppLevelToken(appToken), slack.OptionHTTPClient(httpClient))
+	client := socketmode.New(api)
+
+	go func() {
+		for evt := range client.Events {
+			switch evt.Type {
+			case socketmode.EventTypeConnected:
+				logger.Info("Connected to Slack in socket mode")
+			case socketmode.EventTypeConnectionError:
+				logger.Warnf("Can not connect to Slack in socket mode: %v", evt.Data)
+			case socketmode.EventTypeSlashCommand:
+				cmd, ok := evt.Data.(slack.SlashCommand)
+				if !ok {
+					continue
+				}
+				log := logger.WithFields(logrus.Fields{"user": cmd.UserName, "channel": cmd.ChannelName})
+				reply := refusal(conf, cmd)
+				if reply != "" {
+					log.Warnf("Refused command %s %s", cmd.Command, cmd.Text)
+				} else {
+					log.Infof("Command %s %s", cmd.Command, cmd.Text)
+					reply = Command(cmd.Text, time.Now())
+				}
+				client.Ack(*evt.Request, map[string]interface{}{"text": reply})
+			}
+		}
+	}()
+
+	if err := client.RunContext(ctx); err != nil && ctx.Err() == nil {
+		logger.Errorf("Slack socket mode stopped: %v", err)
+	}
+}
+
+// Command runs a /kubewatch command and returns its reply.
+func Command(text string, now time.Time) string {
+	args := strings.Fields(text)
+	if len(args) == 0 {
+		return usage
+	}
+
+	switch args[0] {
+	case "mute":
+		if len(args) != 3 {
+			return usage
+		}
+		r, err := mute.ParseTarget(args[1])
+		if err != nil {
+			return err.Error()
+		}
+		d, err := time.ParseDuration(args[2])
+		if err != nil || d <= 0 {
+			return fmt.Sprintf("invalid duration %q, e.g. 30m or 2h", args[2])
+		}
+		r.Until = now.Add(d)
+		mute.Add(r)
+		return fmt.Sprintf("Muted `%s` until %s", r.Target(), r.Until.UTC().Format(time.RFC3339))
+	case "unmute":
+		if len(args) != 2 {
+			return usage
+		}
+		if !mute.Remove(args[1]) {
+			return fmt.Sprintf("`%s` is not muted", args[1])
+		}
+		return fmt.Sprintf("Unmuted `%s`", args[1])
+	case "status":
+		return status()
+	}
+	return usage
+}
+
+// refusal returns the reply refusing the command when its user or channel
+// is not allowed to run it, or "" when it is. The users are only matched by
+// ID, their names being mutable and not unique. Without allowed users, only
+// the status command is allowed.
+func refusal(conf config.Slackbot, cmd slack.SlashCommand) string {
+	if len(conf.AllowedChannels) > 0 && !allowed(conf.AllowedChannels, cmd.ChannelID, cmd.ChannelName) {
+		return "The /kubewatch commands are not allowed in this channel"
+	}
+	if len(conf.AllowedUsers) > 0 {
+		if !allowed(conf.AllowedUsers, cmd.UserID, "") {
+			return "You are not allowed to run the /kubewatch commands"
+		}
+		return ""
+	}
+	if args := strings.Fields(cmd.Text); len(args) > 0 && args[0] != "status" {
+		return "Only the status command is allowed, the users allowed to run the others are not configured"
+	}
+	return ""
+}
+
+// allowed reports whether the ID or the name is in the list, the names of the
+// list optionally starting with # or @.
+func allowed(list []string, id, name string) bool {
+	for _, v := range list {
+		v = strings.TrimLeft(v, "#@")
+		if v == id || (name != "" && strings.EqualFold(v, name)) {
+			return true
+		}
+	}
+	return false
+}
+
+// userID matches the IDs of the Slack users, e.g. U012AB3CD.
+var userID = regexp.MustCompile(`^[UW][A-Z0-9]+$`)
+
+// IsUserID reports whether s is the ID of a Slack user rather than a name.
+func IsUserID(s string) bool {
+	return userID.MatchString(s)
+}
+
+// status reports the failed readiness checks and the active mute rules.
+func status() string {
+	var b strings.Builder
+	failures := health.Ready()
+	if len(failures) == 0 {
+		b.WriteString("kubewatch is ready\n")
+	} else {
+		names := make([]string, 0, len(failures))
+		for name := range failures {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		b.WriteString("kubewatch is not ready:\n")
+		for _, name := range names {
+			fmt.Fprintf(&b, "• %s: %v\n", name, failures[name])
+		}
+	}
+
+	rules := mute.Rules()
+	if len(rules) == 0 {
+		b.WriteString("No muted objects")
+	} else {
+		b.WriteString("Muted:")
+		for _, r := range rules {
+			fmt.Fprintf(&b, "\n• `%s` until %s", r.Target(), r.Until.UTC().Format(time.RFC3339))
+			if r.Source != "" {
+				fmt.Fprintf(&b, " by Mute %s", r.Source)
+				if r.Reason != "" {
+					fmt.Fprintf(&b, ": %s", r.Reason)
+				}
+			}
+		}
+	}
+	return b.String()
+}
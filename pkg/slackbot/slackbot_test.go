@@ -0,0 +1,85 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slackbot
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+	"github.com/bitnami-labs/kubewatch/pkg/mute"
+)
+
+func TestCommand(t *testing.T) {
+	now := time.Now()
+	e := event.Event{Kind: "deployment", Namespace: "default", Name: "foo"}
+
+	if got := Command("mute deployment/foo 1h", now); !strings.HasPrefix(got, "Muted `deployment/foo` until") {
+		t.Fatalf("mute: got %q", got)
+	}
+	if !mute.Muted(e) {
+		t.Fatal("mute: the event is not muted")
+	}
+	if got := Command("status", now); !strings.Contains(got, "`deployment/foo`") {
+		t.Fatalf("status: got %q", got)
+	}
+	if got := Command("unmute deployment/foo", now); got != "Unmuted `deployment/foo`" {
+		t.Fatalf("unmute: got %q", got)
+	}
+	if mute.Muted(e) {
+		t.Fatal("unmute: the event is still muted")
+	}
+
+	for _, text := range []string{"", "help", "mute deployment/foo", "mute deployment/foo soon", "mute foo 1h"} {
+		if got := Command(text, now); got == "" || strings.HasPrefix(got, "Muted") {
+			t.Fatalf("%q: got %q", text, got)
+		}
+	}
+}
+
+func TestRefusal(t *testing.T) {
+	ops := slack.SlashCommand{UserID: "U1", UserName: "alice", ChannelID: "C1", ChannelName: "ops", Text: "mute deployment/foo 1h"}
+	other := slack.SlashCommand{UserID: "U2", UserName: "bob", ChannelID: "C2", ChannelName: "general", Text: "mute deployment/foo 1h"}
+	status := slack.SlashCommand{UserID: "U2", UserName: "bob", ChannelID: "C2", ChannelName: "general", Text: "status"}
+
+	var Tests = []struct {
+		conf    config.Slackbot
+		cmd     slack.SlashCommand
+		allowed bool
+	}{
+		{config.Slackbot{}, ops, false},
+		{config.Slackbot{}, status, true},
+		{config.Slackbot{AllowedUsers: []string{"U1"}}, ops, true},
+		{config.Slackbot{AllowedUsers: []string{"@Alice"}}, ops, false},
+		{config.Slackbot{AllowedUsers: []string{"U3"}}, slack.SlashCommand{UserID: "U2", UserName: "U3", Text: "status"}, false},
+		{config.Slackbot{AllowedUsers: []string{"U1"}}, other, false},
+		{config.Slackbot{AllowedUsers: []string{"U1"}}, status, false},
+		{config.Slackbot{AllowedUsers: []string{"U1", "U2"}, AllowedChannels: []string{"#ops"}}, ops, true},
+		{config.Slackbot{AllowedUsers: []string{"U1", "U2"}, AllowedChannels: []string{"C1"}}, other, false},
+		{config.Slackbot{AllowedChannels: []string{"C1"}}, status, false},
+	}
+
+	for _, tt := range Tests {
+		if got := refusal(tt.conf, tt.cmd); (got == "") != tt.allowed {
+			t.Fatalf("refusal(%+v, %+v): got %q, want allowed %v", tt.conf, tt.cmd, got, tt.allowed)
+		}
+	}
+}
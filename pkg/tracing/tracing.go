@@ -0,0 +1,112 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package tracing traces the handling of the events with OpenTelemetry.
+package tracing
+
+import (
+	"context"
+	"os"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/bitnami-labs/kubewatch"
+
+// Tracer returns the tracer of kubewatch. It does nothing until Start is
+// called.
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Start exports the traces to the configured OTLP collector. The returned
+// function flushes and stops the export. Nothing is exported when no
+// collector is configured.
+func Start(conf config.Tracing) (func(), error) {
+	if conf.Endpoint == "" && os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") == "" {
+		return func() {}, nil
+	}
+
+	var opts []otlptracegrpc.Option
+	if conf.Endpoint != "" {
+		opts = append(opts, otlptracegrpc.WithEndpoint(conf.Endpoint))
+	}
+	if conf.Insecure {
+		opts = append(opts, otlptracegrpc.WithInsecure())
+	}
+	ctx := context.Background()
+	exporter, err := otlptracegrpc.New(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	// $OTEL_SERVICE_NAME and $OTEL_RESOURCE_ATTRIBUTES take precedence
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceNameKey.String("kubewatch")),
+		resource.WithFromEnv(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	ratio := conf.SampleRatio
+	if ratio <= 0 {
+		ratio = 1
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return func() {
+		provider.Shutdown(context.Background())
+	}, nil
+}
+
+// Attributes returns the attributes describing an event.
+func Attributes(e event.Event) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		attribute.String("kubewatch.kind", e.Kind),
+		attribute.String("kubewatch.name", e.Name),
+		attribute.String("kubewatch.reason", e.Reason),
+	}
+	if e.Namespace != "" {
+		attrs = append(attrs, attribute.String("kubewatch.namespace", e.Namespace))
+	}
+	if e.Cluster != "" {
+		attrs = append(attrs, attribute.String("kubewatch.cluster", e.Cluster))
+	}
+	if e.Severity != "" {
+		attrs = append(attrs, attribute.String("kubewatch.severity", e.Severity))
+	}
+	return attrs
+}
+
+// AddEvent records a step of the handling of the event, e.g. "muted", on its
+// span.
+func AddEvent(e event.Event, name string, attrs ...attribute.KeyValue) {
+	trace.SpanFromContext(e.Context()).AddEvent(name, trace.WithAttributes(attrs...))
+}
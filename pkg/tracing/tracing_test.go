@@ -0,0 +1,87 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/bitnami-labs/kubewatch/config"
+	"github.com/bitnami-labs/kubewatch/pkg/event"
+	"github.com/bitnami-labs/kubewatch/pkg/handlers"
+	"github.com/bitnami-labs/kubewatch/pkg/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// failingSender fails to deliver every event.
+type failingSender struct{}
+
+func (failingSender) Init(c *config.Config) error { return nil }
+func (failingSender) Handle(e event.Event)        {}
+func (failingSender) Send(e event.Event) error    { return fmt.Errorf("unavailable") }
+
+func TestTrace(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(provider)
+	defer otel.SetTracerProvider(sdktrace.NewTracerProvider())
+
+	h := handlers.Trace("webhook", failingSender{})
+	sender, ok := h.(handlers.Sender)
+	if !ok {
+		t.Fatal("Trace() of a sender is not a sender")
+	}
+	pipeline := &handlers.Severity{}
+	pipeline.Handler = h
+
+	e := event.Event{Kind: "pod", Name: "web", Namespace: "default", Reason: "Deleted", Status: "Danger"}
+	ctx, root := tracing.Tracer().Start(context.Background(), "kubewatch.event")
+	pipeline.Handle(e.WithContext(ctx))
+	if err := sender.Send(e.WithContext(ctx)); err == nil {
+		t.Fatal("Send() did not return the error")
+	}
+	root.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("%d spans ended, want 3", len(spans))
+	}
+	rootSpan := spans[2]
+	if events := rootSpan.Events(); len(events) != 1 || events[0].Name != "classified" {
+		t.Fatalf("root span events: %+v", events)
+	}
+	for _, s := range spans[:2] {
+		if s.Name() != "kubewatch.deliver" || s.Parent().SpanID() != rootSpan.SpanContext().SpanID() {
+			t.Fatalf("span %s with parent %s", s.Name(), s.Parent().SpanID())
+		}
+	}
+	if spans[1].Status().Code != codes.Error {
+		t.Fatalf("failed delivery status: %+v", spans[1].Status())
+	}
+}
+
+func TestStartDisabled(t *testing.T) {
+	stop, err := tracing.Start(config.Tracing{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stop()
+}
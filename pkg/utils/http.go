@@ -0,0 +1,61 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/bitnami-labs/kubewatch/config"
+)
+
+var (
+	transportMutex sync.RWMutex
+	transport      http.RoundTripper
+)
+
+// SetTransport makes the HTTP clients returned by HTTPClient send their
+// requests with the given round tripper, e.g. to capture them instead of
+// sending them, or with their own transports again when nil
+func SetTransport(rt http.RoundTripper) {
+	transportMutex.Lock()
+	defer transportMutex.Unlock()
+	transport = rt
+}
+
+// HTTPClient returns an HTTP client of the given handler type, verifying the
+// servers and authenticating with the given TLS settings when not empty, and
+// using the proxy of the handler type
+func HTTPClient(handler string, c config.TLS) (*http.Client, error) {
+	transportMutex.RLock()
+	rt := transport
+	transportMutex.RUnlock()
+	if rt != nil {
+		return &http.Client{Transport: rt}, nil
+	}
+
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.Proxy = Proxy(handler)
+	if TLSEnabled(c) {
+		tlsConfig, err := TLSConfig(c)
+		if err != nil {
+			return nil, err
+		}
+		t.TLSClientConfig = tlsConfig
+	}
+	return &http.Client{Transport: t}, nil
+}
@@ -5,12 +5,17 @@ import (
 
 	"github.com/sirupsen/logrus"
 	apps_v1 "k8s.io/api/apps/v1"
+	autoscaling_v1 "k8s.io/api/autoscaling/v1"
 	batch_v1 "k8s.io/api/batch/v1"
+	batch_v1beta1 "k8s.io/api/batch/v1beta1"
 	api_v1 "k8s.io/api/core/v1"
 	ext_v1beta1 "k8s.io/api/extensions/v1beta1"
+	networking_v1 "k8s.io/api/networking/v1"
 	rbac_v1beta1 "k8s.io/api/rbac/v1beta1"
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/metadata"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 )
@@ -53,6 +58,80 @@ func GetClientOutOfCluster() kubernetes.Interface {
 	return clientset
 }
 
+// GetKubeClient returns a k8s clientset, from inside of cluster when running in
+// a pod and from the kubeconfig otherwise
+func GetKubeClient() kubernetes.Interface {
+	if _, err := rest.InClusterConfig(); err != nil {
+		return GetClientOutOfCluster()
+	}
+	return GetClient()
+}
+
+// GetClusterClient returns a k8s clientset for the given context of a
+// kubeconfig, defaulting to $KUBECONFIG or ~/.kube/config and its current
+// context
+func GetClusterClient(kubeconfigPath, context string) (kubernetes.Interface, error) {
+	config, err := buildClusterConfig(kubeconfigPath, context)
+	if err != nil {
+		return nil, err
+	}
+	return kubernetes.NewForConfig(config)
+}
+
+// GetKubeMetadataClient returns a client of the metadata of the objects,
+// from inside of cluster when running in a pod and from the kubeconfig
+// otherwise
+func GetKubeMetadataClient() (metadata.Interface, error) {
+	config, err := rest.InClusterConfig()
+	if err != nil {
+		if config, err = buildOutOfClusterConfig(); err != nil {
+			return nil, err
+		}
+	}
+	return metadata.NewForConfig(config)
+}
+
+// GetClusterMetadataClient returns a client of the metadata of the objects
+// for the given context of a kubeconfig, like GetClusterClient
+func GetClusterMetadataClient(kubeconfigPath, context string) (metadata.Interface, error) {
+	config, err := buildClusterConfig(kubeconfigPath, context)
+	if err != nil {
+		return nil, err
+	}
+	return metadata.NewForConfig(config)
+}
+
+// GetKubeDynamicClient returns a client of the objects of any resource, like
+// GetKubeMetadataClient
+func GetKubeDynamicClient() (dynamic.Interface, error) {
+	config, err := rest.InClusterConfig()
+	if err != nil {
+		if config, err = buildOutOfClusterConfig(); err != nil {
+			return nil, err
+		}
+	}
+	return dynamic.NewForConfig(config)
+}
+
+// GetClusterDynamicClient returns a client of the objects of any resource for
+// the given context of a kubeconfig, like GetClusterClient
+func GetClusterDynamicClient(kubeconfigPath, context string) (dynamic.Interface, error) {
+	config, err := buildClusterConfig(kubeconfigPath, context)
+	if err != nil {
+		return nil, err
+	}
+	return dynamic.NewForConfig(config)
+}
+
+func buildClusterConfig(kubeconfigPath, context string) (*rest.Config, error) {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if kubeconfigPath != "" {
+		rules.ExplicitPath = kubeconfigPath
+	}
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: context}
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, overrides).ClientConfig()
+}
+
 // GetObjectMetaData returns metadata of a given k8s object
 func GetObjectMetaData(obj interface{}) (objectMeta meta_v1.ObjectMeta) {
 
@@ -73,6 +152,8 @@ func GetObjectMetaData(obj interface{}) (objectMeta meta_v1.ObjectMeta) {
 		objectMeta = object.ObjectMeta
 	case *api_v1.PersistentVolume:
 		objectMeta = object.ObjectMeta
+	case *api_v1.PersistentVolumeClaim:
+		objectMeta = object.ObjectMeta
 	case *api_v1.Namespace:
 		objectMeta = object.ObjectMeta
 	case *api_v1.Secret:
@@ -87,6 +168,18 @@ func GetObjectMetaData(obj interface{}) (objectMeta meta_v1.ObjectMeta) {
 		objectMeta = object.ObjectMeta
 	case *api_v1.Event:
 		objectMeta = object.ObjectMeta
+	case *api_v1.ConfigMap:
+		objectMeta = object.ObjectMeta
+	case *apps_v1.StatefulSet:
+		objectMeta = object.ObjectMeta
+	case *batch_v1beta1.CronJob:
+		objectMeta = object.ObjectMeta
+	case *autoscaling_v1.HorizontalPodAutoscaler:
+		objectMeta = object.ObjectMeta
+	case *networking_v1.NetworkPolicy:
+		objectMeta = object.ObjectMeta
+	case *meta_v1.PartialObjectMetadata:
+		objectMeta = object.ObjectMeta
 	}
 	return objectMeta
 }
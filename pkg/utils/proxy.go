@@ -0,0 +1,137 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/bitnami-labs/kubewatch/config"
+)
+
+// proxySettings are the parsed proxy settings
+type proxySettings struct {
+	url      *url.URL
+	noProxy  []string
+	networks []*net.IPNet
+	// handlers maps the handler types to their proxy, nil to not use any.
+	handlers map[string]*url.URL
+}
+
+var (
+	proxyMutex sync.RWMutex
+	proxy      proxySettings
+)
+
+// SetProxy sets the proxy of the HTTP clients returned by HTTPClient, and of
+// the default transport used by the other clients
+func SetProxy(c config.Proxy) error {
+	s := proxySettings{handlers: map[string]*url.URL{}}
+	var err error
+	if c.URL != "" {
+		if s.url, err = ParseProxyURL(c.URL); err != nil {
+			return err
+		}
+	}
+	for handler, u := range c.Handlers {
+		var proxyURL *url.URL
+		if u != "direct" {
+			if proxyURL, err = ParseProxyURL(u); err != nil {
+				return fmt.Errorf("%s: %v", handler, err)
+			}
+		}
+		s.handlers[strings.ToLower(handler)] = proxyURL
+	}
+	for _, host := range c.NoProxy {
+		host = strings.ToLower(strings.TrimSpace(host))
+		if _, network, err := net.ParseCIDR(host); err == nil {
+			s.networks = append(s.networks, network)
+		} else if host != "" {
+			s.noProxy = append(s.noProxy, host)
+		}
+	}
+
+	proxyMutex.Lock()
+	proxy = s
+	proxyMutex.Unlock()
+	http.DefaultTransport.(*http.Transport).Proxy = Proxy("")
+	return nil
+}
+
+// Proxy returns the function selecting the proxy of the requests of the given
+// handler type: its own proxy, the configured one or the one of the
+// environment, unless the host is excluded
+func Proxy(handler string) func(*http.Request) (*url.URL, error) {
+	handler = strings.ToLower(handler)
+	return func(req *http.Request) (*url.URL, error) {
+		proxyMutex.RLock()
+		s := proxy
+		proxyMutex.RUnlock()
+
+		if s.excluded(req.URL.Hostname()) {
+			return nil, nil
+		}
+		if u, ok := s.handlers[handler]; ok {
+			return u, nil
+		}
+		if s.url != nil {
+			return s.url, nil
+		}
+		return http.ProxyFromEnvironment(req)
+	}
+}
+
+// excluded returns whether the host is reached without the proxy
+func (s proxySettings) excluded(host string) bool {
+	host = strings.ToLower(host)
+	if ip := net.ParseIP(host); ip != nil {
+		for _, network := range s.networks {
+			if network.Contains(ip) {
+				return true
+			}
+		}
+	}
+	for _, pattern := range s.noProxy {
+		switch {
+		case pattern == "*", pattern == host:
+			return true
+		case strings.HasPrefix(pattern, "."):
+			if strings.HasSuffix(host, pattern) || host == pattern[1:] {
+				return true
+			}
+		case strings.HasSuffix(host, "."+pattern):
+			return true
+		}
+	}
+	return false
+}
+
+// ParseProxyURL parses the URL of a proxy, defaulting to the http scheme
+func ParseProxyURL(s string) (*url.URL, error) {
+	if !strings.Contains(s, "://") {
+		s = "http://" + s
+	}
+	u, err := url.Parse(s)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid proxy URL %q", s)
+	}
+	return u, nil
+}
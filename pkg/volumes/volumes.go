@@ -0,0 +1,185 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package volumes describes the persistent volumes and claims: their
+// binding, storage class and capacity, the changes of their phase and the
+// claims pending for too long.
+package volumes
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	api_v1 "k8s.io/api/core/v1"
+)
+
+// ClaimPending is the reason of the claims still pending after the
+// threshold. The changes of phase are notified as the kind followed by the
+// new phase, e.g. ClaimBound or VolumeReleased.
+const ClaimPending = "ClaimPending"
+
+// Change is a change of the phase of a volume or claim.
+type Change struct {
+	Reason string
+	// Status is the status of the event notifying the change: Normal
+	// once bound or available, Danger when failed or lost, Warning
+	// otherwise.
+	Status string
+	// Description describes the change in a sentence.
+	Description string
+}
+
+// Detect returns the change of the phase of the volume or claim between two
+// of its versions, false when its phase did not change or the objects are
+// not volumes or claims.
+func Detect(old, new interface{}) (Change, bool) {
+	var kind, from, to string
+	switch n := new.(type) {
+	case *api_v1.PersistentVolume:
+		o, ok := old.(*api_v1.PersistentVolume)
+		if !ok {
+			return Change{}, false
+		}
+		kind, from, to = "Volume", string(o.Status.Phase), string(n.Status.Phase)
+	case *api_v1.PersistentVolumeClaim:
+		o, ok := old.(*api_v1.PersistentVolumeClaim)
+		if !ok {
+			return Change{}, false
+		}
+		kind, from, to = "Claim", string(o.Status.Phase), string(n.Status.Phase)
+	default:
+		return Change{}, false
+	}
+	if from == to || to == "" {
+		return Change{}, false
+	}
+	if from == "" {
+		from = "none"
+	}
+	return Change{
+		Reason:      kind + to,
+		Status:      phaseStatus(to),
+		Description: fmt.Sprintf("%s phase changed from %s to %s", kind, from, to),
+	}, true
+}
+
+// phaseStatus returns the status of the event notifying the phase.
+func phaseStatus(phase string) string {
+	switch phase {
+	case string(api_v1.VolumeBound), string(api_v1.VolumeAvailable):
+		return "Normal"
+	case string(api_v1.VolumeFailed), string(api_v1.ClaimLost):
+		return "Danger"
+	}
+	return "Warning"
+}
+
+// Describe describes the volume or claim, one detail per line: its phase
+// and binding, its storage class, its capacity and its access modes. It
+// returns an empty string for the other objects.
+func Describe(obj interface{}) string {
+	var b strings.Builder
+	switch o := obj.(type) {
+	case *api_v1.PersistentVolume:
+		fmt.Fprintf(&b, "Phase: %s", phase(string(o.Status.Phase)))
+		if ref := o.Spec.ClaimRef; ref != nil {
+			fmt.Fprintf(&b, "\nClaim: %s/%s", ref.Namespace, ref.Name)
+		}
+		fmt.Fprintf(&b, "\nStorage class: %s", storageClass(o.Spec.StorageClassName))
+		if q, ok := o.Spec.Capacity[api_v1.ResourceStorage]; ok {
+			fmt.Fprintf(&b, "\nCapacity: %s", q.String())
+		}
+		if o.Spec.PersistentVolumeReclaimPolicy != "" {
+			fmt.Fprintf(&b, "\nReclaim policy: %s", o.Spec.PersistentVolumeReclaimPolicy)
+		}
+		writeAccessModes(&b, o.Spec.AccessModes)
+	case *api_v1.PersistentVolumeClaim:
+		fmt.Fprintf(&b, "Phase: %s", phase(string(o.Status.Phase)))
+		if o.Spec.VolumeName != "" {
+			fmt.Fprintf(&b, "\nVolume: %s", o.Spec.VolumeName)
+		}
+		class := ""
+		if o.Spec.StorageClassName != nil {
+			class = *o.Spec.StorageClassName
+		}
+		fmt.Fprintf(&b, "\nStorage class: %s", storageClass(class))
+		if q, ok := o.Spec.Resources.Requests[api_v1.ResourceStorage]; ok {
+			fmt.Fprintf(&b, "\nRequested: %s", q.String())
+		}
+		if q, ok := o.Status.Capacity[api_v1.ResourceStorage]; ok {
+			fmt.Fprintf(&b, "\nProvisioned: %s", q.String())
+		}
+		writeAccessModes(&b, o.Spec.AccessModes)
+	}
+	return b.String()
+}
+
+// Pending returns how long the claim has been pending, false when it is
+// not pending or has been for less than after.
+func Pending(pvc *api_v1.PersistentVolumeClaim, after time.Duration, now time.Time) (time.Duration, bool) {
+	if pvc.Status.Phase != api_v1.ClaimPending && pvc.Status.Phase != "" {
+		return 0, false
+	}
+	if pvc.DeletionTimestamp != nil {
+		return 0, false
+	}
+	d := now.Sub(pvc.CreationTimestamp.Time)
+	return d, d >= after
+}
+
+// PendingDescription describes the claim pending for d in a sentence, e.g.
+// "Claim pending for 15m0s, requesting 10Gi of storage class fast".
+func PendingDescription(pvc *api_v1.PersistentVolumeClaim, d time.Duration) string {
+	msg := fmt.Sprintf("Claim pending for %s", d.Round(time.Second))
+	if q, ok := pvc.Spec.Resources.Requests[api_v1.ResourceStorage]; ok {
+		msg += ", requesting " + q.String()
+	}
+	if pvc.Spec.StorageClassName != nil && *pvc.Spec.StorageClassName != "" {
+		msg += " of storage class " + *pvc.Spec.StorageClassName
+	}
+	return msg
+}
+
+// phase returns the phase, Pending when not set yet.
+func phase(p string) string {
+	if p == "" {
+		return string(api_v1.ClaimPending)
+	}
+	return p
+}
+
+// storageClass returns the name of the storage class, or "none" for the
+// volumes and claims without one.
+func storageClass(name string) string {
+	if name == "" {
+		return "none"
+	}
+	return name
+}
+
+// writeAccessModes writes the access modes, e.g. ReadWriteOnce, on their
+// own line.
+func writeAccessModes(b *strings.Builder, modes []api_v1.PersistentVolumeAccessMode) {
+	if len(modes) == 0 {
+		return
+	}
+	names := make([]string, len(modes))
+	for i, m := range modes {
+		names[i] = string(m)
+	}
+	fmt.Fprintf(b, "\nAccess modes: %s", strings.Join(names, ", "))
+}
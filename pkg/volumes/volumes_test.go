@@ -0,0 +1,137 @@
+/*
+Copyright 2020 VMware
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package volumes
+
+import (
+	"testing"
+	"time"
+
+	api_v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func claim(phase api_v1.PersistentVolumeClaimPhase, class string, requested, provisioned string) *api_v1.PersistentVolumeClaim {
+	pvc := &api_v1.PersistentVolumeClaim{
+		ObjectMeta: meta_v1.ObjectMeta{Namespace: "shop", Name: "data"},
+		Spec: api_v1.PersistentVolumeClaimSpec{
+			AccessModes: []api_v1.PersistentVolumeAccessMode{api_v1.ReadWriteOnce},
+			Resources: api_v1.ResourceRequirements{
+				Requests: api_v1.ResourceList{api_v1.ResourceStorage: resource.MustParse(requested)},
+			},
+		},
+		Status: api_v1.PersistentVolumeClaimStatus{Phase: phase},
+	}
+	if class != "" {
+		pvc.Spec.StorageClassName = &class
+	}
+	if provisioned != "" {
+		pvc.Spec.VolumeName = "pvc-123"
+		pvc.Status.Capacity = api_v1.ResourceList{api_v1.ResourceStorage: resource.MustParse(provisioned)}
+	}
+	return pvc
+}
+
+func volume(phase api_v1.PersistentVolumePhase) *api_v1.PersistentVolume {
+	return &api_v1.PersistentVolume{
+		Spec: api_v1.PersistentVolumeSpec{
+			Capacity:                      api_v1.ResourceList{api_v1.ResourceStorage: resource.MustParse("20Gi")},
+			ClaimRef:                      &api_v1.ObjectReference{Namespace: "shop", Name: "data"},
+			StorageClassName:              "fast",
+			PersistentVolumeReclaimPolicy: api_v1.PersistentVolumeReclaimDelete,
+			AccessModes:                   []api_v1.PersistentVolumeAccessMode{api_v1.ReadWriteOnce, api_v1.ReadOnlyMany},
+		},
+		Status: api_v1.PersistentVolumeStatus{Phase: phase},
+	}
+}
+
+func TestDetect(t *testing.T) {
+	var Tests = []struct {
+		old, new interface{}
+		want     Change
+		changed  bool
+	}{
+		{claim(api_v1.ClaimPending, "fast", "10Gi", ""), claim(api_v1.ClaimPending, "fast", "10Gi", ""), Change{}, false},
+		{claim(api_v1.ClaimPending, "fast", "10Gi", ""), claim(api_v1.ClaimBound, "fast", "10Gi", "20Gi"),
+			Change{Reason: "ClaimBound", Status: "Normal", Description: "Claim phase changed from Pending to Bound"}, true},
+		{claim(api_v1.ClaimBound, "fast", "10Gi", "20Gi"), claim(api_v1.ClaimLost, "fast", "10Gi", "20Gi"),
+			Change{Reason: "ClaimLost", Status: "Danger", Description: "Claim phase changed from Bound to Lost"}, true},
+		{volume(api_v1.VolumeBound), volume(api_v1.VolumeReleased),
+			Change{Reason: "VolumeReleased", Status: "Warning", Description: "Volume phase changed from Bound to Released"}, true},
+		{volume(""), volume(api_v1.VolumeAvailable),
+			Change{Reason: "VolumeAvailable", Status: "Normal", Description: "Volume phase changed from none to Available"}, true},
+		{volume(api_v1.VolumeReleased), volume(api_v1.VolumeFailed),
+			Change{Reason: "VolumeFailed", Status: "Danger", Description: "Volume phase changed from Released to Failed"}, true},
+		{volume(api_v1.VolumeBound), claim(api_v1.ClaimBound, "", "1Gi", ""), Change{}, false},
+		{&api_v1.Pod{}, &api_v1.Pod{}, Change{}, false},
+	}
+	for _, tt := range Tests {
+		got, changed := Detect(tt.old, tt.new)
+		if got != tt.want || changed != tt.changed {
+			t.Fatalf("Detect(): got %+v, %v, want %+v, %v", got, changed, tt.want, tt.changed)
+		}
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	var Tests = []struct {
+		obj  interface{}
+		want string
+	}{
+		{claim(api_v1.ClaimBound, "fast", "10Gi", "20Gi"),
+			"Phase: Bound\nVolume: pvc-123\nStorage class: fast\nRequested: 10Gi\nProvisioned: 20Gi\nAccess modes: ReadWriteOnce"},
+		{claim("", "", "1Gi", ""),
+			"Phase: Pending\nStorage class: none\nRequested: 1Gi\nAccess modes: ReadWriteOnce"},
+		{volume(api_v1.VolumeBound),
+			"Phase: Bound\nClaim: shop/data\nStorage class: fast\nCapacity: 20Gi\nReclaim policy: Delete\nAccess modes: ReadWriteOnce, ReadOnlyMany"},
+		{&api_v1.Pod{}, ""},
+	}
+	for _, tt := range Tests {
+		if got := Describe(tt.obj); got != tt.want {
+			t.Fatalf("Describe(): got %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestPending(t *testing.T) {
+	now := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	created := meta_v1.NewTime(now.Add(-15 * time.Minute))
+
+	pending := claim(api_v1.ClaimPending, "fast", "10Gi", "")
+	pending.CreationTimestamp = created
+	if d, ok := Pending(pending, 10*time.Minute, now); !ok || d != 15*time.Minute {
+		t.Fatalf("Pending(): got %s, %v, want 15m0s, true", d, ok)
+	}
+	if _, ok := Pending(pending, 20*time.Minute, now); ok {
+		t.Fatalf("Pending(): claim pending for less than the threshold reported")
+	}
+	if got, want := PendingDescription(pending, 15*time.Minute), "Claim pending for 15m0s, requesting 10Gi of storage class fast"; got != want {
+		t.Fatalf("PendingDescription(): got %q, want %q", got, want)
+	}
+
+	bound := claim(api_v1.ClaimBound, "fast", "10Gi", "10Gi")
+	bound.CreationTimestamp = created
+	if _, ok := Pending(bound, 10*time.Minute, now); ok {
+		t.Fatalf("Pending(): bound claim reported")
+	}
+
+	deleted := pending.DeepCopy()
+	deleted.DeletionTimestamp = &created
+	if _, ok := Pending(deleted, 10*time.Minute, now); ok {
+		t.Fatalf("Pending(): deleted claim reported")
+	}
+}
@@ -88,6 +88,9 @@ func emit(w io.Writer, types map[string]*ast.StructType, node *ast.StructType) e
 		if err != nil {
 			return err
 		}
+		if name == "-" {
+			continue
+		}
 		if field.Doc != nil {
 			lines := strings.Split(strings.TrimSpace(field.Doc.Text()), "\n")
 			for _, l := range lines {
@@ -102,8 +105,12 @@ func emit(w io.Writer, types map[string]*ast.StructType, node *ast.StructType) e
 
 			switch name := typ.Name; name {
 			case "string":
-				fmt.Fprintln(w, ` ""`)
-			case "int":
+				if v := sampleValue(field); v != "" {
+					fmt.Fprintf(w, " %s\n", v)
+				} else {
+					fmt.Fprintln(w, ` ""`)
+				}
+			case "int", "int64", "float64":
 				fmt.Fprintln(w, " 0")
 			case "bool":
 				fmt.Fprintln(w, " false")
@@ -120,6 +127,8 @@ func emit(w io.Writer, types map[string]*ast.StructType, node *ast.StructType) e
 			}
 		case *ast.MapType:
 			fmt.Fprintf(w, " {}\n")
+		case *ast.ArrayType:
+			fmt.Fprintf(w, " []\n")
 		default:
 			return fmt.Errorf("unsupported field type: %T (%s)", field.Type, field.Type)
 		}
@@ -159,6 +168,23 @@ func fieldName(field *ast.Field) (string, error) {
 	return strings.ToLower(field.Names[0].Name), nil
 }
 
+// sampleValue returns the value of the sample tag of the field, printed
+// instead of the zero value.
+func sampleValue(field *ast.Field) string {
+	if field.Tag == nil {
+		return ""
+	}
+	tags, err := structtag.Parse(field.Tag.Value[1 : len(field.Tag.Value)-1])
+	if err != nil {
+		return ""
+	}
+	tag, err := tags.Get("sample")
+	if err != nil {
+		return ""
+	}
+	return tag.Name
+}
+
 func collectTypes(n ast.Node) map[string]*ast.StructType {
 	v := typeCollectingVisitor(map[string]*ast.StructType{})
 	ast.Walk(v, n)
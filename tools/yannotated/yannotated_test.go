@@ -10,12 +10,15 @@ import (
 type Config struct {
 	// Foo is foo.
 	Foo string `yaml:"foo"`
+	// Version is a version.
+	Version string `yaml:"version" sample:"v1"`
 	// Bar is bar.
 	// So useful.
 	Bar Bar `yaml:"bar"`
 	// Rebar is another bar.
 	Rebar Bar `yaml:"rebar"`
 	Quz   map[string]string
+	Quux  []Bar
 }
 
 // Bar is a struct.
@@ -44,6 +47,8 @@ func TestMain(t *testing.T) {
 
 	want := `# Foo is foo.
 foo: ""
+# Version is a version.
+version: v1
 # Bar is bar.
 # So useful.
 bar:
@@ -54,6 +59,7 @@ rebar:
   # Baz is baz.
   baz: 0
 quz: {}
+quux: []
 `
 	b, err := ioutil.ReadFile(tmp.Name())
 	if err != nil {